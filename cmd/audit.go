@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kkrt-labs/zk-pig/src"
+	"github.com/spf13/cobra"
+)
+
+// NewAuditCommand creates and returns the audit command
+func NewAuditCommand(rootCtx *RootContext) *cobra.Command {
+	var (
+		ctx         = &ProverInputContext{RootContext: *rootCtx}
+		blockNumber = "latest" // Unused, blocks are given by --block-range
+		blockRange  string
+		reportFile  string
+		opts        src.AuditOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:     "audit",
+		Short:   "Check stored prover inputs of a range of blocks against the canonical chain of a live node",
+		Long:    "Load the stored prover inputs of a range of blocks and compare the hash of their block with the canonical block hash at the same height on the remote node, flagging the stale prover inputs generated before a reorg. It runs online and requires --chain-rpc-url to be set to a remote JSON-RPC Ethereum Execution Layer node. The store is not modified unless --fix is set, in which case stale blocks are regenerated.",
+		PreRunE: preRun(ctx, &blockNumber),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			from, to, err := parseBlockRange(blockRange)
+			if err != nil {
+				return err
+			}
+
+			report, err := ctx.svc.Audit(cmd.Context(), from, to, &opts)
+			if report != nil {
+				if reportFile == "" {
+					reportFile = filepath.Join(ctx.Config.DataDir, "audit-report.json")
+				}
+				if writeErr := report.WriteFile(reportFile); writeErr != nil {
+					return fmt.Errorf("failed to write audit report: %v", writeErr)
+				}
+				fmt.Fprint(cmd.OutOrStdout(), report.String())
+			}
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+
+			return err
+		},
+		PostRunE: func(cmd *cobra.Command, _ []string) error {
+			return ctx.svc.Stop(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&blockRange, "block-range", "", "Inclusive range of blocks to audit (e.g. 100-200)")
+	cmd.Flags().BoolVar(&opts.Fix, "fix", false, "Regenerate the prover inputs of stale blocks")
+	cmd.Flags().StringVar(&reportFile, "report-file", "", "Path where to write the JSON audit report (defaults to <data-dir>/audit-report.json)")
+	_ = cmd.MarkFlagRequired("block-range")
+
+	return cmd
+}
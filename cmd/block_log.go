@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// blockNumberKey is the key of the block number tag set on the loggers of the pipeline of a block
+const blockNumberKey = "block.number"
+
+// maxOpenBlockLogs is the maximum number of block log files kept open at the same time, the least recently written
+// ones are closed and reopened on their next entry
+const maxOpenBlockLogs = 16
+
+// BlockLogs writes the pipeline logs of every block to its own file <dir>/<block>.log (see --log-per-block-dir)
+//
+// The loggers of the pipeline of a block are the ones carrying its block number tag, so run level entries logging a
+// block number as a field of the entry (e.g. the failures of a range) stay in the main log only.
+type BlockLogs struct {
+	dir     string
+	only    bool // If true, the entries of the block loggers are not written to the main log
+	encoder zapcore.Encoder
+
+	mu    sync.Mutex
+	files map[string]*list.Element // Open files by block number, in lru
+	lru   *list.List               // Open *blockLogFile, most recently written first
+}
+
+type blockLogFile struct {
+	block string
+	file  *os.File
+}
+
+// NewBlockLogs creates the directory of the block logs, encoded in format
+func NewBlockLogs(dir string, format log.Format, only bool) (*BlockLogs, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create block logs directory: %v", err)
+	}
+
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	if format == log.TextFormat {
+		encoder = zapcore.NewConsoleEncoder(zap.NewProductionEncoderConfig())
+	}
+	return &BlockLogs{
+		dir:     dir,
+		only:    only,
+		encoder: encoder,
+		files:   make(map[string]*list.Element),
+		lru:     list.New(),
+	}, nil
+}
+
+// Wrap wraps the core of the main log to route the entries of the block loggers to their file
+func (b *BlockLogs) Wrap(core zapcore.Core) zapcore.Core {
+	return &blockLogCore{Core: core, logs: b}
+}
+
+// Close closes the open block log files
+func (b *BlockLogs) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var err error
+	for e := b.lru.Front(); e != nil; e = e.Next() {
+		err = errors.Join(err, e.Value.(*blockLogFile).file.Close())
+	}
+	b.files = make(map[string]*list.Element)
+	b.lru.Init()
+	return err
+}
+
+// write appends p to the log file of block, opening it if needed
+func (b *BlockLogs) write(block string, p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e, ok := b.files[block]
+	if ok {
+		b.lru.MoveToFront(e)
+	} else {
+		file, err := os.OpenFile(filepath.Join(b.dir, block+".log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return 0, err
+		}
+		e = b.lru.PushFront(&blockLogFile{block: block, file: file})
+		b.files[block] = e
+		if b.lru.Len() > maxOpenBlockLogs {
+			oldest := b.lru.Remove(b.lru.Back()).(*blockLogFile)
+			delete(b.files, oldest.block)
+			oldest.file.Close()
+		}
+	}
+	return e.Value.(*blockLogFile).file.Write(p)
+}
+
+// blockLogWriter is the zapcore.WriteSyncer of the log file of a block
+type blockLogWriter struct {
+	logs  *BlockLogs
+	block string
+}
+
+func (w *blockLogWriter) Write(p []byte) (int, error) {
+	return w.logs.write(w.block, p)
+}
+
+func (w *blockLogWriter) Sync() error {
+	return nil // Files are written without buffering
+}
+
+// blockLogCore is a zapcore.Core writing to the main log, and, once a block number tag is set, to the log file of the block
+type blockLogCore struct {
+	zapcore.Core
+	logs   *BlockLogs
+	fields []zapcore.Field // Fields of the logger, applied to the block core once created
+	block  zapcore.Core    // Core of the log file of the block, nil if the logger has no block number tag
+}
+
+func (c *blockLogCore) With(fields []zapcore.Field) zapcore.Core {
+	child := &blockLogCore{Core: c.Core.With(fields), logs: c.logs, fields: append(c.fields[:len(c.fields):len(c.fields)], fields...)}
+	if block, ok := blockNumber(fields); ok {
+		child.block = zapcore.NewCore(c.logs.encoder, &blockLogWriter{logs: c.logs, block: block}, zapcore.DebugLevel).With(child.fields)
+	} else if c.block != nil {
+		child.block = c.block.With(fields)
+	}
+	return child
+}
+
+func (c *blockLogCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.block == nil {
+		return c.Core.Check(entry, ce)
+	}
+	ce = c.block.Check(entry, ce)
+	if !c.logs.only {
+		ce = c.Core.Check(entry, ce)
+	}
+	return ce
+}
+
+func (c *blockLogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if c.block == nil {
+		return c.Core.Write(entry, fields)
+	}
+	err := c.block.Write(entry, fields)
+	if !c.logs.only {
+		err = errors.Join(err, c.Core.Write(entry, fields))
+	}
+	return err
+}
+
+// blockNumber returns the block number of the block number tag among fields, if any
+func blockNumber(fields []zapcore.Field) (string, bool) {
+	for _, field := range fields {
+		if field.Key != blockNumberKey {
+			continue
+		}
+		switch field.Type {
+		case zapcore.Int64Type, zapcore.Int32Type:
+			return strconv.FormatInt(field.Integer, 10), true
+		case zapcore.Uint64Type, zapcore.Uint32Type:
+			return strconv.FormatUint(uint64(field.Integer), 10), true
+		case zapcore.StringType:
+			if _, err := strconv.ParseUint(field.String, 10, 64); err == nil {
+				return field.String, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kkrt-labs/zk-pig/src"
+	"github.com/spf13/cobra"
+)
+
+// NewCheckProofCommand creates and returns the check-proof command
+func NewCheckProofCommand(_ *RootContext) *cobra.Command {
+	var (
+		inputPath string
+		proofPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check-proof",
+		Short: "Check the public inputs of a proof against the prover input it was generated from",
+		Long:  "Compare the public inputs baked into a proof (chain ID, block number, block hash, pre and post state roots) with the values derived from the prover input file it was generated from, and report mismatches. It runs off-line and does not verify the proof itself. The public inputs file is a JSON object with optional chainId, blockNumber, blockHash, preStateRoot and postStateRoot fields, only the set fields are checked.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			check, err := src.CheckProof(inputPath, proofPath)
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			if check.OK() {
+				fmt.Fprintf(w, "%s[✓]%s %d public inputs of the proof match the prover input of block %d\n", colorGreen, colorReset, check.Checked, check.BlockNumber)
+				return nil
+			}
+
+			for _, m := range check.Mismatches {
+				fmt.Fprintf(w, "%s[✗]%s %s: proof %s, prover input %s\n", colorRed, colorReset, m.Field, m.Proof, m.Input)
+			}
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d/%d public inputs of the proof do not match the prover input of block %d", len(check.Mismatches), check.Checked, check.BlockNumber)
+		},
+	}
+
+	cmd.Flags().StringVar(&inputPath, "input", "", "Path of the prover input file (JSON or protobuf, optionally gzip compressed)")
+	cmd.Flags().StringVar(&proofPath, "proof", "", "Path of the JSON file of the public inputs of the proof")
+	_ = cmd.MarkFlagRequired("input")
+	_ = cmd.MarkFlagRequired("proof")
+
+	return cmd
+}
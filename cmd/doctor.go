@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kkrt-labs/zk-pig/src"
+	"github.com/spf13/cobra"
+)
+
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorReset = "\033[0m"
+)
+
+// NewDoctorCommand creates and returns the doctor command
+func NewDoctorCommand(rootCtx *RootContext) *cobra.Command {
+	var (
+		ctx = &ProverInputContext{RootContext: *rootCtx}
+	)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose common misconfigurations",
+		Long:  "Run a battery of checks on the configuration (chain data source, chain ID, archive state, stores permissions, disk space, clock) and print remediation hints for failing checks",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			results := []*src.CheckResult{
+				{Name: "S3 configuration complete", Err: validateS3Config(ctx), Hint: "Set --inputs-aws-s3-bucket and --inputs-aws-s3-region, and either both --inputs-aws-s3-access-key and --inputs-aws-s3-secret-key or none of them to use the default AWS credential chain"},
+			}
+
+			svc, err := newDoctorService(ctx)
+			results = append(results, &src.CheckResult{Name: "Configuration valid", Err: err, Hint: "Fix the configuration (run zkpig config to inspect it)"})
+			if svc != nil {
+				results = append(results, svc.Doctor(cmd.Context())...)
+			}
+
+			failed := printCheckResults(cmd.OutOrStdout(), results)
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d/%d checks failed", failed, len(results))
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newDoctorService(ctx *ProverInputContext) (*src.Service, error) {
+	cfg, err := prepareConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return src.New(cfg)
+}
+
+// printCheckResults prints a checklist of the results and returns the number of failed checks
+func printCheckResults(w io.Writer, results []*src.CheckResult) (failed int) {
+	for _, res := range results {
+		if res.OK() {
+			fmt.Fprintf(w, "%s[✓]%s %s\n", colorGreen, colorReset, res.Name)
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(w, "%s[✗]%s %s: %v\n", colorRed, colorReset, res.Name, res.Err)
+		if res.Hint != "" {
+			fmt.Fprintf(w, "    → %s\n", res.Hint)
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDoctorTestNode starts a JSON-RPC server of a non-archive node on chain 1 whose head block is an hour old
+func newDoctorTestNode(t *testing.T) *httptest.Server {
+	head := &gethtypes.Header{Number: big.NewInt(2000), Time: uint64(time.Now().Add(-time.Hour).Unix()), Difficulty: big.NewInt(0)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg jsonrpc.RequestMsg
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID}
+		switch msg.Method {
+		case "eth_chainId":
+			resp["result"] = "0x1"
+		case "eth_getBlockByNumber":
+			resp["result"] = head
+		default:
+			resp["error"] = map[string]interface{}{"code": -32000, "message": "required historical state unavailable"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDoctorFailingChecks(t *testing.T) {
+	srv := newDoctorTestNode(t)
+
+	var out bytes.Buffer
+	command := NewZkPigCommand()
+	command.SetOut(&out)
+	command.SetErr(&out)
+	command.SetArgs([]string{"doctor", "--chain-id", "2", "--chain-rpc-url", srv.URL, "--data-dir", t.TempDir()})
+
+	err := command.Execute()
+	require.Error(t, err, "failing checks make the command exit with a non-zero code")
+	assert.Regexp(t, `^3/\d+ checks failed$`, err.Error())
+
+	assert.Contains(t, out.String(), "[✗]"+colorReset+" Chain ID matches: configured chain ID 2 but node is on chain 1")
+	assert.Contains(t, out.String(), "[✗]"+colorReset+" Archive state available: state at block 976 (head - 1024) is not available")
+	assert.Contains(t, out.String(), "[✗]"+colorReset+" Clock in sync with chain head: head block 2000 is 1h0m")
+}
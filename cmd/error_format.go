@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/kkrt-labs/zk-pig/src"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const errorFormatFlag = "error-format"
+
+// ErrorFormat is the format in which the error of a failed command is written to stderr
+type ErrorFormat string
+
+const (
+	ErrorFormatText ErrorFormat = "text" // Human-readable "Error: <message>" line
+	ErrorFormatJSON ErrorFormat = "json" // Single JSON object (see src.ErrorReport)
+)
+
+// ParseErrorFormat parses an error format, an empty string defaults to ErrorFormatText
+func ParseErrorFormat(s string) (ErrorFormat, error) {
+	switch ErrorFormat(s) {
+	case "", ErrorFormatText:
+		return ErrorFormatText, nil
+	case ErrorFormatJSON:
+		return ErrorFormatJSON, nil
+	}
+	return "", fmt.Errorf("invalid error format %q (expected one of %q)", s, []ErrorFormat{ErrorFormatText, ErrorFormatJSON})
+}
+
+func addErrorFormatFlag(f *pflag.FlagSet) {
+	f.String(errorFormatFlag, string(ErrorFormatText), fmt.Sprintf("Format of the error written to stderr when a command fails (one of %q), json writes a single object with the error type, message, block number, phase and mismatching roots", []ErrorFormat{ErrorFormatText, ErrorFormatJSON}))
+}
+
+// errorFormat returns the error format set on the command line of root, defaulting to ErrorFormatText if invalid
+func errorFormat(root *cobra.Command) ErrorFormat {
+	flag := root.PersistentFlags().Lookup(errorFormatFlag)
+	if flag == nil {
+		return ErrorFormatText
+	}
+	format, err := ParseErrorFormat(flag.Value.String())
+	if err != nil {
+		return ErrorFormatText
+	}
+	return format
+}
+
+// silenceErrors stops cobra from writing errors and usage to stderr, so it only holds the error written by WriteError
+func silenceErrors(root *cobra.Command) {
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+}
+
+// WriteError writes the error of a failed command to w as JSON if --error-format json is set on root,
+// errors are otherwise written by cobra as text
+func WriteError(root *cobra.Command, w io.Writer, err error) {
+	if !root.SilenceErrors {
+		return // Already written by cobra
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if encErr := enc.Encode(src.NewErrorReport(err)); encErr != nil {
+		fmt.Fprintln(w, root.ErrPrefix(), err.Error())
+	}
+}
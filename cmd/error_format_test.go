@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/kkrt-labs/zk-pig/src"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteErrorJSON(t *testing.T) {
+	t.Run("plain error", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		command := NewZkPigCommand()
+		command.SetOut(&stdout)
+		command.SetErr(&stderr)
+		command.SetArgs([]string{"generate", "--error-format", "json", "--block-number", "2", "--data-dir", t.TempDir()})
+
+		err := command.Execute()
+		require.Error(t, err)
+		WriteError(command, &stderr, err)
+
+		// stderr only holds the JSON object, without the text error and usage of cobra
+		assert.JSONEq(t, `{"type":"unknown","message":"failed to start prover inputs service: no chain configuration provided"}`, stderr.String())
+	})
+
+	t.Run("block error", func(t *testing.T) {
+		command := NewZkPigCommand()
+		silenceErrors(command)
+
+		hash := gethcommon.HexToHash("0xb44fb4e949d0f78f87f79ee46428f23a2a5713ce6fc6e0beb3dda78c2ac1ea55")
+		err := fmt.Errorf("failed to generate prover inputs: %w", &src.BlockError{
+			Type:        src.ErrorTypeValidation,
+			Phase:       src.PhaseExecute,
+			BlockNumber: big.NewInt(21465322),
+			BlockHash:   &hash,
+			Err:         fmt.Errorf("invalid merkle root (remote: 0xaa local: 0xbb)"),
+		})
+
+		var stderr bytes.Buffer
+		WriteError(command, &stderr, err)
+
+		var report map[string]interface{}
+		require.NoError(t, json.Unmarshal(stderr.Bytes(), &report))
+		assert.Equal(t, map[string]interface{}{
+			"type":        "validation",
+			"message":     "failed to generate prover inputs: invalid merkle root (remote: 0xaa local: 0xbb)",
+			"phase":       "execute",
+			"blockNumber": float64(21465322),
+			"blockHash":   hash.Hex(),
+			"roots":       map[string]interface{}{"stateRoot": map[string]interface{}{"expected": "0xaa", "computed": "0xbb"}},
+		}, report)
+	})
+
+	t.Run("text format", func(t *testing.T) {
+		var stderr bytes.Buffer
+		WriteError(NewZkPigCommand(), &stderr, fmt.Errorf("failed"))
+		assert.Empty(t, stderr.String(), "text errors are written by cobra")
+	})
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kkrt-labs/zk-pig/src"
+	"github.com/spf13/cobra"
+)
+
+// NewEstimateCommand creates and returns the estimate command
+func NewEstimateCommand(rootCtx *RootContext) *cobra.Command {
+	var (
+		ctx         = &ProverInputContext{RootContext: *rootCtx}
+		blockNumber = "latest" // Unused, blocks are given by --block-range
+		blockRange  string
+		output      string
+		opts        src.EstimateOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:     "estimate",
+		Short:   "Estimate the JSON-RPC calls needed to generate prover inputs for a range of blocks",
+		Long:    "Generate prover inputs for a few blocks sampled over a range, measuring the JSON-RPC calls (including retries) of every phase, and extrapolate the number of calls and, with --price-per-call, the cost of generating the full range. It runs online and requires --chain-rpc-url to be set to a remote JSON-RPC Ethereum Execution Layer node",
+		PreRunE: preRun(ctx, &blockNumber),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			from, to, err := parseBlockRange(blockRange)
+			if err != nil {
+				return err
+			}
+
+			estimate, err := ctx.svc.EstimateRPC(cmd.Context(), from, to, &opts)
+			if err != nil {
+				return err
+			}
+
+			if output != "" {
+				if err := estimate.WriteFile(output); err != nil {
+					return fmt.Errorf("failed to write RPC estimate: %v", err)
+				}
+			}
+			fmt.Fprint(cmd.OutOrStdout(), estimate.String())
+
+			return nil
+		},
+		PostRunE: func(cmd *cobra.Command, _ []string) error {
+			return ctx.svc.Stop(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&blockRange, "block-range", "", "Inclusive range of blocks to estimate (e.g. 100-200)")
+	cmd.Flags().IntVar(&opts.Samples, "samples", 3, "Number of blocks, evenly spread over the range, generated to measure JSON-RPC calls")
+	cmd.Flags().Float64Var(&opts.PricePerCall, "price-per-call", 0, "Optional price of a JSON-RPC call charged by the provider, to estimate the cost of the range")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Optional path where to write the JSON estimate")
+	_ = cmd.MarkFlagRequired("block-range")
+
+	return cmd
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kkrt-labs/zk-pig/src"
+	"github.com/spf13/cobra"
+)
+
+// NewExportCommand creates and returns the export command
+func NewExportCommand(rootCtx *RootContext) *cobra.Command {
+	var (
+		ctx         = &ProverInputContext{RootContext: *rootCtx}
+		blockNumber string
+		format      string
+		output      string
+		blockRange  string
+		tarFile     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the prover input of a block previously generated during prepare in a given format",
+		Long:  fmt.Sprintf("Export the prover input of a block previously generated during prepare as JSON, either in the zkpig prover input schema (%q) or in the standard execution witness layout returned by debug_executionWitness (%q). With --block-range and --tar, the prover inputs of a range of blocks are packaged instead into a single gzipped tar archive, with a manifest holding their SHA-256 checksums, to be loaded by zkpig import. It can be ran off-line in which case it needs --chain-id to be provided.", src.ExportFormatProverInput, src.ExportFormatExecutionWitness),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if (blockRange == "") == (blockNumber == "") {
+				return fmt.Errorf("exactly one of --block-number and --block-range is required")
+			}
+			if (blockRange == "") != (tarFile == "") {
+				return fmt.Errorf("--block-range and --tar must be set together")
+			}
+			if blockRange != "" {
+				blockNumber = "latest" // Unused, blocks are given by --block-range
+			}
+			return preRun(ctx, &blockNumber)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if tarFile != "" {
+				return exportArchive(cmd, ctx, blockRange, tarFile)
+			}
+
+			exportFormat, err := src.ParseExportFormat(format)
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = cmd.OutOrStdout()
+			if output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("failed to create output file: %v", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			return ctx.svc.Export(cmd.Context(), ctx.blockNumber, exportFormat, w)
+		},
+		PostRunE: func(cmd *cobra.Command, _ []string) error {
+			return ctx.svc.Stop(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVarP(&blockNumber, "block-number", "b", "", "Block number")
+	cmd.Flags().StringVar(&format, "format", string(src.ExportFormatProverInput), fmt.Sprintf("Output format (one of %q)", []src.ExportFormat{src.ExportFormatProverInput, src.ExportFormatExecutionWitness}))
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Path of the output file (defaults to stdout)")
+	cmd.Flags().StringVar(&blockRange, "block-range", "", "Inclusive range of blocks to export to --tar (e.g. 100-200)")
+	cmd.Flags().StringVar(&tarFile, "tar", "", "Path of the gzipped tar archive receiving the prover inputs of --block-range and their manifest")
+
+	return cmd
+}
+
+// exportArchive writes the prover inputs of blockRange to the archive tarFile
+func exportArchive(cmd *cobra.Command, ctx *ProverInputContext, blockRange, tarFile string) error {
+	from, to, err := parseBlockRange(blockRange)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(tarFile)
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %v", err)
+	}
+	defer f.Close()
+
+	manifest, err := ctx.svc.ExportArchive(cmd.Context(), from, to, f)
+	if err != nil {
+		cmd.SilenceUsage = true
+		_ = os.Remove(tarFile)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write archive file: %v", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Exported %d prover inputs (blocks %d-%d) to %v\n", len(manifest.Entries), manifest.From, manifest.To, tarFile)
+	return nil
+}
@@ -3,8 +3,13 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/kkrt-labs/go-utils/ethereum/rpc/jsonrpc"
 	"github.com/kkrt-labs/zk-pig/src"
 	"github.com/spf13/cobra"
@@ -21,15 +26,80 @@ func NewGenerateCommand(rootCtx *RootContext) *cobra.Command {
 	var (
 		ctx         = &ProverInputContext{RootContext: *rootCtx}
 		blockNumber string
+		blockRange  string
+		blockFile   string
+		summaryFile string
+		rangeOpts   src.RangeOptions
+		stopAfter   string
+		rpcMethods  string
+		continueErr bool
+		touching    string
+		minGasUsed  uint64
+		maxGasUsed  uint64
 	)
 
 	cmd := &cobra.Command{
 		Use:     "generate",
-		Short:   "Generate prover input for a specific block",
-		Long:    "Generate prover inputs by running preflight, prepare and execute in a single run. It runs online and requires --chain-rpc-url to be set to a remote JSON-RPC Ethereum Execution Layer node",
-		PreRunE: preRun(ctx, &blockNumber),
-		RunE: func(cmd *cobra.Command, _ []string) error {
-			return ctx.svc.Generate(cmd.Context(), ctx.blockNumber)
+		Short:   "Generate prover input for a specific block or a range of blocks",
+		Long:    "Generate prover inputs by running preflight, prepare and execute in a single run. It runs online and requires --chain-rpc-url to be set to a remote JSON-RPC Ethereum Execution Layer node (or --chain-datadir to be set to a local geth data directory)",
+		PreRunE: preRunCheckingParent(ctx, &blockNumber, func() bool { return blockRange == "" && blockFile == "" }),
+		RunE: func(cmd *cobra.Command, _ []string) (err error) {
+			defer func() { err = recordRPCMethods(ctx, rpcMethods, err) }()
+
+			phase, err := src.ParsePhase(stopAfter)
+			if err != nil {
+				return err
+			}
+
+			if touching != "" {
+				if blockRange == "" {
+					return fmt.Errorf("--touching requires --block-range")
+				}
+				if !gethcommon.IsHexAddress(touching) {
+					return fmt.Errorf("invalid --touching address %q", touching)
+				}
+				address := gethcommon.HexToAddress(touching)
+				rangeOpts.Touching = &address
+			}
+			if cmd.Flags().Changed("min-gas-used") {
+				rangeOpts.MinGasUsed = &minGasUsed
+			}
+			if cmd.Flags().Changed("max-gas-used") {
+				rangeOpts.MaxGasUsed = &maxGasUsed
+			}
+
+			if blockRange == "" && blockFile == "" {
+				return ctx.svc.Generate(cmd.Context(), ctx.blockNumber, &src.GenerateOptions{StopAfter: phase})
+			}
+			rangeOpts.StopAfter = phase
+			defer watchReload(cmd.Context(), &ctx.RootContext)()
+
+			var summary *src.RunSummary
+			if blockFile != "" {
+				var blocks []*src.BlockRef
+				if blocks, err = src.ReadBlockFile(blockFile); err != nil {
+					return err
+				}
+				rangeOpts.StopOnError = !continueErr
+				summary, err = ctx.svc.GenerateBlocks(cmd.Context(), blocks, &rangeOpts)
+			} else {
+				var from, to *big.Int
+				if from, to, err = parseBlockRange(blockRange); err != nil {
+					return err
+				}
+				summary, err = ctx.svc.GenerateRange(cmd.Context(), from, to, &rangeOpts)
+			}
+			if summary != nil {
+				if summaryFile == "" {
+					summaryFile = filepath.Join(ctx.Config.DataDir, "run-summary.json")
+				}
+				if writeErr := summary.WriteFile(summaryFile); writeErr != nil {
+					return fmt.Errorf("failed to write run summary: %v", writeErr)
+				}
+				fmt.Fprint(cmd.OutOrStdout(), summary.String())
+			}
+
+			return err
 		},
 		PostRunE: func(cmd *cobra.Command, _ []string) error {
 			return ctx.svc.Stop(cmd.Context())
@@ -37,6 +107,20 @@ func NewGenerateCommand(rootCtx *RootContext) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&blockNumber, "block-number", "b", "latest", "Block number")
+	cmd.Flags().StringVar(&blockRange, "block-range", "", "Inclusive range of blocks to generate prover inputs for (e.g. 100-200). Takes precedence over --block-number")
+	cmd.Flags().StringVar(&blockFile, "block-file", "", "Path to a file listing the blocks to generate prover inputs for, one block number or hash per line ('#' starts a comment). Takes precedence over --block-number")
+	cmd.Flags().StringVar(&touching, "touching", "", "In range mode, only generate prover inputs for the blocks with a transaction sent from or to this address, or a log emitted by it")
+	cmd.Flags().Uint64Var(&minGasUsed, "min-gas-used", 0, "In range and block file modes, skip blocks whose gas used is below this value (e.g. to skip near-empty blocks)")
+	cmd.Flags().Uint64Var(&maxGasUsed, "max-gas-used", 0, "In range and block file modes, skip blocks whose gas used is above this value")
+	cmd.Flags().BoolVar(&rangeOpts.SkipExisting, "skip-existing", false, "In range and block file modes, skip blocks whose prover input is already in the store")
+	cmd.Flags().BoolVar(&continueErr, "continue-on-error", false, "In block file mode, keep going when a block fails instead of stopping (failed blocks are reported in the run summary, range mode always keeps going)")
+	cmd.Flags().StringVar(&stopAfter, "stop-after", "execute", fmt.Sprintf("Last phase to run (one of %q), preflight data and prover inputs are stored as with the separate subcommands", []src.Phase{src.PhasePreflight, src.PhasePrepare, src.PhaseExecute}))
+	cmd.Flags().BoolVar(&rangeOpts.Pipeline, "pipeline", false, "In range mode, prepare the next block while executing the current one (prover inputs are still stored in block order)")
+	cmd.Flags().DurationVar(&rangeOpts.InterBlockDelay, "inter-block-delay", 0, "In range mode, minimum delay between the start of two blocks (e.g. 500ms), applying in aggregate to all workers")
+	cmd.Flags().DurationVar(&rangeOpts.InterBlockJitter, "inter-block-jitter", 0, "In range mode, maximum random jitter added to --inter-block-delay (e.g. 200ms)")
+	cmd.Flags().StringVar(&summaryFile, "run-summary-file", "", "Path where to write the JSON run summary in range mode (defaults to <data-dir>/run-summary.json)")
+	addRecordRPCMethodsFlag(cmd, &rpcMethods)
+	cmd.MarkFlagsMutuallyExclusive("block-range", "block-file")
 
 	return cmd
 }
@@ -45,15 +129,27 @@ func NewPreflightCommand(rootCtx *RootContext) *cobra.Command {
 	var (
 		ctx         = &ProverInputContext{RootContext: *rootCtx}
 		blockNumber string
+		rpcMethods  string
+		export      string
 	)
 
 	cmd := &cobra.Command{
 		Use:     "preflight",
 		Short:   "Collect necessary data to generate prover inputs from a remote JSON-RPC Ethereum Execution Layer node",
-		Long:    "Collect necessary data to generate prover inputs from a remote JSON-RPC Ethereum Execution Layer node. It runs online and requires --chain-rpc-url to be set to a remote JSON-RPC Ethereum Execution Layer node",
-		PreRunE: preRun(ctx, &blockNumber),
+		Long:    "Collect necessary data to generate prover inputs from a remote JSON-RPC Ethereum Execution Layer node. It runs online and requires --chain-rpc-url to be set to a remote JSON-RPC Ethereum Execution Layer node (or --chain-datadir to be set to a local geth data directory)",
+		PreRunE: preRunCheckingParent(ctx, &blockNumber, nil),
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return ctx.svc.Preflight(cmd.Context(), ctx.blockNumber)
+			if export == "" {
+				return recordRPCMethods(ctx, rpcMethods, ctx.svc.Preflight(cmd.Context(), ctx.blockNumber))
+			}
+
+			f, err := os.Create(export)
+			if err != nil {
+				return fmt.Errorf("failed to create preflight export file: %v", err)
+			}
+			defer f.Close()
+
+			return recordRPCMethods(ctx, rpcMethods, ctx.svc.ExportPreflight(cmd.Context(), ctx.blockNumber, f))
 		},
 		PostRunE: func(cmd *cobra.Command, _ []string) error {
 			return ctx.svc.Stop(cmd.Context())
@@ -61,6 +157,8 @@ func NewPreflightCommand(rootCtx *RootContext) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&blockNumber, "block-number", "b", "latest", "Block number")
+	cmd.Flags().StringVar(&export, "export", "", "Optional path where to write the collected preflight data as a standalone versioned JSON export, that prepare --import can consume")
+	addRecordRPCMethodsFlag(cmd, &rpcMethods)
 
 	return cmd
 }
@@ -69,6 +167,8 @@ func NewPrepareCommand(rootCtx *RootContext) *cobra.Command {
 	var (
 		ctx         = &ProverInputContext{RootContext: *rootCtx}
 		blockNumber string
+		importPath  string
+		blockRLP    string
 	)
 
 	cmd := &cobra.Command{
@@ -77,7 +177,21 @@ func NewPrepareCommand(rootCtx *RootContext) *cobra.Command {
 		Long:    "Prepare prover inputs by basing on data previously collected during preflight. It can be ran off-line in which case it needs --chain-id to be provided",
 		PreRunE: preRun(ctx, &blockNumber),
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return ctx.svc.Prepare(cmd.Context(), ctx.blockNumber)
+			if blockRLP != "" {
+				return prepareBlockRLP(cmd, ctx, blockRLP, importPath)
+			}
+
+			if importPath == "" {
+				return ctx.svc.Prepare(cmd.Context(), ctx.blockNumber)
+			}
+
+			f, err := os.Open(importPath)
+			if err != nil {
+				return fmt.Errorf("failed to open preflight export file: %v", err)
+			}
+			defer f.Close()
+
+			return ctx.svc.PrepareImport(cmd.Context(), f)
 		},
 		PostRunE: func(cmd *cobra.Command, _ []string) error {
 			return ctx.svc.Stop(cmd.Context())
@@ -85,14 +199,39 @@ func NewPrepareCommand(rootCtx *RootContext) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&blockNumber, "block-number", "b", "latest", "Block number")
+	cmd.Flags().StringVar(&importPath, "import", "", "Optional path of a JSON preflight export (see preflight --export) to prepare instead of the stored preflight data, the block is the one of the export")
+	cmd.Flags().StringVar(&blockRLP, "block-rlp", "", "Optional path of a raw RLP-encoded block (binary or hex, e.g. from debug_getRawBlock) to prepare without fetching the block, the pre-state being read from --import, --chain-datadir or the stored preflight data")
 
 	return cmd
 }
 
+// prepareBlockRLP prepares the prover inputs of the raw RLP block at path, with the pre-state of the preflight export at importPath if set
+func prepareBlockRLP(cmd *cobra.Command, ctx *ProverInputContext, path, importPath string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open block RLP file: %v", err)
+	}
+	defer f.Close()
+
+	var witness io.Reader
+	if importPath != "" {
+		w, err := os.Open(importPath)
+		if err != nil {
+			return fmt.Errorf("failed to open preflight export file: %v", err)
+		}
+		defer w.Close()
+		witness = w
+	}
+
+	return ctx.svc.PrepareBlockRLP(cmd.Context(), f, witness)
+}
+
 func NewExecuteCommand(rootCtx *RootContext) *cobra.Command {
 	var (
-		ctx         = &ProverInputContext{RootContext: *rootCtx}
-		blockNumber string
+		ctx           = &ProverInputContext{RootContext: *rootCtx}
+		blockNumber   string
+		crossCheckRPC string
+		upToTx        int
 	)
 
 	cmd := &cobra.Command{
@@ -101,7 +240,22 @@ func NewExecuteCommand(rootCtx *RootContext) *cobra.Command {
 		Long:    "Execute block by basing on prover inputs previously generated during prepare. It can be ran off-line in which case it needs --chain-id to be provided.",
 		PreRunE: preRun(ctx, &blockNumber),
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return ctx.svc.Execute(cmd.Context(), ctx.blockNumber)
+			if upToTx < 0 {
+				return ctx.svc.Execute(cmd.Context(), ctx.blockNumber, &src.ExecuteOptions{CrossCheckRPC: crossCheckRPC})
+			}
+
+			if crossCheckRPC != "" {
+				return fmt.Errorf("--cross-check-rpc can not be used with --up-to-tx")
+			}
+			results, err := ctx.svc.ExecuteUpToTx(cmd.Context(), ctx.blockNumber, upToTx)
+			w := cmd.OutOrStdout()
+			for _, res := range results {
+				fmt.Fprintf(w, "tx %d %s status=%d gas=%d root=%s\n", res.Index, res.Hash.Hex(), res.Status, res.GasUsed, res.StateRoot.Hex())
+			}
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
 		},
 		PostRunE: func(cmd *cobra.Command, _ []string) error {
 			return ctx.svc.Stop(cmd.Context())
@@ -109,27 +263,45 @@ func NewExecuteCommand(rootCtx *RootContext) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&blockNumber, "block-number", "b", "latest", "Block number")
+	cmd.Flags().StringVar(&crossCheckRPC, "cross-check-rpc", "", "Optional JSON-RPC URL of a reference node to spot-check a sample of the block's transactions against after execution (receipts and witness completeness, requires debug_traceTransaction for the latter)")
+	cmd.Flags().IntVar(&upToTx, "up-to-tx", -1, "Optional index of the last transaction to execute, the intermediate state root after every executed transaction is printed and the block is not validated (to bisect the transaction introducing a divergence)")
 
 	return cmd
 }
 
 func NewConfigCommand(rootCtx *RootContext) *cobra.Command {
 	var (
-		ctx = &ProverInputContext{RootContext: *rootCtx}
+		ctx    = &ProverInputContext{RootContext: *rootCtx}
+		format string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Returns current configuration",
+		Long:  "Returns the current configuration with its secrets redacted, either as the JSON resolved configuration (json), as a YAML configuration file that can be loaded back with --config (yaml) or as KEY=value environment variable lines (env)",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			cfg, err := prepareConfig(ctx)
-			if err != nil {
-				return err
+			if ctx.Config.Execution.SkipRootVerification {
+				fmt.Fprintln(cmd.ErrOrStderr(), "WARNING: execution.skip-root-verification is set, generated prover inputs are NOT verified by the execute phase")
 			}
-			return json.NewEncoder(cmd.OutOrStdout()).Encode(cfg)
+
+			switch format {
+			case "json":
+				cfg, err := prepareConfig(ctx)
+				if err != nil {
+					return err
+				}
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(cfg.Redacted())
+			case "yaml":
+				return ctx.Config.Redacted().WriteYAML(cmd.OutOrStdout())
+			case "env":
+				return ctx.Config.Redacted().WriteEnv(cmd.OutOrStdout())
+			}
+			return fmt.Errorf("invalid format %q (expected one of %q)", format, []string{"json", "yaml", "env"})
 		},
 	}
 
+	cmd.Flags().StringVar(&format, "format", "json", fmt.Sprintf("Output format (one of %q)", []string{"json", "yaml", "env"}))
+
 	return cmd
 }
 
@@ -174,27 +346,94 @@ func preRun(ctx *ProverInputContext, blockNumber *string) func(cmd *cobra.Comman
 	}
 }
 
+// preRunCheckingParent is preRun for the commands collecting a block from the remote RPC, which then checks the block
+// links to its parent header (see src.Service.CheckParentLinkage) if single is nil or returns true
+func preRunCheckingParent(ctx *ProverInputContext, blockNumber *string, single func() bool) func(cmd *cobra.Command, args []string) error {
+	run := preRun(ctx, blockNumber)
+	return func(cmd *cobra.Command, args []string) error {
+		if err := run(cmd, args); err != nil {
+			return err
+		}
+		if single != nil && !single() {
+			return nil
+		}
+		return ctx.svc.CheckParentLinkage(cmd.Context(), ctx.blockNumber)
+	}
+}
+
+func addRecordRPCMethodsFlag(cmd *cobra.Command, path *string) {
+	cmd.Flags().StringVar(path, "record-rpc-methods", "", "Optional path where to write the JSON tally of JSON-RPC calls per method (including retries) after the run")
+}
+
+// recordRPCMethods writes the tally of JSON-RPC calls per method to path if set, whatever the outcome err of the run
+func recordRPCMethods(ctx *ProverInputContext, path string, err error) error {
+	if path == "" {
+		return err
+	}
+
+	if writeErr := ctx.svc.RPCMethodsTally().WriteFile(path); writeErr != nil && err == nil {
+		return fmt.Errorf("failed to write RPC methods tally: %v", writeErr)
+	}
+
+	return err
+}
+
+// parseBlockRange parses an inclusive block range in the form "<from>-<to>"
+func parseBlockRange(s string) (from, to *big.Int, err error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("invalid block range %q: expected <from>-<to>", s)
+	}
+
+	from, ok := new(big.Int).SetString(strings.TrimSpace(parts[0]), 10)
+	if !ok || from.Sign() < 0 {
+		return nil, nil, fmt.Errorf("invalid block range %q: invalid start block", s)
+	}
+
+	to, ok = new(big.Int).SetString(strings.TrimSpace(parts[1]), 10)
+	if !ok || to.Sign() < 0 {
+		return nil, nil, fmt.Errorf("invalid block range %q: invalid end block", s)
+	}
+
+	if from.Cmp(to) > 0 {
+		return nil, nil, fmt.Errorf("invalid block range %q: start block is after end block", s)
+	}
+
+	return from, to, nil
+}
+
 // Helper function to validate S3 configuration
+// Static access and secret keys are optional: when both are missing the default AWS credential chain is used
+// (e.g. an EC2 instance profile or an EKS IAM role for service accounts).
 func validateS3Config(ctx *ProverInputContext) error {
+	s3Cfg := ctx.Config.ProverInputStore.S3
+	hasAccessKey := s3Cfg.AWSProvider.Credentials.AccessKey != ""
+	hasSecretKey := s3Cfg.AWSProvider.Credentials.SecretKey != ""
+
 	// Check if any S3 field is set
-	if ctx.Config.ProverInputStore.S3.Bucket != "" ||
-		ctx.Config.ProverInputStore.S3.BucketKeyPrefix != "" ||
-		ctx.Config.ProverInputStore.S3.AWSProvider.Credentials.AccessKey != "" ||
-		ctx.Config.ProverInputStore.S3.AWSProvider.Credentials.SecretKey != "" ||
-		ctx.Config.ProverInputStore.S3.AWSProvider.Region != "" {
+	if s3Cfg.Bucket != "" ||
+		s3Cfg.BucketKeyPrefix != "" ||
+		hasAccessKey ||
+		hasSecretKey ||
+		s3Cfg.AWSProvider.UseDefaultCredentials ||
+		s3Cfg.AWSProvider.Region != "" {
+
+		if s3Cfg.AWSProvider.UseDefaultCredentials && (hasAccessKey || hasSecretKey) {
+			return fmt.Errorf("access-key and secret-key can not be set with s3-use-default-credentials")
+		}
 
 		// If any S3 field is set, ensure all required fields are set
 		missingFields := []string{}
-		if ctx.Config.ProverInputStore.S3.Bucket == "" {
+		if s3Cfg.Bucket == "" {
 			missingFields = append(missingFields, "s3-bucket")
 		}
-		if ctx.Config.ProverInputStore.S3.AWSProvider.Credentials.AccessKey == "" {
+		if hasSecretKey && !hasAccessKey {
 			missingFields = append(missingFields, "access-key")
 		}
-		if ctx.Config.ProverInputStore.S3.AWSProvider.Credentials.SecretKey == "" {
+		if hasAccessKey && !hasSecretKey {
 			missingFields = append(missingFields, "secret-key")
 		}
-		if ctx.Config.ProverInputStore.S3.AWSProvider.Region == "" {
+		if s3Cfg.AWSProvider.Region == "" {
 			missingFields = append(missingFields, "region")
 		}
 
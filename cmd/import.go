@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewImportCommand creates and returns the import command
+func NewImportCommand(rootCtx *RootContext) *cobra.Command {
+	var (
+		ctx         = &ProverInputContext{RootContext: *rootCtx}
+		blockNumber = "latest" // Unused, blocks are given by the archive
+		tarFile     string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "import",
+		Short:   "Load the prover inputs of an archive created by zkpig export --tar into the configured store",
+		Long:    "Load the prover inputs of a gzipped tar archive created by zkpig export --block-range --tar into the configured store, with the current store configuration (e.g. content type, content encoding). Every prover input is checked against the SHA-256 checksum of the archive manifest and against its block and chain before being stored. It can be ran off-line in which case it needs --chain-id to be provided.",
+		PreRunE: preRun(ctx, &blockNumber),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			f, err := os.Open(tarFile)
+			if err != nil {
+				return fmt.Errorf("failed to open archive file: %v", err)
+			}
+			defer f.Close()
+
+			manifest, err := ctx.svc.ImportArchive(cmd.Context(), f)
+			if err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Imported %d prover inputs (blocks %d-%d) from %v\n", len(manifest.Entries), manifest.From, manifest.To, tarFile)
+			return nil
+		},
+		PostRunE: func(cmd *cobra.Command, _ []string) error {
+			return ctx.svc.Stop(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&tarFile, "tar", "", "Path of the gzipped tar archive to import")
+	_ = cmd.MarkFlagRequired("tar")
+
+	return cmd
+}
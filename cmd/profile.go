@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/spf13/pflag"
+)
+
+// Profiler writes standard Go profiles for the duration of a command
+type Profiler struct {
+	Kind string // Kind of profile (one of "cpu", "mem", "trace"), profiling is disabled if empty
+	Out  string // Path of the output profile file
+
+	file *os.File
+}
+
+func (p *Profiler) AddFlags(f *pflag.FlagSet) {
+	f.StringVar(&p.Kind, "profile", "", fmt.Sprintf("Optional profile to write for the duration of the command (one of %q)", []string{"cpu", "mem", "trace"}))
+	f.StringVar(&p.Out, "profile-out", "", "Path where to write the profile (defaults to <profile>.pprof, or trace.out for trace)")
+}
+
+// Start starts profiling, it is a no-op if no profile is configured
+func (p *Profiler) Start() error {
+	if p.Kind == "" {
+		return nil
+	}
+
+	switch p.Kind {
+	case "cpu", "mem", "trace":
+	default:
+		return fmt.Errorf("invalid profile %q (must be one of %q)", p.Kind, []string{"cpu", "mem", "trace"})
+	}
+
+	out := p.Out
+	if out == "" {
+		out = p.Kind + ".pprof"
+		if p.Kind == "trace" {
+			out = "trace.out"
+		}
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("failed to create profile file: %v", err)
+	}
+
+	switch p.Kind {
+	case "cpu":
+		err = pprof.StartCPUProfile(f)
+	case "trace":
+		err = trace.Start(f)
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to start %v profile: %v", p.Kind, err)
+	}
+	p.file = f
+
+	return nil
+}
+
+// Stop stops profiling and flushes the profile
+func (p *Profiler) Stop() error {
+	if p.file == nil {
+		return nil
+	}
+	defer func() { p.file = nil }()
+
+	var err error
+	switch p.Kind {
+	case "cpu":
+		pprof.StopCPUProfile()
+	case "trace":
+		trace.Stop()
+	case "mem":
+		runtime.GC() // Get up-to-date statistics
+		err = pprof.WriteHeapProfile(p.file)
+	}
+	if err != nil {
+		p.file.Close()
+		return fmt.Errorf("failed to write %v profile: %v", p.Kind, err)
+	}
+
+	return p.file.Close()
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// NewRefreshMetadataCommand creates and returns the refresh-metadata command
+func NewRefreshMetadataCommand(rootCtx *RootContext) *cobra.Command {
+	var (
+		ctx         = &ProverInputContext{RootContext: *rootCtx}
+		blockNumber = "latest" // Unused, blocks are given by --block-range
+		blockRange  string
+		summaryFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "refresh-metadata",
+		Short:   "Rewrite stored prover inputs of a range of blocks with up-to-date metadata, without refetching chain data",
+		Long:    "Load the stored prover inputs of a range of blocks, execute them statelessly to verify their final state, recompute their derived metadata (e.g. the EVM version) and rewrite them in place with the current store configuration. It makes no JSON-RPC call and can be ran off-line in which case it needs --chain-id to be provided.",
+		PreRunE: preRun(ctx, &blockNumber),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			from, to, err := parseBlockRange(blockRange)
+			if err != nil {
+				return err
+			}
+
+			summary, err := ctx.svc.RefreshMetadata(cmd.Context(), from, to)
+			if summary != nil {
+				if summaryFile == "" {
+					summaryFile = filepath.Join(ctx.Config.DataDir, "refresh-summary.json")
+				}
+				if writeErr := summary.WriteFile(summaryFile); writeErr != nil {
+					return fmt.Errorf("failed to write run summary: %v", writeErr)
+				}
+				fmt.Fprint(cmd.OutOrStdout(), summary.String())
+			}
+
+			return err
+		},
+		PostRunE: func(cmd *cobra.Command, _ []string) error {
+			return ctx.svc.Stop(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&blockRange, "block-range", "", "Inclusive range of blocks to refresh (e.g. 100-200)")
+	cmd.Flags().StringVar(&summaryFile, "run-summary-file", "", "Path where to write the JSON run summary (defaults to <data-dir>/refresh-summary.json)")
+	_ = cmd.MarkFlagRequired("block-range")
+
+	return cmd
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kkrt-labs/go-utils/log"
+	"github.com/kkrt-labs/zk-pig/src/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// setLogLevel parses level and sets it to the logger level
+func setLogLevel(logLevel zap.AtomicLevel, level string) error {
+	if _, err := log.ParseLevel(level); err != nil {
+		return err
+	}
+	l, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logLevel.SetLevel(l)
+	return nil
+}
+
+// watchReload reloads the configuration on SIGHUP until the returned function is called.
+//
+// Changes of the log level are applied without interrupting the run, other changes require a restart and are logged as ignored.
+func watchReload(ctx context.Context, rootCtx *RootContext) (stop func()) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sighup:
+				reloadConfig(ctx, rootCtx)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sighup)
+		close(done)
+	}
+}
+
+func reloadConfig(ctx context.Context, rootCtx *RootContext) {
+	logger := log.LoggerFromContext(ctx)
+	logger.Info("Reloading configuration")
+
+	updated := new(config.Config)
+	if err := updated.Load(rootCtx.Viper); err != nil {
+		logger.Error("Failed to reload configuration, keeping the current one", zap.Error(err))
+		return
+	}
+
+	for _, key := range config.ChangedKeys(rootCtx.Config, updated) {
+		switch key {
+		case "log.level":
+			if rootCtx.Verbosity.IsSet() {
+				rootCtx.Config.Log.Level = updated.Log.Level
+				logger.Warn("Configuration change overridden by verbosity flags, ignored", zap.String("key", key))
+				continue
+			}
+			if err := setLogLevel(rootCtx.LogLevel, updated.Log.Level); err != nil {
+				logger.Error("Invalid configuration change, ignored", zap.String("key", key), zap.Error(err))
+				continue
+			}
+			rootCtx.Config.Log.Level = updated.Log.Level
+			logger.Info("Configuration change applied", zap.String("key", key), zap.String("value", updated.Log.Level))
+		default:
+			logger.Warn("Configuration change can not be applied without restart, ignored", zap.String("key", key))
+		}
+	}
+}
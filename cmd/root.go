@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/kkrt-labs/go-utils/log"
 	"github.com/kkrt-labs/zk-pig/src/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func init() {
@@ -14,55 +17,113 @@ func init() {
 }
 
 type RootContext struct {
-	Config *config.Config
-	Viper  *viper.Viper
+	Config    *config.Config
+	Viper     *viper.Viper
+	Profiler  *Profiler
+	Verbosity *Verbosity      // -q/-v/-vv flags, overriding the configured log level
+	LogLevel  zap.AtomicLevel // Level of the logger, which can be changed at runtime (see watchReload)
+	BlockLogs *BlockLogs      // Set if the pipeline logs of every block are written to their own file (see --log-per-block-dir)
 }
 
 // NewZkPigCommand creates and returns the root command
 func NewZkPigCommand() *cobra.Command {
 	ctx := &RootContext{
-		Viper:  viper.New(),
-		Config: new(config.Config),
+		Viper:     viper.New(),
+		Config:    new(config.Config),
+		Profiler:  new(Profiler),
+		Verbosity: new(Verbosity),
+		LogLevel:  zap.NewAtomicLevel(),
 	}
 
 	rootCmd := &cobra.Command{
 		Use:   "zkpig",
 		Short: "zkpig is a CLI tool for generating and validating ZK-EVM prover inputs.",
 		PersistentPreRunE: func(rootCmd *cobra.Command, _ []string) error {
+			format, err := ParseErrorFormat(rootCmd.Flag(errorFormatFlag).Value.String())
+			if err != nil {
+				return err
+			}
+			if format == ErrorFormatJSON {
+				silenceErrors(rootCmd.Root())
+			}
+
 			if err := ctx.Config.Load(ctx.Viper); err != nil {
 				return fmt.Errorf("failed to load configuration: %w", err)
 			}
 
-			level, err := log.ParseLevel(ctx.Config.Log.Level)
-			if err != nil {
+			if err := setLogLevel(ctx.LogLevel, ctx.Config.Log.Level); err != nil {
 				return err
 			}
+			if ctx.Verbosity.IsSet() {
+				ctx.LogLevel.SetLevel(ctx.Verbosity.Level())
+			}
 
-			format, err := log.ParseFormat(ctx.Config.Log.Format)
+			logFormat, err := log.ParseFormat(ctx.Config.Log.Format)
 			if err != nil {
 				return err
 			}
 
-			logger, err := log.NewLogger(level, format)
+			// The logger logs every level and is filtered by ctx.LogLevel, so the level can be changed on reload
+			logger, err := log.NewLogger(log.DebugLevel, logFormat)
 			if err != nil {
 				return fmt.Errorf("failed to create logger: %w", err)
 			}
+			if dir := ctx.Config.Log.PerBlockDir; dir != "" {
+				if ctx.BlockLogs, err = NewBlockLogs(dir, logFormat, ctx.Config.Log.PerBlockOnly); err != nil {
+					return err
+				}
+				logger = logger.WithOptions(zap.WrapCore(ctx.BlockLogs.Wrap))
+			}
+			logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+				filtered, _ := zapcore.NewIncreaseLevelCore(core, ctx.LogLevel) // Never fails on a debug core
+				return ctx.Verbosity.FilterTrace(filtered)
+			}))
+
+			if err := ctx.Profiler.Start(); err != nil {
+				return err
+			}
 
-			ctx := log.WithLogger(rootCmd.Context(), logger)
-			rootCmd.SetContext(ctx)
+			logCtx := log.WithLogger(rootCmd.Context(), logger)
+			rootCmd.SetContext(logCtx)
 
 			return nil
 		},
 	}
 
+	// Errors of the command line itself happen before PersistentPreRunE
+	rootCmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		if errorFormat(c.Root()) == ErrorFormatJSON {
+			silenceErrors(c.Root())
+		}
+		return err
+	})
+
+	// Stop profiling once the command completes
+	rootCmd.PersistentPostRunE = func(_ *cobra.Command, _ []string) error {
+		return ctx.finalize()
+	}
+
 	// Add persistent flags for logging
 	log.AddFlags(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddLogFlags(ctx.Viper, rootCmd.PersistentFlags())
 	config.AddConfigFileFlag(ctx.Viper, rootCmd.PersistentFlags())
+	ctx.Profiler.AddFlags(rootCmd.PersistentFlags())
+	ctx.Verbosity.AddFlags(rootCmd.PersistentFlags())
+	addErrorFormatFlag(rootCmd.PersistentFlags())
 
-	// Add flags for chain, aws, and store
+	// Add flags for chain, evm, preflight, execution, aws, store and metrics
 	config.AddChainFlags(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddEVMFlags(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddPreflightFlags(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddExecutionFlags(ctx.Viper, rootCmd.PersistentFlags())
 	config.AddAWSFlags(ctx.Viper, rootCmd.PersistentFlags())
 	config.AddStoreFlags(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddMetricsFlags(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddConcurrencyFlags(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddMaxBlocksFlag(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddMemoryLimitFlags(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddSeedFlag(ctx.Viper, rootCmd.PersistentFlags())
+	config.AddSinkFlags(ctx.Viper, rootCmd.PersistentFlags())
 
 	// Add subcommands
 	rootCmd.AddCommand(VersionCommand(ctx))
@@ -71,6 +132,51 @@ func NewZkPigCommand() *cobra.Command {
 	rootCmd.AddCommand(NewPrepareCommand(ctx))
 	rootCmd.AddCommand(NewExecuteCommand(ctx))
 	rootCmd.AddCommand(NewConfigCommand(ctx))
+	rootCmd.AddCommand(NewDoctorCommand(ctx))
+	rootCmd.AddCommand(NewStoreMigrateCommand(ctx))
+	rootCmd.AddCommand(NewExportCommand(ctx))
+	rootCmd.AddCommand(NewImportCommand(ctx))
+	rootCmd.AddCommand(NewEstimateCommand(ctx))
+	rootCmd.AddCommand(NewRefreshMetadataCommand(ctx))
+	rootCmd.AddCommand(NewAuditCommand(ctx))
+	rootCmd.AddCommand(NewRPCStateCommand(ctx))
+	rootCmd.AddCommand(NewStorageDiffCommand(ctx))
+	rootCmd.AddCommand(NewCheckProofCommand(ctx))
+
+	// Post-run hooks are skipped once a hook fails, so failing commands stop profiling before returning their error
+	finalizeOnError(rootCmd, ctx.finalize)
 
 	return rootCmd
 }
+
+// finalize stops profiling and closes the block logs, it is a no-op once done
+func (ctx *RootContext) finalize() error {
+	err := ctx.Profiler.Stop()
+	if ctx.BlockLogs != nil {
+		err = errors.Join(err, ctx.BlockLogs.Close())
+		ctx.BlockLogs = nil
+	}
+	return err
+}
+
+// finalizeOnError wraps the hooks run after PersistentPreRunE of cmd and of all its subcommands to call finalize when one fails
+func finalizeOnError(cmd *cobra.Command, finalize func() error) {
+	wrap := func(run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+		if run == nil {
+			return nil
+		}
+		return func(c *cobra.Command, args []string) error {
+			if err := run(c, args); err != nil {
+				if ferr := finalize(); ferr != nil {
+					fmt.Fprintln(c.ErrOrStderr(), ferr)
+				}
+				return err
+			}
+			return nil
+		}
+	}
+	cmd.PreRunE, cmd.RunE, cmd.PostRunE = wrap(cmd.PreRunE), wrap(cmd.RunE), wrap(cmd.PostRunE)
+	for _, sub := range cmd.Commands() {
+		finalizeOnError(sub, finalize)
+	}
+}
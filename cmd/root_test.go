@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runZkPig runs the command line args on a newly built root command
+func runZkPig(args ...string) error {
+	rootCmd := NewZkPigCommand()
+	rootCmd.SetArgs(args)
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	return rootCmd.Execute()
+}
+
+func TestProfile(t *testing.T) {
+	dir := t.TempDir()
+	assertProfile := func(t *testing.T, path string) {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Positive(t, info.Size(), "the profile is flushed once the command completes")
+	}
+
+	// Every command is built anew, as when run from main, the profile of a run must not be left running for the next
+	for i, name := range []string{"first.pprof", "second.pprof"} {
+		out := filepath.Join(dir, name)
+		require.NoError(t, runZkPig("version", "--profile", "cpu", "--profile-out", out), "run %d", i)
+		assertProfile(t, out)
+	}
+
+	t.Run("failing command", func(t *testing.T) {
+		out := filepath.Join(dir, "failing.pprof")
+		require.EqualError(t, runZkPig("generate", "--profile", "cpu", "--profile-out", out, "--data-dir", dir), "failed to start prover inputs service: no chain configuration provided")
+		assertProfile(t, out)
+		require.NoError(t, runZkPig("version", "--profile", "cpu", "--profile-out", filepath.Join(dir, "after.pprof")), "profiling was stopped")
+	})
+}
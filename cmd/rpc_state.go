@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+// NewRPCStateCommand creates and returns the rpc-state command
+func NewRPCStateCommand(rootCtx *RootContext) *cobra.Command {
+	var (
+		ctx         = &ProverInputContext{RootContext: *rootCtx}
+		blockNumber string
+		address     string
+		slots       []string
+		asJSON      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "rpc-state",
+		Short:   "Compare the state of an account before a block on the node with the one embedded in the stored prover input",
+		Long:    "Fetch the state of an account (and of optional storage slots) before a block, i.e. at its parent block, from the remote node and print it side by side with the state read from the witness of the stored prover input of the block, to pinpoint missing or wrong state in a witness. It requires --chain-rpc-url (or --chain-datadir) to be set.",
+		PreRunE: preRun(ctx, &blockNumber),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !gethcommon.IsHexAddress(address) {
+				return fmt.Errorf("invalid address %q", address)
+			}
+			keys := make([]gethcommon.Hash, 0, len(slots))
+			for _, slot := range slots {
+				key, err := parseSlot(slot)
+				if err != nil {
+					return err
+				}
+				keys = append(keys, key)
+			}
+
+			report, err := ctx.svc.RPCState(cmd.Context(), ctx.blockNumber, gethcommon.HexToAddress(address), keys)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), report.String())
+			return nil
+		},
+		PostRunE: func(cmd *cobra.Command, _ []string) error {
+			return ctx.svc.Stop(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVarP(&blockNumber, "block-number", "b", "", "Block number of the prover input, the state is read at its parent block")
+	cmd.Flags().StringVar(&address, "address", "", "Address of the account")
+	cmd.Flags().StringArrayVar(&slots, "slot", nil, "Optional storage slot of the account to compare (repeatable)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the comparison as JSON")
+	_ = cmd.MarkFlagRequired("block-number")
+	_ = cmd.MarkFlagRequired("address")
+
+	return cmd
+}
+
+// parseSlot parses a hex storage slot, left-padded to 32 bytes (e.g. 0x0)
+func parseSlot(s string) (gethcommon.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		// Accept odd length slots such as 0x0
+		v, bigErr := hexutil.DecodeBig(s)
+		if bigErr != nil {
+			return gethcommon.Hash{}, fmt.Errorf("invalid storage slot %q: %v", s, err)
+		}
+		return gethcommon.BigToHash(v), nil
+	}
+	if len(b) > gethcommon.HashLength {
+		return gethcommon.Hash{}, fmt.Errorf("invalid storage slot %q: longer than 32 bytes", s)
+	}
+	return gethcommon.BytesToHash(b), nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/kkrt-labs/go-utils/ethereum/rpc/jsonrpc"
+	"github.com/spf13/cobra"
+)
+
+// NewStorageDiffCommand creates and returns the storage-diff command
+func NewStorageDiffCommand(rootCtx *RootContext) *cobra.Command {
+	var (
+		ctx     = &ProverInputContext{RootContext: *rootCtx}
+		from    string
+		to      string
+		address string
+		slots   []string
+		asJSON  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "storage-diff",
+		Short: "List the storage slots of an account that changed between two blocks, with their old and new values",
+		Long: "Compute the storage slots of an account whose value changed between the state after block --from and the state after block --to. " +
+			"If the prover inputs of every block of the range are stored, they are executed on their witness to find every changed slot, offline. " +
+			"Otherwise the slots given with --slot are compared with storage proofs from the node, which requires --chain-rpc-url (or --chain-datadir).",
+		PreRunE: preRun(ctx, &from),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !gethcommon.IsHexAddress(address) {
+				return fmt.Errorf("invalid address %q", address)
+			}
+			toBlock, err := jsonrpc.FromBlockNumArg(to)
+			if err != nil {
+				return fmt.Errorf("invalid to block number: %v", err)
+			}
+			keys := make([]gethcommon.Hash, 0, len(slots))
+			for _, slot := range slots {
+				key, err := parseSlot(slot)
+				if err != nil {
+					return err
+				}
+				keys = append(keys, key)
+			}
+
+			report, err := ctx.svc.StorageDiff(cmd.Context(), gethcommon.HexToAddress(address), ctx.blockNumber, toBlock, keys)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), report.String())
+			return nil
+		},
+		PostRunE: func(cmd *cobra.Command, _ []string) error {
+			return ctx.svc.Stop(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "Block number of the old state (the state after this block)")
+	cmd.Flags().StringVar(&to, "to", "", "Block number of the new state (the state after this block)")
+	cmd.Flags().StringVar(&address, "address", "", "Address of the account")
+	cmd.Flags().StringArrayVar(&slots, "slot", nil, "Optional storage slot to compare (repeatable), required if the prover inputs of the range are not all stored")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the diff as JSON")
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+	_ = cmd.MarkFlagRequired("address")
+
+	return cmd
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kkrt-labs/zk-pig/src"
+	"github.com/spf13/cobra"
+)
+
+// NewStoreMigrateCommand creates and returns the store-migrate command
+func NewStoreMigrateCommand(rootCtx *RootContext) *cobra.Command {
+	var (
+		ctx        = &ProverInputContext{RootContext: *rootCtx}
+		from, to   string
+		blockRange string
+		opts       src.MigrateOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "store-migrate",
+		Short: "Copy prover inputs from a store to another",
+		Long:  "Copy the prover inputs of a range of blocks from a source store to a destination store, optionally changing content type, content encoding or chunk size. Every copy is loaded back from the destination and verified against the source. It runs off-line and requires --chain-id to be provided",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if ctx.Config.Chain.ID == "" {
+				return fmt.Errorf("--chain-id is required")
+			}
+			chainID, err := strconv.ParseUint(ctx.Config.Chain.ID, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid chain id %q", ctx.Config.Chain.ID)
+			}
+
+			fromBlock, toBlock, err := parseBlockRange(blockRange)
+			if err != nil {
+				return err
+			}
+
+			fromCfg, err := src.ParseStoreURL(from, ctx.Config)
+			if err != nil {
+				return fmt.Errorf("invalid --from: %v", err)
+			}
+			toCfg, err := src.ParseStoreURL(to, ctx.Config)
+			if err != nil {
+				return fmt.Errorf("invalid --to: %v", err)
+			}
+
+			res, err := src.MigrateStore(cmd.Context(), chainID, fromCfg, toCfg, fromBlock.Uint64(), toBlock.Uint64(), &opts)
+			if res != nil {
+				fmt.Fprint(cmd.OutOrStdout(), res.String())
+				for n, failure := range res.Failed {
+					fmt.Fprintf(cmd.OutOrStdout(), "  block %d: %v\n", n, failure)
+				}
+			}
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "URL of the source store: file://<dir> or s3://<bucket>[/<key-prefix>], with optional content-type, content-encoding, chunk-size and file-partitioning query parameters (defaulting to the --inputs-* flags)")
+	cmd.Flags().StringVar(&to, "to", "", "URL of the destination store (same format as --from)")
+	cmd.Flags().StringVar(&blockRange, "block-range", "", "Inclusive range of blocks to migrate (e.g. 100-200)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", 4, "Number of blocks migrated concurrently")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", false, "Skip blocks already present in the destination with the same content (e.g. to resume an interrupted migration)")
+	cmd.Flags().BoolVar(&opts.Move, "move", false, "Delete prover inputs from the source once every block of the range has been copied and verified (file sources only)")
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+	_ = cmd.MarkFlagRequired("block-range")
+
+	return cmd
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/kkrt-labs/zk-pig/src/rpc"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap/zapcore"
+)
+
+// Verbosity holds the -q/-v/-vv flags, which override the configured log level
+type Verbosity struct {
+	Quiet   bool // Errors only
+	Verbose int  // Number of -v: 1 for debug, 2 or more for trace (debug including trace logs, e.g. every JSON-RPC call)
+}
+
+func (v *Verbosity) AddFlags(f *pflag.FlagSet) {
+	f.BoolVarP(&v.Quiet, "quiet", "q", false, "Only log errors, overrides --log-level")
+	f.CountVarP(&v.Verbose, "verbose", "v", "Log at debug level (-v) or trace level (-vv, every JSON-RPC call), overrides --log-level")
+}
+
+// IsSet returns true if a verbosity flag overrides the configured log level
+func (v *Verbosity) IsSet() bool {
+	return v.Quiet || v.Verbose > 0
+}
+
+// Level returns the log level set by the verbosity flags, --quiet takes precedence over --verbose
+func (v *Verbosity) Level() zapcore.Level {
+	if v.Quiet {
+		return zapcore.ErrorLevel
+	}
+	return zapcore.DebugLevel
+}
+
+// Trace returns true if trace logs are enabled
+func (v *Verbosity) Trace() bool {
+	return !v.Quiet && v.Verbose >= 2
+}
+
+// FilterTrace wraps core to drop trace logs unless trace logs are enabled
+func (v *Verbosity) FilterTrace(core zapcore.Core) zapcore.Core {
+	if v.Trace() {
+		return core
+	}
+	return &traceFilterCore{Core: core}
+}
+
+// traceFilterCore is a zapcore.Core dropping the entries of the trace logger (and its children)
+type traceFilterCore struct {
+	zapcore.Core
+}
+
+func (c *traceFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &traceFilterCore{Core: c.Core.With(fields)}
+}
+
+func (c *traceFilterCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if isTraceLogger(entry.LoggerName) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// isTraceLogger returns true if name is the trace logger or one of its children, possibly below named parent loggers
+func isTraceLogger(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if part == rpc.TraceLoggerName {
+			return true
+		}
+	}
+	return false
+}
@@ -9,6 +9,7 @@ import (
 func main() {
 	command := cmd.NewZkPigCommand()
 	if err := command.Execute(); err != nil {
+		cmd.WriteError(command, os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,44 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+)
+
+// ExportArchive writes the stored prover inputs of every block in the inclusive range [from, to] to w as a gzipped tar
+// with their manifest (see inputstore.ExportArchive)
+func (s *Service) ExportArchive(ctx context.Context, from, to *big.Int, w io.Writer) (*inputstore.ArchiveManifest, error) {
+	if from.Cmp(to) > 0 {
+		return nil, fmt.Errorf("invalid block range: %v > %v", from, to)
+	}
+	if s.chainID == nil {
+		return nil, fmt.Errorf("chain ID missing")
+	}
+
+	manifest, err := inputstore.ExportArchive(ctx, s.ProverInputStore, w, s.chainID.Uint64(), from.Uint64(), to.Uint64(), s.cfg.ProverInputStore.NumberFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export prover inputs: %v", err)
+	}
+	return manifest, nil
+}
+
+// ImportArchive stores the prover inputs of an archive written by ExportArchive, checking them against the checksums
+// of its manifest, with the current store configuration
+func (s *Service) ImportArchive(ctx context.Context, r io.ReadSeeker) (*inputstore.ArchiveManifest, error) {
+	if s.chainID == nil {
+		return nil, fmt.Errorf("chain ID missing")
+	}
+
+	manifest, err := inputstore.ImportArchive(ctx, s.ProverInputStore, r, s.chainID.Uint64())
+	if err != nil {
+		return nil, fmt.Errorf("failed to import prover inputs: %v", err)
+	}
+	if failures := s.waitUploads(); len(failures) > 0 {
+		return nil, fmt.Errorf("failed to import prover inputs: failed to store prover input of block %d: %v", failures[0].BlockNumber, failures[0].Err)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,193 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/kkrt-labs/go-utils/log"
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+	"go.uber.org/zap"
+)
+
+// AuditOptions are the options for auditing stored prover inputs.
+type AuditOptions struct {
+	// Fix regenerates the prover inputs of stale blocks, otherwise the store is not modified
+	Fix bool
+}
+
+// AuditReport is a machine-readable report of an audit of stored prover inputs against the canonical chain.
+type AuditReport struct {
+	From      uint64          `json:"from"`
+	To        uint64          `json:"to"`
+	Checked   int             `json:"checked"`         // Number of stored prover inputs checked against the canonical chain
+	Missing   []uint64        `json:"missing"`         // Blocks without a stored prover input
+	Stale     []*StaleInput   `json:"stale"`           // Blocks whose stored prover input is not on the canonical chain anymore
+	Fixed     []uint64        `json:"fixed,omitempty"` // Stale blocks whose prover input was regenerated (with --fix)
+	Failed    []*BlockFailure `json:"failed"`          // Blocks that could not be audited (or fixed)
+	RPCCalls  uint64          `json:"rpcCalls"`        // Total number of JSON-RPC calls (including retries)
+	StartTime time.Time       `json:"startTime"`
+	EndTime   time.Time       `json:"endTime"`
+	Duration  string          `json:"duration"`
+}
+
+// StaleInput is a stored prover input whose block is not the canonical block at its height anymore (e.g. after a reorg).
+type StaleInput struct {
+	BlockNumber   uint64          `json:"blockNumber"`
+	StoredHash    gethcommon.Hash `json:"storedHash"`    // Hash of the block embedded in the stored prover input
+	CanonicalHash gethcommon.Hash `json:"canonicalHash"` // Hash of the canonical block at the same height on the node
+}
+
+// Audit checks the stored prover inputs of every block in the inclusive range [from, to] against the canonical chain of the remote
+// node, flagging the ones whose embedded block hash does not match the canonical hash at their height anymore, i.e. prover inputs
+// generated before a reorg that need to be regenerated.
+//
+// The store is not modified unless opts.Fix is set, in which case stale blocks are regenerated as with Generate.
+// An error is returned if a prover input is stale (and not fixed) or if a block could not be audited.
+func (s *Service) Audit(ctx context.Context, from, to *big.Int, opts *AuditOptions) (*AuditReport, error) {
+	if from.Cmp(to) > 0 {
+		return nil, fmt.Errorf("invalid block range: %v > %v", from, to)
+	}
+	if s.remote == nil {
+		return nil, fmt.Errorf("auditing prover inputs requires a remote RPC")
+	}
+	if opts == nil {
+		opts = &AuditOptions{}
+	}
+
+	report := &AuditReport{
+		From:      from.Uint64(),
+		To:        to.Uint64(),
+		Missing:   []uint64{},
+		Stale:     []*StaleInput{},
+		Failed:    []*BlockFailure{},
+		StartTime: time.Now(),
+	}
+	rpcCallsStart := s.rpcCalls.Calls()
+
+	for n := from.Uint64(); n <= to.Uint64() && ctx.Err() == nil; n++ {
+		stale, err := s.auditBlock(ctx, n)
+		switch {
+		case inputstore.IsNotFound(err):
+			report.Missing = append(report.Missing, n)
+			continue
+		case err != nil:
+			log.LoggerFromContext(ctx).Error("Failed to audit prover input", zap.Uint64("block.number", n), zap.Error(err))
+			report.Failed = append(report.Failed, &BlockFailure{BlockNumber: n, Error: err.Error()})
+			continue
+		}
+
+		report.Checked++
+		if stale == nil {
+			continue
+		}
+		log.LoggerFromContext(ctx).Warn(
+			"Stored prover input is not on the canonical chain",
+			zap.Uint64("block.number", n),
+			zap.String("block.hash", stale.StoredHash.Hex()),
+			zap.String("canonical.hash", stale.CanonicalHash.Hex()),
+		)
+		report.Stale = append(report.Stale, stale)
+
+		if opts.Fix {
+			if err := s.Generate(ctx, new(big.Int).SetUint64(n), nil); err != nil {
+				log.LoggerFromContext(ctx).Error("Failed to regenerate stale prover input", zap.Uint64("block.number", n), zap.Error(err))
+				report.Failed = append(report.Failed, &BlockFailure{BlockNumber: n, Error: fmt.Sprintf("failed to regenerate stale prover input: %v", err)})
+				continue
+			}
+			report.Fixed = append(report.Fixed, n)
+		}
+	}
+
+	for _, failure := range s.waitUploads() {
+		err := fmt.Errorf("failed to store provable inputs: %v", failure.Err)
+		log.LoggerFromContext(ctx).Error("Failed to regenerate stale prover input", zap.Uint64("block.number", failure.BlockNumber), zap.Error(err))
+		report.Fixed = slices.DeleteFunc(report.Fixed, func(n uint64) bool { return n == failure.BlockNumber })
+		report.Failed = append(report.Failed, &BlockFailure{BlockNumber: failure.BlockNumber, Error: err.Error()})
+	}
+
+	report.EndTime = time.Now()
+	report.Duration = report.EndTime.Sub(report.StartTime).String()
+	report.RPCCalls = s.rpcCalls.Calls() - rpcCallsStart
+
+	if len(report.Failed) > 0 {
+		return report, fmt.Errorf("failed to audit prover inputs for %d blocks", len(report.Failed))
+	}
+	if unfixed := len(report.Stale) - len(report.Fixed); unfixed > 0 {
+		return report, fmt.Errorf("found %d stale prover inputs (run with --fix to regenerate them)", unfixed)
+	}
+
+	if ctx.Err() != nil {
+		return report, ctx.Err()
+	}
+
+	return report, nil
+}
+
+// auditBlock compares the hash of the block embedded in its stored prover input with the canonical hash at the same height,
+// it returns nil if they match
+func (s *Service) auditBlock(ctx context.Context, n uint64) (*StaleInput, error) {
+	inputs, err := s.ProverInputStore.LoadProverInput(ctx, s.chainID.Uint64(), n)
+	if err != nil {
+		if inputstore.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to load provable inputs: %v", err)
+	}
+	if err := inputs.CheckBlock(s.chainID.Uint64(), n); err != nil {
+		return nil, err
+	}
+
+	header, err := s.ethrpc.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch canonical block header: %v", err)
+	}
+
+	stored := inputs.Blocks[len(inputs.Blocks)-1].Header.Hash()
+	if stored == header.Hash() {
+		return nil, nil
+	}
+
+	return &StaleInput{BlockNumber: n, StoredHash: stored, CanonicalHash: header.Hash()}, nil
+}
+
+// WriteFile writes the report as JSON to the given path.
+func (r *AuditReport) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create audit report directory: %v", err)
+	}
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode audit report: %v", err)
+	}
+
+	return os.WriteFile(path, b, 0o600)
+}
+
+// String returns a human-readable report.
+func (r *AuditReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Audit report for blocks %d-%d\n", r.From, r.To)
+	fmt.Fprintf(&b, "  Checked:       %d\n", r.Checked)
+	fmt.Fprintf(&b, "  Missing:       %d\n", len(r.Missing))
+	fmt.Fprintf(&b, "  Stale:         %d\n", len(r.Stale))
+	fmt.Fprintf(&b, "  Fixed:         %d\n", len(r.Fixed))
+	fmt.Fprintf(&b, "  Failed:        %d\n", len(r.Failed))
+	fmt.Fprintf(&b, "  RPC calls:     %d\n", r.RPCCalls)
+	fmt.Fprintf(&b, "  Duration:      %s\n", r.Duration)
+	for _, stale := range r.Stale {
+		fmt.Fprintf(&b, "  - block %d: stored %s, canonical %s\n", stale.BlockNumber, stale.StoredHash.Hex(), stale.CanonicalHash.Hex())
+	}
+	for _, f := range r.Failed {
+		fmt.Fprintf(&b, "  - block %d: %s\n", f.BlockNumber, f.Error)
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/kkrt-labs/zk-pig/src/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuditTestService returns a service reading chain data from chain, and storing prover inputs under dataDir
+func newAuditTestService(t *testing.T, chain *testChain, dataDir string) *Service {
+	cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+	cfg.DataDir = dataDir
+	cfg.PreflightDataStore.FileConfig.DataDir = filepath.Join(dataDir, "default", "preflight")
+	cfg.ProverInputStore.StoreConfig.FileConfig.DataDir = filepath.Join(dataDir, "default", "inputs")
+	s := newTestService(t, cfg)
+	// Canonical headers are read from the local chain data, the remote is only required to be configured
+	s.remote = jsonrpc.ClientFunc(func(context.Context, *jsonrpc.Request, interface{}) error {
+		return fmt.Errorf("unexpected remote call")
+	})
+	return s
+}
+
+func TestServiceAudit(t *testing.T) {
+	// Both chains share blocks 1 and 2, then the canonical chain reorged from block 3
+	to := gethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	forked := newTestChain(t, nil, nil, 5, nil)
+	canonical := newTestChain(t, nil, nil, 5, func(i int, b *core.BlockGen) {
+		if i == 2 {
+			sendTestTx(t, testConfig, b, to, nil)
+		}
+	})
+	require.Equal(t, forked.blocks[1].Hash(), canonical.blocks[1].Hash())
+	require.NotEqual(t, forked.blocks[2].Hash(), canonical.blocks[2].Hash())
+
+	// Generates the prover inputs of blocks 2 to 4 on the forked chain, block 5 is missing
+	dataDir := t.TempDir()
+	t.Run("generate", func(t *testing.T) {
+		s := newAuditTestService(t, forked, dataDir)
+		for n := int64(2); n <= 4; n++ {
+			require.NoError(t, s.Generate(context.Background(), big.NewInt(n), nil))
+		}
+	})
+	inputsDir := filepath.Join(dataDir, testChainID.String(), "inputs")
+	generated := readStoredFiles(t, inputsDir)
+	require.Len(t, generated, 3)
+
+	stale := []*StaleInput{
+		{BlockNumber: 3, StoredHash: forked.blocks[2].Hash(), CanonicalHash: canonical.blocks[2].Hash()},
+		{BlockNumber: 4, StoredHash: forked.blocks[3].Hash(), CanonicalHash: canonical.blocks[3].Hash()},
+	}
+
+	t.Run("report", func(t *testing.T) {
+		s := newAuditTestService(t, canonical, dataDir)
+		report, err := s.Audit(context.Background(), big.NewInt(2), big.NewInt(5), nil)
+		require.EqualError(t, err, "found 2 stale prover inputs (run with --fix to regenerate them)")
+		assert.Equal(t, 3, report.Checked)
+		assert.Equal(t, []uint64{5}, report.Missing)
+		assert.Equal(t, stale, report.Stale)
+		assert.Empty(t, report.Fixed)
+		assert.Empty(t, report.Failed)
+		assert.Equal(t, generated, readStoredFiles(t, inputsDir), "the store is not modified without --fix")
+	})
+
+	t.Run("fix", func(t *testing.T) {
+		s := newAuditTestService(t, canonical, dataDir)
+		report, err := s.Audit(context.Background(), big.NewInt(2), big.NewInt(5), &AuditOptions{Fix: true})
+		require.NoError(t, err)
+		assert.Equal(t, stale, report.Stale)
+		assert.Equal(t, []uint64{3, 4}, report.Fixed)
+		assert.Equal(t, []uint64{5}, report.Missing, "missing prover inputs are not generated")
+
+		fixed := readStoredFiles(t, inputsDir)
+		require.Len(t, fixed, 3)
+		assert.Equal(t, generated["2.json"], fixed["2.json"], "prover inputs on the canonical chain are left untouched")
+		for _, n := range []uint64{3, 4} {
+			assert.NotEqual(t, generated[fmt.Sprintf("%d.json", n)], fixed[fmt.Sprintf("%d.json", n)])
+			inputs, err := s.ProverInputStore.LoadProverInput(context.Background(), testChainID.Uint64(), n)
+			require.NoError(t, err)
+			assert.Equal(t, canonical.blocks[n-1].Hash(), inputs.Blocks[0].Header.Hash(), "block %d is regenerated on the canonical chain", n)
+		}
+
+		report, err = s.Audit(context.Background(), big.NewInt(2), big.NewInt(4), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Checked)
+		assert.Empty(t, report.Stale)
+	})
+
+	t.Run("fix failure", func(t *testing.T) {
+		// The prover inputs of blocks 3 and 4 are stale again on the forked chain, the regeneration of block 4 fails
+		s := newAuditTestService(t, forked, dataDir)
+		s.cfg.Execution.PreExecuteHooks = []generator.PreExecuteHook{func(_ context.Context, env *generator.ExecutionEnv) error {
+			if env.Block.NumberU64() == 4 {
+				return fmt.Errorf("hook failed")
+			}
+			return nil
+		}}
+
+		report, err := s.Audit(context.Background(), big.NewInt(2), big.NewInt(4), &AuditOptions{Fix: true})
+		require.EqualError(t, err, "failed to audit prover inputs for 1 blocks")
+		assert.Len(t, report.Stale, 2)
+		assert.Equal(t, []uint64{3}, report.Fixed)
+		require.Len(t, report.Failed, 1)
+		assert.Equal(t, uint64(4), report.Failed[0].BlockNumber)
+		assert.Contains(t, report.Failed[0].Error, "failed to regenerate stale prover input")
+		assert.Contains(t, report.Failed[0].Error, "hook failed")
+
+		assert.Equal(t, generated["3.json"], readStoredFiles(t, inputsDir)["3.json"], "block 3 is regenerated on the forked chain")
+	})
+}
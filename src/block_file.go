@@ -0,0 +1,78 @@
+package src
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// BlockRef references a block either by number or by hash
+type BlockRef struct {
+	Number *big.Int
+	Hash   *gethcommon.Hash
+}
+
+func (r *BlockRef) String() string {
+	if r.Hash != nil {
+		return r.Hash.Hex()
+	}
+	return r.Number.String()
+}
+
+// ParseBlockRef parses a decimal or 0x-prefixed hexadecimal block number, or a 0x-prefixed 32 bytes block hash
+func ParseBlockRef(s string) (*BlockRef, error) {
+	if strings.HasPrefix(s, "0x") && len(s) == 2+2*gethcommon.HashLength {
+		b, err := gethcommon.ParseHexOrString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block hash %q: %v", s, err)
+		}
+		hash := gethcommon.BytesToHash(b)
+		return &BlockRef{Hash: &hash}, nil
+	}
+
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok || n.Sign() < 0 || !n.IsUint64() {
+		return nil, fmt.Errorf("invalid block number or hash %q", s)
+	}
+	return &BlockRef{Number: n}, nil
+}
+
+// ReadBlockFile reads the blocks listed in a file, one block number or hash per line
+//
+// Blank lines and everything after a '#' are ignored.
+func ReadBlockFile(path string) ([]*BlockRef, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open block file: %v", err)
+	}
+	defer f.Close()
+
+	var refs []*BlockRef
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		s, _, _ := strings.Cut(scanner.Text(), "#")
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		ref, err := ParseBlockRef(s)
+		if err != nil {
+			return nil, fmt.Errorf("%v:%d: %v", path, line, err)
+		}
+		refs = append(refs, ref)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read block file: %v", err)
+	}
+
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("block file %v lists no blocks", path)
+	}
+
+	return refs, nil
+}
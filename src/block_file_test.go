@@ -0,0 +1,119 @@
+package src
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBlockHash = "0xb44fb4e949d0f78f87f79ee46428f23a2a5713ce6fc6e0beb3dda78c2ac1ea55"
+
+func numberRef(n int64) *BlockRef {
+	return &BlockRef{Number: big.NewInt(n)}
+}
+
+func hashRef(h string) *BlockRef {
+	hash := gethcommon.HexToHash(h)
+	return &BlockRef{Hash: &hash}
+}
+
+func TestParseBlockRef(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		s    string
+		ref  *BlockRef
+		err  string
+	}{
+		{name: "decimal", s: "21465322", ref: numberRef(21465322)},
+		{name: "zero", s: "0", ref: numberRef(0)},
+		{name: "hex", s: "0x14788ea", ref: numberRef(21465322)},
+		{name: "hash", s: testBlockHash, ref: hashRef(testBlockHash)},
+		{name: "short hash", s: testBlockHash[:64], err: "invalid block number or hash"},
+		{name: "invalid hash", s: "0x" + "zz" + testBlockHash[4:], err: "invalid block hash"},
+		{name: "negative", s: "-1", err: `invalid block number or hash "-1"`},
+		{name: "above uint64", s: "18446744073709551616", err: "invalid block number or hash"},
+		{name: "not a number", s: "latest", err: `invalid block number or hash "latest"`},
+		{name: "empty", s: "", err: "invalid block number or hash"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ref, err := ParseBlockRef(tc.s)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.ref, ref)
+		})
+	}
+}
+
+func TestReadBlockFile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		refs    []*BlockRef
+		err     string // Prefixed with the path of the file
+	}{
+		{
+			name:    "numbers and hashes",
+			content: "21465322\n0x14788eb\n" + testBlockHash + "\n",
+			refs:    []*BlockRef{numberRef(21465322), numberRef(21465323), hashRef(testBlockHash)},
+		},
+		{
+			name:    "comments and blank lines",
+			content: "# Blocks to regenerate\n\n21465322 # failed on 2024-12-22\n   \n\t0x14788eb\n#21465324\n",
+			refs:    []*BlockRef{numberRef(21465322), numberRef(21465323)},
+		},
+		{
+			name:    "no trailing newline",
+			content: "21465322",
+			refs:    []*BlockRef{numberRef(21465322)},
+		},
+		{
+			name:    "negative number",
+			content: "21465322\n\n-1\n",
+			err:     `:3: invalid block number or hash "-1"`,
+		},
+		{
+			name:    "invalid line",
+			content: "# header\n21465322\nlatest # not supported\n",
+			err:     `:3: invalid block number or hash "latest"`,
+		},
+		{
+			name:    "invalid hash",
+			content: "0x" + "zz" + testBlockHash[4:],
+			err:     ":1: invalid block hash",
+		},
+		{
+			name:    "empty file",
+			content: "",
+			err:     " lists no blocks",
+		},
+		{
+			name:    "only comments",
+			content: "# nothing to do\n\n",
+			err:     " lists no blocks",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "blocks.txt")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o600))
+
+			refs, err := ReadBlockFile(path)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.refs, refs)
+		})
+	}
+
+	_, err := ReadBlockFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.ErrorContains(t, err, "failed to open block file")
+}
@@ -0,0 +1,124 @@
+package src
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	filestore "github.com/kkrt-labs/go-utils/store/file"
+	multistore "github.com/kkrt-labs/go-utils/store/multi"
+	"github.com/kkrt-labs/zk-pig/src/generator"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testSender  = crypto.PubkeyToAddress(testKey.PublicKey)
+	testChainID = big.NewInt(generator.DevNodeChainID)
+	testConfig  = generator.DevChainConfig(testChainID)
+)
+
+// testChain is a chain generated in a local geth data directory, every block being generated with the state of its
+// parent, so prover inputs can be generated for its blocks off-line (see newTestService)
+type testChain struct {
+	dir      string
+	genesis  *core.Genesis
+	blocks   []*gethtypes.Block // Blocks 1 to n
+	receipts []gethtypes.Receipts
+}
+
+// newTestChain generates n blocks of a chain with configuration cfg (testConfig if nil) on top of a genesis funding
+// testSender with alloc, and writes them to a Pebble chain database, with their receipts and state
+func newTestChain(t *testing.T, cfg *params.ChainConfig, alloc gethtypes.GenesisAlloc, n int, gen func(i int, b *core.BlockGen)) *testChain {
+	dir := t.TempDir()
+	kvdb, err := pebble.New(dir, 16, 16, "", false)
+	require.NoError(t, err)
+	db, err := rawdb.NewDatabaseWithFreezer(kvdb, filepath.Join(dir, "ancient"), "", false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	if alloc == nil {
+		alloc = gethtypes.GenesisAlloc{}
+	}
+	alloc[testSender] = gethtypes.Account{Balance: new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(1000))}
+	if cfg == nil {
+		cfg = testConfig
+	}
+	genesis := &core.Genesis{Config: cfg, Alloc: alloc, Difficulty: gethcommon.Big0, GasLimit: 30_000_000, BaseFee: big.NewInt(params.InitialBaseFee)}
+
+	tdb := triedb.NewDatabase(db, &triedb.Config{HashDB: hashdb.Defaults})
+	genesisBlock, err := genesis.Commit(db, tdb)
+	require.NoError(t, err)
+	require.NoError(t, tdb.Close())
+
+	blocks, receipts := core.GenerateChain(genesis.Config, genesisBlock, beacon.New(ethash.NewFaker()), db, n, func(i int, b *core.BlockGen) {
+		b.SetPoS()
+		if gen != nil {
+			gen(i, b)
+		}
+	})
+	for i, block := range blocks {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	}
+	rawdb.WriteHeadBlockHash(db, blocks[len(blocks)-1].Hash())
+	rawdb.WriteHeadHeaderHash(db, blocks[len(blocks)-1].Hash())
+
+	return &testChain{dir: dir, genesis: genesis, blocks: blocks, receipts: receipts}
+}
+
+// sendTestTx adds to b a transaction of testSender to address with data, on the chain with configuration cfg
+func sendTestTx(t *testing.T, cfg *params.ChainConfig, b *core.BlockGen, to gethcommon.Address, data []byte) {
+	tx, err := gethtypes.SignNewTx(testKey, gethtypes.LatestSigner(cfg), &gethtypes.DynamicFeeTx{
+		ChainID:   cfg.ChainID,
+		Nonce:     b.TxNonce(testSender),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: new(big.Int).Mul(b.BaseFee(), big.NewInt(2)),
+		Gas:       200_000,
+		To:        &to,
+		Data:      data,
+	})
+	require.NoError(t, err)
+	b.AddTx(tx)
+}
+
+// newTestConfig returns the configuration of a service fetching chain data with chain, and storing preflight data
+// and prover inputs under a temporary data directory
+func newTestConfig(t *testing.T, chain ChainConfig) *Config {
+	dataDir := t.TempDir()
+	return &Config{
+		Chain:   chain,
+		DataDir: dataDir,
+		PreflightDataStore: inputstore.PreflightDataStoreConfig{
+			FileConfig: &filestore.Config{DataDir: filepath.Join(dataDir, "default", "preflight")},
+		},
+		ProverInputStore: inputstore.ProverInputStoreConfig{
+			StoreConfig: multistore.Config{FileConfig: &filestore.Config{DataDir: filepath.Join(dataDir, "default", "inputs")}},
+			Format:      input.FormatJSON,
+		},
+	}
+}
+
+// newTestService creates and starts a service with cfg, stopped at the end of the test
+func newTestService(t *testing.T, cfg *Config) *Service {
+	s, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, s.Start(context.Background()))
+	t.Cleanup(func() { _ = s.Stop(context.Background()) })
+	return s
+}
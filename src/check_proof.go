@@ -0,0 +1,119 @@
+package src
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	store "github.com/kkrt-labs/go-utils/store"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	protoinput "github.com/kkrt-labs/zk-pig/src/prover-input/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProofCheck is the outcome of checking the public inputs of a proof against the prover input it was generated from
+type ProofCheck struct {
+	BlockNumber uint64                       `json:"blockNumber"`
+	Checked     int                          `json:"checked"` // Number of public inputs set in the proof and checked
+	Mismatches  []*input.PublicInputMismatch `json:"mismatches"`
+}
+
+// OK returns true if every checked public input matches the prover input
+func (c *ProofCheck) OK() bool {
+	return len(c.Mismatches) == 0
+}
+
+// CheckProof compares the public inputs of a proof (a JSON file, see input.PublicInputs) with the prover input file it was
+// generated from (JSON or protobuf, optionally gzip compressed, as stored by zkpig)
+//
+// It is an interoperability check between the prover and zkpig, the proof itself is not verified.
+func CheckProof(inputPath, proofPath string) (*ProofCheck, error) {
+	pi, err := ReadProverInputFile(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(proofPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof public inputs: %v", err)
+	}
+	pub := new(input.PublicInputs)
+	if err := json.Unmarshal(b, pub); err != nil {
+		return nil, fmt.Errorf("failed to decode proof public inputs: %v", err)
+	}
+
+	checked := 0
+	for _, set := range []bool{pub.ChainID != nil, pub.BlockNumber != nil, pub.BlockHash != nil, pub.PreStateRoot != nil, pub.PostStateRoot != nil} {
+		if set {
+			checked++
+		}
+	}
+	if checked == 0 {
+		return nil, fmt.Errorf("proof public inputs file sets none of chainId, blockNumber, blockHash, preStateRoot and postStateRoot")
+	}
+
+	mismatches, err := pi.CheckPublicInputs(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProofCheck{
+		BlockNumber: pi.Blocks[0].Header.Number.Uint64(),
+		Checked:     checked,
+		Mismatches:  mismatches,
+	}, nil
+}
+
+// ReadProverInputFile reads a prover input file, optionally gzip compressed
+//
+// The format is detected from the file extension (<block>.<format>[.<content-encoding>], as stored by zkpig) if it is the
+// name of a registered codec, otherwise the content is sniffed as either JSON or protobuf.
+func ReadProverInputFile(path string) (*input.ProverInput, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prover input: %v", err)
+	}
+
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress prover input: %v", err)
+		}
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("failed to decompress prover input: %v", err)
+		}
+	}
+
+	if codec, err := input.LookupCodec(fileFormat(path)); err == nil {
+		pi, err := codec.Unmarshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode prover input: %v", err)
+		}
+		return pi, nil
+	}
+
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		pi := new(input.ProverInput)
+		if err := json.Unmarshal(b, pi); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON prover input: %v", err)
+		}
+		return pi, nil
+	}
+
+	msg := new(protoinput.ProverInput)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return nil, fmt.Errorf("failed to decode protobuf prover input: %v", err)
+	}
+	return protoinput.FromProto(msg), nil
+}
+
+// fileFormat returns the format extension of a prover input file name, before its gzip extension if any
+func fileFormat(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), "."+store.ContentEncodingGzip.String())
+	return strings.TrimPrefix(filepath.Ext(name), ".")
+}
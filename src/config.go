@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"math/big"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	aws "github.com/kkrt-labs/go-utils/aws"
 	jsonrpcmrgd "github.com/kkrt-labs/go-utils/jsonrpc/merged"
 	store "github.com/kkrt-labs/go-utils/store"
@@ -12,12 +16,28 @@ import (
 	multistore "github.com/kkrt-labs/go-utils/store/multi"
 	s3store "github.com/kkrt-labs/go-utils/store/s3"
 	"github.com/kkrt-labs/zk-pig/src/config"
+	"github.com/kkrt-labs/zk-pig/src/ethereum"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/state"
+	"github.com/kkrt-labs/zk-pig/src/generator"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/kkrt-labs/zk-pig/src/rpc"
+	"github.com/kkrt-labs/zk-pig/src/sink"
 	inputstore "github.com/kkrt-labs/zk-pig/src/store"
 )
 
 type ChainConfig struct {
-	ID  *big.Int
-	RPC *jsonrpcmrgd.Config
+	ID        *big.Int
+	RPC       *rpc.Config
+	VerifyRPC *rpc.Config // Optional trusted node against which the headers of the collected blocks are verified
+	DataDir   string      // Local geth data directory, if set chain data is read from it instead of RPC
+	RPCRecord string      // Optional path of the file every RPC call and its response are recorded to (see rpc.Recorder)
+	RPCReplay string      // Optional path of a recording RPC calls are served from instead of RPC (see rpc.Replayer)
+}
+
+type EVMConfig struct {
+	Version       string // Version of the EVM library the application is built with
+	AssertVersion string // Optional expected EVM library version
 }
 
 type StoreConfig struct {
@@ -27,10 +47,25 @@ type StoreConfig struct {
 
 // Config is the configuration for the RPCPreflight.
 type Config struct {
-	Chain              ChainConfig
-	DataDir            string
-	PreflightDataStore inputstore.PreflightDataStoreConfig
-	ProverInputStore   inputstore.ProverInputStoreConfig
+	Chain               ChainConfig
+	EVM                 EVMConfig
+	DataDir             string
+	Preflight           generator.PreflightConfig
+	Execution           generator.ExecutionConfig
+	ExecuteReport       string // Optional path of the execution report appended by execute (see evm.ExecutorWithReport)
+	CompletenessReport  string // Optional path of the completeness report appended by execute (see generator.CompletenessReport)
+	AccessList          string // Optional path of the access lists appended by execute (see generator.AccessListReport)
+	CallGraph           string // Optional path of the DOT call graphs appended by execute (see evm.ExecutorWithCallGraph)
+	Metrics             MetricsConfig
+	MemoryLimit         MemoryLimitConfig
+	MaxConcurrentBlocks int // Maximum number of blocks processed at the same time by every entry point of the service (unlimited if 0)
+	MaxBlocks           int // Maximum number of blocks of a single GenerateRange or GenerateBlocks run (unlimited if 0)
+	PreflightDataStore  inputstore.PreflightDataStoreConfig
+	ProverInputStore    inputstore.ProverInputStoreConfig
+	ProofFormat         input.ProofFormat // Format of the state witness of the generated prover inputs
+	Labels              map[string]string // Labels embedded in every generated prover input (see input.ProverInput)
+	Sink                sink.Config       // Optional message queue generate publishes the generated prover inputs to
+	Seed                *uint64           // Seed of the pseudo-random choices of the run (see random.Source), a random seed is used if nil
 }
 
 func (cfg *Config) SetDefault() *Config {
@@ -42,14 +77,55 @@ func (cfg *Config) SetDefault() *Config {
 		cfg.Chain.RPC.SetDefault()
 	}
 
+	if cfg.Chain.VerifyRPC != nil {
+		cfg.Chain.VerifyRPC.SetDefault()
+	}
+
 	return cfg
 }
 
+// Redacted returns a copy of the configuration with its secrets (AWS credentials and the passwords of the RPC and sink URLs) redacted
+func (cfg *Config) Redacted() *Config {
+	redacted := *cfg
+	if cfg.Chain.RPC != nil {
+		rpcCfg := *cfg.Chain.RPC
+		rpcCfg.Addr = config.RedactURL(rpcCfg.Addr)
+		redacted.Chain.RPC = &rpcCfg
+	}
+	if cfg.Chain.VerifyRPC != nil {
+		verifyCfg := *cfg.Chain.VerifyRPC
+		verifyCfg.Addr = config.RedactURL(verifyCfg.Addr)
+		redacted.Chain.VerifyRPC = &verifyCfg
+	}
+	redacted.Sink.URL = config.RedactURL(cfg.Sink.URL)
+	if s3Cfg := cfg.ProverInputStore.StoreConfig.S3Config; s3Cfg != nil && s3Cfg.ProviderConfig != nil && s3Cfg.ProviderConfig.Credentials != nil {
+		credentials := aws.CredentialsConfig{
+			AccessKey: config.RedactSecret(s3Cfg.ProviderConfig.Credentials.AccessKey),
+			SecretKey: config.RedactSecret(s3Cfg.ProviderConfig.Credentials.SecretKey),
+		}
+		provider := *s3Cfg.ProviderConfig
+		provider.Credentials = &credentials
+		redactedS3Cfg := *s3Cfg
+		redactedS3Cfg.ProviderConfig = &provider
+		redacted.ProverInputStore.StoreConfig.S3Config = &redactedS3Cfg
+	}
+	return &redacted
+}
+
 func FromGlobalConfig(gcfg *config.Config) (*Config, error) {
 	// Initialize configuration with default values
 	cfg := &Config{
-		Chain:   ChainConfig{},
-		DataDir: gcfg.DataDir,
+		Chain: ChainConfig{},
+		EVM: EVMConfig{
+			Version:       evm.Version,
+			AssertVersion: gcfg.EVM.AssertVersion,
+		},
+		DataDir:            gcfg.DataDir,
+		ExecuteReport:      gcfg.Execution.Report,
+		CompletenessReport: gcfg.Execution.CompletenessReport,
+		AccessList:         gcfg.Execution.AccessList,
+		CallGraph:          gcfg.Execution.CallGraph,
+		Metrics:            MetricsConfig{Addr: gcfg.Metrics.Addr},
 	}
 
 	// Set Chain ID if provided
@@ -62,13 +138,119 @@ func FromGlobalConfig(gcfg *config.Config) (*Config, error) {
 
 	// --- Set RPC configuration if URL is provided ---
 	if gcfg.Chain.RPC.URL != "" {
-		cfg.Chain.RPC = &jsonrpcmrgd.Config{Addr: gcfg.Chain.RPC.URL}
+		cfg.Chain.RPC = &rpc.Config{
+			Config:    jsonrpcmrgd.Config{Addr: gcfg.Chain.RPC.URL},
+			UserAgent: gcfg.Chain.RPC.UserAgent,
+		}
+		if cfg.Chain.RPC.UserAgent == "" {
+			cfg.Chain.RPC.UserAgent = "zkpig/" + Version
+		}
+
+		tlsCfg := &rpc.TLSConfig{
+			CAFile:   gcfg.Chain.RPC.TLS.CAFile,
+			CertFile: gcfg.Chain.RPC.TLS.CertFile,
+			KeyFile:  gcfg.Chain.RPC.TLS.KeyFile,
+		}
+		if !tlsCfg.IsEmpty() {
+			cfg.Chain.RPC.TLS = tlsCfg
+		}
+
+		if gcfg.Chain.RPC.CacheTTL != "" {
+			if cfg.Chain.RPC.CacheTTL, err = time.ParseDuration(gcfg.Chain.RPC.CacheTTL); err != nil {
+				return nil, fmt.Errorf("invalid RPC cache TTL %q: %v", gcfg.Chain.RPC.CacheTTL, err)
+			}
+		}
+
+		if gcfg.Chain.RPC.SlowLogThreshold != "" {
+			if cfg.Chain.RPC.SlowLogThreshold, err = time.ParseDuration(gcfg.Chain.RPC.SlowLogThreshold); err != nil {
+				return nil, fmt.Errorf("invalid RPC slow log threshold %q: %v", gcfg.Chain.RPC.SlowLogThreshold, err)
+			}
+		}
+
+		if gcfg.Chain.RPC.CallTimeout != "" {
+			if cfg.Chain.RPC.CallTimeout, err = time.ParseDuration(gcfg.Chain.RPC.CallTimeout); err != nil || cfg.Chain.RPC.CallTimeout <= 0 {
+				return nil, fmt.Errorf("invalid RPC call timeout %q", gcfg.Chain.RPC.CallTimeout)
+			}
+		}
+
+		if gcfg.Chain.RPC.MissingTrieNodeRetries != "" {
+			if cfg.Chain.RPC.MissingTrieNodeRetries, err = strconv.Atoi(gcfg.Chain.RPC.MissingTrieNodeRetries); err != nil || cfg.Chain.RPC.MissingTrieNodeRetries < 0 {
+				return nil, fmt.Errorf("invalid RPC missing trie node retries %q", gcfg.Chain.RPC.MissingTrieNodeRetries)
+			}
+		}
+
+		if gcfg.Chain.RPC.ProofChunkSize != "" {
+			if cfg.Chain.RPC.ProofChunkSize, err = strconv.Atoi(gcfg.Chain.RPC.ProofChunkSize); err != nil || cfg.Chain.RPC.ProofChunkSize < 0 {
+				return nil, fmt.Errorf("invalid RPC proof chunk size %q", gcfg.Chain.RPC.ProofChunkSize)
+			}
+		}
+
+		if gcfg.Chain.RPC.HeaderCacheSize != "" {
+			if cfg.Chain.RPC.HeaderCacheSize, err = strconv.Atoi(gcfg.Chain.RPC.HeaderCacheSize); err != nil || cfg.Chain.RPC.HeaderCacheSize < 0 {
+				return nil, fmt.Errorf("invalid RPC header cache size %q", gcfg.Chain.RPC.HeaderCacheSize)
+			}
+		}
+
+		if gcfg.Chain.RPC.MaxResponseBytes != "" {
+			if cfg.Chain.RPC.MaxResponseBytes, err = strconv.ParseInt(gcfg.Chain.RPC.MaxResponseBytes, 10, 64); err != nil || cfg.Chain.RPC.MaxResponseBytes <= 0 {
+				return nil, fmt.Errorf("invalid RPC max response bytes %q", gcfg.Chain.RPC.MaxResponseBytes)
+			}
+		}
+	}
+
+	if gcfg.Chain.VerifyRPC.URL != "" {
+		cfg.Chain.VerifyRPC = &rpc.Config{
+			Config:    jsonrpcmrgd.Config{Addr: gcfg.Chain.VerifyRPC.URL},
+			UserAgent: "zkpig/" + Version,
+		}
+		if cfg.Chain.RPC != nil {
+			cfg.Chain.VerifyRPC.UserAgent = cfg.Chain.RPC.UserAgent
+			cfg.Chain.VerifyRPC.MaxResponseBytes = cfg.Chain.RPC.MaxResponseBytes
+		}
+	}
+
+	cfg.Chain.DataDir = gcfg.Chain.DataDir
+	cfg.Chain.RPCRecord = gcfg.Chain.RPC.Record
+	cfg.Chain.RPCReplay = gcfg.Chain.RPC.Replay
+
+	if gcfg.Chain.Genesis != "" {
+		if cfg.Preflight.Genesis, err = ethereum.ReadGenesisFile(gcfg.Chain.Genesis); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.Preflight.SeedAccessLists = gcfg.Preflight.SeedAccessLists
+	if gcfg.Preflight.AncestorHeaders != "" {
+		if cfg.Preflight.AncestorHeaders, err = strconv.Atoi(gcfg.Preflight.AncestorHeaders); err != nil || cfg.Preflight.AncestorHeaders < 0 {
+			return nil, fmt.Errorf("invalid number of ancestor headers %q", gcfg.Preflight.AncestorHeaders)
+		}
+	}
+	if gcfg.Preflight.MemoryBudget != "" {
+		if cfg.Preflight.MemoryBudget, err = strconv.ParseUint(gcfg.Preflight.MemoryBudget, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid memory budget %q", gcfg.Preflight.MemoryBudget)
+		}
+	}
+	if gcfg.Preflight.ProofDedupCacheSize != "" {
+		size, err := strconv.Atoi(gcfg.Preflight.ProofDedupCacheSize)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid proof dedup cache size %q", gcfg.Preflight.ProofDedupCacheSize)
+		}
+		if size > 0 {
+			cfg.Preflight.ProofDedupCache = state.NewProofDedupCache(size)
+		}
 	}
 
 	// --- Set Preflight Data Store configuration ---
 	if gcfg.PreflightDataStore.File.Dir != "" {
 		cfg.PreflightDataStore = inputstore.PreflightDataStoreConfig{
-			FileConfig: &filestore.Config{DataDir: filepath.Join(gcfg.DataDir, ChainID(gcfg), gcfg.PreflightDataStore.File.Dir)},
+			FileConfig:   &filestore.Config{DataDir: StoreDir(gcfg, gcfg.PreflightDataStore.File.Dir)},
+			MemoryBudget: cfg.Preflight.MemoryBudget,
+		}
+		cfg.PreflightDataStore.PruneFinalized = gcfg.PreflightDataStore.Prune.Finalized
+		if gcfg.PreflightDataStore.Prune.MaxAge != "" {
+			if cfg.PreflightDataStore.PruneMaxAge, err = time.ParseDuration(gcfg.PreflightDataStore.Prune.MaxAge); err != nil || cfg.PreflightDataStore.PruneMaxAge <= 0 {
+				return nil, fmt.Errorf("invalid preflight prune max age %q", gcfg.PreflightDataStore.Prune.MaxAge)
+			}
 		}
 	}
 
@@ -77,38 +259,217 @@ func FromGlobalConfig(gcfg *config.Config) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse content encoding: %v", err)
 	}
-	contentType, err := store.ParseContentType(gcfg.ProverInputStore.ContentType)
-	if err != nil {
+	if _, err := input.LookupCodec(gcfg.ProverInputStore.ContentType); err != nil {
 		return nil, fmt.Errorf("failed to parse content type: %v", err)
 	}
+	numberFormat, err := input.ParseNumberFormat(gcfg.ProverInputStore.NumberFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse number format: %v", err)
+	}
+	jsonEncoder, err := input.ParseJSONEncoder(gcfg.ProverInputStore.JSONEncoder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON encoder: %v", err)
+	}
 
 	var proverInputStoreCfg multistore.Config
 
 	// If File Dir config is set file store
 	if gcfg.ProverInputStore.File.Dir != "" {
-		proverInputStoreCfg.FileConfig = &filestore.Config{DataDir: filepath.Join(gcfg.DataDir, ChainID(gcfg), gcfg.ProverInputStore.File.Dir)}
+		proverInputStoreCfg.FileConfig = &filestore.Config{DataDir: StoreDir(gcfg, gcfg.ProverInputStore.File.Dir)}
 	}
 
 	// Configure S3 store
 	if gcfg.ProverInputStore.S3.Bucket != "" {
 		proverInputStoreCfg.S3Config = &s3store.Config{
-			Bucket:    gcfg.ProverInputStore.S3.Bucket,
-			KeyPrefix: gcfg.ProverInputStore.S3.BucketKeyPrefix,
-			ProviderConfig: &aws.ProviderConfig{
-				Region: gcfg.ProverInputStore.S3.AWSProvider.Region,
-				Credentials: &aws.CredentialsConfig{
-					AccessKey: gcfg.ProverInputStore.S3.AWSProvider.Credentials.AccessKey,
-					SecretKey: gcfg.ProverInputStore.S3.AWSProvider.Credentials.SecretKey,
-				},
-			},
+			Bucket:         gcfg.ProverInputStore.S3.Bucket,
+			KeyPrefix:      gcfg.ProverInputStore.S3.BucketKeyPrefix,
+			ProviderConfig: AWSProviderConfig(gcfg),
 		}
 	}
 
 	// Set prover inputs store configuration
 	cfg.ProverInputStore = inputstore.ProverInputStoreConfig{
-		StoreConfig:     proverInputStoreCfg,
+		StoreConfig: proverInputStoreCfg,
+		S3Client: inputstore.S3ClientConfig{
+			Endpoint:         gcfg.ProverInputStore.S3.Endpoint,
+			ForcePathStyle:   gcfg.ProverInputStore.S3.ForcePathStyle,
+			KMSKeyID:         gcfg.ProverInputStore.S3.KMSKeyID,
+			FallbackPrefixes: gcfg.ProverInputStore.S3.FallbackPrefixes,
+			FlatLayout:       gcfg.FlatLayout,
+		},
 		ContentEncoding: contentEncoding,
-		ContentType:     contentType,
+		Format:          gcfg.ProverInputStore.ContentType,
+		NumberFormat:    numberFormat,
+		JSONEncoder:     jsonEncoder,
+	}
+
+	if gcfg.ProverInputStore.CompressionLevel != "" {
+		if cfg.ProverInputStore.CompressionLevel, err = inputstore.ParseCompressionLevel(gcfg.ProverInputStore.CompressionLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	if gcfg.ProverInputStore.ChunkSize != "" {
+		if cfg.ProverInputStore.ChunkSize, err = strconv.ParseUint(gcfg.ProverInputStore.ChunkSize, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid chunk size %q: %v", gcfg.ProverInputStore.ChunkSize, err)
+		}
+	}
+
+	if cfg.ProverInputStore.S3Client.SSE, err = inputstore.ParseS3SSE(gcfg.ProverInputStore.S3.SSE); err != nil {
+		return nil, err
+	}
+	if gcfg.ProverInputStore.S3.ACL != "" {
+		if cfg.ProverInputStore.S3Client.ACL, err = inputstore.ParseS3ACL(gcfg.ProverInputStore.S3.ACL); err != nil {
+			return nil, err
+		}
+	}
+	if gcfg.ProverInputStore.S3.DownloadResumes != "" {
+		if cfg.ProverInputStore.S3Client.DownloadResumes, err = strconv.Atoi(gcfg.ProverInputStore.S3.DownloadResumes); err != nil {
+			return nil, fmt.Errorf("invalid S3 download resumes %q: %v", gcfg.ProverInputStore.S3.DownloadResumes, err)
+		}
+	}
+	if err := cfg.ProverInputStore.S3Client.Validate(); err != nil {
+		return nil, err
+	}
+
+	if gcfg.ProverInputStore.Uploads != "" {
+		if cfg.ProverInputStore.Uploads, err = strconv.Atoi(gcfg.ProverInputStore.Uploads); err != nil || cfg.ProverInputStore.Uploads < 0 {
+			return nil, fmt.Errorf("invalid upload concurrency %q", gcfg.ProverInputStore.Uploads)
+		}
+	}
+
+	if cfg.ProverInputStore.FilePartitioning, err = inputstore.ParseFilePartitioning(gcfg.ProverInputStore.File.Partitioning); err != nil {
+		return nil, err
+	}
+
+	cfg.ProverInputStore.FanOut = gcfg.ProverInputStore.FanOut
+	if gcfg.ProverInputStore.FanOutQuorum != "" {
+		if cfg.ProverInputStore.FanOutQuorum, err = strconv.Atoi(gcfg.ProverInputStore.FanOutQuorum); err != nil || cfg.ProverInputStore.FanOutQuorum < 0 || cfg.ProverInputStore.FanOutQuorum > len(cfg.ProverInputStore.FanOut) {
+			return nil, fmt.Errorf("invalid fan-out quorum %q (expected 0 to the number of fan-out stores)", gcfg.ProverInputStore.FanOutQuorum)
+		}
+	}
+
+	cfg.ProverInputStore.Retry.MaxAttempts = inputstore.DefaultRetryMaxAttempts
+	if gcfg.ProverInputStore.Retry.MaxAttempts != "" {
+		if cfg.ProverInputStore.Retry.MaxAttempts, err = strconv.Atoi(gcfg.ProverInputStore.Retry.MaxAttempts); err != nil || cfg.ProverInputStore.Retry.MaxAttempts < 0 {
+			return nil, fmt.Errorf("invalid store retry max attempts %q", gcfg.ProverInputStore.Retry.MaxAttempts)
+		}
+	}
+	cfg.ProverInputStore.Retry.InitialInterval = inputstore.DefaultRetryInitialInterval
+	if gcfg.ProverInputStore.Retry.InitialInterval != "" {
+		if cfg.ProverInputStore.Retry.InitialInterval, err = time.ParseDuration(gcfg.ProverInputStore.Retry.InitialInterval); err != nil || cfg.ProverInputStore.Retry.InitialInterval <= 0 {
+			return nil, fmt.Errorf("invalid store retry initial interval %q", gcfg.ProverInputStore.Retry.InitialInterval)
+		}
+	}
+	cfg.ProverInputStore.Retry.MaxInterval = inputstore.DefaultRetryMaxInterval
+	if gcfg.ProverInputStore.Retry.MaxInterval != "" {
+		if cfg.ProverInputStore.Retry.MaxInterval, err = time.ParseDuration(gcfg.ProverInputStore.Retry.MaxInterval); err != nil || cfg.ProverInputStore.Retry.MaxInterval <= 0 {
+			return nil, fmt.Errorf("invalid store retry max interval %q", gcfg.ProverInputStore.Retry.MaxInterval)
+		}
+	}
+
+	if gcfg.Sink.Type != "" {
+		if cfg.Sink.Type, err = sink.ParseType(gcfg.Sink.Type); err != nil {
+			return nil, err
+		}
+		cfg.Sink.URL = gcfg.Sink.URL
+		cfg.Sink.Topic = gcfg.Sink.Topic
+		cfg.Sink.Retry = cfg.ProverInputStore.Retry // Publishes are retried as store operations
+	}
+
+	if gcfg.Seed != "" {
+		seed, err := strconv.ParseUint(gcfg.Seed, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed %q: %v", gcfg.Seed, err)
+		}
+		cfg.Seed = &seed
+	}
+
+	if gcfg.MaxConcurrentBlocks != "" {
+		if cfg.MaxConcurrentBlocks, err = strconv.Atoi(gcfg.MaxConcurrentBlocks); err != nil || cfg.MaxConcurrentBlocks < 0 {
+			return nil, fmt.Errorf("invalid max concurrent blocks %q", gcfg.MaxConcurrentBlocks)
+		}
+	}
+
+	if gcfg.MaxBlocks != "" {
+		if cfg.MaxBlocks, err = strconv.Atoi(gcfg.MaxBlocks); err != nil || cfg.MaxBlocks < 0 {
+			return nil, fmt.Errorf("invalid max blocks %q", gcfg.MaxBlocks)
+		}
+	}
+
+	if gcfg.MemoryLimit.Preflight != "" {
+		if cfg.MemoryLimit.Preflight, err = strconv.ParseUint(gcfg.MemoryLimit.Preflight, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid preflight memory limit %q", gcfg.MemoryLimit.Preflight)
+		}
+	}
+	if gcfg.MemoryLimit.Prepare != "" {
+		if cfg.MemoryLimit.Prepare, err = strconv.ParseUint(gcfg.MemoryLimit.Prepare, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid prepare memory limit %q", gcfg.MemoryLimit.Prepare)
+		}
+	}
+	if gcfg.MemoryLimit.Execute != "" {
+		if cfg.MemoryLimit.Execute, err = strconv.ParseUint(gcfg.MemoryLimit.Execute, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid execute memory limit %q", gcfg.MemoryLimit.Execute)
+		}
+	}
+	if gcfg.MemoryLimit.PollInterval != "" {
+		if cfg.MemoryLimit.PollInterval, err = time.ParseDuration(gcfg.MemoryLimit.PollInterval); err != nil || cfg.MemoryLimit.PollInterval <= 0 {
+			return nil, fmt.Errorf("invalid memory limit poll interval %q", gcfg.MemoryLimit.PollInterval)
+		}
+	}
+	if err := cfg.MemoryLimit.checkSequential(cfg.MaxConcurrentBlocks); err != nil {
+		return nil, err
+	}
+
+	if cfg.Labels, err = parseLabels(gcfg.Labels, gcfg.Label); err != nil {
+		return nil, err
+	}
+
+	if gcfg.Execution.OverrideTimestamp != "" {
+		timestamp, err := strconv.ParseUint(gcfg.Execution.OverrideTimestamp, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid override timestamp %q: %v", gcfg.Execution.OverrideTimestamp, err)
+		}
+		cfg.Execution.OverrideTimestamp = &timestamp
+	}
+
+	if gcfg.Execution.OverrideCoinbase != "" {
+		if !gethcommon.IsHexAddress(gcfg.Execution.OverrideCoinbase) {
+			return nil, fmt.Errorf("invalid override coinbase %q", gcfg.Execution.OverrideCoinbase)
+		}
+		coinbase := gethcommon.HexToAddress(gcfg.Execution.OverrideCoinbase)
+		cfg.Execution.OverrideCoinbase = &coinbase
+	}
+	cfg.Execution.SkipRootVerification = gcfg.Execution.SkipRootVerification
+	if gcfg.Execution.WitnessCommitment && !gcfg.Execution.SkipRootVerification {
+		return nil, fmt.Errorf("execution.witness-commitment requires execution.skip-root-verification")
+	}
+	cfg.Execution.WitnessCommitment = gcfg.Execution.WitnessCommitment
+	cfg.Execution.DualExecute = gcfg.Execution.DualExecute
+	cfg.Execution.VerifySignatures = gcfg.Execution.VerifySignatures
+
+	if gcfg.Execution.VerifyConcurrency != "" {
+		if cfg.Execution.VerifyConcurrency, err = strconv.Atoi(gcfg.Execution.VerifyConcurrency); err != nil || cfg.Execution.VerifyConcurrency < 1 {
+			return nil, fmt.Errorf("invalid verify concurrency %q", gcfg.Execution.VerifyConcurrency)
+		}
+	}
+	if cfg.Execution.StateLoading, err = generator.ParseStateLoading(gcfg.Execution.StateLoading); err != nil {
+		return nil, err
+	}
+
+	if gcfg.ProverInputStore.DeltaBase != "" {
+		deltaBase, err := strconv.ParseUint(gcfg.ProverInputStore.DeltaBase, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid delta base block %q: %v", gcfg.ProverInputStore.DeltaBase, err)
+		}
+		cfg.ProverInputStore.DeltaBase = &deltaBase
+	}
+
+	if cfg.ProofFormat, err = input.ParseProofFormat(gcfg.ProverInputStore.ProofFormat); err != nil {
+		return nil, err
+	}
+	if cfg.ProofFormat == input.ProofFormatMultiproof && cfg.ProverInputStore.DeltaBase != nil {
+		return nil, fmt.Errorf("prover-input-store.delta-base requires the %s proof format", input.ProofFormatPerAccount)
 	}
 
 	return cfg, err
@@ -123,9 +484,59 @@ func parseChainID(chainID string) (*big.Int, error) {
 	return id, nil
 }
 
+// AWSProviderConfig returns the AWS provider configuration of the S3 prover input store
+// Credentials are left unset when static keys are not provided or --s3-use-default-credentials is set, in which case
+// the default AWS credential chain is used (see inputstore.NewS3Store).
+func AWSProviderConfig(gcfg *config.Config) *aws.ProviderConfig {
+	provider := gcfg.ProverInputStore.S3.AWSProvider
+	cfg := &aws.ProviderConfig{Region: provider.Region}
+	if !provider.UseDefaultCredentials && (provider.Credentials.AccessKey != "" || provider.Credentials.SecretKey != "") {
+		cfg.Credentials = &aws.CredentialsConfig{
+			AccessKey: provider.Credentials.AccessKey,
+			SecretKey: provider.Credentials.SecretKey,
+		}
+	}
+	return cfg
+}
+
 func ChainID(gcfg *config.Config) string {
 	if gcfg.Chain.ID == "" {
 		return "default"
 	}
 	return gcfg.Chain.ID
 }
+
+// StoreDir returns the directory of a file store within the data directory, <data-dir>/<chain ID>/<dir>
+//
+// If dir is a template containing inputstore.ChainIDPlaceholder, the chain ID replaces it instead, and if the flat layout
+// is set the directory is <data-dir>/<dir>. The chain ID is "default" without --chain-id, replaced by the chain ID of the
+// stored data by the file store.
+func StoreDir(gcfg *config.Config, dir string) string {
+	switch {
+	case strings.Contains(dir, inputstore.ChainIDPlaceholder):
+		return filepath.Join(gcfg.DataDir, strings.ReplaceAll(dir, inputstore.ChainIDPlaceholder, ChainID(gcfg)))
+	case gcfg.FlatLayout:
+		return filepath.Join(gcfg.DataDir, dir)
+	}
+	return filepath.Join(gcfg.DataDir, ChainID(gcfg), dir)
+}
+
+// parseLabels merges the labels of the configuration file with the key=value labels of the command line, the latter taking precedence
+func parseLabels(defaults map[string]string, labels []string) (map[string]string, error) {
+	if len(defaults) == 0 && len(labels) == 0 {
+		return nil, nil
+	}
+
+	merged := make(map[string]string, len(defaults)+len(labels))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for _, label := range labels {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label %q (expected key=value)", label)
+		}
+		merged[key] = value
+	}
+	return merged, nil
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/spf13/viper"
 )
@@ -10,25 +11,108 @@ type Config struct {
 	Chain struct {
 		ID  string `mapstructure:"id,omitempty"`
 		RPC struct {
-			URL string `mapstructure:"url"`
+			URL                    string `mapstructure:"url"`
+			UserAgent              string `mapstructure:"user-agent"`
+			CacheTTL               string `mapstructure:"cache-ttl"`
+			SlowLogThreshold       string `mapstructure:"slow-log-threshold"`
+			CallTimeout            string `mapstructure:"call-timeout"`
+			MissingTrieNodeRetries string `mapstructure:"missing-trie-node-retries"`
+			ProofChunkSize         string `mapstructure:"proof-chunk-size"`
+			HeaderCacheSize        string `mapstructure:"header-cache-size"`
+			MaxResponseBytes       string `mapstructure:"max-response-bytes"`
+			Record                 string `mapstructure:"record"`
+			Replay                 string `mapstructure:"replay"`
+			TLS                    struct {
+				CAFile   string `mapstructure:"ca-file"`
+				CertFile string `mapstructure:"cert-file"`
+				KeyFile  string `mapstructure:"key-file"`
+			} `mapstructure:"tls"`
 		} `mapstructure:"rpc,omitempty"`
+		VerifyRPC struct {
+			URL string `mapstructure:"url"`
+		} `mapstructure:"verify-rpc,omitempty"`
+		DataDir string `mapstructure:"datadir"`
+		Genesis string `mapstructure:"genesis"`
 	} `mapstructure:"chain"`
 	Log struct {
-		Format string `mapstructure:"format"`
-		Level  string `mapstructure:"level"`
+		Format       string `mapstructure:"format"`
+		Level        string `mapstructure:"level"`
+		PerBlockDir  string `mapstructure:"per-block-dir"`
+		PerBlockOnly bool   `mapstructure:"per-block-only"`
 	} `mapstructure:"log"`
-	DataDir            string   `mapstructure:"data-dir"`
-	Config             []string `mapstructure:"config"`
+	EVM struct {
+		AssertVersion string `mapstructure:"assert-version"`
+	} `mapstructure:"evm"`
+	DataDir    string   `mapstructure:"data-dir"`
+	FlatLayout bool     `mapstructure:"store-flat-layout"`
+	Config     []string `mapstructure:"config"`
+	Preflight  struct {
+		SeedAccessLists     bool   `mapstructure:"seed-access-lists"`
+		AncestorHeaders     string `mapstructure:"ancestor-headers"`
+		MemoryBudget        string `mapstructure:"memory-budget"`
+		ProofDedupCacheSize string `mapstructure:"proof-dedup-cache-size"`
+	} `mapstructure:"preflight"`
+	Execution struct {
+		OverrideTimestamp  string `mapstructure:"override-timestamp"`
+		OverrideCoinbase   string `mapstructure:"override-coinbase"`
+		Report             string `mapstructure:"report"`
+		CompletenessReport string `mapstructure:"completeness-report"`
+		AccessList         string `mapstructure:"access-list"`
+		CallGraph          string `mapstructure:"callgraph"`
+		VerifyConcurrency  string `mapstructure:"verify-concurrency"`
+		StateLoading       string `mapstructure:"state-loading"`
+
+		SkipRootVerification bool `mapstructure:"skip-root-verification"`
+		WitnessCommitment    bool `mapstructure:"witness-commitment"`
+		DualExecute          bool `mapstructure:"dual-execute"`
+		VerifySignatures     bool `mapstructure:"verify-signatures"`
+	} `mapstructure:"execution"`
+	Metrics struct {
+		Addr string `mapstructure:"addr"`
+	} `mapstructure:"metrics"`
+	MemoryLimit struct {
+		Preflight    string `mapstructure:"preflight"`
+		Prepare      string `mapstructure:"prepare"`
+		Execute      string `mapstructure:"execute"`
+		PollInterval string `mapstructure:"poll-interval"`
+	} `mapstructure:"memory-limit"`
+	MaxConcurrentBlocks string `mapstructure:"max-concurrent-blocks"`
+	MaxBlocks           string `mapstructure:"max-blocks"`
+	Seed                string `mapstructure:"seed"`
+	Sink                struct {
+		Type  string `mapstructure:"type"`
+		URL   string `mapstructure:"url"`
+		Topic string `mapstructure:"topic"`
+	} `mapstructure:"sink"`
 	PreflightDataStore struct {
 		File struct {
 			Dir string `mapstructure:"dir"`
 		} `mapstructure:"file"`
+		Prune struct {
+			Finalized bool   `mapstructure:"finalized"`
+			MaxAge    string `mapstructure:"max-age"`
+		} `mapstructure:"prune"`
 	} `mapstructure:"preflight-data-store"`
 	ProverInputStore struct {
-		ContentType     string `mapstructure:"content-type"`
-		ContentEncoding string `mapstructure:"content-encoding"`
-		File            struct {
-			Dir string `mapstructure:"dir"`
+		ContentType      string   `mapstructure:"content-type"`
+		ContentEncoding  string   `mapstructure:"content-encoding"`
+		CompressionLevel string   `mapstructure:"compression-level"`
+		NumberFormat     string   `mapstructure:"number-format"`
+		JSONEncoder      string   `mapstructure:"json-encoder"`
+		ChunkSize        string   `mapstructure:"chunk-size"`
+		ProofFormat      string   `mapstructure:"proof-format"`
+		DeltaBase        string   `mapstructure:"delta-base"`
+		Uploads          string   `mapstructure:"upload-concurrency"`
+		FanOut           []string `mapstructure:"fan-out"`
+		FanOutQuorum     string   `mapstructure:"fan-out-quorum"`
+		Retry            struct {
+			MaxAttempts     string `mapstructure:"max-attempts"`
+			InitialInterval string `mapstructure:"initial-interval"`
+			MaxInterval     string `mapstructure:"max-interval"`
+		} `mapstructure:"retry"`
+		File struct {
+			Dir          string `mapstructure:"dir"`
+			Partitioning string `mapstructure:"partitioning"`
 		} `mapstructure:"file"`
 		S3 struct {
 			AWSProvider struct {
@@ -37,12 +121,22 @@ type Config struct {
 					AccessKey string `mapstructure:"access-key"`
 					SecretKey string `mapstructure:"secret-key"`
 				} `mapstructure:"credentials"`
+				UseDefaultCredentials bool `mapstructure:"use-default-credentials"`
 			} `mapstructure:"aws-provider"`
-			Bucket          string `mapstructure:"bucket"`
-			BucketKeyPrefix string `mapstructure:"bucket-key-prefix"`
+			Bucket           string   `mapstructure:"bucket"`
+			BucketKeyPrefix  string   `mapstructure:"bucket-key-prefix"`
+			Endpoint         string   `mapstructure:"endpoint"`
+			ForcePathStyle   bool     `mapstructure:"force-path-style"`
+			SSE              string   `mapstructure:"sse"`
+			KMSKeyID         string   `mapstructure:"kms-key-id"`
+			ACL              string   `mapstructure:"acl"`
+			DownloadResumes  string   `mapstructure:"download-resumes"`
+			FallbackPrefixes []string `mapstructure:"fallback-prefixes"`
 		} `mapstructure:"s3,omitempty"`
 	} `mapstructure:"prover-input-store"`
-	Extra map[string]interface{} `mapstructure:"_extra,remain,omitempty"`
+	Labels map[string]string      `mapstructure:"labels"`
+	Label  []string               `mapstructure:"label"`
+	Extra  map[string]interface{} `mapstructure:"_extra,remain,omitempty"`
 }
 
 func (config *Config) Load(v *viper.Viper) error {
@@ -61,5 +155,10 @@ func (config *Config) Load(v *viper.Viper) error {
 		return fmt.Errorf("unable to load config: %w", err)
 	}
 
+	// Expand environment variables referenced in config values (e.g. ${AWS_REGION})
+	if err := expandEnvFields(reflect.ValueOf(config), ""); err != nil {
+		return fmt.Errorf("unable to load config: %w", err)
+	}
+
 	return nil
 }
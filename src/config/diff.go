@@ -0,0 +1,37 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ChangedKeys returns the keys (e.g. "chain.rpc.url") of the configuration values that differ between old and updated
+func ChangedKeys(old, updated *Config) []string {
+	var keys []string
+	changedKeys(reflect.ValueOf(old).Elem(), reflect.ValueOf(updated).Elem(), "", &keys)
+	return keys
+}
+
+func changedKeys(old, updated reflect.Value, path string, keys *[]string) {
+	if old.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(old.Interface(), updated.Interface()) {
+			*keys = append(*keys, path)
+		}
+		return
+	}
+
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		if strings.HasPrefix(name, "_") {
+			continue // e.g. extra values
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+		changedKeys(old.Field(i), updated.Field(i), name, keys)
+	}
+}
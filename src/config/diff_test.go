@@ -0,0 +1,23 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedKeys(t *testing.T) {
+	old := new(Config)
+	old.Log.Level = "info"
+	old.Chain.RPC.URL = "http://localhost:8545"
+	old.Label = []string{"run=1"}
+
+	updated := *old
+	assert.Empty(t, ChangedKeys(old, &updated))
+
+	updated.Log.Level = "debug"
+	updated.Chain.RPC.URL = "http://localhost:8546"
+	updated.Label = []string{"run=2"}
+	updated.Extra = map[string]interface{}{"unknown": 1}
+	assert.Equal(t, []string{"chain.rpc.url", "log.level", "label"}, ChangedKeys(old, &updated))
+}
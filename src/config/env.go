@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// expandEnv expands the environment variables referenced in s
+//
+// Supported syntaxes are $VAR, ${VAR} and ${VAR:-default} (default is used if VAR is unset or empty).
+// Variables without default are required and expanding an unset one errors. $$ is a literal $.
+func expandEnv(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var (
+		b   strings.Builder
+		err error
+	)
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch {
+		case i == len(s):
+			return "", fmt.Errorf("invalid trailing $ (use $$ for a literal $)")
+		case s[i] == '$':
+			b.WriteByte('$')
+		case s[i] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("missing closing } in %q", s[i-1:])
+			}
+			expr := s[i+1 : i+end]
+			i += end
+
+			name, def, hasDefault := strings.Cut(expr, ":-")
+			if !isEnvName(name) {
+				return "", fmt.Errorf("invalid environment variable name %q", name)
+			}
+			value, ok := os.LookupEnv(name)
+			switch {
+			case hasDefault && value == "":
+				value = def
+			case !ok:
+				err = fmt.Errorf("environment variable %q is not set", name)
+			}
+			b.WriteString(value)
+		default:
+			end := i
+			for end < len(s) && isEnvNameChar(s[end], end == i) {
+				end++
+			}
+			if end == i {
+				return "", fmt.Errorf("invalid $ at position %d (use $$ for a literal $)", i-1)
+			}
+			name := s[i:end]
+			i = end - 1
+
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				err = fmt.Errorf("environment variable %q is not set", name)
+			}
+			b.WriteString(value)
+		}
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return b.String(), nil
+}
+
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isEnvNameChar(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isEnvNameChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// expandEnvFields expands environment variables in every string field of the given struct, recursively
+// path is the mapstructure path of the struct, used in error messages
+func expandEnvFields(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return expandEnvFields(v.Elem(), path)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			if strings.HasPrefix(name, "_") {
+				continue // e.g. extra values
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := expandEnvFields(v.Field(i), name); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := expandEnvFields(v.Index(i), fmt.Sprintf("%v[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		expanded, err := expandEnv(v.String())
+		if err != nil {
+			return fmt.Errorf("invalid value for %v: %v", path, err)
+		}
+		v.SetString(expanded)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("ZKPIG_TEST_REGION", "eu-west-1")
+	t.Setenv("ZKPIG_TEST_EMPTY", "")
+
+	testCases := []struct {
+		in       string
+		expected string
+		err      bool
+	}{
+		{in: "plain", expected: "plain"},
+		{in: "${ZKPIG_TEST_REGION}", expected: "eu-west-1"},
+		{in: "$ZKPIG_TEST_REGION", expected: "eu-west-1"},
+		{in: "s3://bucket-$ZKPIG_TEST_REGION/prefix", expected: "s3://bucket-eu-west-1/prefix"},
+		{in: "${ZKPIG_TEST_UNSET:-us-east-1}", expected: "us-east-1"},
+		{in: "${ZKPIG_TEST_EMPTY:-default}", expected: "default"},
+		{in: "${ZKPIG_TEST_REGION:-default}", expected: "eu-west-1"},
+		{in: "${ZKPIG_TEST_UNSET:-}", expected: ""},
+		{in: "${ZKPIG_TEST_EMPTY}", expected: ""},
+		{in: "price$$", expected: "price$"},
+		{in: "${ZKPIG_TEST_UNSET}", err: true},
+		{in: "$ZKPIG_TEST_UNSET", err: true},
+		{in: "${ZKPIG_TEST_REGION", err: true},
+		{in: "${}", err: true},
+		{in: "trailing$", err: true},
+		{in: "$-", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			out, err := expandEnv(tc.in)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestExpandEnvFields(t *testing.T) {
+	t.Setenv("ZKPIG_TEST_REGION", "eu-west-1")
+
+	cfg := &Config{}
+	cfg.ProverInputStore.S3.AWSProvider.Region = "${ZKPIG_TEST_REGION}"
+	cfg.Config = []string{"config-${ZKPIG_TEST_REGION}.yaml"}
+	require.NoError(t, expandEnvFields(reflect.ValueOf(cfg), ""))
+	assert.Equal(t, "eu-west-1", cfg.ProverInputStore.S3.AWSProvider.Region)
+	assert.Equal(t, []string{"config-eu-west-1.yaml"}, cfg.Config)
+
+	cfg.ProverInputStore.S3.Bucket = "${ZKPIG_TEST_UNSET}"
+	err := expandEnvFields(reflect.ValueOf(cfg), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prover-input-store.s3.bucket")
+	assert.Contains(t, err.Error(), `"ZKPIG_TEST_UNSET" is not set`)
+}
@@ -36,6 +36,107 @@ var (
 		Env:         "CHAIN_RPC_URL",
 		Description: "Chain JSON-RPC URL",
 	}
+	chainRPCUserAgentFlag = &spf13.StringFlag{
+		ViperKey:    "chain.rpc.user-agent",
+		Name:        "chain-rpc-user-agent",
+		Env:         "CHAIN_RPC_USER_AGENT",
+		Description: "Optional User-Agent sent with Chain JSON-RPC requests (defaults to zkpig/<version>)",
+	}
+	chainRPCCacheTTLFlag = &spf13.StringFlag{
+		ViperKey:    "chain.rpc.cache-ttl",
+		Name:        "chain-rpc-cache-ttl",
+		Env:         "CHAIN_RPC_CACHE_TTL",
+		Description: "Optional duration (e.g. 30s) during which eth_chainId and eth_getBlockByNumber responses are cached to avoid repeated Chain JSON-RPC calls (disabled if empty)",
+	}
+	chainRPCSlowLogThresholdFlag = &spf13.StringFlag{
+		ViperKey:    "chain.rpc.slow-log-threshold",
+		Name:        "chain-rpc-slow-log-threshold",
+		Env:         "CHAIN_RPC_SLOW_LOG_THRESHOLD",
+		Description: "Optional duration (e.g. 2s) above which Chain JSON-RPC calls are logged at warn level with their method, params summary and duration (disabled if empty)",
+	}
+	chainRPCCallTimeoutFlag = &spf13.StringFlag{
+		ViperKey:     "chain.rpc.call-timeout",
+		Name:         "chain-rpc-call-timeout",
+		Env:          "CHAIN_RPC_CALL_TIMEOUT",
+		Description:  "Timeout (e.g. 30s) of every Chain JSON-RPC call attempt, an attempt timing out is retried with backoff",
+		DefaultValue: common.Ptr("30s"),
+	}
+	chainRPCProofChunkSizeFlag = &spf13.StringFlag{
+		ViperKey:     "chain.rpc.proof-chunk-size",
+		Name:         "chain-rpc-proof-chunk-size",
+		Env:          "CHAIN_RPC_PROOF_CHUNK_SIZE",
+		Description:  "Maximum number of storage keys per eth_getProof call, proofs of accounts with more touched storage slots are fetched in several calls and merged (0 disables splitting)",
+		DefaultValue: common.Ptr("1000"),
+	}
+	chainRPCMissingTrieNodeRetriesFlag = &spf13.StringFlag{
+		ViperKey:     "chain.rpc.missing-trie-node-retries",
+		Name:         "chain-rpc-missing-trie-node-retries",
+		Env:          "CHAIN_RPC_MISSING_TRIE_NODE_RETRIES",
+		Description:  "Maximum number of retries of a Chain JSON-RPC call failing with a transient \"missing trie node\" error, e.g. from a node pruning its state (0 disables these retries)",
+		DefaultValue: common.Ptr("3"),
+	}
+	chainRPCHeaderCacheSizeFlag = &spf13.StringFlag{
+		ViperKey:     "chain.rpc.header-cache-size",
+		Name:         "chain-rpc-header-cache-size",
+		Env:          "CHAIN_RPC_HEADER_CACHE_SIZE",
+		Description:  "Number of the last fetched block headers kept to serve header lookups, so consecutive blocks do not fetch the same parent and ancestor headers twice (0 disables caching)",
+		DefaultValue: common.Ptr("256"),
+	}
+	chainRPCMaxResponseBytesFlag = &spf13.StringFlag{
+		ViperKey:     "chain.rpc.max-response-bytes",
+		Name:         "chain-rpc-max-response-bytes",
+		Env:          "CHAIN_RPC_MAX_RESPONSE_BYTES",
+		Description:  "Maximum size in bytes of a Chain JSON-RPC response body read over HTTP, a larger response fails the call without retry (safety limit against untrusted providers)",
+		DefaultValue: common.Ptr("536870912"),
+	}
+	chainRPCTLSCAFileFlag = &spf13.StringFlag{
+		ViperKey:    "chain.rpc.tls.ca-file",
+		Name:        "chain-rpc-tls-ca-file",
+		Env:         "CHAIN_RPC_TLS_CA_FILE",
+		Description: "Optional path to a PEM encoded CA certificate bundle used to verify the Chain JSON-RPC server certificate",
+	}
+	chainRPCTLSCertFileFlag = &spf13.StringFlag{
+		ViperKey:    "chain.rpc.tls.cert-file",
+		Name:        "chain-rpc-tls-cert-file",
+		Env:         "CHAIN_RPC_TLS_CERT_FILE",
+		Description: "Optional path to a PEM encoded client certificate presented to the Chain JSON-RPC server (mTLS)",
+	}
+	chainRPCTLSKeyFileFlag = &spf13.StringFlag{
+		ViperKey:    "chain.rpc.tls.key-file",
+		Name:        "chain-rpc-tls-key-file",
+		Env:         "CHAIN_RPC_TLS_KEY_FILE",
+		Description: "Optional path to the PEM encoded private key of the client certificate (mTLS)",
+	}
+	verifyRPCURLFlag = &spf13.StringFlag{
+		ViperKey:    "chain.verify-rpc.url",
+		Name:        "verify-rpc-url",
+		Env:         "VERIFY_RPC_URL",
+		Description: "Optional JSON-RPC URL of a trusted node against which the header (hash, parent hash and roots) of every collected block is verified after preflight, a mismatch aborts the block",
+	}
+	chainDataDirFlag = &spf13.StringFlag{
+		ViperKey:    "chain.datadir",
+		Name:        "chain-datadir",
+		Env:         "CHAIN_DATADIR",
+		Description: "Optional path to a local geth data directory (LevelDB or Pebble) to read chain data from instead of --chain-rpc-url (the geth node must be stopped)",
+	}
+	recordRPCFlag = &spf13.StringFlag{
+		ViperKey:    "chain.rpc.record",
+		Name:        "record-rpc",
+		Env:         "RECORD_RPC",
+		Description: "Optional path of a JSON lines file to which every Chain JSON-RPC call and its response are recorded, to be replayed with --replay-rpc",
+	}
+	replayRPCFlag = &spf13.StringFlag{
+		ViperKey:    "chain.rpc.replay",
+		Name:        "replay-rpc",
+		Env:         "REPLAY_RPC",
+		Description: "Optional path of a recording written with --record-rpc from which Chain JSON-RPC calls are served instead of --chain-rpc-url, without any network access",
+	}
+	chainGenesisFlag = &spf13.StringFlag{
+		ViperKey:    "chain.genesis",
+		Name:        "chain-genesis",
+		Env:         "CHAIN_GENESIS",
+		Description: "Optional path to the genesis JSON file of the chain (as passed to geth init), whose allocation is the pre-state of block 1 and whose configuration is used for chains without a built-in configuration (required to generate block 1 of chains other than Mainnet, Sepolia and Holesky)",
+	}
 	dataDirFlag = &spf13.StringFlag{
 		ViperKey:     "data-dir",
 		Name:         "data-dir",
@@ -43,25 +144,49 @@ var (
 		Description:  "Path to data directory",
 		DefaultValue: common.Ptr("data"),
 	}
+	storeFlatLayoutFlag = &spf13.BoolFlag{
+		ViperKey:    "store-flat-layout",
+		Name:        "store-flat-layout",
+		Env:         "STORE_FLAT_LAYOUT",
+		Description: "Store preflight data and prover inputs without the chain ID level of the key layout (<data-dir>/<inputs-dir> and <bucket key prefix>/<block>), for stores written without it",
+	}
 	preflightDirFlag = &spf13.StringFlag{
 		ViperKey:     "preflight-data-store.file.dir",
 		Name:         "preflight-dir",
 		Env:          "PREFLIGHT_DIR",
-		Description:  "Directory where to store preflight data within --data-dir. If set to \"\" then does not store preflight data",
+		Description:  "Directory where to store preflight data within --data-dir, under the chain ID unless it contains the {chainID} placeholder. If set to \"\" then does not store preflight data",
 		DefaultValue: common.Ptr("preflight"),
 	}
+	preflightPruneFinalizedFlag = &spf13.BoolFlag{
+		ViperKey:    "preflight-data-store.prune.finalized",
+		Name:        "preflight-prune-finalized",
+		Env:         "PREFLIGHT_PRUNE_FINALIZED",
+		Description: "Evict the stored preflight data of blocks older than the finalized head of the chain, opportunistically in the background",
+	}
+	preflightPruneMaxAgeFlag = &spf13.StringFlag{
+		ViperKey:    "preflight-data-store.prune.max-age",
+		Name:        "preflight-prune-max-age",
+		Env:         "PREFLIGHT_PRUNE_MAX_AGE",
+		Description: "Optional duration (e.g. 24h) after which stored preflight data are evicted, opportunistically in the background",
+	}
 	inputsDirFlag = &spf13.StringFlag{
 		ViperKey:     "prover-input-store.file.dir",
 		Name:         "inputs-dir",
 		Env:          "INPUTS_DIR",
-		Description:  "Directory where to store prover inputs within --data-dir. If set to \"\" then does not store file to dir",
+		Description:  "Directory where to store prover inputs within --data-dir, under the chain ID unless it contains the {chainID} placeholder. If set to \"\" then does not store file to dir",
 		DefaultValue: common.Ptr("inputs"),
 	}
+	inputsDirPartitioningFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.file.partitioning",
+		Name:        "inputs-dir-partitioning",
+		Env:         "INPUTS_DIR_PARTITIONING",
+		Description: "Optional layout of the sub-directories prover inputs are bucketed into within --inputs-dir, \"none\", \"number\" (1000 blocks per directory, e.g. 021/465/21465322.json) or \"hash\" (e.g. 3f/a2/21465322.json), so directories stay small on large backfills",
+	}
 	contentTypeFlag = &spf13.StringFlag{
 		ViperKey:     "prover-input-store.content-type",
 		Name:         "inputs-content-type",
 		Env:          "INPUTS_CONTENT_TYPE",
-		Description:  fmt.Sprintf("Content type for storing prover inputs (one of %q)", []string{"json", "protobuf"}),
+		Description:  fmt.Sprintf("Content type for storing prover inputs (one of %q, or the name of a registered codec)", []string{"json", "protobuf"}),
 		DefaultValue: common.Ptr("json"),
 	}
 	contentEncodingFlag = &spf13.StringFlag{
@@ -71,11 +196,382 @@ var (
 		Description:  fmt.Sprintf("Optional content encoding to apply to prover inputs before storing (one of %q)", []string{"gzip", "flate"}),
 		DefaultValue: common.Ptr(""),
 	}
+	compressionLevelFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.compression-level",
+		Name:        "inputs-compression-level",
+		Env:         "INPUTS_COMPRESSION_LEVEL",
+		Description: "Optional compression level of the content encoding, from 1 (fastest) to 9 (smallest), fixed for reproducible output (defaults to 6 for gzip and zlib, 9 for flate)",
+	}
+	numberFormatFlag = &spf13.StringFlag{
+		ViperKey:     "prover-input-store.number-format",
+		Name:         "inputs-number-format",
+		Env:          "INPUTS_NUMBER_FORMAT",
+		Description:  fmt.Sprintf("JSON serialization of the big integers of prover inputs (one of %q), decimal is the legacy format losing precision above 2^53 in most JSON parsers", []string{"hex", "decimal"}),
+		DefaultValue: common.Ptr("hex"),
+	}
+	jsonEncoderFlag = &spf13.StringFlag{
+		ViperKey:     "prover-input-store.json-encoder",
+		Name:         "inputs-json-encoder",
+		Env:          "INPUTS_JSON_ENCODER",
+		Description:  fmt.Sprintf("Implementation encoding JSON prover inputs (one of %q), fast encodes large witnesses several times faster into the same bytes as std", []string{"std", "fast"}),
+		DefaultValue: common.Ptr("std"),
+	}
+	chunkSizeFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.chunk-size",
+		Name:        "inputs-chunk-size",
+		Env:         "INPUTS_CHUNK_SIZE",
+		Description: "Optional size in bytes above which serialized prover inputs are split into numbered parts with an index object (parts are reassembled on load)",
+	}
+	proofFormatFlag = &spf13.StringFlag{
+		ViperKey:     "prover-input-store.proof-format",
+		Name:         "inputs-proof-format",
+		Env:          "INPUTS_PROOF_FORMAT",
+		Description:  fmt.Sprintf("Format of the state witness of prover inputs (one of %q), multiproof encodes the paths shared by the accessed accounts and slots once", []string{"per-account", "multiproof"}),
+		DefaultValue: common.Ptr("per-account"),
+	}
+	deltaBaseFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.delta-base",
+		Name:        "inputs-delta-base",
+		Env:         "INPUTS_DELTA_BASE",
+		Description: "Experimental: optional base block number, prover inputs of the following blocks are stored as deltas relative to the base block prover input (which must be generated first)",
+	}
+	uploadConcurrencyFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.upload-concurrency",
+		Name:        "inputs-upload-concurrency",
+		Env:         "INPUTS_UPLOAD_CONCURRENCY",
+		Description: "Optional maximum number of prover inputs stored concurrently in the background, so the next blocks are generated while the previous prover inputs are uploaded (by default prover inputs are stored before moving to the next block)",
+	}
+	fanOutFlag = &spf13.StringArrayFlag{
+		ViperKey:    "prover-input-store.fan-out",
+		Name:        "inputs-fan-out",
+		Env:         "INPUTS_FAN_OUT",
+		Description: "Optional store every prover input is written to at once, \"local\" (inputs-dir) or \"s3\" (inputs-aws-s3-bucket), prover inputs are loaded from them in order (can be repeated, by default prover inputs are written to every configured store)",
+	}
+	fanOutQuorumFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.fan-out-quorum",
+		Name:        "inputs-fan-out-quorum",
+		Env:         "INPUTS_FAN_OUT_QUORUM",
+		Description: "Optional number of inputs-fan-out stores a prover input must be written to for the write to succeed (all of them if empty or 0)",
+	}
+	retryMaxAttemptsFlag = &spf13.StringFlag{
+		ViperKey:     "prover-input-store.retry.max-attempts",
+		Name:         "inputs-retry-max-attempts",
+		Env:          "INPUTS_RETRY_MAX_ATTEMPTS",
+		Description:  "Maximum number of attempts of prover input store operations failing with a transient error (e.g. S3 5xx or throttling errors), 1 disables retries",
+		DefaultValue: common.Ptr("3"),
+	}
+	retryInitialIntervalFlag = &spf13.StringFlag{
+		ViperKey:     "prover-input-store.retry.initial-interval",
+		Name:         "inputs-retry-initial-interval",
+		Env:          "INPUTS_RETRY_INITIAL_INTERVAL",
+		Description:  "Delay before the first retry of a prover input store operation, doubled after every attempt",
+		DefaultValue: common.Ptr("200ms"),
+	}
+	retryMaxIntervalFlag = &spf13.StringFlag{
+		ViperKey:     "prover-input-store.retry.max-interval",
+		Name:         "inputs-retry-max-interval",
+		Env:          "INPUTS_RETRY_MAX_INTERVAL",
+		Description:  "Maximum delay between two attempts of a prover input store operation",
+		DefaultValue: common.Ptr("5s"),
+	}
+	labelFlag = &spf13.StringArrayFlag{
+		ViperKey:    "label",
+		Name:        "label",
+		Env:         "LABEL",
+		Description: "Label key=value embedded in generated prover inputs and set as S3 object metadata (can be repeated), added to the labels of the configuration file",
+	}
 )
 
 func AddChainFlags(v *viper.Viper, f *pflag.FlagSet) {
 	chainIDFlag.Add(v, f)
 	chainRPCURLFlag.Add(v, f)
+	chainRPCUserAgentFlag.Add(v, f)
+	verifyRPCURLFlag.Add(v, f)
+	chainRPCCacheTTLFlag.Add(v, f)
+	chainRPCSlowLogThresholdFlag.Add(v, f)
+	chainRPCCallTimeoutFlag.Add(v, f)
+	chainRPCMissingTrieNodeRetriesFlag.Add(v, f)
+	chainRPCProofChunkSizeFlag.Add(v, f)
+	chainRPCHeaderCacheSizeFlag.Add(v, f)
+	chainRPCMaxResponseBytesFlag.Add(v, f)
+	chainRPCTLSCAFileFlag.Add(v, f)
+	chainRPCTLSCertFileFlag.Add(v, f)
+	chainRPCTLSKeyFileFlag.Add(v, f)
+	chainDataDirFlag.Add(v, f)
+	recordRPCFlag.Add(v, f)
+	replayRPCFlag.Add(v, f)
+	chainGenesisFlag.Add(v, f)
+}
+
+var (
+	assertEVMVersionFlag = &spf13.StringFlag{
+		ViperKey:    "evm.assert-version",
+		Name:        "assert-evm-version",
+		Env:         "ASSERT_EVM_VERSION",
+		Description: "Optional expected EVM library version (as reported by the config command), errors if the built-in version differs",
+	}
+)
+
+func AddEVMFlags(v *viper.Viper, f *pflag.FlagSet) {
+	assertEVMVersionFlag.Add(v, f)
+}
+
+var (
+	preflightSeedAccessListsFlag = &spf13.BoolFlag{
+		ViperKey:    "preflight.seed-access-lists",
+		Name:        "preflight-seed-access-lists",
+		Env:         "PREFLIGHT_SEED_ACCESS_LISTS",
+		Description: "Fetch the accounts and storage slots of the transactions' EIP-2930 access lists upfront during preflight (one eth_getProof call per account) instead of discovering them one by one during execution",
+	}
+	preflightAncestorHeadersFlag = &spf13.StringFlag{
+		ViperKey:    "preflight.ancestor-headers",
+		Name:        "ancestor-headers",
+		Env:         "ANCESTOR_HEADERS",
+		Description: "Optional number of ancestor headers of the block embedded in prover inputs (parent first), so a verifier can check the prover input chains back to a trusted checkpoint (execute verifies the hash linkage)",
+	}
+	preflightMemoryBudgetFlag = &spf13.StringFlag{
+		ViperKey:    "preflight.memory-budget",
+		Name:        "memory-budget",
+		Env:         "MEMORY_BUDGET",
+		Description: "Optional soft memory budget in bytes of the pre-state proofs, above which they are spilled to a temporary file on disk and streamed back when storing and preparing preflight data",
+	}
+	preflightProofDedupCacheSizeFlag = &spf13.StringFlag{
+		ViperKey:    "preflight.proof-dedup-cache-size",
+		Name:        "proof-dedup-cache-size",
+		Env:         "PROOF_DEDUP_CACHE_SIZE",
+		Description: "Optional maximum number of account proofs cached by state root and account during a run, so proofs fetched during the execution of a block, or by a previous preflight of the same block, are not fetched again (proofs are not shared between the blocks of a range, each being proved under its own parent state root)",
+	}
+)
+
+func AddPreflightFlags(v *viper.Viper, f *pflag.FlagSet) {
+	preflightSeedAccessListsFlag.Add(v, f)
+	preflightAncestorHeadersFlag.Add(v, f)
+	preflightMemoryBudgetFlag.Add(v, f)
+	preflightProofDedupCacheSizeFlag.Add(v, f)
+}
+
+var (
+	executionOverrideTimestampFlag = &spf13.StringFlag{
+		ViperKey:    "execution.override-timestamp",
+		Name:        "execution-override-timestamp",
+		Env:         "EXECUTION_OVERRIDE_TIMESTAMP",
+		Description: "Optional timestamp replacing the block timestamp during execution, to generate deterministic test vectors (disables block validation, as overridden blocks do not match the canonical state root)",
+	}
+	executionOverrideCoinbaseFlag = &spf13.StringFlag{
+		ViperKey:    "execution.override-coinbase",
+		Name:        "execution-override-coinbase",
+		Env:         "EXECUTION_OVERRIDE_COINBASE",
+		Description: "Optional address replacing the block coinbase during execution, to generate deterministic test vectors (disables block validation, as overridden blocks do not match the canonical state root)",
+	}
+	executionSkipRootVerificationFlag = &spf13.BoolFlag{
+		ViperKey:    "execution.skip-root-verification",
+		Name:        "execution-skip-root-verification",
+		Env:         "EXECUTION_SKIP_ROOT_VERIFICATION",
+		Description: "UNSAFE: skip the validation (post-state root recomputation) of the execute phase of generate, for trusted pipelines whose witnesses are already validated (the execute command always validates)",
+	}
+	executionWitnessCommitmentFlag = &spf13.BoolFlag{
+		ViperKey:    "execution.witness-commitment",
+		Name:        "execution-witness-commitment",
+		Env:         "EXECUTION_WITNESS_COMMITMENT",
+		Description: "UNSAFE: skip the execute phase of generate altogether and store a commitment to the witness and the block hash in the prover input metadata instead, for a downstream verifier to bind the input to its block (requires execution-skip-root-verification)",
+	}
+	executionDualExecuteFlag = &spf13.BoolFlag{
+		ViperKey:    "execution.dual-execute",
+		Name:        "dual-execute",
+		Env:         "DUAL_EXECUTE",
+		Description: "Execute every block a second time with the go-ethereum reference stateless state processor and fail on any per-transaction receipt, receipt root or state root mismatch between the two (doubles the execution cost)",
+	}
+	executionVerifySignaturesFlag = &spf13.BoolFlag{
+		ViperKey:    "execution.verify-signatures",
+		Name:        "verify-signatures",
+		Env:         "VERIFY_SIGNATURES",
+		Description: "Recover the sender of every transaction before executing a block, and fail on an invalid signature or on transactions not matching the transactions root of the block header (detects tampered prover inputs)",
+	}
+	executeReportFlag = &spf13.StringFlag{
+		ViperKey:    "execution.report",
+		Name:        "execute-report",
+		Env:         "EXECUTE_REPORT",
+		Description: "Optional path of a JSON lines report appended with the start and end of every executed block and transaction, written as execution goes so it survives a crash",
+	}
+	completenessReportFlag = &spf13.StringFlag{
+		ViperKey:    "execution.completeness-report",
+		Name:        "completeness-report",
+		Env:         "COMPLETENESS_REPORT",
+		Description: "Optional path of a JSON lines report appended, for every executed block, with every account, storage slot and bytecode accessed during execution and whether the prover input witness backed it",
+	}
+	accessListFlag = &spf13.StringFlag{
+		ViperKey:    "execution.access-list",
+		Name:        "emit-access-list",
+		Env:         "EMIT_ACCESS_LIST",
+		Description: "Optional path of a JSON lines file appended, for every executed block, with its EIP-2930 access list (every account and storage slot accessed during execution)",
+	}
+	callGraphFlag = &spf13.StringFlag{
+		ViperKey:    "execution.callgraph",
+		Name:        "emit-callgraph",
+		Env:         "EMIT_CALLGRAPH",
+		Description: "Optional path of a Graphviz DOT file appended, for every executed block, with its call graph (CALL, DELEGATECALL, STATICCALL... edges between addresses labelled with their number of calls)",
+	}
+	verifyConcurrencyFlag = &spf13.StringFlag{
+		ViperKey:     "execution.verify-concurrency",
+		Name:         "verify-concurrency",
+		Env:          "VERIFY_CONCURRENCY",
+		Description:  "Maximum number of goroutines verifying the witness state nodes of a block before executing it (1 verifies sequentially), independent of the preflight concurrency",
+		DefaultValue: common.Ptr("4"),
+	}
+	stateLoadingFlag = &spf13.StringFlag{
+		ViperKey:    "execution.state-loading",
+		Name:        "state-loading",
+		Env:         "STATE_LOADING",
+		Description: "Optional way the witness state nodes are loaded before executing a block, \"eager\" (all nodes are verified and loaded before execution) or \"lazy\" (nodes are verified and loaded on first access, faster for blocks accessing a small fraction of their witness)",
+	}
+)
+
+func AddExecutionFlags(v *viper.Viper, f *pflag.FlagSet) {
+	executionOverrideTimestampFlag.Add(v, f)
+	executionOverrideCoinbaseFlag.Add(v, f)
+	executionSkipRootVerificationFlag.Add(v, f)
+	executionWitnessCommitmentFlag.Add(v, f)
+	executionDualExecuteFlag.Add(v, f)
+	executionVerifySignaturesFlag.Add(v, f)
+	executeReportFlag.Add(v, f)
+	completenessReportFlag.Add(v, f)
+	accessListFlag.Add(v, f)
+	callGraphFlag.Add(v, f)
+	verifyConcurrencyFlag.Add(v, f)
+	stateLoadingFlag.Add(v, f)
+}
+
+var (
+	metricsAddrFlag = &spf13.StringFlag{
+		ViperKey:    "metrics.addr",
+		Name:        "metrics-addr",
+		Env:         "METRICS_ADDR",
+		Description: "Optional address on which to serve Prometheus metrics at /metrics (e.g. :9090)",
+	}
+)
+
+func AddMetricsFlags(v *viper.Viper, f *pflag.FlagSet) {
+	metricsAddrFlag.Add(v, f)
+}
+
+var (
+	logPerBlockDirFlag = &spf13.StringFlag{
+		ViperKey:    "log.per-block-dir",
+		Name:        "log-per-block-dir",
+		Env:         "LOG_PER_BLOCK_DIR",
+		Description: "Optional directory the pipeline logs of every block are also written to, in a file named by block number (<block>.log)",
+	}
+	logPerBlockOnlyFlag = &spf13.BoolFlag{
+		ViperKey:    "log.per-block-only",
+		Name:        "log-per-block-only",
+		Env:         "LOG_PER_BLOCK_ONLY",
+		Description: "If true (with log-per-block-dir), the pipeline logs of the blocks are written to their file only, not to the main log",
+	}
+)
+
+func AddLogFlags(v *viper.Viper, f *pflag.FlagSet) {
+	logPerBlockDirFlag.Add(v, f)
+	logPerBlockOnlyFlag.Add(v, f)
+}
+
+var (
+	maxConcurrentBlocksFlag = &spf13.StringFlag{
+		ViperKey:    "max-concurrent-blocks",
+		Name:        "max-concurrent-blocks",
+		Env:         "MAX_CONCURRENT_BLOCKS",
+		Description: "Optional maximum number of blocks processed at the same time, across every command and worker of the process (blocks above it wait for a slot, unlimited if empty or 0)",
+	}
+)
+
+func AddConcurrencyFlags(v *viper.Viper, f *pflag.FlagSet) {
+	maxConcurrentBlocksFlag.Add(v, f)
+}
+
+var (
+	maxBlocksFlag = &spf13.StringFlag{
+		ViperKey:    "max-blocks",
+		Name:        "max-blocks",
+		Env:         "MAX_BLOCKS",
+		Description: "Optional maximum number of blocks a single generate invocation processes, a larger --block-range or --block-file fails before processing any block (unlimited if empty or 0)",
+	}
+)
+
+func AddMaxBlocksFlag(v *viper.Viper, f *pflag.FlagSet) {
+	maxBlocksFlag.Add(v, f)
+}
+
+var (
+	memoryLimitPreflightFlag = &spf13.StringFlag{
+		ViperKey:    "memory-limit.preflight",
+		Name:        "memory-limit-preflight",
+		Env:         "MEMORY_LIMIT_PREFLIGHT",
+		Description: "Optional limit in bytes of the heap in use during the preflight of a block, above which the block is aborted with a memory-limit error (unlimited if empty or 0)",
+	}
+	memoryLimitPrepareFlag = &spf13.StringFlag{
+		ViperKey:    "memory-limit.prepare",
+		Name:        "memory-limit-prepare",
+		Env:         "MEMORY_LIMIT_PREPARE",
+		Description: "Optional limit in bytes of the heap in use during the preparation of a block, above which the block is aborted with a memory-limit error (unlimited if empty or 0)",
+	}
+	memoryLimitExecuteFlag = &spf13.StringFlag{
+		ViperKey:    "memory-limit.execute",
+		Name:        "memory-limit-execute",
+		Env:         "MEMORY_LIMIT_EXECUTE",
+		Description: "Optional limit in bytes of the heap in use during the execution of a block, above which the block is aborted with a memory-limit error (unlimited if empty or 0)",
+	}
+	memoryLimitPollIntervalFlag = &spf13.StringFlag{
+		ViperKey:    "memory-limit.poll-interval",
+		Name:        "memory-limit-poll-interval",
+		Env:         "MEMORY_LIMIT_POLL_INTERVAL",
+		Description: "Optional interval at which the heap in use is polled against the memory limits (e.g. 50ms, defaults to 100ms)",
+	}
+)
+
+func AddMemoryLimitFlags(v *viper.Viper, f *pflag.FlagSet) {
+	memoryLimitPreflightFlag.Add(v, f)
+	memoryLimitPrepareFlag.Add(v, f)
+	memoryLimitExecuteFlag.Add(v, f)
+	memoryLimitPollIntervalFlag.Add(v, f)
+}
+
+var (
+	seedFlag = &spf13.StringFlag{
+		ViperKey:    "seed",
+		Name:        "seed",
+		Env:         "SEED",
+		Description: "Optional seed of the pseudo-random choices of the run (block pacing and retry jitter), logged and reported in the run summary so a run can be reproduced (random if empty)",
+	}
+)
+
+func AddSeedFlag(v *viper.Viper, f *pflag.FlagSet) {
+	seedFlag.Add(v, f)
+}
+
+var (
+	sinkTypeFlag = &spf13.StringFlag{
+		ViperKey:    "sink.type",
+		Name:        "sink-type",
+		Env:         "SINK_TYPE",
+		Description: "Optional message queue (one of \"kafka\", \"kafka-rest\", \"nats\") generate publishes a reference to every generated prover input to",
+	}
+	sinkURLFlag = &spf13.StringFlag{
+		ViperKey:    "sink.url",
+		Name:        "sink-url",
+		Env:         "SINK_URL",
+		Description: "URL of the Kafka brokers (comma separated kafka:// or kafka+tls://[user:password@]host:port), of the NATS servers (comma separated nats:// or tls://[user:password@]host:port) or of the Kafka REST Proxy (http(s)://[user:password@]host:port) of --sink-type",
+	}
+	sinkTopicFlag = &spf13.StringFlag{
+		ViperKey:    "sink.topic",
+		Name:        "sink-topic",
+		Env:         "SINK_TOPIC",
+		Description: "Kafka topic or NATS subject prover inputs are published to",
+	}
+)
+
+func AddSinkFlags(v *viper.Viper, f *pflag.FlagSet) {
+	sinkTypeFlag.Add(v, f)
+	sinkURLFlag.Add(v, f)
+	sinkTopicFlag.Add(v, f)
 }
 
 var (
@@ -89,7 +585,7 @@ var (
 		ViperKey:    "prover-input-store.s3.bucket-key-prefix",
 		Name:        "inputs-aws-s3-bucket-key-prefix",
 		Env:         "INPUTS_AWS_S3_BUCKET_KEY_PREFIX",
-		Description: "Optional AWS S3 bucket key prefix where to store prover inputs",
+		Description: "Optional AWS S3 bucket key prefix where to store prover inputs, followed by the chain ID unless it contains the {chainID} placeholder (e.g. zkpig/{chainID}/inputs)",
 	}
 	awsS3AccessKeyFlag = &spf13.StringFlag{
 		ViperKey:    "prover-input-store.s3.aws-provider.credentials.access-key",
@@ -103,6 +599,55 @@ var (
 		Env:         "INPUTS_AWS_S3_SECRET_KEY",
 		Description: "Optional AWS Secret Key to write prover inputs into S3 bucket",
 	}
+	awsS3UseDefaultCredentialsFlag = &spf13.BoolFlag{
+		ViperKey:    "prover-input-store.s3.aws-provider.use-default-credentials",
+		Name:        "s3-use-default-credentials",
+		Env:         "S3_USE_DEFAULT_CREDENTIALS",
+		Description: "Authenticate to AWS S3 with the default AWS credential chain (environment, shared config, web identity, instance profile) instead of static access and secret keys",
+	}
+	awsS3EndpointFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.s3.endpoint",
+		Name:        "inputs-aws-s3-endpoint",
+		Env:         "INPUTS_AWS_S3_ENDPOINT",
+		Description: "Optional endpoint URL of an S3-compatible service (e.g. MinIO, Cloudflare R2) replacing the AWS S3 endpoints",
+	}
+	awsS3ForcePathStyleFlag = &spf13.BoolFlag{
+		ViperKey:    "prover-input-store.s3.force-path-style",
+		Name:        "inputs-aws-s3-force-path-style",
+		Env:         "INPUTS_AWS_S3_FORCE_PATH_STYLE",
+		Description: "Address S3 buckets in the URL path (<endpoint>/<bucket>) instead of the host (<bucket>.<endpoint>), as required by most S3-compatible services",
+	}
+	awsS3SSEFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.s3.sse",
+		Name:        "inputs-aws-s3-sse",
+		Env:         "INPUTS_AWS_S3_SSE",
+		Description: "Optional server-side encryption of the prover inputs written to S3 (one of none, aes256 or kms; defaults to none, i.e. the bucket default encryption)",
+	}
+	awsS3KMSKeyIDFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.s3.kms-key-id",
+		Name:        "inputs-aws-s3-kms-key-id",
+		Env:         "INPUTS_AWS_S3_KMS_KEY_ID",
+		Description: "Optional ID or ARN of the KMS key encrypting the prover inputs written to S3, requires --inputs-aws-s3-sse kms (defaults to the AWS managed key)",
+	}
+	awsS3ACLFlag = &spf13.StringFlag{
+		ViperKey:    "prover-input-store.s3.acl",
+		Name:        "inputs-aws-s3-acl",
+		Env:         "INPUTS_AWS_S3_ACL",
+		Description: "Optional canned ACL of the prover inputs written to S3 (e.g. private, bucket-owner-full-control; defaults to the bucket default)",
+	}
+	awsS3DownloadResumesFlag = &spf13.StringFlag{
+		ViperKey:     "prover-input-store.s3.download-resumes",
+		Name:         "inputs-aws-s3-download-resumes",
+		Env:          "INPUTS_AWS_S3_DOWNLOAD_RESUMES",
+		Description:  "Maximum number of times a prover input download from S3 interrupted by a connection error is resumed from the last received byte with a range request, 0 restarts failed downloads from scratch",
+		DefaultValue: common.Ptr("3"),
+	}
+	awsS3FallbackPrefixFlag = &spf13.StringArrayFlag{
+		ViperKey:    "prover-input-store.s3.fallback-prefixes",
+		Name:        "inputs-aws-s3-fallback-prefix",
+		Env:         "INPUTS_AWS_S3_FALLBACK_PREFIXES",
+		Description: "Optional AWS S3 bucket key prefix where prover inputs missing under the bucket key prefix are looked up (can be repeated, tried in order), prover inputs are always written under the bucket key prefix",
+	}
 	awsS3RegionFlag = &spf13.StringFlag{
 		ViperKey:    "prover-input-store.s3.aws-provider.region",
 		Name:        "inputs-aws-s3-region",
@@ -116,13 +661,65 @@ func AddAWSFlags(v *viper.Viper, f *pflag.FlagSet) {
 	awsS3RegionFlag.Add(v, f)
 	awsS3AccessKeyFlag.Add(v, f)
 	awsS3SecretKeyFlag.Add(v, f)
+	awsS3UseDefaultCredentialsFlag.Add(v, f)
 	awsS3BucketKeyPrefixFlag.Add(v, f)
+	awsS3EndpointFlag.Add(v, f)
+	awsS3ForcePathStyleFlag.Add(v, f)
+	awsS3SSEFlag.Add(v, f)
+	awsS3KMSKeyIDFlag.Add(v, f)
+	awsS3ACLFlag.Add(v, f)
+	awsS3DownloadResumesFlag.Add(v, f)
+	awsS3FallbackPrefixFlag.Add(v, f)
 }
 
 func AddStoreFlags(v *viper.Viper, f *pflag.FlagSet) {
 	dataDirFlag.Add(v, f)
+	storeFlatLayoutFlag.Add(v, f)
 	preflightDirFlag.Add(v, f)
+	preflightPruneFinalizedFlag.Add(v, f)
+	preflightPruneMaxAgeFlag.Add(v, f)
 	inputsDirFlag.Add(v, f)
+	inputsDirPartitioningFlag.Add(v, f)
 	contentTypeFlag.Add(v, f)
 	contentEncodingFlag.Add(v, f)
+	compressionLevelFlag.Add(v, f)
+	numberFormatFlag.Add(v, f)
+	jsonEncoderFlag.Add(v, f)
+	chunkSizeFlag.Add(v, f)
+	proofFormatFlag.Add(v, f)
+	deltaBaseFlag.Add(v, f)
+	uploadConcurrencyFlag.Add(v, f)
+	fanOutFlag.Add(v, f)
+	fanOutQuorumFlag.Add(v, f)
+	retryMaxAttemptsFlag.Add(v, f)
+	retryInitialIntervalFlag.Add(v, f)
+	retryMaxIntervalFlag.Add(v, f)
+	labelFlag.Add(v, f)
+}
+
+// envVars returns the environment variables of the configuration keys, by key
+func envVars() map[string]string {
+	vars := map[string]string{
+		"log.level":  "LOG_LEVEL", // Flags of the log package
+		"log.format": "LOG_FORMAT",
+	}
+	for _, flag := range []*spf13.StringFlag{
+		logPerBlockDirFlag, chainIDFlag, chainRPCURLFlag, chainRPCUserAgentFlag, verifyRPCURLFlag, chainRPCCacheTTLFlag, chainRPCSlowLogThresholdFlag,
+		chainRPCCallTimeoutFlag, chainRPCMissingTrieNodeRetriesFlag, chainRPCProofChunkSizeFlag, chainRPCHeaderCacheSizeFlag, chainRPCMaxResponseBytesFlag, chainRPCTLSCAFileFlag, chainRPCTLSCertFileFlag, chainRPCTLSKeyFileFlag, chainDataDirFlag,
+		recordRPCFlag, replayRPCFlag, chainGenesisFlag, dataDirFlag, preflightDirFlag, preflightPruneMaxAgeFlag, inputsDirFlag, inputsDirPartitioningFlag, contentTypeFlag, contentEncodingFlag, compressionLevelFlag, numberFormatFlag,
+		jsonEncoderFlag, chunkSizeFlag, proofFormatFlag, deltaBaseFlag, uploadConcurrencyFlag, fanOutQuorumFlag, retryMaxAttemptsFlag, retryInitialIntervalFlag, retryMaxIntervalFlag, assertEVMVersionFlag, preflightAncestorHeadersFlag,
+		preflightMemoryBudgetFlag, preflightProofDedupCacheSizeFlag, executionOverrideTimestampFlag, executionOverrideCoinbaseFlag, executeReportFlag, completenessReportFlag, accessListFlag, callGraphFlag, verifyConcurrencyFlag, stateLoadingFlag, metricsAddrFlag, maxConcurrentBlocksFlag, maxBlocksFlag, memoryLimitPreflightFlag, memoryLimitPrepareFlag,
+		memoryLimitExecuteFlag, memoryLimitPollIntervalFlag, seedFlag, sinkTypeFlag, sinkURLFlag, sinkTopicFlag, awsS3BucketFlag, awsS3DownloadResumesFlag,
+		awsS3BucketKeyPrefixFlag, awsS3AccessKeyFlag, awsS3SecretKeyFlag, awsS3EndpointFlag, awsS3SSEFlag,
+		awsS3KMSKeyIDFlag, awsS3ACLFlag, awsS3RegionFlag,
+	} {
+		vars[flag.ViperKey] = flag.Env
+	}
+	for _, flag := range []*spf13.BoolFlag{logPerBlockOnlyFlag, preflightSeedAccessListsFlag, preflightPruneFinalizedFlag, executionSkipRootVerificationFlag, executionWitnessCommitmentFlag, executionDualExecuteFlag, executionVerifySignaturesFlag, storeFlatLayoutFlag, awsS3UseDefaultCredentialsFlag, awsS3ForcePathStyleFlag} {
+		vars[flag.ViperKey] = flag.Env
+	}
+	for _, flag := range []*spf13.StringArrayFlag{configFileFlag, labelFlag, fanOutFlag, awsS3FallbackPrefixFlag} {
+		vars[flag.ViperKey] = flag.Env
+	}
+	return vars
 }
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secret values, as url.URL.Redacted does for passwords
+const redacted = "xxxxx"
+
+// Redacted returns a copy of the configuration with its secrets (AWS credentials and the passwords of the RPC and sink URLs) redacted
+func (config *Config) Redacted() *Config {
+	cfg := *config
+	cfg.ProverInputStore.S3.AWSProvider.Credentials.AccessKey = RedactSecret(cfg.ProverInputStore.S3.AWSProvider.Credentials.AccessKey)
+	cfg.ProverInputStore.S3.AWSProvider.Credentials.SecretKey = RedactSecret(cfg.ProverInputStore.S3.AWSProvider.Credentials.SecretKey)
+	cfg.Chain.RPC.URL = RedactURL(cfg.Chain.RPC.URL)
+	cfg.Chain.VerifyRPC.URL = RedactURL(cfg.Chain.VerifyRPC.URL)
+	cfg.Sink.URL = RedactURL(cfg.Sink.URL)
+	return &cfg
+}
+
+// RedactSecret returns the redacted secret, an empty secret is returned as is
+func RedactSecret(s string) string {
+	if s == "" {
+		return s
+	}
+	return redacted
+}
+
+// RedactURL returns the URL with its password redacted, s is returned as is if it is not a URL with a password
+func RedactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+	return u.Redacted()
+}
+
+// WriteYAML writes the configuration as a YAML configuration file, that can be loaded back with --config
+//
+// The list of configuration files is not written.
+func (config *Config) WriteYAML(w io.Writer) error {
+	node, err := yamlNode(reflect.ValueOf(config).Elem(), "")
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return fmt.Errorf("failed to encode YAML configuration: %v", err)
+	}
+	return enc.Close()
+}
+
+func yamlNode(v reflect.Value, path string) (*yaml.Node, error) {
+	node := new(yaml.Node)
+	if v.Kind() != reflect.Struct {
+		if err := node.Encode(v.Interface()); err != nil {
+			return nil, fmt.Errorf("failed to encode %v: %v", path, err)
+		}
+		return node, nil
+	}
+
+	node.Kind = yaml.MappingNode
+	for i := 0; i < v.NumField(); i++ {
+		name, key := fieldKey(v.Type().Field(i), path)
+		if strings.HasPrefix(name, "_") || key == "config" {
+			continue // Extra values and configuration files
+		}
+		value, err := yamlNode(v.Field(i), key)
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	}
+	return node, nil
+}
+
+// WriteEnv writes the configuration as KEY=value lines of the environment variables of its flags
+//
+// Empty values are not written, as empty environment variables are ignored. Labels of the configuration file are merged
+// into LABEL and the list of configuration files is not written.
+func (config *Config) WriteEnv(w io.Writer) error {
+	vars := envVars()
+
+	var lines []string
+	err := walkKeys(reflect.ValueOf(config).Elem(), "", func(key string, v reflect.Value) error {
+		var value string
+		switch key {
+		case "config":
+			return nil
+		case "labels":
+			return nil // Merged into label
+		case "label":
+			labels := make([]string, 0, len(config.Labels)+len(config.Label))
+			for k, v := range config.Labels {
+				labels = append(labels, k+"="+v)
+			}
+			slices.Sort(labels)
+			value = strings.Join(append(labels, config.Label...), ",")
+		default:
+			switch v.Kind() {
+			case reflect.String:
+				value = v.String()
+			case reflect.Bool:
+				value = strconv.FormatBool(v.Bool())
+			case reflect.Slice:
+				value = strings.Join(v.Interface().([]string), ",")
+			default:
+				return fmt.Errorf("unsupported type %v of %v", v.Type(), key)
+			}
+		}
+
+		if value == "" {
+			return nil
+		}
+		env, ok := vars[key]
+		if !ok {
+			return fmt.Errorf("no environment variable for %v", key)
+		}
+		lines = append(lines, env+"="+value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkKeys calls fn with the key (e.g. "chain.rpc.url") and the value of every leaf of the configuration
+func walkKeys(v reflect.Value, path string, fn func(key string, v reflect.Value) error) error {
+	if v.Kind() != reflect.Struct {
+		return fn(path, v)
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		name, key := fieldKey(v.Type().Field(i), path)
+		if strings.HasPrefix(name, "_") {
+			continue // e.g. extra values
+		}
+		if err := walkKeys(v.Field(i), key, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldKey returns the mapstructure name of the field and its key within path
+func fieldKey(field reflect.StructField, path string) (name, key string) {
+	name, _, _ = strings.Cut(field.Tag.Get("mapstructure"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	if path == "" {
+		return name, name
+	}
+	return name, path + "." + name
+}
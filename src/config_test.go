@@ -0,0 +1,27 @@
+package src
+
+import (
+	"testing"
+
+	"github.com/kkrt-labs/zk-pig/src/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreDir(t *testing.T) {
+	for _, test := range []struct {
+		chainID string
+		flat    bool
+		dir     string
+		want    string
+	}{
+		{"", false, "inputs", "data/default/inputs"},
+		{"1", false, "inputs", "data/1/inputs"},
+		{"1", true, "inputs", "data/inputs"},
+		{"", false, "inputs/{chainID}", "data/inputs/default"},
+		{"1", true, "inputs/{chainID}", "data/inputs/1"},
+	} {
+		gcfg := &config.Config{DataDir: "data", FlatLayout: test.flat}
+		gcfg.Chain.ID = test.chainID
+		assert.Equal(t, test.want, StoreDir(gcfg, test.dir), "chain ID %q, dir %q (flat %v)", test.chainID, test.dir, test.flat)
+	}
+}
@@ -0,0 +1,59 @@
+package src
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	jsonrpcmrgd "github.com/kkrt-labs/go-utils/jsonrpc/merged"
+	"github.com/kkrt-labs/go-utils/log"
+	"github.com/kkrt-labs/go-utils/svc"
+	"github.com/kkrt-labs/zk-pig/src/generator"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/kkrt-labs/zk-pig/src/rpc"
+	"go.uber.org/zap"
+)
+
+// crossCheck spot-checks the stateless execution of a block against the reference node at the given URL
+// Every divergence is logged and an error is returned if any divergence is found.
+func (s *Service) crossCheck(ctx context.Context, url string, inputs *input.ProverInput, res *core.ProcessResult) error {
+	cfg := (&rpc.Config{Config: jsonrpcmrgd.Config{Addr: url}}).SetDefault()
+	if s.cfg.Chain.RPC != nil {
+		cfg.UserAgent = s.cfg.Chain.RPC.UserAgent
+	}
+
+	remote, err := rpc.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create cross-check RPC client: %v", err)
+	}
+
+	if runnable, ok := remote.(svc.Runnable); ok {
+		if err := runnable.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start cross-check RPC client: %v", err)
+		}
+		defer runnable.Stop(ctx) //nolint:errcheck // best effort
+	}
+
+	remote = jsonrpc.WithLog()(remote)
+	remote = rpc.WithRequestID()(remote)
+	remote = jsonrpc.WithTags("cross-check")(remote)
+	remote = jsonrpc.WithVersion("2.0")(remote)
+	remote = jsonrpc.WithIncrementalID()(remote)
+
+	divergences, err := generator.NewCrossChecker(remote, url).CrossCheck(ctx, inputs, res)
+	if err != nil {
+		return fmt.Errorf("failed to cross-check block: %v", err)
+	}
+
+	for _, d := range divergences {
+		log.LoggerFromContext(ctx).Warn("Divergence with reference node", zap.String("divergence", d.String()))
+	}
+	if len(divergences) > 0 {
+		return fmt.Errorf("cross-check found %d divergence(s) with reference node %v", len(divergences), url)
+	}
+
+	log.LoggerFromContext(ctx).Info("Cross-check against reference node succeeded")
+
+	return nil
+}
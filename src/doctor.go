@@ -0,0 +1,243 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	store "github.com/kkrt-labs/go-utils/store"
+	s3store "github.com/kkrt-labs/go-utils/store/s3"
+	"github.com/kkrt-labs/go-utils/svc"
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// archiveCheckDepth is how far behind the head block state is requested to check the node is an archive node
+	// (non-archive geth nodes only keep the state of the last 128 blocks)
+	archiveCheckDepth = 1024
+	// maxClockSkew is the maximum tolerated difference between the local clock and the head block timestamp
+	maxClockSkew = 2 * time.Minute
+	// minFreeDiskSpace is the minimum free disk space on the data directory
+	minFreeDiskSpace = 1 << 30 // 1 GiB
+
+	doctorProbeKey = "zkpig-doctor-probe"
+)
+
+// CheckResult is the result of a diagnostic check
+type CheckResult struct {
+	Name string
+	Err  error
+	Hint string // Remediation hint, only relevant on failure
+}
+
+// OK returns whether the check succeeded
+func (r *CheckResult) OK() bool {
+	return r.Err == nil
+}
+
+// Doctor runs a battery of diagnostic checks on the service configuration (chain data source, stores, disk space, clock)
+// It does not require the service to be started and it returns the result of every check that could be run.
+func (s *Service) Doctor(ctx context.Context) []*CheckResult {
+	results := s.doctorChain(ctx)
+	results = append(results, s.doctorStores(ctx)...)
+	results = append(results, s.doctorDiskSpace())
+	return results
+}
+
+func (s *Service) doctorChain(ctx context.Context) []*CheckResult {
+	reachable := &CheckResult{Name: "Chain data source reachable"}
+	if s.ethrpc == nil {
+		reachable.Err = fmt.Errorf("no chain data source configured")
+		reachable.Hint = "Set --chain-rpc-url to the JSON-RPC URL of an Ethereum Execution Layer node (or --chain-datadir to a local geth data directory)"
+		return []*CheckResult{reachable}
+	}
+
+	if s.chaindata != nil {
+		if err := s.chaindata.Start(ctx); err != nil {
+			reachable.Err = err
+			reachable.Hint = "Check --chain-datadir points to a geth data directory and that the geth node is stopped"
+			return []*CheckResult{reachable}
+		}
+		defer s.chaindata.Stop(ctx) //nolint:errcheck // best effort
+	} else if runnable, ok := s.remote.(svc.Runnable); ok {
+		if err := runnable.Start(ctx); err != nil {
+			reachable.Err = err
+			reachable.Hint = "Check --chain-rpc-url is correct and the node is up (ws:// and wss:// URLs require a WebSocket endpoint)"
+			return []*CheckResult{reachable}
+		}
+		defer runnable.Stop(ctx) //nolint:errcheck // best effort
+	}
+
+	chainID, err := s.ethrpc.ChainID(ctx)
+	if err != nil {
+		reachable.Err = err
+		reachable.Hint = "Check --chain-rpc-url is correct, the node is up and reachable from this machine, and TLS settings (--chain-rpc-tls-*) if any"
+		return []*CheckResult{reachable}
+	}
+	results := []*CheckResult{reachable}
+
+	chainIDMatch := &CheckResult{Name: "Chain ID matches"}
+	if s.cfg.Chain.ID != nil && s.cfg.Chain.ID.Cmp(chainID) != 0 {
+		chainIDMatch.Err = fmt.Errorf("configured chain ID %v but node is on chain %v", s.cfg.Chain.ID, chainID)
+		chainIDMatch.Hint = fmt.Sprintf("Set --chain-id %v or point --chain-rpc-url to a node of chain %v", chainID, s.cfg.Chain.ID)
+	}
+	results = append(results, chainIDMatch)
+
+	head, err := s.ethrpc.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return append(results, &CheckResult{Name: "Head block available", Err: err, Hint: "Check the node is synced"})
+	}
+
+	archive := &CheckResult{Name: "Archive state available"}
+	if head.Number.Uint64() > archiveCheckDepth {
+		old := new(big.Int).Sub(head.Number, big.NewInt(archiveCheckDepth))
+		if _, err := s.ethrpc.GetProof(ctx, gethcommon.Address{}, nil, old); err != nil {
+			archive.Err = fmt.Errorf("state at block %v (head - %d) is not available: %v", old, archiveCheckDepth, err)
+			archive.Hint = "Generating prover inputs for blocks older than the node's state history requires an archive node (e.g. geth --gcmode archive)"
+		}
+	}
+	results = append(results, archive)
+
+	clock := &CheckResult{Name: "Clock in sync with chain head"}
+	if skew := time.Since(time.Unix(int64(head.Time), 0)); skew > maxClockSkew || skew < -maxClockSkew {
+		clock.Err = fmt.Errorf("head block %v is %v away from local time", head.Number, skew.Round(time.Second))
+		clock.Hint = "Check the local clock is synchronized (NTP) and the node is fully synced"
+	}
+	results = append(results, clock)
+
+	return results
+}
+
+func (s *Service) doctorStores(ctx context.Context) []*CheckResult {
+	var results []*CheckResult
+
+	if fileCfg := s.cfg.PreflightDataStore.FileConfig; fileCfg != nil {
+		results = append(results, doctorDir("Preflight data directory writable", fileCfg.DataDir))
+	}
+
+	if fileCfg := s.cfg.ProverInputStore.StoreConfig.FileConfig; fileCfg != nil {
+		results = append(results, doctorDir("Prover input directory writable", fileCfg.DataDir))
+	}
+
+	if s3Cfg := s.cfg.ProverInputStore.StoreConfig.S3Config; s3Cfg != nil {
+		results = append(results, s.doctorS3(ctx, s3Cfg))
+	}
+
+	return results
+}
+
+// doctorDir checks the directory can be created and files can be written, read and deleted in it
+func doctorDir(name, dir string) *CheckResult {
+	res := &CheckResult{Name: name, Hint: fmt.Sprintf("Check the permissions of %v or change --data-dir", dir)}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		res.Err = err
+		return res
+	}
+
+	f, err := os.CreateTemp(dir, doctorProbeKey)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	f.Close()
+
+	if _, err = os.ReadFile(f.Name()); err == nil {
+		err = os.Remove(f.Name())
+	}
+	res.Err = err
+
+	return res
+}
+
+// doctorS3 checks a probe object can be written to, listed in, read from and deleted from the S3 bucket
+func (s *Service) doctorS3(ctx context.Context, cfg *s3store.Config) *CheckResult {
+	res := &CheckResult{
+		Name: "Prover input S3 bucket writable",
+		Hint: fmt.Sprintf("Check bucket %q exists in region %q and credentials allow s3:PutObject, s3:ListBucket, s3:GetObject and s3:DeleteObject", cfg.Bucket, cfg.ProviderConfig.Region),
+	}
+
+	s3, err := inputstore.NewS3Store(cfg, &s.cfg.ProverInputStore.S3Client)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	chainID := "default"
+	if s.cfg.Chain.ID != nil {
+		chainID = s.cfg.Chain.ID.String()
+	}
+	headers := &store.Headers{KeyValue: map[string]string{"chainID": chainID}}
+	probe := []byte(time.Now().UTC().Format(time.RFC3339))
+
+	if err = s3.Store(ctx, doctorProbeKey, bytes.NewReader(probe), headers); err != nil {
+		res.Err = fmt.Errorf("write probe: %v", err)
+		return res
+	}
+	// Delete the probe even if a later step fails, so no probe object is left in the bucket
+	defer func() {
+		if err := s3.Delete(ctx, doctorProbeKey, headers); err != nil && res.Err == nil {
+			res.Err = fmt.Errorf("delete probe: %v", err)
+		}
+	}()
+
+	keys, err := s3.List(ctx, doctorProbeKey, headers)
+	if err != nil {
+		res.Err = fmt.Errorf("list probe: %v", err)
+		return res
+	}
+	if !slices.Contains(keys, doctorProbeKey) {
+		res.Err = fmt.Errorf("list probe: probe object not listed")
+		return res
+	}
+
+	reader, err := s3.Load(ctx, doctorProbeKey, headers)
+	if err != nil {
+		res.Err = fmt.Errorf("read probe: %v", err)
+		return res
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	b, err := io.ReadAll(reader)
+	if err == nil && !bytes.Equal(b, probe) {
+		err = fmt.Errorf("probe object read back does not match written content")
+	}
+	if err != nil {
+		res.Err = fmt.Errorf("read probe: %v", err)
+	}
+
+	return res
+}
+
+func (s *Service) doctorDiskSpace() *CheckResult {
+	res := &CheckResult{
+		Name: "Free disk space",
+		Hint: fmt.Sprintf("Free some disk space on the device of %v or change --data-dir", s.cfg.DataDir),
+	}
+
+	if err := os.MkdirAll(s.cfg.DataDir, 0o755); err != nil {
+		res.Err = err
+		return res
+	}
+
+	var stat unix.Statfs_t
+	if err := unix.Statfs(s.cfg.DataDir, &stat); err != nil {
+		res.Err = err
+		return res
+	}
+
+	//nolint:unconvert // field types differ across platforms
+	if free := uint64(stat.Bavail) * uint64(stat.Bsize); free < minFreeDiskSpace {
+		res.Err = fmt.Errorf("only %d MiB available on %v", free>>20, s.cfg.DataDir)
+	}
+
+	return res
+}
@@ -0,0 +1,150 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	aws "github.com/kkrt-labs/go-utils/aws"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	jsonrpcmrgd "github.com/kkrt-labs/go-utils/jsonrpc/merged"
+	s3store "github.com/kkrt-labs/go-utils/store/s3"
+	"github.com/kkrt-labs/zk-pig/src/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDoctorTestNode starts a JSON-RPC server of a node on chain 1 whose head is block 2000 with timestamp headTime,
+// serving state proofs only if archive is set
+func newDoctorTestNode(t *testing.T, headTime time.Time, archive bool) *httptest.Server {
+	head := &gethtypes.Header{Number: big.NewInt(2000), Time: uint64(headTime.Unix()), Difficulty: big.NewInt(0)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg jsonrpc.RequestMsg
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID}
+		switch {
+		case msg.Method == "eth_chainId":
+			resp["result"] = "0x1"
+		case msg.Method == "eth_getBlockByNumber":
+			resp["result"] = head
+		case msg.Method == "eth_getProof" && archive:
+			resp["result"] = map[string]interface{}{"address": "0x0000000000000000000000000000000000000000", "balance": "0x0", "nonce": "0x0", "storageHash": gethtypes.EmptyRootHash, "codeHash": gethtypes.EmptyCodeHash, "accountProof": []string{}, "storageProof": []interface{}{}}
+		default:
+			resp["error"] = map[string]interface{}{"code": -32000, "message": "required historical state unavailable"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newDoctorTestService(t *testing.T, url string, chainID *big.Int) *Service {
+	cfg := newTestConfig(t, ChainConfig{ID: chainID, RPC: (&rpc.Config{Config: jsonrpcmrgd.Config{Addr: url}}).SetDefault()})
+	s, err := New(cfg)
+	require.NoError(t, err)
+	return s
+}
+
+// failedChecks returns the error of every failed check by name
+func failedChecks(results []*CheckResult) map[string]string {
+	failed := make(map[string]string)
+	for _, res := range results {
+		if !res.OK() {
+			failed[res.Name] = res.Err.Error()
+		}
+	}
+	return failed
+}
+
+func TestDoctorChain(t *testing.T) {
+	t.Run("healthy", func(t *testing.T) {
+		s := newDoctorTestService(t, newDoctorTestNode(t, time.Now(), true).URL, big.NewInt(1))
+		results := s.doctorChain(context.Background())
+		require.Len(t, results, 4)
+		assert.Empty(t, failedChecks(results))
+	})
+
+	t.Run("chain ID mismatch", func(t *testing.T) {
+		s := newDoctorTestService(t, newDoctorTestNode(t, time.Now(), true).URL, big.NewInt(2))
+		failed := failedChecks(s.doctorChain(context.Background()))
+		assert.Equal(t, map[string]string{"Chain ID matches": "configured chain ID 2 but node is on chain 1"}, failed)
+	})
+
+	t.Run("non-archive node", func(t *testing.T) {
+		s := newDoctorTestService(t, newDoctorTestNode(t, time.Now(), false).URL, big.NewInt(1))
+		failed := failedChecks(s.doctorChain(context.Background()))
+		require.Len(t, failed, 1)
+		assert.Contains(t, failed["Archive state available"], "state at block 976 (head - 1024) is not available")
+	})
+
+	t.Run("clock skew", func(t *testing.T) {
+		s := newDoctorTestService(t, newDoctorTestNode(t, time.Now().Add(-time.Hour), true).URL, big.NewInt(1))
+		failed := failedChecks(s.doctorChain(context.Background()))
+		require.Len(t, failed, 1)
+		assert.Contains(t, failed["Clock in sync with chain head"], "head block 2000 is 1h0m")
+	})
+}
+
+func TestDoctorS3DeletesProbe(t *testing.T) {
+	// Minimal S3-compatible server keeping objects in memory by request path, recording the operations
+	var (
+		mu      sync.Mutex
+		objects = make(map[string][]byte)
+		ops     []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPut:
+			ops = append(ops, "put")
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+			ops = append(ops, "list")
+			_, _ = fmt.Fprint(w, "<ListBucketResult><Name>bucket</Name><IsTruncated>false</IsTruncated>")
+			for path := range objects {
+				if key := strings.TrimPrefix(path, "/bucket/"); strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+					_, _ = fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", key)
+				}
+			}
+			_, _ = fmt.Fprint(w, "</ListBucketResult>")
+		case r.Method == http.MethodGet:
+			ops = append(ops, "get")
+			_, _ = w.Write(objects[r.URL.Path])
+		case r.Method == http.MethodDelete:
+			ops = append(ops, "delete")
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	s, err := New(newTestConfig(t, ChainConfig{ID: big.NewInt(1)}))
+	require.NoError(t, err)
+	s.cfg.ProverInputStore.S3Client.Endpoint = srv.URL
+	s.cfg.ProverInputStore.S3Client.ForcePathStyle = true
+	res := s.doctorS3(context.Background(), &s3store.Config{
+		ProviderConfig: &aws.ProviderConfig{
+			Region:      "us-east-1",
+			Credentials: &aws.CredentialsConfig{AccessKey: "access-key", SecretKey: "secret-key"},
+		},
+		Bucket:    "bucket",
+		KeyPrefix: "prefix",
+	})
+	require.NoError(t, res.Err)
+	assert.Equal(t, []string{"put", "list", "get", "delete"}, ops)
+	assert.Empty(t, objects, "the probe object is deleted")
+}
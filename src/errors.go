@@ -0,0 +1,139 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"regexp"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// ErrorType classifies the failures of a block generation, to let orchestrators handle them without parsing messages
+type ErrorType string
+
+const (
+	ErrorTypeUnknown          ErrorType = "unknown"           // Not classified, e.g. an invalid configuration
+	ErrorTypeCanceled         ErrorType = "canceled"          // The run was interrupted
+	ErrorTypeChainData        ErrorType = "chain-data"        // The block or its pre-state could not be fetched, or the block not be pre-executed on it
+	ErrorTypeStateUnavailable ErrorType = "state-unavailable" // The node does not keep the pre-state of the block (e.g. not an archive node), retrying on it does not help
+	ErrorTypeStore            ErrorType = "store"             // Preflight data or a prover input could not be stored or loaded
+	ErrorTypePrepare          ErrorType = "prepare"           // The prover input could not be prepared from the preflight data
+	ErrorTypeExecution        ErrorType = "execution"         // The block could not be executed on its prover input
+	ErrorTypeValidation       ErrorType = "validation"        // The executed block does not match its header (state root, receipts root, gas used...)
+	ErrorTypePublish          ErrorType = "publish"           // The generated prover input could not be published to the sink
+	ErrorTypeMemoryLimit      ErrorType = "memory-limit"      // The phase was aborted as the memory in use exceeded its limit (see ErrMemoryLimit)
+)
+
+// BlockError is the error of a phase of the generation of a block
+//
+// Its message is the one of the underlying error, so wrapping an error in a BlockError does not change the logs.
+type BlockError struct {
+	Type        ErrorType
+	Phase       Phase
+	BlockNumber *big.Int         // Optional, nil if unknown
+	BlockHash   *gethcommon.Hash // Optional, nil if unknown
+	Err         error
+}
+
+func (e *BlockError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BlockError) Unwrap() error {
+	return e.Err
+}
+
+// newBlockError returns a BlockError of type typ, or of type ErrorTypeCanceled if ctx is done
+func newBlockError(ctx context.Context, typ ErrorType, phase Phase, blockNumber *big.Int, blockHash *gethcommon.Hash, err error) *BlockError {
+	if ctx.Err() != nil {
+		typ = ErrorTypeCanceled
+	}
+	return &BlockError{Type: typ, Phase: phase, BlockNumber: blockNumber, BlockHash: blockHash, Err: err}
+}
+
+// RootMismatch is a root (or hash) of a block header which does not match the one computed by the execution
+type RootMismatch struct {
+	Expected string `json:"expected"` // Value of the block header
+	Computed string `json:"computed"` // Value computed by the execution
+}
+
+// rootMismatchPatterns match the go-ethereum block validation errors (see core.BlockValidator), which are not typed
+var rootMismatchPatterns = map[string]*regexp.Regexp{
+	"stateRoot":        regexp.MustCompile(`invalid merkle root \(remote: (?:0x)?([0-9a-f]+) local: (?:0x)?([0-9a-f]+)\)`),
+	"receiptsRoot":     regexp.MustCompile(`invalid receipt root hash \(remote: (?:0x)?([0-9a-f]+) local: (?:0x)?([0-9a-f]+)\)`),
+	"requestsHash":     regexp.MustCompile(`invalid requests hash \(remote: (?:0x)?([0-9a-f]+) local: (?:0x)?([0-9a-f]+)\)`),
+	"transactionsRoot": regexp.MustCompile(`transaction root hash mismatch \(header value (?:0x)?([0-9a-f]+), calculated (?:0x)?([0-9a-f]+)\)`),
+	"unclesHash":       regexp.MustCompile(`uncle root hash mismatch \(header value (?:0x)?([0-9a-f]+), calculated (?:0x)?([0-9a-f]+)\)`),
+	"withdrawalsRoot":  regexp.MustCompile(`withdrawals root hash mismatch \(header value (?:0x)?([0-9a-f]+), calculated (?:0x)?([0-9a-f]+)\)`),
+}
+
+// validationPatterns match the other go-ethereum block validation errors
+var validationPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`invalid gas used \(remote: \d+ local: \d+\)`),
+	regexp.MustCompile(`invalid bloom \(remote: `),
+	regexp.MustCompile(`blob gas used mismatch`),
+}
+
+// rootMismatches returns the root mismatches reported in an error message
+func rootMismatches(msg string) map[string]*RootMismatch {
+	var roots map[string]*RootMismatch
+	for name, pattern := range rootMismatchPatterns {
+		if m := pattern.FindStringSubmatch(msg); m != nil {
+			if roots == nil {
+				roots = make(map[string]*RootMismatch)
+			}
+			roots[name] = &RootMismatch{Expected: "0x" + m[1], Computed: "0x" + m[2]}
+		}
+	}
+	return roots
+}
+
+// isValidationError returns true if msg reports a mismatch between an executed block and its header
+func isValidationError(msg string) bool {
+	if len(rootMismatches(msg)) > 0 {
+		return true
+	}
+	for _, pattern := range validationPatterns {
+		if pattern.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorReport is the machine-readable representation of the error of a run (see --error-format)
+type ErrorReport struct {
+	Type        ErrorType                `json:"type"`
+	Message     string                   `json:"message"`
+	Phase       Phase                    `json:"phase,omitempty"`
+	BlockNumber *uint64                  `json:"blockNumber,omitempty"`
+	BlockHash   string                   `json:"blockHash,omitempty"`
+	Roots       map[string]*RootMismatch `json:"roots,omitempty"` // Mismatching roots of a validation error, by header field
+}
+
+// NewErrorReport returns the report of err, classified by the first BlockError it wraps
+func NewErrorReport(err error) *ErrorReport {
+	report := &ErrorReport{
+		Type:    ErrorTypeUnknown,
+		Message: err.Error(),
+		Roots:   rootMismatches(err.Error()),
+	}
+
+	var blockErr *BlockError
+	switch {
+	case errors.As(err, &blockErr):
+		report.Type = blockErr.Type
+		report.Phase = blockErr.Phase
+		if blockErr.BlockNumber != nil {
+			n := blockErr.BlockNumber.Uint64()
+			report.BlockNumber = &n
+		}
+		if blockErr.BlockHash != nil {
+			report.BlockHash = blockErr.BlockHash.Hex()
+		}
+	case errors.Is(err, context.Canceled):
+		report.Type = ErrorTypeCanceled
+	}
+	return report
+}
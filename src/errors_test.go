@@ -0,0 +1,76 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewErrorReport(t *testing.T) {
+	hash := gethcommon.HexToHash(testBlockHash)
+	for _, tc := range []struct {
+		name   string
+		err    error
+		report string // JSON object
+	}{
+		{
+			name: "block error",
+			err: fmt.Errorf("block 21465322: %w", &BlockError{
+				Type:        ErrorTypeStore,
+				Phase:       PhasePrepare,
+				BlockNumber: big.NewInt(21465322),
+				BlockHash:   &hash,
+				Err:         fmt.Errorf("failed to store provable inputs: access denied"),
+			}),
+			report: `{"type":"store","message":"block 21465322: failed to store provable inputs: access denied","phase":"prepare","blockNumber":21465322,"blockHash":"` + testBlockHash + `"}`,
+		},
+		{
+			name:   "block error without number nor hash",
+			err:    &BlockError{Type: ErrorTypeChainData, Phase: PhasePreflight, Err: fmt.Errorf("failed to execute preflight: not found")},
+			report: `{"type":"chain-data","message":"failed to execute preflight: not found","phase":"preflight"}`,
+		},
+		{
+			name: "validation error",
+			err: &BlockError{
+				Type:        ErrorTypeValidation,
+				Phase:       PhaseExecute,
+				BlockNumber: big.NewInt(2),
+				Err:         fmt.Errorf("invalid merkle root (remote: 0xaa local: 0xbb), invalid receipt root hash (remote: cc local: dd)"),
+			},
+			report: `{"type":"validation","message":"invalid merkle root (remote: 0xaa local: 0xbb), invalid receipt root hash (remote: cc local: dd)","phase":"execute","blockNumber":2,` +
+				`"roots":{"stateRoot":{"expected":"0xaa","computed":"0xbb"},"receiptsRoot":{"expected":"0xcc","computed":"0xdd"}}}`,
+		},
+		{
+			name:   "plain error",
+			err:    fmt.Errorf("invalid block range: 3 > 2"),
+			report: `{"type":"unknown","message":"invalid block range: 3 > 2"}`,
+		},
+		{
+			name:   "canceled",
+			err:    fmt.Errorf("failed to fetch block: %w", context.Canceled),
+			report: `{"type":"canceled","message":"failed to fetch block: context canceled"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(NewErrorReport(tc.err))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.report, string(b))
+		})
+	}
+}
+
+func TestNewBlockError(t *testing.T) {
+	err := newBlockError(context.Background(), ErrorTypeExecution, PhaseExecute, big.NewInt(2), nil, fmt.Errorf("failed"))
+	assert.Equal(t, ErrorTypeExecution, err.Type)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = newBlockError(ctx, ErrorTypeExecution, PhaseExecute, big.NewInt(2), nil, fmt.Errorf("failed"))
+	assert.Equal(t, ErrorTypeCanceled, err.Type, "errors of an interrupted run are reported as canceled")
+}
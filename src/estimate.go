@@ -0,0 +1,183 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// EstimateOptions are the options for estimating the RPC cost of a range.
+type EstimateOptions struct {
+	// Samples is the number of blocks, evenly spread over the range, that are generated to measure RPC calls (defaults to 3)
+	Samples int
+
+	// PricePerCall is an optional price of a JSON-RPC call, to estimate the cost of the range
+	PricePerCall float64
+}
+
+// RPCEstimate is an estimation of the JSON-RPC calls needed to generate the prover inputs of a range,
+// extrapolated from the calls (including retries) measured on sample blocks.
+type RPCEstimate struct {
+	From            uint64             `json:"from"`
+	To              uint64             `json:"to"`
+	Sampled         []uint64           `json:"sampled"`         // Blocks used to measure calls
+	CallsPerBlock   map[Phase]float64  `json:"callsPerBlock"`   // Average number of calls per sampled block, per phase (preflight includes prepare)
+	MethodsPerBlock map[string]float64 `json:"methodsPerBlock"` // Average number of calls per sampled block, per method
+	TotalCalls      uint64             `json:"totalCalls"`      // Estimated number of calls for the range
+	PricePerCall    float64            `json:"pricePerCall,omitempty"`
+	Cost            float64            `json:"cost,omitempty"` // Estimated cost of the range (TotalCalls x PricePerCall)
+}
+
+// defaultEstimateSamples is the default number of blocks sampled to estimate the RPC cost of a range
+const defaultEstimateSamples = 3
+
+// EstimateRPC generates the prover inputs of a few blocks of the inclusive range [from, to], measuring the JSON-RPC calls
+// of every phase, and extrapolates the number of calls (and the cost) of generating the full range.
+//
+// Sampled blocks are generated as with Generate, so their preflight data and prover inputs are stored.
+func (s *Service) EstimateRPC(ctx context.Context, from, to *big.Int, opts *EstimateOptions) (*RPCEstimate, error) {
+	if from.Cmp(to) > 0 {
+		return nil, fmt.Errorf("invalid block range: %v > %v", from, to)
+	}
+	if s.remote == nil {
+		return nil, fmt.Errorf("estimating RPC calls requires a remote RPC")
+	}
+	if opts == nil {
+		opts = &EstimateOptions{}
+	}
+
+	estimate := &RPCEstimate{
+		From:            from.Uint64(),
+		To:              to.Uint64(),
+		Sampled:         sampleBlocks(from.Uint64(), to.Uint64(), opts.Samples),
+		CallsPerBlock:   make(map[Phase]float64),
+		MethodsPerBlock: make(map[string]float64),
+		PricePerCall:    opts.PricePerCall,
+	}
+
+	methodsStart := s.rpcCalls.CallsByMethod()
+	for _, n := range estimate.Sampled {
+		log.LoggerFromContext(ctx).Info("Sampling block", zap.Uint64("block.number", n))
+		if err := s.sampleBlock(ctx, n, estimate); err != nil {
+			return nil, fmt.Errorf("failed to sample block %d: %v", n, err)
+		}
+	}
+
+	methodCalls := make(map[string]uint64)
+	for method, calls := range s.rpcCalls.CallsByMethod() {
+		methodCalls[method] = calls - methodsStart[method]
+	}
+	estimate.extrapolate(methodCalls)
+
+	return estimate, nil
+}
+
+// extrapolate averages the calls of every phase summed over the sampled blocks, and the calls of every method over
+// all sampled blocks given by methodCalls, and extrapolates the total calls and cost of the range from them
+func (e *RPCEstimate) extrapolate(methodCalls map[string]uint64) {
+	samples := float64(len(e.Sampled))
+	var callsPerBlock float64
+	for phase := range e.CallsPerBlock {
+		e.CallsPerBlock[phase] /= samples
+		callsPerBlock += e.CallsPerBlock[phase]
+	}
+	for method, calls := range methodCalls {
+		if calls > 0 {
+			e.MethodsPerBlock[method] = float64(calls) / samples
+		}
+	}
+
+	blocks := e.To - e.From + 1
+	e.TotalCalls = uint64(callsPerBlock*float64(blocks) + 0.5)
+	e.Cost = float64(e.TotalCalls) * e.PricePerCall
+}
+
+// sampleBlocks returns up to samples blocks evenly spread over the inclusive range [from, to], including both ends
+func sampleBlocks(from, to uint64, samples int) []uint64 {
+	if samples <= 0 {
+		samples = defaultEstimateSamples
+	}
+	if count := to - from + 1; uint64(samples) >= count {
+		blocks := make([]uint64, 0, count)
+		for n := from; n <= to; n++ {
+			blocks = append(blocks, n)
+		}
+		return blocks
+	}
+	if samples == 1 {
+		return []uint64{from}
+	}
+
+	blocks := make([]uint64, 0, samples)
+	for i := 0; i < samples; i++ {
+		blocks = append(blocks, from+uint64(i)*(to-from)/uint64(samples-1))
+	}
+	return slices.Compact(blocks)
+}
+
+// WriteFile writes the estimate as JSON to the given path.
+func (e *RPCEstimate) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create estimate directory: %v", err)
+	}
+
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode RPC estimate: %v", err)
+	}
+
+	return os.WriteFile(path, b, 0o600)
+}
+
+// String returns a human-readable estimate.
+func (e *RPCEstimate) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "RPC estimate for blocks %d-%d (%d blocks, sampled %v)\n", e.From, e.To, e.To-e.From+1, e.Sampled)
+	fmt.Fprintf(&b, "  Preflight:     %.1f calls/block (including prepare)\n", e.CallsPerBlock[PhasePreflight])
+	fmt.Fprintf(&b, "  Execute:       %.1f calls/block\n", e.CallsPerBlock[PhaseExecute])
+	methods := make([]string, 0, len(e.MethodsPerBlock))
+	for method := range e.MethodsPerBlock {
+		methods = append(methods, method)
+	}
+	slices.Sort(methods)
+	for _, method := range methods {
+		fmt.Fprintf(&b, "  - %s: %.1f calls/block\n", method, e.MethodsPerBlock[method])
+	}
+	fmt.Fprintf(&b, "  Total calls:   %d\n", e.TotalCalls)
+	if e.PricePerCall > 0 {
+		fmt.Fprintf(&b, "  Cost:          %.2f (%g per call)\n", e.Cost, e.PricePerCall)
+	}
+	return b.String()
+}
+
+// sampleBlock generates the prover input of a sampled block, adding the JSON-RPC calls of every phase to the estimate
+func (s *Service) sampleBlock(ctx context.Context, n uint64, estimate *RPCEstimate) error {
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return err
+	}
+	defer s.blocks.Release()
+
+	// Phases run as in Generate, prepare runs offline on the preflight data so its calls are the ones of preflight
+	calls := s.rpcCalls.Calls()
+	block, err := s.preflightAndPrepare(ctx, new(big.Int).SetUint64(n))
+	if err != nil {
+		return err
+	}
+	estimate.CallsPerBlock[PhasePreflight] += float64(s.rpcCalls.Calls() - calls)
+
+	calls = s.rpcCalls.Calls()
+	if err := s.execute(ctx, block.number); err != nil {
+		return err
+	}
+	estimate.CallsPerBlock[PhaseExecute] += float64(s.rpcCalls.Calls() - calls)
+
+	return nil
+}
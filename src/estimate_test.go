@@ -0,0 +1,88 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleBlocks(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		from, to uint64
+		samples  int
+		blocks   []uint64
+	}{
+		{name: "default samples", from: 100, to: 200, blocks: []uint64{100, 150, 200}},
+		{name: "evenly spread", from: 100, to: 200, samples: 5, blocks: []uint64{100, 125, 150, 175, 200}},
+		{name: "single sample", from: 100, to: 200, samples: 1, blocks: []uint64{100}},
+		{name: "more samples than blocks", from: 100, to: 102, samples: 5, blocks: []uint64{100, 101, 102}},
+		{name: "single block", from: 100, to: 100, blocks: []uint64{100}},
+		{name: "rounded down", from: 0, to: 10, samples: 4, blocks: []uint64{0, 3, 6, 10}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.blocks, sampleBlocks(tc.from, tc.to, tc.samples))
+		})
+	}
+}
+
+func TestRPCEstimateExtrapolate(t *testing.T) {
+	// 3 blocks sampled over a range of 1000 blocks, with 31 preflight calls and 3 execute calls in total
+	estimate := &RPCEstimate{
+		From:            1001,
+		To:              2000,
+		Sampled:         []uint64{1001, 1500, 2000},
+		CallsPerBlock:   map[Phase]float64{PhasePreflight: 31, PhaseExecute: 3},
+		MethodsPerBlock: make(map[string]float64),
+		PricePerCall:    0.0002,
+	}
+	estimate.extrapolate(map[string]uint64{"eth_getProof": 25, "debug_getRawBlock": 3, "eth_getCode": 6, "eth_chainId": 0})
+
+	assert.InDelta(t, 31.0/3, estimate.CallsPerBlock[PhasePreflight], 1e-9)
+	assert.InDelta(t, 1.0, estimate.CallsPerBlock[PhaseExecute], 1e-9)
+	assert.Equal(t, map[string]float64{"eth_getProof": 25.0 / 3, "debug_getRawBlock": 1, "eth_getCode": 2}, estimate.MethodsPerBlock, "methods without calls are omitted")
+	assert.Equal(t, uint64(11333), estimate.TotalCalls, "(31 + 3) / 3 calls per block over 1000 blocks, rounded to the nearest")
+	assert.InDelta(t, 2.2666, estimate.Cost, 1e-9)
+
+	out := estimate.String()
+	assert.Contains(t, out, "RPC estimate for blocks 1001-2000 (1000 blocks, sampled [1001 1500 2000])")
+	assert.Contains(t, out, "Preflight:     10.3 calls/block (including prepare)")
+	assert.Contains(t, out, "- eth_getProof: 8.3 calls/block")
+	assert.Contains(t, out, "Total calls:   11333")
+	assert.Contains(t, out, "Cost:          2.27 (0.0002 per call)")
+
+	t.Run("without price", func(t *testing.T) {
+		estimate := &RPCEstimate{From: 1, To: 10, Sampled: []uint64{1}, CallsPerBlock: map[Phase]float64{PhasePreflight: 4}, MethodsPerBlock: make(map[string]float64)}
+		estimate.extrapolate(nil)
+		assert.Equal(t, uint64(40), estimate.TotalCalls)
+		assert.Zero(t, estimate.Cost)
+		assert.NotContains(t, estimate.String(), "Cost")
+	})
+
+	t.Run("write file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "reports", "estimate.json")
+		require.NoError(t, estimate.WriteFile(path))
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		var written RPCEstimate
+		require.NoError(t, json.Unmarshal(b, &written))
+		assert.Equal(t, estimate, &written)
+	})
+}
+
+func TestEstimateRPCRequiresRemote(t *testing.T) {
+	chain := newTestChain(t, nil, nil, 2, nil)
+	s := newTestService(t, newTestConfig(t, ChainConfig{DataDir: chain.dir}))
+
+	_, err := s.EstimateRPC(context.Background(), big.NewInt(2), big.NewInt(1), nil)
+	assert.EqualError(t, err, "invalid block range: 2 > 1")
+	_, err = s.EstimateRPC(context.Background(), big.NewInt(1), big.NewInt(2), nil)
+	assert.EqualError(t, err, "estimating RPC calls requires a remote RPC")
+}
@@ -3,6 +3,8 @@ package ethereum
 import (
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	gethstate "github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
@@ -12,15 +14,29 @@ import (
 // NewChain creates a new core.HeaderChain instance
 func NewChain(cfg *params.ChainConfig, stateDB gethstate.Database) (*core.HeaderChain, error) {
 	// Setup the genesis block, to avoid error on core.NewHeaderChain
-	_, err := core.DefaultGenesisBlock().Commit(stateDB.TrieDB().Disk(), stateDB.TrieDB())
+	// (unknown chains default to the mainnet genesis, which is only used as a placeholder)
+	genesis := Genesis(cfg)
+	if genesis == nil {
+		genesis = core.DefaultGenesisBlock()
+	}
+	_, err := genesis.Commit(stateDB.TrieDB().Disk(), stateDB.TrieDB())
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply genesis block: %v", err)
 	}
 
 	// Create consensus engine
-	engine, err := ethconfig.CreateConsensusEngine(cfg, stateDB.TrieDB().Disk())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create consensus engine: %v", err)
+	//
+	// For pre-Merge headers (non-zero difficulty), the beacon engine delegates to ethash
+	// which credits the mining and ommer rewards when finalizing the block
+	var engine consensus.Engine
+	if cfg.TerminalTotalDifficulty == nil {
+		// Chain that never transitioned to PoS
+		engine = ethash.NewFaker()
+	} else {
+		engine, err = ethconfig.CreateConsensusEngine(cfg, stateDB.TrieDB().Disk())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consensus engine: %v", err)
+		}
 	}
 
 	hc, err := core.NewHeaderChain(stateDB.TrieDB().Disk(), cfg, engine, nil)
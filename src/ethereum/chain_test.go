@@ -0,0 +1,81 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func executeEmptyBlock(t *testing.T, cfg *params.ChainConfig, header *gethtypes.Header, uncles []*gethtypes.Header) *gethstate.StateDB {
+	db := rawdb.NewMemoryDatabase()
+	stateDB := gethstate.NewDatabase(triedb.NewDatabase(db, &triedb.Config{HashDB: &hashdb.Config{}}), nil)
+	hc, err := NewChain(cfg, stateDB)
+	require.NoError(t, err)
+
+	st, err := gethstate.New(gethtypes.EmptyRootHash, stateDB)
+	require.NoError(t, err)
+
+	block := gethtypes.NewBlockWithHeader(header).WithBody(gethtypes.Body{Uncles: uncles})
+	_, err = evm.NewExecutor().Execute(context.Background(), &evm.ExecParams{
+		VMConfig: &vm.Config{},
+		Block:    block,
+		Chain:    hc,
+		State:    st,
+	})
+	require.NoError(t, err)
+
+	return st
+}
+
+func TestBlockRewards(t *testing.T) {
+	var (
+		miner       = gethcommon.HexToAddress("0x1")
+		uncleMiner1 = gethcommon.HexToAddress("0x2")
+		uncleMiner2 = gethcommon.HexToAddress("0x3")
+		ether       = big.NewInt(params.Ether)
+	)
+
+	t.Run("pre-Merge block with uncles", func(t *testing.T) {
+		// Byzantium block (3 ETH block reward)
+		header := &gethtypes.Header{Number: big.NewInt(5_000_000), Difficulty: big.NewInt(1), GasLimit: 8_000_000, Coinbase: miner}
+		uncles := []*gethtypes.Header{
+			{Number: big.NewInt(4_999_999), Difficulty: big.NewInt(1), GasLimit: 8_000_000, Coinbase: uncleMiner1},
+			{Number: big.NewInt(4_999_998), Difficulty: big.NewInt(1), GasLimit: 8_000_000, Coinbase: uncleMiner2},
+		}
+		st := executeEmptyBlock(t, params.MainnetChainConfig, header, uncles)
+
+		reward := new(big.Int).Mul(big.NewInt(3), ether)
+		minerReward := new(big.Int).Add(reward, new(big.Int).Div(reward, big.NewInt(16))) // + 1/32 of the reward per uncle
+		assert.Equal(t, minerReward, st.GetBalance(miner).ToBig())
+		assert.Equal(t, new(big.Int).Div(new(big.Int).Mul(reward, big.NewInt(7)), big.NewInt(8)), st.GetBalance(uncleMiner1).ToBig()) // 7/8 of the reward
+		assert.Equal(t, new(big.Int).Div(new(big.Int).Mul(reward, big.NewInt(6)), big.NewInt(8)), st.GetBalance(uncleMiner2).ToBig()) // 6/8 of the reward
+	})
+
+	t.Run("pre-Merge block on chain without terminal total difficulty", func(t *testing.T) {
+		cfg := *params.MainnetChainConfig
+		cfg.TerminalTotalDifficulty = nil
+		header := &gethtypes.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), GasLimit: 5000, Coinbase: miner}
+		st := executeEmptyBlock(t, &cfg, header, nil)
+
+		assert.Equal(t, new(big.Int).Mul(big.NewInt(5), ether), st.GetBalance(miner).ToBig()) // Frontier block reward
+	})
+
+	t.Run("post-Merge block", func(t *testing.T) {
+		header := &gethtypes.Header{Number: big.NewInt(16_000_000), Time: 1_670_000_000, Difficulty: big.NewInt(0), GasLimit: 30_000_000, BaseFee: big.NewInt(1), Coinbase: miner}
+		st := executeEmptyBlock(t, params.MainnetChainConfig, header, nil)
+
+		assert.True(t, st.GetBalance(miner).IsZero())
+	})
+}
@@ -0,0 +1,377 @@
+package chaindata
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+)
+
+const (
+	dbCache   = 512 // Cache size in MB
+	dbHandles = 256 // Number of open file handles
+)
+
+// Client is an ethrpc.Client reading chain data directly from a local geth data directory (LevelDB or Pebble),
+// bypassing JSON-RPC.
+//
+// It only implements the subset of methods necessary to generate prover inputs
+// (ChainID, BlockByNumber, HeaderByHash, HeaderByNumber, CodeAt, StorageAt, GetProof and FilterLogs),
+// calling any other method panics.
+//
+// The database is opened in read-only mode, but both LevelDB and Pebble lock the database directory
+// so the geth node owning the data directory must be stopped.
+type Client struct {
+	ethrpc.Client // Not implemented methods
+
+	datadir string
+
+	db     ethdb.Database
+	triedb *triedb.Database
+	state  gethstate.Database
+}
+
+// NewClient creates a new Client for the given geth data directory.
+//
+// datadir can either be a geth data directory (containing geth/chaindata) or a chaindata directory.
+func NewClient(datadir string) *Client {
+	return &Client{datadir: datadir}
+}
+
+// NewGenesisClient creates a new Client serving the chain data of the genesis block from memory
+//
+// It enables to read the genesis state (e.g. the pre-state of block 1) from the genesis allocation,
+// as RPC nodes often can not serve proofs for it. The returned client is already started.
+func NewGenesisClient(genesis *core.Genesis) (*Client, error) {
+	db := rawdb.NewMemoryDatabase()
+	trieDB := triedb.NewDatabase(db, &triedb.Config{HashDB: hashdb.Defaults})
+	if _, err := genesis.Commit(db, trieDB); err != nil {
+		return nil, fmt.Errorf("failed to commit genesis: %v", err)
+	}
+
+	return &Client{
+		db:     db,
+		triedb: trieDB,
+		state:  gethstate.NewDatabase(trieDB, nil),
+	}, nil
+}
+
+// Start opens the local chain database
+func (c *Client) Start(_ context.Context) error {
+	dir := chaindataDir(c.datadir)
+
+	var (
+		kvdb ethdb.KeyValueStore
+		err  error
+	)
+	switch dbType := rawdb.PreexistingDatabase(dir); dbType {
+	case rawdb.DBPebble:
+		kvdb, err = pebble.New(dir, dbCache, dbHandles, "", true)
+	case rawdb.DBLeveldb:
+		kvdb, err = leveldb.New(dir, dbCache, dbHandles, "", true)
+	default:
+		return fmt.Errorf("no chain database found in %v", dir)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open chain database %v: %v", dir, err)
+	}
+
+	db, err := rawdb.NewDatabaseWithFreezer(kvdb, filepath.Join(dir, "ancient"), "", true)
+	if err != nil {
+		kvdb.Close()
+		return fmt.Errorf("failed to open ancient chain database: %v", err)
+	}
+
+	trieCfg := &triedb.Config{HashDB: hashdb.Defaults}
+	if rawdb.ReadStateScheme(db) == rawdb.PathScheme {
+		trieCfg = &triedb.Config{PathDB: pathdb.ReadOnly}
+	}
+
+	c.db = db
+	c.triedb = triedb.NewDatabase(db, trieCfg)
+	c.state = gethstate.NewDatabase(c.triedb, nil)
+
+	return nil
+}
+
+// Stop closes the local chain database
+func (c *Client) Stop(_ context.Context) error {
+	if c.triedb != nil {
+		c.triedb.Close()
+	}
+	if c.db != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+// chaindataDir returns the chaindata directory for the given data directory
+func chaindataDir(datadir string) string {
+	dir := filepath.Join(datadir, "geth", "chaindata")
+	if _, err := os.Stat(dir); err == nil {
+		return dir
+	}
+	return datadir
+}
+
+// ChainID returns the chain ID from the chain configuration stored with the genesis block
+func (c *Client) ChainID(_ context.Context) (*big.Int, error) {
+	cfg := rawdb.ReadChainConfig(c.db, rawdb.ReadCanonicalHash(c.db, 0))
+	if cfg == nil {
+		return nil, fmt.Errorf("chain configuration not found")
+	}
+	return cfg.ChainID, nil
+}
+
+// BlockByNumber returns the canonical block with the given number, or the head block if number is nil or negative
+func (c *Client) BlockByNumber(_ context.Context, number *big.Int) (*gethtypes.Block, error) {
+	hash, n, err := c.canonical(number)
+	if err != nil {
+		return nil, err
+	}
+
+	block := rawdb.ReadBlock(c.db, hash, n)
+	if block == nil {
+		return nil, geth.NotFound
+	}
+	return block, nil
+}
+
+// HeaderByNumber returns the canonical header with the given number, or the head header if number is nil or negative
+func (c *Client) HeaderByNumber(_ context.Context, number *big.Int) (*gethtypes.Header, error) {
+	hash, n, err := c.canonical(number)
+	if err != nil {
+		return nil, err
+	}
+
+	header := rawdb.ReadHeader(c.db, hash, n)
+	if header == nil {
+		return nil, geth.NotFound
+	}
+	return header, nil
+}
+
+// HeaderByHash returns the header with the given hash
+func (c *Client) HeaderByHash(_ context.Context, hash gethcommon.Hash) (*gethtypes.Header, error) {
+	n := rawdb.ReadHeaderNumber(c.db, hash)
+	if n == nil {
+		return nil, geth.NotFound
+	}
+
+	header := rawdb.ReadHeader(c.db, hash, *n)
+	if header == nil {
+		return nil, geth.NotFound
+	}
+	return header, nil
+}
+
+// CodeAt returns the contract code of the given account at the given block
+func (c *Client) CodeAt(ctx context.Context, account gethcommon.Address, blockNumber *big.Int) ([]byte, error) {
+	state, _, err := c.stateAt(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return state.GetCode(account), state.Error()
+}
+
+// StorageAt returns the value of the given storage slot of the given account at the given block
+func (c *Client) StorageAt(ctx context.Context, account gethcommon.Address, key gethcommon.Hash, blockNumber *big.Int) ([]byte, error) {
+	state, _, err := c.stateAt(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	value := state.GetState(account, key)
+	return value.Bytes(), state.Error()
+}
+
+// GetProof returns the account and storage values of the given account including the Merkle-proofs,
+// consistently with eth_getProof
+func (c *Client) GetProof(ctx context.Context, account gethcommon.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	state, header, err := c.stateAt(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	storageRoot := state.GetStorageRoot(account)
+	storageProof := make([]gethclient.StorageResult, len(keys))
+	if len(keys) > 0 {
+		var storageTrie gethstate.Trie
+		if storageRoot != gethtypes.EmptyRootHash && storageRoot != (gethcommon.Hash{}) {
+			id := trie.StorageTrieID(header.Root, crypto.Keccak256Hash(account.Bytes()), storageRoot)
+			storageTrie, err = trie.NewStateTrie(id, c.triedb)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for i, hexKey := range keys {
+			b, err := hexutil.Decode(hexKey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid storage key %q: %v", hexKey, err)
+			}
+			key := gethcommon.BytesToHash(b)
+
+			if storageTrie == nil {
+				storageProof[i] = gethclient.StorageResult{Key: hexKey, Value: new(big.Int), Proof: []string{}}
+				continue
+			}
+
+			var proof proofList
+			if err := storageTrie.Prove(crypto.Keccak256(key.Bytes()), &proof); err != nil {
+				return nil, err
+			}
+			value := state.GetState(account, key)
+			storageProof[i] = gethclient.StorageResult{Key: hexKey, Value: value.Big(), Proof: proof}
+		}
+	}
+
+	accountTrie, err := trie.NewStateTrie(trie.StateTrieID(header.Root), c.triedb)
+	if err != nil {
+		return nil, err
+	}
+	var accountProof proofList
+	if err := accountTrie.Prove(crypto.Keccak256(account.Bytes()), &accountProof); err != nil {
+		return nil, err
+	}
+
+	return &gethclient.AccountResult{
+		Address:      account,
+		AccountProof: accountProof,
+		Balance:      state.GetBalance(account).ToBig(),
+		CodeHash:     state.GetCodeHash(account),
+		Nonce:        state.GetNonce(account),
+		StorageHash:  storageRoot,
+		StorageProof: storageProof,
+	}, state.Error()
+}
+
+// FilterLogs returns the logs of the canonical blocks of the query range matching its addresses and topics, consistently
+// with eth_getLogs, read from the receipts stored in the database
+//
+// A nil FromBlock or ToBlock is the head block, block hash queries are not supported.
+func (c *Client) FilterLogs(ctx context.Context, q geth.FilterQuery) ([]gethtypes.Log, error) {
+	if q.BlockHash != nil {
+		return nil, fmt.Errorf("filtering logs by block hash is not supported on local chain data")
+	}
+
+	from, to := q.FromBlock, q.ToBlock
+	if from == nil || from.Sign() < 0 || to == nil || to.Sign() < 0 {
+		head, err := c.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		if from == nil || from.Sign() < 0 {
+			from = head.Number
+		}
+		if to == nil || to.Sign() < 0 {
+			to = head.Number
+		}
+	}
+
+	var logs []gethtypes.Log
+	for n := from.Uint64(); n <= to.Uint64(); n++ {
+		hash, _, err := c.canonical(new(big.Int).SetUint64(n))
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", n, err)
+		}
+		body := rawdb.ReadBody(c.db, hash, n)
+		if body == nil {
+			return nil, fmt.Errorf("body of block %d not found", n)
+		}
+
+		index := uint(0)
+		for txIndex, txLogs := range rawdb.ReadLogs(c.db, hash, n) {
+			for _, l := range txLogs {
+				l.BlockNumber, l.BlockHash, l.TxIndex, l.Index = n, hash, uint(txIndex), index
+				if txIndex < len(body.Transactions) {
+					l.TxHash = body.Transactions[txIndex].Hash()
+				}
+				index++
+				if matchLog(l, q.Addresses, q.Topics) {
+					logs = append(logs, *l)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+// matchLog returns true if the log is emitted by one of addresses and matches the topics, both matching any if empty
+func matchLog(l *gethtypes.Log, addresses []gethcommon.Address, topics [][]gethcommon.Hash) bool {
+	if len(addresses) > 0 && !slices.Contains(addresses, l.Address) {
+		return false
+	}
+	if len(topics) > len(l.Topics) {
+		return false
+	}
+	for i, alternatives := range topics {
+		if len(alternatives) > 0 && !slices.Contains(alternatives, l.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonical returns the hash and number of the canonical block with the given number
+func (c *Client) canonical(number *big.Int) (gethcommon.Hash, uint64, error) {
+	if number == nil || number.Sign() < 0 {
+		hash := rawdb.ReadHeadBlockHash(c.db)
+		n := rawdb.ReadHeaderNumber(c.db, hash)
+		if n == nil {
+			return gethcommon.Hash{}, 0, geth.NotFound
+		}
+		return hash, *n, nil
+	}
+
+	hash := rawdb.ReadCanonicalHash(c.db, number.Uint64())
+	if hash == (gethcommon.Hash{}) {
+		return gethcommon.Hash{}, 0, geth.NotFound
+	}
+	return hash, number.Uint64(), nil
+}
+
+// stateAt returns the state at the given block
+func (c *Client) stateAt(ctx context.Context, blockNumber *big.Int) (*gethstate.StateDB, *gethtypes.Header, error) {
+	header, err := c.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := gethstate.New(header.Root, c.state)
+	if err != nil {
+		return nil, nil, fmt.Errorf("state not available for block %v (requires an archive node for historical blocks): %v", header.Number, err)
+	}
+	return state, header, nil
+}
+
+// proofList collects hex encoded proof nodes
+type proofList []string
+
+func (n *proofList) Put(_, value []byte) error {
+	*n = append(*n, hexutil.Encode(value))
+	return nil
+}
+
+func (n *proofList) Delete(_ []byte) error {
+	panic("not supported")
+}
@@ -0,0 +1,110 @@
+package chaindata
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAccount = gethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	testSlot    = gethcommon.HexToHash("0x01")
+	testValue   = gethcommon.HexToHash("0x2a")
+	testCode    = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+)
+
+// newTestDatadir creates a geth like Pebble chain database containing a genesis block
+func newTestDatadir(t *testing.T) (string, *gethtypes.Block) {
+	dir := t.TempDir()
+
+	kvdb, err := pebble.New(dir, 16, 16, "", false)
+	require.NoError(t, err)
+	db, err := rawdb.NewDatabaseWithFreezer(kvdb, filepath.Join(dir, "ancient"), "", false)
+	require.NoError(t, err)
+
+	tdb := triedb.NewDatabase(db, &triedb.Config{HashDB: hashdb.Defaults})
+	genesis := &core.Genesis{
+		Config: params.AllEthashProtocolChanges,
+		Alloc: gethtypes.GenesisAlloc{
+			testAccount: {
+				Balance: big.NewInt(1000),
+				Nonce:   1,
+				Code:    testCode,
+				Storage: map[gethcommon.Hash]gethcommon.Hash{testSlot: testValue},
+			},
+		},
+	}
+	block, err := genesis.Commit(db, tdb)
+	require.NoError(t, err)
+
+	require.NoError(t, tdb.Close())
+	require.NoError(t, db.Close())
+
+	return dir, block
+}
+
+func TestClient(t *testing.T) {
+	dir, genesis := newTestDatadir(t)
+
+	client := NewClient(dir)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop(context.Background()) //nolint:errcheck // test
+
+	ctx := context.Background()
+
+	chainID, err := client.ChainID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, params.AllEthashProtocolChanges.ChainID, chainID)
+
+	block, err := client.BlockByNumber(ctx, big.NewInt(0))
+	require.NoError(t, err)
+	assert.Equal(t, genesis.Hash(), block.Hash())
+
+	latest, err := client.BlockByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, genesis.Hash(), latest.Hash())
+
+	header, err := client.HeaderByHash(ctx, genesis.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, genesis.Root(), header.Root)
+
+	_, err = client.BlockByNumber(ctx, big.NewInt(1))
+	assert.Error(t, err, "unknown block")
+
+	code, err := client.CodeAt(ctx, testAccount, big.NewInt(0))
+	require.NoError(t, err)
+	assert.Equal(t, testCode, code)
+
+	value, err := client.StorageAt(ctx, testAccount, testSlot, big.NewInt(0))
+	require.NoError(t, err)
+	assert.Equal(t, testValue.Bytes(), value)
+
+	res, err := client.GetProof(ctx, testAccount, []string{testSlot.Hex()}, big.NewInt(0))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000), res.Balance)
+	assert.Equal(t, uint64(1), res.Nonce)
+	require.Len(t, res.StorageProof, 1)
+	assert.Equal(t, testValue.Big(), res.StorageProof[0].Value)
+
+	// Proofs are valid against the genesis state root
+	proof := trie.AccountProofFromRPC(res)
+	exists, err := proof.Exists(genesis.Root())
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	_, err = trie.NodeSetFromStateProofs(genesis.Root(), []*trie.AccountProof{proof})
+	require.NoError(t, err)
+}
@@ -1,6 +1,8 @@
 package ethereum
 
 import (
+	"sync"
+
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
@@ -47,3 +49,112 @@ func WriteNodesToHashDB(db ethdb.Database, nodes ...[]byte) {
 		rawdb.WriteLegacyTrieNode(db, gethcommon.BytesToHash(hash), node)
 	}
 }
+
+// WriteNodesToHashDBConcurrently fills an ethdb.Database with the provided nodes, hashing them with up to concurrency goroutines
+//
+// Nodes are only written once all of them are hashed, in their given order, so the content of the database does not
+// depend on the concurrency (values lower than 2 hash sequentially, as WriteNodesToHashDB).
+func WriteNodesToHashDBConcurrently(db ethdb.Database, concurrency int, nodes ...[]byte) {
+	if concurrency < 2 || len(nodes) < 2 {
+		WriteNodesToHashDB(db, nodes...)
+		return
+	}
+
+	hashes := make([]gethcommon.Hash, len(nodes))
+	size := (len(nodes) + concurrency - 1) / concurrency
+	var wg sync.WaitGroup
+	for start := 0; start < len(nodes); start += size {
+		wg.Add(1)
+		go func(batch [][]byte, hashes []gethcommon.Hash) {
+			defer wg.Done()
+			hasher := crypto.NewKeccakState()
+			//nolint:errcheck // Can't fail
+			for i, node := range batch {
+				hasher.Reset()
+				hasher.Write(node)
+				hasher.Read(hashes[i][:])
+			}
+		}(nodes[start:min(start+size, len(nodes))], hashes[start:min(start+size, len(nodes))])
+	}
+	wg.Wait()
+
+	for i, node := range nodes {
+		rawdb.WriteLegacyTrieNode(db, hashes[i], node)
+	}
+}
+
+// LazyNodeDatabase is an ethdb.Database materializing trie nodes on first access, instead of hashing and writing all of
+// them upfront (see WriteNodesToHashDB)
+//
+// Nodes added with AddNodes are kept pending: when a trie node missing from the underlying database is read, the
+// pending nodes are hashed and written in their given order until the requested one is found. A block accessing a
+// small fraction of a witness near its start thus only hashes that fraction, the worst case being the eager cost.
+// As nodes are still found by hash, the resolved nodes are bound to the state root as with eager loading.
+type LazyNodeDatabase struct {
+	ethdb.Database
+
+	mu      sync.Mutex
+	pending [][]byte
+	hasher  crypto.KeccakState
+}
+
+// NewLazyNodeDatabase creates a new LazyNodeDatabase over db
+func NewLazyNodeDatabase(db ethdb.Database) *LazyNodeDatabase {
+	return &LazyNodeDatabase{Database: db, hasher: crypto.NewKeccakState()}
+}
+
+// AddNodes adds trie nodes to materialize on first access
+func (db *LazyNodeDatabase) AddNodes(nodes ...[]byte) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.pending = append(db.pending, nodes...)
+}
+
+// Has returns true if the key is in the database, materializing pending nodes if it is a missing trie node
+func (db *LazyNodeDatabase) Has(key []byte) (bool, error) {
+	if ok, err := db.Database.Has(key); ok || err != nil {
+		return ok, err
+	}
+	if !db.materialize(key) {
+		return false, nil
+	}
+	return db.Database.Has(key)
+}
+
+// Get returns the value of the key, materializing pending nodes if it is a missing trie node
+func (db *LazyNodeDatabase) Get(key []byte) ([]byte, error) {
+	value, err := db.Database.Get(key)
+	if err == nil || !db.materialize(key) {
+		return value, err
+	}
+	return db.Database.Get(key)
+}
+
+// materialize writes pending nodes until the node of hash key is written, and returns true if it was found
+func (db *LazyNodeDatabase) materialize(key []byte) bool {
+	if len(key) != gethcommon.HashLength {
+		return false // Not a legacy trie node key
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if ok, _ := db.Database.Has(key); ok {
+		return true // Materialized by a concurrent read
+	}
+
+	var hash gethcommon.Hash
+	//nolint:errcheck // Can't fail
+	for len(db.pending) > 0 {
+		node := db.pending[0]
+		db.pending = db.pending[1:]
+
+		db.hasher.Reset()
+		db.hasher.Write(node)
+		db.hasher.Read(hash[:])
+		rawdb.WriteLegacyTrieNode(db.Database, hash, node)
+		if hash == gethcommon.Hash(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package ethereum
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -23,3 +24,40 @@ func TestFillDBWithBytecode(t *testing.T) {
 	code2 := rawdb.ReadCode(db, crypto.Keccak256Hash(codes[1]))
 	assert.Equal(t, codes[1], code2, "Expected code2 to be correct")
 }
+
+func TestWriteNodesToHashDBConcurrently(t *testing.T) {
+	nodes := make([][]byte, 0, 100)
+	for i := 0; i < 100; i++ {
+		nodes = append(nodes, []byte(fmt.Sprintf("node%d", i)))
+	}
+
+	for _, concurrency := range []int{0, 1, 3, 8, 200} {
+		db := rawdb.NewMemoryDatabase()
+		WriteNodesToHashDBConcurrently(db, concurrency, nodes...)
+		for _, node := range nodes {
+			assert.Equal(t, node, rawdb.ReadLegacyTrieNode(db, crypto.Keccak256Hash(node)), "concurrency %d", concurrency)
+		}
+	}
+}
+
+func TestLazyNodeDatabase(t *testing.T) {
+	nodes := make([][]byte, 0, 10)
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, []byte(fmt.Sprintf("node%d", i)))
+	}
+
+	db := NewLazyNodeDatabase(rawdb.NewMemoryDatabase())
+	db.AddNodes(nodes...)
+
+	// Nodes are materialized in order up to the requested one
+	assert.Equal(t, nodes[3], rawdb.ReadLegacyTrieNode(db, crypto.Keccak256Hash(nodes[3])))
+	assert.Len(t, db.pending, 6)
+	assert.True(t, rawdb.HasLegacyTrieNode(db, crypto.Keccak256Hash(nodes[1])))
+	assert.Len(t, db.pending, 6)
+
+	assert.False(t, rawdb.HasLegacyTrieNode(db, crypto.Keccak256Hash([]byte("missing"))))
+	assert.Empty(t, db.pending)
+	for _, node := range nodes {
+		assert.Equal(t, node, rawdb.ReadLegacyTrieNode(db, crypto.Keccak256Hash(node)))
+	}
+}
@@ -36,14 +36,20 @@ func decodeHeaderNumberAndHash(key []byte) (uint64, gethcommon.Hash, bool) {
 
 // Get retrieves the value for a key.
 // It intercepts the key to check if it is a header key.
-// - If the key is a header key, it fetches the header from the remote RPC server.
+// - If the key is a header key of a header missing from the underlying database, it fetches the header from the remote RPC server.
 // - Otherwise, it calls the underlying ethdb.Database.Get method.
+//
+// Headers written to the underlying database (e.g. the placeholder genesis of chains without a built-in
+// genesis) are served locally, as the remote does not know them.
 func (db *Database) Get(key []byte) ([]byte, error) {
 	// Decode the header number and hash from the key
 	_, hash, ok := decodeHeaderNumberAndHash(key)
 	if !ok {
 		return db.Database.Get(key)
 	}
+	if b, err := db.Database.Get(key); err == nil {
+		return b, nil
+	}
 
 	// Fetch the header from the remote RPC server
 	// Note: We use the context.TODO() because the ethdb.Database.Get method does not accept a context.
@@ -84,6 +84,17 @@ func TestDatabase(t *testing.T) {
 		assert.Equal(t, hexutil.Encode(expectedB), hexutil.Encode(b))
 	})
 
+	t.Run("Get Local Header", func(t *testing.T) {
+		header := &gethtypes.Header{Number: big.NewInt(0)}
+		rawdb.WriteHeader(db, header)
+
+		// Served from the underlying database, without calling the remote
+		b, err := db.Get(headerKey(0, header.Hash()))
+		require.NoError(t, err)
+		expectedB, _ := rlp.EncodeToBytes(header)
+		assert.Equal(t, hexutil.Encode(expectedB), hexutil.Encode(b))
+	})
+
 	t.Run("Get Non-Header", func(t *testing.T) {
 		b, err := db.Get([]byte("key"))
 		require.Error(t, err)
@@ -12,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	gethparams "github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/kkrt-labs/go-utils/log"
 )
 
@@ -64,21 +65,22 @@ func (e *executor) Execute(ctx context.Context, params *ExecParams) (res *core.P
 		}
 	}
 
-	if params.Chain.Config().IsByzantium(params.Block.Number()) {
-		if params.VMConfig.StatelessSelfValidation {
-			// Create witness for tracking state accesses
-			witness, err := stateless.NewWitness(params.Block.Header(), params.Chain)
-			if err != nil {
-				execErr = fmt.Errorf("failed to create witness: %v", err)
-				return
-			}
+	if params.VMConfig.StatelessSelfValidation {
+		// Create witness for tracking state accesses
+		//
+		// Contrary to geth block import, we also collect the witness of pre-Byzantium blocks: the prefetcher is
+		// dropped after the first intermediate root but the state accesses keep being recorded in the witness.
+		witness, err := stateless.NewWitness(params.Block.Header(), params.Chain)
+		if err != nil {
+			execErr = fmt.Errorf("failed to create witness: %v", err)
+			return
+		}
 
-			params.State.StartPrefetcher("chain", witness)
+		params.State.StartPrefetcher("chain", witness)
 
-			defer func() {
-				params.State.StopPrefetcher()
-			}()
-		}
+		defer func() {
+			params.State.StopPrefetcher()
+		}()
 	}
 
 	// Process block on given state
@@ -110,6 +112,10 @@ func (e *executor) processBlock(ctx context.Context, params *ExecParams) (*core.
 
 func (e *executor) validateBlock(ctx context.Context, params *ExecParams, res *core.ProcessResult) error {
 	log.LoggerFromContext(ctx).Info("Validate block & state transition...")
+	if err := validateWithdrawals(params.Chain.Config(), params.Block); err != nil {
+		return fmt.Errorf("block validation failed: %v", err)
+	}
+
 	validator := core.NewBlockValidator(params.Chain.Config(), nil)
 	err := validator.ValidateState(params.Block, params.State, res, false)
 	if params.Reporter != nil {
@@ -121,6 +127,33 @@ func (e *executor) validateBlock(ctx context.Context, params *ExecParams, res *c
 	return nil
 }
 
+// validateWithdrawals validates the block withdrawals against the header withdrawals root
+// - post-Shanghai blocks must have a withdrawals root matching the withdrawals of the block body
+// - pre-Shanghai blocks must have neither withdrawals root nor withdrawals
+//
+// Post-Shanghai blocks without withdrawals may have a nil withdrawals list, as serialization formats (e.g. protobuf,
+// or JSON with omitted empty fields) do not distinguish it from an empty one: it then hashes to the empty root.
+func validateWithdrawals(chainCfg *gethparams.ChainConfig, block *types.Block) error {
+	header := block.Header()
+	if !chainCfg.IsShanghai(header.Number, header.Time) {
+		if header.WithdrawalsHash != nil {
+			return fmt.Errorf("invalid withdrawals root: have %v, expected nil (pre-Shanghai)", header.WithdrawalsHash.Hex())
+		}
+		if block.Withdrawals() != nil {
+			return fmt.Errorf("withdrawals present in pre-Shanghai block body")
+		}
+		return nil
+	}
+
+	if header.WithdrawalsHash == nil {
+		return fmt.Errorf("missing withdrawals root in post-Shanghai block header")
+	}
+	if hash := types.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil)); hash != *header.WithdrawalsHash {
+		return fmt.Errorf("withdrawals root mismatch: have %v, header %v", hash.Hex(), header.WithdrawalsHash.Hex())
+	}
+	return nil
+}
+
 // summarizeBadBlock generates a human-readable summary of a bad block.
 func summarizeBadBlockError(chainCfg *gethparams.ChainConfig, block *types.Block, res *core.ProcessResult, err error) error {
 	var receipts types.Receipts
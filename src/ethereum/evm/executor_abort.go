@@ -0,0 +1,55 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// ExecutorWithAbort is an executor decorator that aborts the execution of the block once ctx is done
+//
+// The EVM does not take a context, so ctx is checked at the start of every transaction and call frame: the execution
+// is then unwound and fails with the cause of ctx (see context.Cause). The state of an aborted execution must be discarded.
+// It must wrap the executor after ExecutorWithLog, which replaces the VM tracer.
+func ExecutorWithAbort() ExecutorDecorator {
+	return func(executor Executor) Executor {
+		return ExecutorFunc(func(ctx context.Context, params *ExecParams) (res *core.ProcessResult, err error) {
+			if ctx.Done() == nil {
+				// ctx can never be done
+				return executor.Execute(ctx, params)
+			}
+
+			check := func() {
+				if ctx.Err() != nil {
+					panic(abortedExecution{context.Cause(ctx)})
+				}
+			}
+			params.VMConfig.Tracer = chainHooks(params.VMConfig.Tracer, &tracing.Hooks{
+				OnTxStart: func(*tracing.VMContext, *gethtypes.Transaction, gethcommon.Address) { check() },
+				OnEnter:   func(int, byte, gethcommon.Address, gethcommon.Address, []byte, uint64, *big.Int) { check() },
+			})
+
+			defer func() {
+				if r := recover(); r != nil {
+					aborted, ok := r.(abortedExecution)
+					if !ok {
+						panic(r)
+					}
+					res, err = nil, fmt.Errorf("block execution aborted: %w", aborted.cause)
+				}
+			}()
+
+			return executor.Execute(ctx, params)
+		})
+	}
+}
+
+// abortedExecution is the panic value unwinding an execution aborted by ExecutorWithAbort
+type abortedExecution struct {
+	cause error
+}
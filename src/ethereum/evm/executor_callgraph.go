@@ -0,0 +1,99 @@
+package evm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// ExecutorWithCallGraph is an executor decorator that writes to w the call graph of every executed block, as a
+// Graphviz DOT digraph with one node per address and one edge per caller, callee and call type
+// (CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE, CREATE2), labelled with the number of such calls
+//
+// The transaction calls (from the sender) and the system calls are included. Edges are sorted so the graph of a block
+// is deterministic, and it is written at the end of the block execution, even if it failed.
+// It must wrap the executor after ExecutorWithLog, which replaces the VM tracer.
+func ExecutorWithCallGraph(w io.Writer) ExecutorDecorator {
+	var mu sync.Mutex // Graphs may be shared by concurrent executions
+	return func(executor Executor) Executor {
+		return ExecutorFunc(func(ctx context.Context, params *ExecParams) (*core.ProcessResult, error) {
+			t := &callGraphTracer{ctx: ctx, mu: &mu, w: w, block: params.Block, calls: make(map[callEdge]uint64)}
+			params.VMConfig.Tracer = chainHooks(params.VMConfig.Tracer, t.Hooks())
+			return executor.Execute(ctx, params)
+		})
+	}
+}
+
+type callEdge struct {
+	from, to gethcommon.Address
+	op       vm.OpCode
+}
+
+// callGraphTracer is an EVM tracer counting the calls between addresses of a block
+type callGraphTracer struct {
+	ctx   context.Context
+	mu    *sync.Mutex
+	w     io.Writer
+	block *gethtypes.Block
+	calls map[callEdge]uint64
+}
+
+// OnEnter counts a call frame entry
+func (t *callGraphTracer) OnEnter(_ int, typ byte, from, to gethcommon.Address, _ []byte, _ uint64, _ *big.Int) {
+	t.calls[callEdge{from, to, vm.OpCode(typ)}]++
+}
+
+// OnBlockEnd writes the call graph of the block
+func (t *callGraphTracer) OnBlockEnd(_ error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := writeCallGraph(t.w, t.block, t.calls); err != nil {
+		log.LoggerFromContext(t.ctx).Warn("Failed to write call graph", zap.Error(err))
+	}
+}
+
+// Hooks returns the call graph tracer hooks
+func (t *callGraphTracer) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnEnter:    t.OnEnter,
+		OnBlockEnd: t.OnBlockEnd,
+	}
+}
+
+// writeCallGraph writes the DOT digraph of the calls of a block
+func writeCallGraph(w io.Writer, block *gethtypes.Block, calls map[callEdge]uint64) error {
+	edges := make([]callEdge, 0, len(calls))
+	for edge := range calls {
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if c := edges[i].from.Cmp(edges[j].from); c != 0 {
+			return c < 0
+		}
+		if c := edges[i].to.Cmp(edges[j].to); c != 0 {
+			return c < 0
+		}
+		return edges[i].op < edges[j].op
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph \"block_%d\" {\n", block.NumberU64())
+	fmt.Fprintf(bw, "  label=\"block %d (%v)\";\n", block.NumberU64(), block.Hash().Hex())
+	for _, edge := range edges {
+		fmt.Fprintf(bw, "  %q -> %q [label=\"%v x%d\"];\n", edge.from.Hex(), edge.to.Hex(), edge.op, calls[edge])
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
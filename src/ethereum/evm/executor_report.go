@@ -0,0 +1,222 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"runtime"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// Report events
+const (
+	ReportBlockStart = "blockStart"
+	ReportTxStart    = "txStart"
+	ReportTxEnd      = "txEnd"
+	ReportBlockEnd   = "blockEnd"
+)
+
+// ReportEntry is a line of an execution report
+type ReportEntry struct {
+	Event             string    `json:"event"`
+	Time              time.Time `json:"time"`
+	BlockNumber       uint64    `json:"blockNumber"`
+	BlockHash         string    `json:"blockHash,omitempty"`
+	TxCount           int       `json:"txCount,omitempty"`           // blockStart only
+	TxIndex           *int      `json:"txIndex,omitempty"`           // txStart & txEnd only
+	TxHash            string    `json:"txHash,omitempty"`            // txStart & txEnd only
+	From              string    `json:"from,omitempty"`              // txStart only
+	Status            *uint64   `json:"status,omitempty"`            // txEnd only
+	GasUsed           uint64    `json:"gasUsed,omitempty"`           // txEnd only
+	CumulativeGasUsed uint64    `json:"cumulativeGasUsed,omitempty"` // txEnd only
+	Duration          string    `json:"duration,omitempty"`          // txEnd & blockEnd only
+	HeapBytes         uint64    `json:"heapBytes"`                   // Go heap in use when the entry was written
+	Error             string    `json:"error,omitempty"`             // txEnd & blockEnd only, blockEnd includes validation errors
+}
+
+// ExecutorWithReport is an executor decorator that writes a JSON line to w at every step of the block execution
+// (block start, start and end of every transaction, block end including validation)
+//
+// Every line is written, and synced if w supports it (e.g. *os.File), before the execution goes on, so if the process
+// crashes or hangs mid-block (e.g. OOM, infinite loop) the report records how far it got and the last transaction processed.
+// It must wrap the executor after ExecutorWithLog, which replaces the VM tracer.
+func ExecutorWithReport(w io.Writer) ExecutorDecorator {
+	var mu sync.Mutex // Reports may be shared by concurrent executions
+	return func(executor Executor) Executor {
+		return ExecutorFunc(func(ctx context.Context, params *ExecParams) (*core.ProcessResult, error) {
+			r := &reportTracer{ctx: ctx, mu: &mu, w: w, block: params.Block}
+			params.VMConfig.Tracer = chainHooks(params.VMConfig.Tracer, r.Hooks())
+			return executor.Execute(ctx, params)
+		})
+	}
+}
+
+// reportTracer is an EVM tracer writing an execution report
+type reportTracer struct {
+	ctx   context.Context
+	mu    *sync.Mutex
+	w     io.Writer
+	block *gethtypes.Block
+	err   error // First write error, further entries are dropped
+
+	blockStart time.Time
+	txIndex    int
+	txHash     gethcommon.Hash
+	txStart    time.Time
+}
+
+func (t *reportTracer) write(entry *ReportEntry) {
+	if t.err != nil {
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	entry.Time = time.Now()
+	entry.BlockNumber = t.block.NumberU64()
+	entry.HeapBytes = mem.HeapInuse
+
+	b, err := json.Marshal(entry)
+	if err == nil {
+		t.mu.Lock()
+		_, err = t.w.Write(append(b, '\n'))
+		if syncer, ok := t.w.(interface{ Sync() error }); ok && err == nil {
+			err = syncer.Sync()
+		}
+		t.mu.Unlock()
+	}
+	if err != nil {
+		t.err = err
+		log.LoggerFromContext(t.ctx).Warn("Failed to write execution report, dropping further entries", zap.Error(err))
+	}
+}
+
+// OnBlockStart reports block execution start
+func (t *reportTracer) OnBlockStart(event tracing.BlockEvent) {
+	t.blockStart = time.Now()
+	t.txIndex = -1
+	t.write(&ReportEntry{
+		Event:     ReportBlockStart,
+		BlockHash: event.Block.Hash().Hex(),
+		TxCount:   len(event.Block.Transactions()),
+	})
+}
+
+// OnBlockEnd reports block execution end
+func (t *reportTracer) OnBlockEnd(err error) {
+	entry := &ReportEntry{
+		Event:    ReportBlockEnd,
+		Duration: time.Since(t.blockStart).String(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	t.write(entry)
+}
+
+// OnTxStart reports transaction execution start
+func (t *reportTracer) OnTxStart(_ *tracing.VMContext, tx *gethtypes.Transaction, from gethcommon.Address) {
+	t.txIndex++
+	t.txHash = tx.Hash()
+	t.txStart = time.Now()
+	t.write(&ReportEntry{
+		Event:   ReportTxStart,
+		TxIndex: &t.txIndex,
+		TxHash:  t.txHash.Hex(),
+		From:    from.Hex(),
+	})
+}
+
+// OnTxEnd reports transaction execution end
+func (t *reportTracer) OnTxEnd(receipt *gethtypes.Receipt, err error) {
+	entry := &ReportEntry{
+		Event:    ReportTxEnd,
+		TxIndex:  &t.txIndex,
+		TxHash:   t.txHash.Hex(),
+		Duration: time.Since(t.txStart).String(),
+	}
+	if receipt != nil {
+		entry.Status = &receipt.Status
+		entry.GasUsed = receipt.GasUsed
+		entry.CumulativeGasUsed = receipt.CumulativeGasUsed
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	t.write(entry)
+}
+
+// Hooks returns the report tracer hooks
+func (t *reportTracer) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnBlockStart: t.OnBlockStart,
+		OnBlockEnd:   t.OnBlockEnd,
+		OnTxStart:    t.OnTxStart,
+		OnTxEnd:      t.OnTxEnd,
+	}
+}
+
+// chainHooks returns hooks calling the block, transaction and call frame entry hooks of base then the ones of next
+// Other hooks of base are kept as is.
+func chainHooks(base, next *tracing.Hooks) *tracing.Hooks {
+	if base == nil {
+		return next
+	}
+
+	hooks := *base
+	if next.OnBlockStart != nil {
+		prev := base.OnBlockStart
+		hooks.OnBlockStart = func(event tracing.BlockEvent) {
+			if prev != nil {
+				prev(event)
+			}
+			next.OnBlockStart(event)
+		}
+	}
+	if next.OnBlockEnd != nil {
+		prev := base.OnBlockEnd
+		hooks.OnBlockEnd = func(err error) {
+			if prev != nil {
+				prev(err)
+			}
+			next.OnBlockEnd(err)
+		}
+	}
+	if next.OnTxStart != nil {
+		prev := base.OnTxStart
+		hooks.OnTxStart = func(vm *tracing.VMContext, tx *gethtypes.Transaction, from gethcommon.Address) {
+			if prev != nil {
+				prev(vm, tx, from)
+			}
+			next.OnTxStart(vm, tx, from)
+		}
+	}
+	if next.OnTxEnd != nil {
+		prev := base.OnTxEnd
+		hooks.OnTxEnd = func(receipt *gethtypes.Receipt, err error) {
+			if prev != nil {
+				prev(receipt, err)
+			}
+			next.OnTxEnd(receipt, err)
+		}
+	}
+	if next.OnEnter != nil {
+		prev := base.OnEnter
+		hooks.OnEnter = func(depth int, typ byte, from, to gethcommon.Address, input []byte, gas uint64, value *big.Int) {
+			if prev != nil {
+				prev(depth, typ, from, to, input, gas, value)
+			}
+			next.OnEnter(depth, typ, from, to, input, gas, value)
+		}
+	}
+	return &hooks
+}
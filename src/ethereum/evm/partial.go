@@ -0,0 +1,88 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// TxResult is the outcome of a transaction of a partial block execution
+type TxResult struct {
+	Index     int             `json:"index"`
+	Hash      gethcommon.Hash `json:"hash"`
+	Status    uint64          `json:"status"`
+	GasUsed   uint64          `json:"gasUsed"`
+	StateRoot gethcommon.Hash `json:"stateRoot"` // Intermediate state root after the transaction
+}
+
+// ExecuteUpTo executes the transactions 0 to upTo (included) of the block and returns the intermediate state root after
+// every one of them, to bisect the transaction introducing a divergence
+//
+// It mirrors core.StateProcessor.Process (DAO fork and pre-execution system calls, then transactions) but stops after
+// transaction upTo: post-execution system calls and block rewards are not applied and the block is not validated.
+func ExecuteUpTo(ctx context.Context, params *ExecParams, upTo int) ([]*TxResult, error) {
+	block := params.Block
+	txs := block.Transactions()
+	if upTo < 0 || upTo >= len(txs) {
+		return nil, fmt.Errorf("transaction index %d out of range, block %v has %d transactions", upTo, block.Number(), len(txs))
+	}
+
+	var (
+		config      = params.Chain.Config()
+		header      = block.Header()
+		statedb     = params.State
+		usedGas     = new(uint64)
+		gp          = new(core.GasPool).AddGas(block.GasLimit())
+		signer      = gethtypes.MakeSigner(config, header.Number, header.Time)
+		deleteEmpty = config.IsEIP158(header.Number)
+	)
+
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+
+	vmenv := vm.NewEVM(core.NewEVMBlockContext(header, params.Chain, nil), vm.TxContext{}, statedb, config, *params.VMConfig)
+	if beaconRoot := block.BeaconRoot(); beaconRoot != nil {
+		core.ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
+	}
+	if config.IsPrague(block.Number(), block.Time()) {
+		core.ProcessParentBlockHash(block.ParentHash(), vmenv, statedb)
+	}
+
+	results := make([]*TxResult, 0, upTo+1)
+	for i, tx := range txs[:upTo+1] {
+		msg, err := core.TransactionToMessage(tx, signer, header.BaseFee)
+		if err != nil {
+			return results, fmt.Errorf("could not apply tx %d [%v]: %v", i, tx.Hash().Hex(), err)
+		}
+		statedb.SetTxContext(tx.Hash(), i)
+
+		receipt, err := core.ApplyTransactionWithEVM(msg, config, gp, statedb, block.Number(), block.Hash(), tx, usedGas, vmenv)
+		if err != nil {
+			return results, fmt.Errorf("could not apply tx %d [%v]: %v", i, tx.Hash().Hex(), err)
+		}
+
+		root := statedb.IntermediateRoot(deleteEmpty)
+		if err := statedb.Error(); err != nil {
+			return results, fmt.Errorf("failed to compute state root after tx %d [%v]: %v", i, tx.Hash().Hex(), err)
+		}
+
+		results = append(results, &TxResult{
+			Index:     i,
+			Hash:      tx.Hash(),
+			Status:    receipt.Status,
+			GasUsed:   receipt.GasUsed,
+			StateRoot: root,
+		})
+		log.LoggerFromContext(ctx).Debug("Transaction executed", zap.Int("tx.index", i), zap.String("state.root", root.Hex()))
+	}
+
+	return results, nil
+}
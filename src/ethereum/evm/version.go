@@ -0,0 +1,34 @@
+package evm
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	gethversion "github.com/ethereum/go-ethereum/version"
+)
+
+const gethModulePath = "github.com/ethereum/go-ethereum"
+
+// Version is the version of the EVM library (go-ethereum state transition) the application is built with.
+//
+// Execution results for a given input may change across EVM library versions, so it allows to detect
+// when an upgrade may change generated prover inputs.
+var Version = evmVersion()
+
+func evmVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path != gethModulePath {
+				continue
+			}
+			if dep.Replace != nil {
+				// go-ethereum is replaced by a fork, the fork version identifies the state transition
+				return fmt.Sprintf("%v@%v", dep.Replace.Path, dep.Replace.Version)
+			}
+			return fmt.Sprintf("%v@%v", dep.Path, dep.Version)
+		}
+	}
+
+	// Build information is not available (e.g. binary built without module support)
+	return fmt.Sprintf("%v@v%d.%d.%d", gethModulePath, gethversion.Major, gethversion.Minor, gethversion.Patch)
+}
@@ -0,0 +1,44 @@
+package ethereum
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Genesis returns the genesis of the chain with the given configuration, or nil if the chain is unknown
+func Genesis(cfg *params.ChainConfig) *core.Genesis {
+	if cfg == nil || cfg.ChainID == nil {
+		return nil
+	}
+
+	switch cfg.ChainID.Uint64() {
+	case params.MainnetChainConfig.ChainID.Uint64():
+		return core.DefaultGenesisBlock()
+	case params.SepoliaChainConfig.ChainID.Uint64():
+		return core.DefaultSepoliaGenesisBlock()
+	case params.HoleskyChainConfig.ChainID.Uint64():
+		return core.DefaultHoleskyGenesisBlock()
+	}
+	return nil
+}
+
+// ReadGenesisFile reads a genesis JSON file, in the format of geth init, which must hold the chain configuration
+func ReadGenesisFile(path string) (*core.Genesis, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genesis file: %v", err)
+	}
+
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(b, genesis); err != nil {
+		return nil, fmt.Errorf("invalid genesis file %v: %v", path, err)
+	}
+	if genesis.Config == nil || genesis.Config.ChainID == nil {
+		return nil, fmt.Errorf("invalid genesis file %v: missing chain configuration", path)
+	}
+	return genesis, nil
+}
@@ -0,0 +1,205 @@
+package state
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// AccessStatus is the outcome of a state access against a witness
+type AccessStatus string
+
+const (
+	AccessPresent AccessStatus = "present" // The accessed state is in the witness
+	AccessAbsent  AccessStatus = "absent"  // The witness proves the accessed account does not exist
+	AccessMissing AccessStatus = "missing" // The accessed state is not in the witness, the EVM read it as zero
+)
+
+// Access kinds
+const (
+	AccessKindAccount = "account"
+	AccessKindStorage = "storage"
+	AccessKindCode    = "code"
+)
+
+// StateAccess is an account, storage slot or bytecode accessed during execution, and whether the witness backed it
+type StateAccess struct {
+	Kind     string             `json:"kind"`
+	Address  gethcommon.Address `json:"address"`
+	Slot     *gethcommon.Hash   `json:"slot,omitempty"`     // Set for storage accesses
+	CodeHash *gethcommon.Hash   `json:"codeHash,omitempty"` // Set for code accesses
+	Status   AccessStatus       `json:"status"`
+}
+
+// CompletenessTracker records every state access of an execution, and whether it was backed by the state database
+//
+// It is shared by every reader (and reader copy) of a CompletenessDatabase, so it can be used concurrently.
+type CompletenessTracker struct {
+	mux      sync.Mutex
+	accounts map[gethcommon.Address]AccessStatus
+	storage  map[gethcommon.Address]map[gethcommon.Hash]AccessStatus
+	codes    map[gethcommon.Address]map[gethcommon.Hash]AccessStatus
+}
+
+// NewCompletenessTracker creates a new CompletenessTracker
+func NewCompletenessTracker() *CompletenessTracker {
+	return &CompletenessTracker{
+		accounts: make(map[gethcommon.Address]AccessStatus),
+		storage:  make(map[gethcommon.Address]map[gethcommon.Hash]AccessStatus),
+		codes:    make(map[gethcommon.Address]map[gethcommon.Hash]AccessStatus),
+	}
+}
+
+// Accesses returns every recorded access, sorted by kind, address and slot (or code hash)
+func (t *CompletenessTracker) Accesses() []*StateAccess {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	accesses := make([]*StateAccess, 0, len(t.accounts))
+	for addr, status := range t.accounts {
+		accesses = append(accesses, &StateAccess{Kind: AccessKindAccount, Address: addr, Status: status})
+	}
+	for addr, slots := range t.storage {
+		for slot, status := range slots {
+			accesses = append(accesses, &StateAccess{Kind: AccessKindStorage, Address: addr, Slot: &slot, Status: status})
+		}
+	}
+	for addr, codes := range t.codes {
+		for codeHash, status := range codes {
+			accesses = append(accesses, &StateAccess{Kind: AccessKindCode, Address: addr, CodeHash: &codeHash, Status: status})
+		}
+	}
+
+	kinds := map[string]int{AccessKindAccount: 0, AccessKindStorage: 1, AccessKindCode: 2}
+	sort.Slice(accesses, func(i, j int) bool {
+		a, b := accesses[i], accesses[j]
+		if a.Kind != b.Kind {
+			return kinds[a.Kind] < kinds[b.Kind]
+		}
+		if c := bytes.Compare(a.Address.Bytes(), b.Address.Bytes()); c != 0 {
+			return c < 0
+		}
+		switch a.Kind {
+		case AccessKindStorage:
+			return bytes.Compare(a.Slot.Bytes(), b.Slot.Bytes()) < 0
+		case AccessKindCode:
+			return bytes.Compare(a.CodeHash.Bytes(), b.CodeHash.Bytes()) < 0
+		}
+		return false
+	})
+
+	return accesses
+}
+
+// record records an access, a missing access is never overridden by a later successful one
+func record[K comparable](m map[K]AccessStatus, key K, status AccessStatus) {
+	if m[key] != AccessMissing {
+		m[key] = status
+	}
+}
+
+func (t *CompletenessTracker) recordAccount(addr gethcommon.Address, status AccessStatus) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	record(t.accounts, addr, status)
+}
+
+func (t *CompletenessTracker) recordStorage(addr gethcommon.Address, slot gethcommon.Hash, status AccessStatus) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if _, ok := t.storage[addr]; !ok {
+		t.storage[addr] = make(map[gethcommon.Hash]AccessStatus)
+	}
+	record(t.storage[addr], slot, status)
+}
+
+func (t *CompletenessTracker) recordCode(addr gethcommon.Address, codeHash gethcommon.Hash, status AccessStatus) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if _, ok := t.codes[addr]; !ok {
+		t.codes[addr] = make(map[gethcommon.Hash]AccessStatus)
+	}
+	record(t.codes[addr], codeHash, status)
+}
+
+// CompletenessDatabase is a state database that records every state access (account, storage and bytecode) in a
+// CompletenessTracker, flagging the accesses the underlying database could not serve, which the EVM reads as zero.
+type CompletenessDatabase struct {
+	gethstate.Database
+
+	tracker *CompletenessTracker
+}
+
+// NewCompletenessDatabase creates a new state database recording the state accesses in tracker
+func NewCompletenessDatabase(db gethstate.Database, tracker *CompletenessTracker) *CompletenessDatabase {
+	return &CompletenessDatabase{
+		Database: db,
+		tracker:  tracker,
+	}
+}
+
+// Reader implements the gethstate.Database interface.
+func (db *CompletenessDatabase) Reader(stateRoot gethcommon.Hash) (gethstate.Reader, error) {
+	reader, err := db.Database.Reader(stateRoot)
+	if err != nil {
+		return nil, err
+	}
+	return &completenessReader{reader: reader, tracker: db.tracker}, nil
+}
+
+// ContractCode implements the gethstate.Database interface.
+func (db *CompletenessDatabase) ContractCode(addr gethcommon.Address, codeHash gethcommon.Hash) ([]byte, error) {
+	code, err := db.Database.ContractCode(addr, codeHash)
+	if err != nil || len(code) == 0 {
+		db.tracker.recordCode(addr, codeHash, AccessMissing)
+	} else {
+		db.tracker.recordCode(addr, codeHash, AccessPresent)
+	}
+	return code, err
+}
+
+// ContractCodeSize implements the gethstate.Database interface.
+func (db *CompletenessDatabase) ContractCodeSize(addr gethcommon.Address, codeHash gethcommon.Hash) (int, error) {
+	code, err := db.ContractCode(addr, codeHash)
+	return len(code), err
+}
+
+// completenessReader is a state reader recording the state accesses in a CompletenessTracker
+type completenessReader struct {
+	reader  gethstate.Reader
+	tracker *CompletenessTracker
+}
+
+// Account implements the gethstate.Reader interface.
+func (r *completenessReader) Account(addr gethcommon.Address) (*gethtypes.StateAccount, error) {
+	account, err := r.reader.Account(addr)
+	switch {
+	case err != nil:
+		r.tracker.recordAccount(addr, AccessMissing)
+	case account == nil:
+		r.tracker.recordAccount(addr, AccessAbsent)
+	default:
+		r.tracker.recordAccount(addr, AccessPresent)
+	}
+	return account, err
+}
+
+// Storage implements the gethstate.Reader interface.
+func (r *completenessReader) Storage(addr gethcommon.Address, slot gethcommon.Hash) (gethcommon.Hash, error) {
+	value, err := r.reader.Storage(addr, slot)
+	if err != nil {
+		r.tracker.recordStorage(addr, slot, AccessMissing)
+	} else {
+		r.tracker.recordStorage(addr, slot, AccessPresent)
+	}
+	return value, err
+}
+
+// Copy implements the gethstate.Reader interface, the copy records in the same tracker.
+func (r *completenessReader) Copy() gethstate.Reader {
+	return &completenessReader{reader: r.reader.Copy(), tracker: r.tracker}
+}
@@ -4,17 +4,23 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sync"
 
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	gethstate "github.com/ethereum/go-ethereum/core/state"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/trie/trienode"
 	"github.com/holiman/uint256"
 	"github.com/kkrt-labs/go-utils/ethereum/rpc"
+	"golang.org/x/sync/errgroup"
 )
 
+// maxParallelPrefetches is the maximum number of accounts prefetched concurrently
+const maxParallelPrefetches = 8
+
 // RPCDatabase is a gethstate.Database that reads the state from a remote RPC node.
 type RPCDatabase struct {
 	gethstate.Database
@@ -22,6 +28,14 @@ type RPCDatabase struct {
 	remote                 rpc.Client
 	stateRootToBlockNumber map[gethcommon.Hash]*big.Int
 	currentBlockNumber     *big.Int
+	prefetched             map[gethcommon.Hash]map[gethcommon.Address]*prefetchedAccount
+	proofs                 *ProofDedupCache // Optional cache of the account proofs, shared with other databases
+}
+
+// prefetchedAccount is an account proof prefetched from the remote node
+type prefetchedAccount struct {
+	result  *gethclient.AccountResult
+	storage map[gethcommon.Hash]gethcommon.Hash
 }
 
 // NewRPCDatabase creates a new state database that reads the state from a remote RPC node.
@@ -30,6 +44,7 @@ func NewRPCDatabase(db gethstate.Database, remote rpc.Client) *RPCDatabase {
 		Database:               db,
 		remote:                 remote,
 		stateRootToBlockNumber: make(map[gethcommon.Hash]*big.Int),
+		prefetched:             make(map[gethcommon.Hash]map[gethcommon.Address]*prefetchedAccount),
 	}
 }
 
@@ -40,6 +55,11 @@ func (db *RPCDatabase) MarkBlock(header *gethtypes.Header) {
 	db.currentBlockNumber = header.Number
 }
 
+// UseProofDedupCache makes the readers serve the accounts and storage slots cached in proofs, and cache the accounts they fetch
+func (db *RPCDatabase) UseProofDedupCache(proofs *ProofDedupCache) {
+	db.proofs = proofs
+}
+
 func (db *RPCDatabase) getBlockNumber(stateRoot gethcommon.Hash) (*big.Int, error) {
 	if blockNumber, ok := db.stateRootToBlockNumber[stateRoot]; ok {
 		return blockNumber, nil
@@ -59,9 +79,75 @@ func (db *RPCDatabase) Reader(root gethcommon.Hash) (gethstate.Reader, error) {
 		remote:      db.remote,
 		blockNumber: blockNumber,
 		root:        root,
+		prefetched:  db.prefetched[root],
+		proofs:      db.proofs,
 	}, nil
 }
 
+// Prefetch fetches the accounts and storage slots of the access list at the given state root, with a single eth_getProof call per account.
+// Readers created afterwards serve them without any further RPC call, which saves one eth_getStorageAt call per slot.
+//
+// It must be called before the state is read.
+func (db *RPCDatabase) Prefetch(ctx context.Context, root gethcommon.Hash, accessList gethtypes.AccessList) error {
+	blockNumber, err := db.getBlockNumber(root)
+	if err != nil {
+		return err
+	}
+
+	// Merge the slots of duplicated addresses
+	slots := make(map[gethcommon.Address][]string)
+	for _, tuple := range accessList {
+		list := slots[tuple.Address]
+		for _, slot := range tuple.StorageKeys {
+			list = append(list, slot.Hex())
+		}
+		slots[tuple.Address] = list
+	}
+
+	var mu sync.Mutex
+	prefetched := db.prefetched[root]
+	if prefetched == nil {
+		prefetched = make(map[gethcommon.Address]*prefetchedAccount)
+		db.prefetched[root] = prefetched
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxParallelPrefetches)
+	for addr, keys := range slots {
+		g.Go(func() error {
+			res, err := db.remote.GetProof(gctx, addr, keys, blockNumber)
+			if err != nil {
+				return fmt.Errorf("failed to get proof for address %s and block %v: %v", addr.Hex(), blockNumber, err)
+			}
+			if res == nil {
+				return nil
+			}
+
+			account := &prefetchedAccount{result: res, storage: make(map[gethcommon.Hash]gethcommon.Hash)}
+			for _, slot := range res.StorageProof {
+				if slot.Value != nil {
+					account.storage[gethcommon.HexToHash(slot.Key)] = gethcommon.BigToHash(slot.Value)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			prefetched[addr] = account
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// PrefetchedProof returns the proof of the account prefetched at the given state root, if any
+func (db *RPCDatabase) PrefetchedProof(root gethcommon.Hash, addr gethcommon.Address) *gethclient.AccountResult {
+	if account, ok := db.prefetched[root][addr]; ok {
+		return account.result
+	}
+	return nil
+}
+
 // OpenTrie implements the gethstate.Database interface.
 func (db *RPCDatabase) OpenTrie(root gethcommon.Hash) (gethstate.Trie, error) {
 	if tr, err := db.Database.OpenTrie(root); err == nil {
@@ -113,6 +199,9 @@ type rpcReader struct {
 
 	blockNumber *big.Int        // Block number to retrieve state information
 	root        gethcommon.Hash // State root corresponding to the block number (it is assumed that the state root for the given block does not change (i.e. no re-org))
+
+	prefetched map[gethcommon.Address]*prefetchedAccount // Accounts prefetched at the state root (read-only)
+	proofs     *ProofDedupCache                          // Optional cache of the account proofs
 }
 
 // Account implementing Reader interface, retrieving the account associated with
@@ -122,25 +211,53 @@ type rpcReader struct {
 // - Returns an error only if remote node returns an error
 // - The returned account is safe to modify after the call
 func (r *rpcReader) Account(addr gethcommon.Address) (*gethtypes.StateAccount, error) {
-	account, err := r.remote.GetProof(context.TODO(), addr, nil, r.blockNumber)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get proof for address %s and block %v: %v", addr.Hex(), r.blockNumber, err)
+	var account *gethclient.AccountResult
+	if prefetched, ok := r.prefetched[addr]; ok {
+		account = prefetched.result
+	} else if account = r.proofs.Get(r.root, addr); account == nil {
+		var err error
+		account, err = r.remote.GetProof(context.TODO(), addr, nil, r.blockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get proof for address %s and block %v: %v", addr.Hex(), r.blockNumber, err)
+		}
+		r.proofs.Add(r.root, addr, account)
 	}
 
 	if account == nil {
 		return nil, nil
 	}
 
-	balance, hasOverflowed := uint256.FromBig(account.Balance)
-	if hasOverflowed {
-		return nil, fmt.Errorf("failed to convert balance %v to uint256", account.Balance)
+	return stateAccount(account)
+}
+
+// stateAccount returns the state account of a proof
+//
+// Some nodes (e.g. Hardhat Network, Anvil on a fork) report a zero storage hash, code hash or a missing balance for
+// accounts without storage or code (typically accounts that do not exist), instead of the empty trie root and the hash
+// of the empty code: they are normalized, as an actual zero code hash would make the account non-empty for the EVM and
+// change the post-state root.
+func stateAccount(account *gethclient.AccountResult) (*gethtypes.StateAccount, error) {
+	balance := new(uint256.Int)
+	if account.Balance != nil {
+		var hasOverflowed bool
+		if balance, hasOverflowed = uint256.FromBig(account.Balance); hasOverflowed {
+			return nil, fmt.Errorf("failed to convert balance %v to uint256", account.Balance)
+		}
+	}
+
+	root, codeHash := account.StorageHash, account.CodeHash
+	if root == (gethcommon.Hash{}) {
+		root = gethtypes.EmptyRootHash
+	}
+	if codeHash == (gethcommon.Hash{}) {
+		codeHash = gethtypes.EmptyCodeHash
 	}
 
 	return &gethtypes.StateAccount{
 		Nonce:    account.Nonce,
 		Balance:  balance,
-		Root:     account.StorageHash,
-		CodeHash: account.CodeHash.Bytes(),
+		Root:     root,
+		CodeHash: codeHash.Bytes(),
 	}, nil
 }
 
@@ -151,6 +268,15 @@ func (r *rpcReader) Account(addr gethcommon.Address) (*gethtypes.StateAccount, e
 // - Returns an error only if an unexpected issue occurs
 // - The returned storage slot is safe to modify after the call
 func (r *rpcReader) Storage(addr gethcommon.Address, slot gethcommon.Hash) (gethcommon.Hash, error) {
+	if prefetched, ok := r.prefetched[addr]; ok {
+		if value, ok := prefetched.storage[slot]; ok {
+			return value, nil
+		}
+	}
+	if value, ok := r.proofs.Storage(r.root, addr, slot); ok {
+		return value, nil
+	}
+
 	value, err := r.remote.StorageAt(context.TODO(), addr, slot, r.blockNumber)
 	if err != nil {
 		return gethcommon.Hash{}, fmt.Errorf("failed to get storage slot for address %s and slot %s and block %v: %v", addr.Hex(), slot.Hex(), r.blockNumber, err)
@@ -164,6 +290,8 @@ func (r *rpcReader) Copy() gethstate.Reader {
 		blockNumber: r.blockNumber,
 		remote:      r.remote,
 		root:        r.root,
+		prefetched:  r.prefetched,
+		proofs:      r.proofs,
 	}
 }
 
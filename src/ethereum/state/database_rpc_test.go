@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"math/big"
 	"testing"
 
@@ -60,6 +61,20 @@ func TestRPCDatabase(t *testing.T) {
 		assert.Equal(t, accountResult.StorageHash, stateAccount.Root, "Root mismatch")
 	})
 
+	t.Run("reader.GetProof empty account", func(t *testing.T) {
+		// As reported by Hardhat Network for accounts that do not exist
+		emptyAddr := gethcommon.HexToAddress("0x000000000000000000000000000000000000dead")
+		remote.EXPECT().
+			GetProof(gomock.Any(), emptyAddr, nil, blockNumber).
+			Return(&gethclient.AccountResult{Address: emptyAddr}, nil)
+
+		stateAccount, err := reader.Account(emptyAddr)
+		require.NoError(t, err)
+		assert.True(t, stateAccount.Balance.IsZero())
+		assert.Equal(t, gethtypes.EmptyCodeHash.Bytes(), stateAccount.CodeHash)
+		assert.Equal(t, gethtypes.EmptyRootHash, stateAccount.Root)
+	})
+
 	t.Run("reader.StorageAt", func(t *testing.T) {
 		slot := gethcommon.HexToHash("0x0fb6d5609c9edab75bf587ea7449e6e6940d6e3df1992a1bd96ca8b74ffd16fc")
 		remote.EXPECT().
@@ -95,6 +110,66 @@ func TestRPCDatabase(t *testing.T) {
 	})
 }
 
+func TestRPCDatabasePrefetch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	remote := rpcmock.NewMockClient(ctrl)
+	db := NewRPCDatabase(nil, remote)
+
+	stateRoot := gethcommon.HexToHash("0x6f39539da0b571e36e04cdee1ef9273ce168644d63822352f3a18c0504220166")
+	accountAddr := gethcommon.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")
+	slot1 := gethcommon.HexToHash("0x1")
+	slot2 := gethcommon.HexToHash("0x2")
+	otherSlot := gethcommon.HexToHash("0x3")
+	blockNumber := big.NewInt(15)
+	accountResult := &gethclient.AccountResult{
+		Address: accountAddr,
+		Balance: big.NewInt(1),
+		Nonce:   1,
+		StorageProof: []gethclient.StorageResult{
+			{Key: slot1.Hex(), Value: big.NewInt(0xabcd)},
+			{Key: slot2.Hex(), Value: big.NewInt(0)},
+		},
+	}
+
+	db.MarkBlock(&gethtypes.Header{Root: stateRoot, Number: blockNumber})
+
+	// Duplicated addresses are merged into a single call
+	remote.EXPECT().
+		GetProof(gomock.Any(), accountAddr, []string{slot1.Hex(), slot2.Hex()}, blockNumber).
+		Return(accountResult, nil)
+	require.NoError(t, db.Prefetch(context.Background(), stateRoot, gethtypes.AccessList{
+		{Address: accountAddr, StorageKeys: []gethcommon.Hash{slot1}},
+		{Address: accountAddr, StorageKeys: []gethcommon.Hash{slot2}},
+	}))
+	assert.Equal(t, accountResult, db.PrefetchedProof(stateRoot, accountAddr))
+
+	reader, err := db.Reader(stateRoot)
+	require.NoError(t, err)
+
+	// Prefetched account and slots are served without RPC calls
+	stateAccount, err := reader.Account(accountAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stateAccount.Nonce)
+
+	value, err := reader.Storage(accountAddr, slot1)
+	require.NoError(t, err)
+	assert.Equal(t, gethcommon.HexToHash("0xabcd"), value)
+
+	value, err = reader.Copy().Storage(accountAddr, slot2)
+	require.NoError(t, err)
+	assert.Equal(t, gethcommon.Hash{}, value)
+
+	// Other slots are still fetched from the remote
+	remote.EXPECT().
+		StorageAt(gomock.Any(), accountAddr, otherSlot, blockNumber).
+		Return(hexutil.MustDecode("0x01"), nil)
+	value, err = reader.Storage(accountAddr, otherSlot)
+	require.NoError(t, err)
+	assert.Equal(t, gethcommon.HexToHash("0x1"), value)
+}
+
 func TestStateAccessTrackerDatabaseImplementsInterface(t *testing.T) {
 	assert.Implements(t, (*gethstate.Database)(nil), new(AccessTrackerDatabase))
 }
@@ -1,6 +1,8 @@
 package state
 
 import (
+	"maps"
+
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	gethstate "github.com/ethereum/go-ethereum/core/state"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
@@ -56,6 +58,7 @@ func (db *AccessTrackerDatabase) ContractCodeSize(addr gethcommon.Address, codeH
 
 type AccessTracker struct {
 	Accounts map[gethcommon.Address]*gethtypes.StateAccount             `json:"accounts"`
+	Absent   map[gethcommon.Address]struct{}                            `json:"absent"` // Accounts read but absent from the state
 	Storage  map[gethcommon.Address]map[gethcommon.Hash]gethcommon.Hash `json:"storage"`
 }
 
@@ -92,6 +95,7 @@ func (m *AccessTrackerManager) Clear() {
 func newStateAccessTracker() *AccessTracker {
 	return &AccessTracker{
 		Accounts: make(map[gethcommon.Address]*gethtypes.StateAccount),
+		Absent:   make(map[gethcommon.Address]struct{}),
 		Storage:  make(map[gethcommon.Address]map[gethcommon.Hash]gethcommon.Hash),
 	}
 }
@@ -120,6 +124,8 @@ func (r *stateAccessTrackerReader) Account(addr gethcommon.Address) (*gethtypes.
 
 	if account != nil {
 		r.tracker.Accounts[addr] = account.Copy()
+	} else {
+		r.tracker.Absent[addr] = struct{}{}
 	}
 
 	return account, nil
@@ -148,6 +154,7 @@ func (r *stateAccessTrackerReader) Copy() gethstate.Reader {
 		reader: r.reader.Copy(),
 		tracker: &AccessTracker{
 			Accounts: copyAccounts(r.tracker.Accounts),
+			Absent:   maps.Clone(r.tracker.Absent),
 			Storage:  copyStorage(r.tracker.Storage),
 		},
 	}
@@ -0,0 +1,152 @@
+package state
+
+import (
+	"container/list"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+)
+
+// ProofDedupCache is a bounded cache of the account proofs (eth_getProof results) fetched from a remote node, keyed by
+// state root and account, deduplicating the proofs fetched more than once under the same state root during a run
+//
+// A proof contains the path from the state root down to the account, so it is only valid under its state root: an
+// account changed by a block, or any other change of the state, yields a new state root and so a new key, and a cached
+// entry can never be stale. The flip side is that the blocks of a range do not share proofs, each block being proved
+// under the state root of its parent: the cache serves the accounts fetched during the execution of a block again for
+// its pre-state proofs, and the blocks preflighted again in the same run. Only the post-state proofs of the accounts and
+// slots deleted by a block, fetched at its state root, may serve its next block. Entries of the state roots no longer
+// accessed are evicted, least recently used first.
+//
+// The storage proofs of the entries of an account are merged, so an entry serves every slot proved under its state root.
+// All methods are safe for concurrent use, and a nil *ProofDedupCache is a cache that is always empty.
+type ProofDedupCache struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[proofDedupKey]*list.Element
+	lru     *list.List // *proofDedupEntry, most recently used first
+
+	hits, misses uint64
+}
+
+type proofDedupKey struct {
+	root    gethcommon.Hash
+	account gethcommon.Address
+}
+
+type proofDedupEntry struct {
+	key     proofDedupKey
+	result  *gethclient.AccountResult
+	storage map[gethcommon.Hash]int // Index of the storage proofs of result by slot
+}
+
+// NewProofDedupCache creates a cache holding the proofs of at most size accounts
+func NewProofDedupCache(size int) *ProofDedupCache {
+	return &ProofDedupCache{
+		size:    size,
+		entries: make(map[proofDedupKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Get returns the cached proof of account under root, with every storage proof cached for it, or nil if it is not cached
+//
+// The returned proof must not be modified.
+func (c *ProofDedupCache) Get(root gethcommon.Hash, account gethcommon.Address) *gethclient.AccountResult {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[proofDedupKey{root, account}]
+	if !ok {
+		c.misses++
+		return nil
+	}
+	c.hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*proofDedupEntry).result
+}
+
+// Storage returns the cached value of a storage slot of account under root, if its storage proof is cached
+func (c *ProofDedupCache) Storage(root gethcommon.Hash, account gethcommon.Address, slot gethcommon.Hash) (gethcommon.Hash, bool) {
+	if c == nil {
+		return gethcommon.Hash{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[proofDedupKey{root, account}]
+	if !ok {
+		return gethcommon.Hash{}, false
+	}
+	entry := e.Value.(*proofDedupEntry)
+	i, ok := entry.storage[slot]
+	if !ok {
+		return gethcommon.Hash{}, false
+	}
+	c.lru.MoveToFront(e)
+	if value := entry.result.StorageProof[i].Value; value != nil {
+		return gethcommon.BigToHash(value), true
+	}
+	return gethcommon.Hash{}, true
+}
+
+// Add caches the proof of account fetched under root, merging its storage proofs with the cached ones
+func (c *ProofDedupCache) Add(root gethcommon.Hash, account gethcommon.Address, res *gethclient.AccountResult) {
+	if c == nil || res == nil || c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := proofDedupKey{root, account}
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		e.Value = mergeProofs(e.Value.(*proofDedupEntry), res)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(mergeProofs(&proofDedupEntry{key: key}, res))
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Remove(c.lru.Back()).(*proofDedupEntry)
+		delete(c.entries, oldest.key)
+	}
+}
+
+// Stats returns the number of lookups of a cached proof (Get) served by the cache and not
+func (c *ProofDedupCache) Stats() (hits, misses uint64) {
+	if c == nil {
+		return 0, 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// mergeProofs returns a copy of the entry with the account proof res and the storage proofs of both
+// Cached results are never modified, as they may still be used by the callers of Get.
+func mergeProofs(entry *proofDedupEntry, res *gethclient.AccountResult) *proofDedupEntry {
+	merged := &proofDedupEntry{key: entry.key, storage: make(map[gethcommon.Hash]int)}
+	cpy := *res
+	cpy.StorageProof = nil
+	add := func(proofs []gethclient.StorageResult) {
+		for _, proof := range proofs {
+			slot := gethcommon.HexToHash(proof.Key)
+			if _, ok := merged.storage[slot]; !ok {
+				merged.storage[slot] = len(cpy.StorageProof)
+				cpy.StorageProof = append(cpy.StorageProof, proof)
+			}
+		}
+	}
+	add(res.StorageProof)
+	if entry.result != nil {
+		add(entry.result.StorageProof)
+	}
+	merged.result = &cpy
+	return merged
+}
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	rpcmock "github.com/kkrt-labs/go-utils/ethereum/rpc/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProofDedupCache(t *testing.T) {
+	rootA, rootB := gethcommon.HexToHash("0xa"), gethcommon.HexToHash("0xb")
+	account := gethcommon.HexToAddress("0x1")
+	slot1, slot2 := gethcommon.HexToHash("0x1"), gethcommon.HexToHash("0x2")
+
+	cache := NewProofDedupCache(2)
+	cache.Add(rootA, account, &gethclient.AccountResult{Balance: big.NewInt(1), StorageProof: []gethclient.StorageResult{{Key: slot1.Hex(), Value: big.NewInt(10)}}})
+	cache.Add(rootA, account, &gethclient.AccountResult{Balance: big.NewInt(1), StorageProof: []gethclient.StorageResult{{Key: "0x2", Value: nil}}})
+
+	res := cache.Get(rootA, account)
+	require.NotNil(t, res)
+	assert.Len(t, res.StorageProof, 2, "storage proofs must be merged")
+	value, ok := cache.Storage(rootA, account, slot1)
+	assert.True(t, ok)
+	assert.Equal(t, gethcommon.BigToHash(big.NewInt(10)), value)
+	value, ok = cache.Storage(rootA, account, slot2)
+	assert.True(t, ok)
+	assert.Equal(t, gethcommon.Hash{}, value)
+	_, ok = cache.Storage(rootA, account, gethcommon.HexToHash("0x3"))
+	assert.False(t, ok)
+
+	// Proofs are only served under their state root
+	assert.Nil(t, cache.Get(rootB, account))
+
+	// Least recently used entries are evicted
+	cache.Add(rootB, account, &gethclient.AccountResult{Balance: big.NewInt(2)})
+	assert.NotNil(t, cache.Get(rootA, account))
+	cache.Add(rootB, gethcommon.HexToAddress("0x2"), &gethclient.AccountResult{Balance: big.NewInt(3)})
+	assert.Nil(t, cache.Get(rootB, account))
+	assert.NotNil(t, cache.Get(rootA, account))
+
+	hits, misses := cache.Stats()
+	assert.Equal(t, uint64(3), hits)
+	assert.Equal(t, uint64(2), misses)
+
+	var empty *ProofDedupCache
+	empty.Add(rootA, account, res)
+	assert.Nil(t, empty.Get(rootA, account))
+}
+
+func TestRPCDatabaseProofDedupCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	remote := rpcmock.NewMockClient(ctrl)
+	cache := NewProofDedupCache(16)
+	root := gethcommon.HexToHash("0x6f39539da0b571e36e04cdee1ef9273ce168644d63822352f3a18c0504220166")
+	header := &gethtypes.Header{Root: root, Number: big.NewInt(15)}
+	account := gethcommon.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")
+	slot := gethcommon.HexToHash("0x1")
+
+	// A proof fetched by a database is served to the readers of other databases sharing the cache
+	remote.EXPECT().GetProof(gomock.Any(), account, nil, header.Number).Return(&gethclient.AccountResult{Balance: big.NewInt(1), Nonce: 7}, nil).Times(1)
+	for i := 0; i < 2; i++ {
+		db := NewRPCDatabase(nil, remote)
+		db.UseProofDedupCache(cache)
+		db.MarkBlock(header)
+		reader, err := db.Reader(root)
+		require.NoError(t, err)
+		stateAccount, err := reader.Account(account)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(7), stateAccount.Nonce)
+	}
+
+	// Storage slots with a cached proof are served without eth_getStorageAt
+	cache.Add(root, account, &gethclient.AccountResult{StorageProof: []gethclient.StorageResult{{Key: slot.Hex(), Value: big.NewInt(42)}}})
+	db := NewRPCDatabase(nil, remote)
+	db.UseProofDedupCache(cache)
+	db.MarkBlock(header)
+	reader, err := db.Reader(root)
+	require.NoError(t, err)
+	value, err := reader.Storage(account, slot)
+	require.NoError(t, err)
+	assert.Equal(t, gethcommon.BigToHash(big.NewInt(42)), value)
+}
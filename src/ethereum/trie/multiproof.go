@@ -0,0 +1,309 @@
+package trie
+
+import (
+	"fmt"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// multiproofRef replaces, in a multiproof node, the reference (hash) of a child node that is part of the multiproof.
+// A single 0x00 byte is never a valid MPT child reference, which is either empty, a 32 bytes hash or an embedded node.
+var multiproofRef = []byte{0x00}
+
+// EncodeMultiproof compresses a set of MPT nodes of the state rooted at stateRoot (account trie nodes and the storage
+// trie nodes of its accounts) into a multiproof
+//
+// The multiproof lists the nodes reachable from stateRoot in depth-first pre-order, from the state root down to the
+// storage tries of the accounts, in which the hash of every child node that is part of the multiproof (including the
+// storage root of the accounts) is replaced with a single 0x00 byte, as it is recomputed when decoding. Paths shared
+// by several accounts and slots are thus encoded once, and without the hashes linking them. The nodes that are not
+// reachable from stateRoot follow, uncompressed, so no node of the set is lost.
+func EncodeMultiproof(stateRoot gethcommon.Hash, nodes [][]byte) ([][]byte, error) {
+	e := &multiproofEncoder{
+		nodes:   make(map[gethcommon.Hash][]byte, len(nodes)),
+		emitted: make(map[gethcommon.Hash]bool, len(nodes)),
+		out:     make([][]byte, 0, len(nodes)),
+	}
+	order := make([]gethcommon.Hash, 0, len(nodes))
+	for _, node := range nodes {
+		hash := crypto.Keccak256Hash(node)
+		if _, ok := e.nodes[hash]; !ok {
+			order = append(order, hash)
+		}
+		e.nodes[hash] = node
+	}
+
+	if stateRoot != gethtypes.EmptyRootHash {
+		if _, ok := e.nodes[stateRoot]; !ok {
+			return nil, fmt.Errorf("state root %v node is missing", stateRoot.Hex())
+		}
+		if err := e.encode(stateRoot, false); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, hash := range order {
+		if !e.emitted[hash] {
+			e.out = append(e.out, e.nodes[hash])
+		}
+	}
+	return e.out, nil
+}
+
+type multiproofEncoder struct {
+	nodes   map[gethcommon.Hash][]byte
+	emitted map[gethcommon.Hash]bool
+	out     [][]byte
+}
+
+// encode appends the node of hash then its children that are part of the multiproof
+func (e *multiproofEncoder) encode(hash gethcommon.Hash, storage bool) error {
+	e.emitted[hash] = true
+	pos := len(e.out)
+	e.out = append(e.out, nil) // Set once the references of the node are replaced
+
+	items, err := splitNode(e.nodes[hash])
+	if err != nil {
+		return fmt.Errorf("invalid node %v: %v", hash.Hex(), err)
+	}
+
+	var children []gethcommon.Hash
+	child := func(i int) {
+		if hash, ok := e.ref(items[i]); ok {
+			items[i] = multiproofRef
+			children = append(children, hash)
+		}
+	}
+
+	var storageRoot *gethcommon.Hash
+	switch len(items) {
+	case 17: // Branch node
+		for i := 0; i < 16; i++ {
+			child(i)
+		}
+	case 2: // Extension or leaf node
+		leaf, err := isLeaf(items[0])
+		if err != nil {
+			return fmt.Errorf("invalid node %v: %v", hash.Hex(), err)
+		}
+		if !leaf {
+			child(1)
+		} else if !storage {
+			if items[1], storageRoot, err = e.compressAccount(items[1]); err != nil {
+				return fmt.Errorf("invalid account leaf %v: %v", hash.Hex(), err)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid node %v: %d items", hash.Hex(), len(items))
+	}
+
+	if e.out[pos], err = rlp.EncodeToBytes(items); err != nil {
+		return err
+	}
+	for _, hash := range children {
+		if err := e.encode(hash, storage); err != nil {
+			return err
+		}
+	}
+	if storageRoot != nil {
+		return e.encode(*storageRoot, true)
+	}
+	return nil
+}
+
+// ref returns the hash referenced by a child item if its node is part of the multiproof and not already emitted
+func (e *multiproofEncoder) ref(item rlp.RawValue) (gethcommon.Hash, bool) {
+	content, _, err := rlp.SplitString(item)
+	if err != nil || len(content) != gethcommon.HashLength {
+		return gethcommon.Hash{}, false
+	}
+	hash := gethcommon.BytesToHash(content)
+	if _, ok := e.nodes[hash]; !ok || e.emitted[hash] {
+		return gethcommon.Hash{}, false
+	}
+	return hash, true
+}
+
+// compressAccount replaces the storage root of an account leaf value if its storage trie is part of the multiproof
+func (e *multiproofEncoder) compressAccount(value rlp.RawValue) (rlp.RawValue, *gethcommon.Hash, error) {
+	account, err := splitAccount(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash, ok := e.ref(account[2])
+	if !ok {
+		return value, nil, nil
+	}
+	account[2] = multiproofRef
+	value, err = encodeAccount(account)
+	return value, &hash, err
+}
+
+// DecodeMultiproof decodes a multiproof created with EncodeMultiproof and returns its MPT nodes
+//
+// The hashes of the compressed nodes are recomputed bottom-up, so the multiproof is verified against stateRoot:
+// an error is returned if any node was tampered with.
+func DecodeMultiproof(stateRoot gethcommon.Hash, multiproof [][]byte) ([][]byte, error) {
+	d := &multiproofDecoder{multiproof: multiproof, nodes: make([][]byte, 0, len(multiproof))}
+	if stateRoot != gethtypes.EmptyRootHash {
+		root, err := d.decode(false)
+		if err != nil {
+			return nil, err
+		}
+		if root != stateRoot {
+			return nil, fmt.Errorf("multiproof root mismatch: expected %v but multiproof hashes to %v", stateRoot.Hex(), root.Hex())
+		}
+	}
+	return append(d.nodes, multiproof[d.pos:]...), nil
+}
+
+type multiproofDecoder struct {
+	multiproof [][]byte
+	pos        int
+	nodes      [][]byte
+}
+
+// decode decodes the next node of the multiproof and its children, and returns its hash
+func (d *multiproofDecoder) decode(storage bool) (gethcommon.Hash, error) {
+	if d.pos >= len(d.multiproof) {
+		return gethcommon.Hash{}, fmt.Errorf("truncated multiproof")
+	}
+	pos := d.pos
+	d.pos++
+
+	items, err := splitNode(d.multiproof[pos])
+	if err != nil {
+		return gethcommon.Hash{}, fmt.Errorf("invalid multiproof node %d: %v", pos, err)
+	}
+
+	child := func(i int) error {
+		if !isMultiproofRef(items[i]) {
+			return nil
+		}
+		hash, err := d.decode(storage)
+		if err != nil {
+			return err
+		}
+		items[i], err = rlp.EncodeToBytes(hash.Bytes())
+		return err
+	}
+
+	switch len(items) {
+	case 17:
+		for i := 0; i < 16; i++ {
+			if err := child(i); err != nil {
+				return gethcommon.Hash{}, err
+			}
+		}
+	case 2:
+		leaf, err := isLeaf(items[0])
+		if err != nil {
+			return gethcommon.Hash{}, fmt.Errorf("invalid multiproof node %d: %v", pos, err)
+		}
+		if !leaf {
+			err = child(1)
+		} else if !storage {
+			items[1], err = d.decompressAccount(items[1])
+		}
+		if err != nil {
+			return gethcommon.Hash{}, err
+		}
+	default:
+		return gethcommon.Hash{}, fmt.Errorf("invalid multiproof node %d: %d items", pos, len(items))
+	}
+
+	node, err := rlp.EncodeToBytes(items)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+	d.nodes = append(d.nodes, node)
+	return crypto.Keccak256Hash(node), nil
+}
+
+// decompressAccount decodes the storage trie of an account leaf value if it is part of the multiproof
+func (d *multiproofDecoder) decompressAccount(value rlp.RawValue) (rlp.RawValue, error) {
+	account, err := splitAccount(value)
+	if err != nil {
+		return nil, err
+	}
+	if !isMultiproofRef(account[2]) {
+		return value, nil
+	}
+	storageRoot, err := d.decode(true)
+	if err != nil {
+		return nil, err
+	}
+	if account[2], err = rlp.EncodeToBytes(storageRoot.Bytes()); err != nil {
+		return nil, err
+	}
+	return encodeAccount(account)
+}
+
+func isMultiproofRef(item rlp.RawValue) bool {
+	return len(item) == 1 && item[0] == multiproofRef[0]
+}
+
+// splitNode returns the raw RLP items of a MPT node
+func splitNode(node []byte) ([]rlp.RawValue, error) {
+	content, rest, err := rlp.SplitList(node)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing bytes after node")
+	}
+	return splitItems(content)
+}
+
+func splitItems(content []byte) ([]rlp.RawValue, error) {
+	var items []rlp.RawValue
+	for len(content) > 0 {
+		_, _, rest, err := rlp.Split(content)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, rlp.RawValue(content[:len(content)-len(rest)]))
+		content = rest
+	}
+	return items, nil
+}
+
+// isLeaf returns whether the compact encoded key of a short node is the one of a leaf node (i.e. has the terminator flag)
+func isLeaf(key rlp.RawValue) (bool, error) {
+	content, _, err := rlp.SplitString(key)
+	if err != nil {
+		return false, err
+	}
+	if len(content) == 0 {
+		return false, fmt.Errorf("empty node key")
+	}
+	return content[0]&0x20 != 0, nil
+}
+
+// splitAccount returns the raw RLP items of the account encoded in a leaf value (nonce, balance, storage root, code hash)
+func splitAccount(value rlp.RawValue) ([]rlp.RawValue, error) {
+	content, _, err := rlp.SplitString(value)
+	if err != nil {
+		return nil, err
+	}
+	account, err := splitNode(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(account) != 4 {
+		return nil, fmt.Errorf("invalid account: %d items", len(account))
+	}
+	return account, nil
+}
+
+// encodeAccount encodes the raw RLP items of an account as a leaf value
+func encodeAccount(account []rlp.RawValue) (rlp.RawValue, error) {
+	encoded, err := rlp.EncodeToBytes(account)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(encoded)
+}
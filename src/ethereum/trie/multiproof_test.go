@@ -0,0 +1,112 @@
+package trie
+
+import (
+	"bytes"
+	"math/big"
+	"slices"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nodeBlobs(set *trienode.NodeSet) [][]byte {
+	var blobs [][]byte
+	for _, n := range set.Nodes {
+		blobs = append(blobs, n.Blob)
+	}
+	return blobs
+}
+
+// testStateNodes returns the nodes of a state with accounts with and without storage (two of them sharing the same storage trie)
+func testStateNodes(t *testing.T) (gethcommon.Hash, [][]byte) {
+	storageTrie := trie.NewEmpty(newTestTrieDB())
+	for i := int64(0); i < 20; i++ {
+		value, err := rlp.EncodeToBytes(big.NewInt(i + 1).Bytes())
+		require.NoError(t, err)
+		storageTrie.MustUpdate(StorageTrieKey(gethcommon.BigToHash(big.NewInt(i)).Bytes()), value)
+	}
+	storageRoot, storageSet := storageTrie.Commit(false)
+	nodes := nodeBlobs(storageSet)
+
+	accountTrie := trie.NewEmpty(newTestTrieDB())
+	for i := int64(0); i < 30; i++ {
+		account := &gethtypes.StateAccount{
+			Nonce:    uint64(i),
+			Balance:  uint256.NewInt(uint64(1000 * i)),
+			Root:     gethtypes.EmptyRootHash,
+			CodeHash: gethtypes.EmptyCodeHash.Bytes(),
+		}
+		if i == 3 || i == 7 {
+			account.Root = storageRoot
+		}
+		value, err := rlp.EncodeToBytes(account)
+		require.NoError(t, err)
+		accountTrie.MustUpdate(AccountTrieKey(gethcommon.BigToAddress(big.NewInt(i))), value)
+	}
+	stateRoot, accountSet := accountTrie.Commit(false)
+	return stateRoot, append(nodes, nodeBlobs(accountSet)...)
+}
+
+func sortBlobs(blobs [][]byte) [][]byte {
+	sorted := slices.Clone(blobs)
+	slices.SortFunc(sorted, bytes.Compare)
+	return sorted
+}
+
+func totalSize(blobs [][]byte) (size int) {
+	for _, blob := range blobs {
+		size += len(blob)
+	}
+	return size
+}
+
+func TestMultiproof(t *testing.T) {
+	stateRoot, nodes := testStateNodes(t)
+	extra := []byte{0xc2, 0x20, 0x01} // Not reachable from the state root
+	nodes = append(nodes, extra)
+
+	multiproof, err := EncodeMultiproof(stateRoot, nodes)
+	require.NoError(t, err)
+	assert.Len(t, multiproof, len(nodes))
+	assert.Less(t, totalSize(multiproof), totalSize(nodes))
+	assert.Equal(t, extra, multiproof[len(multiproof)-1])
+
+	decoded, err := DecodeMultiproof(stateRoot, multiproof)
+	require.NoError(t, err)
+	assert.Equal(t, sortBlobs(nodes), sortBlobs(decoded))
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := slices.Clone(multiproof)
+		last := slices.Clone(tampered[len(tampered)-2]) // Last node reachable from the state root
+		last[len(last)-1]++
+		tampered[len(tampered)-2] = last
+		_, err := DecodeMultiproof(stateRoot, tampered)
+		assert.ErrorContains(t, err, "multiproof root mismatch")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := DecodeMultiproof(stateRoot, multiproof[:2])
+		assert.ErrorContains(t, err, "truncated multiproof")
+	})
+
+	t.Run("missing root", func(t *testing.T) {
+		_, err := EncodeMultiproof(crypto.Keccak256Hash([]byte("unknown")), nodes)
+		assert.ErrorContains(t, err, "node is missing")
+	})
+
+	t.Run("empty state", func(t *testing.T) {
+		multiproof, err := EncodeMultiproof(gethtypes.EmptyRootHash, [][]byte{extra})
+		require.NoError(t, err)
+		decoded, err := DecodeMultiproof(gethtypes.EmptyRootHash, multiproof)
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{extra}, decoded)
+	})
+}
@@ -48,9 +48,24 @@ func NodeSetFromStateProofs(stateRoot gethcommon.Hash, accountProofs []*AccountP
 // NodeSetFromStateTransitionProofs constructs a MPT node set from a set of state transition proofs.
 // It verifies every proof and an error is returned, if any of the proofs is invalid.
 func NodeSetFromStateTransitionProofs(preRoot, postRoot gethcommon.Hash, preProofs, postProofs []*AccountProof) (*trienode.MergedNodeSet, error) {
+	return NodeSetFromStateTransitionProofsFunc(preRoot, postRoot, func(fn func(*AccountProof) error) error {
+		for _, accountProof := range preProofs {
+			if err := fn(accountProof); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, postProofs)
+}
+
+// NodeSetFromStateTransitionProofsFunc is like NodeSetFromStateTransitionProofs but iterates the pre-state proofs with eachPreProof,
+// so they do not need to be all held in memory (e.g. when streamed from disk). eachPreProof is called twice.
+func NodeSetFromStateTransitionProofsFunc(preRoot, postRoot gethcommon.Hash, eachPreProof func(func(*AccountProof) error) error, postProofs []*AccountProof) (*trienode.MergedNodeSet, error) {
 	// Compute node set for accounts world state
 	accountNodeSet := NewAccountNodeSet()
-	err := accountNodeSet.AddAccountNodes(preRoot, preProofs)
+	err := eachPreProof(func(accountProof *AccountProof) error {
+		return accountNodeSet.AddAccountNodes(preRoot, []*AccountProof{accountProof})
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to add account nodes for pre-state root %v: %w", preRoot.Hex(), err)
 	}
@@ -69,10 +84,10 @@ func NodeSetFromStateTransitionProofs(preRoot, postRoot gethcommon.Hash, preProo
 		postProofsByAddress[accountProof.Address] = accountProof
 	}
 
-	for _, accountProof := range preProofs {
+	err = eachPreProof(func(accountProof *AccountProof) error {
 		// If the account has no storage, we skip it
 		if accountProof.StorageHash == (gethcommon.Hash{}) {
-			continue
+			return nil
 		}
 
 		// Compute the storage node set for the account
@@ -82,7 +97,7 @@ func NodeSetFromStateTransitionProofs(preRoot, postRoot gethcommon.Hash, preProo
 			accountProof.Storage,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add storage nodes for account %v: %w", accountProof.Address.Hex(), err)
+			return fmt.Errorf("failed to add storage nodes for account %v: %w", accountProof.Address.Hex(), err)
 		}
 
 		// Add orphan nodes for storage that were deleted during the state transition
@@ -93,14 +108,18 @@ func NodeSetFromStateTransitionProofs(preRoot, postRoot gethcommon.Hash, preProo
 				postAccountProof.Storage,
 			)
 			if err != nil {
-				return nil, fmt.Errorf("failed to add storage orphan nodes for account %v: %w", accountProof.Address.Hex(), err)
+				return fmt.Errorf("failed to add storage orphan nodes for account %v: %w", accountProof.Address.Hex(), err)
 			}
 		}
 
 		err = mergedNodeSet.Merge(provedStorageNodeSet.Set())
 		if err != nil {
-			return nil, fmt.Errorf("failed to merge storage node set for account %v: %w", accountProof.Address.Hex(), err)
+			return fmt.Errorf("failed to merge storage node set for account %v: %w", accountProof.Address.Hex(), err)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return mergedNodeSet, nil
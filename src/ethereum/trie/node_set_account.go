@@ -1,6 +1,8 @@
 package trie
 
 import (
+	"fmt"
+
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient/gethclient"
@@ -39,6 +41,25 @@ func AccountProofFromRPC(res *gethclient.AccountResult) *AccountProof {
 	return proof
 }
 
+// Exists verifies the account proof against the given state root and returns whether the account exists in the state.
+//
+// An account that is absent from the state trie (never created, self-destructed or removed as empty) is proved
+// by a valid exclusion proof, in which case Exists returns false.
+func (p *AccountProof) Exists(root gethcommon.Hash) (bool, error) {
+	proofDB := memorydb.New()
+	err := trie.StoreHexProofs(p.Proof, proofDB)
+	if err != nil {
+		return false, err
+	}
+
+	v, err := trie.VerifyProof(root, AccountTrieKey(p.Address), proofDB)
+	if err != nil {
+		return false, fmt.Errorf("invalid proof for account %v: %v", p.Address.Hex(), err)
+	}
+
+	return v != nil, nil
+}
+
 // AccountsNodeSet is a wrapper around trienode.NodeSet that allows to add
 // accounts to a MPT node set with proof verification
 type AccountsNodeSet struct {
@@ -64,3 +64,24 @@ func TestAccountsNodeSet(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountProofExists(t *testing.T) {
+	data := loadStateTransitionProofs(t, "1_21344154")
+
+	// Accounts that are absent from the state are returned with an empty code hash
+	for _, proof := range data.PreProofs {
+		exists, err := proof.Exists(data.PreRoot)
+		require.NoError(t, err)
+		assert.Equal(t, proof.CodeHash != (gethcommon.Hash{}), exists, "Existence mismatch for account %v", proof.Address.Hex())
+	}
+
+	for _, proof := range data.PostProofs {
+		exists, err := proof.Exists(data.PostRoot)
+		require.NoError(t, err)
+		assert.Equal(t, proof.CodeHash != (gethcommon.Hash{}), exists, "Existence mismatch for account %v", proof.Address.Hex())
+	}
+
+	// Proof verified against the wrong root is invalid
+	_, err := data.PreProofs[0].Exists(gethcommon.Hash{})
+	assert.Error(t, err)
+}
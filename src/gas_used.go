@@ -0,0 +1,45 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// errGasUsedOutOfRange is reported for blocks skipped because their gas used is outside the range of the run
+// (see RangeOptions.MinGasUsed)
+var errGasUsedOutOfRange = errors.New("gas used out of range")
+
+// checkGasUsedRange checks the gas used bounds of opts are consistent
+func checkGasUsedRange(opts *RangeOptions) error {
+	if opts.MinGasUsed != nil && opts.MaxGasUsed != nil && *opts.MinGasUsed > *opts.MaxGasUsed {
+		return fmt.Errorf("invalid gas used range: minimum %d is above maximum %d", *opts.MinGasUsed, *opts.MaxGasUsed)
+	}
+	return nil
+}
+
+// filterGasUsed fetches the header of block n if opts bounds the gas used of the blocks of the run, and returns
+// errGasUsedOutOfRange if the gas used by the block is outside the bounds
+func (s *Service) filterGasUsed(ctx context.Context, n uint64, opts *RangeOptions) error {
+	if opts.MinGasUsed == nil && opts.MaxGasUsed == nil {
+		return nil
+	}
+	if s.ethrpc == nil {
+		return fmt.Errorf("filtering blocks on gas used requires a remote RPC or a local chain data directory")
+	}
+
+	header, err := s.ethrpc.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+	if err != nil {
+		return fmt.Errorf("failed to fetch header to filter on gas used: %v", err)
+	}
+
+	if (opts.MinGasUsed != nil && header.GasUsed < *opts.MinGasUsed) || (opts.MaxGasUsed != nil && header.GasUsed > *opts.MaxGasUsed) {
+		log.LoggerFromContext(ctx).Info("Block gas used out of range, skipping", zap.Uint64("block.number", n), zap.Uint64("gasUsed", header.GasUsed))
+		return errGasUsedOutOfRange
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package src
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uint64Ptr(n uint64) *uint64 {
+	return &n
+}
+
+func TestCheckGasUsedRange(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		min, max *uint64
+		err      string
+	}{
+		{name: "no bounds"},
+		{name: "min only", min: uint64Ptr(21000)},
+		{name: "max only", max: uint64Ptr(21000)},
+		{name: "single value", min: uint64Ptr(21000), max: uint64Ptr(21000)},
+		{name: "inverted", min: uint64Ptr(21001), max: uint64Ptr(21000), err: "invalid gas used range: minimum 21001 is above maximum 21000"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkGasUsedRange(&RangeOptions{MinGasUsed: tc.min, MaxGasUsed: tc.max})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGenerateRangeGasUsed(t *testing.T) {
+	// Blocks 2 and 4 hold a plain transfer, blocks 3 and 5 are empty
+	to := gethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	chain := newTestChain(t, nil, nil, 5, func(i int, b *core.BlockGen) {
+		if i%2 == 1 {
+			sendTestTx(t, testConfig, b, to, nil)
+		}
+	})
+	require.Equal(t, params.TxGas, chain.blocks[1].GasUsed())
+	require.Zero(t, chain.blocks[2].GasUsed())
+
+	for _, tc := range []struct {
+		name      string
+		min, max  *uint64
+		generated []uint64
+	}{
+		{name: "min only", min: uint64Ptr(1), generated: []uint64{2, 4}},
+		{name: "max only", max: uint64Ptr(0), generated: []uint64{3, 5}},
+		{name: "inclusive bounds", min: uint64Ptr(params.TxGas), max: uint64Ptr(params.TxGas), generated: []uint64{2, 4}},
+		{name: "no block in range", min: uint64Ptr(params.TxGas + 1)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestService(t, newTestConfig(t, ChainConfig{DataDir: chain.dir}))
+			recorder := &recordingStore{ProverInputStore: s.ProverInputStore}
+			s.ProverInputStore = recorder
+
+			summary, err := s.GenerateRange(context.Background(), big.NewInt(2), big.NewInt(5), &RangeOptions{MinGasUsed: tc.min, MaxGasUsed: tc.max})
+			require.NoError(t, err, "blocks out of the gas used range are skipped, not failed")
+			assert.Empty(t, summary.Failed)
+			assert.Equal(t, len(tc.generated), summary.Succeeded)
+			assert.Equal(t, len(tc.generated), summary.Attempted)
+			assert.Equal(t, 4-len(tc.generated), summary.Skipped)
+			assert.Equal(t, tc.generated, recorder.stored)
+		})
+	}
+
+	t.Run("inverted range", func(t *testing.T) {
+		s := newTestService(t, newTestConfig(t, ChainConfig{DataDir: chain.dir}))
+		_, err := s.GenerateRange(context.Background(), big.NewInt(2), big.NewInt(5), &RangeOptions{MinGasUsed: uint64Ptr(2), MaxGasUsed: uint64Ptr(1)})
+		assert.ErrorContains(t, err, "invalid gas used range")
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		s := newTestService(t, newTestConfig(t, ChainConfig{DataDir: chain.dir}))
+		opts := &RangeOptions{MinGasUsed: uint64Ptr(1)}
+		assert.NoError(t, s.filterGasUsed(context.Background(), 2, opts))
+		assert.ErrorIs(t, s.filterGasUsed(context.Background(), 3, opts), errGasUsedOutOfRange)
+		assert.NoError(t, s.filterGasUsed(context.Background(), 3, &RangeOptions{}), "no header is fetched without bounds")
+		assert.ErrorContains(t, s.filterGasUsed(context.Background(), 9, opts), "failed to fetch header to filter on gas used")
+	})
+}
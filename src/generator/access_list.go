@@ -0,0 +1,51 @@
+package generator
+
+import (
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/state"
+)
+
+// AccessListReport is the access list of the execution of a block, written as a JSON line by the Executor if
+// ExecutionConfig.AccessList is set
+//
+// The access list is in the EIP-2930 format: every account accessed during the execution, with the storage slots of the
+// account that were accessed, sorted by address then by slot.
+type AccessListReport struct {
+	BlockNumber uint64               `json:"blockNumber"`
+	BlockHash   string               `json:"blockHash"`
+	AccessList  gethtypes.AccessList `json:"accessList"`
+	Error       string               `json:"error,omitempty"` // Execution error, if any
+}
+
+// NewAccessListReport creates the access list report of a block from the accesses recorded during its execution
+func NewAccessListReport(header *gethtypes.Header, tracker *state.CompletenessTracker, execErr error) *AccessListReport {
+	report := &AccessListReport{
+		BlockNumber: header.Number.Uint64(),
+		BlockHash:   header.Hash().Hex(),
+		AccessList:  gethtypes.AccessList{},
+	}
+
+	// Accesses are sorted by kind then address, so the accounts are listed in order before their slots
+	index := make(map[gethcommon.Address]int)
+	for _, access := range tracker.Accesses() {
+		switch access.Kind {
+		case state.AccessKindAccount:
+			index[access.Address] = len(report.AccessList)
+			report.AccessList = append(report.AccessList, gethtypes.AccessTuple{Address: access.Address, StorageKeys: []gethcommon.Hash{}})
+		case state.AccessKindStorage:
+			i, ok := index[access.Address]
+			if !ok {
+				// The account of a slot is always read first, but do not lose the slot if it was not recorded
+				i = len(report.AccessList)
+				index[access.Address] = i
+				report.AccessList = append(report.AccessList, gethtypes.AccessTuple{Address: access.Address, StorageKeys: []gethcommon.Hash{}})
+			}
+			report.AccessList[i].StorageKeys = append(report.AccessList[i].StorageKeys, *access.Slot)
+		}
+	}
+	if execErr != nil {
+		report.Error = execErr.Error()
+	}
+	return report
+}
@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+)
+
+// ReadBlockRLP reads a raw RLP-encoded block, either binary or hex encoded (as returned by debug_getRawBlock),
+// and checks its body matches its header
+func ReadBlockRLP(r io.Reader) (*gethtypes.Block, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block RLP: %v", err)
+	}
+	if text := bytes.TrimSpace(raw); bytes.HasPrefix(text, []byte("0x")) {
+		if raw, err = hexutil.Decode(string(text)); err != nil {
+			return nil, fmt.Errorf("failed to decode hex block RLP: %v", err)
+		}
+	}
+
+	block := new(gethtypes.Block)
+	if err := rlp.DecodeBytes(raw, block); err != nil {
+		return nil, fmt.Errorf("failed to decode block RLP: %v", err)
+	}
+	if err := CheckBlockBody(block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// CheckBlockBody checks the transactions, uncles and withdrawals of block match the roots of its header,
+// so the block hash also commits to its body
+func CheckBlockBody(block *gethtypes.Block) error {
+	header := block.Header()
+	if hash := gethtypes.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
+		return fmt.Errorf("invalid block %v: transactions root mismatch: header has %v but transactions hash to %v", block.Number(), header.TxHash.Hex(), hash.Hex())
+	}
+	if hash := gethtypes.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("invalid block %v: uncles hash mismatch: header has %v but uncles hash to %v", block.Number(), header.UncleHash.Hex(), hash.Hex())
+	}
+	switch {
+	case header.WithdrawalsHash == nil && block.Withdrawals() != nil:
+		return fmt.Errorf("invalid block %v: withdrawals present but header has no withdrawals root", block.Number())
+	case header.WithdrawalsHash != nil && block.Withdrawals() == nil:
+		return fmt.Errorf("invalid block %v: header has a withdrawals root but withdrawals are missing", block.Number())
+	case header.WithdrawalsHash != nil:
+		if hash := gethtypes.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil)); hash != *header.WithdrawalsHash {
+			return fmt.Errorf("invalid block %v: withdrawals root mismatch: header has %v but withdrawals hash to %v", block.Number(), header.WithdrawalsHash.Hex(), hash.Hex())
+		}
+	}
+	return nil
+}
+
+// WithBlock returns a copy of the preflight data executing block instead of the block of the data,
+// block must be the block the preflight data was collected for
+func (d *PreflightData) WithBlock(block *gethtypes.Block) (*PreflightData, error) {
+	if d.Block == nil || d.ChainConfig == nil {
+		return nil, fmt.Errorf("incomplete preflight data: block and chain configuration are required")
+	}
+	if block.Hash() != d.Block.Hash {
+		return nil, fmt.Errorf("block mismatch: block %v has hash %v but preflight data is for block %v (%v)", block.Number(), block.Hash().Hex(), d.Block.Number, d.Block.Hash.Hex())
+	}
+
+	cpy := *d
+	cpy.Block = new(ethrpc.Block).FromBlock(block, d.ChainConfig)
+	cpy.Uncles = block.Uncles()
+	return &cpy, nil
+}
+
+// blockClient is an ethrpc.Client serving a given block, and forwarding every other request to the remote
+//
+// It enables to run the preflight of a block which is not available on the remote (e.g. a raw RLP block), the remote
+// only serving the pre-state and the ancestors of the block.
+type blockClient struct {
+	ethrpc.Client
+
+	block *gethtypes.Block
+}
+
+// NewBlockClient returns an ethrpc.Client serving block, and serving every other request from remote
+func NewBlockClient(remote ethrpc.Client, block *gethtypes.Block) ethrpc.Client {
+	return &blockClient{Client: remote, block: block}
+}
+
+func (c *blockClient) BlockByNumber(ctx context.Context, number *big.Int) (*gethtypes.Block, error) {
+	if number != nil && number.Cmp(c.block.Number()) == 0 {
+		return c.block, nil
+	}
+	return c.Client.BlockByNumber(ctx, number)
+}
+
+func (c *blockClient) HeaderByNumber(ctx context.Context, number *big.Int) (*gethtypes.Header, error) {
+	if number != nil && number.Cmp(c.block.Number()) == 0 {
+		return c.block.Header(), nil
+	}
+	return c.Client.HeaderByNumber(ctx, number)
+}
@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockRLP(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	data := &testDataInputs.PreflightData
+	raw, err := rlp.EncodeToBytes(data.block())
+	require.NoError(t, err)
+
+	block, err := ReadBlockRLP(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, data.Block.Hash, block.Hash())
+
+	hexBlock, err := ReadBlockRLP(strings.NewReader(hexutil.Encode(raw) + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), hexBlock.Hash())
+
+	// The preflight data with the RLP block prepares the same prover input
+	blockData, err := data.WithBlock(block)
+	require.NoError(t, err)
+	result, err := NewPreparer(nil).Prepare(context.Background(), blockData)
+	require.NoError(t, err)
+	assert.True(t, input.CompareProverInput(&testDataInputs.ProverInput, result))
+
+	t.Run("body mismatch", func(t *testing.T) {
+		txs := block.Transactions()
+		tampered := block.WithBody(gethtypes.Body{Transactions: txs[:len(txs)-1], Withdrawals: block.Withdrawals()})
+		tamperedRaw, err := rlp.EncodeToBytes(tampered)
+		require.NoError(t, err)
+
+		_, err = ReadBlockRLP(bytes.NewReader(tamperedRaw))
+		assert.ErrorContains(t, err, "transactions root mismatch")
+	})
+
+	t.Run("block mismatch", func(t *testing.T) {
+		header := block.Header()
+		header.Extra = []byte("other")
+		_, err := data.WithBlock(block.WithSeal(header))
+		assert.ErrorContains(t, err, "block mismatch")
+	})
+}
@@ -7,11 +7,32 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 )
 
+// Chain IDs of local development nodes
+const (
+	AnvilChainID   = 31337 // Default chain ID of Anvil and Hardhat Network (Anvil forks report the chain ID of the forked chain instead)
+	DevNodeChainID = 1337  // Default chain ID of geth --dev and Ganache
+)
+
 // ChainConfigs are supported chain configurations.
 var ChainConfigs = map[string]*params.ChainConfig{
 	params.MainnetChainConfig.ChainID.String(): params.MainnetChainConfig,
 	params.SepoliaChainConfig.ChainID.String(): params.SepoliaChainConfig,
 	params.HoleskyChainConfig.ChainID.String(): params.HoleskyChainConfig,
+	big.NewInt(AnvilChainID).String():          DevChainConfig(big.NewInt(AnvilChainID)),
+	big.NewInt(DevNodeChainID).String():        DevChainConfig(big.NewInt(DevNodeChainID)),
+}
+
+// DevChainConfig returns the configuration of a local development chain (e.g. Anvil, Hardhat Network), with every
+// hardfork up to Cancun active from genesis and proof-of-stake from genesis
+//
+// Development nodes run their configured hardfork from genesis, including on forks of another chain (their blocks keep
+// the numbering of the forked chain), so they must run Cancun (e.g. anvil --hardfork cancun) for their blocks to be
+// executed with the same rules.
+func DevChainConfig(chainID *big.Int) *params.ChainConfig {
+	cfg := *params.AllDevChainProtocolChanges
+	cfg.ChainID = new(big.Int).Set(chainID)
+	cfg.PragueTime = nil // Not supported by the EVM
+	return &cfg
 }
 
 func getChainConfig(chainID *big.Int) (*params.ChainConfig, error) {
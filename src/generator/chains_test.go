@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetChainConfig(t *testing.T) {
+	cfg, err := getChainConfig(big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, params.MainnetChainConfig, cfg)
+
+	// Local development chains run Cancun from genesis
+	for _, chainID := range []int64{AnvilChainID, DevNodeChainID} {
+		cfg, err = getChainConfig(big.NewInt(chainID))
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(chainID), cfg.ChainID)
+		rules := cfg.Rules(big.NewInt(21465322), true, 1734000000)
+		assert.True(t, rules.IsCancun)
+		assert.False(t, rules.IsPrague)
+	}
+	assert.Equal(t, big.NewInt(1337), params.AllDevChainProtocolChanges.ChainID, "shared configuration must not be modified")
+
+	_, err = getChainConfig(big.NewInt(10))
+	assert.ErrorContains(t, err, "unsupported chain ID: 10")
+}
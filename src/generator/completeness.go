@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/state"
+)
+
+// CompletenessReport is the completeness report of the execution of a block on its prover input, written as a JSON line
+// by the Executor if ExecutionConfig.CompletenessReport is set
+//
+// It enumerates every account, storage slot and bytecode accessed during execution and whether the witness backed it.
+// Missing accesses were read as zero by the EVM: they flag an incomplete witness (e.g. an incomplete preflight).
+type CompletenessReport struct {
+	BlockNumber uint64               `json:"blockNumber"`
+	BlockHash   string               `json:"blockHash"`
+	Complete    bool                 `json:"complete"` // True if every access was backed by the witness
+	Accounts    int                  `json:"accounts"` // Number of accessed accounts
+	Slots       int                  `json:"slots"`    // Number of accessed storage slots
+	Codes       int                  `json:"codes"`    // Number of accessed bytecodes
+	Missing     []*state.StateAccess `json:"missing"`  // Accesses not backed by the witness
+	Accesses    []*state.StateAccess `json:"accesses"`
+	Error       string               `json:"error,omitempty"` // Execution error, if any
+}
+
+// NewCompletenessReport creates the completeness report of a block from the accesses recorded during its execution
+func NewCompletenessReport(header *gethtypes.Header, tracker *state.CompletenessTracker, execErr error) *CompletenessReport {
+	report := &CompletenessReport{
+		BlockNumber: header.Number.Uint64(),
+		BlockHash:   header.Hash().Hex(),
+		Missing:     []*state.StateAccess{},
+		Accesses:    tracker.Accesses(),
+	}
+	for _, access := range report.Accesses {
+		switch access.Kind {
+		case state.AccessKindAccount:
+			report.Accounts++
+		case state.AccessKindStorage:
+			report.Slots++
+		case state.AccessKindCode:
+			report.Codes++
+		}
+		if access.Status == state.AccessMissing {
+			report.Missing = append(report.Missing, access)
+		}
+	}
+	report.Complete = len(report.Missing) == 0
+	if execErr != nil {
+		report.Error = execErr.Error()
+	}
+	return report
+}
+
+var reportMu sync.Mutex // Reports may be shared by concurrent executions
+
+// writeReport writes the report (e.g. CompletenessReport) as a JSON line to w, synced if w supports it (e.g. *os.File)
+func writeReport(w io.Writer, report interface{}) error {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	reportMu.Lock()
+	defer reportMu.Unlock()
+	if _, err := w.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if syncer, ok := w.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kkrt-labs/go-utils/ethereum/rpc"
+	ethjsonrpc "github.com/kkrt-labs/go-utils/ethereum/rpc/jsonrpc"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/kkrt-labs/go-utils/log"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"go.uber.org/zap"
+)
+
+// crossCheckSampleSize is the maximum number of transactions of a block that are cross-checked
+const crossCheckSampleSize = 8
+
+// Divergence is a difference between the stateless execution of a transaction and a reference node
+type Divergence struct {
+	TxHash   gethcommon.Hash
+	Field    string
+	Expected string // Value on the reference node
+	Got      string // Value from the stateless execution
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("tx %v: %v differs (reference node: %v, stateless execution: %v)", d.TxHash.Hex(), d.Field, d.Expected, d.Got)
+}
+
+// CrossChecker spot-checks a stateless block execution against a reference node
+//
+// For a sample of the block's transactions, it
+// - compares the receipts (status, gas used, logs, bloom, contract address) to the ones of the reference node (eth_getBlockReceipts,
+// falling back to eth_getTransactionReceipt on nodes not supporting it)
+// - checks that every account and storage slot accessed by the transaction on the reference node (debug_traceTransaction with prestateTracer)
+// can be resolved from the witness, which helps validating witness completeness against ground truth
+type CrossChecker struct {
+	remote   jsonrpc.Client
+	ethrpc   rpc.Client
+	endpoint string
+}
+
+// NewCrossChecker creates a new CrossChecker using the given reference node, endpoint identifies the node
+// to cache the JSON-RPC methods it supports
+func NewCrossChecker(remote jsonrpc.Client, endpoint string) *CrossChecker {
+	return &CrossChecker{
+		remote:   remote,
+		ethrpc:   ethjsonrpc.NewFromClient(remote),
+		endpoint: endpoint,
+	}
+}
+
+// blockReceiptsSupport caches per endpoint whether eth_getBlockReceipts is supported (only set once the node answered)
+var blockReceiptsSupport sync.Map
+
+// CrossCheck compares the result of the stateless execution of inputs with the reference node and returns the divergences
+func (c *CrossChecker) CrossCheck(ctx context.Context, inputs *input.ProverInput, res *core.ProcessResult) ([]*Divergence, error) {
+	block := inputs.Blocks[0]
+	if len(res.Receipts) != len(block.Transactions) {
+		return nil, fmt.Errorf("invalid execution result: %d receipts for %d transactions", len(res.Receipts), len(block.Transactions))
+	}
+
+	preState, err := newWitnessState(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	blockReceipts := c.blockReceipts(ctx, block)
+
+	var divergences []*Divergence
+	traceSupported := true
+	for _, i := range sampleIndexes(len(block.Transactions), crossCheckSampleSize) {
+		tx := block.Transactions[i]
+
+		var receipt *gethtypes.Receipt
+		if blockReceipts != nil {
+			receipt = blockReceipts[i]
+		} else if receipt, err = c.ethrpc.TransactionReceipt(ctx, tx.Hash()); err != nil {
+			return nil, fmt.Errorf("failed to fetch receipt of tx %v from reference node: %v", tx.Hash().Hex(), err)
+		}
+		divergences = append(divergences, compareReceipts(tx.Hash(), receipt, res.Receipts[i])...)
+
+		if !traceSupported {
+			continue
+		}
+
+		prestate, err := c.tracePrestate(ctx, tx.Hash())
+		if err != nil {
+			log.LoggerFromContext(ctx).Warn("Reference node does not support debug_traceTransaction, skipping witness completeness checks", zap.Error(err))
+			traceSupported = false
+			continue
+		}
+		divergences = append(divergences, preState.check(tx.Hash(), prestate)...)
+	}
+
+	return divergences, nil
+}
+
+// blockReceipts returns all the receipts of the block in a single eth_getBlockReceipts call,
+// or nil if the reference node does not support it, in which case receipts must be fetched per transaction
+func (c *CrossChecker) blockReceipts(ctx context.Context, block *input.Block) []*gethtypes.Receipt {
+	if supported, ok := blockReceiptsSupport.Load(c.endpoint); ok && !supported.(bool) {
+		return nil
+	}
+
+	var receipts []*gethtypes.Receipt
+	req := &jsonrpc.Request{
+		Method: "eth_getBlockReceipts",
+		Params: []interface{}{hexutil.EncodeBig(block.Header.Number)},
+	}
+	err := c.remote.Call(ctx, req, &receipts)
+	if err == nil && len(receipts) != len(block.Transactions) {
+		err = fmt.Errorf("got %d receipts for %d transactions", len(receipts), len(block.Transactions))
+	}
+	if err != nil {
+		// Only errors returned by the node mean the method is unsupported, others (e.g. timeouts) may be transient
+		var errMsg jsonrpc.ErrorMsg
+		var errMsgPtr *jsonrpc.ErrorMsg
+		if errors.As(err, &errMsg) || errors.As(err, &errMsgPtr) {
+			blockReceiptsSupport.Store(c.endpoint, false)
+		}
+		log.LoggerFromContext(ctx).Debug("eth_getBlockReceipts failed, fetching receipts per transaction", zap.Error(err))
+		return nil
+	}
+
+	blockReceiptsSupport.Store(c.endpoint, true)
+	return receipts
+}
+
+// prestateAccount is an account as returned by the prestateTracer
+type prestateAccount struct {
+	Storage map[gethcommon.Hash]gethcommon.Hash `json:"storage"`
+}
+
+// tracePrestate returns the accounts and storage slots accessed by a transaction
+func (c *CrossChecker) tracePrestate(ctx context.Context, txHash gethcommon.Hash) (map[gethcommon.Address]*prestateAccount, error) {
+	var res map[gethcommon.Address]*prestateAccount
+	req := &jsonrpc.Request{
+		Method: "debug_traceTransaction",
+		Params: []interface{}{txHash, map[string]interface{}{"tracer": "prestateTracer"}},
+	}
+	if err := c.remote.Call(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func compareReceipts(txHash gethcommon.Hash, expected, got *gethtypes.Receipt) []*Divergence {
+	var divergences []*Divergence
+	diff := func(field string, e, g interface{}) {
+		if fmt.Sprint(e) != fmt.Sprint(g) {
+			divergences = append(divergences, &Divergence{TxHash: txHash, Field: field, Expected: fmt.Sprint(e), Got: fmt.Sprint(g)})
+		}
+	}
+
+	diff("status", expected.Status, got.Status)
+	diff("gas used", expected.GasUsed, got.GasUsed)
+	diff("cumulative gas used", expected.CumulativeGasUsed, got.CumulativeGasUsed)
+	diff("logs count", len(expected.Logs), len(got.Logs))
+	diff("logs bloom", expected.Bloom.Big().Text(16), got.Bloom.Big().Text(16))
+	diff("contract address", expected.ContractAddress.Hex(), got.ContractAddress.Hex())
+
+	return divergences
+}
+
+// witnessState resolves accounts and storage slots from the pre-state of a witness
+type witnessState struct {
+	db   gethstate.Database
+	root gethcommon.Hash
+	trie gethstate.Trie
+}
+
+func newWitnessState(ctx context.Context, inputs *input.ProverInput) (*witnessState, error) {
+	e := &executor{}
+	execCtx, err := e.prepareContext(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.preparePreState(execCtx, inputs); err != nil {
+		return nil, fmt.Errorf("failed to prepare pre-state: %v", err)
+	}
+
+	if len(inputs.Witness.Ancestors) == 0 {
+		return nil, fmt.Errorf("no ancestors provided")
+	}
+	root := inputs.Witness.Ancestors[0].Root
+
+	tr, err := execCtx.stateDB.OpenTrie(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pre-state trie: %v", err)
+	}
+
+	return &witnessState{db: execCtx.stateDB, root: root, trie: tr}, nil
+}
+
+// check returns a divergence for every account or storage slot that can not be resolved from the witness
+func (s *witnessState) check(txHash gethcommon.Hash, prestate map[gethcommon.Address]*prestateAccount) []*Divergence {
+	var divergences []*Divergence
+	missing := func(item string, err error) {
+		divergences = append(divergences, &Divergence{TxHash: txHash, Field: "witness", Expected: item + " accessed", Got: fmt.Sprintf("not resolvable (%v)", err)})
+	}
+
+	for addr, account := range prestate {
+		stateAccount, err := s.trie.GetAccount(addr)
+		if err != nil {
+			missing(fmt.Sprintf("account %v", addr.Hex()), err)
+			continue
+		}
+		if stateAccount == nil || account == nil || len(account.Storage) == 0 {
+			continue // Account does not exist before the block or no storage is accessed
+		}
+
+		storageTrie, err := s.db.OpenStorageTrie(s.root, addr, stateAccount.Root, s.trie)
+		if err != nil {
+			missing(fmt.Sprintf("storage of account %v", addr.Hex()), err)
+			continue
+		}
+		for slot := range account.Storage {
+			if _, err := storageTrie.GetStorage(addr, slot.Bytes()); err != nil {
+				missing(fmt.Sprintf("storage slot %v of account %v", slot.Hex(), addr.Hex()), err)
+			}
+		}
+	}
+
+	return divergences
+}
+
+// sampleIndexes returns at most size indexes evenly spread over [0, n)
+func sampleIndexes(n, size int) []int {
+	if n <= size {
+		indexes := make([]int, n)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes
+	}
+
+	indexes := make([]int, size)
+	for i := range indexes {
+		indexes[i] = i * (n - 1) / (size - 1)
+	}
+	return indexes
+}
@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrossChecker(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+	res, err := NewExecutor(nil).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+
+	txs := proverInput.Blocks[0].Transactions
+	require.NotEmpty(t, txs)
+
+	// Reference node returning the receipts of the stateless execution, except for a tampered receipt
+	// and accessing an account which is not in the witness
+	tampered := txs[0].Hash()
+	unknown := gethcommon.HexToAddress("0x00000000000000000000000000000000deadbeef")
+	receipt := func(i int) *gethtypes.Receipt {
+		r := *res.Receipts[i]
+		if r.Logs == nil {
+			r.Logs = []*gethtypes.Log{}
+		}
+		if txs[i].Hash() == tampered {
+			r.GasUsed++
+		}
+		return &r
+	}
+	newRemote := func(blockReceipts bool, calls map[string]int) jsonrpc.Client {
+		return jsonrpc.ClientFunc(func(_ context.Context, req *jsonrpc.Request, res interface{}) error {
+			calls[req.Method]++
+
+			var result interface{}
+			switch req.Method {
+			case "eth_getBlockReceipts":
+				if !blockReceipts {
+					return jsonrpc.ErrorMsg{Code: -32601, Message: "the method eth_getBlockReceipts does not exist/is not available"}
+				}
+				receipts := make([]*gethtypes.Receipt, len(txs))
+				for i := range txs {
+					receipts[i] = receipt(i)
+				}
+				result = receipts
+			case "eth_getTransactionReceipt":
+				txHash := req.Params.([]interface{})[0].(gethcommon.Hash)
+				for i, tx := range txs {
+					if tx.Hash() == txHash {
+						result = receipt(i)
+					}
+				}
+			case "debug_traceTransaction":
+				from, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(txs[0].ChainId()), txs[0])
+				require.NoError(t, err)
+				result = map[gethcommon.Address]interface{}{from: map[string]interface{}{}, unknown: map[string]interface{}{}}
+			default:
+				return fmt.Errorf("unexpected method %v", req.Method)
+			}
+
+			b, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(b, res)
+		})
+	}
+
+	checkDivergences := func(t *testing.T, divergences []*Divergence) {
+		var gasUsed, witness int
+		for _, d := range divergences {
+			switch d.Field {
+			case "gas used":
+				gasUsed++
+				assert.Equal(t, tampered, d.TxHash)
+			case "witness":
+				witness++
+				assert.Contains(t, d.Expected, unknown.Hex())
+			default:
+				t.Errorf("unexpected divergence: %v", d)
+			}
+		}
+		assert.Equal(t, 1, gasUsed)
+		assert.Equal(t, len(sampleIndexes(len(txs), crossCheckSampleSize)), witness)
+	}
+	sampled := len(sampleIndexes(len(txs), crossCheckSampleSize))
+
+	t.Run("block receipts", func(t *testing.T) {
+		calls := make(map[string]int)
+		divergences, err := NewCrossChecker(newRemote(true, calls), "block-receipts").CrossCheck(context.Background(), proverInput, res)
+		require.NoError(t, err)
+		checkDivergences(t, divergences)
+		assert.Equal(t, 1, calls["eth_getBlockReceipts"])
+		assert.Zero(t, calls["eth_getTransactionReceipt"])
+	})
+
+	t.Run("fallback to transaction receipts", func(t *testing.T) {
+		calls := make(map[string]int)
+		for i := 0; i < 2; i++ {
+			divergences, err := NewCrossChecker(newRemote(false, calls), "no-block-receipts").CrossCheck(context.Background(), proverInput, res)
+			require.NoError(t, err)
+			checkDivergences(t, divergences)
+		}
+		// Lack of support is cached for the endpoint
+		assert.Equal(t, 1, calls["eth_getBlockReceipts"])
+		assert.Equal(t, 2*sampled, calls["eth_getTransactionReceipt"])
+	})
+}
+
+func TestSampleIndexes(t *testing.T) {
+	assert.Equal(t, []int{0, 1, 2}, sampleIndexes(3, 8))
+	assert.Equal(t, []int{0, 33, 66, 99}, sampleIndexes(100, 4))
+	assert.Empty(t, sampleIndexes(0, 8))
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"fmt"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/kkrt-labs/go-utils/log"
+	"github.com/kkrt-labs/zk-pig/src/ethereum"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"go.uber.org/zap"
+)
+
+// referenceExecution is the result of the execution of a block by the go-ethereum reference stateless processor
+type referenceExecution struct {
+	res       *core.ProcessResult
+	stateRoot gethcommon.Hash
+}
+
+// executeReference executes the block of execParams with the go-ethereum reference stateless path (see core.ExecuteStateless):
+// a hash database built by stateless.Witness from the prover input witness, and the plain state processor, without
+// any of the pre-state preparation, tracers and validation of the Executor.
+func executeReference(inputs *input.ProverInput, block *gethtypes.Block) (*referenceExecution, error) {
+	nodes, err := inputs.StateNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	witness, err := stateless.NewWitness(block.Header(), nil)
+	if err != nil {
+		return nil, err
+	}
+	witness.Headers = inputs.Witness.Ancestors
+	for _, code := range inputs.Witness.Codes {
+		witness.AddCode(code)
+	}
+	state := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		state[string(node)] = struct{}{}
+	}
+	witness.AddState(state)
+
+	stateDB := gethstate.NewDatabase(triedb.NewDatabase(witness.MakeHashDB(), triedb.HashDefaults), nil)
+	preState, err := gethstate.New(witness.Root(), stateDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reference pre-state from parent root %v: %v", witness.Root(), err)
+	}
+	hc, err := ethereum.NewChain(inputs.ChainConfig, stateDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reference chain: %v", err)
+	}
+
+	res, err := core.NewStateProcessor(inputs.ChainConfig, hc).Process(block, preState, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return &referenceExecution{
+		res:       res,
+		stateRoot: preState.IntermediateRoot(inputs.ChainConfig.IsEIP158(block.Number())),
+	}, nil
+}
+
+// dualExecute executes the block again with the go-ethereum reference stateless path and returns the divergences of
+// the execution of the Executor (res on the post-state of execParams) from it
+func (e *executor) dualExecute(ctx *executorContext, inputs *input.ProverInput, execParams *evm.ExecParams, res *core.ProcessResult) []*Divergence {
+	log.LoggerFromContext(ctx.ctx).Info("Dual execute with reference state processor...")
+
+	block := execParams.Block
+	ref, err := executeReference(inputs, block)
+	if err != nil {
+		// The Executor succeeded, so the reference failing is a divergence
+		return []*Divergence{{Field: "execution error", Expected: err.Error(), Got: "none"}}
+	}
+
+	divergences := compareExecutions(block, ref.res, res)
+	stateRoot := execParams.State.IntermediateRoot(inputs.ChainConfig.IsEIP158(block.Number()))
+	if ref.stateRoot != stateRoot {
+		divergences = append(divergences, &Divergence{Field: "state root", Expected: ref.stateRoot.Hex(), Got: stateRoot.Hex()})
+	}
+	for _, d := range divergences {
+		log.LoggerFromContext(ctx.ctx).Warn("Divergence with reference state processor", zap.String("divergence", d.dualString()))
+	}
+	return divergences
+}
+
+// compareExecutions returns the divergences of the receipts of got from the ones of expected, per transaction then for the block
+func compareExecutions(block *gethtypes.Block, expected, got *core.ProcessResult) []*Divergence {
+	if len(expected.Receipts) != len(got.Receipts) {
+		return []*Divergence{{Field: "receipts count", Expected: fmt.Sprint(len(expected.Receipts)), Got: fmt.Sprint(len(got.Receipts))}}
+	}
+
+	var divergences []*Divergence
+	for i, tx := range block.Transactions() {
+		divergences = append(divergences, compareReceipts(tx.Hash(), expected.Receipts[i], got.Receipts[i])...)
+	}
+	if expected.GasUsed != got.GasUsed {
+		divergences = append(divergences, &Divergence{Field: "gas used", Expected: fmt.Sprint(expected.GasUsed), Got: fmt.Sprint(got.GasUsed)})
+	}
+	expectedRoot := gethtypes.DeriveSha(expected.Receipts, trie.NewStackTrie(nil))
+	if gotRoot := gethtypes.DeriveSha(got.Receipts, trie.NewStackTrie(nil)); expectedRoot != gotRoot {
+		divergences = append(divergences, &Divergence{Field: "receipt root", Expected: expectedRoot.Hex(), Got: gotRoot.Hex()})
+	}
+	return divergences
+}
+
+// dualString formats a divergence from the reference state processor, block level divergences have no transaction hash
+func (d *Divergence) dualString() string {
+	if d.TxHash == (gethcommon.Hash{}) {
+		return fmt.Sprintf("block: %v differs (reference: %v, executor: %v)", d.Field, d.Expected, d.Got)
+	}
+	return fmt.Sprintf("tx %v: %v differs (reference: %v, executor: %v)", d.TxHash.Hex(), d.Field, d.Expected, d.Got)
+}
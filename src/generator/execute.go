@@ -3,17 +3,22 @@ package generator
 import (
 	"context"
 	"fmt"
+	"io"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	gethstate "github.com/ethereum/go-ethereum/core/state"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/ethereum/go-ethereum/triedb/hashdb"
 	"github.com/kkrt-labs/go-utils/log"
 	"github.com/kkrt-labs/go-utils/tag"
 	"github.com/kkrt-labs/zk-pig/src/ethereum"
 	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/state"
 	input "github.com/kkrt-labs/zk-pig/src/prover-input"
 	"go.uber.org/zap"
 )
@@ -26,11 +31,117 @@ type Executor interface {
 	Execute(ctx context.Context, inputs *input.ProverInput) (*core.ProcessResult, error)
 }
 
-type executor struct{}
+// ExecutionConfig holds overrides of the executed block, meant to generate deterministic test vectors
+//
+// Overridden blocks differ from the canonical chain, so their final state (and the state root in their header)
+// can not be verified: validation of the block execution is skipped when any override is set.
+type ExecutionConfig struct {
+	OverrideTimestamp *uint64             // If set, the block timestamp is replaced by this value
+	OverrideCoinbase  *gethcommon.Address // If set, the block coinbase is replaced by this address
+
+	// If true, the Executor skips the validation of the block execution, in particular the expensive recomputation of the
+	// post-state root, so a wrong witness is not detected. Meant for trusted pipelines whose witnesses are already validated,
+	// it never applies to the Preparer which needs the post-state root to complete the witness.
+	SkipRootVerification bool
+
+	// If true (only with SkipRootVerification), the execute phase of generate is skipped altogether and a commitment to the
+	// witness and the block hash is stored in the prover input labels instead (see input.ProverInput.CommitWitness), so a
+	// downstream verifier can bind the prover input to its block. It never applies to the Executor itself.
+	WitnessCommitment bool
+
+	// If true, the Executor executes every block a second time with the go-ethereum reference stateless path (a hash
+	// database built by core/stateless from the witness and the plain state processor) and fails on any divergence of
+	// the receipts, receipt root or post-state root between the two, to catch bugs of the stateless execution itself.
+	DualExecute bool
+
+	// If true, the Executor recovers the sender of every transaction before executing the block, and fails on an invalid
+	// signature or on signed transactions not matching the transactions root of the header, to detect tampered prover inputs
+	VerifySignatures bool
+
+	// Report is an optional writer receiving the execution report of the Executor (see evm.ExecutorWithReport)
+	Report io.Writer `json:"-"`
+
+	// CompletenessReport is an optional writer receiving the completeness report of every block executed by the Executor
+	// (see CompletenessReport)
+	CompletenessReport io.Writer `json:"-"`
+
+	// AccessList is an optional writer receiving the access list of every block executed by the Executor (see AccessListReport)
+	AccessList io.Writer `json:"-"`
+
+	// CallGraph is an optional writer receiving the DOT call graph of every block executed by the Executor
+	// (see evm.ExecutorWithCallGraph)
+	CallGraph io.Writer `json:"-"`
+
+	// PreExecuteHooks are functions called in order by the Executor right before executing every block, with access to its
+	// pre-state (see ExecutionEnv), an error aborts the execution of the block
+	PreExecuteHooks []PreExecuteHook `json:"-"`
+
+	// StateLoading is the way the pre-state nodes of the witness are loaded before executing a block, eagerly if empty
+	StateLoading StateLoading
+
+	// VerifyConcurrency is the maximum number of goroutines hashing the witness state nodes of a block before its execution,
+	// which is what binds them to the pre-state root (sequential if lower than 2, see ethereum.WriteNodesToHashDBConcurrently)
+	VerifyConcurrency int `json:"-"`
+}
+
+// Enabled returns true if any override is set
+func (cfg *ExecutionConfig) Enabled() bool {
+	return cfg != nil && (cfg.OverrideTimestamp != nil || cfg.OverrideCoinbase != nil)
+}
+
+func (cfg *ExecutionConfig) verifyConcurrency() int {
+	if cfg == nil {
+		return 1
+	}
+	return cfg.VerifyConcurrency
+}
+
+func (cfg *ExecutionConfig) lazyStateLoading() bool {
+	return cfg != nil && cfg.StateLoading == StateLoadingLazy
+}
+
+func (cfg *ExecutionConfig) dualExecute() bool {
+	return cfg != nil && cfg.DualExecute
+}
+
+func (cfg *ExecutionConfig) verifySignatures() bool {
+	return cfg != nil && cfg.VerifySignatures
+}
+
+func (cfg *ExecutionConfig) skipRootVerification() bool {
+	return cfg != nil && cfg.SkipRootVerification
+}
+
+// tracksAccesses returns true if the state accesses of the execution are recorded, for the completeness report or the access list
+func (cfg *ExecutionConfig) tracksAccesses() bool {
+	return cfg != nil && (cfg.CompletenessReport != nil || cfg.AccessList != nil)
+}
+
+// apply returns the block with the overrides applied to its header
+func (cfg *ExecutionConfig) apply(block *gethtypes.Block) *gethtypes.Block {
+	if !cfg.Enabled() {
+		return block
+	}
+
+	header := block.Header()
+	if cfg.OverrideTimestamp != nil {
+		header.Time = *cfg.OverrideTimestamp
+	}
+	if cfg.OverrideCoinbase != nil {
+		header.Coinbase = *cfg.OverrideCoinbase
+	}
+
+	return gethtypes.NewBlockWithHeader(header).WithBody(*block.Body())
+}
+
+type executor struct {
+	cfg *ExecutionConfig
+}
 
 // NewExecutor creates a new instance of the BaseExecutor.
-func NewExecutor() Executor {
-	return &executor{}
+// If cfg is nil, blocks are executed without overrides.
+func NewExecutor(cfg *ExecutionConfig) Executor {
+	return &executor{cfg: cfg}
 }
 
 // Execute runs the ProvableBlockInputs data for the EVM prover engine.
@@ -61,36 +172,99 @@ func (e *executor) Execute(ctx context.Context, inputs *input.ProverInput) (*cor
 }
 
 type executorContext struct {
-	ctx     context.Context
-	stateDB gethstate.Database
-	hc      *core.HeaderChain
+	ctx          context.Context
+	stateDB      gethstate.Database
+	hc           *core.HeaderChain
+	completeness *state.CompletenessTracker // Set if a completeness report or an access list is written
+	lazyNodes    *ethereum.LazyNodeDatabase // Set if the pre-state nodes are loaded lazily
 }
 
 func (e *executor) execute(ctx context.Context, inputs *input.ProverInput) (*core.ProcessResult, error) {
 	log.LoggerFromContext(ctx).Info("Process provable execution...")
+	if e.cfg.Enabled() {
+		log.LoggerFromContext(ctx).Warn("Execution overrides set, skipping block validation")
+	} else if e.cfg.skipRootVerification() {
+		log.LoggerFromContext(ctx).Warn("Root verification disabled, skipping block validation: the prover input is NOT verified")
+	}
+
+	if err := inputs.VerifyAncestorHeaders(); err != nil {
+		return nil, fmt.Errorf("invalid ancestor headers: %v", err)
+	}
+
+	if e.cfg.verifySignatures() {
+		if err := verifySignatures(inputs.ChainConfig, inputs.Blocks[0]); err != nil {
+			return nil, fmt.Errorf("invalid transaction signatures: %v", err)
+		}
+		log.LoggerFromContext(ctx).Debug("Transaction signatures verified", zap.Int("transactions", len(inputs.Blocks[0].Transactions)))
+	}
 
 	execCtx, err := e.prepareContext(ctx, inputs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare execution context: %v", err)
 	}
 
-	e.preparePreState(execCtx, inputs)
+	if err := e.preparePreState(execCtx, inputs); err != nil {
+		return nil, fmt.Errorf("failed to prepare pre-state: %v", err)
+	}
 
 	execParams, err := e.prepareExecParams(execCtx, inputs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare execution exec params: %v", err)
 	}
 
-	return e.execEVM(execCtx, execParams)
+	env := &ExecutionEnv{Inputs: inputs, Chain: execCtx.hc, Block: execParams.Block, State: execParams.State}
+	if err := e.runPreExecuteHooks(ctx, env); err != nil {
+		return nil, err
+	}
+	execParams.Block = env.Block
+
+	res, err := e.execEVM(execCtx, execParams)
+	if execCtx.completeness != nil && e.cfg.CompletenessReport != nil {
+		report := NewCompletenessReport(inputs.Blocks[0].Header, execCtx.completeness, err)
+		if !report.Complete {
+			log.LoggerFromContext(ctx).Warn("Execution accessed state missing from the prover input", zap.Int("missing", len(report.Missing)))
+		}
+		if writeErr := writeReport(e.cfg.CompletenessReport, report); writeErr != nil && err == nil {
+			return res, fmt.Errorf("failed to write completeness report: %v", writeErr)
+		}
+	}
+	if execCtx.completeness != nil && e.cfg.AccessList != nil {
+		report := NewAccessListReport(inputs.Blocks[0].Header, execCtx.completeness, err)
+		if writeErr := writeReport(e.cfg.AccessList, report); writeErr != nil && err == nil {
+			return res, fmt.Errorf("failed to write access list: %v", writeErr)
+		}
+	}
+
+	if err == nil && e.cfg.dualExecute() {
+		if divergences := e.dualExecute(execCtx, inputs, execParams, res); len(divergences) > 0 {
+			return res, fmt.Errorf("dual execution found %d divergence(s) with the reference state processor, first: %v", len(divergences), divergences[0].dualString())
+		}
+		log.LoggerFromContext(ctx).Info("Dual execution matches the reference state processor")
+	}
+
+	return res, err
 }
 
 func (e *executor) prepareContext(ctx context.Context, inputs *input.ProverInput) (*executorContext, error) {
 	log.LoggerFromContext(ctx).Debug("Prepare context...")
 
 	// --- Create necessary database and chain instances ---
-	db := rawdb.NewMemoryDatabase()
+	var (
+		db        ethdb.Database = rawdb.NewMemoryDatabase()
+		lazyNodes *ethereum.LazyNodeDatabase
+	)
+	if e.cfg.lazyStateLoading() {
+		lazyNodes = ethereum.NewLazyNodeDatabase(db)
+		db = lazyNodes
+	}
 	trieDB := triedb.NewDatabase(db, &triedb.Config{HashDB: &hashdb.Config{}})
-	stateDB := gethstate.NewDatabase(trieDB, nil) // We use a modified trie database to track trie modifications
+	var stateDB gethstate.Database = gethstate.NewDatabase(trieDB, nil) // We use a modified trie database to track trie modifications
+
+	var completeness *state.CompletenessTracker
+	if e.cfg.tracksAccesses() {
+		completeness = state.NewCompletenessTracker()
+		stateDB = state.NewCompletenessDatabase(stateDB, completeness)
+	}
 
 	hc, err := ethereum.NewChain(inputs.ChainConfig, stateDB)
 	if err != nil {
@@ -98,15 +272,23 @@ func (e *executor) prepareContext(ctx context.Context, inputs *input.ProverInput
 	}
 
 	return &executorContext{
-		ctx:     ctx,
-		stateDB: stateDB,
-		hc:      hc,
+		ctx:          ctx,
+		stateDB:      stateDB,
+		hc:           hc,
+		completeness: completeness,
+		lazyNodes:    lazyNodes,
 	}, nil
 }
 
-func (e *executor) preparePreState(ctx *executorContext, inputs *input.ProverInput) {
+func (e *executor) preparePreState(ctx *executorContext, inputs *input.ProverInput) error {
 	log.LoggerFromContext(ctx.ctx).Info("Prepare pre-state...")
 
+	// -- Decode the pre-state nodes (verifying a multiproof against the parent state root) ---
+	nodes, err := inputs.StateNodes()
+	if err != nil {
+		return err
+	}
+
 	// -- Preload the ancestors of the block into database ---
 	ethereum.WriteHeaders(ctx.stateDB.TrieDB().Disk(), inputs.Witness.Ancestors...)
 
@@ -117,12 +299,14 @@ func (e *executor) preparePreState(ctx *executorContext, inputs *input.ProverInp
 	}
 	ethereum.WriteCodes(ctx.stateDB.TrieDB().Disk(), codes...)
 
-	// -- Preload the pre-state nodes to database ---
-	nodes := make([][]byte, 0)
-	for _, node := range inputs.Witness.State {
-		nodes = append(nodes, node)
+	// -- Preload the pre-state nodes to database, or defer them to their first access ---
+	if ctx.lazyNodes != nil {
+		ctx.lazyNodes.AddNodes(nodes...)
+	} else {
+		ethereum.WriteNodesToHashDBConcurrently(ctx.stateDB.TrieDB().Disk(), e.cfg.verifyConcurrency(), nodes...)
 	}
-	ethereum.WriteNodesToHashDB(ctx.stateDB.TrieDB().Disk(), nodes...)
+
+	return nil
 }
 
 func (e *executor) prepareExecParams(ctx *executorContext, inputs *input.ProverInput) (*evm.ExecParams, error) {
@@ -151,8 +335,8 @@ func (e *executor) prepareExecParams(ctx *executorContext, inputs *input.ProverI
 		VMConfig: &vm.Config{
 			StatelessSelfValidation: true,
 		},
-		Block:    inputs.Blocks[0].Block(),
-		Validate: true, // We validate the block execution to ensure the result and final state are correct
+		Block:    e.cfg.apply(inputs.Blocks[0].Block()),
+		Validate: !e.cfg.Enabled() && !e.cfg.skipRootVerification(), // We validate the block execution to ensure the result and final state are correct
 		Chain:    ctx.hc,
 		State:    preState,
 	}, nil
@@ -161,10 +345,61 @@ func (e *executor) prepareExecParams(ctx *executorContext, inputs *input.ProverI
 func (e *executor) execEVM(ctx *executorContext, execParams *evm.ExecParams) (*core.ProcessResult, error) {
 	log.LoggerFromContext(ctx.ctx).Info("Execute EVM...")
 
-	res, err := evm.ExecutorWithTags("evm")(evm.ExecutorWithLog()(evm.NewExecutor())).Execute(ctx.ctx, execParams)
+	evmExecutor := evm.NewExecutor()
+	if e.cfg != nil && e.cfg.Report != nil {
+		evmExecutor = evm.ExecutorWithReport(e.cfg.Report)(evmExecutor)
+	}
+	if e.cfg != nil && e.cfg.CallGraph != nil {
+		evmExecutor = evm.ExecutorWithCallGraph(e.cfg.CallGraph)(evmExecutor)
+	}
+
+	res, err := evm.ExecutorWithTags("evm")(evm.ExecutorWithLog()(evm.ExecutorWithAbort()(evmExecutor))).Execute(ctx.ctx, execParams)
 	if err != nil {
 		return res, fmt.Errorf("failed to execute block: %v", err)
 	}
 
 	return res, nil
 }
+
+// ExecuteUpToTx executes the transactions 0 to upTo (included) of the block of the prover input, with the overrides of cfg,
+// and returns the intermediate state root after every one of them (see evm.ExecuteUpTo)
+//
+// It is meant to bisect the transaction introducing a divergence between the witness and the execution: the block is
+// not validated and no report is written.
+func ExecuteUpToTx(ctx context.Context, cfg *ExecutionConfig, inputs *input.ProverInput, upTo int) ([]*evm.TxResult, error) {
+	if len(inputs.Blocks) == 0 {
+		return nil, fmt.Errorf("no blocks provided")
+	}
+
+	e := &executor{cfg: &ExecutionConfig{}}
+	if cfg != nil {
+		e.cfg.OverrideTimestamp = cfg.OverrideTimestamp
+		e.cfg.OverrideCoinbase = cfg.OverrideCoinbase
+	}
+
+	ctx = tag.WithComponent(ctx, "execute")
+	ctx = tag.WithTags(
+		ctx,
+		tag.Key("chain.id").String(inputs.ChainConfig.ChainID.String()),
+		tag.Key("block.number").Int64(inputs.Blocks[0].Header.Number.Int64()),
+		tag.Key("block.hash").String(inputs.Blocks[0].Header.Hash().Hex()),
+	)
+	log.LoggerFromContext(ctx).Info("Process partial execution...")
+
+	execCtx, err := e.prepareContext(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare execution context: %v", err)
+	}
+
+	if err := e.preparePreState(execCtx, inputs); err != nil {
+		return nil, fmt.Errorf("failed to prepare pre-state: %v", err)
+	}
+
+	execParams, err := e.prepareExecParams(execCtx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare execution exec params: %v", err)
+	}
+	execParams.VMConfig = &vm.Config{}
+
+	return evm.ExecuteUpTo(ctx, execParams, upTo)
+}
@@ -1,10 +1,29 @@
 package generator
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
 	"testing"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/state"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExecutor(t *testing.T) {
@@ -12,9 +31,515 @@ func TestExecutor(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			testDataInputs := loadTestDataInputs(t, testDataInputsPath(name))
 			proverInput := &testDataInputs.ProverInput
-			e := NewExecutor().(*executor)
+			e := NewExecutor(nil).(*executor)
 			_, err := e.Execute(context.Background(), proverInput)
 			assert.Equal(t, false, err != nil)
 		})
 	}
 }
+
+func TestExecutorInvalidWithdrawalsRoot(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+	require.NotEmpty(t, proverInput.Blocks[0].Withdrawals)
+
+	// Tamper with the withdrawals root, which does not impact the state transition
+	proverInput.Blocks[0].Header.WithdrawalsHash = &gethcommon.Hash{}
+
+	e := NewExecutor(nil).(*executor)
+	_, err := e.Execute(context.Background(), proverInput)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "withdrawals root mismatch")
+}
+
+func TestExecutorSkipRootVerification(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	// Tamper with the post-state root
+	proverInput.Blocks[0].Header.Root = gethcommon.Hash{}
+
+	_, err := NewExecutor(nil).Execute(context.Background(), proverInput)
+	require.Error(t, err)
+
+	_, err = NewExecutor(&ExecutionConfig{SkipRootVerification: true}).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+}
+
+func TestExecutorMultiproof(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+	size := witnessStateSize(proverInput)
+
+	require.NoError(t, proverInput.ToMultiproof())
+	assert.Equal(t, input.ProofFormatMultiproof, proverInput.ProofFormat)
+	assert.Less(t, witnessStateSize(proverInput), size)
+
+	_, err := NewExecutor(nil).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+
+	// Tamper with the first node of the multiproof, its hash no longer matches the pre-state root
+	proverInput.Witness.State[0] = append(hexutil.Bytes{}, proverInput.Witness.State[0]...)
+	proverInput.Witness.State[0][len(proverInput.Witness.State[0])-1] ^= 0x01
+	_, err = NewExecutor(nil).Execute(context.Background(), proverInput)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid state multiproof")
+}
+
+func witnessStateSize(proverInput *input.ProverInput) int {
+	size := 0
+	for _, node := range proverInput.Witness.State {
+		size += len(node)
+	}
+	return size
+}
+
+// sparseStateInput returns the test prover input with its witness padded with nodes the block never accesses, as the
+// witness of a block accessing a small fraction of it
+func sparseStateInput(t testing.TB) *input.ProverInput {
+	proverInput := &loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json")).ProverInput
+	for i := range 10 * len(proverInput.Witness.State) {
+		node := make(hexutil.Bytes, 532)
+		copy(node, fmt.Sprintf("unaccessed node %d", i))
+		proverInput.Witness.State = append(proverInput.Witness.State, node)
+	}
+	return proverInput
+}
+
+func TestExecutorStateLoading(t *testing.T) {
+	load := func(t *testing.T) *input.ProverInput {
+		return &loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json")).ProverInput
+	}
+	multiproof := func(t *testing.T) *input.ProverInput {
+		proverInput := load(t)
+		require.NoError(t, proverInput.ToMultiproof())
+		return proverInput
+	}
+	sparse := func(t *testing.T) *input.ProverInput { return sparseStateInput(t) }
+
+	for name, load := range map[string]func(t *testing.T) *input.ProverInput{"per-account": load, "multiproof": multiproof, "sparse": sparse} {
+		t.Run(name, func(t *testing.T) {
+			eager, err := NewExecutor(&ExecutionConfig{StateLoading: StateLoadingEager}).Execute(context.Background(), load(t))
+			require.NoError(t, err)
+			lazy, err := NewExecutor(&ExecutionConfig{StateLoading: StateLoadingLazy}).Execute(context.Background(), load(t))
+			require.NoError(t, err)
+
+			// Both executions are validated against the post-state root of the block header
+			assert.Equal(t, eager.GasUsed, lazy.GasUsed)
+			assert.Equal(t, gethtypes.DeriveSha(eager.Receipts, trie.NewStackTrie(nil)), gethtypes.DeriveSha(lazy.Receipts, trie.NewStackTrie(nil)))
+		})
+	}
+
+	t.Run("missing root node", func(t *testing.T) {
+		proverInput := load(t)
+		root := proverInput.Witness.Ancestors[0].Root
+		proverInput.Witness.State = slices.DeleteFunc(proverInput.Witness.State, func(node hexutil.Bytes) bool { return crypto.Keccak256Hash(node) == root })
+
+		_, err := NewExecutor(&ExecutionConfig{StateLoading: StateLoadingLazy}).Execute(context.Background(), proverInput)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create pre-state from parent root")
+	})
+
+	_, err := ParseStateLoading("streaming")
+	assert.ErrorContains(t, err, `invalid state loading "streaming"`)
+}
+
+// BenchmarkExecutorStateLoading compares eager and lazy state loading on a dense witness (the block accesses all of
+// it) and a sparse one (padded with nodes the block never accesses)
+func BenchmarkExecutorStateLoading(b *testing.B) {
+	dense := &loadTestDataInputs(b, testDataInputsPath("Ethereum_Mainnet_21465322.json")).ProverInput
+	sparse := sparseStateInput(b)
+
+	for _, witness := range []struct {
+		name  string
+		input *input.ProverInput
+	}{{"dense", dense}, {"sparse", sparse}} {
+		for _, loading := range []StateLoading{StateLoadingEager, StateLoadingLazy} {
+			b.Run(fmt.Sprintf("%v/%v", witness.name, loading), func(b *testing.B) {
+				executor := NewExecutor(&ExecutionConfig{StateLoading: loading})
+				for range b.N {
+					if _, err := executor.Execute(context.Background(), witness.input); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestExecutorAncestorHeaders(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	// The parent header links to the block
+	proverInput.AncestorHeaders = []*gethtypes.Header{proverInput.Witness.Ancestors[0]}
+	_, err := NewExecutor(nil).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+
+	// A header which is not the parent of the previous one breaks the chain
+	proverInput.AncestorHeaders = append(proverInput.AncestorHeaders, proverInput.Witness.Ancestors[0])
+	_, err = NewExecutor(nil).Execute(context.Background(), proverInput)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid ancestor headers: ancestor header 1")
+}
+
+func TestExecutorReport(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	var report bytes.Buffer
+	_, err := NewExecutor(&ExecutionConfig{Report: &report}).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+
+	var entries []*evm.ReportEntry
+	scanner := bufio.NewScanner(&report)
+	for scanner.Scan() {
+		entry := new(evm.ReportEntry)
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), entry))
+		entries = append(entries, entry)
+	}
+
+	txs := proverInput.Blocks[0].Transactions
+	require.Len(t, entries, 2+2*len(txs))
+	assert.Equal(t, evm.ReportBlockStart, entries[0].Event)
+	assert.Equal(t, len(txs), entries[0].TxCount)
+
+	last := entries[len(entries)-2]
+	assert.Equal(t, evm.ReportTxEnd, last.Event)
+	assert.Equal(t, len(txs)-1, *last.TxIndex)
+	assert.Equal(t, txs[len(txs)-1].Hash().Hex(), last.TxHash)
+	assert.Equal(t, proverInput.Blocks[0].Header.GasUsed, last.CumulativeGasUsed)
+
+	assert.Equal(t, evm.ReportBlockEnd, entries[len(entries)-1].Event)
+	assert.Empty(t, entries[len(entries)-1].Error)
+}
+
+func TestExecutorCompletenessReport(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	execute := func() (*CompletenessReport, error) {
+		var buf bytes.Buffer
+		_, err := NewExecutor(&ExecutionConfig{CompletenessReport: &buf}).Execute(context.Background(), proverInput)
+		report := new(CompletenessReport)
+		require.NoError(t, json.Unmarshal(buf.Bytes(), report))
+		return report, err
+	}
+
+	report, err := execute()
+	require.NoError(t, err)
+	assert.True(t, report.Complete)
+	assert.Equal(t, proverInput.Blocks[0].Header.Number.Uint64(), report.BlockNumber)
+	assert.Empty(t, report.Missing)
+	assert.Positive(t, report.Accounts)
+	assert.Positive(t, report.Slots)
+	assert.Positive(t, report.Codes)
+	assert.Len(t, report.Accesses, report.Accounts+report.Slots+report.Codes)
+
+	// Drop a bytecode from the witness, its accesses are flagged as missing
+	require.NotEmpty(t, proverInput.Witness.Codes)
+	dropped := crypto.Keccak256Hash(proverInput.Witness.Codes[0])
+	proverInput.Witness.Codes = proverInput.Witness.Codes[1:]
+
+	report, err = execute()
+	require.Error(t, err)
+	assert.False(t, report.Complete)
+	require.NotEmpty(t, report.Missing)
+	for _, access := range report.Missing {
+		assert.Equal(t, state.AccessKindCode, access.Kind)
+		assert.Equal(t, dropped, *access.CodeHash)
+		assert.Equal(t, state.AccessMissing, access.Status)
+	}
+	assert.NotEmpty(t, report.Error)
+}
+
+func TestExecutorAccessList(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	var accessList, completeness bytes.Buffer
+	_, err := NewExecutor(&ExecutionConfig{AccessList: &accessList, CompletenessReport: &completeness}).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+
+	report := new(AccessListReport)
+	require.NoError(t, json.Unmarshal(accessList.Bytes(), report))
+	assert.Equal(t, proverInput.Blocks[0].Header.Number.Uint64(), report.BlockNumber)
+	assert.Empty(t, report.Error)
+
+	// The access list lists the accounts and slots of the completeness report
+	completenessReport := new(CompletenessReport)
+	require.NoError(t, json.Unmarshal(completeness.Bytes(), completenessReport))
+	assert.Len(t, report.AccessList, completenessReport.Accounts)
+	assert.Equal(t, completenessReport.Slots, report.AccessList.StorageKeys())
+
+	// The coinbase receives the fees of the block
+	assert.True(t, slices.ContainsFunc(report.AccessList, func(tuple gethtypes.AccessTuple) bool {
+		return tuple.Address == proverInput.Blocks[0].Header.Coinbase
+	}))
+}
+
+func TestExecutorCallGraph(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	var callGraph bytes.Buffer
+	_, err := NewExecutor(&ExecutionConfig{CallGraph: &callGraph}).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+
+	graph := callGraph.String()
+	assert.True(t, strings.HasPrefix(graph, fmt.Sprintf("digraph \"block_%d\" {\n", proverInput.Blocks[0].Header.Number.Uint64())), graph)
+	assert.True(t, strings.HasSuffix(graph, "}\n"))
+	assert.Regexp(t, `"0x[0-9a-fA-F]{40}" -> "0x[0-9a-fA-F]{40}" \[label="CALL x\d+"\];`, graph)
+	assert.Regexp(t, `\[label="(DELEGATECALL|STATICCALL) x\d+"\];`, graph)
+
+	// The graph of a block is deterministic
+	var again bytes.Buffer
+	_, err = NewExecutor(&ExecutionConfig{CallGraph: &again}).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+	assert.Equal(t, graph, again.String())
+}
+
+func TestExecutorAbort(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	// The execution is aborted once ctx is done, at the start of the next transaction
+	errAbort := fmt.Errorf("test abort")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	var report bytes.Buffer
+	w := &cancelingWriter{w: &report, cancel: func() { cancel(errAbort) }}
+	_, err := NewExecutor(&ExecutionConfig{Report: w}).Execute(ctx, proverInput)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "block execution aborted: test abort")
+}
+
+// cancelingWriter is a writer calling cancel on its first write
+type cancelingWriter struct {
+	w      *bytes.Buffer
+	cancel func()
+}
+
+func (w *cancelingWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	return w.w.Write(p)
+}
+
+func TestExecuteUpToTx(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	res, err := NewExecutor(nil).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+	last := len(res.Receipts) - 1
+	require.Greater(t, last, 2)
+
+	proverInput = &loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json")).ProverInput
+	all, err := ExecuteUpToTx(context.Background(), nil, proverInput, last)
+	require.NoError(t, err)
+	require.Len(t, all, last+1)
+	for i, txRes := range all {
+		assert.Equal(t, i, txRes.Index)
+		assert.Equal(t, res.Receipts[i].TxHash, txRes.Hash)
+		assert.Equal(t, res.Receipts[i].GasUsed, txRes.GasUsed)
+		assert.Equal(t, res.Receipts[i].Status, txRes.Status)
+		if i > 0 {
+			assert.NotEqual(t, all[i-1].StateRoot, txRes.StateRoot)
+		}
+	}
+
+	// Stopping earlier yields the same intermediate roots
+	proverInput = &loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json")).ProverInput
+	partial, err := ExecuteUpToTx(context.Background(), nil, proverInput, 2)
+	require.NoError(t, err)
+	assert.Equal(t, all[:3], partial)
+
+	_, err = ExecuteUpToTx(context.Background(), nil, proverInput, last+1)
+	assert.ErrorContains(t, err, "out of range")
+}
+
+func TestExecutorBeaconRootsSystemCall(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+	header := proverInput.Blocks[0].Header
+	require.True(t, proverInput.ChainConfig.IsCancun(header.Number, header.Time))
+	require.NotNil(t, header.ParentBeaconRoot)
+
+	// The EIP-4788 system call stores the timestamp and the parent beacon root in the ring buffer of the beacon roots contract
+	const historyBufferLength = 8191
+	timestampSlot := gethcommon.BigToHash(new(big.Int).SetUint64(header.Time % historyBufferLength))
+	rootSlot := gethcommon.BigToHash(new(big.Int).SetUint64(header.Time%historyBufferLength + historyBufferLength))
+
+	// Preflight collected the pre-state of both slots
+	var slots []gethcommon.Hash
+	for _, proof := range testDataInputs.PreflightData.PreStateProofs {
+		if proof.Address == params.BeaconRootsAddress {
+			for _, storage := range proof.Storage {
+				slots = append(slots, gethcommon.HexToHash(storage.Key))
+			}
+		}
+	}
+	assert.ElementsMatch(t, []gethcommon.Hash{timestampSlot, rootSlot}, slots)
+
+	// Execution accesses the contract and both slots, backed by the witness
+	var buf bytes.Buffer
+	_, err := NewExecutor(&ExecutionConfig{CompletenessReport: &buf}).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+	report := new(CompletenessReport)
+	require.NoError(t, json.Unmarshal(buf.Bytes(), report))
+	accessed := make(map[string]state.AccessStatus)
+	for _, access := range report.Accesses {
+		if access.Address != params.BeaconRootsAddress {
+			continue
+		}
+		key := access.Kind
+		if access.Slot != nil {
+			key += ":" + access.Slot.Hex()
+		}
+		accessed[key] = access.Status
+	}
+	assert.Equal(t, state.AccessPresent, accessed[state.AccessKindAccount])
+	assert.Equal(t, state.AccessPresent, accessed[state.AccessKindStorage+":"+timestampSlot.Hex()])
+	assert.Equal(t, state.AccessPresent, accessed[state.AccessKindStorage+":"+rootSlot.Hex()])
+
+	// The system call uses the parent beacon root of the header: another root yields another post-state
+	otherRoot := gethcommon.HexToHash("0x1")
+	header.ParentBeaconRoot = &otherRoot
+	_, err = NewExecutor(nil).Execute(context.Background(), proverInput)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid merkle root")
+}
+
+func TestExecutorDualExecute(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	_, err := NewExecutor(&ExecutionConfig{DualExecute: true}).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+
+	// Both paths apply the same overrides
+	coinbase := gethcommon.HexToAddress("0x1234")
+	_, err = NewExecutor(&ExecutionConfig{DualExecute: true, OverrideCoinbase: &coinbase}).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+}
+
+func TestExecutorVerifySignatures(t *testing.T) {
+	cfg := &ExecutionConfig{VerifySignatures: true}
+	load := func(t *testing.T) *input.ProverInput {
+		return &loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json")).ProverInput
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		_, err := NewExecutor(cfg).Execute(context.Background(), load(t))
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		proverInput := load(t)
+		block := proverInput.Blocks[0]
+		signer := gethtypes.LatestSignerForChainID(proverInput.ChainConfig.ChainID)
+		tx, err := block.Transactions[1].WithSignature(signer, make([]byte, crypto.SignatureLength))
+		require.NoError(t, err)
+		block.Transactions[1] = tx
+
+		_, err = NewExecutor(cfg).Execute(context.Background(), proverInput)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid transaction signatures: failed to recover sender of transaction 1")
+	})
+
+	t.Run("re-signed transaction", func(t *testing.T) {
+		proverInput := load(t)
+		block := proverInput.Blocks[0]
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		tx, err := gethtypes.SignTx(block.Transactions[1], gethtypes.MakeSigner(proverInput.ChainConfig, block.Header.Number, block.Header.Time), key)
+		require.NoError(t, err)
+		block.Transactions[1] = tx
+
+		_, err = NewExecutor(cfg).Execute(context.Background(), proverInput)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid transaction signatures: signed transactions do not match the block header")
+	})
+}
+
+func TestExecutorPreExecuteHooks(t *testing.T) {
+	load := func(t *testing.T) *input.ProverInput {
+		return &loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json")).ProverInput
+	}
+
+	t.Run("hooks run in order", func(t *testing.T) {
+		proverInput := load(t)
+		var calls []string
+		var coinbaseBalance *big.Int
+		cfg := &ExecutionConfig{PreExecuteHooks: []PreExecuteHook{
+			func(_ context.Context, env *ExecutionEnv) error {
+				calls = append(calls, "first")
+				assert.Equal(t, proverInput.Blocks[0].Header.Hash(), env.Block.Hash())
+				coinbaseBalance = env.State.GetBalance(env.Block.Coinbase()).ToBig()
+				return nil
+			},
+			func(context.Context, *ExecutionEnv) error {
+				calls = append(calls, "second")
+				return nil
+			},
+		}}
+
+		_, err := NewExecutor(cfg).Execute(context.Background(), proverInput)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first", "second"}, calls)
+		assert.Positive(t, coinbaseBalance.Sign())
+	})
+
+	t.Run("error aborts execution", func(t *testing.T) {
+		called := false
+		cfg := &ExecutionConfig{PreExecuteHooks: []PreExecuteHook{
+			func(context.Context, *ExecutionEnv) error { return fmt.Errorf("rejected") },
+			func(context.Context, *ExecutionEnv) error {
+				called = true
+				return nil
+			},
+		}}
+
+		_, err := NewExecutor(cfg).Execute(context.Background(), load(t))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "pre-execute hook 0 failed: rejected")
+		assert.False(t, called)
+	})
+
+	t.Run("state modification fails validation", func(t *testing.T) {
+		proverInput := load(t)
+		modify := func(_ context.Context, env *ExecutionEnv) error {
+			env.State.AddBalance(env.Block.Coinbase(), uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+			return nil
+		}
+
+		_, err := NewExecutor(&ExecutionConfig{PreExecuteHooks: []PreExecuteHook{modify}}).Execute(context.Background(), proverInput)
+		require.Error(t, err)
+
+		_, err = NewExecutor(&ExecutionConfig{PreExecuteHooks: []PreExecuteHook{modify}, SkipRootVerification: true}).Execute(context.Background(), load(t))
+		require.NoError(t, err)
+	})
+}
+
+func TestCompareExecutions(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+	block := proverInput.Blocks[0].Block()
+
+	ref, err := executeReference(proverInput, block)
+	require.NoError(t, err)
+	assert.Equal(t, block.Root(), ref.stateRoot)
+	assert.Empty(t, compareExecutions(block, ref.res, ref.res))
+
+	got := *ref.res
+	got.Receipts = slices.Clone(ref.res.Receipts)
+	receipt := *got.Receipts[1]
+	receipt.CumulativeGasUsed++
+	got.Receipts[1] = &receipt
+
+	divergences := compareExecutions(block, ref.res, &got)
+	require.Len(t, divergences, 2)
+	assert.Equal(t, block.Transactions()[1].Hash(), divergences[0].TxHash)
+	assert.Equal(t, "cumulative gas used", divergences[0].Field)
+	assert.Equal(t, "block: receipt root differs (reference: "+divergences[1].Expected+", executor: "+divergences[1].Got+")", divergences[1].dualString())
+}
@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/state"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+)
+
+// NewExecutionWitness returns the witness of a prover input in the standard execution witness layout
+//
+// The preimages of the accessed state keys are not part of the prover input, so they are collected by executing the block
+// on the prover input (which also verifies the witness is complete).
+func NewExecutionWitness(ctx context.Context, inputs *input.ProverInput) (*input.ExecutionWitness, error) {
+	keys, err := accessedKeys(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return input.NewExecutionWitness(inputs, keys)
+}
+
+// accessedKeys executes the block on the prover input and returns the addresses of the accessed accounts,
+// each followed by its accessed storage slots, sorted
+func accessedKeys(ctx context.Context, inputs *input.ProverInput) ([][]byte, error) {
+	if len(inputs.Blocks) == 0 {
+		return nil, fmt.Errorf("no blocks provided")
+	}
+
+	e := &executor{}
+	execCtx, err := e.prepareContext(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare execution context: %v", err)
+	}
+	trackers := state.NewAccessTrackerManager()
+	execCtx.stateDB = state.NewAccessTrackerDatabase(execCtx.stateDB, trackers)
+
+	if err := e.preparePreState(execCtx, inputs); err != nil {
+		return nil, err
+	}
+
+	execParams, err := e.prepareExecParams(execCtx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare execution exec params: %v", err)
+	}
+
+	if _, err := e.execEVM(execCtx, execParams); err != nil {
+		return nil, err
+	}
+
+	tracker := trackers.GetAccessTracker(inputs.Witness.Ancestors[0].Root)
+	if tracker == nil {
+		return nil, fmt.Errorf("no state access recorded")
+	}
+
+	accounts := make(map[gethcommon.Address]struct{})
+	for addr := range tracker.Accounts {
+		accounts[addr] = struct{}{}
+	}
+	for addr := range tracker.Absent {
+		accounts[addr] = struct{}{}
+	}
+	for addr := range tracker.Storage {
+		accounts[addr] = struct{}{}
+	}
+	addrs := make([]gethcommon.Address, 0, len(accounts))
+	for addr := range accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+
+	var keys [][]byte
+	for _, addr := range addrs {
+		keys = append(keys, addr.Bytes())
+
+		slots := make([]gethcommon.Hash, 0, len(tracker.Storage[addr]))
+		for slot := range tracker.Storage[addr] {
+			slots = append(slots, slot)
+		}
+		sort.Slice(slots, func(i, j int) bool { return bytes.Compare(slots[i][:], slots[j][:]) < 0 })
+		for _, slot := range slots {
+			keys = append(keys, slot.Bytes())
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// executeStateless executes block on a JSON encoded execution witness with the go-ethereum stateless executor,
+// which only depends on the standard execution witness layout, and checks the resulting state and receipts roots
+func executeStateless(t *testing.T, chainCfg *params.ChainConfig, block *gethtypes.Block, raw []byte) {
+	var ext struct {
+		Headers []*gethtypes.Header `json:"headers"`
+		Codes   []hexutil.Bytes     `json:"codes"`
+		State   []hexutil.Bytes     `json:"state"`
+		Keys    []hexutil.Bytes     `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &ext))
+
+	// The stateless executor computes the roots so it expects them to be zeroed
+	header := block.Header()
+	header.Root, header.ReceiptHash = gethcommon.Hash{}, gethcommon.Hash{}
+
+	witness, err := stateless.NewWitness(header, nil)
+	require.NoError(t, err)
+	witness.Headers = ext.Headers
+	for _, code := range ext.Codes {
+		witness.AddCode(code)
+	}
+	nodes := make(map[string]struct{})
+	for _, node := range ext.State {
+		nodes[string(node)] = struct{}{}
+	}
+	witness.AddState(nodes)
+
+	stateRoot, receiptRoot, err := core.ExecuteStateless(chainCfg, gethtypes.NewBlockWithHeader(header).WithBody(*block.Body()), witness)
+	require.NoError(t, err)
+	assert.Equal(t, block.Root(), stateRoot)
+	assert.Equal(t, block.ReceiptHash(), receiptRoot)
+}
+
+func TestExecutionWitnessReferenceVector(t *testing.T) {
+	remote, _, block1 := newMainnetGenesisRemote(t)
+	data, err := NewPreflight(remote, nil).Preflight(context.Background(), big.NewInt(1))
+	require.NoError(t, err)
+	proverInput, err := NewPreparer(nil).Prepare(context.Background(), data)
+	require.NoError(t, err)
+
+	w, err := NewExecutionWitness(context.Background(), proverInput)
+	require.NoError(t, err)
+	assert.Equal(t, []hexutil.Bytes{block1.Coinbase().Bytes()}, w.Keys, "only the coinbase is accessed")
+
+	b, err := json.Marshal(w)
+	require.NoError(t, err)
+	ref, err := os.ReadFile(filepath.Join("testdata", "execution-witness", "mainnet_1.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, string(ref), string(b))
+
+	executeStateless(t, params.MainnetChainConfig, block1, ref)
+}
+
+func TestExecutionWitness(t *testing.T) {
+	for _, name := range testcases {
+		t.Run(name, func(t *testing.T) {
+			proverInput := &loadTestDataInputs(t, testDataInputsPath(name)).ProverInput
+
+			w, err := NewExecutionWitness(context.Background(), proverInput)
+			require.NoError(t, err)
+			assert.Len(t, w.State, len(proverInput.Witness.State))
+			assert.Len(t, w.Codes, len(proverInput.Witness.Codes))
+			assert.Contains(t, w.Keys, hexutil.Bytes(proverInput.Blocks[0].Header.Coinbase.Bytes()))
+
+			b, err := json.Marshal(w)
+			require.NoError(t, err)
+			executeStateless(t, proverInput.ChainConfig, proverInput.Blocks[0].Block(), b)
+		})
+	}
+}
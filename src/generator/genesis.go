@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"context"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/chaindata"
+)
+
+// genesisClient is an ethrpc.Client serving state requests on the genesis block from the genesis allocation
+// and forwarding every other request to the remote
+//
+// It enables to generate prover inputs for block 1, whose pre-state is the genesis state,
+// as RPC nodes often can not serve proofs for the genesis block.
+type genesisClient struct {
+	ethrpc.Client
+
+	genesis *chaindata.Client
+}
+
+func newGenesisClient(remote ethrpc.Client, genesis *core.Genesis) (*genesisClient, error) {
+	local, err := chaindata.NewGenesisClient(genesis)
+	if err != nil {
+		return nil, err
+	}
+	return &genesisClient{Client: remote, genesis: local}, nil
+}
+
+func isGenesis(blockNumber *big.Int) bool {
+	return blockNumber != nil && blockNumber.Sign() == 0
+}
+
+func (c *genesisClient) GetProof(ctx context.Context, account gethcommon.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	if isGenesis(blockNumber) {
+		return c.genesis.GetProof(ctx, account, keys, blockNumber)
+	}
+	return c.Client.GetProof(ctx, account, keys, blockNumber)
+}
+
+func (c *genesisClient) CodeAt(ctx context.Context, account gethcommon.Address, blockNumber *big.Int) ([]byte, error) {
+	if isGenesis(blockNumber) {
+		return c.genesis.CodeAt(ctx, account, blockNumber)
+	}
+	return c.Client.CodeAt(ctx, account, blockNumber)
+}
+
+func (c *genesisClient) StorageAt(ctx context.Context, account gethcommon.Address, key gethcommon.Hash, blockNumber *big.Int) ([]byte, error) {
+	if isGenesis(blockNumber) {
+		return c.genesis.StorageAt(ctx, account, key, blockNumber)
+	}
+	return c.Client.StorageAt(ctx, account, key, blockNumber)
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/params"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mainnetRemote serves the first blocks of Ethereum mainnet and fails on any state request
+type mainnetRemote struct {
+	ethrpc.Client
+
+	blocks map[uint64]*gethtypes.Block
+}
+
+func (r *mainnetRemote) ChainID(context.Context) (*big.Int, error) {
+	return params.MainnetChainConfig.ChainID, nil
+}
+
+func (r *mainnetRemote) BlockByNumber(_ context.Context, n *big.Int) (*gethtypes.Block, error) {
+	block, ok := r.blocks[n.Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("block %v not found", n)
+	}
+	return block, nil
+}
+
+func (r *mainnetRemote) HeaderByHash(_ context.Context, hash gethcommon.Hash) (*gethtypes.Header, error) {
+	for _, block := range r.blocks {
+		if block.Hash() == hash {
+			return block.Header(), nil
+		}
+	}
+	return nil, fmt.Errorf("header %v not found", hash)
+}
+
+func (r *mainnetRemote) GetProof(_ context.Context, account gethcommon.Address, _ []string, n *big.Int) (*gethclient.AccountResult, error) {
+	return nil, fmt.Errorf("unexpected proof request for account %v at block %v", account, n)
+}
+
+// newMainnetGenesisRemote returns a remote serving the mainnet genesis block and block 1
+func newMainnetGenesisRemote(t *testing.T) (remote *mainnetRemote, genesis, block1 *gethtypes.Block) {
+	genesis = core.DefaultGenesisBlock().ToBlock()
+	block1 = gethtypes.NewBlockWithHeader(&gethtypes.Header{
+		ParentHash:  genesis.Hash(),
+		UncleHash:   gethtypes.EmptyUncleHash,
+		Coinbase:    gethcommon.HexToAddress("0x05a56e2d52c817161883f50c441c3228cfe54d9f"),
+		Root:        gethcommon.HexToHash("0xd67e4d450343046425ae4271474353857ab860dbc0a1dde64b41b5cd3a532bf3"),
+		TxHash:      gethtypes.EmptyTxsHash,
+		ReceiptHash: gethtypes.EmptyReceiptsHash,
+		Difficulty:  big.NewInt(17171480576),
+		Number:      big.NewInt(1),
+		GasLimit:    5000,
+		Time:        1438269988,
+		Extra:       hexutil.MustDecode("0x476574682f76312e302e302f6c696e75782f676f312e342e32"),
+		MixDigest:   gethcommon.HexToHash("0x969b900de27b6ac6a67742365dd65f55a0526c41fd18e1b16f1a1215c2e66f59"),
+		Nonce:       gethtypes.EncodeNonce(0x539bd4979fef1ec4),
+	})
+	require.Equal(t, gethcommon.HexToHash("0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"), block1.Hash())
+
+	return &mainnetRemote{blocks: map[uint64]*gethtypes.Block{0: genesis, 1: block1}}, genesis, block1
+}
+
+func TestGenesisBlocks(t *testing.T) {
+	remote, genesis, _ := newMainnetGenesisRemote(t)
+
+	// Block 1 pre-state is read from the genesis allocation
+	data, err := NewPreflight(remote, nil).Preflight(context.Background(), big.NewInt(1))
+	require.NoError(t, err)
+	require.Len(t, data.PreStateProofs, 1, "only the coinbase is accessed")
+	assert.Equal(t, genesis.Header().Hash(), data.Ancestors[0].Hash())
+
+	proverInput, err := NewPreparer(nil).Prepare(context.Background(), data)
+	require.NoError(t, err)
+
+	_, err = NewExecutor(nil).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+
+	// The genesis block can not be executed
+	_, err = NewPreflight(remote, nil).Preflight(context.Background(), big.NewInt(0))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "genesis block")
+}
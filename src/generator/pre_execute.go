@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+)
+
+// ExecutionEnv is the environment of a block about to be executed by the Executor, passed to the pre-execute hooks
+type ExecutionEnv struct {
+	Inputs *input.ProverInput // Prover input of the block
+	Chain  *core.HeaderChain  // Chain of the ancestors of the block
+
+	// Block is the block to execute, with the overrides of the ExecutionConfig applied, a hook may replace it
+	Block *gethtypes.Block
+
+	// State is the pre-state of the block, built from the witness of the prover input. A hook may modify it, which makes
+	// the post-state root differ from the one of the block header: the block then fails validation unless it is skipped
+	// (see ExecutionConfig.SkipRootVerification).
+	State *gethstate.StateDB
+}
+
+// PreExecuteHook is a function called by the Executor right before executing a block (see ExecutionConfig.PreExecuteHooks),
+// an error aborts the execution of the block
+type PreExecuteHook func(ctx context.Context, env *ExecutionEnv) error
+
+// runPreExecuteHooks runs the pre-execute hooks of cfg in order, stopping at the first failing one
+func (e *executor) runPreExecuteHooks(ctx context.Context, env *ExecutionEnv) error {
+	if e.cfg == nil {
+		return nil
+	}
+	for i, hook := range e.cfg.PreExecuteHooks {
+		if err := hook(ctx, env); err != nil {
+			return fmt.Errorf("pre-execute hook %d failed: %v", i, err)
+		}
+	}
+	return nil
+}
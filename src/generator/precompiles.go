@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"slices"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+)
+
+// precompileTracker records the precompiled contracts called during the execution of a block
+//
+// Precompiles have no code nor storage, but their account is part of the state: calling one reads it (and touches it
+// per EIP-161), so its account proof is needed to execute the block on the witness, even when the account does not
+// exist in the state (in which case the state access tracker does not record it).
+type precompileTracker struct {
+	precompiles map[gethcommon.Address]struct{} // Precompiles active at the block
+
+	mu     sync.Mutex
+	called map[gethcommon.Address]struct{}
+}
+
+// newPrecompileTracker creates a tracker of the precompiles active at block
+func newPrecompileTracker(chainCfg *params.ChainConfig, block *gethtypes.Block) *precompileTracker {
+	rules := chainCfg.Rules(block.Number(), block.Difficulty().Sign() == 0, block.Time())
+	precompiles := make(map[gethcommon.Address]struct{})
+	for _, addr := range vm.ActivePrecompiles(rules) {
+		precompiles[addr] = struct{}{}
+	}
+	return &precompileTracker{
+		precompiles: precompiles,
+		called:      make(map[gethcommon.Address]struct{}),
+	}
+}
+
+// Called returns the precompiles called during the execution, sorted by address
+func (t *precompileTracker) Called() []gethcommon.Address {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	called := make([]gethcommon.Address, 0, len(t.called))
+	for addr := range t.called {
+		called = append(called, addr)
+	}
+	slices.SortFunc(called, func(a, b gethcommon.Address) int { return bytes.Compare(a.Bytes(), b.Bytes()) })
+	return called
+}
+
+// OnEnter records the call frames entering a precompile
+func (t *precompileTracker) OnEnter(_ int, _ byte, _, to gethcommon.Address, _ []byte, _ uint64, _ *big.Int) {
+	if _, ok := t.precompiles[to]; !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.called[to] = struct{}{}
+}
+
+// Executor is an executor decorator recording the precompiles called by the block
+// It chains its hook with the tracer already set on the execution parameters (e.g. by evm.ExecutorWithLog)
+func (t *precompileTracker) Executor(executor evm.Executor) evm.Executor {
+	return evm.ExecutorFunc(func(ctx context.Context, params *evm.ExecParams) (*core.ProcessResult, error) {
+		hooks := &tracing.Hooks{OnEnter: t.OnEnter}
+		if params.VMConfig.Tracer != nil {
+			chained := *params.VMConfig.Tracer
+			if prev := chained.OnEnter; prev != nil {
+				chained.OnEnter = func(depth int, typ byte, from, to gethcommon.Address, input []byte, gas uint64, value *big.Int) {
+					prev(depth, typ, from, to, input, gas, value)
+					t.OnEnter(depth, typ, from, to, input, gas, value)
+				}
+			} else {
+				chained.OnEnter = t.OnEnter
+			}
+			hooks = &chained
+		}
+		params.VMConfig.Tracer = hooks
+		return executor.Execute(ctx, params)
+	})
+}
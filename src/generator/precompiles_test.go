@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/state"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ecrecover = gethcommon.BytesToAddress([]byte{0x01})
+
+func TestPrecompileTracker(t *testing.T) {
+	// Block 21465322 calls the ecrecover precompile
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	e := NewExecutor(nil).(*executor)
+	execCtx, err := e.prepareContext(context.Background(), proverInput)
+	require.NoError(t, err)
+	require.NoError(t, e.preparePreState(execCtx, proverInput))
+	execParams, err := e.prepareExecParams(execCtx, proverInput)
+	require.NoError(t, err)
+
+	tracker := newPrecompileTracker(proverInput.ChainConfig, execParams.Block)
+	_, err = evm.ExecutorWithLog()(tracker.Executor(evm.NewExecutor())).Execute(context.Background(), execParams)
+	require.NoError(t, err)
+	assert.Contains(t, tracker.Called(), ecrecover)
+
+	var proved bool
+	require.NoError(t, testDataInputs.PreflightData.EachPreStateProof(func(proof *trie.AccountProof) error {
+		proved = proved || proof.Address == ecrecover
+		return nil
+	}))
+	assert.True(t, proved, "precompile called by the block must be proved")
+}
+
+func TestProvedAccounts(t *testing.T) {
+	account := gethcommon.HexToAddress("0xabcd")
+	modexp := gethcommon.BytesToAddress([]byte{0x05})
+	kzg := gethcommon.BytesToAddress([]byte{0x0a})
+	tracker := &state.AccessTracker{
+		Accounts: map[gethcommon.Address]*gethtypes.StateAccount{account: {}, ecrecover: {}},
+		Absent:   map[gethcommon.Address]struct{}{kzg: {}},
+	}
+
+	assert.ElementsMatch(t, []gethcommon.Address{account, ecrecover, modexp, kzg}, provedAccounts(tracker, []gethcommon.Address{ecrecover, modexp, kzg}))
+	assert.ElementsMatch(t, []gethcommon.Address{account, ecrecover}, provedAccounts(tracker, nil))
+}
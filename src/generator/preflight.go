@@ -2,8 +2,10 @@ package generator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -12,6 +14,7 @@ import (
 	gethstate "github.com/ethereum/go-ethereum/core/state"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/ethereum/go-ethereum/triedb/hashdb"
@@ -30,12 +33,17 @@ import (
 // It contains the partial state & chain data necessary for processing the block and validating the final state.
 // The format is convenient but sub-optimal as it contains duplicated data, it is an intermediate object necessary to generate the final ProverInput.
 type PreflightData struct {
-	Block           *ethrpc.Block        `json:"block"`           // Block to execute
-	Ancestors       []*gethtypes.Header  `json:"ancestors"`       // Ancestors of the block that are accessed during the block execution
-	ChainConfig     *params.ChainConfig  `json:"chainConfig"`     // Chain configuration
-	Codes           []hexutil.Bytes      `json:"codes"`           // Contract bytecodes used during the block execution
-	PreStateProofs  []*trie.AccountProof `json:"preStateProofs"`  // Proofs of every accessed account and storage slot accessed during the block processing
-	PostStateProofs []*trie.AccountProof `json:"postStateProofs"` // Proofs of every account and storage slot deleted during the block processing
+	Block           *ethrpc.Block        `json:"block"`            // Block to execute
+	Uncles          []*gethtypes.Header  `json:"uncles,omitempty"` // Uncles of the block (pre-Merge blocks only)
+	Ancestors       []*gethtypes.Header  `json:"ancestors"`        // Ancestors of the block that are accessed during the block execution
+	ChainConfig     *params.ChainConfig  `json:"chainConfig"`      // Chain configuration
+	Codes           []hexutil.Bytes      `json:"codes"`            // Contract bytecodes used during the block execution
+	PreStateProofs  []*trie.AccountProof `json:"preStateProofs"`   // Proofs of every accessed account and storage slot accessed during the block processing
+	PostStateProofs []*trie.AccountProof `json:"postStateProofs"`  // Proofs of every account and storage slot deleted during the block processing
+
+	AncestorHeaders []*gethtypes.Header `json:"ancestorHeaders,omitempty"` // Last ancestor headers of the block, parent first (see PreflightConfig.AncestorHeaders)
+
+	spilledPreStateProofs *ProofBuffer // Pre-state proofs spilled to disk above the memory budget, after PreStateProofs (see EachPreStateProof)
 }
 
 // Preflight is the interface for the preflight block execution which consists of processing an EVM block without final state validation.
@@ -46,16 +54,51 @@ type Preflight interface {
 	Preflight(ctx context.Context, blockNumber *big.Int) (*PreflightData, error)
 }
 
+// PreflightConfig is the configuration of the preflight block execution
+type PreflightConfig struct {
+	// If true, the accounts and storage slots of the transactions' EIP-2930 access lists are fetched upfront,
+	// with a single eth_getProof call per account, instead of being discovered one by one during execution.
+	// Accessed slots are still tracked by execution, so the witness completeness is unchanged
+	// (and it is verified by the final block execution of prepare).
+	SeedAccessLists bool
+
+	// Number of ancestor headers of the block to embed in the prover input, so a verifier can check the block chains back
+	// to a trusted checkpoint (see input.ProverInput.AncestorHeaders). Zero disables it.
+	AncestorHeaders int
+
+	// Overrides applied to the executed block, so the collected state covers the overridden values
+	// (the preflight data still holds the canonical block).
+	Execution *ExecutionConfig
+
+	// Soft memory budget in bytes of the pre-state proofs, above which they are spilled to a temporary file on disk
+	// and streamed back when the preflight data is stored or prepared (see ProofBuffer). Zero disables it.
+	MemoryBudget uint64
+
+	// Optional cache of the account proofs, keyed by state root and account, deduplicating the proofs fetched again
+	// under the same state root by execution and by the pre-state proofs of a block (see state.ProofDedupCache)
+	ProofDedupCache *state.ProofDedupCache
+
+	// Optional genesis of the chain, whose allocation is the pre-state of block 1 (defaults to the built-in genesis of
+	// known chains, see ethereum.Genesis). Its chain configuration is used if the chain has no built-in configuration.
+	Genesis *core.Genesis
+}
+
 // preflight is the implementation of the Preflight interface using an RPC remote to fetch the state datas.
 type preflight struct {
 	remote ethrpc.Client
+	cfg    PreflightConfig
 }
 
 // NewPreflight creates a new RPC Preflight instance using the provided RPC client.
-func NewPreflight(remote ethrpc.Client) Preflight {
-	return &preflight{
+// If cfg is nil, the default configuration is used.
+func NewPreflight(remote ethrpc.Client, cfg *PreflightConfig) Preflight {
+	pf := &preflight{
 		remote: remote,
 	}
+	if cfg != nil {
+		pf.cfg = *cfg
+	}
+	return pf
 }
 
 // Preflight executes a preflight block execution, that collect and returns the intermediary preflight data input.
@@ -75,6 +118,26 @@ func (pf *preflight) Preflight(ctx context.Context, blockNumber *big.Int) (*Pref
 		tag.Key("block.hash").String(block.Hash().Hex()),
 	)
 
+	// The pre-state of block 1 is the genesis state, which is served from the genesis allocation
+	if block.NumberU64() == 1 {
+		genesis := pf.cfg.Genesis
+		if genesis != nil {
+			if hash := genesis.ToBlock().Hash(); hash != block.ParentHash() {
+				return nil, fmt.Errorf("genesis block %v does not match the parent of block 1 %v", hash.Hex(), block.ParentHash().Hex())
+			}
+		} else {
+			genesis = ethereum.Genesis(chainCfg)
+		}
+		if genesis != nil {
+			log.LoggerFromContext(ctx).Debug("Parent is the genesis block, reading pre-state from the genesis allocation")
+			remote, err := newGenesisClient(pf.remote, genesis)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load genesis state: %v", err)
+			}
+			pf = &preflight{remote: remote, cfg: pf.cfg}
+		}
+	}
+
 	// Execute preflight
 	data, err := pf.preflight(ctx, chainCfg, block)
 	if err != nil {
@@ -93,15 +156,27 @@ func (pf *preflight) init(ctx context.Context, blockNumber *big.Int) (*params.Ch
 	}
 
 	chainCfg, err := getChainConfig(chainID)
+	if err != nil && pf.cfg.Genesis != nil && pf.cfg.Genesis.Config.ChainID.Cmp(chainID) == 0 {
+		chainCfg, err = pf.cfg.Genesis.Config, nil
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
 	block, err := pf.remote.BlockByNumber(ctx, blockNumber)
+	if err != nil && isTxTypeNotSupported(err) {
+		// Typically EIP-7702 set-code transactions of post-Pectra blocks, which are not supported: the EVM predates Prague
+		// and can neither decode them nor apply their code delegations, so the block is rejected rather than executed wrongly
+		return nil, nil, fmt.Errorf("block contains a transaction type not supported by EVM %v (EIP-7702 set-code transactions are not supported, the EVM predates Prague): %v", evm.Version, err)
+	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to fetch block: %v", err)
 	}
 
+	if block.NumberU64() == 0 {
+		return nil, nil, fmt.Errorf("genesis block has no parent and nothing to execute")
+	}
+
 	return chainCfg, block, nil
 }
 
@@ -112,6 +187,7 @@ type preflightContext struct {
 	stateDB      gethstate.Database
 	hc           *core.HeaderChain
 	parentHeader *gethtypes.Header
+	precompiles  *precompileTracker // Set once the block executed is known
 }
 
 func (pf *preflight) preflight(ctx context.Context, chainCfg *params.ChainConfig, block *gethtypes.Block) (*PreflightData, error) {
@@ -126,10 +202,17 @@ func (pf *preflight) preflight(ctx context.Context, chainCfg *params.ChainConfig
 		return nil, err
 	}
 
-	execParams, err := pf.prepareProcessBlockExecParams(genCtx, block)
+	if pf.cfg.SeedAccessLists {
+		if err := pf.seedAccessLists(genCtx, block); err != nil {
+			return nil, err
+		}
+	}
+
+	execParams, err := pf.prepareProcessBlockExecParams(genCtx, pf.cfg.Execution.apply(block))
 	if err != nil {
 		return nil, err
 	}
+	genCtx.precompiles = newPrecompileTracker(chainCfg, execParams.Block)
 
 	if err := pf.execute(genCtx, execParams); err != nil {
 		return nil, err
@@ -143,9 +226,17 @@ func (pf *preflight) preflight(ctx context.Context, chainCfg *params.ChainConfig
 	data := &PreflightData{
 		ChainConfig:     chainCfg,
 		Block:           new(ethrpc.Block).FromBlock(block, chainCfg),
-		PreStateProofs:  preStateProofs,
+		Uncles:          block.Uncles(),
 		PostStateProofs: deletionsPostStateProofs,
 	}
+	data.setPreStateProofs(preStateProofs)
+	if preStateProofs.Spilled() > 0 {
+		log.LoggerFromContext(ctx).Info(
+			"Pre-state proofs exceeded the memory budget, spilled to disk",
+			zap.Int("proofs.count", preStateProofs.Len()),
+			zap.Int("proofs.spilled", preStateProofs.Spilled()),
+		)
+	}
 
 	witness := execParams.State.Witness()
 	for code := range witness.Codes {
@@ -154,6 +245,11 @@ func (pf *preflight) preflight(ctx context.Context, chainCfg *params.ChainConfig
 
 	data.Ancestors = witness.Headers
 
+	if data.AncestorHeaders, err = pf.fetchAncestorHeaders(genCtx); err != nil {
+		data.Close()
+		return nil, err
+	}
+
 	return data, nil
 }
 
@@ -164,6 +260,7 @@ func (pf *preflight) prepareContext(ctx context.Context, chainCfg *params.ChainC
 	db := rpcdb.Hack(rawdb.NewMemoryDatabase(), pf.remote)
 	trieDB := triedb.NewDatabase(db, &triedb.Config{HashDB: &hashdb.Config{}})
 	rpcDB := state.NewRPCDatabase(gethstate.NewDatabase(trieDB, nil), pf.remote)
+	rpcDB.UseProofDedupCache(pf.cfg.ProofDedupCache)
 	stateDB := state.NewAccessTrackerDatabase(rpcDB, trackers)
 
 	hc, err := ethereum.NewChain(chainCfg, stateDB)
@@ -194,6 +291,29 @@ func (pf *preflight) fetchParentHeader(ctx *preflightContext, block *gethtypes.B
 	return nil
 }
 
+// fetchAncestorHeaders returns the last cfg.AncestorHeaders ancestor headers of the block, parent first, stopping at genesis
+func (pf *preflight) fetchAncestorHeaders(ctx *preflightContext) ([]*gethtypes.Header, error) {
+	if pf.cfg.AncestorHeaders <= 0 {
+		return nil, nil
+	}
+
+	log.LoggerFromContext(ctx.ctx).Info("Fetch ancestor headers...", zap.Int("count", pf.cfg.AncestorHeaders))
+	headers := []*gethtypes.Header{ctx.parentHeader}
+	for len(headers) < pf.cfg.AncestorHeaders {
+		last := headers[len(headers)-1]
+		if last.Number.Sign() == 0 {
+			break
+		}
+		header := ctx.hc.GetHeader(last.ParentHash, last.Number.Uint64()-1)
+		if header == nil {
+			return nil, fmt.Errorf("failed to fetch ancestor header with hash: %v", last.ParentHash)
+		}
+		headers = append(headers, header)
+	}
+
+	return headers, nil
+}
+
 func (pf *preflight) prepareProcessBlockExecParams(ctx *preflightContext, block *gethtypes.Block) (*evm.ExecParams, error) {
 	log.LoggerFromContext(ctx.ctx).Debug("Prepare execution parameters... (this may take a while)")
 
@@ -202,6 +322,15 @@ func (pf *preflight) prepareProcessBlockExecParams(ctx *preflightContext, block
 		return nil, fmt.Errorf("failed to create state from parent root %v: %v", ctx.parentHeader.Root, err)
 	}
 
+	// On pre-Merge blocks, the consensus engine credits the mining and ommer rewards to the block and uncles coinbases.
+	// We access the beneficiaries explicitly so their pre-state is always collected.
+	if block.Difficulty().Sign() != 0 {
+		st.GetBalance(block.Coinbase())
+		for _, uncle := range block.Uncles() {
+			st.GetBalance(uncle.Coinbase)
+		}
+	}
+
 	return &evm.ExecParams{
 		Block:    block,
 		Validate: false, // We do not validate on because we don't have a proper pre-state yet
@@ -213,10 +342,28 @@ func (pf *preflight) prepareProcessBlockExecParams(ctx *preflightContext, block
 	}, nil
 }
 
+// seedAccessLists prefetches the accounts and storage slots of the transactions' access lists at the parent state
+func (pf *preflight) seedAccessLists(ctx *preflightContext, block *gethtypes.Block) error {
+	var accessList gethtypes.AccessList
+	for _, tx := range block.Transactions() {
+		accessList = append(accessList, tx.AccessList()...)
+	}
+	if len(accessList) == 0 {
+		return nil
+	}
+
+	log.LoggerFromContext(ctx.ctx).Info("Prefetch access lists state...", zap.Int("accessList.size", len(accessList)))
+	if err := ctx.rpcDB.Prefetch(ctx.ctx, ctx.parentHeader.Root, accessList); err != nil {
+		return fmt.Errorf("failed to prefetch access lists state: %v", err)
+	}
+
+	return nil
+}
+
 // execute runs the actual block EVM execution
 func (pf *preflight) execute(ctx *preflightContext, execParams *evm.ExecParams) error {
 	log.LoggerFromContext(ctx.ctx).Info("Execute EVM... (this may take a while)")
-	_, err := evm.ExecutorWithTags("evm")(evm.ExecutorWithLog()(evm.NewExecutor())).Execute(ctx.ctx, execParams)
+	_, err := evm.ExecutorWithTags("evm")(evm.ExecutorWithLog()(evm.ExecutorWithAbort()(ctx.precompiles.Executor(evm.NewExecutor())))).Execute(ctx.ctx, execParams)
 	if err != nil {
 		return fmt.Errorf("failed to execute block: %v", err)
 	}
@@ -226,12 +373,19 @@ func (pf *preflight) execute(ctx *preflightContext, execParams *evm.ExecParams)
 
 // fetchStateProofs for all accounts and storage slots that were accessed during the block execution
 // It fetches the state proofs both at the initial state (parent state) and at the final state
-func (pf *preflight) fetchStateProofs(ctx *preflightContext, execParams *evm.ExecParams) (preStateProofs, postStateProofs []*trie.AccountProof, err error) {
+func (pf *preflight) fetchStateProofs(ctx *preflightContext, execParams *evm.ExecParams) (preStateProofs *ProofBuffer, postStateProofs []*trie.AccountProof, err error) {
 	log.LoggerFromContext(ctx.ctx).Info("Fetch state proofs after successful EVM execution... (this may take a while)")
 
+	preStateProofs = NewProofBuffer(pf.cfg.MemoryBudget)
+	defer func() {
+		if err != nil {
+			preStateProofs.Close()
+		}
+	}()
+
 	finalState := execParams.State
 	tracker := ctx.trackers.GetAccessTracker(ctx.parentHeader.Root)
-	for account := range tracker.Accounts {
+	for _, account := range provedAccounts(tracker, ctx.precompiles.Called()) {
 		var (
 			slots       = []string{}
 			deletedSlot = []string{}
@@ -246,25 +400,117 @@ func (pf *preflight) fetchStateProofs(ctx *preflightContext, execParams *evm.Exe
 		}
 
 		// Get proofs for every accounts on the initial state (parent state)
-		acc, err := pf.remote.GetProof(ctx.ctx, account, slots, ctx.parentHeader.Number)
+		// reusing the proof prefetched from the access lists or cached if it covers every accessed slot
+		acc := prefetchedProof(ctx.rpcDB.PrefetchedProof(ctx.parentHeader.Root, account), slots)
+		if acc == nil {
+			acc = prefetchedProof(pf.cfg.ProofDedupCache.Get(ctx.parentHeader.Root, account), slots)
+		}
+		if acc == nil {
+			acc, err = pf.remote.GetProof(ctx.ctx, account, slots, ctx.parentHeader.Number)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get proof for account %v: %v", account, err)
+			}
+			pf.cfg.ProofDedupCache.Add(ctx.parentHeader.Root, account, acc)
+		}
+		preStateProof := trie.AccountProofFromRPC(acc)
+		if err = preStateProofs.Add(preStateProof); err != nil {
+			return nil, nil, err
+		}
+
+		// Detect accounts deleted during the block (self-destructed or removed as empty per EIP-161)
+		//
+		// Note: we can not rely on finalState.HasSelfDestructed as self-destructed accounts are
+		// dropped from the state at the end of each transaction. Instead, we compare the pre-state
+		// existence of the account, as proved by its pre-state proof, with its existence in the final state.
+		// The fork specific deletion semantics (pre/post EIP-6780) are applied by the EVM during execution.
+		preStateExists, err := preStateProof.Exists(ctx.parentHeader.Root)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to get proof for account %v: %v", account, err)
+			return nil, nil, fmt.Errorf("failed to verify pre-state proof for account %v: %v", account, err)
 		}
-		preStateProofs = append(preStateProofs, trie.AccountProofFromRPC(acc))
+		deletedAccount := preStateExists && !finalState.Exist(account)
 
 		// Also get necessary proofs at final state
-		if len(deletedSlot) == 0 && !finalState.HasSelfDestructed(account) {
-			// Account was not deleted so we don't need to fetch post-state proofs for it
+		if len(deletedSlot) == 0 && !deletedAccount {
+			// Neither the account nor any of its slots was deleted so we don't need to fetch post-state proofs for it
 			continue
 		}
 
 		// Also get proofs at final state for deleted accounts & slots
-		acc, err = pf.remote.GetProof(ctx.ctx, account, deletedSlot, execParams.Block.Number())
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to get proof for account %v: %v", account, err)
+		// (cached under the state root of the block, which is the parent state root of the next block)
+		acc = prefetchedProof(pf.cfg.ProofDedupCache.Get(execParams.Block.Root(), account), deletedSlot)
+		if acc == nil {
+			acc, err = pf.remote.GetProof(ctx.ctx, account, deletedSlot, execParams.Block.Number())
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get proof for account %v: %v", account, err)
+			}
+			pf.cfg.ProofDedupCache.Add(execParams.Block.Root(), account, acc)
 		}
 		postStateProofs = append(postStateProofs, trie.AccountProofFromRPC(acc))
 	}
 
+	if pf.cfg.ProofDedupCache != nil {
+		hits, misses := pf.cfg.ProofDedupCache.Stats()
+		log.LoggerFromContext(ctx.ctx).Debug("Proof dedup cache usage", zap.Uint64("proofDedupCache.hits", hits), zap.Uint64("proofDedupCache.misses", misses))
+	}
+
 	return preStateProofs, postStateProofs, nil
 }
+
+// provedAccounts returns the accounts to prove: the existing accounts accessed during the block execution, and the
+// precompiles it called, which are proved even if they do not exist in the state (see precompileTracker)
+func provedAccounts(tracker *state.AccessTracker, precompiles []gethcommon.Address) []gethcommon.Address {
+	accounts := make([]gethcommon.Address, 0, len(tracker.Accounts)+len(precompiles))
+	for account := range tracker.Accounts {
+		accounts = append(accounts, account)
+	}
+	for _, precompile := range precompiles {
+		if _, ok := tracker.Accounts[precompile]; !ok {
+			accounts = append(accounts, precompile)
+		}
+	}
+	return accounts
+}
+
+// prefetchedProof returns a copy of the prefetched proof restricted to the given slots,
+// or nil if the proof is missing or does not cover every slot
+func prefetchedProof(res *gethclient.AccountResult, slots []string) *gethclient.AccountResult {
+	if res == nil {
+		return nil
+	}
+
+	storage := make(map[gethcommon.Hash]gethclient.StorageResult, len(res.StorageProof))
+	for _, slot := range res.StorageProof {
+		storage[gethcommon.HexToHash(slot.Key)] = slot
+	}
+
+	cpy := *res
+	cpy.StorageProof = make([]gethclient.StorageResult, 0, len(slots))
+	for _, slot := range slots {
+		proof, ok := storage[gethcommon.HexToHash(slot)]
+		if !ok {
+			return nil
+		}
+		cpy.StorageProof = append(cpy.StorageProof, proof)
+	}
+
+	return &cpy
+}
+
+// block returns the block to execute, including its uncles
+func (d *PreflightData) block() *gethtypes.Block {
+	block := d.Block.Block()
+	if len(d.Uncles) == 0 {
+		return block
+	}
+	return block.WithBody(gethtypes.Body{
+		Transactions: block.Transactions(),
+		Uncles:       d.Uncles,
+		Withdrawals:  block.Withdrawals(),
+	})
+}
+
+// isTxTypeNotSupported returns true if err is caused by a transaction of a type unknown to the EVM
+// (RPC clients may not wrap decoding errors, so the message is matched as well)
+func isTxTypeNotSupported(err error) bool {
+	return errors.Is(err, gethtypes.ErrTxTypeNotSupported) || strings.Contains(err.Error(), gethtypes.ErrTxTypeNotSupported.Error())
+}
@@ -0,0 +1,217 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/kkrt-labs/zk-pig/src/ethereum/trie"
+)
+
+// EachPreStateProof calls fn on every pre-state proof, including the ones spilled to disk during preflight or load
+// (see PreflightConfig.MemoryBudget)
+func (d *PreflightData) EachPreStateProof(fn func(*trie.AccountProof) error) error {
+	for _, proof := range d.PreStateProofs {
+		if err := fn(proof); err != nil {
+			return err
+		}
+	}
+	if d.spilledPreStateProofs != nil {
+		return d.spilledPreStateProofs.Each(fn)
+	}
+	return nil
+}
+
+// setPreStateProofs sets the pre-state proofs from a buffer, which is kept only if some proofs were spilled to disk
+func (d *PreflightData) setPreStateProofs(buf *ProofBuffer) {
+	if buf.Spilled() == 0 {
+		d.PreStateProofs = buf.proofs
+		return
+	}
+	d.spilledPreStateProofs = buf
+}
+
+// Close removes the temporary file of the pre-state proofs spilled to disk, if any
+func (d *PreflightData) Close() error {
+	if d.spilledPreStateProofs == nil {
+		return nil
+	}
+	return d.spilledPreStateProofs.Close()
+}
+
+// MarshalJSON encodes the preflight data, including the pre-state proofs spilled to disk
+func (d *PreflightData) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.WriteJSON(&buf); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// WriteJSON writes the preflight data as json.Encoder does, streaming the pre-state proofs spilled to disk
+// so they are never all held in memory
+func (d *PreflightData) WriteJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	field := func(name string, v any, first bool) error {
+		if !first {
+			bw.WriteByte(',')
+		}
+		bw.WriteString(`"` + name + `":`)
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode %v: %v", name, err)
+		}
+		_, err = bw.Write(b)
+		return err
+	}
+
+	// Fields are in the order and with the omitempty rules of the PreflightData JSON tags
+	bw.WriteByte('{')
+	if err := field("block", d.Block, true); err != nil {
+		return err
+	}
+	if len(d.Uncles) > 0 {
+		if err := field("uncles", d.Uncles, false); err != nil {
+			return err
+		}
+	}
+	for _, f := range []struct {
+		name string
+		v    any
+	}{{"ancestors", d.Ancestors}, {"chainConfig", d.ChainConfig}, {"codes", d.Codes}} {
+		if err := field(f.name, f.v, false); err != nil {
+			return err
+		}
+	}
+
+	if d.spilledPreStateProofs == nil {
+		if err := field("preStateProofs", d.PreStateProofs, false); err != nil {
+			return err
+		}
+	} else {
+		bw.WriteString(`,"preStateProofs":[`)
+		first := true
+		err := d.EachPreStateProof(func(proof *trie.AccountProof) error {
+			if !first {
+				bw.WriteByte(',')
+			}
+			first = false
+			b, err := json.Marshal(proof)
+			if err != nil {
+				return fmt.Errorf("failed to encode preStateProofs: %v", err)
+			}
+			_, err = bw.Write(b)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		bw.WriteByte(']')
+	}
+
+	if err := field("postStateProofs", d.PostStateProofs, false); err != nil {
+		return err
+	}
+	if len(d.AncestorHeaders) > 0 {
+		if err := field("ancestorHeaders", d.AncestorHeaders, false); err != nil {
+			return err
+		}
+	}
+	bw.WriteString("}\n")
+
+	return bw.Flush()
+}
+
+// ReadPreflightData decodes JSON preflight data, the pre-state proofs exceeding the soft memory budget (in bytes) are
+// spilled to disk while decoding (see ProofBuffer). A zero budget disables spilling.
+//
+// The returned preflight data must be closed to remove its spilled proofs.
+func ReadPreflightData(r io.Reader, budget uint64) (*PreflightData, error) {
+	data := new(PreflightData)
+	dec := json.NewDecoder(r)
+	if budget == 0 {
+		if err := dec.Decode(data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	fields := map[string]any{
+		"block":           &data.Block,
+		"uncles":          &data.Uncles,
+		"ancestors":       &data.Ancestors,
+		"chainConfig":     &data.ChainConfig,
+		"codes":           &data.Codes,
+		"postStateProofs": &data.PostStateProofs,
+		"ancestorHeaders": &data.AncestorHeaders,
+	}
+	buf := NewProofBuffer(budget)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			buf.Close()
+			return nil, err
+		}
+		key, _ := tok.(string)
+
+		switch v, ok := fields[key]; {
+		case key == "preStateProofs":
+			err = readProofs(dec, buf)
+		case ok:
+			err = dec.Decode(v)
+		default:
+			err = dec.Decode(new(json.RawMessage)) // Unknown field
+		}
+		if err != nil {
+			buf.Close()
+			return nil, fmt.Errorf("invalid preflight data %v: %v", key, err)
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		buf.Close()
+		return nil, err
+	}
+
+	data.setPreStateProofs(buf)
+	return data, nil
+}
+
+// readProofs decodes a JSON array of account proofs one by one into the buffer
+func readProofs(dec *json.Decoder, buf *ProofBuffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil // null
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("expected array but got %v", tok)
+	}
+	for dec.More() {
+		proof := new(trie.AccountProof)
+		if err := dec.Decode(proof); err != nil {
+			return err
+		}
+		if err := buf.Add(proof); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v but got %v", delim, tok)
+	}
+	return nil
+}
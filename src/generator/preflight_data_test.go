@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/kkrt-labs/zk-pig/src/ethereum/trie"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plainPreflightData is PreflightData encoded by encoding/json, without its custom encoding
+type plainPreflightData PreflightData
+
+func TestProofBuffer(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proofs := testDataInputs.PreflightData.PreStateProofs
+	require.NotEmpty(t, proofs)
+
+	// Force every proof to be spilled to disk
+	buf := NewProofBuffer(1)
+	for _, proof := range proofs {
+		require.NoError(t, buf.Add(proof))
+	}
+	assert.Equal(t, len(proofs), buf.Len())
+	assert.Equal(t, len(proofs), buf.Spilled())
+	path := buf.file.Name()
+
+	// Proofs are read back in order, and can be iterated several times
+	for i := 0; i < 2; i++ {
+		var got []*trie.AccountProof
+		require.NoError(t, buf.Each(func(proof *trie.AccountProof) error {
+			got = append(got, proof)
+			return nil
+		}))
+		assert.Equal(t, proofs, got)
+	}
+
+	require.NoError(t, buf.Close())
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProofBufferNoBudget(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+
+	buf := NewProofBuffer(0)
+	for _, proof := range testDataInputs.PreflightData.PreStateProofs {
+		require.NoError(t, buf.Add(proof))
+	}
+	assert.Equal(t, 0, buf.Spilled())
+	assert.Nil(t, buf.file)
+}
+
+func TestReadPreflightDataWithLowMemoryBudget(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	expected, err := json.Marshal((*plainPreflightData)(&testDataInputs.PreflightData))
+	require.NoError(t, err)
+
+	data, err := ReadPreflightData(bytes.NewReader(expected), 1)
+	require.NoError(t, err)
+	defer data.Close()
+	assert.Empty(t, data.PreStateProofs)
+	require.NotNil(t, data.spilledPreStateProofs)
+	assert.Equal(t, len(testDataInputs.PreflightData.PreStateProofs), data.spilledPreStateProofs.Spilled())
+
+	// Spilled proofs are encoded as if they were in memory
+	var buf bytes.Buffer
+	require.NoError(t, data.WriteJSON(&buf))
+	assert.Equal(t, string(expected)+"\n", buf.String())
+
+	// Preparing from spilled proofs gives the same prover input
+	result, err := NewPreparer(nil).Prepare(context.Background(), data)
+	require.NoError(t, err)
+	assert.True(t, input.CompareProverInput(&testDataInputs.ProverInput, result))
+}
+
+func TestPreflightDataWriteJSON(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	data := &testDataInputs.PreflightData
+
+	var expected bytes.Buffer
+	require.NoError(t, json.NewEncoder(&expected).Encode((*plainPreflightData)(data)))
+
+	var buf bytes.Buffer
+	require.NoError(t, data.WriteJSON(&buf))
+	assert.Equal(t, expected.String(), buf.String())
+
+	// Without a budget, proofs are decoded in memory
+	decoded, err := ReadPreflightData(&buf, 0)
+	require.NoError(t, err)
+	assert.Nil(t, decoded.spilledPreStateProofs)
+	assert.Len(t, decoded.PreStateProofs, len(data.PreStateProofs))
+}
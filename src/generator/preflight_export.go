@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+)
+
+// PreflightExportVersion is the version of the preflight export format, it is bumped on incompatible changes
+const PreflightExportVersion = "1"
+
+// PreflightExport is a standalone and versioned JSON representation of the preflight data of a block, independent of the
+// internal format of the preflight data store, to archive and share preflight data (e.g. between teams).
+//
+// The block and its identification are duplicated at the top level so an export can be identified without decoding its data.
+// Data holds the PreflightData fields:
+//   - block: the block to execute, as returned by eth_getBlockByNumber with full transactions
+//   - uncles: the uncle headers of the block (pre-Merge blocks only)
+//   - ancestors: the ancestor headers of the block accessed during execution (e.g. by the BLOCKHASH opcode)
+//   - chainConfig: the go-ethereum chain configuration, with big integers as decimal numbers
+//   - codes: the contract bytecodes used during execution
+//   - preStateProofs: the eth_getProof proofs, on the parent block, of every account and storage slot accessed during execution
+//   - postStateProofs: the eth_getProof proofs, on the block, of every account and storage slot deleted during execution
+//   - ancestorHeaders: the last ancestor headers of the block, parent first (see PreflightConfig.AncestorHeaders)
+type PreflightExport struct {
+	Version     string          `json:"version"`     // Version of the export format (see PreflightExportVersion)
+	ChainID     *hexutil.Big    `json:"chainId"`     // Chain ID of the block
+	BlockNumber *hexutil.Big    `json:"blockNumber"` // Number of the block
+	BlockHash   gethcommon.Hash `json:"blockHash"`   // Hash of the block
+	EVMVersion  string          `json:"evmVersion"`  // Version of the EVM library the data was collected with
+	Data        *PreflightData  `json:"data"`        // Preflight data of the block
+}
+
+// NewPreflightExport returns the export of the preflight data of a block
+func NewPreflightExport(data *PreflightData) (*PreflightExport, error) {
+	if data.Block == nil || data.ChainConfig == nil || data.ChainConfig.ChainID == nil {
+		return nil, fmt.Errorf("incomplete preflight data: block and chain configuration are required")
+	}
+
+	return &PreflightExport{
+		Version:     PreflightExportVersion,
+		ChainID:     (*hexutil.Big)(data.ChainConfig.ChainID),
+		BlockNumber: data.Block.Number,
+		BlockHash:   data.Block.Hash,
+		EVMVersion:  evm.Version,
+		Data:        data,
+	}, nil
+}
+
+// Check checks the export is of a supported version and that its identification matches its data
+func (e *PreflightExport) Check() error {
+	if e.Version != PreflightExportVersion {
+		return fmt.Errorf("unsupported preflight export version %q (expected %q)", e.Version, PreflightExportVersion)
+	}
+	if e.Data == nil || e.Data.Block == nil || e.Data.ChainConfig == nil || e.Data.ChainConfig.ChainID == nil {
+		return fmt.Errorf("incomplete preflight export: block and chain configuration are required")
+	}
+	if e.ChainID == nil || e.ChainID.ToInt().Cmp(e.Data.ChainConfig.ChainID) != 0 {
+		return fmt.Errorf("preflight export chain ID mismatch: %v but data is for chain %v", e.ChainID, e.Data.ChainConfig.ChainID)
+	}
+	if e.BlockNumber == nil || e.BlockNumber.ToInt().Cmp(e.Data.Block.Number.ToInt()) != 0 || e.BlockHash != e.Data.Block.Hash {
+		return fmt.Errorf("preflight export block mismatch: %v (%v) but data is for block %v (%v)", e.BlockNumber, e.BlockHash.Hex(), e.Data.Block.Number, e.Data.Block.Hash.Hex())
+	}
+	return nil
+}
+
+// WritePreflightExport writes the export of the preflight data of a block as JSON
+func WritePreflightExport(w io.Writer, data *PreflightData) error {
+	export, err := NewPreflightExport(data)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return fmt.Errorf("failed to encode preflight export: %v", err)
+	}
+	return nil
+}
+
+// ReadPreflightExport reads and checks a JSON preflight export
+func ReadPreflightExport(r io.Reader) (*PreflightExport, error) {
+	export := new(PreflightExport)
+	if err := json.NewDecoder(r).Decode(export); err != nil {
+		return nil, fmt.Errorf("failed to decode preflight export: %v", err)
+	}
+	if err := export.Check(); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
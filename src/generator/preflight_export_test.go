@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreflightExport(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePreflightExport(&buf, &testDataInputs.PreflightData))
+	raw := buf.String()
+
+	export, err := ReadPreflightExport(strings.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, PreflightExportVersion, export.Version)
+	assert.Equal(t, uint64(1), export.ChainID.ToInt().Uint64())
+	assert.Equal(t, testDataInputs.PreflightData.Block.Hash, export.BlockHash)
+
+	// The imported preflight data prepares the same prover input
+	result, err := NewPreparer(nil).Prepare(context.Background(), export.Data)
+	require.NoError(t, err)
+	assert.True(t, input.CompareProverInput(&testDataInputs.ProverInput, result))
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := ReadPreflightExport(strings.NewReader(strings.Replace(raw, `"version": "1"`, `"version": "2"`, 1)))
+		assert.ErrorContains(t, err, "unsupported preflight export version")
+	})
+
+	t.Run("block mismatch", func(t *testing.T) {
+		export.BlockNumber = (*hexutil.Big)(hexutil.MustDecodeBig("0x1"))
+		assert.ErrorContains(t, export.Check(), "block mismatch")
+	})
+}
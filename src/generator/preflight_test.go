@@ -2,10 +2,16 @@ package generator
 
 import (
 	"encoding/json"
+	"fmt"
+	"math/big"
 	"os"
 	"testing"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
 	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -15,7 +21,7 @@ type TestDataInputs struct {
 	ProverInput   input.ProverInput `json:"proverInput"`
 }
 
-func loadTestDataInputs(t *testing.T, path string) *TestDataInputs {
+func loadTestDataInputs(t testing.TB, path string) *TestDataInputs {
 	f, err := os.Open(path)
 	require.NoError(t, err)
 	defer f.Close()
@@ -31,5 +37,48 @@ func TestUnmarshal(t *testing.T) {
 	_ = loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
 }
 
+func TestPrefetchedProof(t *testing.T) {
+	res := &gethclient.AccountResult{
+		Balance: big.NewInt(1),
+		StorageProof: []gethclient.StorageResult{
+			{Key: "0x1", Value: big.NewInt(1)},
+			{Key: "0x0000000000000000000000000000000000000000000000000000000000000002", Value: big.NewInt(2)},
+		},
+	}
+
+	assert.Nil(t, prefetchedProof(nil, nil))
+	assert.Nil(t, prefetchedProof(res, []string{gethcommon.HexToHash("0x3").Hex()}), "slot not covered")
+
+	proof := prefetchedProof(res, []string{gethcommon.HexToHash("0x2").Hex()})
+	require.NotNil(t, proof)
+	assert.Equal(t, []gethclient.StorageResult{res.StorageProof[1]}, proof.StorageProof)
+	assert.Len(t, res.StorageProof, 2, "original proof must not be modified")
+
+	proof = prefetchedProof(res, []string{})
+	require.NotNil(t, proof)
+	assert.Empty(t, proof.StorageProof)
+}
+
 // TODO: Add unit-tests for the preflight block execution
 // It is probably possible to create a mock ethrpc.Client that uses some preloaded preflight data
+
+func TestIsTxTypeNotSupported(t *testing.T) {
+	// EIP-7702 set-code transaction as returned by eth_getBlockByNumber, unsupported by the EVM which predates Prague
+	// (it must be decoded once the EVM supports it, and this guard replaced by a regression test of a 7702 block)
+	err := json.Unmarshal([]byte(`{"type":"0x4","chainId":"0x1","nonce":"0x0","to":"0x0000000000000000000000000000000000000001","gas":"0x5208","maxFeePerGas":"0x1","maxPriorityFeePerGas":"0x1","value":"0x0","input":"0x","accessList":[],"authorizationList":[],"v":"0x0","r":"0x1","s":"0x1"}`), new(gethtypes.Transaction))
+	require.Error(t, err)
+	assert.True(t, isTxTypeNotSupported(err))
+	assert.True(t, isTxTypeNotSupported(fmt.Errorf("failed to decode block: %v", err)))
+	assert.False(t, isTxTypeNotSupported(fmt.Errorf("connection refused")))
+}
+
+func TestProverInputMarshalJSONFast(t *testing.T) {
+	proverInput := &loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json")).ProverInput
+	for _, format := range []input.NumberFormat{input.NumberFormatHex, input.NumberFormatDecimal} {
+		expected, err := proverInput.MarshalJSONWithFormat(format)
+		require.NoError(t, err)
+		got, err := proverInput.MarshalJSONFast(format)
+		require.NoError(t, err)
+		assert.True(t, string(expected) == string(got), "%v encoding differs", format)
+	}
+}
@@ -1,9 +1,12 @@
 package generator
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"slices"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	gethstate "github.com/ethereum/go-ethereum/core/state"
@@ -28,11 +31,14 @@ type Preparer interface {
 	Prepare(ctx context.Context, inputs *PreflightData) (*input.ProverInput, error)
 }
 
-type preparer struct{}
+type preparer struct {
+	cfg *ExecutionConfig
+}
 
 // NewPreparer creates a new Preparer.
-func NewPreparer() Preparer {
-	return &preparer{}
+// If cfg is set, its overrides are applied to the block and stored in the prover input header.
+func NewPreparer(cfg *ExecutionConfig) Preparer {
+	return &preparer{cfg: cfg}
 }
 
 // Prepare prepares the ProvableBlockInputs data for the EVM prover engine.
@@ -64,6 +70,9 @@ type preparerContext struct {
 
 func (p *preparer) prepare(ctx context.Context, inputs *PreflightData) (*input.ProverInput, error) {
 	log.LoggerFromContext(ctx).Info("Process provable inputs preparation...")
+	if p.cfg.Enabled() {
+		log.LoggerFromContext(ctx).Warn("Execution overrides set, skipping block validation")
+	}
 
 	valCtx, err := p.prepareContext(ctx, inputs)
 	if err != nil {
@@ -83,7 +92,10 @@ func (p *preparer) prepare(ctx context.Context, inputs *PreflightData) (*input.P
 		return nil, fmt.Errorf("validation execution failed: %v", err)
 	}
 
-	return p.prepareProverInput(valCtx, execParams), nil
+	proverInput := p.prepareProverInput(valCtx, execParams)
+	proverInput.AncestorHeaders = inputs.AncestorHeaders
+
+	return proverInput, nil
 }
 
 func (p *preparer) prepareContext(ctx context.Context, inputs *PreflightData) (*preparerContext, error) {
@@ -118,7 +130,7 @@ func (p *preparer) preparePreState(ctx *preparerContext, inputs *PreflightData)
 	parentHeader := inputs.Ancestors[0]
 	genesisHeader := ctx.hc.GetHeaderByNumber(0)
 
-	nodeSet, err := trie.NodeSetFromStateTransitionProofs(parentHeader.Root, inputs.Block.Root, inputs.PreStateProofs, inputs.PostStateProofs)
+	nodeSet, err := trie.NodeSetFromStateTransitionProofsFunc(parentHeader.Root, inputs.Block.Root, inputs.EachPreStateProof, inputs.PostStateProofs)
 	if err != nil {
 		return fmt.Errorf("failed to create state nodes: %v", err)
 	}
@@ -151,8 +163,8 @@ func (p *preparer) prepareExecParams(ctx *preparerContext, inputs *PreflightData
 		VMConfig: &vm.Config{
 			StatelessSelfValidation: true,
 		},
-		Block:    inputs.Block.Block(),
-		Validate: true, // We validate the block execution to ensure the result and final state are correct
+		Block:    p.cfg.apply(inputs.block()),
+		Validate: !p.cfg.Enabled(), // We validate the block execution to ensure the result and final state are correct
 		Chain:    ctx.hc,
 		State:    preState,
 	}, nil
@@ -160,17 +172,24 @@ func (p *preparer) prepareExecParams(ctx *preparerContext, inputs *PreflightData
 
 func (p *preparer) execute(ctx *preparerContext, execParams *evm.ExecParams) error {
 	log.LoggerFromContext(ctx.ctx).Info("Execute EVM...")
-	_, err := evm.ExecutorWithTags("evm")(evm.ExecutorWithLog()(evm.NewExecutor())).Execute(ctx.ctx, execParams)
+	_, err := evm.ExecutorWithTags("evm")(evm.ExecutorWithLog()(evm.ExecutorWithAbort()(evm.NewExecutor()))).Execute(ctx.ctx, execParams)
 	if err != nil {
 		return fmt.Errorf("failed to execute block: %v", err)
 	}
 
+	if !execParams.Validate {
+		// Without validation the post-state root is never computed, so we compute it to collect the trie nodes
+		// it requires into the witness
+		execParams.State.IntermediateRoot(execParams.Chain.Config().IsEIP158(execParams.Block.Number()))
+	}
+
 	return nil
 }
 
 func (p *preparer) prepareProverInput(_ *preparerContext, execParams *evm.ExecParams) *input.ProverInput {
 	proverInput := &input.ProverInput{
 		ChainConfig: execParams.Chain.Config(),
+		EVMVersion:  evm.Version,
 		Blocks: []*input.Block{
 			{
 				Header:       execParams.Block.Header(),
@@ -194,5 +213,9 @@ func (p *preparer) prepareProverInput(_ *preparerContext, execParams *evm.ExecPa
 		proverInput.Witness.State = append(proverInput.Witness.State, blob)
 	}
 
+	// Witness sets are iterated in random order, the nodes and codes are sorted so the prover input is reproducible
+	slices.SortFunc(proverInput.Witness.Codes, func(a, b hexutil.Bytes) int { return bytes.Compare(a, b) })
+	slices.SortFunc(proverInput.Witness.State, func(a, b hexutil.Bytes) int { return bytes.Compare(a, b) })
+
 	return proverInput
 }
@@ -16,7 +16,7 @@ func TestPreparer(t *testing.T) {
 	for _, name := range testcases {
 		t.Run(name, func(t *testing.T) {
 			testDataInputs := loadTestDataInputs(t, testDataInputsPath(name))
-			p := NewPreparer().(*preparer)
+			p := NewPreparer(nil).(*preparer)
 			result, err := p.Prepare(context.Background(), &testDataInputs.PreflightData)
 			require.NoError(t, err)
 			require.NotNil(t, result)
@@ -26,6 +26,38 @@ func TestPreparer(t *testing.T) {
 	}
 }
 
+func TestPreparerReproducible(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	first, err := NewPreparer(nil).Prepare(context.Background(), &testDataInputs.PreflightData)
+	require.NoError(t, err)
+	second, err := NewPreparer(nil).Prepare(context.Background(), &testDataInputs.PreflightData)
+	require.NoError(t, err)
+
+	// The witness is serialized in the same order by every run
+	require.Equal(t, first.Witness.State, second.Witness.State)
+	require.Equal(t, first.Witness.Codes, second.Witness.Codes)
+}
+
 func testDataInputsPath(filename string) string {
 	return "testdata/" + filename
 }
+
+func TestPreparerWithOverrides(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	coinbase := testDataInputs.PreflightData.Block.Miner
+	timestamp := uint64(1700000000)
+
+	p := NewPreparer(&ExecutionConfig{OverrideTimestamp: &timestamp, OverrideCoinbase: &coinbase})
+	result, err := p.Prepare(context.Background(), &testDataInputs.PreflightData)
+	require.NoError(t, err)
+	require.Equal(t, timestamp, result.Blocks[0].Header.Time)
+	require.Equal(t, coinbase, result.Blocks[0].Header.Coinbase)
+
+	// Executing the test vector with the same overrides succeeds
+	_, err = NewExecutor(&ExecutionConfig{OverrideTimestamp: &timestamp}).Execute(context.Background(), result)
+	require.NoError(t, err)
+
+	// Executing it as a canonical block fails, as the header no longer matches the execution
+	_, err = NewExecutor(nil).Execute(context.Background(), result)
+	require.Error(t, err)
+}
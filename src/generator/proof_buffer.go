@@ -0,0 +1,131 @@
+package generator
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kkrt-labs/zk-pig/src/ethereum/trie"
+)
+
+// ProofBuffer is a list of account proofs held in memory up to a soft memory budget. Once the approximate size of the
+// in-memory proofs exceeds the budget, they are spilled to a temporary file on disk (as JSON lines) and read back when
+// the buffer is iterated, trading disk IO for memory on state-heavy blocks.
+//
+// A ProofBuffer must be closed to remove its temporary file.
+type ProofBuffer struct {
+	budget uint64 // Soft memory budget in bytes, zero disables spilling
+	size   uint64 // Approximate size of the in-memory proofs
+
+	proofs  []*trie.AccountProof // In-memory proofs, added after the spilled ones
+	file    *os.File             // Temporary file of the spilled proofs, created on the first spill
+	w       *bufio.Writer
+	spilled int // Number of spilled proofs
+}
+
+// NewProofBuffer creates a new ProofBuffer with the given soft memory budget in bytes, zero disables spilling
+func NewProofBuffer(budget uint64) *ProofBuffer {
+	return &ProofBuffer{budget: budget}
+}
+
+// Add adds a proof to the buffer, spilling the in-memory proofs to disk if the budget is exceeded
+func (b *ProofBuffer) Add(proof *trie.AccountProof) error {
+	b.proofs = append(b.proofs, proof)
+	b.size += proofSize(proof)
+	if b.budget == 0 || b.size <= b.budget {
+		return nil
+	}
+	return b.spill()
+}
+
+func (b *ProofBuffer) spill() error {
+	if b.file == nil {
+		file, err := os.CreateTemp("", "zkpig-proofs-*.jsonl")
+		if err != nil {
+			return fmt.Errorf("failed to create proof spill file: %v", err)
+		}
+		b.file, b.w = file, bufio.NewWriter(file)
+	}
+
+	enc := json.NewEncoder(b.w)
+	for _, proof := range b.proofs {
+		if err := enc.Encode(proof); err != nil {
+			return fmt.Errorf("failed to spill proof of account %v: %v", proof.Address.Hex(), err)
+		}
+	}
+	b.spilled += len(b.proofs)
+	b.proofs, b.size = nil, 0
+
+	return nil
+}
+
+// Len returns the number of proofs in the buffer
+func (b *ProofBuffer) Len() int {
+	return b.spilled + len(b.proofs)
+}
+
+// Spilled returns the number of proofs spilled to disk
+func (b *ProofBuffer) Spilled() int {
+	return b.spilled
+}
+
+// Each calls fn on every proof of the buffer, in the order they were added, reading spilled proofs back from disk
+// Spilled proofs are only held in memory during their call to fn.
+func (b *ProofBuffer) Each(fn func(*trie.AccountProof) error) error {
+	if b.file != nil {
+		if err := b.w.Flush(); err != nil {
+			return fmt.Errorf("failed to flush proof spill file: %v", err)
+		}
+		if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to read proof spill file: %v", err)
+		}
+		dec := json.NewDecoder(bufio.NewReader(b.file))
+		for i := 0; i < b.spilled; i++ {
+			proof := new(trie.AccountProof)
+			if err := dec.Decode(proof); err != nil {
+				return fmt.Errorf("failed to read spilled proof: %v", err)
+			}
+			if err := fn(proof); err != nil {
+				return err
+			}
+		}
+		if _, err := b.file.Seek(0, io.SeekEnd); err != nil {
+			return fmt.Errorf("failed to read proof spill file: %v", err)
+		}
+	}
+
+	for _, proof := range b.proofs {
+		if err := fn(proof); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close removes the temporary file of the spilled proofs
+func (b *ProofBuffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	file := b.file
+	b.file, b.w, b.spilled = nil, nil, 0
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// proofSize returns the approximate memory size of an account proof
+func proofSize(proof *trie.AccountProof) uint64 {
+	size := uint64(256) // Fixed size fields and headers
+	for _, node := range proof.Proof {
+		size += uint64(len(node)) + 16
+	}
+	for _, storage := range proof.Storage {
+		size += 128
+		for _, node := range storage.Proof {
+			size += uint64(len(node)) + 16
+		}
+	}
+	return size
+}
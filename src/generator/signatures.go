@@ -0,0 +1,29 @@
+package generator
+
+import (
+	"fmt"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+)
+
+// verifySignatures recovers the sender of every transaction of the block with the signer of its fork (chain ID, EIP-155,
+// EIP-2 low s values...) and checks the signed transactions are the ones committed by the block header
+//
+// The transactions root of the header commits to the signatures, so once it matches, the recovered senders are the
+// ones of the canonical block: a transaction re-signed by another key is detected even if its signature is valid.
+func verifySignatures(chainConfig *params.ChainConfig, block *input.Block) error {
+	signer := gethtypes.MakeSigner(chainConfig, block.Header.Number, block.Header.Time)
+	for i, tx := range block.Transactions {
+		if _, err := gethtypes.Sender(signer, tx); err != nil {
+			return fmt.Errorf("failed to recover sender of transaction %d (%v): %v", i, tx.Hash().Hex(), err)
+		}
+	}
+
+	if root := gethtypes.DeriveSha(gethtypes.Transactions(block.Transactions), trie.NewStackTrie(nil)); root != block.Header.TxHash {
+		return fmt.Errorf("signed transactions do not match the block header: transactions root %v, expected %v", root.Hex(), block.Header.TxHash.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StateLoading is the way the Executor loads the pre-state nodes of the witness before executing a block
+type StateLoading string
+
+const (
+	// StateLoadingEager hashes and writes every witness node to the state database before execution
+	StateLoadingEager StateLoading = "eager"
+
+	// StateLoadingLazy materializes witness nodes on first access during execution (see ethereum.LazyNodeDatabase),
+	// which is faster for blocks accessing a small fraction of their witness
+	StateLoadingLazy StateLoading = "lazy"
+)
+
+// ParseStateLoading parses a state loading mode, an empty string defaults to StateLoadingEager
+func ParseStateLoading(s string) (StateLoading, error) {
+	switch StateLoading(strings.ToLower(s)) {
+	case "", StateLoadingEager:
+		return StateLoadingEager, nil
+	case StateLoadingLazy:
+		return StateLoadingLazy, nil
+	}
+	return "", fmt.Errorf("invalid state loading %q (expected one of %q)", s, []StateLoading{StateLoadingEager, StateLoadingLazy})
+}
@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/state"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+)
+
+// SlotChange is the value of a storage slot before and after a block (or a range of blocks)
+type SlotChange struct {
+	Slot   gethcommon.Hash `json:"slot"`
+	Before gethcommon.Hash `json:"before"`
+	After  gethcommon.Hash `json:"after"`
+}
+
+// StorageChanges executes the block of the prover input on its witness and returns every storage slot of address
+// accessed during the execution, sorted by slot, with its value before and after the block
+//
+// The slots written by the block are all accessed, since the EVM reads the original value of a slot before writing it,
+// so the slots whose value changed are the returned slots whose values before and after differ.
+func StorageChanges(ctx context.Context, inputs *input.ProverInput, address gethcommon.Address) ([]*SlotChange, error) {
+	e := &executor{}
+	execCtx, err := e.prepareContext(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare execution context: %v", err)
+	}
+	trackers := state.NewAccessTrackerManager()
+	execCtx.stateDB = state.NewAccessTrackerDatabase(execCtx.stateDB, trackers)
+
+	if err := e.preparePreState(execCtx, inputs); err != nil {
+		return nil, fmt.Errorf("failed to prepare pre-state: %v", err)
+	}
+
+	execParams, err := e.prepareExecParams(execCtx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare execution exec params: %v", err)
+	}
+
+	if _, err := e.execEVM(execCtx, execParams); err != nil {
+		return nil, err
+	}
+
+	tracker := trackers.GetAccessTracker(inputs.Witness.Ancestors[0].Root)
+	if tracker == nil {
+		return nil, fmt.Errorf("no state access recorded")
+	}
+
+	changes := make([]*SlotChange, 0, len(tracker.Storage[address]))
+	for slot, before := range tracker.Storage[address] {
+		changes = append(changes, &SlotChange{Slot: slot, Before: before, After: execParams.State.GetState(address, slot)})
+	}
+	sort.Slice(changes, func(i, j int) bool { return bytes.Compare(changes[i].Slot[:], changes[j].Slot[:]) < 0 })
+
+	return changes, nil
+}
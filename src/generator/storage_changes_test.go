@@ -0,0 +1,43 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageChanges(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	proverInput := &testDataInputs.ProverInput
+
+	var buf bytes.Buffer
+	_, err := NewExecutor(&ExecutionConfig{AccessList: &buf}).Execute(context.Background(), proverInput)
+	require.NoError(t, err)
+	report := new(AccessListReport)
+	require.NoError(t, json.Unmarshal(buf.Bytes(), report))
+
+	// Every execution takes a while, so only a few accounts are checked
+	checked, changed := 0, 0
+	for _, tuple := range report.AccessList {
+		if len(tuple.StorageKeys) == 0 || checked == 3 {
+			continue
+		}
+		checked++
+		changes, err := StorageChanges(context.Background(), proverInput, tuple.Address)
+		require.NoError(t, err)
+
+		// Every accessed slot of the account is reported
+		require.Len(t, changes, len(tuple.StorageKeys))
+		for i, change := range changes {
+			assert.Equal(t, tuple.StorageKeys[i], change.Slot)
+			if change.Before != change.After {
+				changed++
+			}
+		}
+	}
+	assert.Positive(t, changed)
+}
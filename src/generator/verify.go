@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+)
+
+// VerifyBlockHeader checks the block of the preflight data, as collected from a possibly untrusted node, against the
+// header served by the trusted node
+//
+// The hash of the collected block is recomputed from its header fields and must match the trusted header, so every
+// field of the header (in particular its parent hash and roots) is confirmed. The parent header of the preflight
+// data, whose state root is the pre-state root of the block, must be the parent of the block.
+func VerifyBlockHeader(ctx context.Context, trusted ethrpc.Client, data *PreflightData) error {
+	header := data.block().Header()
+	if hash := header.Hash(); hash != data.Block.Hash {
+		return fmt.Errorf("block %v hashes to %v but was served with hash %v", header.Number, hash.Hex(), data.Block.Hash.Hex())
+	}
+
+	trustedHeader, err := trusted.HeaderByNumber(ctx, header.Number)
+	if err != nil {
+		return fmt.Errorf("failed to fetch block %v header from verify RPC: %v", header.Number, err)
+	}
+	if trustedHeader.Hash() != header.Hash() {
+		return fmt.Errorf("block %v header mismatch with verify RPC: %v", header.Number, strings.Join(headerDiff(header, trustedHeader), ", "))
+	}
+
+	if len(data.Ancestors) > 0 && data.Ancestors[0].Hash() != header.ParentHash {
+		return fmt.Errorf("block %v parent header mismatch: parent hash is %v but parent header hashes to %v", header.Number, header.ParentHash.Hex(), data.Ancestors[0].Hash().Hex())
+	}
+	return nil
+}
+
+// headerDiff returns the main fields differing between the collected and the trusted headers
+func headerDiff(collected, trusted *gethtypes.Header) []string {
+	diff := []string{fmt.Sprintf("hash %v (verify %v)", collected.Hash().Hex(), trusted.Hash().Hex())}
+	for _, field := range []struct {
+		name               string
+		collected, trusted string
+	}{
+		{"parentHash", collected.ParentHash.Hex(), trusted.ParentHash.Hex()},
+		{"stateRoot", collected.Root.Hex(), trusted.Root.Hex()},
+		{"transactionsRoot", collected.TxHash.Hex(), trusted.TxHash.Hex()},
+		{"receiptsRoot", collected.ReceiptHash.Hex(), trusted.ReceiptHash.Hex()},
+	} {
+		if field.collected != field.trusted {
+			diff = append(diff, fmt.Sprintf("%s %v (verify %v)", field.name, field.collected, field.trusted))
+		}
+	}
+	return diff
+}
+
+// CheckParentLinkage checks the header of the block served by the node links to the header of its parent, also served
+// by the node, i.e. that its parent hash is the hash of the parent header
+//
+// It catches providers serving an inconsistent view of the chain, e.g. load-balanced nodes at different heights.
+func CheckParentLinkage(ctx context.Context, client ethrpc.Client, blockNumber *big.Int) error {
+	header, err := client.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to fetch block %v header: %v", blockNumber, err)
+	}
+	if header.Number.Sign() == 0 {
+		return nil // Genesis has no parent
+	}
+
+	parentNumber := new(big.Int).Sub(header.Number, big.NewInt(1))
+	parent, err := client.HeaderByNumber(ctx, parentNumber)
+	if err != nil {
+		return fmt.Errorf("failed to fetch block %v header: %v", parentNumber, err)
+	}
+	if parent.Hash() != header.ParentHash {
+		return fmt.Errorf("block %v parent hash %v does not match the hash %v of block %v: the node serves an inconsistent view of the chain", header.Number, header.ParentHash.Hex(), parent.Hash().Hex(), parentNumber)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerClient serves a single header by number
+type headerClient struct {
+	ethrpc.Client
+
+	header *gethtypes.Header
+}
+
+func (c *headerClient) HeaderByNumber(_ context.Context, _ *big.Int) (*gethtypes.Header, error) {
+	return c.header, nil
+}
+
+func TestVerifyBlockHeader(t *testing.T) {
+	testDataInputs := loadTestDataInputs(t, testDataInputsPath("Ethereum_Mainnet_21465322.json"))
+	data := &testDataInputs.PreflightData
+	header := data.block().Header()
+
+	require.NoError(t, VerifyBlockHeader(context.Background(), &headerClient{header: header}, data))
+
+	t.Run("header mismatch", func(t *testing.T) {
+		trusted := gethtypes.CopyHeader(header)
+		trusted.Root = gethcommon.HexToHash("0x01")
+		err := VerifyBlockHeader(context.Background(), &headerClient{header: trusted}, data)
+		assert.ErrorContains(t, err, "header mismatch with verify RPC")
+		assert.ErrorContains(t, err, "stateRoot "+header.Root.Hex())
+	})
+
+	t.Run("served hash mismatch", func(t *testing.T) {
+		tampered := *data
+		block := *data.Block
+		block.Hash = gethcommon.HexToHash("0x02")
+		tampered.Block = &block
+		err := VerifyBlockHeader(context.Background(), &headerClient{header: header}, &tampered)
+		assert.ErrorContains(t, err, "but was served with hash")
+	})
+}
+
+// headersClient serves headers by number
+type headersClient struct {
+	ethrpc.Client
+
+	headers map[uint64]*gethtypes.Header
+}
+
+func (c *headersClient) HeaderByNumber(_ context.Context, n *big.Int) (*gethtypes.Header, error) {
+	return c.headers[n.Uint64()], nil
+}
+
+func TestCheckParentLinkage(t *testing.T) {
+	parent := &gethtypes.Header{Number: big.NewInt(9), Difficulty: big.NewInt(0)}
+	header := &gethtypes.Header{Number: big.NewInt(10), Difficulty: big.NewInt(0), ParentHash: parent.Hash()}
+	client := &headersClient{headers: map[uint64]*gethtypes.Header{9: parent, 10: header}}
+	require.NoError(t, CheckParentLinkage(context.Background(), client, big.NewInt(10)))
+
+	t.Run("genesis", func(t *testing.T) {
+		genesis := &gethtypes.Header{Number: big.NewInt(0), Difficulty: big.NewInt(0)}
+		assert.NoError(t, CheckParentLinkage(context.Background(), &headersClient{headers: map[uint64]*gethtypes.Header{0: genesis}}, big.NewInt(0)))
+	})
+
+	t.Run("inconsistent view", func(t *testing.T) {
+		other := gethtypes.CopyHeader(parent)
+		other.Root = gethcommon.HexToHash("0x01")
+		client := &headersClient{headers: map[uint64]*gethtypes.Header{9: other, 10: header}}
+		err := CheckParentLinkage(context.Background(), client, big.NewInt(10))
+		assert.ErrorContains(t, err, "block 10 parent hash "+parent.Hash().Hex()+" does not match the hash "+other.Hash().Hex()+" of block 9")
+	})
+}
@@ -0,0 +1,52 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kkrt-labs/zk-pig/src/ethereum"
+	"github.com/kkrt-labs/zk-pig/src/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGenesisFile(t *testing.T) {
+	// A chain without built-in configuration nor genesis
+	chain := newTestChain(t, generator.DevChainConfig(big.NewInt(424242)), nil, 1, nil)
+
+	b, err := json.Marshal(chain.genesis)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	t.Run("without genesis", func(t *testing.T) {
+		s := newTestService(t, newTestConfig(t, ChainConfig{DataDir: chain.dir}))
+		err := s.Generate(context.Background(), big.NewInt(1), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported chain ID")
+	})
+
+	t.Run("with genesis", func(t *testing.T) {
+		cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+		cfg.Preflight.Genesis, err = ethereum.ReadGenesisFile(path)
+		require.NoError(t, err)
+		s := newTestService(t, cfg)
+		require.NoError(t, s.Generate(context.Background(), big.NewInt(1), nil))
+	})
+
+	t.Run("with genesis of another chain", func(t *testing.T) {
+		cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+		cfg.Preflight.Genesis, err = ethereum.ReadGenesisFile(path)
+		require.NoError(t, err)
+		cfg.Preflight.Genesis.Alloc = gethtypes.GenesisAlloc{}
+		s := newTestService(t, cfg)
+		err := s.Generate(context.Background(), big.NewInt(1), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match the parent of block 1")
+	})
+}
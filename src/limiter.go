@@ -0,0 +1,54 @@
+package src
+
+import (
+	"context"
+
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// blockLimiter caps the number of blocks being processed at the same time
+//
+// It is shared by every entry point of a Service, so the cap applies in aggregate to every block being generated,
+// whatever the command or worker processing it. Blocks exceeding the cap wait for a slot instead of failing.
+type blockLimiter struct {
+	slots chan struct{}
+}
+
+// newBlockLimiter creates a new blockLimiter, returning nil if max is not positive (a nil limiter never waits)
+func newBlockLimiter(max int) *blockLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &blockLimiter{slots: make(chan struct{}, max)}
+}
+
+// Acquire blocks until a block can be processed or ctx is done
+// Release must be called once the block is processed, if Acquire returned no error.
+func (l *blockLimiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	log.LoggerFromContext(ctx).Debug("Waiting for a block processing slot", zap.Int("max-concurrent-blocks", cap(l.slots)))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case l.slots <- struct{}{}:
+		return nil
+	}
+}
+
+// Release releases the slot of a processed block
+func (l *blockLimiter) Release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
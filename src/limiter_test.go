@@ -0,0 +1,115 @@
+package src
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/kkrt-labs/zk-pig/src/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyProbe records the maximum number of callers between enter and leave at the same time
+type concurrencyProbe struct {
+	active, max atomic.Int32
+}
+
+func (p *concurrencyProbe) enter() {
+	n := p.active.Add(1)
+	for m := p.max.Load(); n > m && !p.max.CompareAndSwap(m, n); m = p.max.Load() {
+	}
+}
+
+func (p *concurrencyProbe) leave() {
+	p.active.Add(-1)
+}
+
+func TestBlockLimiter(t *testing.T) {
+	l := newBlockLimiter(2)
+
+	var (
+		probe concurrencyProbe
+		wg    sync.WaitGroup
+		errs  = make(chan error, 10)
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Acquire(context.Background()); err != nil {
+				errs <- err
+				return
+			}
+			defer l.Release()
+			probe.enter()
+			defer probe.leave()
+			time.Sleep(10 * time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	assert.Empty(t, errs, "callers over the cap wait for a slot")
+	assert.Equal(t, int32(2), probe.max.Load())
+
+	t.Run("context done while waiting", func(t *testing.T) {
+		l := newBlockLimiter(1)
+		require.NoError(t, l.Acquire(context.Background()))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, l.Acquire(ctx), context.DeadlineExceeded)
+
+		l.Release()
+		assert.NoError(t, l.Acquire(context.Background()), "the slot is released")
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		l := newBlockLimiter(0)
+		assert.Nil(t, l)
+		for i := 0; i < 10; i++ {
+			require.NoError(t, l.Acquire(context.Background()))
+		}
+		l.Release()
+	})
+}
+
+func TestServiceMaxConcurrentBlocks(t *testing.T) {
+	to := gethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	chain := newTestChain(t, nil, nil, 5, func(_ int, b *core.BlockGen) { sendTestTx(t, testConfig, b, to, nil) })
+
+	var probe concurrencyProbe
+	cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+	cfg.MaxConcurrentBlocks = 2
+	cfg.Execution.PreExecuteHooks = []generator.PreExecuteHook{func(context.Context, *generator.ExecutionEnv) error {
+		probe.enter()
+		defer probe.leave()
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}}
+	s := newTestService(t, cfg)
+
+	// Concurrent workers generating every block, above the cap
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(n int64) {
+			defer wg.Done()
+			errs <- s.Generate(context.Background(), big.NewInt(n), nil)
+		}(int64(2 + i%4))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err, "workers over the cap wait for a slot")
+	}
+	assert.LessOrEqual(t, probe.max.Load(), int32(2))
+	assert.Positive(t, probe.max.Load())
+}
@@ -0,0 +1,125 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// ErrMemoryLimit is the error of a block phase aborted because the memory in use exceeded the limit of the phase
+// (see MemoryLimitConfig), the BlockError of such a phase is of type ErrorTypeMemoryLimit
+var ErrMemoryLimit = errors.New("memory limit exceeded")
+
+// defaultMemoryPollInterval is the interval at which the memory in use is polled if not configured
+const defaultMemoryPollInterval = 100 * time.Millisecond
+
+// MemoryLimitConfig caps the memory in use while a phase of a block runs
+//
+// It is a soft limit, aborting the block before its memory grows until the process is killed by the OS: the Go heap in
+// use is polled periodically, and a phase still running when it exceeds the limit of the phase is aborted with
+// ErrMemoryLimit. The heap is the one of the whole process, so limits require blocks to be processed one at a time
+// (see checkSequential), for the heap to be the one of the limited block.
+type MemoryLimitConfig struct {
+	Preflight    uint64        // Limit in bytes of the heap in use during the preflight phase (unlimited if 0)
+	Prepare      uint64        // Limit in bytes of the heap in use during the prepare phase (unlimited if 0)
+	Execute      uint64        // Limit in bytes of the heap in use during the execute phase (unlimited if 0)
+	PollInterval time.Duration // Interval at which the heap in use is polled (100ms if 0)
+}
+
+// enabled returns whether a phase is limited
+func (cfg *MemoryLimitConfig) enabled() bool {
+	return cfg.Preflight > 0 || cfg.Prepare > 0 || cfg.Execute > 0
+}
+
+// checkSequential returns an error if phases are limited while blocks may be processed concurrently (see
+// Config.MaxConcurrentBlocks), which would abort every block running a limited phase when one of them exceeds its limit
+func (cfg *MemoryLimitConfig) checkSequential(maxConcurrentBlocks int) error {
+	if cfg.enabled() && maxConcurrentBlocks != 1 {
+		return fmt.Errorf("memory limits require blocks to be processed one at a time (set max concurrent blocks to 1)")
+	}
+	return nil
+}
+
+// limit returns the memory limit of a phase, 0 if unlimited
+func (cfg *MemoryLimitConfig) limit(phase Phase) uint64 {
+	switch phase {
+	case PhasePreflight:
+		return cfg.Preflight
+	case PhasePrepare:
+		return cfg.Prepare
+	case PhaseExecute:
+		return cfg.Execute
+	default:
+		return 0
+	}
+}
+
+// withMemoryLimit runs a phase of a block, aborting it if the heap in use exceeds the memory limit of the phase
+//
+// The context passed to fn is canceled on abort, fn must then return: its error is replaced by a BlockError of type
+// ErrorTypeMemoryLimit wrapping ErrMemoryLimit, so the block fails like any other and the run goes on.
+func (s *Service) withMemoryLimit(ctx context.Context, phase Phase, blockNumber *big.Int, fn func(ctx context.Context) error) error {
+	limit := s.cfg.MemoryLimit.limit(phase)
+	if limit == 0 {
+		return fn(ctx)
+	}
+	interval := s.cfg.MemoryLimit.PollInterval
+	if interval <= 0 {
+		interval = defaultMemoryPollInterval
+	}
+
+	// The heap of the previous phase or block is garbage by now, collect it so it is not accounted to this phase
+	runtime.GC()
+
+	phaseCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go watchMemory(phaseCtx, phase, limit, interval, cancel)
+
+	err := fn(phaseCtx)
+	cause := context.Cause(phaseCtx)
+	if err == nil || !errors.Is(cause, ErrMemoryLimit) {
+		return err
+	}
+
+	log.LoggerFromContext(ctx).Warn("Block aborted on memory limit", zap.String("phase", string(phase)), zap.Error(cause))
+	// Return the memory of the aborted phase to the OS before the next block
+	debug.FreeOSMemory()
+
+	var blockErr *BlockError
+	if errors.As(err, &blockErr) && blockErr.BlockNumber != nil {
+		return newBlockError(ctx, ErrorTypeMemoryLimit, phase, blockErr.BlockNumber, blockErr.BlockHash, cause)
+	}
+	return newBlockError(ctx, ErrorTypeMemoryLimit, phase, blockNumber, nil, cause)
+}
+
+// watchMemory polls the heap in use until ctx is done, canceling it with ErrMemoryLimit once the heap exceeds limit
+//
+// A heap above the limit is re-read after a garbage collection, so only live memory aborts the phase.
+func watchMemory(ctx context.Context, phase Phase, limit uint64, interval time.Duration, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var mem runtime.MemStats
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if runtime.ReadMemStats(&mem); mem.HeapInuse <= limit {
+				continue
+			}
+			runtime.GC()
+			if runtime.ReadMemStats(&mem); mem.HeapInuse > limit {
+				cancel(fmt.Errorf("%w: %d bytes of heap in use during %v phase, above the limit of %d bytes", ErrMemoryLimit, mem.HeapInuse, phase, limit))
+				return
+			}
+		}
+	}
+}
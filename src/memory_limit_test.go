@@ -0,0 +1,73 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimitRequiresSequentialBlocks(t *testing.T) {
+	limited := &MemoryLimitConfig{Execute: 4294967296}
+	for _, n := range []int{0, 2} {
+		assert.EqualError(t, limited.checkSequential(n), "memory limits require blocks to be processed one at a time (set max concurrent blocks to 1)", "max concurrent blocks %d", n)
+	}
+	assert.NoError(t, limited.checkSequential(1))
+	assert.NoError(t, new(MemoryLimitConfig).checkSequential(0), "blocks may be processed concurrently without memory limits")
+
+	s := &Service{cfg: &Config{MemoryLimit: MemoryLimitConfig{Execute: 4294967296}, MaxConcurrentBlocks: 1}}
+	_, err := s.GenerateRange(context.Background(), big.NewInt(1), big.NewInt(2), &RangeOptions{Pipeline: true})
+	assert.EqualError(t, err, "memory limits can not be combined with pipelined mode")
+}
+
+var garbage []byte
+
+func TestWithMemoryLimit(t *testing.T) {
+	var mem runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&mem)
+	limit := mem.HeapInuse + 64<<20
+
+	s := &Service{cfg: &Config{MemoryLimit: MemoryLimitConfig{Execute: limit, PollInterval: time.Millisecond}}}
+	wait := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		}
+	}
+
+	t.Run("garbage of a previous phase", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			garbage = make([]byte, 64<<20)
+		}
+		garbage = nil
+		assert.NoError(t, s.withMemoryLimit(context.Background(), PhaseExecute, big.NewInt(1), wait))
+	})
+
+	t.Run("live heap above the limit", func(t *testing.T) {
+		err := s.withMemoryLimit(context.Background(), PhaseExecute, big.NewInt(1), func(ctx context.Context) error {
+			live := make([]byte, 128<<20)
+			defer runtime.KeepAlive(live)
+			return wait(ctx)
+		})
+		var blockErr *BlockError
+		require.True(t, errors.As(err, &blockErr))
+		assert.Equal(t, ErrorTypeMemoryLimit, blockErr.Type)
+		assert.ErrorIs(t, err, ErrMemoryLimit)
+	})
+
+	t.Run("unlimited phase", func(t *testing.T) {
+		assert.NoError(t, s.withMemoryLimit(context.Background(), PhasePrepare, big.NewInt(1), func(ctx context.Context) error {
+			live := make([]byte, 128<<20)
+			defer runtime.KeepAlive(live)
+			return wait(ctx)
+		}))
+	})
+}
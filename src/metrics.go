@@ -0,0 +1,81 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kkrt-labs/go-utils/log"
+	store "github.com/kkrt-labs/go-utils/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// MetricsConfig is the configuration of the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Addr string // Address on which to serve metrics at /metrics (e.g. ":9090"), metrics are not served if empty
+}
+
+// metrics holds the Prometheus metrics of the service
+type metrics struct {
+	registry        *prometheus.Registry
+	proverInputSize *prometheus.HistogramVec
+
+	server *http.Server
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		proverInputSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "prover_input_size_bytes",
+			Help: "Size of the serialized prover inputs (before content encoding)",
+			// From 16KiB (small L1 blocks) to 1GiB (huge L2 blocks)
+			Buckets: prometheus.ExponentialBuckets(16*1024, 2, 17),
+		}, []string{"chain_id"}),
+	}
+	m.registry.MustRegister(m.proverInputSize)
+	return m
+}
+
+// observeProverInput records the size of a prover input written to the store
+func (m *metrics) observeProverInput(headers *store.Headers, size uint64) {
+	var chainID string
+	if headers != nil {
+		chainID = headers.KeyValue["chainID"]
+	}
+	m.proverInputSize.WithLabelValues(chainID).Observe(float64(size))
+}
+
+// start serves the metrics on addr
+func (m *metrics) start(ctx context.Context, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %q: %v", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	m.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		if err := m.server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.LoggerFromContext(ctx).Error("Metrics server failed", zap.Error(err))
+		}
+	}()
+	log.LoggerFromContext(ctx).Info("Serving metrics", zap.String("addr", lis.Addr().String()))
+
+	return nil
+}
+
+// stop stops serving the metrics
+func (m *metrics) stop(ctx context.Context) error {
+	if m.server == nil {
+		return nil
+	}
+	return m.server.Shutdown(ctx)
+}
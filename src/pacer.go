@@ -0,0 +1,60 @@
+package src
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kkrt-labs/zk-pig/src/random"
+)
+
+// blockPacer spaces out the processing of blocks by a fixed delay plus a random jitter
+//
+// Slots are reserved under a lock, so the pacing applies in aggregate to every worker sharing the pacer.
+type blockPacer struct {
+	delay  time.Duration
+	jitter time.Duration
+	random *random.Source
+
+	mu   sync.Mutex
+	next time.Time // Earliest start of the next block
+}
+
+// newBlockPacer creates a new blockPacer drawing its jitter from source, returning nil if delay and jitter are both zero
+// (a nil pacer never waits)
+func newBlockPacer(delay, jitter time.Duration, source *random.Source) *blockPacer {
+	if delay <= 0 && jitter <= 0 {
+		return nil
+	}
+	return &blockPacer{delay: delay, jitter: jitter, random: source}
+}
+
+// Wait blocks until the next block can be started or ctx is done
+// The first block is started immediately.
+func (p *blockPacer) Wait(ctx context.Context) error {
+	if p == nil {
+		return ctx.Err()
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	start := p.next
+	if start.Before(now) {
+		start = now
+	}
+	p.next = start.Add(p.delay)
+	if p.jitter > 0 {
+		p.next = p.next.Add(p.random.Duration(p.jitter))
+	}
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
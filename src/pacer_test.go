@@ -0,0 +1,81 @@
+package src
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kkrt-labs/zk-pig/src/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pacedStarts returns the times n goroutines sharing p started at, in order, relative to the time they were spawned
+func pacedStarts(t *testing.T, p *blockPacer, n int) []time.Duration {
+	spawned := time.Now()
+	var (
+		mu     sync.Mutex
+		starts []time.Time
+		wg     sync.WaitGroup
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, p.Wait(context.Background()))
+			mu.Lock()
+			starts = append(starts, time.Now())
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(starts, func(a, b time.Time) int { return a.Compare(b) })
+	offsets := make([]time.Duration, n)
+	for i, start := range starts {
+		offsets[i] = start.Sub(spawned)
+	}
+	return offsets
+}
+
+func TestBlockPacer(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	t.Run("delay", func(t *testing.T) {
+		// Starts are reserved in aggregate, the i-th goroutine to start does so at least i delays after they were spawned
+		offsets := pacedStarts(t, newBlockPacer(delay, 0, random.New(1)), 6)
+		assert.Less(t, offsets[0], delay, "the first block starts immediately")
+		for i, offset := range offsets {
+			assert.GreaterOrEqual(t, offset, time.Duration(i)*delay, "start %d", i)
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		offsets := pacedStarts(t, newBlockPacer(delay, delay, random.New(1)), 6)
+		for i := 1; i < len(offsets); i++ {
+			assert.GreaterOrEqual(t, offsets[i], time.Duration(i)*delay, "start %d", i)
+		}
+		assert.Less(t, offsets[len(offsets)-1], time.Duration(len(offsets))*2*delay, "jitter is bounded")
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		p := newBlockPacer(time.Hour, 0, random.New(1))
+		require.NoError(t, p.Wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, p.Wait(ctx), context.DeadlineExceeded)
+	})
+
+	t.Run("no pacing", func(t *testing.T) {
+		p := newBlockPacer(0, 0, random.New(1))
+		assert.Nil(t, p)
+		assert.NoError(t, p.Wait(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, p.Wait(ctx), context.Canceled)
+	})
+}
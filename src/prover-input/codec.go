@@ -0,0 +1,107 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Names of the built-in serialization formats of prover inputs
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf" // Registered by the proto package
+)
+
+// Codec serializes prover inputs in a format
+//
+// Codecs are registered under the name of their format with RegisterCodec, the format of the prover input store is then
+// selected by name (see the inputs-content-type flag).
+type Codec interface {
+	Marshal(pi *ProverInput) ([]byte, error)
+	Unmarshal(data []byte) (*ProverInput, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[string]Codec)
+)
+
+// formatNameRegexp matches the valid format names, which are also used as file extensions
+var formatNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// RegisterCodec makes a codec available under the name of its format
+//
+// The name is also the extension of the files the prover inputs are stored in and is set as their metadata, so it must
+// consist of lowercase letters, digits and dashes. It is meant to be called from the init function of the package
+// implementing the codec and panics if the name is invalid or already registered.
+func RegisterCodec(name string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if !formatNameRegexp.MatchString(name) {
+		panic(fmt.Sprintf("input: invalid codec name %q", name))
+	}
+	if codec == nil {
+		panic(fmt.Sprintf("input: codec %q is nil", name))
+	}
+	if _, ok := codecs[name]; ok {
+		panic(fmt.Sprintf("input: codec %q registered twice", name))
+	}
+	codecs[name] = codec
+}
+
+// LookupCodec returns the codec registered under name
+func LookupCodec(name string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format %q (expected one of %q)", name, codecNames())
+	}
+	return codec, nil
+}
+
+// CodecNames returns the sorted names of the registered codecs
+func CodecNames() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	return codecNames()
+}
+
+func codecNames() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSONCodec is the codec of the json format, it encodes the big integers with NumberFormat using Encoder
+type JSONCodec struct {
+	NumberFormat NumberFormat
+	Encoder      JSONEncoder
+}
+
+// Marshal encodes the prover input as a line of JSON
+func (c *JSONCodec) Marshal(pi *ProverInput) ([]byte, error) {
+	b, err := pi.MarshalJSONWithEncoder(c.NumberFormat, c.Encoder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return append(b, '\n'), nil
+}
+
+// Unmarshal decodes a JSON prover input, in any number format
+func (c *JSONCodec) Unmarshal(data []byte) (*ProverInput, error) {
+	pi := new(ProverInput)
+	if err := json.Unmarshal(data, pi); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return pi, nil
+}
+
+func init() {
+	RegisterCodec(FormatJSON, &JSONCodec{})
+}
@@ -0,0 +1,35 @@
+package input
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecRegistry(t *testing.T) {
+	codec, err := LookupCodec(FormatJSON)
+	require.NoError(t, err)
+
+	pi := &ProverInput{Version: "1", ChainConfig: &params.ChainConfig{ChainID: big.NewInt(1)}}
+	b, err := codec.Marshal(pi)
+	require.NoError(t, err)
+	decoded, err := codec.Unmarshal(b)
+	require.NoError(t, err)
+	assert.Equal(t, pi.Version, decoded.Version)
+	assert.Equal(t, pi.ChainConfig.ChainID, decoded.ChainConfig.ChainID)
+
+	_, err = LookupCodec("columnar")
+	assert.ErrorContains(t, err, `unsupported format "columnar"`)
+
+	RegisterCodec("test-codec", &JSONCodec{NumberFormat: NumberFormatDecimal})
+	assert.Contains(t, CodecNames(), "test-codec")
+	_, err = LookupCodec("test-codec")
+	assert.NoError(t, err)
+
+	assert.PanicsWithValue(t, `input: codec "test-codec" registered twice`, func() { RegisterCodec("test-codec", &JSONCodec{}) })
+	assert.PanicsWithValue(t, `input: invalid codec name "Test.Codec"`, func() { RegisterCodec("Test.Codec", &JSONCodec{}) })
+	assert.PanicsWithValue(t, `input: codec "nil-codec" is nil`, func() { RegisterCodec("nil-codec", nil) })
+}
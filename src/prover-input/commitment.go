@@ -0,0 +1,86 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Labels of the witness commitment of a prover input (see CommitWitness)
+const (
+	WitnessCommitmentLabel = "witness-commitment"
+	CommitmentBlockLabel   = "witness-commitment-block-hash"
+)
+
+// WitnessCommitment returns a commitment binding the witness of the prover input to the hash of its block
+//
+// It is the keccak256 hash of the block hash, the hashes of the witness ancestor headers (in order), and the sorted
+// keccak256 hashes of the witness state nodes and of the codes. The order of the state nodes and codes is not
+// significant, so the commitment does not depend on it nor on the serialization or proof format of the prover input.
+func (pi *ProverInput) WitnessCommitment() (gethcommon.Hash, error) {
+	if len(pi.Blocks) == 0 || pi.Blocks[0].Header == nil || pi.Witness == nil {
+		return gethcommon.Hash{}, fmt.Errorf("incomplete prover input: block and witness are required")
+	}
+	nodes, err := pi.StateNodes()
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	blockHash := pi.Blocks[0].Header.Hash()
+	items := [][]byte{blockHash.Bytes()}
+	for _, header := range pi.Witness.Ancestors {
+		items = append(items, header.Hash().Bytes())
+	}
+	items = append(items, sortedHashes(hexBytesList(nodes))...)
+	items = append(items, sortedHashes(pi.Witness.Codes)...)
+	return crypto.Keccak256Hash(items...), nil
+}
+
+func sortedHashes(items []hexutil.Bytes) [][]byte {
+	hashes := make([][]byte, 0, len(items))
+	for _, item := range items {
+		hashes = append(hashes, crypto.Keccak256(item))
+	}
+	slices.SortFunc(hashes, bytes.Compare)
+	return hashes
+}
+
+// CommitWitness sets the witness commitment and the hash of the block it corresponds to as labels of the prover input
+// (and so as metadata of the stored object), to let a downstream verifier bind the prover input to a block without executing it
+func (pi *ProverInput) CommitWitness() error {
+	commitment, err := pi.WitnessCommitment()
+	if err != nil {
+		return err
+	}
+	labels := make(map[string]string, len(pi.Labels)+2)
+	for key, value := range pi.Labels {
+		labels[key] = value
+	}
+	labels[WitnessCommitmentLabel] = commitment.Hex()
+	labels[CommitmentBlockLabel] = pi.Blocks[0].Header.Hash().Hex()
+	pi.Labels = labels
+	return nil
+}
+
+// VerifyWitnessCommitment checks the witness commitment labels of the prover input (see CommitWitness) match its block and witness
+func (pi *ProverInput) VerifyWitnessCommitment() error {
+	expected, ok := pi.Labels[WitnessCommitmentLabel]
+	if !ok {
+		return fmt.Errorf("prover input has no witness commitment")
+	}
+	commitment, err := pi.WitnessCommitment()
+	if err != nil {
+		return err
+	}
+	if blockHash := pi.Blocks[0].Header.Hash().Hex(); pi.Labels[CommitmentBlockLabel] != blockHash {
+		return fmt.Errorf("witness commitment block mismatch: committed to block %v but prover input is for block %v", pi.Labels[CommitmentBlockLabel], blockHash)
+	}
+	if commitment.Hex() != expected {
+		return fmt.Errorf("witness commitment mismatch: expected %v but witness commits to %v", expected, commitment.Hex())
+	}
+	return nil
+}
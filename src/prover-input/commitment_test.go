@@ -0,0 +1,41 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWitnessCommitment(t *testing.T) {
+	pi := &ProverInput{
+		Blocks: []*Block{{Header: testHeader(10)}},
+		Witness: &Witness{
+			State:     []hexutil.Bytes{{0x01}, {0x02}},
+			Ancestors: []*gethtypes.Header{testHeader(9)},
+			Codes:     []hexutil.Bytes{{0x03}},
+		},
+		Labels: map[string]string{"env": "test"},
+	}
+	labels := pi.Labels
+	require.NoError(t, pi.CommitWitness())
+	assert.Equal(t, map[string]string{"env": "test"}, labels, "labels of the prover input should not be mutated")
+	assert.Equal(t, "test", pi.Labels["env"])
+	assert.Equal(t, testHeader(10).Hash().Hex(), pi.Labels[CommitmentBlockLabel])
+	require.NoError(t, pi.VerifyWitnessCommitment())
+
+	// The order of state nodes is not significant
+	pi.Witness.State = []hexutil.Bytes{{0x02}, {0x01}}
+	require.NoError(t, pi.VerifyWitnessCommitment())
+
+	pi.Witness.State = []hexutil.Bytes{{0x02}}
+	assert.ErrorContains(t, pi.VerifyWitnessCommitment(), "witness commitment mismatch")
+
+	pi.Witness.State = []hexutil.Bytes{{0x01}, {0x02}}
+	pi.Blocks[0].Header = testHeader(11)
+	assert.ErrorContains(t, pi.VerifyWitnessCommitment(), "witness commitment block mismatch")
+
+	assert.ErrorContains(t, (&ProverInput{}).VerifyWitnessCommitment(), "no witness commitment")
+}
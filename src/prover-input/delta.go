@@ -0,0 +1,133 @@
+package input
+
+import (
+	"fmt"
+	"sort"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// IsDelta returns whether the prover input is a delta relative to a base prover input
+func (pi *ProverInput) IsDelta() bool {
+	return pi.BaseBlockHash != nil
+}
+
+// NewDelta returns a delta of full relative to base (experimental).
+//
+// The delta witness only contains the state nodes, codes and ancestors that are not in the base witness,
+// and the delta references the last block of base so it can be reconstructed with ApplyDelta.
+//
+// Typically, base is the prover input of block N and full the prover input of block N+1,
+// in which case most of the state nodes accessed by block N+1 are already in the base witness.
+func NewDelta(base, full *ProverInput) (*ProverInput, error) {
+	if base.IsDelta() {
+		return nil, fmt.Errorf("base prover input must not be a delta")
+	}
+	if base.IsMultiproof() || full.IsMultiproof() {
+		return nil, fmt.Errorf("delta prover inputs require the %s proof format", ProofFormatPerAccount)
+	}
+	if len(base.Blocks) == 0 || len(full.Blocks) == 0 {
+		return nil, fmt.Errorf("prover inputs must contain at least one block")
+	}
+
+	baseHeader := base.Blocks[len(base.Blocks)-1].Header
+	if baseHeader.Number.Cmp(full.Blocks[0].Header.Number) >= 0 {
+		return nil, fmt.Errorf("base block %v must be before block %v", baseHeader.Number, full.Blocks[0].Header.Number)
+	}
+
+	delta := *full
+	delta.BaseBlockNumber = baseHeader.Number.Uint64()
+	delta.BaseBlockHash = new(gethcommon.Hash)
+	*delta.BaseBlockHash = baseHeader.Hash()
+
+	if full.Witness != nil {
+		baseWitness := base.Witness
+		if baseWitness == nil {
+			baseWitness = &Witness{}
+		}
+
+		delta.Witness = &Witness{
+			State:     diffBytes(full.Witness.State, baseWitness.State),
+			Codes:     diffBytes(full.Witness.Codes, baseWitness.Codes),
+			Ancestors: diffHeaders(full.Witness.Ancestors, baseWitness.Ancestors),
+		}
+	}
+
+	return &delta, nil
+}
+
+// ApplyDelta reconstructs a full prover input from a base prover input and a delta created with NewDelta
+//
+// The reconstructed witness is the union of the base and delta witnesses, so it may contain
+// state nodes, codes and ancestors that are not accessed during the block execution.
+func ApplyDelta(base, delta *ProverInput) (*ProverInput, error) {
+	if !delta.IsDelta() {
+		return nil, fmt.Errorf("prover input is not a delta")
+	}
+	if len(base.Blocks) == 0 {
+		return nil, fmt.Errorf("base prover input must contain at least one block")
+	}
+
+	baseHeader := base.Blocks[len(base.Blocks)-1].Header
+	if baseHeader.Hash() != *delta.BaseBlockHash {
+		return nil, fmt.Errorf("base block mismatch: delta expects block %v (hash %v) but got block %v (hash %v)", delta.BaseBlockNumber, delta.BaseBlockHash.Hex(), baseHeader.Number, baseHeader.Hash().Hex())
+	}
+
+	full := *delta
+	full.BaseBlockNumber = 0
+	full.BaseBlockHash = nil
+
+	if delta.Witness != nil && base.Witness != nil {
+		full.Witness = &Witness{
+			State:     append(append([]hexutil.Bytes{}, delta.Witness.State...), diffBytes(base.Witness.State, delta.Witness.State)...),
+			Codes:     append(append([]hexutil.Bytes{}, delta.Witness.Codes...), diffBytes(base.Witness.Codes, delta.Witness.Codes)...),
+			Ancestors: mergeHeaders(delta.Witness.Ancestors, base.Witness.Ancestors),
+		}
+	}
+
+	return &full, nil
+}
+
+// diffBytes returns the items of a that are not in b
+func diffBytes(a, b []hexutil.Bytes) []hexutil.Bytes {
+	known := make(map[gethcommon.Hash]struct{}, len(b))
+	for _, item := range b {
+		known[crypto.Keccak256Hash(item)] = struct{}{}
+	}
+
+	diff := []hexutil.Bytes{}
+	for _, item := range a {
+		if _, ok := known[crypto.Keccak256Hash(item)]; !ok {
+			diff = append(diff, item)
+		}
+	}
+	return diff
+}
+
+// diffHeaders returns the headers of a that are not in b
+func diffHeaders(a, b []*gethtypes.Header) []*gethtypes.Header {
+	known := make(map[gethcommon.Hash]struct{}, len(b))
+	for _, header := range b {
+		known[header.Hash()] = struct{}{}
+	}
+
+	diff := []*gethtypes.Header{}
+	for _, header := range a {
+		if _, ok := known[header.Hash()]; !ok {
+			diff = append(diff, header)
+		}
+	}
+	return diff
+}
+
+// mergeHeaders returns the union of a and b in decreasing block number order (the order of witness ancestors)
+func mergeHeaders(a, b []*gethtypes.Header) []*gethtypes.Header {
+	merged := append(append([]*gethtypes.Header{}, a...), diffHeaders(b, a)...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Number.Cmp(merged[j].Number) > 0
+	})
+	return merged
+}
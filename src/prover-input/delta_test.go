@@ -0,0 +1,65 @@
+package input
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHeader(n int64) *gethtypes.Header {
+	return &gethtypes.Header{Number: big.NewInt(n), Difficulty: big.NewInt(0)}
+}
+
+func TestDelta(t *testing.T) {
+	base := &ProverInput{
+		Version:     "1",
+		ChainConfig: params.MainnetChainConfig,
+		Blocks:      []*Block{{Header: testHeader(10)}},
+		Witness: &Witness{
+			State:     []hexutil.Bytes{{0x1}, {0x2}, {0x3}},
+			Codes:     []hexutil.Bytes{{0xa}},
+			Ancestors: []*gethtypes.Header{testHeader(9), testHeader(8)},
+		},
+	}
+	full := &ProverInput{
+		Version:     "1",
+		ChainConfig: params.MainnetChainConfig,
+		Blocks:      []*Block{{Header: testHeader(11)}},
+		Witness: &Witness{
+			State:     []hexutil.Bytes{{0x2}, {0x4}},
+			Codes:     []hexutil.Bytes{{0xa}, {0xb}},
+			Ancestors: []*gethtypes.Header{testHeader(10), testHeader(8)},
+		},
+	}
+
+	delta, err := NewDelta(base, full)
+	require.NoError(t, err)
+	assert.True(t, delta.IsDelta())
+	assert.Equal(t, uint64(10), delta.BaseBlockNumber)
+	assert.Equal(t, base.Blocks[0].Header.Hash(), *delta.BaseBlockHash)
+	assert.Equal(t, []hexutil.Bytes{{0x4}}, delta.Witness.State)
+	assert.Equal(t, []hexutil.Bytes{{0xb}}, delta.Witness.Codes)
+	assert.Equal(t, []*gethtypes.Header{testHeader(10)}, delta.Witness.Ancestors)
+
+	reconstructed, err := ApplyDelta(base, delta)
+	require.NoError(t, err)
+	assert.False(t, reconstructed.IsDelta())
+	assert.ElementsMatch(t, []hexutil.Bytes{{0x1}, {0x2}, {0x3}, {0x4}}, reconstructed.Witness.State)
+	assert.ElementsMatch(t, []hexutil.Bytes{{0xa}, {0xb}}, reconstructed.Witness.Codes)
+	assert.Equal(t, []*gethtypes.Header{testHeader(10), testHeader(9), testHeader(8)}, reconstructed.Witness.Ancestors)
+	assert.Equal(t, full.Blocks, reconstructed.Blocks)
+
+	// Applying the delta on another base fails
+	other := &ProverInput{Blocks: []*Block{{Header: testHeader(9)}}, Witness: &Witness{}}
+	_, err = ApplyDelta(other, delta)
+	assert.ErrorContains(t, err, "base block mismatch")
+
+	// Base must be before the delta block
+	_, err = NewDelta(full, base)
+	assert.Error(t, err)
+}
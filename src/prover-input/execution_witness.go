@@ -0,0 +1,60 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// ExecutionWitness is the witness of a block in the standard execution witness layout, as returned by the
+// debug_executionWitness JSON-RPC method of execution clients, so it can be consumed by any prover supporting it
+//
+// Contrary to the prover input, it does not embed the block nor the chain configuration.
+type ExecutionWitness struct {
+	Headers []*gethtypes.Header `json:"headers"` // Ancestors of the block accessed during execution, parent first
+	Codes   []hexutil.Bytes     `json:"codes"`   // Contract bytecodes accessed during execution
+	State   []hexutil.Bytes     `json:"state"`   // Pre-state MPT nodes (accounts and storage) accessed during execution
+	Keys    []hexutil.Bytes     `json:"keys"`    // Preimages of the accessed state keys (account addresses, each followed by its storage slots)
+}
+
+// NewExecutionWitness returns the execution witness of a prover input
+//
+// keys are the preimages of the state keys accessed by the block, which are not part of the prover input.
+// Codes and state nodes are sorted, so the execution witness of a block is deterministic.
+func NewExecutionWitness(pi *ProverInput, keys [][]byte) (*ExecutionWitness, error) {
+	if pi.IsDelta() {
+		return nil, fmt.Errorf("delta prover inputs must be applied to their base first")
+	}
+	if pi.Witness == nil || len(pi.Witness.Ancestors) == 0 {
+		return nil, fmt.Errorf("prover input witness has no ancestors")
+	}
+
+	nodes, err := pi.StateNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &ExecutionWitness{
+		Headers: pi.Witness.Ancestors,
+		Codes:   sortedBytes(pi.Witness.Codes),
+		State:   sortedBytes(hexBytesList(nodes)),
+		Keys:    make([]hexutil.Bytes, 0, len(keys)),
+	}
+	for _, key := range keys {
+		w.Keys = append(w.Keys, key)
+	}
+
+	return w, nil
+}
+
+func sortedBytes(list []hexutil.Bytes) []hexutil.Bytes {
+	sorted := make([]hexutil.Bytes, len(list))
+	copy(sorted, list)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	return sorted
+}
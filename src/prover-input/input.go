@@ -1,6 +1,10 @@
 package input
 
 import (
+	"errors"
+	"fmt"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
@@ -9,14 +13,26 @@ import (
 // ProverInput contains the data expected by an EVM prover engine to execute & prove the block.
 // It contains the minimal partial state & chain data necessary for processing the block and validating the final state.
 type ProverInput struct {
-	Version     string              `json:"version"`     // Prover Input version
-	Blocks      []*Block            `json:"blocks"`      // Block to execute
-	Witness     *Witness            `json:"witness"`     // Ancestors of the block that are accessed during the block execution
-	ChainConfig *params.ChainConfig `json:"chainConfig"` // Chain configuration
+	Version     string              `json:"version"`               // Prover Input version
+	Blocks      []*Block            `json:"blocks"`                // Block to execute
+	Witness     *Witness            `json:"witness"`               // Ancestors of the block that are accessed during the block execution
+	ProofFormat ProofFormat         `json:"proofFormat,omitempty"` // Format of the state witness, empty for ProofFormatPerAccount
+	ChainConfig *params.ChainConfig `json:"chainConfig"`           // Chain configuration
+	EVMVersion  string              `json:"evmVersion,omitempty"`  // Version of the EVM library used to generate the prover input
+
+	// Experimental: set on delta prover inputs only (see NewDelta)
+	BaseBlockNumber uint64           `json:"baseBlockNumber,omitempty"` // Number of the block of the base prover input
+	BaseBlockHash   *gethcommon.Hash `json:"baseBlockHash,omitempty"`   // Hash of the block of the base prover input
+
+	// Optional chain of the last ancestor headers of the block, anchoring the prover input to a trusted checkpoint (see VerifyAncestorHeaders)
+	AncestorHeaders []*gethtypes.Header `json:"ancestorHeaders,omitempty"` // Parent first, every header is the parent of the previous one
+
+	// Optional free-form labels attached at generation for traceability (e.g. pipeline run ID, git commit)
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 type Witness struct {
-	State     []hexutil.Bytes     `json:"state"`     // Partial pre-state, consisting in a list of MPT nodes
+	State     []hexutil.Bytes     `json:"state"`     // Partial pre-state, consisting in a list of MPT nodes or a multiproof (see ProverInput.ProofFormat)
 	Ancestors []*gethtypes.Header `json:"ancestors"` // Ancestors of the block that are accessed during the block execution
 	Codes     []hexutil.Bytes     `json:"codes"`     // Contract bytecodes used during the block execution
 }
@@ -39,3 +55,49 @@ func (b *Block) Block() *gethtypes.Block {
 			},
 		)
 }
+
+// ErrInputMismatch is returned when a prover input is not the one of the requested block
+var ErrInputMismatch = errors.New("input mismatch")
+
+// CheckBlock returns an ErrInputMismatch error if the prover input is not the one of block blockNumber on chain chainID
+//
+// It catches prover inputs that were overwritten with the data of another block (e.g. store key collisions, stale data)
+// before they fail executing with confusing state root mismatches.
+func (pi *ProverInput) CheckBlock(chainID, blockNumber uint64) error {
+	if len(pi.Blocks) == 0 || pi.Blocks[0].Header == nil || pi.Blocks[0].Header.Number == nil {
+		return fmt.Errorf("%w: prover input contains no block", ErrInputMismatch)
+	}
+	if n := pi.Blocks[0].Header.Number; !n.IsUint64() || n.Uint64() != blockNumber {
+		return fmt.Errorf("%w: prover input is for block %v but block %v was requested", ErrInputMismatch, n, blockNumber)
+	}
+	if pi.ChainConfig == nil || pi.ChainConfig.ChainID == nil {
+		return fmt.Errorf("%w: prover input has no chain ID", ErrInputMismatch)
+	}
+	if id := pi.ChainConfig.ChainID; !id.IsUint64() || id.Uint64() != chainID {
+		return fmt.Errorf("%w: prover input is for chain %v but chain %v was requested", ErrInputMismatch, id, chainID)
+	}
+	return nil
+}
+
+// VerifyAncestorHeaders verifies the hash linkage of the ancestor headers chain: the first header is the parent of the block
+// and every header is the parent of the previous one, so a verifier trusting the last header can trust the block.
+func (pi *ProverInput) VerifyAncestorHeaders() error {
+	if len(pi.AncestorHeaders) == 0 {
+		return nil
+	}
+	if len(pi.Blocks) == 0 || pi.Blocks[0].Header == nil {
+		return fmt.Errorf("prover input contains no block")
+	}
+
+	child := pi.Blocks[0].Header
+	for i, header := range pi.AncestorHeaders {
+		if header == nil {
+			return fmt.Errorf("ancestor header %d is missing", i)
+		}
+		if hash := header.Hash(); hash != child.ParentHash {
+			return fmt.Errorf("ancestor header %d (block %v, hash %v) is not the parent of block %v (parent hash %v)", i, header.Number, hash.Hex(), child.Number, child.ParentHash.Hex())
+		}
+		child = header
+	}
+	return nil
+}
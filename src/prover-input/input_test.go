@@ -1 +1,55 @@
 package input
+
+import (
+	"testing"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBlock(t *testing.T) {
+	pi := &ProverInput{
+		ChainConfig: params.MainnetChainConfig,
+		Blocks:      []*Block{{Header: testHeader(10)}},
+	}
+	require.NoError(t, pi.CheckBlock(1, 10))
+
+	err := pi.CheckBlock(1, 11)
+	assert.ErrorIs(t, err, ErrInputMismatch)
+	assert.ErrorContains(t, err, "prover input is for block 10 but block 11 was requested")
+
+	err = pi.CheckBlock(5, 10)
+	assert.ErrorIs(t, err, ErrInputMismatch)
+	assert.ErrorContains(t, err, "prover input is for chain 1 but chain 5 was requested")
+
+	assert.ErrorIs(t, (&ProverInput{ChainConfig: params.MainnetChainConfig}).CheckBlock(1, 10), ErrInputMismatch)
+	assert.ErrorIs(t, (&ProverInput{Blocks: []*Block{{Header: testHeader(10)}}}).CheckBlock(1, 10), ErrInputMismatch)
+}
+
+func TestVerifyAncestorHeaders(t *testing.T) {
+	// Chain of headers 7 <- 8 <- 9 <- 10
+	headers := []*gethtypes.Header{testHeader(7)}
+	for n := int64(8); n <= 10; n++ {
+		header := testHeader(n)
+		header.ParentHash = headers[len(headers)-1].Hash()
+		headers = append(headers, header)
+	}
+	pi := &ProverInput{
+		Blocks:          []*Block{{Header: headers[3]}},
+		AncestorHeaders: []*gethtypes.Header{headers[2], headers[1], headers[0]},
+	}
+	require.NoError(t, pi.VerifyAncestorHeaders())
+
+	// No ancestor headers
+	require.NoError(t, (&ProverInput{Blocks: pi.Blocks}).VerifyAncestorHeaders())
+
+	// Broken linkage
+	pi.AncestorHeaders = []*gethtypes.Header{headers[2], headers[0]}
+	assert.ErrorContains(t, pi.VerifyAncestorHeaders(), "ancestor header 1 (block 7")
+
+	// First header is not the parent of the block
+	pi.AncestorHeaders = []*gethtypes.Header{headers[1]}
+	assert.ErrorContains(t, pi.VerifyAncestorHeaders(), "is not the parent of block 10")
+}
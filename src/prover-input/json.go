@@ -0,0 +1,154 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// NumberFormat is the JSON serialization format of the big integers of a prover input (i.e. the ones of the chain configuration,
+// other quantities such as block and transaction fields are always 0x-prefixed hexadecimal strings)
+type NumberFormat int
+
+const (
+	NumberFormatHex     NumberFormat = iota // 0x-prefixed hexadecimal quantity strings, as in the Ethereum JSON-RPC API
+	NumberFormatDecimal                     // Decimal JSON numbers, the legacy format (most JSON parsers lose precision above 2^53)
+)
+
+var numberFormatStrings = [...]string{
+	"hex",
+	"decimal",
+}
+
+func (f NumberFormat) String() string {
+	if f < 0 || int(f) >= len(numberFormatStrings) {
+		return "unknown"
+	}
+	return numberFormatStrings[f]
+}
+
+// ParseNumberFormat parses a number format, an empty string defaults to NumberFormatHex
+func ParseNumberFormat(s string) (NumberFormat, error) {
+	switch s {
+	case "", "hex":
+		return NumberFormatHex, nil
+	case "decimal":
+		return NumberFormatDecimal, nil
+	}
+	return -1, fmt.Errorf("invalid number format %q (expected one of %q)", s, numberFormatStrings)
+}
+
+// chainConfigBigInts are the JSON keys of the big integer fields of the chain configuration
+var chainConfigBigInts = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(params.ChainConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Type == reflect.TypeOf((*big.Int)(nil)) {
+			key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			keys[key] = true
+		}
+	}
+	return keys
+}()
+
+// proverInput has the fields of ProverInput without its JSON methods
+type proverInput ProverInput
+
+// MarshalJSON encodes the prover input with its big integers as 0x-prefixed hexadecimal quantities
+func (pi *ProverInput) MarshalJSON() ([]byte, error) {
+	return pi.MarshalJSONWithFormat(NumberFormatHex)
+}
+
+// MarshalJSONWithFormat encodes the prover input with its big integers in the given format
+func (pi *ProverInput) MarshalJSONWithFormat(format NumberFormat) ([]byte, error) {
+	if format == NumberFormatDecimal || pi.ChainConfig == nil {
+		return json.Marshal((*proverInput)(pi))
+	}
+
+	chainConfig, err := convertChainConfigBigInts(pi.ChainConfig, hexBigInt)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&struct {
+		*proverInput
+		ChainConfig json.RawMessage `json:"chainConfig"`
+	}{(*proverInput)(pi), chainConfig})
+}
+
+// hexBigInt converts a JSON decimal number to a 0x-prefixed hexadecimal quantity string
+func hexBigInt(raw json.RawMessage) (json.RawMessage, error) {
+	n, ok := new(big.Int).SetString(string(raw), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %s", raw)
+	}
+	return json.Marshal(hexutil.EncodeBig(n))
+}
+
+// UnmarshalJSON decodes a prover input with its big integers either as 0x-prefixed hexadecimal quantities or as decimal numbers
+func (pi *ProverInput) UnmarshalJSON(b []byte) error {
+	aux := &struct {
+		*proverInput
+		ChainConfig json.RawMessage `json:"chainConfig"`
+	}{proverInput: (*proverInput)(pi)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+
+	pi.ChainConfig = nil
+	if len(aux.ChainConfig) == 0 || string(aux.ChainConfig) == "null" {
+		return nil
+	}
+
+	raw, err := convertChainConfigBigInts(aux.ChainConfig, func(raw json.RawMessage) (json.RawMessage, error) {
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			return raw, nil // Decimal number
+		}
+		n, err := hexutil.DecodeBig(s)
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(n.String()), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pi.ChainConfig = new(params.ChainConfig)
+	return json.Unmarshal(raw, pi.ChainConfig)
+}
+
+// convertChainConfigBigInts returns the JSON chain configuration with convert applied to the values of its big integers,
+// chainConfig is either a *params.ChainConfig or its JSON encoding
+func convertChainConfigBigInts(chainConfig any, convert func(json.RawMessage) (json.RawMessage, error)) (json.RawMessage, error) {
+	raw, ok := chainConfig.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(chainConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("invalid chain config: %v", err)
+	}
+	for key, value := range fields {
+		if !chainConfigBigInts[key] || string(value) == "null" {
+			continue
+		}
+		converted, err := convert(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain config %v: %v", key, err)
+		}
+		fields[key] = converted
+	}
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,185 @@
+package input
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// JSONEncoder is the implementation used to encode prover inputs to JSON, all encoders produce the same bytes
+type JSONEncoder int
+
+const (
+	JSONEncoderStd  JSONEncoder = iota // encoding/json (see MarshalJSONWithFormat)
+	JSONEncoderFast                    // Faster encoding of the witness (see MarshalJSONFast)
+)
+
+var jsonEncoderStrings = [...]string{
+	"std",
+	"fast",
+}
+
+func (e JSONEncoder) String() string {
+	if e < 0 || int(e) >= len(jsonEncoderStrings) {
+		return "unknown"
+	}
+	return jsonEncoderStrings[e]
+}
+
+// ParseJSONEncoder parses a JSON encoder, an empty string defaults to JSONEncoderStd
+func ParseJSONEncoder(s string) (JSONEncoder, error) {
+	switch s {
+	case "", "std":
+		return JSONEncoderStd, nil
+	case "fast":
+		return JSONEncoderFast, nil
+	}
+	return -1, fmt.Errorf("invalid JSON encoder %q (expected one of %q)", s, jsonEncoderStrings)
+}
+
+// MarshalJSONWithEncoder encodes the prover input with its big integers in the given format, using the given encoder
+func (pi *ProverInput) MarshalJSONWithEncoder(format NumberFormat, encoder JSONEncoder) ([]byte, error) {
+	if encoder == JSONEncoderFast {
+		return pi.MarshalJSONFast(format)
+	}
+	return pi.MarshalJSONWithFormat(format)
+}
+
+// MarshalJSONFast encodes the prover input as MarshalJSONWithFormat does, byte for byte, but faster.
+//
+// The witness, which makes most of the size of a prover input, is hex encoded directly into a buffer sized upfront,
+// instead of going through the reflection and the per-element allocations of encoding/json. Other fields are encoded
+// with encoding/json.
+func (pi *ProverInput) MarshalJSONFast(format NumberFormat) ([]byte, error) {
+	// Fields are in the order of encoding/json: with hex numbers, the chain configuration overlays the embedded
+	// prover input fields (see MarshalJSONWithFormat) so it comes last
+	hexChainConfig := format != NumberFormatDecimal && pi.ChainConfig != nil
+
+	buf := bytes.NewBuffer(make([]byte, 0, pi.witnessJSONSize()+4096))
+	e := &fastEncoder{buf: buf, first: true}
+	buf.WriteByte('{')
+	e.field("version", pi.Version)
+	e.field("blocks", pi.Blocks)
+	e.key("witness")
+	e.witness(pi.Witness)
+	if !hexChainConfig {
+		e.field("chainConfig", pi.ChainConfig)
+	}
+	if pi.ProofFormat != "" {
+		e.field("proofFormat", pi.ProofFormat)
+	}
+	if pi.EVMVersion != "" {
+		e.field("evmVersion", pi.EVMVersion)
+	}
+	if pi.BaseBlockNumber != 0 {
+		e.field("baseBlockNumber", pi.BaseBlockNumber)
+	}
+	if pi.BaseBlockHash != nil {
+		e.field("baseBlockHash", pi.BaseBlockHash)
+	}
+	if len(pi.AncestorHeaders) > 0 {
+		e.field("ancestorHeaders", pi.AncestorHeaders)
+	}
+	if len(pi.Labels) > 0 {
+		e.field("labels", pi.Labels)
+	}
+	if hexChainConfig {
+		chainConfig, err := convertChainConfigBigInts(pi.ChainConfig, hexBigInt)
+		if err != nil {
+			return nil, err
+		}
+		e.field("chainConfig", chainConfig)
+	}
+	buf.WriteByte('}')
+
+	if e.err != nil {
+		return nil, e.err
+	}
+	return buf.Bytes(), nil
+}
+
+// witnessJSONSize returns the approximate size of the JSON encoding of the witness bytes
+func (pi *ProverInput) witnessJSONSize() int {
+	if pi.Witness == nil {
+		return 0
+	}
+	size := 0
+	for _, b := range pi.Witness.State {
+		size += 2*len(b) + 5
+	}
+	for _, b := range pi.Witness.Codes {
+		size += 2*len(b) + 5
+	}
+	return size
+}
+
+// fastEncoder writes the fields of a JSON object, keeping the first error
+type fastEncoder struct {
+	buf   *bytes.Buffer
+	first bool
+	err   error
+}
+
+func (e *fastEncoder) key(name string) {
+	if !e.first {
+		e.buf.WriteByte(',')
+	}
+	e.first = false
+	e.buf.WriteByte('"')
+	e.buf.WriteString(name)
+	e.buf.WriteString(`":`)
+}
+
+func (e *fastEncoder) field(name string, v any) {
+	if e.err != nil {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.key(name)
+	e.buf.Write(b)
+}
+
+func (e *fastEncoder) witness(w *Witness) {
+	if w == nil {
+		e.buf.WriteString("null")
+		return
+	}
+
+	e.buf.WriteString(`{"state":`)
+	e.hexBytes(w.State)
+	e.buf.WriteString(`,"ancestors":`)
+	b, err := json.Marshal(w.Ancestors)
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+	e.buf.Write(b)
+	e.buf.WriteString(`,"codes":`)
+	e.hexBytes(w.Codes)
+	e.buf.WriteByte('}')
+}
+
+// hexBytes writes the list as encoding/json does with hexutil.Bytes (including "0x" for nil elements)
+func (e *fastEncoder) hexBytes(list []hexutil.Bytes) {
+	if list == nil {
+		e.buf.WriteString("null")
+		return
+	}
+
+	e.buf.WriteByte('[')
+	for i, b := range list {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.buf.WriteString(`"0x`)
+		e.buf.Write(hex.AppendEncode(e.buf.AvailableBuffer(), b))
+		e.buf.WriteByte('"')
+	}
+	e.buf.WriteByte(']')
+}
@@ -0,0 +1,94 @@
+package input
+
+import (
+	"math/rand"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeProverInput returns a prover input with a witness of the size of a busy mainnet block
+func largeProverInput() *ProverInput {
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec // Deterministic test data
+	randBytes := func(n int) hexutil.Bytes {
+		b := make([]byte, n)
+		rnd.Read(b)
+		return b
+	}
+
+	pi := &ProverInput{
+		Version:     "1",
+		ChainConfig: params.MainnetChainConfig,
+		Blocks:      []*Block{{Header: testHeader(10)}},
+		Witness:     &Witness{Ancestors: []*gethtypes.Header{testHeader(9)}},
+		EVMVersion:  "go-ethereum@v1.14.13",
+	}
+	for i := 0; i < 20000; i++ {
+		pi.Witness.State = append(pi.Witness.State, randBytes(100+rnd.Intn(432)))
+	}
+	for i := 0; i < 300; i++ {
+		pi.Witness.Codes = append(pi.Witness.Codes, randBytes(rnd.Intn(24576)))
+	}
+	return pi
+}
+
+func TestMarshalJSONFast(t *testing.T) {
+	hash := gethcommon.Hash{0x1}
+	for _, tc := range []struct {
+		name string
+		pi   *ProverInput
+	}{
+		{"empty", &ProverInput{}},
+		{"no chain config", &ProverInput{Version: "1", Blocks: []*Block{{Header: testHeader(10)}}, Witness: &Witness{}}},
+		{"nil and empty witness bytes", &ProverInput{
+			ChainConfig: params.MainnetChainConfig,
+			Witness:     &Witness{State: []hexutil.Bytes{nil, {}, {0x0, 0xab}}, Codes: []hexutil.Bytes{}},
+		}},
+		{"optional fields", &ProverInput{
+			Version:         "1",
+			ChainConfig:     params.SepoliaChainConfig,
+			Blocks:          []*Block{{Header: testHeader(10)}},
+			Witness:         &Witness{State: []hexutil.Bytes{{0x1}}, Ancestors: []*gethtypes.Header{testHeader(9)}},
+			BaseBlockNumber: 5,
+			BaseBlockHash:   &hash,
+			AncestorHeaders: []*gethtypes.Header{testHeader(9)},
+			Labels:          map[string]string{"run": "<a&b>", "commit": "abc"},
+		}},
+		{"large", largeProverInput()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, format := range []NumberFormat{NumberFormatHex, NumberFormatDecimal} {
+				expected, err := tc.pi.MarshalJSONWithFormat(format)
+				require.NoError(t, err)
+				got, err := tc.pi.MarshalJSONFast(format)
+				require.NoError(t, err)
+				assert.True(t, string(expected) == string(got), "%v encoding differs", format)
+			}
+		})
+	}
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	pi := largeProverInput()
+
+	b.Run("stdlib", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := pi.MarshalJSONWithFormat(NumberFormatHex); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("fast", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := pi.MarshalJSONFast(NumberFormatHex); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package input
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProverInputJSONNumbers(t *testing.T) {
+	pow := func(n uint) *big.Int { return new(big.Int).Lsh(big.NewInt(1), n) }
+	add := func(a *big.Int, b int64) *big.Int { return new(big.Int).Add(a, big.NewInt(b)) }
+
+	for _, tc := range []struct {
+		name string
+		n    *big.Int
+		hex  string
+	}{
+		{"zero", big.NewInt(0), "0x0"},
+		{"2^53-1", add(pow(53), -1), "0x1fffffffffffff"},
+		{"2^53", pow(53), "0x20000000000000"},
+		{"2^53+1", add(pow(53), 1), "0x20000000000001"},
+		{"2^64", pow(64), "0x10000000000000000"},
+		{"mainnet terminal total difficulty", params.MainnetChainConfig.TerminalTotalDifficulty, "0xc70d808a128d7380000"},
+		{"2^256-1", add(pow(256), -1), "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pi := &ProverInput{
+				Version:     "1",
+				ChainConfig: &params.ChainConfig{ChainID: big.NewInt(1), TerminalTotalDifficulty: tc.n},
+				Blocks:      []*Block{{Header: testHeader(10)}},
+			}
+
+			// Hex quantities
+			b, err := json.Marshal(pi)
+			require.NoError(t, err)
+			var raw struct {
+				ChainConfig map[string]any `json:"chainConfig"`
+			}
+			require.NoError(t, json.Unmarshal(b, &raw))
+			assert.Equal(t, "0x1", raw.ChainConfig["chainId"])
+			assert.Equal(t, tc.hex, raw.ChainConfig["terminalTotalDifficulty"])
+
+			decoded := new(ProverInput)
+			require.NoError(t, json.Unmarshal(b, decoded))
+			assert.Equal(t, 0, tc.n.Cmp(decoded.ChainConfig.TerminalTotalDifficulty))
+			assert.Equal(t, 0, big.NewInt(1).Cmp(decoded.ChainConfig.ChainID))
+			assert.Equal(t, pi.Blocks[0].Header.Hash(), decoded.Blocks[0].Header.Hash())
+
+			// Legacy decimal numbers
+			b, err = pi.MarshalJSONWithFormat(NumberFormatDecimal)
+			require.NoError(t, err)
+			assert.Contains(t, string(b), `"terminalTotalDifficulty":`+tc.n.String())
+
+			decoded = new(ProverInput)
+			require.NoError(t, json.Unmarshal(b, decoded))
+			assert.Equal(t, 0, tc.n.Cmp(decoded.ChainConfig.TerminalTotalDifficulty))
+		})
+	}
+
+	t.Run("invalid hex quantity", func(t *testing.T) {
+		err := json.Unmarshal([]byte(`{"chainConfig":{"chainId":"0xzz"}}`), new(ProverInput))
+		assert.ErrorContains(t, err, "invalid chain config chainId")
+	})
+
+	t.Run("no chain config", func(t *testing.T) {
+		b, err := json.Marshal(&ProverInput{Version: "1"})
+		require.NoError(t, err)
+		decoded := new(ProverInput)
+		require.NoError(t, json.Unmarshal(b, decoded))
+		assert.Nil(t, decoded.ChainConfig)
+	})
+}
+
+func TestParseNumberFormat(t *testing.T) {
+	for s, expected := range map[string]NumberFormat{"": NumberFormatHex, "hex": NumberFormatHex, "decimal": NumberFormatDecimal} {
+		format, err := ParseNumberFormat(s)
+		require.NoError(t, err)
+		assert.Equal(t, expected, format)
+	}
+	_, err := ParseNumberFormat("octal")
+	assert.Error(t, err)
+}
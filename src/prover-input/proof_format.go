@@ -0,0 +1,91 @@
+package input
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/trie"
+)
+
+// ProofFormat is the format of the state witness of a prover input (Witness.State)
+type ProofFormat string
+
+const (
+	ProofFormatPerAccount ProofFormat = "per-account" // MPT nodes of the proofs of the accessed accounts and slots, in any order (the default)
+	ProofFormatMultiproof ProofFormat = "multiproof"  // Single multiproof over all the accessed accounts and slots (see trie.EncodeMultiproof)
+)
+
+// ParseProofFormat parses a proof format, an empty string defaults to ProofFormatPerAccount
+func ParseProofFormat(s string) (ProofFormat, error) {
+	switch ProofFormat(s) {
+	case "", ProofFormatPerAccount:
+		return ProofFormatPerAccount, nil
+	case ProofFormatMultiproof:
+		return ProofFormatMultiproof, nil
+	}
+	return "", fmt.Errorf("invalid proof format %q (expected one of %q)", s, []ProofFormat{ProofFormatPerAccount, ProofFormatMultiproof})
+}
+
+// IsMultiproof returns whether the state witness of the prover input is a multiproof
+func (pi *ProverInput) IsMultiproof() bool {
+	return pi.ProofFormat == ProofFormatMultiproof
+}
+
+// ToMultiproof compresses the state witness of the prover input into a multiproof over its pre-state root,
+// the state root of the parent header (see trie.EncodeMultiproof)
+func (pi *ProverInput) ToMultiproof() error {
+	if pi.IsMultiproof() {
+		return nil
+	}
+	if pi.IsDelta() {
+		return fmt.Errorf("delta prover inputs can not be converted to a multiproof")
+	}
+	if pi.Witness == nil || len(pi.Witness.Ancestors) == 0 {
+		return fmt.Errorf("prover input witness has no parent header")
+	}
+
+	multiproof, err := trie.EncodeMultiproof(pi.Witness.Ancestors[0].Root, bytesList(pi.Witness.State))
+	if err != nil {
+		return fmt.Errorf("failed to encode multiproof: %v", err)
+	}
+	pi.Witness.State = hexBytesList(multiproof)
+	pi.ProofFormat = ProofFormatMultiproof
+	return nil
+}
+
+// StateNodes returns the MPT nodes of the state witness, a multiproof is decoded and verified against the pre-state root
+func (pi *ProverInput) StateNodes() ([][]byte, error) {
+	if pi.Witness == nil {
+		return nil, nil
+	}
+	switch pi.ProofFormat {
+	case "", ProofFormatPerAccount:
+		return bytesList(pi.Witness.State), nil
+	case ProofFormatMultiproof:
+		if len(pi.Witness.Ancestors) == 0 {
+			return nil, fmt.Errorf("prover input witness has no parent header")
+		}
+		nodes, err := trie.DecodeMultiproof(pi.Witness.Ancestors[0].Root, bytesList(pi.Witness.State))
+		if err != nil {
+			return nil, fmt.Errorf("invalid state multiproof: %v", err)
+		}
+		return nodes, nil
+	}
+	return nil, fmt.Errorf("unsupported proof format %q", pi.ProofFormat)
+}
+
+func bytesList(list []hexutil.Bytes) [][]byte {
+	out := make([][]byte, 0, len(list))
+	for _, b := range list {
+		out = append(out, b)
+	}
+	return out
+}
+
+func hexBytesList(list [][]byte) []hexutil.Bytes {
+	out := make([]hexutil.Bytes, 0, len(list))
+	for _, b := range list {
+		out = append(out, b)
+	}
+	return out
+}
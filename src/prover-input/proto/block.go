@@ -14,7 +14,7 @@ func BlockToProto(b *input.Block) *Block {
 	return &Block{
 		Header:       HeaderToProto(b.Header),
 		Transactions: TransactionsToProto(b.Transactions),
-		Uncles:       HeadersToProto(b.Uncles), // we assume a post-merge
+		Uncles:       HeadersToProto(b.Uncles),
 		Withdrawals:  WithdrawalsToProto(b.Withdrawals),
 	}
 }
@@ -24,12 +24,19 @@ func BlockFromProto(b *Block) *input.Block {
 		return nil
 	}
 
-	return &input.Block{
+	block := &input.Block{
 		Header:       HeaderFromProto(b.Header),
 		Transactions: TransactionsFromProto(b.Transactions),
-		Uncles:       HeadersFromProto(b.Uncles), // we assume a post-merge
+		Uncles:       HeadersFromProto(b.Uncles),
 		Withdrawals:  WithdrawalsFromProto(b.Withdrawals),
 	}
+
+	// Protobuf does not distinguish an empty list from a missing one, post-Shanghai blocks (with a withdrawals root)
+	// without withdrawals have an empty list
+	if block.Withdrawals == nil && block.Header != nil && block.Header.WithdrawalsHash != nil {
+		block.Withdrawals = []*gethtypes.Withdrawal{}
+	}
+	return block
 }
 
 func BlocksToProto(blocks []*input.Block) []*Block {
@@ -10,6 +10,8 @@ import (
 	"github.com/kkrt-labs/go-utils/common"
 	input "github.com/kkrt-labs/zk-pig/src/prover-input"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
 )
 
 func TestBlock(t *testing.T) {
@@ -69,6 +71,29 @@ func TestBlock(t *testing.T) {
 	}
 }
 
+func TestBlockWithoutWithdrawals(t *testing.T) {
+	// Post-Shanghai block without withdrawals
+	block := &input.Block{
+		Header:      &gethtypes.Header{Number: big.NewInt(1), WithdrawalsHash: &gethtypes.EmptyWithdrawalsHash},
+		Withdrawals: []*gethtypes.Withdrawal{},
+	}
+
+	b, err := protobuf.Marshal(BlockToProto(block))
+	require.NoError(t, err)
+	protoBlock := new(Block)
+	require.NoError(t, protobuf.Unmarshal(b, protoBlock))
+	require.Nil(t, protoBlock.Withdrawals, "protobuf does not distinguish an empty list")
+
+	blockFromProto := BlockFromProto(protoBlock)
+	assert.NotNil(t, blockFromProto.Withdrawals)
+	assert.Empty(t, blockFromProto.Withdrawals)
+	assert.Equal(t, block.Block().Hash(), blockFromProto.Block().Hash())
+
+	// Pre-Shanghai block
+	block.Header.WithdrawalsHash, block.Withdrawals = nil, nil
+	assert.Nil(t, BlockFromProto(BlockToProto(block)).Withdrawals)
+}
+
 func TestHeader(t *testing.T) {
 	type testCase struct {
 		desc   string
@@ -0,0 +1,33 @@
+package proto
+
+import (
+	"fmt"
+
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// Codec is the codec of the protobuf format
+type Codec struct{}
+
+// Marshal encodes the prover input as a ProverInput protobuf message
+func (Codec) Marshal(pi *input.ProverInput) ([]byte, error) {
+	b, err := protobuf.Marshal(ToProto(pi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf: %w", err)
+	}
+	return b, nil
+}
+
+// Unmarshal decodes a ProverInput protobuf message
+func (Codec) Unmarshal(data []byte) (*input.ProverInput, error) {
+	msg := new(ProverInput)
+	if err := protobuf.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal protobuf: %w", err)
+	}
+	return FromProto(msg), nil
+}
+
+func init() {
+	input.RegisterCodec(input.FormatProtobuf, Codec{})
+}
@@ -10,12 +10,22 @@ func ToProto(pi *input.ProverInput) *ProverInput {
 		return nil
 	}
 
-	return &ProverInput{
-		Version:     pi.Version,
-		Blocks:      BlocksToProto(pi.Blocks),
-		Witness:     WitnessToProto(pi.Witness),
-		ChainConfig: ChainConfigToProto(pi.ChainConfig),
+	p := &ProverInput{
+		Version:         pi.Version,
+		Blocks:          BlocksToProto(pi.Blocks),
+		Witness:         WitnessToProto(pi.Witness),
+		ChainConfig:     ChainConfigToProto(pi.ChainConfig),
+		EvmVersion:      pi.EVMVersion,
+		BaseBlockNumber: pi.BaseBlockNumber,
+		AncestorHeaders: HeadersToProto(pi.AncestorHeaders),
+		Labels:          pi.Labels,
+		ProofFormat:     string(pi.ProofFormat),
 	}
+	if pi.BaseBlockHash != nil {
+		p.BaseBlockHash = pi.BaseBlockHash.Bytes()
+	}
+
+	return p
 }
 
 func FromProto(pi *ProverInput) *input.ProverInput {
@@ -24,10 +34,16 @@ func FromProto(pi *ProverInput) *input.ProverInput {
 	}
 
 	return &input.ProverInput{
-		Version:     pi.Version,
-		Blocks:      BlocksFromProto(pi.Blocks),
-		Witness:     WitnessFromProto(pi.Witness),
-		ChainConfig: ChainConfigFromProto(pi.ChainConfig),
+		Version:         pi.Version,
+		Blocks:          BlocksFromProto(pi.Blocks),
+		Witness:         WitnessFromProto(pi.Witness),
+		ChainConfig:     ChainConfigFromProto(pi.ChainConfig),
+		EVMVersion:      pi.EvmVersion,
+		BaseBlockNumber: pi.BaseBlockNumber,
+		BaseBlockHash:   bytesToHashPtr(pi.BaseBlockHash),
+		AncestorHeaders: HeadersFromProto(pi.AncestorHeaders),
+		Labels:          pi.Labels,
+		ProofFormat:     input.ProofFormat(pi.ProofFormat),
 	}
 }
 
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.3
+// 	protoc-gen-go v1.36.5
 // 	protoc        v5.29.3
 // source: src/prover-input/proto/input.proto
 
@@ -11,6 +11,7 @@ import (
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -22,13 +23,19 @@ const (
 
 // ProverInput contains the minimal data needed for block execution and proof validation
 type ProverInput struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
-	Blocks        []*Block               `protobuf:"bytes,2,rep,name=blocks,proto3" json:"blocks,omitempty"`
-	Witness       *Witness               `protobuf:"bytes,3,opt,name=witness,proto3" json:"witness,omitempty"`
-	ChainConfig   *ChainConfig           `protobuf:"bytes,4,opt,name=chain_config,json=chainConfig,proto3" json:"chain_config,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Version         string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Blocks          []*Block               `protobuf:"bytes,2,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	Witness         *Witness               `protobuf:"bytes,3,opt,name=witness,proto3" json:"witness,omitempty"`
+	ChainConfig     *ChainConfig           `protobuf:"bytes,4,opt,name=chain_config,json=chainConfig,proto3" json:"chain_config,omitempty"`
+	EvmVersion      string                 `protobuf:"bytes,5,opt,name=evm_version,json=evmVersion,proto3" json:"evm_version,omitempty"`
+	BaseBlockNumber uint64                 `protobuf:"varint,6,opt,name=base_block_number,json=baseBlockNumber,proto3" json:"base_block_number,omitempty"`
+	BaseBlockHash   []byte                 `protobuf:"bytes,7,opt,name=base_block_hash,json=baseBlockHash,proto3" json:"base_block_hash,omitempty"`
+	AncestorHeaders []*Header              `protobuf:"bytes,8,rep,name=ancestor_headers,json=ancestorHeaders,proto3" json:"ancestor_headers,omitempty"`
+	Labels          map[string]string      `protobuf:"bytes,9,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ProofFormat     string                 `protobuf:"bytes,10,opt,name=proof_format,json=proofFormat,proto3" json:"proof_format,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ProverInput) Reset() {
@@ -89,6 +96,48 @@ func (x *ProverInput) GetChainConfig() *ChainConfig {
 	return nil
 }
 
+func (x *ProverInput) GetEvmVersion() string {
+	if x != nil {
+		return x.EvmVersion
+	}
+	return ""
+}
+
+func (x *ProverInput) GetBaseBlockNumber() uint64 {
+	if x != nil {
+		return x.BaseBlockNumber
+	}
+	return 0
+}
+
+func (x *ProverInput) GetBaseBlockHash() []byte {
+	if x != nil {
+		return x.BaseBlockHash
+	}
+	return nil
+}
+
+func (x *ProverInput) GetAncestorHeaders() []*Header {
+	if x != nil {
+		return x.AncestorHeaders
+	}
+	return nil
+}
+
+func (x *ProverInput) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *ProverInput) GetProofFormat() string {
+	if x != nil {
+		return x.ProofFormat
+	}
+	return ""
+}
+
 type Witness struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	State         [][]byte               `protobuf:"bytes,1,rep,name=state,proto3" json:"state,omitempty"`
@@ -151,7 +200,7 @@ func (x *Witness) GetCodes() [][]byte {
 
 var File_src_prover_input_proto_input_proto protoreflect.FileDescriptor
 
-var file_src_prover_input_proto_input_proto_rawDesc = []byte{
+var file_src_prover_input_proto_input_proto_rawDesc = string([]byte{
 	0x0a, 0x22, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2d, 0x69, 0x6e, 0x70,
 	0x75, 0x74, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x2e, 0x70,
 	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x1a, 0x22, 0x73, 0x72, 0x63,
@@ -159,7 +208,7 @@ var file_src_prover_input_proto_input_proto_rawDesc = []byte{
 	0x6f, 0x74, 0x6f, 0x2f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a,
 	0x29, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2d, 0x69, 0x6e, 0x70, 0x75,
 	0x74, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x63, 0x6f,
-	0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xae, 0x01, 0x0a, 0x0b, 0x50,
+	0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xf3, 0x03, 0x0a, 0x0b, 0x50,
 	0x72, 0x6f, 0x76, 0x65, 0x72, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
 	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72,
 	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x24, 0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x02,
@@ -170,49 +219,73 @@ var file_src_prover_input_proto_input_proto_rawDesc = []byte{
 	0x6e, 0x65, 0x73, 0x73, 0x12, 0x35, 0x0a, 0x0c, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x63, 0x6f,
 	0x6e, 0x66, 0x69, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x69, 0x6e, 0x70,
 	0x75, 0x74, 0x2e, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x0b,
-	0x63, 0x68, 0x61, 0x69, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x62, 0x0a, 0x07, 0x57,
-	0x69, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18,
-	0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x2b, 0x0a, 0x09,
-	0x61, 0x6e, 0x63, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x0d, 0x2e, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x09,
-	0x61, 0x6e, 0x63, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x64,
-	0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x63, 0x6f, 0x64, 0x65, 0x73, 0x42,
-	0x34, 0x5a, 0x32, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b, 0x6b,
-	0x72, 0x74, 0x2d, 0x6c, 0x61, 0x62, 0x73, 0x2f, 0x7a, 0x6b, 0x2d, 0x70, 0x69, 0x67, 0x2f, 0x73,
-	0x72, 0x63, 0x2f, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2d, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x2f,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
-}
+	0x63, 0x68, 0x61, 0x69, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1f, 0x0a, 0x0b, 0x65,
+	0x76, 0x6d, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x65, 0x76, 0x6d, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x2a, 0x0a, 0x11,
+	0x62, 0x61, 0x73, 0x65, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0f, 0x62, 0x61, 0x73, 0x65, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x26, 0x0a, 0x0f, 0x62, 0x61, 0x73, 0x65,
+	0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0d, 0x62, 0x61, 0x73, 0x65, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68,
+	0x12, 0x38, 0x0a, 0x10, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x5f, 0x68, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x69, 0x6e, 0x70,
+	0x75, 0x74, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x0f, 0x61, 0x6e, 0x63, 0x65, 0x73,
+	0x74, 0x6f, 0x72, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x36, 0x0a, 0x06, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x69, 0x6e, 0x70,
+	0x75, 0x74, 0x2e, 0x50, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x2e, 0x4c,
+	0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65,
+	0x6c, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x46,
+	0x6f, 0x72, 0x6d, 0x61, 0x74, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x22, 0x62, 0x0a, 0x07, 0x57, 0x69, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x2b, 0x0a, 0x09, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x2e, 0x48, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x52, 0x09, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x63, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x63,
+	0x6f, 0x64, 0x65, 0x73, 0x42, 0x34, 0x5a, 0x32, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x6b, 0x6b, 0x72, 0x74, 0x2d, 0x6c, 0x61, 0x62, 0x73, 0x2f, 0x7a, 0x6b, 0x2d,
+	0x70, 0x69, 0x67, 0x2f, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2d, 0x69,
+	0x6e, 0x70, 0x75, 0x74, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+})
 
 var (
 	file_src_prover_input_proto_input_proto_rawDescOnce sync.Once
-	file_src_prover_input_proto_input_proto_rawDescData = file_src_prover_input_proto_input_proto_rawDesc
+	file_src_prover_input_proto_input_proto_rawDescData []byte
 )
 
 func file_src_prover_input_proto_input_proto_rawDescGZIP() []byte {
 	file_src_prover_input_proto_input_proto_rawDescOnce.Do(func() {
-		file_src_prover_input_proto_input_proto_rawDescData = protoimpl.X.CompressGZIP(file_src_prover_input_proto_input_proto_rawDescData)
+		file_src_prover_input_proto_input_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_src_prover_input_proto_input_proto_rawDesc), len(file_src_prover_input_proto_input_proto_rawDesc)))
 	})
 	return file_src_prover_input_proto_input_proto_rawDescData
 }
 
-var file_src_prover_input_proto_input_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_src_prover_input_proto_input_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_src_prover_input_proto_input_proto_goTypes = []any{
 	(*ProverInput)(nil), // 0: input.ProverInput
 	(*Witness)(nil),     // 1: input.Witness
-	(*Block)(nil),       // 2: input.Block
-	(*ChainConfig)(nil), // 3: input.ChainConfig
-	(*Header)(nil),      // 4: input.Header
+	nil,                 // 2: input.ProverInput.LabelsEntry
+	(*Block)(nil),       // 3: input.Block
+	(*ChainConfig)(nil), // 4: input.ChainConfig
+	(*Header)(nil),      // 5: input.Header
 }
 var file_src_prover_input_proto_input_proto_depIdxs = []int32{
-	2, // 0: input.ProverInput.blocks:type_name -> input.Block
+	3, // 0: input.ProverInput.blocks:type_name -> input.Block
 	1, // 1: input.ProverInput.witness:type_name -> input.Witness
-	3, // 2: input.ProverInput.chain_config:type_name -> input.ChainConfig
-	4, // 3: input.Witness.ancestors:type_name -> input.Header
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	4, // 2: input.ProverInput.chain_config:type_name -> input.ChainConfig
+	5, // 3: input.ProverInput.ancestor_headers:type_name -> input.Header
+	2, // 4: input.ProverInput.labels:type_name -> input.ProverInput.LabelsEntry
+	5, // 5: input.Witness.ancestors:type_name -> input.Header
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_src_prover_input_proto_input_proto_init() }
@@ -226,9 +299,9 @@ func file_src_prover_input_proto_input_proto_init() {
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_src_prover_input_proto_input_proto_rawDesc,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_src_prover_input_proto_input_proto_rawDesc), len(file_src_prover_input_proto_input_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -237,7 +310,6 @@ func file_src_prover_input_proto_input_proto_init() {
 		MessageInfos:      file_src_prover_input_proto_input_proto_msgTypes,
 	}.Build()
 	File_src_prover_input_proto_input_proto = out.File
-	file_src_prover_input_proto_input_proto_rawDesc = nil
 	file_src_prover_input_proto_input_proto_goTypes = nil
 	file_src_prover_input_proto_input_proto_depIdxs = nil
 }
@@ -1,8 +1,10 @@
 package proto
 
 import (
+	"math/big"
 	"testing"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
@@ -27,11 +29,53 @@ func TestInput(t *testing.T) {
 			desc: "input with all fields set",
 			input: &input.ProverInput{
 				Version:     "1",
+				EVMVersion:  "v1.14.13",
 				Blocks:      []*input.Block{},
 				Witness:     &input.Witness{},
 				ChainConfig: &params.ChainConfig{},
 			},
 		},
+		{
+			desc: "delta input",
+			input: &input.ProverInput{
+				Version:         "1",
+				Blocks:          []*input.Block{},
+				Witness:         &input.Witness{},
+				ChainConfig:     &params.ChainConfig{},
+				BaseBlockNumber: 10,
+				BaseBlockHash:   &gethcommon.Hash{0xa},
+			},
+		},
+		{
+			desc: "input with ancestor headers",
+			input: &input.ProverInput{
+				Version:         "1",
+				Blocks:          []*input.Block{},
+				Witness:         &input.Witness{},
+				ChainConfig:     &params.ChainConfig{},
+				AncestorHeaders: []*gethtypes.Header{{Number: big.NewInt(9), Difficulty: big.NewInt(0), ParentHash: gethcommon.Hash{0xb}}},
+			},
+		},
+		{
+			desc: "input with labels",
+			input: &input.ProverInput{
+				Version:     "1",
+				Blocks:      []*input.Block{},
+				Witness:     &input.Witness{},
+				ChainConfig: &params.ChainConfig{},
+				Labels:      map[string]string{"run": "42", "commit": "abc123"},
+			},
+		},
+		{
+			desc: "input with multiproof",
+			input: &input.ProverInput{
+				Version:     "1",
+				Blocks:      []*input.Block{},
+				Witness:     &input.Witness{},
+				ChainConfig: &params.ChainConfig{},
+				ProofFormat: input.ProofFormatMultiproof,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,104 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// PublicInputs are the public inputs baked into a proof of a block, as exported by a prover
+//
+// Every field is optional, only set fields are checked against the prover input (see CheckPublicInputs).
+type PublicInputs struct {
+	ChainID       *PublicNumber    `json:"chainId,omitempty"`
+	BlockNumber   *PublicNumber    `json:"blockNumber,omitempty"`
+	BlockHash     *gethcommon.Hash `json:"blockHash,omitempty"`
+	PreStateRoot  *gethcommon.Hash `json:"preStateRoot,omitempty"`  // State root of the parent block
+	PostStateRoot *gethcommon.Hash `json:"postStateRoot,omitempty"` // State root of the block
+}
+
+// PublicNumber is a number of public inputs, decoded from a JSON number, a decimal string or a 0x-prefixed hex string
+type PublicNumber struct {
+	big.Int
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (n *PublicNumber) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if unquoted, err := unquote(b); err == nil {
+		s = unquoted
+	}
+
+	var ok bool
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		_, ok = n.SetString(s[2:], 16)
+	} else {
+		_, ok = n.SetString(s, 10)
+	}
+	if !ok {
+		return fmt.Errorf("invalid number %s", b)
+	}
+	return nil
+}
+
+func unquote(b []byte) (string, error) {
+	var s string
+	err := json.Unmarshal(b, &s)
+	return s, err
+}
+
+// PublicInputMismatch is a public input of a proof that does not match the prover input
+type PublicInputMismatch struct {
+	Field string `json:"field"`
+	Proof string `json:"proof"` // Value baked into the proof
+	Input string `json:"input"` // Value derived from the prover input
+}
+
+// CheckPublicInputs compares the set public inputs of a proof with the values derived from the prover input, and returns
+// the mismatching ones
+//
+// The pre-state root is the state root of the parent header in the witness, which must be the parent of the block.
+func (pi *ProverInput) CheckPublicInputs(pub *PublicInputs) ([]*PublicInputMismatch, error) {
+	if len(pi.Blocks) == 0 || pi.Blocks[0].Header == nil || pi.Blocks[0].Header.Number == nil {
+		return nil, fmt.Errorf("prover input contains no block")
+	}
+	header := pi.Blocks[0].Header
+
+	mismatches := []*PublicInputMismatch{}
+	check := func(field, proof, input string) {
+		if proof != input {
+			mismatches = append(mismatches, &PublicInputMismatch{Field: field, Proof: proof, Input: input})
+		}
+	}
+
+	if pub.ChainID != nil {
+		if pi.ChainConfig == nil || pi.ChainConfig.ChainID == nil {
+			return nil, fmt.Errorf("prover input has no chain ID")
+		}
+		check("chainId", pub.ChainID.String(), pi.ChainConfig.ChainID.String())
+	}
+	if pub.BlockNumber != nil {
+		check("blockNumber", pub.BlockNumber.String(), header.Number.String())
+	}
+	if pub.BlockHash != nil {
+		check("blockHash", pub.BlockHash.Hex(), header.Hash().Hex())
+	}
+	if pub.PreStateRoot != nil {
+		if pi.Witness == nil || len(pi.Witness.Ancestors) == 0 || pi.Witness.Ancestors[0] == nil {
+			return nil, fmt.Errorf("prover input has no parent header")
+		}
+		parent := pi.Witness.Ancestors[0]
+		if hash := parent.Hash(); hash != header.ParentHash {
+			return nil, fmt.Errorf("first witness ancestor %v is not the parent of the block (parent hash %v)", hash.Hex(), header.ParentHash.Hex())
+		}
+		check("preStateRoot", pub.PreStateRoot.Hex(), parent.Root.Hex())
+	}
+	if pub.PostStateRoot != nil {
+		check("postStateRoot", pub.PostStateRoot.Hex(), header.Root.Hex())
+	}
+
+	return mismatches, nil
+}
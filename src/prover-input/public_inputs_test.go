@@ -0,0 +1,54 @@
+package input
+
+import (
+	"encoding/json"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPublicInputs(t *testing.T) {
+	parent := testHeader(9)
+	parent.Root = gethcommon.HexToHash("0x9")
+	header := testHeader(10)
+	header.Root = gethcommon.HexToHash("0xa")
+	header.ParentHash = parent.Hash()
+	pi := &ProverInput{
+		ChainConfig: params.MainnetChainConfig,
+		Blocks:      []*Block{{Header: header}},
+		Witness:     &Witness{Ancestors: []*gethtypes.Header{parent}},
+	}
+
+	var pub PublicInputs
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"chainId": 1,
+		"blockNumber": "0xa",
+		"blockHash": "`+header.Hash().Hex()+`",
+		"preStateRoot": "`+parent.Root.Hex()+`",
+		"postStateRoot": "`+header.Root.Hex()+`"
+	}`), &pub))
+	mismatches, err := pi.CheckPublicInputs(&pub)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	// Only set fields are checked
+	var partial PublicInputs
+	require.NoError(t, json.Unmarshal([]byte(`{"blockNumber": "11", "preStateRoot": "`+header.Root.Hex()+`"}`), &partial))
+	mismatches, err = pi.CheckPublicInputs(&partial)
+	require.NoError(t, err)
+	assert.Equal(t, []*PublicInputMismatch{
+		{Field: "blockNumber", Proof: "11", Input: "10"},
+		{Field: "preStateRoot", Proof: header.Root.Hex(), Input: parent.Root.Hex()},
+	}, mismatches)
+
+	// The first witness ancestor must be the parent of the block
+	pi.Witness.Ancestors = []*gethtypes.Header{testHeader(8)}
+	_, err = pi.CheckPublicInputs(&PublicInputs{PreStateRoot: &parent.Root})
+	assert.ErrorContains(t, err, "is not the parent of the block")
+
+	assert.Error(t, json.Unmarshal([]byte(`{"chainId": "0xzz"}`), &pub))
+}
@@ -22,6 +22,9 @@ func NormalizeProverInput(input *ProverInput) *ProverInput {
 	normalized := &ProverInput{
 		ChainConfig: input.ChainConfig, // Assuming this is comparable as-is
 		Blocks:      input.Blocks,      // Assuming this is comparable as-is
+
+		AncestorHeaders: input.AncestorHeaders,
+		Labels:          input.Labels,
 	}
 
 	if input.Witness != nil {
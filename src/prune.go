@@ -0,0 +1,79 @@
+package src
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/kkrt-labs/go-utils/log"
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+	"go.uber.org/zap"
+)
+
+const (
+	preflightPruneInterval = 10 * time.Minute // Minimum interval between two prunings of the preflight data store
+	preflightPruneTimeout  = time.Minute
+)
+
+// preflightPruner prunes the preflight data store in the background, at most once every preflightPruneInterval
+type preflightPruner struct {
+	mu      sync.Mutex // Held while pruning
+	last    time.Time
+	running sync.WaitGroup
+}
+
+// wait waits for the running pruning, if any
+func (p *preflightPruner) wait() {
+	p.running.Wait()
+}
+
+// prunePreflightData opportunistically evicts stored preflight data following the pruning policy of the preflight data store
+// (see inputstore.PreflightDataStoreConfig): pruning runs in the background, is skipped if already running or run recently,
+// and failures are only logged, so it never blocks nor fails the pipeline.
+func (s *Service) prunePreflightData(ctx context.Context) {
+	cfg := &s.cfg.PreflightDataStore
+	if !cfg.PruneEnabled() || !s.pruner.mu.TryLock() {
+		return
+	}
+	if time.Since(s.pruner.last) < preflightPruneInterval {
+		s.pruner.mu.Unlock()
+		return
+	}
+	s.pruner.last = time.Now()
+
+	s.pruner.running.Add(1)
+	go func() {
+		defer s.pruner.running.Done()
+		defer s.pruner.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), preflightPruneTimeout)
+		defer cancel()
+
+		var finalized uint64
+		if cfg.PruneFinalized {
+			header, err := s.ethrpc.HeaderByNumber(ctx, big.NewInt(int64(gethrpc.FinalizedBlockNumber)))
+			if err != nil {
+				log.LoggerFromContext(ctx).Warn("Failed to fetch finalized block to prune preflight data", zap.Error(err))
+				if cfg.PruneMaxAge == 0 {
+					return
+				}
+			} else {
+				finalized = header.Number.Uint64()
+			}
+		}
+
+		dir := cfg.FileConfig.DataDir
+		if s.chainID != nil {
+			dir = strings.Replace(dir, "default", s.chainID.String(), 1)
+		}
+		removed, err := inputstore.PrunePreflightData(dir, finalized, cfg.PruneMaxAge)
+		if err != nil {
+			log.LoggerFromContext(ctx).Warn("Failed to prune preflight data", zap.String("dir", dir), zap.Error(err))
+		} else if removed > 0 {
+			log.LoggerFromContext(ctx).Info("Pruned preflight data", zap.String("dir", dir), zap.Uint64("finalized", finalized), zap.Int("count", removed))
+		}
+	}()
+}
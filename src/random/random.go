@@ -0,0 +1,81 @@
+package random
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Source is a seeded pseudo-random source, safe for concurrent use
+//
+// The pseudo-random choices of a run (the jitter of the block pacer and of the retry backoffs) all draw from the
+// Source of the service, so a run is reproduced by passing it the same seed.
+// A nil Source draws from the global unseeded source.
+type Source struct {
+	seed uint64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// New creates a new Source from a seed
+func New(seed uint64) *Source {
+	return &Source{seed: seed, rand: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// NewSeed returns a random seed
+func NewSeed() uint64 {
+	return rand.Uint64()
+}
+
+// Seed returns the seed of the source
+func (s *Source) Seed() uint64 {
+	return s.seed
+}
+
+// Float64 returns a pseudo-random number in [0.0, 1.0)
+func (s *Source) Float64() float64 {
+	if s == nil {
+		return rand.Float64()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Float64()
+}
+
+// Duration returns a pseudo-random duration in [0, d), d must be positive
+func (s *Source) Duration(d time.Duration) time.Duration {
+	if s == nil {
+		return rand.N(d)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.rand.Int64N(int64(d)))
+}
+
+// BackOff returns b with its intervals randomized by the source instead of the global source of the backoff package
+//
+// The intervals of b are randomized by +/- b.RandomizationFactor, as backoff.ExponentialBackOff does.
+func (s *Source) BackOff(b *backoff.ExponentialBackOff) backoff.BackOff {
+	factor := b.RandomizationFactor
+	b.RandomizationFactor = 0
+	return &jitterBackOff{BackOff: b, source: s, factor: factor}
+}
+
+type jitterBackOff struct {
+	backoff.BackOff
+
+	source *Source
+	factor float64
+}
+
+func (b *jitterBackOff) NextBackOff() time.Duration {
+	d := b.BackOff.NextBackOff()
+	if d == backoff.Stop || b.factor == 0 {
+		return d
+	}
+	delta := b.factor * float64(d)
+	return time.Duration(float64(d) - delta + b.source.Float64()*(2*delta+1))
+}
@@ -0,0 +1,47 @@
+package random
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	a, b := New(42), New(42)
+	assert.Equal(t, uint64(42), a.Seed())
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, a.Float64(), b.Float64())
+		assert.Equal(t, a.Duration(time.Second), b.Duration(time.Second))
+	}
+
+	other := New(43)
+	assert.NotEqual(t, New(42).Float64(), other.Float64())
+
+	var global *Source
+	assert.Less(t, global.Duration(time.Second), time.Second)
+}
+
+func TestBackOff(t *testing.T) {
+	intervals := func(seed uint64) []time.Duration {
+		b := New(seed).BackOff(backoff.NewExponentialBackOff(
+			backoff.WithInitialInterval(100*time.Millisecond),
+			backoff.WithMaxElapsedTime(0),
+		))
+		var out []time.Duration
+		for i := 0; i < 5; i++ {
+			out = append(out, b.NextBackOff())
+		}
+		return out
+	}
+
+	first := intervals(7)
+	require.Equal(t, first, intervals(7))
+	assert.NotEqual(t, first, intervals(8))
+
+	// Intervals are randomized by +/- 50% around the exponential intervals
+	assert.InDelta(t, 100*time.Millisecond, first[0], float64(50*time.Millisecond)+1)
+	assert.InDelta(t, 150*time.Millisecond, first[1], float64(75*time.Millisecond)+1)
+}
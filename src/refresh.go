@@ -0,0 +1,87 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/kkrt-labs/go-utils/log"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+	"go.uber.org/zap"
+)
+
+// RefreshMetadata rewrites the stored prover inputs of every block in the inclusive range [from, to] with up-to-date metadata,
+// without refetching any chain data.
+//
+// Every prover input is executed statelessly, so its final state is still verified against the state root of the block, then its
+// derived metadata (the EVM version) is recomputed and it is stored again in place with the current store configuration
+// (e.g. content type, number format). Failures are handled as in GenerateRange.
+func (s *Service) RefreshMetadata(ctx context.Context, from, to *big.Int) (*RunSummary, error) {
+	if from.Cmp(to) > 0 {
+		return nil, fmt.Errorf("invalid block range: %v > %v", from, to)
+	}
+	if s.chainID == nil {
+		return nil, fmt.Errorf("chain ID missing")
+	}
+
+	summary := &RunSummary{
+		From:      from.Uint64(),
+		To:        to.Uint64(),
+		Seed:      s.random.Seed(),
+		StartTime: time.Now(),
+	}
+	rpcCallsStart, bytesWrittenStart := s.rpcCalls.Calls(), s.bytesWritten.BytesWritten()
+
+	for n := from.Uint64(); n <= to.Uint64() && ctx.Err() == nil; n++ {
+		summary.Attempted++
+		if err := s.refreshMetadata(ctx, new(big.Int).SetUint64(n)); err != nil {
+			log.LoggerFromContext(ctx).Error("Failed to refresh prover input metadata", zap.Uint64("block.number", n), zap.Error(err))
+			summary.Failed = append(summary.Failed, &BlockFailure{BlockNumber: n, Error: err.Error()})
+			continue
+		}
+		summary.Succeeded++
+	}
+
+	for _, failure := range s.waitUploads() {
+		err := fmt.Errorf("failed to store provable inputs: %v", failure.Err)
+		log.LoggerFromContext(ctx).Error("Failed to refresh prover input metadata", zap.Uint64("block.number", failure.BlockNumber), zap.Error(err))
+		summary.Succeeded--
+		summary.Failed = append(summary.Failed, &BlockFailure{BlockNumber: failure.BlockNumber, Error: err.Error()})
+	}
+
+	summary.EndTime = time.Now()
+	summary.Duration = summary.EndTime.Sub(summary.StartTime).String()
+	summary.RPCCalls = s.rpcCalls.Calls() - rpcCallsStart
+	summary.BytesWritten = s.bytesWritten.BytesWritten() - bytesWrittenStart
+
+	if len(summary.Failed) > 0 {
+		return summary, fmt.Errorf("failed to refresh prover inputs for %d/%d blocks", len(summary.Failed), summary.Attempted)
+	}
+
+	if ctx.Err() != nil {
+		return summary, ctx.Err()
+	}
+
+	return summary, nil
+}
+
+func (s *Service) refreshMetadata(ctx context.Context, blockNumber *big.Int) error {
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return err
+	}
+	defer s.blocks.Release()
+
+	inputs, _, err := s.loadAndExecute(ctx, blockNumber, false) // Always verified before being re-stamped
+	if err != nil {
+		return err
+	}
+
+	inputs.EVMVersion = evm.Version
+
+	if err := s.ProverInputStore.StoreProverInput(ctx, inputs); err != nil {
+		return fmt.Errorf("failed to store provable inputs: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceRefreshMetadata(t *testing.T) {
+	to := gethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	chain := newTestChain(t, nil, nil, 4, func(_ int, b *core.BlockGen) { sendTestTx(t, testConfig, b, to, nil) })
+
+	cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+	s := newTestService(t, cfg)
+	ctx := context.Background()
+	for n := int64(2); n <= 3; n++ {
+		require.NoError(t, s.Generate(ctx, big.NewInt(n), nil))
+	}
+
+	// Block 2 was generated with an older EVM, the state root of block 3 was tampered with, block 4 was never generated
+	tamper := func(n uint64, update func(*input.ProverInput)) {
+		inputs, err := s.ProverInputStore.LoadProverInput(ctx, testChainID.Uint64(), n)
+		require.NoError(t, err)
+		update(inputs)
+		require.NoError(t, s.ProverInputStore.StoreProverInput(ctx, inputs))
+	}
+	tamper(2, func(inputs *input.ProverInput) { inputs.EVMVersion = "go-ethereum@v0.0.0" })
+	tamper(3, func(inputs *input.ProverInput) { inputs.Blocks[0].Header.Root = gethcommon.Hash{0xaa} })
+	inputsDir := filepath.Join(cfg.DataDir, testChainID.String(), "inputs")
+	before := readStoredFiles(t, inputsDir)
+
+	summary, err := s.RefreshMetadata(ctx, big.NewInt(2), big.NewInt(4))
+	require.EqualError(t, err, "failed to refresh prover inputs for 2/3 blocks")
+	assert.Equal(t, 3, summary.Attempted)
+	assert.Equal(t, 1, summary.Succeeded)
+	require.Len(t, summary.Failed, 2)
+	assert.Equal(t, uint64(3), summary.Failed[0].BlockNumber)
+	assert.Contains(t, summary.Failed[0].Error, "invalid merkle root", "prover inputs are verified before being refreshed")
+	assert.Equal(t, uint64(4), summary.Failed[1].BlockNumber)
+	assert.Contains(t, summary.Failed[1].Error, "failed to load provable inputs")
+	assert.Zero(t, summary.RPCCalls, "no chain data is refetched")
+
+	after := readStoredFiles(t, inputsDir)
+	assert.Len(t, after, 2, "no prover input is generated")
+	assert.Equal(t, before["3.json"], after["3.json"], "prover inputs failing verification are left untouched")
+
+	var refreshed, stale map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(after["2.json"]), &refreshed))
+	require.NoError(t, json.Unmarshal([]byte(before["2.json"]), &stale))
+	assert.Equal(t, evm.Version, refreshed["evmVersion"])
+	stale["evmVersion"] = evm.Version
+	assert.Equal(t, stale, refreshed, "only the metadata is refreshed")
+
+	_, err = s.RefreshMetadata(ctx, big.NewInt(3), big.NewInt(2))
+	assert.EqualError(t, err, "invalid block range: 3 > 2")
+}
@@ -0,0 +1,166 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+)
+
+// maxCacheEntries bounds the number of cached responses, expired entries are evicted first
+const maxCacheEntries = 256
+
+// Cache is a TTL cache of JSON-RPC responses for eth_chainId and eth_getBlockByNumber calls on explicit block numbers
+//
+// Blocks are cached by number, so cached blocks are dropped whenever a newly fetched block does not link with
+// its cached parent or child (i.e. a reorg is detected). Invalidate can also be called to drop every cached block.
+type Cache struct {
+	ttl time.Duration
+
+	mux     sync.Mutex
+	chainID *cacheEntry
+	blocks  map[string]*cacheEntry // Keyed by block number and params
+}
+
+type cacheEntry struct {
+	raw     json.RawMessage
+	expires time.Time
+
+	number     uint64
+	hash       gethcommon.Hash
+	parentHash gethcommon.Hash
+}
+
+// NewCache creates a new Cache with the given TTL
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, blocks: make(map[string]*cacheEntry)}
+}
+
+// Invalidate drops every cached block
+func (c *Cache) Invalidate() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.blocks = make(map[string]*cacheEntry)
+}
+
+// WithCache is a decorator that serves eth_chainId and eth_getBlockByNumber calls from the cache when possible
+// Block tags (latest, pending, safe, finalized...) are never cached.
+func WithCache(cache *Cache) jsonrpc.ClientDecorator {
+	return func(c jsonrpc.Client) jsonrpc.Client {
+		return jsonrpc.ClientFunc(func(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+			key, ok := cacheKey(req)
+			if !ok {
+				return c.Call(ctx, req, res)
+			}
+
+			if raw, ok := cache.get(req.Method, key); ok {
+				return json.Unmarshal(raw, res)
+			}
+
+			var raw json.RawMessage
+			if err := c.Call(ctx, req, &raw); err != nil {
+				return err
+			}
+			cache.set(req.Method, key, raw)
+
+			return json.Unmarshal(raw, res)
+		})
+	}
+}
+
+// cacheKey returns the key under which the response to req is cached, and false if req is not cacheable
+func cacheKey(req *jsonrpc.Request) (string, bool) {
+	switch req.Method {
+	case "eth_chainId":
+		return "", true
+	case "eth_getBlockByNumber":
+		params, ok := req.Params.([]interface{})
+		if !ok || len(params) == 0 {
+			return "", false
+		}
+		number, ok := params[0].(string)
+		if !ok || !strings.HasPrefix(number, "0x") {
+			return "", false
+		}
+		b, err := json.Marshal(params)
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+	return "", false
+}
+
+func (c *Cache) get(method, key string) (json.RawMessage, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	entry := c.chainID
+	if method != "eth_chainId" {
+		entry = c.blocks[key]
+	}
+	if entry == nil || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.raw, true
+}
+
+func (c *Cache) set(method, key string, raw json.RawMessage) {
+	entry := &cacheEntry{raw: raw, expires: time.Now().Add(c.ttl)}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if method == "eth_chainId" {
+		c.chainID = entry
+		return
+	}
+
+	var block struct {
+		Number     *hexutil.Uint64  `json:"number"`
+		Hash       *gethcommon.Hash `json:"hash"`
+		ParentHash gethcommon.Hash  `json:"parentHash"`
+	}
+	if err := json.Unmarshal(raw, &block); err != nil || block.Number == nil || block.Hash == nil {
+		return // Missing blocks are not cached
+	}
+	entry.number, entry.hash, entry.parentHash = uint64(*block.Number), *block.Hash, block.ParentHash
+
+	// Drop every cached block on reorg
+	for _, cached := range c.blocks {
+		if (cached.number+1 == entry.number && cached.hash != entry.parentHash) ||
+			(cached.number == entry.number+1 && cached.parentHash != entry.hash) ||
+			(cached.number == entry.number && cached.hash != entry.hash) {
+			c.blocks = make(map[string]*cacheEntry)
+			break
+		}
+	}
+
+	if len(c.blocks) >= maxCacheEntries {
+		c.evict()
+	}
+	c.blocks[key] = entry
+}
+
+// evict removes expired blocks, or the lowest cached block if none is expired
+func (c *Cache) evict() {
+	now := time.Now()
+	var lowest string
+	for key, entry := range c.blocks {
+		if now.After(entry.expires) {
+			delete(c.blocks, key)
+			continue
+		}
+		if lowest == "" || entry.number < c.blocks[lowest].number {
+			lowest = key
+		}
+	}
+	if len(c.blocks) >= maxCacheEntries {
+		delete(c.blocks, lowest)
+	}
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	calls := 0
+	parents := map[uint64]string{} // Parent hash served for each block number
+	client := WithCache(NewCache(time.Minute))(jsonrpc.ClientFunc(func(_ context.Context, req *jsonrpc.Request, res interface{}) error {
+		calls++
+		var raw string
+		switch req.Method {
+		case "eth_chainId":
+			raw = `"0x1"`
+		case "eth_getBlockByNumber":
+			n, err := hexutil.DecodeUint64(req.Params.([]interface{})[0].(string))
+			if err != nil {
+				n = 100 // latest
+			}
+			parent, ok := parents[n]
+			if !ok {
+				parent = fmt.Sprintf("0x%064x", n-1)
+			}
+			raw = fmt.Sprintf(`{"number":"%v","hash":"0x%064x","parentHash":"%v"}`, hexutil.Uint64(n), n, parent)
+		}
+		return json.Unmarshal([]byte(raw), res)
+	}))
+
+	call := func(method string, params ...interface{}) json.RawMessage {
+		var res json.RawMessage
+		require.NoError(t, client.Call(context.Background(), &jsonrpc.Request{Method: method, Params: params}, &res))
+		return res
+	}
+
+	assert.Equal(t, json.RawMessage(`"0x1"`), call("eth_chainId"))
+	call("eth_chainId")
+	assert.Equal(t, 1, calls)
+
+	call("eth_getBlockByNumber", "0xa", false)
+	call("eth_getBlockByNumber", "0xa", false)
+	call("eth_getBlockByNumber", "0xb", false)
+	assert.Equal(t, 3, calls)
+
+	// Block tags are never cached
+	call("eth_getBlockByNumber", "latest", false)
+	call("eth_getBlockByNumber", "latest", false)
+	assert.Equal(t, 5, calls)
+
+	// A block not linking with its cached parent invalidates the cache
+	parents[12] = fmt.Sprintf("0x%064x", 99)
+	call("eth_getBlockByNumber", "0xc", false)
+	call("eth_getBlockByNumber", "0xa", false)
+	assert.Equal(t, 7, calls)
+}
+
+func TestCacheExpiry(t *testing.T) {
+	calls := 0
+	client := WithCache(NewCache(time.Millisecond))(jsonrpc.ClientFunc(func(_ context.Context, _ *jsonrpc.Request, res interface{}) error {
+		calls++
+		return json.Unmarshal([]byte(`"0x1"`), res)
+	}))
+
+	var res string
+	require.NoError(t, client.Call(context.Background(), &jsonrpc.Request{Method: "eth_chainId"}, &res))
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, client.Call(context.Background(), &jsonrpc.Request{Method: "eth_chainId"}, &res))
+	assert.Equal(t, "0x1", res)
+	assert.Equal(t, 2, calls)
+}
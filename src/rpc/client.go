@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	jsonrpchttp "github.com/kkrt-labs/go-utils/jsonrpc/http"
+	jsonrpcws "github.com/kkrt-labs/go-utils/jsonrpc/websocket"
+	comhttp "github.com/kkrt-labs/go-utils/net/http"
+	comurl "github.com/kkrt-labs/go-utils/net/url"
+)
+
+// New creates a new client capable of connecting to a JSON-RPC server either over HTTP or WebSocket
+//
+// If a TLS configuration is provided, it is applied to both the https and wss transports.
+// Over HTTP, every request carries the configured User-Agent and the request ID set by WithRequestID, and response bodies
+// larger than Config.MaxResponseBytes fail the call.
+// Over WebSocket, the User-Agent is only sent when opening the connection.
+func New(cfg *Config) (jsonrpc.Client, error) {
+	u, err := comurl.Parse(cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsCfg *tls.Config
+	if !cfg.TLS.IsEmpty() {
+		if tlsCfg, err = LoadTLSConfig(cfg.TLS); err != nil {
+			return nil, fmt.Errorf("invalid RPC TLS configuration: %v", err)
+		}
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		if cfg.HTTP == nil || cfg.HTTP.HTTP == nil {
+			return nil, fmt.Errorf("HTTP configuration is required for HTTP connection")
+		}
+
+		transport, err := comhttp.NewTransport(cfg.HTTP.HTTP.Transport)
+		if err != nil {
+			return nil, err
+		}
+		if tlsCfg != nil {
+			transport.TLSClientConfig = tlsCfg
+		}
+
+		httpc := &http.Client{
+			Transport: &headerTransport{transport: &limitTransport{transport: transport, maxBytes: cfg.MaxResponseBytes}, userAgent: cfg.UserAgent},
+			Timeout:   cfg.HTTP.HTTP.Timeout.Duration,
+		}
+
+		return jsonrpchttp.NewClientFromClient(
+			autorest.Client{
+				Sender:           httpc,
+				RequestInspector: comhttp.WithBaseURL(u),
+			},
+		), nil
+	case "ws", "wss":
+		if cfg.WS == nil || cfg.WS.Client == nil {
+			return nil, fmt.Errorf("WebSocket configuration is required for WebSocket connection")
+		}
+
+		wsCfg := withUserAgent(cfg.WS, cfg.UserAgent)
+		if tlsCfg == nil {
+			wsc, err := jsonrpcws.NewClient(cfg.Addr, wsCfg)
+			if err != nil {
+				return nil, err
+			}
+			return wsc, nil
+		}
+		return newTLSWebsocketClient(cfg.Addr, wsCfg, tlsCfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme for connection: %s", u.Scheme)
+	}
+}
+
+// withUserAgent returns a copy of the WebSocket configuration sending the given User-Agent when opening the connection
+func withUserAgent(cfg *jsonrpcws.Config, userAgent string) *jsonrpcws.Config {
+	if userAgent == "" || cfg.Client.Dialer == nil {
+		return cfg
+	}
+
+	dialerCfg := *cfg.Client.Dialer
+	dialerCfg.Header = dialerCfg.Header.Clone()
+	if dialerCfg.Header == nil {
+		dialerCfg.Header = make(http.Header)
+	}
+	dialerCfg.Header.Set(userAgentHeader, userAgent)
+
+	clientCfg := *cfg.Client
+	clientCfg.Dialer = &dialerCfg
+
+	return &jsonrpcws.Config{Client: &clientCfg}
+}
@@ -0,0 +1,203 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/gorilla/websocket"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	jsonrpcmrgd "github.com/kkrt-labs/go-utils/jsonrpc/merged"
+	"github.com/kkrt-labs/go-utils/svc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCAFile(t *testing.T, srv *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+	return path
+}
+
+func chainIDResponse(t *testing.T, body []byte) []byte {
+	var msg jsonrpc.RequestMsg
+	require.NoError(t, json.Unmarshal(body, &msg))
+	resp, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      msg.ID,
+		"result":  "0x1",
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func callChainID(t *testing.T, cfg *Config) {
+	client, err := New(cfg.SetDefault())
+	require.NoError(t, err)
+
+	if runnable, ok := client.(svc.Runnable); ok {
+		require.NoError(t, runnable.Start(context.Background()))
+		defer runnable.Stop(context.Background()) //nolint:errcheck // test
+	}
+
+	var res string
+	err = client.Call(context.Background(), &jsonrpc.Request{Version: "2.0", ID: 1, Method: "eth_chainId", Params: []interface{}{}}, &res)
+	require.NoError(t, err)
+	assert.Equal(t, "0x1", res)
+}
+
+func TestClientHTTPSWithCustomCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(chainIDResponse(t, body))
+	}))
+	defer srv.Close()
+
+	callChainID(t, &Config{
+		Config: jsonrpcmrgd.Config{Addr: srv.URL},
+		TLS:    &TLSConfig{CAFile: writeCAFile(t, srv)},
+	})
+}
+
+func TestClientWSSWithCustomCA(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		for {
+			typ, body, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(typ, chainIDResponse(t, body))
+		}
+	}))
+	defer srv.Close()
+
+	callChainID(t, &Config{
+		Config: jsonrpcmrgd.Config{Addr: strings.Replace(srv.URL, "https", "wss", 1)},
+		TLS:    &TLSConfig{CAFile: writeCAFile(t, srv)},
+	})
+}
+
+func TestClientHTTPUserAgentAndRequestID(t *testing.T) {
+	var userAgent, requestID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent, requestID = r.Header.Get("User-Agent"), r.Header.Get(RequestIDHeader)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(chainIDResponse(t, body))
+	}))
+	defer srv.Close()
+
+	client, err := New((&Config{Config: jsonrpcmrgd.Config{Addr: srv.URL}, UserAgent: "zkpig/test"}).SetDefault())
+	require.NoError(t, err)
+	client = WithRequestID()(client)
+
+	var res string
+	err = client.Call(context.Background(), &jsonrpc.Request{Version: "2.0", ID: 1, Method: "eth_chainId", Params: []interface{}{}}, &res)
+	require.NoError(t, err)
+	assert.Equal(t, "zkpig/test", userAgent)
+	assert.Len(t, requestID, 32)
+	firstRequestID := requestID
+
+	err = client.Call(context.Background(), &jsonrpc.Request{Version: "2.0", ID: 2, Method: "eth_chainId", Params: []interface{}{}}, &res)
+	require.NoError(t, err)
+	assert.NotEqual(t, firstRequestID, requestID, "every call gets a new request ID")
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	_, err := LoadTLSConfig(&TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err, "missing CA file")
+
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	require.NoError(t, os.WriteFile(invalid, []byte("not a certificate"), 0o600))
+	_, err = LoadTLSConfig(&TLSConfig{CAFile: invalid})
+	assert.Error(t, err, "invalid CA file")
+
+	_, err = LoadTLSConfig(&TLSConfig{CertFile: "cert.pem"})
+	assert.Error(t, err, "client certificate without key")
+}
+
+func TestEthClientBlockWithUncles(t *testing.T) {
+	uncles := []*gethtypes.Header{
+		{Number: big.NewInt(9), Difficulty: big.NewInt(1), Coinbase: gethcommon.HexToAddress("0x2")},
+		{Number: big.NewInt(8), Difficulty: big.NewInt(1), Coinbase: gethcommon.HexToAddress("0x3")},
+	}
+	block := gethtypes.NewBlock(&gethtypes.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1)}, &gethtypes.Body{Uncles: uncles}, nil, trie.NewStackTrie(nil))
+
+	remote := jsonrpc.ClientFunc(func(_ context.Context, req *jsonrpc.Request, res interface{}) error {
+		var result interface{}
+		switch req.Method {
+		case "eth_getBlockByNumber":
+			result = new(ethrpc.Block).FromBlock(block, params.MainnetChainConfig)
+		case "eth_getUncleCountByBlockHash":
+			result = hexutil.Uint(len(uncles))
+		case "eth_getUncleByBlockHashAndIndex":
+			result = uncles[req.Params.([]interface{})[1].(hexutil.Uint)]
+		}
+		b, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(b, res)
+	})
+
+	got, err := NewEthClient(remote).BlockByNumber(context.Background(), big.NewInt(10))
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), got.Hash())
+	require.Len(t, got.Uncles(), 2)
+	assert.Equal(t, uncles[0].Hash(), got.Uncles()[0].Hash())
+	assert.Equal(t, uncles[1].Hash(), got.Uncles()[1].Hash())
+
+	// Incorrect uncles are rejected
+	uncles = uncles[:1]
+	_, err = NewEthClient(remote).BlockByNumber(context.Background(), big.NewInt(10))
+	assert.ErrorContains(t, err, "invalid uncles")
+}
+
+func TestEthClientFilterLogs(t *testing.T) {
+	addr := gethcommon.HexToAddress("0xaa")
+	remote := jsonrpc.ClientFunc(func(_ context.Context, req *jsonrpc.Request, res interface{}) error {
+		require.Equal(t, "eth_getLogs", req.Method)
+		arg := req.Params.([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "0xa", arg["fromBlock"])
+		assert.Equal(t, "0x14", arg["toBlock"])
+		assert.Equal(t, []gethcommon.Address{addr}, arg["address"])
+		b, err := json.Marshal([]*gethtypes.Log{{Address: addr, Topics: []gethcommon.Hash{}, BlockNumber: 12}})
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(b, res)
+	})
+
+	logs, err := NewEthClient(remote).FilterLogs(context.Background(), geth.FilterQuery{
+		FromBlock: big.NewInt(10),
+		ToBlock:   big.NewInt(20),
+		Addresses: []gethcommon.Address{addr},
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, uint64(12), logs[0].BlockNumber)
+	assert.Equal(t, addr, logs[0].Address)
+}
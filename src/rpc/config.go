@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"time"
+
+	jsonrpcmrgd "github.com/kkrt-labs/go-utils/jsonrpc/merged"
+)
+
+// DefaultCallTimeout is the default timeout of a JSON-RPC call attempt
+const DefaultCallTimeout = 30 * time.Second
+
+// Config is the configuration for the JSON-RPC client used to connect to the chain node.
+type Config struct {
+	jsonrpcmrgd.Config
+
+	// TLS is an optional TLS configuration applied to https and wss connections
+	TLS *TLSConfig `json:"tls,omitempty"`
+
+	// UserAgent is the User-Agent header sent to the server
+	UserAgent string `json:"userAgent,omitempty"`
+
+	// CacheTTL is the duration during which eth_chainId and eth_getBlockByNumber responses are cached (see Cache)
+	// Caching is disabled if zero.
+	CacheTTL time.Duration `json:"cacheTTL,omitempty"`
+
+	// SlowLogThreshold is the duration above which a JSON-RPC call is logged at warn level (see WithSlowLog)
+	// Slow call logging is disabled if zero.
+	SlowLogThreshold time.Duration `json:"slowLogThreshold,omitempty"`
+
+	// CallTimeout is the timeout of every JSON-RPC call attempt, an attempt timing out is retried (see WithRetry)
+	// Defaults to DefaultCallTimeout.
+	CallTimeout time.Duration `json:"callTimeout,omitempty"`
+
+	// ProofChunkSize is the maximum number of storage keys per eth_getProof call, larger calls are split (see ProofChunkingClient)
+	// Splitting is disabled if zero.
+	ProofChunkSize int `json:"proofChunkSize,omitempty"`
+
+	// HeaderCacheSize is the number of the last fetched block headers kept to serve header lookups (see HeaderCachingClient)
+	// Caching is disabled if zero.
+	HeaderCacheSize int `json:"headerCacheSize,omitempty"`
+
+	// MissingTrieNodeRetries is the maximum number of retries of a JSON-RPC call failing with a transient missing trie node
+	// (see WithRetry), such calls are not retried if zero.
+	MissingTrieNodeRetries int `json:"missingTrieNodeRetries,omitempty"`
+
+	// MaxResponseBytes is the maximum size of a response body read from the server over HTTP, a larger response fails
+	// the call without being retried (see IsResponseTooLarge). Defaults to DefaultMaxResponseBytes.
+	MaxResponseBytes int64 `json:"maxResponseBytes,omitempty"`
+}
+
+// TLSConfig is a TLS configuration for connecting to a JSON-RPC server.
+type TLSConfig struct {
+	// CAFile is an optional path to a PEM encoded CA certificate bundle used to verify the server certificate.
+	// Certificates from the bundle are trusted in addition to the system certificate pool.
+	CAFile string `json:"caFile,omitempty"`
+
+	// CertFile and KeyFile are optional paths to a PEM encoded client certificate and private key
+	// presented to the server (mTLS). Both must be set together.
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+}
+
+// IsEmpty returns true if no TLS option is set.
+func (cfg *TLSConfig) IsEmpty() bool {
+	return cfg == nil || (cfg.CAFile == "" && cfg.CertFile == "" && cfg.KeyFile == "")
+}
+
+// SetDefault sets the default values for the configuration
+func (cfg *Config) SetDefault() *Config {
+	cfg.Config.SetDefault()
+	if cfg.CallTimeout == 0 {
+		cfg.CallTimeout = DefaultCallTimeout
+	}
+	if cfg.MaxResponseBytes == 0 {
+		cfg.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	return cfg
+}
@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+)
+
+// CallCounter counts JSON-RPC calls going through a client, in total and per method
+type CallCounter struct {
+	calls atomic.Uint64
+
+	mux      sync.Mutex
+	byMethod map[string]uint64
+}
+
+// NewCallCounter creates a new CallCounter
+func NewCallCounter() *CallCounter {
+	return &CallCounter{byMethod: make(map[string]uint64)}
+}
+
+// Calls returns the number of calls counted so far
+func (c *CallCounter) Calls() uint64 {
+	return c.calls.Load()
+}
+
+// CallsByMethod returns a copy of the number of calls counted so far per method
+func (c *CallCounter) CallsByMethod() map[string]uint64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	byMethod := make(map[string]uint64, len(c.byMethod))
+	for method, calls := range c.byMethod {
+		byMethod[method] = calls
+	}
+	return byMethod
+}
+
+func (c *CallCounter) add(method string) {
+	c.calls.Add(1)
+
+	c.mux.Lock()
+	c.byMethod[method]++
+	c.mux.Unlock()
+}
+
+// WithCallCounter is a decorator that counts every JSON-RPC call
+// When placed below the retry decorator, every retry attempt is counted
+func WithCallCounter(counter *CallCounter) jsonrpc.ClientDecorator {
+	return func(c jsonrpc.Client) jsonrpc.Client {
+		return jsonrpc.ClientFunc(func(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+			counter.add(req.Method)
+			return c.Call(ctx, req, res)
+		})
+	}
+}
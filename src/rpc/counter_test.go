@@ -0,0 +1,24 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallCounter(t *testing.T) {
+	counter := NewCallCounter()
+	client := WithCallCounter(counter)(jsonrpc.ClientFunc(func(_ context.Context, _ *jsonrpc.Request, _ interface{}) error {
+		return nil
+	}))
+
+	for _, method := range []string{"eth_getProof", "eth_chainId", "eth_getProof"} {
+		require.NoError(t, client.Call(context.Background(), &jsonrpc.Request{Method: method}, nil))
+	}
+
+	assert.Equal(t, uint64(3), counter.Calls())
+	assert.Equal(t, map[string]uint64{"eth_getProof": 2, "eth_chainId": 1}, counter.CallsByMethod())
+}
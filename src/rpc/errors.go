@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// stateUnavailablePatterns match the errors of nodes which do not keep the requested state (e.g. a non-archive node
+// asked for the state of an old block), for which retrying on the same node does not help
+var stateUnavailablePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`historical state (?:0x)?[0-9a-fA-F]* ?is not available`),      // geth, path scheme
+	regexp.MustCompile(`required historical state unavailable`),                       // geth, hash scheme
+	regexp.MustCompile(`(?i)state (?:is )?not available|state history not available`), // other clients
+}
+
+// IsStateUnavailable returns true if err is a permanent error of a node which does not keep the requested state
+func IsStateUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, pattern := range stateUnavailablePatterns {
+		if pattern.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMissingTrieNode returns true if err is a "missing trie node" error
+//
+// Nodes return it transiently when the trie nodes of the requested state are being pruned (e.g. under GC pressure),
+// so the call often succeeds when retried, in particular when served by another node behind a load balancer.
+func IsMissingTrieNode(err error) bool {
+	return err != nil && !IsStateUnavailable(err) && strings.Contains(err.Error(), "missing trie node")
+}
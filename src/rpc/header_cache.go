@@ -0,0 +1,115 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+)
+
+// DefaultHeaderCacheSize is the default number of block headers kept by HeaderCachingClient
+const DefaultHeaderCacheSize = 256
+
+// HeaderCachingClient is an Ethereum client keeping the last fetched block headers, to serve eth_getBlockByHash header
+// lookups without hitting the node
+//
+// In range mode, the parent header of block N+1 (and the ancestors accessed with BLOCKHASH) are the headers fetched
+// for the previous blocks, so consecutive blocks do not fetch the same headers twice. Headers of blocks which were not
+// fetched before (e.g. non-contiguous block lists) are fetched from the node. Headers are immutable for a given hash,
+// so cached headers never expire, only the oldest headers are evicted once size headers are cached.
+type HeaderCachingClient struct {
+	ethrpc.Client
+
+	mux     sync.Mutex
+	size    int
+	headers map[gethcommon.Hash]*gethtypes.Header
+	order   []gethcommon.Hash // Ring buffer of the cached hashes, in insertion order
+	next    int               // Index of order where the next hash is inserted
+}
+
+// NewHeaderCachingClient creates a new HeaderCachingClient keeping at most size headers
+func NewHeaderCachingClient(client ethrpc.Client, size int) *HeaderCachingClient {
+	return &HeaderCachingClient{
+		Client:  client,
+		size:    size,
+		headers: make(map[gethcommon.Hash]*gethtypes.Header, size),
+		order:   make([]gethcommon.Hash, 0, size),
+	}
+}
+
+// HeaderByHash returns the header with the given hash, from the cache if it was fetched before
+func (c *HeaderCachingClient) HeaderByHash(ctx context.Context, hash gethcommon.Hash) (*gethtypes.Header, error) {
+	if header := c.get(hash); header != nil {
+		return header, nil
+	}
+	header, err := c.Client.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.add(header)
+	return header, nil
+}
+
+// HeaderByNumber returns the header with the given number, and caches it
+func (c *HeaderCachingClient) HeaderByNumber(ctx context.Context, number *big.Int) (*gethtypes.Header, error) {
+	header, err := c.Client.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	c.add(header)
+	return header, nil
+}
+
+// BlockByNumber returns the block with the given number, and caches its header
+func (c *HeaderCachingClient) BlockByNumber(ctx context.Context, number *big.Int) (*gethtypes.Block, error) {
+	block, err := c.Client.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	c.add(block.Header())
+	return block, nil
+}
+
+// BlockByHash returns the block with the given hash, and caches its header
+func (c *HeaderCachingClient) BlockByHash(ctx context.Context, hash gethcommon.Hash) (*gethtypes.Block, error) {
+	block, err := c.Client.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.add(block.Header())
+	return block, nil
+}
+
+func (c *HeaderCachingClient) get(hash gethcommon.Hash) *gethtypes.Header {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if header, ok := c.headers[hash]; ok {
+		return gethtypes.CopyHeader(header)
+	}
+	return nil
+}
+
+func (c *HeaderCachingClient) add(header *gethtypes.Header) {
+	if header == nil || c.size <= 0 {
+		return
+	}
+	hash := header.Hash()
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if _, ok := c.headers[hash]; ok {
+		return
+	}
+
+	if len(c.order) < c.size {
+		c.order = append(c.order, hash)
+	} else {
+		delete(c.headers, c.order[c.next])
+		c.order[c.next] = hash
+	}
+	c.next = (c.next + 1) % c.size
+	c.headers[hash] = gethtypes.CopyHeader(header)
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerClient serves a chain of headers and records the number of calls of every method
+type headerClient struct {
+	ethrpc.Client
+
+	headers []*gethtypes.Header
+	calls   map[string]int
+}
+
+func newHeaderClient(n int) *headerClient {
+	c := &headerClient{calls: make(map[string]int)}
+	for i := 0; i < n; i++ {
+		header := &gethtypes.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(0)}
+		if i > 0 {
+			header.ParentHash = c.headers[i-1].Hash()
+		}
+		c.headers = append(c.headers, header)
+	}
+	return c
+}
+
+func (c *headerClient) BlockByNumber(_ context.Context, number *big.Int) (*gethtypes.Block, error) {
+	c.calls["BlockByNumber"]++
+	return gethtypes.NewBlockWithHeader(c.headers[number.Int64()]), nil
+}
+
+func (c *headerClient) HeaderByHash(_ context.Context, hash gethcommon.Hash) (*gethtypes.Header, error) {
+	c.calls["HeaderByHash"]++
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header, nil
+		}
+	}
+	return nil, fmt.Errorf("not found")
+}
+
+func TestHeaderCachingClient(t *testing.T) {
+	remote := newHeaderClient(10)
+	client := NewHeaderCachingClient(remote, 2)
+
+	// The parent header of a block is served from the cache once the previous block was fetched
+	_, err := client.BlockByNumber(context.Background(), big.NewInt(5))
+	require.NoError(t, err)
+	block, err := client.BlockByNumber(context.Background(), big.NewInt(6))
+	require.NoError(t, err)
+	parent, err := client.HeaderByHash(context.Background(), block.ParentHash())
+	require.NoError(t, err)
+	assert.Equal(t, remote.headers[5].Hash(), parent.Hash())
+	assert.Equal(t, 0, remote.calls["HeaderByHash"])
+
+	// Cached headers are copies
+	parent.Number = big.NewInt(42)
+	parent, err = client.HeaderByHash(context.Background(), block.ParentHash())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), parent.Number.Int64())
+
+	// Headers which were not fetched before are fetched from the node, then cached
+	for i := 0; i < 2; i++ {
+		_, err = client.HeaderByHash(context.Background(), remote.headers[2].Hash())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, remote.calls["HeaderByHash"])
+
+	// The oldest headers are evicted
+	_, err = client.HeaderByHash(context.Background(), remote.headers[5].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, 2, remote.calls["HeaderByHash"])
+
+	// Errors are not cached
+	_, err = client.HeaderByHash(context.Background(), gethcommon.Hash{})
+	require.Error(t, err)
+}
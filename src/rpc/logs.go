@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethjsonrpc "github.com/kkrt-labs/go-utils/ethereum/rpc/jsonrpc"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+)
+
+// FilterLogs executes a filter query with eth_getLogs
+//
+// The underlying go-utils client decodes the response into a non-pointer value, so it always fails.
+func (c *EthClient) FilterLogs(ctx context.Context, q geth.FilterQuery) ([]gethtypes.Log, error) {
+	arg := map[string]interface{}{
+		"address": q.Addresses,
+		"topics":  q.Topics,
+	}
+	if q.BlockHash != nil {
+		if q.FromBlock != nil || q.ToBlock != nil {
+			return nil, fmt.Errorf("cannot specify both BlockHash and FromBlock/ToBlock")
+		}
+		arg["blockHash"] = *q.BlockHash
+	} else {
+		arg["fromBlock"] = "0x0"
+		if q.FromBlock != nil {
+			arg["fromBlock"] = ethjsonrpc.ToBlockNumArg(q.FromBlock)
+		}
+		arg["toBlock"] = ethjsonrpc.ToBlockNumArg(q.ToBlock)
+	}
+
+	var logs []gethtypes.Log
+	if err := c.remote.Call(ctx, &jsonrpc.Request{Method: "eth_getLogs", Params: []interface{}{arg}}, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
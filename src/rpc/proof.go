@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+)
+
+// ProofChunkingClient is an Ethereum client splitting eth_getProof calls with many storage keys into several calls
+//
+// A single eth_getProof with thousands of storage keys can exceed the request or response size limits of providers.
+// The proofs of every chunk of keys are merged into the result of a single call: the account fields are the same in
+// every chunk and the storage proofs are in the order of the keys.
+type ProofChunkingClient struct {
+	ethrpc.Client
+
+	chunkSize int
+}
+
+// NewProofChunkingClient creates a new ProofChunkingClient requesting at most chunkSize storage keys per eth_getProof call
+func NewProofChunkingClient(client ethrpc.Client, chunkSize int) *ProofChunkingClient {
+	return &ProofChunkingClient{Client: client, chunkSize: chunkSize}
+}
+
+// GetProof returns the proof of the account and of the storage keys
+func (c *ProofChunkingClient) GetProof(ctx context.Context, account gethcommon.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	if c.chunkSize <= 0 || len(keys) <= c.chunkSize {
+		return c.Client.GetProof(ctx, account, keys, blockNumber)
+	}
+
+	var merged *gethclient.AccountResult
+	for start := 0; start < len(keys); start += c.chunkSize {
+		end := min(start+c.chunkSize, len(keys))
+		res, err := c.Client.GetProof(ctx, account, keys[start:end], blockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get proof of storage keys %d-%d: %v", start, end-1, err)
+		}
+		if res == nil {
+			return nil, nil
+		}
+
+		if merged == nil {
+			merged = res
+			merged.StorageProof = append(make([]gethclient.StorageResult, 0, len(keys)), res.StorageProof...)
+			continue
+		}
+		if res.StorageHash != merged.StorageHash || res.CodeHash != merged.CodeHash || res.Nonce != merged.Nonce || res.Balance.Cmp(merged.Balance) != 0 {
+			return nil, fmt.Errorf("inconsistent proofs of account %v between storage key chunks (storage hash %v != %v)", account.Hex(), res.StorageHash.Hex(), merged.StorageHash.Hex())
+		}
+		merged.StorageProof = append(merged.StorageProof, res.StorageProof...)
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// proofClient returns deterministic proofs and records the number of keys of every eth_getProof call
+type proofClient struct {
+	ethrpc.Client
+
+	calls       []int
+	storageHash gethcommon.Hash
+}
+
+func (c *proofClient) GetProof(_ context.Context, account gethcommon.Address, keys []string, _ *big.Int) (*gethclient.AccountResult, error) {
+	c.calls = append(c.calls, len(keys))
+	res := &gethclient.AccountResult{
+		Address:      account,
+		AccountProof: []string{"0xaa", "0xbb"},
+		Balance:      big.NewInt(1),
+		Nonce:        2,
+		StorageHash:  c.storageHash,
+	}
+	for _, key := range keys {
+		res.StorageProof = append(res.StorageProof, gethclient.StorageResult{Key: key, Value: big.NewInt(3), Proof: []string{key}})
+	}
+	return res, nil
+}
+
+func TestProofChunkingClient(t *testing.T) {
+	account := gethcommon.HexToAddress("0x1")
+	keys := make([]string, 2500)
+	for i := range keys {
+		keys[i] = gethcommon.BigToHash(big.NewInt(int64(i))).Hex()
+	}
+
+	base := &proofClient{}
+	expected, err := base.GetProof(context.Background(), account, keys, big.NewInt(10))
+	require.NoError(t, err)
+
+	base.calls = nil
+	merged, err := NewProofChunkingClient(base, 1000).GetProof(context.Background(), account, keys, big.NewInt(10))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1000, 1000, 500}, base.calls)
+	assert.Equal(t, expected, merged)
+
+	// Small key sets and disabled splitting use a single call
+	for _, tc := range []struct {
+		chunkSize int
+		keys      []string
+	}{{1000, keys[:1000]}, {1000, nil}, {0, keys}} {
+		base.calls = nil
+		_, err := NewProofChunkingClient(base, tc.chunkSize).GetProof(context.Background(), account, tc.keys, big.NewInt(10))
+		require.NoError(t, err)
+		assert.Equal(t, []int{len(tc.keys)}, base.calls, fmt.Sprintf("chunk size %d", tc.chunkSize))
+	}
+}
+
+func TestProofChunkingClientInconsistent(t *testing.T) {
+	base := &proofClient{}
+	client := NewProofChunkingClient(&inconsistentProofClient{base}, 1)
+	_, err := client.GetProof(context.Background(), gethcommon.Address{}, []string{"0x01", "0x02"}, big.NewInt(10))
+	assert.ErrorContains(t, err, "inconsistent proofs")
+}
+
+// inconsistentProofClient changes the storage hash of the account on every call
+type inconsistentProofClient struct {
+	*proofClient
+}
+
+func (c *inconsistentProofClient) GetProof(ctx context.Context, account gethcommon.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	c.storageHash[0]++
+	return c.proofClient.GetProof(ctx, account, keys, blockNumber)
+}
@@ -0,0 +1,177 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+)
+
+// RecordedCall is a JSON-RPC call and its outcome, as written by a Recorder (one JSON object per line)
+type RecordedCall struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"` // Set if the call failed
+}
+
+// Recorder records JSON-RPC calls and their responses to a JSON lines file, to replay them later (see Replayer)
+type Recorder struct {
+	mux  sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+// NewRecorder creates a new Recorder writing to the file at path, truncating it if it exists
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RPC recording: %v", err)
+	}
+	return &Recorder{file: f, w: bufio.NewWriter(f)}, nil
+}
+
+// Close flushes the recorded calls and closes the recording
+func (r *Recorder) Close() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if err := r.w.Flush(); err != nil {
+		r.file.Close()
+		return fmt.Errorf("failed to write RPC recording: %v", err)
+	}
+	return r.file.Close()
+}
+
+func (r *Recorder) record(call *RecordedCall) error {
+	b, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if _, err := r.w.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write RPC recording: %v", err)
+	}
+	return nil
+}
+
+// WithRecorder is a decorator that records every JSON-RPC call and its response
+// When placed above the retry decorator, only the final outcome of every call is recorded. Calls interrupted
+// because ctx is done are not recorded.
+func WithRecorder(rec *Recorder) jsonrpc.ClientDecorator {
+	return func(c jsonrpc.Client) jsonrpc.Client {
+		return jsonrpc.ClientFunc(func(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+			params, err := marshalParams(req)
+			if err != nil {
+				return err
+			}
+
+			var raw json.RawMessage
+			callErr := c.Call(ctx, req, &raw)
+			if ctx.Err() == nil {
+				call := &RecordedCall{Method: req.Method, Params: params, Result: raw}
+				if callErr != nil {
+					call.Result, call.Error = nil, callErr.Error()
+				}
+				if err := rec.record(call); err != nil {
+					return err
+				}
+			}
+			if callErr != nil {
+				return callErr
+			}
+
+			return json.Unmarshal(raw, res)
+		})
+	}
+}
+
+// Replayer is a JSON-RPC client serving calls from a recording (see Recorder), without any network access
+//
+// Calls are matched by method and params. Identical calls are served their recorded responses in the recorded order,
+// the last one being served again once they are exhausted. Calls missing from the recording fail.
+type Replayer struct {
+	mux   sync.Mutex
+	calls map[string][]*RecordedCall
+}
+
+// NewReplayer creates a new Replayer from the recording at path
+func NewReplayer(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RPC recording: %v", err)
+	}
+	defer f.Close()
+
+	r, err := ReadReplayer(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RPC recording %v: %v", path, err)
+	}
+	return r, nil
+}
+
+// ReadReplayer creates a new Replayer from a recording read from rd
+func ReadReplayer(rd io.Reader) (*Replayer, error) {
+	r := &Replayer{calls: make(map[string][]*RecordedCall)}
+	dec := json.NewDecoder(rd)
+	for {
+		call := new(RecordedCall)
+		if err := dec.Decode(call); errors.Is(err, io.EOF) {
+			return r, nil
+		} else if err != nil {
+			return nil, err
+		}
+		key := replayKey(call.Method, call.Params)
+		r.calls[key] = append(r.calls[key], call)
+	}
+}
+
+// Call serves a call from the recording
+func (r *Replayer) Call(_ context.Context, req *jsonrpc.Request, res interface{}) error {
+	params, err := marshalParams(req)
+	if err != nil {
+		return err
+	}
+
+	key := replayKey(req.Method, params)
+	r.mux.Lock()
+	calls := r.calls[key]
+	if len(calls) == 0 {
+		r.mux.Unlock()
+		return fmt.Errorf("no recorded response for %v call with params %s", req.Method, params)
+	}
+	call := calls[0]
+	if len(calls) > 1 {
+		r.calls[key] = calls[1:]
+	}
+	r.mux.Unlock()
+
+	if call.Error != "" {
+		return errors.New(call.Error)
+	}
+	return json.Unmarshal(call.Result, res)
+}
+
+// marshalParams returns the compact JSON encoding of the params of req, used to match recorded calls
+func marshalParams(req *jsonrpc.Request) (json.RawMessage, error) {
+	if req.Params == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %v params: %v", req.Method, err)
+	}
+	return b, nil
+}
+
+func replayKey(method string, params json.RawMessage) string {
+	return method + string(params)
+}
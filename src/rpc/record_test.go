@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rpc.jsonl")
+	rec, err := NewRecorder(path)
+	require.NoError(t, err)
+
+	nonce := 0
+	client := WithRecorder(rec)(jsonrpc.ClientFunc(func(_ context.Context, req *jsonrpc.Request, res interface{}) error {
+		switch req.Method {
+		case "eth_chainId":
+			return json.Unmarshal([]byte(`"0x1"`), res)
+		case "eth_getTransactionCount":
+			nonce++ // Responses to identical calls differ
+			return json.Unmarshal([]byte(fmt.Sprintf(`"0x%x"`, nonce)), res)
+		case "eth_getBlockByNumber":
+			return json.Unmarshal([]byte(`null`), res)
+		}
+		return errors.New("method not found")
+	}))
+
+	call := func(c jsonrpc.Client, method string, params ...interface{}) (string, error) {
+		var res json.RawMessage
+		err := c.Call(context.Background(), &jsonrpc.Request{Method: method, Params: params}, &res)
+		return string(res), err
+	}
+
+	var live []string
+	for _, method := range []string{"eth_chainId", "eth_getTransactionCount", "eth_getTransactionCount", "eth_getBlockByNumber"} {
+		res, err := call(client, method, "0xa")
+		require.NoError(t, err)
+		live = append(live, res)
+	}
+	_, err = call(client, "debug_traceBlock", "0xa")
+	require.Error(t, err)
+	require.NoError(t, rec.Close())
+
+	replayer, err := NewReplayer(path)
+	require.NoError(t, err)
+
+	var replayed []string
+	for _, method := range []string{"eth_chainId", "eth_getTransactionCount", "eth_getTransactionCount", "eth_getBlockByNumber"} {
+		res, err := call(replayer, method, "0xa")
+		require.NoError(t, err)
+		replayed = append(replayed, res)
+	}
+	assert.Equal(t, live, replayed)
+	assert.Equal(t, []string{`"0x1"`, `"0x1"`, `"0x2"`, `null`}, replayed)
+
+	// The last recorded response is served again once exhausted
+	res, err := call(replayer, "eth_getTransactionCount", "0xa")
+	require.NoError(t, err)
+	assert.Equal(t, `"0x2"`, res)
+
+	// Recorded errors are replayed
+	_, err = call(replayer, "debug_traceBlock", "0xa")
+	require.EqualError(t, err, "method not found")
+
+	// Calls with other params were not recorded
+	_, err = call(replayer, "eth_chainId", "0xb")
+	require.EqualError(t, err, `no recorded response for eth_chainId call with params ["0xb"]`)
+}
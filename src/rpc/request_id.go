@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/kkrt-labs/go-utils/tag"
+)
+
+const (
+	// RequestIDHeader is the HTTP header carrying the request ID
+	RequestIDHeader = "X-Request-Id"
+	userAgentHeader = "User-Agent"
+)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the given request ID
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, if any
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithRequestID is a decorator that generates a random ID for every JSON-RPC call
+//
+// The ID is sent in the X-Request-Id header (over HTTP only) and attached to the context tags as req.request_id,
+// so it can be correlated with the provider logs. It is only logged by the decorators placed below this one, so it must
+// be placed above jsonrpc.WithLog for failed calls to be logged with their ID.
+// When placed below the retry decorator, every retry attempt gets a new ID.
+func WithRequestID() jsonrpc.ClientDecorator {
+	return func(c jsonrpc.Client) jsonrpc.Client {
+		return jsonrpc.ClientFunc(func(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+			id := newRequestID()
+			ctx = ContextWithRequestID(ctx, id)
+			ctx = tag.WithTags(ctx, tag.Key("req.request_id").String(id))
+			return c.Call(ctx, req, res)
+		})
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b) // never returns an error
+	return hex.EncodeToString(b)
+}
+
+// headerTransport is an http.RoundTripper setting the User-Agent and request ID headers on outgoing requests
+type headerTransport struct {
+	transport http.RoundTripper
+	userAgent string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := RequestIDFromContext(req.Context())
+	if t.userAgent == "" && id == "" {
+		return t.transport.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context()) // RoundTrippers must not modify the request
+	if t.userAgent != "" {
+		req.Header.Set(userAgentHeader, t.userAgent)
+	}
+	if id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	return t.transport.RoundTrip(req)
+}
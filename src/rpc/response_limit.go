@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxResponseBytes is the default maximum size of a JSON-RPC response body read over HTTP
+//
+// It is large enough for the eth_getProof responses of accounts with thousands of storage slots, and for full blocks.
+const DefaultMaxResponseBytes int64 = 512 << 20 // 512 MiB
+
+// responseTooLargeMsg starts the error of a JSON-RPC response body exceeding the maximum size (see IsResponseTooLarge)
+const responseTooLargeMsg = "JSON-RPC response body exceeds the maximum size"
+
+// IsResponseTooLarge returns true if err is the error of a JSON-RPC response body exceeding Config.MaxResponseBytes
+//
+// The error may be wrapped without %w by the JSON-RPC client decoding the response, so it is matched on its message.
+func IsResponseTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), responseTooLargeMsg)
+}
+
+func responseTooLargeError(maxBytes int64) error {
+	return fmt.Errorf("%s of %d bytes", responseTooLargeMsg, maxBytes)
+}
+
+// limitTransport is an http.RoundTripper failing the responses whose body is larger than maxBytes
+//
+// A response announcing a larger Content-Length fails before its body is read, other bodies fail once maxBytes are read,
+// so a malicious or buggy server can not make the client buffer an unbounded response.
+type limitTransport struct {
+	transport http.RoundTripper
+	maxBytes  int64
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || t.maxBytes <= 0 {
+		return resp, err
+	}
+
+	if resp.ContentLength > t.maxBytes {
+		resp.Body.Close()
+		return nil, responseTooLargeError(t.maxBytes)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxBytes, maxBytes: t.maxBytes}
+	return resp, nil
+}
+
+// limitedBody is a response body failing once more than maxBytes are read
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	maxBytes  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, responseTooLargeError(b.maxBytes)
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1] // One more byte than allowed is enough to detect an oversized body
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n, b.remaining = int(b.remaining), -1
+		return n, responseTooLargeError(b.maxBytes)
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	jsonrpcmrgd "github.com/kkrt-labs/go-utils/jsonrpc/merged"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientMaxResponseBytes(t *testing.T) {
+	chunked := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		resp := chainIDResponse(t, body)
+		if chunked {
+			resp = []byte(strings.Replace(string(resp), `"0x1"`, `"0x`+strings.Repeat("0", 1024)+`1"`, 1))
+			w.(http.Flusher).Flush() // No Content-Length
+		}
+		_, _ = w.Write(resp)
+	}))
+	defer srv.Close()
+
+	cfg := (&Config{Config: jsonrpcmrgd.Config{Addr: srv.URL}}).SetDefault()
+	assert.Equal(t, DefaultMaxResponseBytes, cfg.MaxResponseBytes)
+	cfg.MaxResponseBytes = 512
+	callChainID(t, cfg)
+
+	client, err := New(cfg)
+	require.NoError(t, err)
+	call := func(client jsonrpc.Client) error {
+		var res string
+		return client.Call(context.Background(), &jsonrpc.Request{Version: "2.0", ID: 1, Method: "eth_chainId", Params: []interface{}{}}, &res)
+	}
+
+	t.Run("Content-Length", func(t *testing.T) {
+		cfg := *cfg
+		cfg.MaxResponseBytes = 16
+		client, err := New(&cfg)
+		require.NoError(t, err)
+		err = call(client)
+		assert.True(t, IsResponseTooLarge(err), "unexpected error: %v", err)
+	})
+
+	t.Run("body", func(t *testing.T) {
+		chunked = true
+		defer func() { chunked = false }()
+		err := call(client)
+		assert.True(t, IsResponseTooLarge(err), "unexpected error: %v", err)
+		assert.ErrorContains(t, err, "maximum size of 512 bytes")
+	})
+
+	t.Run("not retried", func(t *testing.T) {
+		chunked = true
+		defer func() { chunked = false }()
+		var attempts atomic.Int32
+		retried := WithRetry(0, 0, nil)(jsonrpc.ClientFunc(func(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+			attempts.Add(1)
+			return client.Call(ctx, req, res)
+		}))
+		err := call(retried)
+		assert.True(t, IsResponseTooLarge(err), "unexpected error: %v", err)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+}
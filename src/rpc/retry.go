@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/kkrt-labs/go-utils/log"
+	"github.com/kkrt-labs/zk-pig/src/random"
+	"go.uber.org/zap"
+)
+
+const (
+	retryInitialInterval = 50 * time.Millisecond // Delay before the first retry
+	retryMaxElapsedTime  = 2 * time.Second       // Retry budget of calls failing fast, as jsonrpc.WithRetry
+)
+
+// WithRetry is a decorator that retries failed JSON-RPC calls with an exponential backoff, as jsonrpc.WithRetry does,
+// but with a retry budget extended by twice the call timeout, so a call attempt timing out (see Config.CallTimeout) is retried
+// instead of exhausting the budget at once
+//
+// The call timeout must be set below this decorator (e.g. with jsonrpc.WithTimeout) so it applies to every attempt.
+// Calls are not retried once ctx is done. The backoff jitter draws from source (the global source if nil).
+//
+// Errors are classified: a call failing because the node does not keep the requested state (see IsStateUnavailable)
+// or because its response is too large (see IsResponseTooLarge) is not retried, and a call failing with a transient
+// missing trie node (see IsMissingTrieNode) is retried at most missingTrieNodeRetries times.
+func WithRetry(callTimeout time.Duration, missingTrieNodeRetries int, source *random.Source) jsonrpc.ClientDecorator {
+	return func(c jsonrpc.Client) jsonrpc.Client {
+		return jsonrpc.ClientFunc(func(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+			bckff := source.BackOff(backoff.NewExponentialBackOff(
+				backoff.WithInitialInterval(retryInitialInterval),
+				backoff.WithMaxElapsedTime(retryMaxElapsedTime+2*callTimeout),
+			))
+
+			attempt, missingTrieNodes := 0, 0
+			attemptReq := req
+			return backoff.RetryNotify(
+				func() error {
+					err := c.Call(ctx, attemptReq, res)
+					switch {
+					case IsStateUnavailable(err), IsResponseTooLarge(err):
+						return backoff.Permanent(err)
+					case IsMissingTrieNode(err):
+						if missingTrieNodes++; missingTrieNodes > missingTrieNodeRetries {
+							return backoff.Permanent(err)
+						}
+					}
+					return err
+				},
+				backoff.WithContext(bckff, ctx),
+				func(err error, d time.Duration) {
+					attempt++
+					// Every attempt has a new ID so the response of a previous attempt is not mistaken for it
+					attemptReq = &jsonrpc.Request{
+						Method:  req.Method,
+						Version: req.Version,
+						Params:  req.Params,
+						ID:      fmt.Sprintf("%v#%d", req.ID, attempt),
+					}
+					log.LoggerFromContext(ctx).Warn("Retrying in...",
+						zap.Error(err),
+						zap.Bool("missing_trie_node", IsMissingTrieNode(err)),
+						zap.Duration("duration", d),
+					)
+				},
+			)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryCallTimeout(t *testing.T) {
+	// The first attempt hangs until it times out, the second one succeeds
+	callTimeout := 3 * time.Second
+	attempts := 0
+	client := WithRetry(callTimeout, 0, nil)(jsonrpc.WithTimeout(callTimeout)(jsonrpc.ClientFunc(func(ctx context.Context, _ *jsonrpc.Request, _ interface{}) error {
+		attempts++
+		if attempts == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})))
+
+	require.NoError(t, client.Call(context.Background(), &jsonrpc.Request{Method: "eth_getProof", ID: uint32(1)}, nil))
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// Calls are not retried once the caller context is done, however long the call timeout is
+	attempts := 0
+	client := WithRetry(time.Minute, 0, nil)(jsonrpc.WithTimeout(time.Minute)(jsonrpc.ClientFunc(func(ctx context.Context, _ *jsonrpc.Request, _ interface{}) error {
+		attempts++
+		<-ctx.Done()
+		return ctx.Err()
+	})))
+
+	err := client.Call(ctx, &jsonrpc.Request{Method: "eth_getProof"}, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryMissingTrieNode(t *testing.T) {
+	missingTrieNode := func(failures int) (jsonrpc.Client, *int) {
+		attempts := 0
+		return WithRetry(time.Minute, 2, nil)(jsonrpc.ClientFunc(func(context.Context, *jsonrpc.Request, interface{}) error {
+			attempts++
+			if attempts <= failures {
+				return fmt.Errorf("missing trie node 1f2e3d (path ) <nil>")
+			}
+			return nil
+		})), &attempts
+	}
+
+	// Transient missing trie nodes are retried
+	client, attempts := missingTrieNode(2)
+	require.NoError(t, client.Call(context.Background(), &jsonrpc.Request{Method: "eth_getProof", ID: uint32(1)}, nil))
+	assert.Equal(t, 3, *attempts)
+
+	// Up to the maximum number of retries
+	client, attempts = missingTrieNode(5)
+	err := client.Call(context.Background(), &jsonrpc.Request{Method: "eth_getProof", ID: uint32(1)}, nil)
+	require.Error(t, err)
+	assert.True(t, IsMissingTrieNode(err))
+	assert.Equal(t, 3, *attempts)
+}
+
+func TestRetryStateUnavailable(t *testing.T) {
+	// A node not keeping the state is not retried
+	attempts := 0
+	client := WithRetry(time.Minute, 2, nil)(jsonrpc.ClientFunc(func(context.Context, *jsonrpc.Request, interface{}) error {
+		attempts++
+		return fmt.Errorf("historical state 0x1234abcd is not available")
+	}))
+
+	err := client.Call(context.Background(), &jsonrpc.Request{Method: "eth_getProof", ID: uint32(1)}, nil)
+	require.Error(t, err)
+	assert.True(t, IsStateUnavailable(err))
+	assert.False(t, IsMissingTrieNode(err))
+	assert.Equal(t, 1, attempts)
+}
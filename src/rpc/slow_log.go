@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// maxSlowLogParamsLen is the maximum length of the params summary of a slow call log
+const maxSlowLogParamsLen = 256
+
+// WithSlowLog is a decorator that logs at warn level every JSON-RPC call taking longer than threshold,
+// with its method, a summary of its params and its duration
+//
+// Params are only encoded for slow calls, so fast calls are not impacted.
+// When placed below the retry decorator, every retry attempt is timed separately.
+func WithSlowLog(threshold time.Duration) jsonrpc.ClientDecorator {
+	return func(c jsonrpc.Client) jsonrpc.Client {
+		return jsonrpc.ClientFunc(func(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+			start := time.Now()
+			err := c.Call(ctx, req, res)
+			if duration := time.Since(start); duration > threshold {
+				fields := []zap.Field{
+					zap.String("req.method", req.Method),
+					zap.String("req.params", summarizeParams(req.Params)),
+					zap.Duration("duration", duration),
+				}
+				if err != nil {
+					fields = append(fields, zap.Error(err))
+				}
+				log.LoggerWithFieldsFromContext(ctx).Warn("Slow JSON-RPC call", fields...)
+			}
+			return err
+		})
+	}
+}
+
+// summarizeParams returns the JSON encoding of params, truncated to maxSlowLogParamsLen
+func summarizeParams(params interface{}) string {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	if len(b) > maxSlowLogParamsLen {
+		return fmt.Sprintf("%s... (%d bytes)", b[:maxSlowLogParamsLen], len(b))
+	}
+	return string(b)
+}
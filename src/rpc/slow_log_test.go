@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/kkrt-labs/go-utils/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlowLog(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := log.WithLogger(context.Background(), zap.New(core))
+
+	client := WithSlowLog(50 * time.Millisecond)(jsonrpc.ClientFunc(func(_ context.Context, req *jsonrpc.Request, _ interface{}) error {
+		if req.Method == "eth_getProof" {
+			time.Sleep(60 * time.Millisecond)
+		}
+		return nil
+	}))
+
+	require.NoError(t, client.Call(ctx, &jsonrpc.Request{Method: "eth_chainId"}, nil))
+	assert.Zero(t, logs.Len(), "fast calls are not logged")
+
+	keys := []string{strings.Repeat("0", 64), strings.Repeat("1", 64), strings.Repeat("2", 64), strings.Repeat("3", 64), strings.Repeat("4", 64)}
+	require.NoError(t, client.Call(ctx, &jsonrpc.Request{Method: "eth_getProof", Params: []interface{}{"0xdac17f958d2ee523a2206206994597c13d831ec7", keys, "0x10"}}, nil))
+	require.Equal(t, 1, logs.Len())
+
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	fields := entry.ContextMap()
+	assert.Equal(t, "eth_getProof", fields["req.method"])
+	assert.True(t, strings.HasPrefix(fields["req.params"].(string), `["0xdac17f958d2ee523a2206206994597c13d831ec7",`))
+	assert.Contains(t, fields["req.params"], "... (")
+	assert.GreaterOrEqual(t, fields["duration"], 50*time.Millisecond)
+}
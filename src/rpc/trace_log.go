@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// TraceLoggerName is the name of the logger of trace logs, which are logged at debug level and can be filtered out by name
+// on top of the log level (see the CLI -vv flag)
+const TraceLoggerName = "trace"
+
+// WithTraceLog is a decorator that logs every JSON-RPC call at debug level on the trace logger, with its method, a
+// summary of its params, its duration and its error if any
+//
+// Params are only encoded when the trace logger is enabled.
+func WithTraceLog() jsonrpc.ClientDecorator {
+	return func(c jsonrpc.Client) jsonrpc.Client {
+		return jsonrpc.ClientFunc(func(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+			ce := log.LoggerWithFieldsFromContext(ctx).Named(TraceLoggerName).Check(zap.DebugLevel, "JSON-RPC call")
+			if ce == nil {
+				return c.Call(ctx, req, res)
+			}
+
+			start := time.Now()
+			err := c.Call(ctx, req, res)
+			fields := []zap.Field{
+				zap.String("req.method", req.Method),
+				zap.String("req.params", summarizeParams(req.Params)),
+				zap.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				fields = append(fields, zap.Error(err))
+			}
+			ce.Write(fields...)
+			return err
+		})
+	}
+}
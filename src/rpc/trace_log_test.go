@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	"github.com/kkrt-labs/go-utils/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTraceLog(t *testing.T) {
+	client := WithTraceLog()(jsonrpc.ClientFunc(func(_ context.Context, req *jsonrpc.Request, _ interface{}) error {
+		if req.Method == "eth_getProof" {
+			return errors.New("proof window exceeded")
+		}
+		return nil
+	}))
+
+	// Not logged above debug level
+	core, logs := observer.New(zapcore.InfoLevel)
+	require.NoError(t, client.Call(log.WithLogger(context.Background(), zap.New(core)), &jsonrpc.Request{Method: "eth_chainId"}, nil))
+	assert.Zero(t, logs.Len())
+
+	core, logs = observer.New(zapcore.DebugLevel)
+	ctx := log.WithLogger(context.Background(), zap.New(core))
+	require.NoError(t, client.Call(ctx, &jsonrpc.Request{Method: "eth_chainId", Params: []interface{}{}}, nil))
+	require.Error(t, client.Call(ctx, &jsonrpc.Request{Method: "eth_getProof", Params: []interface{}{"0x1", []string{}, "0x10"}}, nil))
+	require.Equal(t, 2, logs.Len())
+
+	entry := logs.All()[1]
+	assert.Equal(t, TraceLoggerName, entry.LoggerName)
+	assert.Equal(t, zapcore.DebugLevel, entry.Level)
+	fields := entry.ContextMap()
+	assert.Equal(t, "eth_getProof", fields["req.method"])
+	assert.Equal(t, `["0x1",[],"0x10"]`, fields["req.params"])
+	assert.Equal(t, "proof window exceeded", fields["error"])
+}
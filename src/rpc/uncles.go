@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethjsonrpc "github.com/kkrt-labs/go-utils/ethereum/rpc/jsonrpc"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+)
+
+// EthClient is an Ethereum JSON-RPC client returning blocks with their uncle headers
+//
+// The underlying go-utils client does not fetch uncles, which are required to apply the ommer rewards
+// when executing pre-Merge blocks. EthClient fetches them with eth_getUncleByBlockHashAndIndex.
+type EthClient struct {
+	*ethjsonrpc.Client
+
+	remote jsonrpc.Client
+}
+
+// NewEthClient creates a new EthClient
+func NewEthClient(remote jsonrpc.Client) *EthClient {
+	return &EthClient{
+		Client: ethjsonrpc.NewFromClient(remote),
+		remote: remote,
+	}
+}
+
+// BlockByNumber returns the block with the given number, including its uncles
+func (c *EthClient) BlockByNumber(ctx context.Context, number *big.Int) (*gethtypes.Block, error) {
+	block, err := c.Client.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return c.withUncles(ctx, block)
+}
+
+// BlockByHash returns the block with the given hash, including its uncles
+func (c *EthClient) BlockByHash(ctx context.Context, hash gethcommon.Hash) (*gethtypes.Block, error) {
+	block, err := c.Client.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return c.withUncles(ctx, block)
+}
+
+func (c *EthClient) withUncles(ctx context.Context, block *gethtypes.Block) (*gethtypes.Block, error) {
+	if block.UncleHash() == gethtypes.EmptyUncleHash {
+		return block, nil
+	}
+
+	var count hexutil.Uint
+	if err := c.remote.Call(ctx, &jsonrpc.Request{Method: "eth_getUncleCountByBlockHash", Params: []interface{}{block.Hash()}}, &count); err != nil {
+		return nil, fmt.Errorf("failed to fetch uncle count: %v", err)
+	}
+
+	uncles := make([]*gethtypes.Header, count)
+	for i := range uncles {
+		req := &jsonrpc.Request{Method: "eth_getUncleByBlockHashAndIndex", Params: []interface{}{block.Hash(), hexutil.Uint(i)}}
+		if err := c.remote.Call(ctx, req, &uncles[i]); err != nil {
+			return nil, fmt.Errorf("failed to fetch uncle %d: %v", i, err)
+		}
+		if uncles[i] == nil {
+			return nil, fmt.Errorf("uncle %d not found", i)
+		}
+	}
+
+	if hash := gethtypes.CalcUncleHash(uncles); hash != block.UncleHash() {
+		return nil, fmt.Errorf("invalid uncles: hash %v does not match block header %v", hash.Hex(), block.UncleHash().Hex())
+	}
+
+	return block.WithBody(gethtypes.Body{
+		Transactions: block.Transactions(),
+		Uncles:       uncles,
+		Withdrawals:  block.Withdrawals(),
+	}), nil
+}
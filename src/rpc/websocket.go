@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	jsonrpcws "github.com/kkrt-labs/go-utils/jsonrpc/websocket"
+	comnet "github.com/kkrt-labs/go-utils/net"
+)
+
+// tlsWebsocketClient is a JSON-RPC client over a WebSocket connection supporting custom TLS configuration.
+//
+// The go-utils WebSocket client does not allow to configure TLS, so we rely on go-ethereum RPC client
+// which accepts a custom websocket.Dialer.
+type tlsWebsocketClient struct {
+	addr      string
+	dialer    websocket.Dialer
+	header    http.Header
+	readLimit int64
+
+	client *gethrpc.Client
+}
+
+func newTLSWebsocketClient(addr string, cfg *jsonrpcws.Config, tlsCfg *tls.Config) *tlsWebsocketClient {
+	dialerCfg := cfg.Client.Dialer
+	return &tlsWebsocketClient{
+		addr: addr,
+		dialer: websocket.Dialer{
+			NetDialContext:    comnet.NewDialer(dialerCfg.Dialer).DialContext,
+			ReadBufferSize:    dialerCfg.ReadBufferSize,
+			WriteBufferSize:   dialerCfg.WriteBufferSize,
+			HandshakeTimeout:  dialerCfg.HandshakeTimeout,
+			EnableCompression: dialerCfg.EnableCompression,
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   tlsCfg,
+		},
+		header:    dialerCfg.Header,
+		readLimit: dialerCfg.ReadLimit,
+	}
+}
+
+// Start opens the WebSocket connection
+func (c *tlsWebsocketClient) Start(ctx context.Context) error {
+	opts := []gethrpc.ClientOption{
+		gethrpc.WithWebsocketDialer(c.dialer),
+		gethrpc.WithWebsocketMessageSizeLimit(c.readLimit), // 0 means no limit, consistently with go-utils client
+	}
+	if len(c.header) > 0 {
+		opts = append(opts, gethrpc.WithHeaders(c.header))
+	}
+
+	client, err := gethrpc.DialOptions(ctx, c.addr, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to dial websocket: %v", err)
+	}
+	c.client = client
+
+	return nil
+}
+
+// Call performs a JSON-RPC call
+func (c *tlsWebsocketClient) Call(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+	if c.client == nil {
+		return fmt.Errorf("websocket client not started")
+	}
+
+	var args []interface{}
+	switch params := req.Params.(type) {
+	case nil:
+	case []interface{}:
+		args = params
+	default:
+		args = []interface{}{params}
+	}
+
+	return c.client.CallContext(ctx, res, req.Method, args...)
+}
+
+// Stop closes the WebSocket connection
+func (c *tlsWebsocketClient) Stop(_ context.Context) error {
+	if c.client != nil {
+		c.client.Close()
+	}
+	return nil
+}
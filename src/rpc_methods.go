@@ -0,0 +1,37 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RPCMethodsTally is the number of JSON-RPC calls (including retries) made by the service, in total and per method.
+type RPCMethodsTally struct {
+	Calls   uint64            `json:"calls"`
+	Methods map[string]uint64 `json:"methods"`
+}
+
+// RPCMethodsTally returns the JSON-RPC calls made so far per method
+// It is empty when chain data is read from a local database.
+func (s *Service) RPCMethodsTally() *RPCMethodsTally {
+	return &RPCMethodsTally{
+		Calls:   s.rpcCalls.Calls(),
+		Methods: s.rpcCalls.CallsByMethod(),
+	}
+}
+
+// WriteFile writes the tally as JSON to the given path.
+func (t *RPCMethodsTally) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create RPC methods tally directory: %v", err)
+	}
+
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode RPC methods tally: %v", err)
+	}
+
+	return os.WriteFile(path, b, 0o600)
+}
@@ -0,0 +1,251 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"text/tabwriter"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	gethtrie "github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/kkrt-labs/zk-pig/src/ethereum"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/trie"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+)
+
+// AccountState is the state of an account, and of some of its storage slots, as seen by a source (the node or a prover input witness).
+type AccountState struct {
+	Exists      bool                                `json:"exists"`
+	Balance     *hexutil.Big                        `json:"balance"`
+	Nonce       hexutil.Uint64                      `json:"nonce"`
+	CodeHash    gethcommon.Hash                     `json:"codeHash"`
+	StorageHash gethcommon.Hash                     `json:"storageHash"`
+	Storage     map[gethcommon.Hash]gethcommon.Hash `json:"storage,omitempty"`      // Values of the requested storage slots
+	Missing     []gethcommon.Hash                   `json:"missingSlots,omitempty"` // Requested storage slots whose trie nodes are missing from the witness
+	Error       string                              `json:"error,omitempty"`        // Set if the account could not be read (e.g. its trie nodes are missing from the witness)
+}
+
+// RPCStateReport compares the pre-state of an account before a block, as returned by the node, with the pre-state embedded
+// in the stored prover input of the block, to debug incomplete witnesses.
+type RPCStateReport struct {
+	BlockNumber uint64             `json:"blockNumber"` // Block of the prover input, the state is the one of its parent block
+	Address     gethcommon.Address `json:"address"`
+	Slots       []gethcommon.Hash  `json:"slots"`
+	RPC         *AccountState      `json:"rpc"`
+	ProverInput *AccountState      `json:"proverInput"` // Nil if no prover input is stored for the block
+}
+
+// RPCState fetches the state of an account, and of the given storage slots, before the given block (i.e. at its parent block)
+// from the node and from the witness of the stored prover input of the block, so they can be compared side by side.
+func (s *Service) RPCState(ctx context.Context, blockNumber *big.Int, address gethcommon.Address, slots []gethcommon.Hash) (*RPCStateReport, error) {
+	if s.ethrpc == nil {
+		return nil, fmt.Errorf("querying state requires a remote RPC or local chain data")
+	}
+	if blockNumber.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid block number %v: the genesis block has no pre-state", blockNumber)
+	}
+
+	report := &RPCStateReport{
+		BlockNumber: blockNumber.Uint64(),
+		Address:     address,
+		Slots:       slots,
+	}
+
+	parent, err := s.ethrpc.HeaderByNumber(ctx, new(big.Int).Sub(blockNumber, big.NewInt(1)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch parent block header: %v", err)
+	}
+	if report.RPC, err = s.rpcAccountState(ctx, parent.Number, parent.Root, address, slots); err != nil {
+		return nil, err
+	}
+
+	inputs, err := s.ProverInputStore.LoadProverInput(ctx, s.chainID.Uint64(), blockNumber.Uint64())
+	switch {
+	case inputstore.IsNotFound(err):
+		return report, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to load provable inputs: %v", err)
+	}
+	if err := inputs.CheckBlock(s.chainID.Uint64(), blockNumber.Uint64()); err != nil {
+		return nil, err
+	}
+	report.ProverInput = witnessAccountState(inputs, address, slots)
+
+	return report, nil
+}
+
+func (s *Service) rpcAccountState(ctx context.Context, blockNumber *big.Int, root gethcommon.Hash, address gethcommon.Address, slots []gethcommon.Hash) (*AccountState, error) {
+	keys := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		keys = append(keys, slot.Hex())
+	}
+	res, err := s.ethrpc.GetProof(ctx, address, keys, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proof for account %v: %v", address.Hex(), err)
+	}
+
+	exists, err := trie.AccountProofFromRPC(res).Exists(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify proof for account %v: %v", address.Hex(), err)
+	}
+
+	state := &AccountState{
+		Exists:      exists,
+		Balance:     (*hexutil.Big)(res.Balance),
+		Nonce:       hexutil.Uint64(res.Nonce),
+		CodeHash:    res.CodeHash,
+		StorageHash: res.StorageHash,
+		Storage:     make(map[gethcommon.Hash]gethcommon.Hash, len(slots)),
+	}
+	for _, slot := range res.StorageProof {
+		if slot.Value != nil {
+			state.Storage[gethcommon.HexToHash(slot.Key)] = gethcommon.BigToHash(slot.Value)
+		} else {
+			state.Storage[gethcommon.HexToHash(slot.Key)] = gethcommon.Hash{}
+		}
+	}
+
+	return state, nil
+}
+
+// witnessAccountState reads the pre-state of an account from the witness of a prover input
+func witnessAccountState(inputs *input.ProverInput, address gethcommon.Address, slots []gethcommon.Hash) *AccountState {
+	state := &AccountState{Storage: make(map[gethcommon.Hash]gethcommon.Hash, len(slots))}
+	if len(inputs.Witness.Ancestors) == 0 {
+		state.Error = "no parent header in witness"
+		return state
+	}
+	root := inputs.Witness.Ancestors[0].Root
+
+	nodes, err := inputs.StateNodes()
+	if err != nil {
+		state.Error = err.Error()
+		return state
+	}
+	db := rawdb.NewMemoryDatabase()
+	ethereum.WriteNodesToHashDB(db, nodes...)
+	trieDB := triedb.NewDatabase(db, &triedb.Config{HashDB: &hashdb.Config{}})
+
+	accountTrie, err := gethtrie.NewStateTrie(gethtrie.StateTrieID(root), trieDB)
+	if err != nil {
+		state.Error = fmt.Sprintf("state root not in witness: %v", err)
+		return state
+	}
+	account, err := accountTrie.GetAccount(address)
+	if err != nil {
+		state.Error = fmt.Sprintf("account not in witness: %v", err)
+		return state
+	}
+	if account == nil {
+		// The witness proves the account does not exist, so neither do its slots
+		state.Balance = new(hexutil.Big)
+		for _, slot := range slots {
+			state.Storage[slot] = gethcommon.Hash{}
+		}
+		return state
+	}
+
+	state.Exists = true
+	state.Balance = (*hexutil.Big)(account.Balance.ToBig())
+	state.Nonce = hexutil.Uint64(account.Nonce)
+	state.CodeHash = gethcommon.BytesToHash(account.CodeHash)
+	state.StorageHash = account.Root
+
+	storageTrie, err := gethtrie.NewStateTrie(gethtrie.StorageTrieID(root, crypto.Keccak256Hash(address.Bytes()), account.Root), trieDB)
+	if err != nil {
+		state.Missing = slots
+		return state
+	}
+	for _, slot := range slots {
+		value, err := storageTrie.GetStorage(address, slot.Bytes())
+		if err != nil {
+			state.Missing = append(state.Missing, slot)
+			continue
+		}
+		state.Storage[slot] = gethcommon.BytesToHash(value)
+	}
+
+	return state
+}
+
+// Mismatches returns the fields (and storage slots) whose values differ between the node and the prover input
+func (r *RPCStateReport) Mismatches() []string {
+	if r.ProverInput == nil || r.ProverInput.Error != "" {
+		return nil
+	}
+
+	var mismatches []string
+	rpc, pi := r.RPC, r.ProverInput
+	if rpc.Exists != pi.Exists {
+		mismatches = append(mismatches, "exists")
+	}
+	if !rpc.Exists || !pi.Exists {
+		return mismatches // Fields of non-existing accounts are not meaningful
+	}
+	if rpc.Balance.ToInt().Cmp(pi.Balance.ToInt()) != 0 {
+		mismatches = append(mismatches, "balance")
+	}
+	if rpc.Nonce != pi.Nonce {
+		mismatches = append(mismatches, "nonce")
+	}
+	if rpc.CodeHash != pi.CodeHash {
+		mismatches = append(mismatches, "codeHash")
+	}
+	if rpc.StorageHash != pi.StorageHash {
+		mismatches = append(mismatches, "storageHash")
+	}
+	for _, slot := range r.Slots {
+		if value, ok := pi.Storage[slot]; ok && value != rpc.Storage[slot] {
+			mismatches = append(mismatches, "slot "+slot.Hex())
+		}
+	}
+	return mismatches
+}
+
+// String returns a human-readable side by side comparison.
+func (r *RPCStateReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "State of account %v before block %d (at block %d)\n", r.Address.Hex(), r.BlockNumber, r.BlockNumber-1)
+	if r.ProverInput == nil {
+		fmt.Fprintf(&b, "  No prover input stored for block %d\n", r.BlockNumber)
+	} else if r.ProverInput.Error != "" {
+		fmt.Fprintf(&b, "  Prover input: %s\n", r.ProverInput.Error)
+	}
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  FIELD\tRPC\tPROVER INPUT")
+	row := func(name, rpc string, pi func() string) {
+		value := "-"
+		if r.ProverInput != nil && r.ProverInput.Error == "" {
+			value = pi()
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", name, rpc, value)
+	}
+	rpc, pi := r.RPC, r.ProverInput
+	row("exists", fmt.Sprint(rpc.Exists), func() string { return fmt.Sprint(pi.Exists) })
+	row("balance", rpc.Balance.String(), func() string { return pi.Balance.String() })
+	row("nonce", fmt.Sprint(uint64(rpc.Nonce)), func() string { return fmt.Sprint(uint64(pi.Nonce)) })
+	row("codeHash", rpc.CodeHash.Hex(), func() string { return pi.CodeHash.Hex() })
+	row("storageHash", rpc.StorageHash.Hex(), func() string { return pi.StorageHash.Hex() })
+	for _, slot := range r.Slots {
+		row("slot "+slot.Hex(), rpc.Storage[slot].Hex(), func() string {
+			if value, ok := pi.Storage[slot]; ok {
+				return value.Hex()
+			}
+			return "missing from witness"
+		})
+	}
+	w.Flush()
+
+	if mismatches := r.Mismatches(); len(mismatches) > 0 {
+		fmt.Fprintf(&b, "  Mismatches: %s\n", strings.Join(mismatches, ", "))
+	}
+	return b.String()
+}
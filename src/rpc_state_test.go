@@ -0,0 +1,88 @@
+package src
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceRPCState(t *testing.T) {
+	// The contract reads slot 1 when called, slot 2 is only set in genesis
+	contract := gethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	slot1, slot2 := gethcommon.BigToHash(big.NewInt(1)), gethcommon.BigToHash(big.NewInt(2))
+	chain := newTestChain(t, nil, gethtypes.GenesisAlloc{
+		contract: {
+			Code:    []byte{0x60, 0x01, 0x54, 0x00}, // PUSH1 1 SLOAD STOP
+			Storage: map[gethcommon.Hash]gethcommon.Hash{slot1: gethcommon.BigToHash(big.NewInt(42)), slot2: gethcommon.BigToHash(big.NewInt(43))},
+			Balance: big.NewInt(7),
+		},
+	}, 3, func(i int, b *core.BlockGen) {
+		if i == 1 {
+			sendTestTx(t, testConfig, b, contract, nil)
+		}
+	})
+
+	s := newTestService(t, newTestConfig(t, ChainConfig{DataDir: chain.dir}))
+	ctx := context.Background()
+	require.NoError(t, s.Generate(ctx, big.NewInt(2), nil))
+
+	report, err := s.RPCState(ctx, big.NewInt(2), contract, []gethcommon.Hash{slot1, slot2})
+	require.NoError(t, err)
+	assert.Equal(t, &AccountState{
+		Exists:      true,
+		Balance:     (*hexutil.Big)(big.NewInt(7)),
+		CodeHash:    report.RPC.CodeHash,
+		StorageHash: report.RPC.StorageHash,
+		Storage:     map[gethcommon.Hash]gethcommon.Hash{slot1: gethcommon.BigToHash(big.NewInt(42)), slot2: gethcommon.BigToHash(big.NewInt(43))},
+	}, report.RPC)
+	require.NotNil(t, report.ProverInput)
+	assert.Empty(t, report.ProverInput.Error)
+	assert.True(t, report.ProverInput.Exists)
+	assert.Equal(t, report.RPC.CodeHash, report.ProverInput.CodeHash)
+	assert.Equal(t, report.RPC.StorageHash, report.ProverInput.StorageHash)
+	assert.Equal(t, map[gethcommon.Hash]gethcommon.Hash{slot1: gethcommon.BigToHash(big.NewInt(42))}, report.ProverInput.Storage, "only the slot read by the block is in the witness")
+	assert.Equal(t, []gethcommon.Hash{slot2}, report.ProverInput.Missing)
+	assert.Empty(t, report.Mismatches(), "slots missing from the witness are not mismatches")
+	assert.Contains(t, report.String(), "slot "+slot2.Hex()+"  0x000000000000000000000000000000000000000000000000000000000000002b  missing from witness")
+
+	t.Run("sender", func(t *testing.T) {
+		report, err := s.RPCState(ctx, big.NewInt(2), testSender, nil)
+		require.NoError(t, err)
+		assert.Equal(t, report.RPC, report.ProverInput)
+		assert.Empty(t, report.Mismatches())
+	})
+
+	t.Run("mismatches", func(t *testing.T) {
+		tampered := *report
+		pi := *report.ProverInput
+		pi.Nonce = 1
+		pi.Storage = map[gethcommon.Hash]gethcommon.Hash{slot1: gethcommon.BigToHash(big.NewInt(0))}
+		tampered.ProverInput = &pi
+		assert.Equal(t, []string{"nonce", "slot " + slot1.Hex()}, tampered.Mismatches())
+		assert.Contains(t, tampered.String(), "Mismatches: nonce, slot "+slot1.Hex())
+
+		pi.Exists = false
+		assert.Equal(t, []string{"exists"}, tampered.Mismatches(), "fields of non-existing accounts are not compared")
+	})
+
+	t.Run("no prover input", func(t *testing.T) {
+		report, err := s.RPCState(ctx, big.NewInt(3), contract, nil)
+		require.NoError(t, err)
+		assert.True(t, report.RPC.Exists)
+		assert.Nil(t, report.ProverInput)
+		assert.Empty(t, report.Mismatches())
+		assert.Contains(t, report.String(), "No prover input stored for block 3")
+	})
+
+	t.Run("genesis", func(t *testing.T) {
+		_, err := s.RPCState(ctx, big.NewInt(0), contract, nil)
+		assert.EqualError(t, err, "invalid block number 0: the genesis block has no pre-state")
+	})
+}
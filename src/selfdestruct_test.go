@@ -0,0 +1,78 @@
+package src
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/kkrt-labs/zk-pig/src/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Regression tests of the post-state proofs of accounts deleted by SELFDESTRUCT, whose semantics changed with
+// EIP-6780 (Cancun): the deleted accounts must be in the witness for the state root of the block to be recomputed
+func TestGenerateSelfDestruct(t *testing.T) {
+	var (
+		// Self-destructs when called, the only other account of the state under its first nibble being bystander, so
+		// its deletion collapses their trie branch into the untouched leaf of bystander
+		destructible = gethcommon.HexToAddress("0x2000000000000000000000000000000000000011")
+		bystander    = gethcommon.HexToAddress("0x200000000000000000000000000000000000003f")
+		factory      = gethcommon.HexToAddress("0x2000000000000000000000000000000000000002") // Creates a contract self-destructing in its constructor
+		beneficiary  = gethcommon.HexToAddress("0x2000000000000000000000000000000000000003")
+	)
+	// SELFDESTRUCT(beneficiary)
+	selfDestructCode := append(append([]byte{0x73}, beneficiary.Bytes()...), 0xff)
+	// MSTORE(0, selfDestructCode) then CREATE(0, 10, 22), with selfDestructCode as init code
+	factoryCode := append(append([]byte{0x75}, selfDestructCode...), 0x60, 0x00, 0x52, 0x60, 0x16, 0x60, 0x0a, 0x60, 0x00, 0xf0, 0x50, 0x00)
+
+	shanghai := generator.DevChainConfig(big.NewInt(424243))
+	shanghai.CancunTime = nil
+
+	for _, tc := range []struct {
+		desc    string
+		config  *params.ChainConfig
+		deleted bool // Whether the called contract is deleted
+	}{
+		{desc: "Shanghai", config: shanghai, deleted: true},
+		{desc: "Cancun", config: testConfig, deleted: false}, // EIP-6780: only contracts created in the same transaction are deleted
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			alloc := gethtypes.GenesisAlloc{
+				destructible: {
+					Code:    selfDestructCode,
+					Balance: big.NewInt(params.Ether),
+					Storage: map[gethcommon.Hash]gethcommon.Hash{{1}: {1}, {2}: {2}},
+				},
+				factory:   {Code: factoryCode},
+				bystander: {Balance: big.NewInt(1)},
+			}
+			chain := newTestChain(t, tc.config, alloc, 2, func(i int, b *core.BlockGen) {
+				if i == 1 {
+					sendTestTx(t, tc.config, b, destructible, nil)
+					sendTestTx(t, tc.config, b, factory, nil)
+				}
+			})
+			require.Len(t, chain.receipts[1], 2)
+			for _, receipt := range chain.receipts[1] {
+				require.Equal(t, gethtypes.ReceiptStatusSuccessful, receipt.Status)
+			}
+
+			cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+			cfg.Preflight.Genesis = chain.genesis // Configuration of the Shanghai chain
+			s := newTestService(t, cfg)
+
+			ctx := context.Background()
+			require.NoError(t, s.Generate(ctx, big.NewInt(2), nil), "preflight, prepare and execute")
+			require.NoError(t, s.Execute(ctx, big.NewInt(2), nil), "the stored prover input recomputes the state root of the block")
+
+			code, err := s.ethrpc.CodeAt(ctx, destructible, big.NewInt(2))
+			require.NoError(t, err)
+			assert.Equal(t, tc.deleted, len(code) == 0)
+		})
+	}
+}
@@ -2,19 +2,32 @@ package src
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"os"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	ethrpc "github.com/kkrt-labs/go-utils/ethereum/rpc"
-	ethjsonrpc "github.com/kkrt-labs/go-utils/ethereum/rpc/jsonrpc"
 	"github.com/kkrt-labs/go-utils/jsonrpc"
-	jsonrpcmrgd "github.com/kkrt-labs/go-utils/jsonrpc/merged"
-	compressstore "github.com/kkrt-labs/go-utils/store/compress"
+	"github.com/kkrt-labs/go-utils/log"
 	"github.com/kkrt-labs/go-utils/svc"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/chaindata"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/evm"
 	"github.com/kkrt-labs/zk-pig/src/generator"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/kkrt-labs/zk-pig/src/random"
+	"github.com/kkrt-labs/zk-pig/src/rpc"
+	"github.com/kkrt-labs/zk-pig/src/sink"
 	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+	"go.uber.org/zap"
 )
 
 // Service is a service that enables the generation of prover inpunts for EVM compatible blocks.
@@ -25,34 +38,128 @@ type Service struct {
 	initOnce           sync.Once
 	remote             jsonrpc.Client
 	ethrpc             ethrpc.Client
+	verifyRemote       jsonrpc.Client // Set if the headers of the collected blocks are verified against a trusted node
+	verify             ethrpc.Client
 	chainID            *big.Int
 	err                error
+
+	chaindata    *chaindata.Client
+	rpcCalls     *rpc.CallCounter
+	recorder     *rpc.Recorder // Set if RPC calls are recorded
+	bytesWritten *inputstore.CountingStore
+	metrics      *metrics
+	uploads      *inputstore.AsyncProverInputStore // Set if prover inputs are stored in the background
+	blocks       *blockLimiter                     // Caps the number of blocks processed at the same time, nil if unlimited
+	pruner       preflightPruner
+	sink         sink.Sink // Set if generated prover inputs are published to a message queue
+	random       *random.Source
+
+	preExecuteHooks []generator.PreExecuteHook // Called before executing every block (see RegisterPreExecuteHook)
+
+	executeReport      *os.File
+	completenessReport *os.File
+	accessList         *os.File
+	callGraph          *os.File
 }
 
 // New creates a new Service.
 func New(cfg *Config) (*Service, error) {
 	cfg = cfg.SetDefault()
 
+	if cfg.EVM.AssertVersion != "" && cfg.EVM.AssertVersion != evm.Version {
+		return nil, fmt.Errorf("EVM version mismatch: expected %q but built with %q", cfg.EVM.AssertVersion, evm.Version)
+	}
+
+	seed := random.NewSeed()
+	if cfg.Seed != nil {
+		seed = *cfg.Seed
+	}
+
 	s := &Service{
-		cfg: cfg,
+		cfg:      cfg,
+		rpcCalls: rpc.NewCallCounter(),
+		metrics:  newMetrics(),
+		blocks:   newBlockLimiter(cfg.MaxConcurrentBlocks),
+		random:   random.New(seed),
 	}
 
-	if cfg.Chain.RPC != nil {
-		remote, err := jsonrpcmrgd.New(cfg.Chain.RPC)
+	if cfg.Chain.RPCRecord != "" && (cfg.Chain.RPC == nil || cfg.Chain.DataDir != "" || cfg.Chain.RPCReplay != "") {
+		return nil, fmt.Errorf("recording RPC calls requires a remote RPC, without local chain data nor replay")
+	}
+
+	if cfg.Chain.RPCReplay != "" {
+		// Serve chain data from a recording, without any network access
+		replayer, err := rpc.NewReplayer(cfg.Chain.RPCReplay)
+		if err != nil {
+			return nil, err
+		}
+		s.remote = replayer
+
+		proofChunkSize, headerCacheSize := 0, 0
+		if cfg.Chain.RPC != nil {
+			// Chunked eth_getProof calls are recorded as such, and cached headers are not recorded
+			proofChunkSize, headerCacheSize = cfg.Chain.RPC.ProofChunkSize, cfg.Chain.RPC.HeaderCacheSize
+		}
+		s.ethrpc = rpc.NewProofChunkingClient(rpc.NewEthClient(rpc.WithCallCounter(s.rpcCalls)(replayer)), proofChunkSize)
+		if headerCacheSize > 0 {
+			s.ethrpc = rpc.NewHeaderCachingClient(s.ethrpc, headerCacheSize)
+		}
+	} else if cfg.Chain.DataDir != "" {
+		// Read chain data from the local database, bypassing RPC
+		s.chaindata = chaindata.NewClient(cfg.Chain.DataDir)
+		s.ethrpc = s.chaindata
+	} else if cfg.Chain.RPC != nil {
+		remote, err := rpc.New(cfg.Chain.RPC)
 		if err != nil {
 			return nil, err
 		}
 		s.remote = remote
 
-		remote = jsonrpc.WithLog()(remote)                           // Logs a first time before the Retry
-		remote = jsonrpc.WithTimeout(500 * time.Millisecond)(remote) // Sets a timeout on outgoing requests
-		remote = jsonrpc.WithTags("")(remote)                        // Add tags are updated according to retry
-		remote = jsonrpc.WithRetry()(remote)
+		remote = rpc.WithTraceLog()(remote) // Logs every call attempt on the trace logger
+		if cfg.Chain.RPC.SlowLogThreshold > 0 {
+			remote = rpc.WithSlowLog(cfg.Chain.RPC.SlowLogThreshold)(remote) // Logs call attempts slower than the threshold
+		}
+		remote = rpc.WithCallCounter(s.rpcCalls)(remote)                                                          // Counts every call attempt
+		remote = jsonrpc.WithLog()(remote)                                                                        // Logs a first time before the Retry
+		remote = rpc.WithRequestID()(remote)                                                                      // Sets a new request ID on every call attempt, logged on failure
+		remote = jsonrpc.WithTimeout(cfg.Chain.RPC.CallTimeout)(remote)                                           // Sets a timeout on every call attempt
+		remote = jsonrpc.WithTags("")(remote)                                                                     // Add tags are updated according to retry
+		remote = rpc.WithRetry(cfg.Chain.RPC.CallTimeout, cfg.Chain.RPC.MissingTrieNodeRetries, s.random)(remote) // Retries failed and timed out call attempts
 		remote = jsonrpc.WithTags("jsonrpc")(remote)
 		remote = jsonrpc.WithVersion("2.0")(remote)
 		remote = jsonrpc.WithIncrementalID()(remote)
+		if cfg.Chain.RPCRecord != "" {
+			if s.recorder, err = rpc.NewRecorder(cfg.Chain.RPCRecord); err != nil {
+				return nil, err
+			}
+			remote = rpc.WithRecorder(s.recorder)(remote) // Records the final outcome of every call
+		}
+		if cfg.Chain.RPC.CacheTTL > 0 {
+			remote = rpc.WithCache(rpc.NewCache(cfg.Chain.RPC.CacheTTL))(remote) // Serves repeated calls without hitting the node
+		}
 
-		s.ethrpc = ethjsonrpc.NewFromClient(remote)
+		s.ethrpc = rpc.NewProofChunkingClient(rpc.NewEthClient(remote), cfg.Chain.RPC.ProofChunkSize)
+		if cfg.Chain.RPC.HeaderCacheSize > 0 {
+			s.ethrpc = rpc.NewHeaderCachingClient(s.ethrpc, cfg.Chain.RPC.HeaderCacheSize) // Serves the headers of the previous blocks
+		}
+	}
+
+	if cfg.Chain.VerifyRPC != nil {
+		verify, err := rpc.New(cfg.Chain.VerifyRPC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create verify RPC client: %v", err)
+		}
+		s.verifyRemote = verify
+
+		verify = jsonrpc.WithLog()(verify)
+		verify = rpc.WithRequestID()(verify)
+		verify = jsonrpc.WithTimeout(cfg.Chain.VerifyRPC.CallTimeout)(verify)
+		verify = jsonrpc.WithTags("")(verify)
+		verify = rpc.WithRetry(cfg.Chain.VerifyRPC.CallTimeout, cfg.Chain.VerifyRPC.MissingTrieNodeRetries, s.random)(verify)
+		verify = jsonrpc.WithTags("verify")(verify)
+		verify = jsonrpc.WithVersion("2.0")(verify)
+		verify = jsonrpc.WithIncrementalID()(verify)
+		s.verify = rpc.NewEthClient(verify)
 	}
 
 	preflightDataStore, err := inputstore.NewPreflightDataStore(&cfg.PreflightDataStore)
@@ -60,15 +167,36 @@ func New(cfg *Config) (*Service, error) {
 		return nil, fmt.Errorf("failed to create preflight data store: %v", err)
 	}
 
-	compressStore, err := compressstore.New(compressstore.Config{
-		MultiStoreConfig: cfg.ProverInputStore.StoreConfig,
-		ContentEncoding:  cfg.ProverInputStore.ContentEncoding,
-	})
-
-	ProverInputStore := inputstore.NewFromStore(compressStore, cfg.ProverInputStore.ContentType)
+	storeCfg := cfg.ProverInputStore
+	storeCfg.Retry.Random = s.random // Jitter of the store retries and of the S3 download resumes
+	baseStore, err := inputstore.NewBackendStore(&storeCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create prover inputs store: %v", err)
 	}
+	s.bytesWritten = inputstore.NewCountingStore(inputstore.NewCompressStore(inputstore.NewRetryStore(baseStore, &storeCfg.Retry), cfg.ProverInputStore.ContentEncoding, cfg.ProverInputStore.CompressionLevel))
+	proverInputStore := inputstore.NewFromStore(
+		inputstore.NewObservingStore(inputstore.NewChunkingStore(s.bytesWritten, cfg.ProverInputStore.ChunkSize), s.metrics.observeProverInput),
+		cfg.ProverInputStore.Format,
+		cfg.ProverInputStore.NumberFormat,
+		cfg.ProverInputStore.JSONEncoder,
+	)
+	if cfg.ProverInputStore.Uploads > 0 {
+		s.uploads = inputstore.NewAsyncProverInputStore(proverInputStore, cfg.ProverInputStore.Uploads)
+		proverInputStore = s.uploads
+	}
+	ProverInputStore := inputstore.NewDeltaStore(proverInputStore, cfg.ProverInputStore.DeltaBase)
+
+	if cfg.Sink.Type != "" {
+		// Prover inputs are published once stored, which uploads in the background do not guarantee
+		if cfg.ProverInputStore.Uploads > 0 {
+			return nil, fmt.Errorf("publishing prover inputs to a sink requires synchronous uploads (unset inputs-upload-concurrency)")
+		}
+		sinkCfg := cfg.Sink
+		sinkCfg.Retry.Random = s.random
+		if s.sink, err = sink.New(&sinkCfg); err != nil {
+			return nil, fmt.Errorf("failed to create sink: %v", err)
+		}
+	}
 
 	s.preflightDataStore = preflightDataStore
 	s.ProverInputStore = ProverInputStore
@@ -76,14 +204,31 @@ func New(cfg *Config) (*Service, error) {
 	return s, nil
 }
 
+// RegisterPreExecuteHook registers a hook called by the execute phase right before executing every block, with access to
+// the pre-state and the block (see generator.ExecutionEnv), e.g. to modify the state or capture metrics
+//
+// Hooks run in registration order, after the ones of the execution configuration, and an error of a hook fails the
+// block. Hooks must be registered before the service processes blocks, and may be called concurrently when blocks are.
+func (s *Service) RegisterPreExecuteHook(hook generator.PreExecuteHook) {
+	s.preExecuteHooks = append(s.preExecuteHooks, hook)
+}
+
 // Start starts the service.
 func (s *Service) Start(ctx context.Context) error {
 	s.initOnce.Do(func() {
-		if s.cfg.Chain.RPC == nil && s.cfg.Chain.ID == nil {
+		if s.cfg.Chain.RPC == nil && s.cfg.Chain.DataDir == "" && s.cfg.Chain.RPCReplay == "" && s.cfg.Chain.ID == nil {
 			s.err = fmt.Errorf("no chain configuration provided")
 			return
 		}
 
+		if s.chaindata != nil {
+			s.err = s.chaindata.Start(ctx)
+			if s.err != nil {
+				s.err = fmt.Errorf("failed to open local chain data: %v", s.err)
+				return
+			}
+		}
+
 		if runable, ok := s.remote.(svc.Runnable); ok {
 			s.err = runable.Start(ctx)
 			if s.err != nil {
@@ -92,6 +237,14 @@ func (s *Service) Start(ctx context.Context) error {
 			}
 		}
 
+		if runable, ok := s.verifyRemote.(svc.Runnable); ok {
+			s.err = runable.Start(ctx)
+			if s.err != nil {
+				s.err = fmt.Errorf("failed to start verify RPC client: %v", s.err)
+				return
+			}
+		}
+
 		if s.ethrpc != nil {
 			s.chainID, s.err = s.ethrpc.ChainID(ctx)
 			if s.err != nil {
@@ -100,104 +253,907 @@ func (s *Service) Start(ctx context.Context) error {
 		} else {
 			s.chainID = s.cfg.Chain.ID
 		}
+
+		if s.err == nil {
+			s.cleanTempFiles(ctx)
+		}
+
+		if s.err == nil {
+			// Logged so a run can be reproduced with --seed
+			log.LoggerFromContext(ctx).Info("Random seed", zap.Uint64("seed", s.random.Seed()))
+		}
+
+		if s.err == nil && s.cfg.Execution.SkipRootVerification {
+			log.LoggerFromContext(ctx).Warn("Root verification is disabled (execution.skip-root-verification): the execute phase of generate does NOT verify the generated prover inputs")
+		}
+		if s.err == nil && s.cfg.Execution.WitnessCommitment {
+			log.LoggerFromContext(ctx).Warn("Execution is disabled (execution.witness-commitment): generate only stores a witness commitment in the prover inputs metadata")
+		}
+
+		if s.err == nil && s.cfg.Metrics.Addr != "" {
+			s.err = s.metrics.start(ctx, s.cfg.Metrics.Addr)
+		}
+
+		if s.err == nil && s.cfg.ExecuteReport != "" {
+			// The report is appended to, so it accumulates the executions of every block (and every run)
+			s.executeReport, s.err = os.OpenFile(s.cfg.ExecuteReport, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+			if s.err != nil {
+				s.err = fmt.Errorf("failed to open execution report: %v", s.err)
+			}
+		}
+
+		if s.err == nil && s.cfg.CompletenessReport != "" {
+			s.completenessReport, s.err = os.OpenFile(s.cfg.CompletenessReport, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+			if s.err != nil {
+				s.err = fmt.Errorf("failed to open completeness report: %v", s.err)
+			}
+		}
+
+		if s.err == nil && s.cfg.AccessList != "" {
+			s.accessList, s.err = os.OpenFile(s.cfg.AccessList, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+			if s.err != nil {
+				s.err = fmt.Errorf("failed to open access list file: %v", s.err)
+			}
+		}
+
+		if s.err == nil && s.cfg.CallGraph != "" {
+			s.callGraph, s.err = os.OpenFile(s.cfg.CallGraph, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+			if s.err != nil {
+				s.err = fmt.Errorf("failed to open call graph file: %v", s.err)
+			}
+		}
 	})
 
 	return s.err
 }
 
-func (s *Service) Generate(ctx context.Context, blockNumber *big.Int) error {
-	data, err := s.preflight(ctx, blockNumber)
-	if err != nil {
+// staleTempFileAge is the age after which temporary files left by interrupted writes are considered stale
+const staleTempFileAge = time.Hour
+
+// cleanTempFiles removes the stale temporary files left in the local stores by interrupted runs
+func (s *Service) cleanTempFiles(ctx context.Context) {
+	var dirs []string
+	if cfg := s.cfg.PreflightDataStore.FileConfig; cfg != nil {
+		dirs = append(dirs, cfg.DataDir)
+	}
+	if cfg := s.cfg.ProverInputStore.StoreConfig.FileConfig; cfg != nil {
+		dirs = append(dirs, cfg.DataDir)
+	}
+
+	for _, dir := range dirs {
+		if s.chainID != nil {
+			dir = strings.Replace(dir, "default", s.chainID.String(), 1)
+		}
+		removed, err := inputstore.CleanTempFiles(dir, staleTempFileAge)
+		if err != nil {
+			log.LoggerFromContext(ctx).Warn("Failed to clean temporary files", zap.String("dir", dir), zap.Error(err))
+		} else if removed > 0 {
+			log.LoggerFromContext(ctx).Info("Removed stale temporary files", zap.String("dir", dir), zap.Int("count", removed))
+		}
+	}
+}
+
+// Phase is a phase of the prover inputs generation
+type Phase string
+
+const (
+	PhasePreflight Phase = "preflight"
+	PhasePrepare   Phase = "prepare"
+	PhaseExecute   Phase = "execute"
+)
+
+// ParsePhase parses a generation phase, an empty string defaults to PhaseExecute (i.e. all phases)
+func ParsePhase(s string) (Phase, error) {
+	switch Phase(s) {
+	case "", PhaseExecute:
+		return PhaseExecute, nil
+	case PhasePreflight, PhasePrepare:
+		return Phase(s), nil
+	}
+	return "", fmt.Errorf("invalid phase %q (expected one of %q)", s, []Phase{PhasePreflight, PhasePrepare, PhaseExecute})
+}
+
+// GenerateOptions are the options for generating the prover input of a block.
+type GenerateOptions struct {
+	// StopAfter is the last phase to run (defaults to PhaseExecute, i.e. all phases)
+	StopAfter Phase
+}
+
+func (s *Service) Generate(ctx context.Context, blockNumber *big.Int, opts *GenerateOptions) error {
+	if opts == nil {
+		opts = &GenerateOptions{}
+	}
+
+	if err := s.blocks.Acquire(ctx); err != nil {
 		return err
 	}
+	defer s.blocks.Release()
 
-	if s.chainID == nil {
-		return fmt.Errorf("chain ID missing")
+	if opts.StopAfter == PhasePreflight {
+		data, err := s.preflight(ctx, blockNumber)
+		if err != nil {
+			return err
+		}
+		return data.Close()
 	}
-	_, err = s.preflight(ctx, data.Block.Number.ToInt())
+
+	block, err := s.preflightAndPrepare(ctx, blockNumber)
 	if err != nil {
 		return err
 	}
 
-	if err := s.prepare(ctx, data.Block.Number.ToInt()); err != nil {
-		return err
+	if opts.StopAfter == PhasePrepare {
+		return s.publish(ctx, block, PhasePrepare)
 	}
 
-	if err := s.execute(ctx, data.Block.Number.ToInt()); err != nil {
+	if err := s.execute(ctx, block.number); err != nil {
 		return err
 	}
 
+	return s.publish(ctx, block, PhaseExecute)
+}
+
+// preparedBlock is a block whose prover input is prepared and stored
+type preparedBlock struct {
+	number *big.Int
+	hash   gethcommon.Hash
+	labels map[string]string // Labels of the prover input
+}
+
+// preflightAndPrepare runs preflight and prepare for the given block
+func (s *Service) preflightAndPrepare(ctx context.Context, blockNumber *big.Int) (*preparedBlock, error) {
+	data, err := s.preflight(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	data.Close()
+
+	if s.chainID == nil {
+		return nil, fmt.Errorf("chain ID missing")
+	}
+	data, err = s.preflight(ctx, data.Block.Number.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	data.Close()
+
+	return s.prepare(ctx, data.Block.Number.ToInt())
+}
+
+// RangeOptions are the options for generating prover inputs for a range or a list of blocks.
+type RangeOptions struct {
+	// Pipeline overlaps preflight & prepare of block N+1 with execute of block N.
+	// Blocks are still prepared in order by a single worker, so prover inputs are written to the store in block order.
+	Pipeline bool
+
+	// InterBlockDelay is the minimum delay between the start of two blocks, plus a random jitter in [0, InterBlockJitter).
+	// It smooths the load on the RPC provider and applies in aggregate to all workers.
+	InterBlockDelay  time.Duration
+	InterBlockJitter time.Duration
+
+	// StopAfter is the last phase to run for every block (defaults to PhaseExecute, i.e. all phases).
+	// Pipeline has no effect if execute is not run.
+	StopAfter Phase
+
+	// SkipExisting skips blocks whose prover input can already be loaded from the store
+	SkipExisting bool
+
+	// StopOnError stops the run at the first failed block, otherwise failed blocks are reported in the summary
+	StopOnError bool
+
+	// Touching restricts GenerateRange to the blocks of the range touching this address (see BlocksTouching)
+	Touching *gethcommon.Address
+
+	// MinGasUsed and MaxGasUsed, if set, skip the blocks whose header gas used is below or above them (inclusive bounds),
+	// the header of every block being fetched before it is generated
+	MinGasUsed *uint64
+	MaxGasUsed *uint64
+}
+
+// pipelineDepth is the number of prepared blocks that can be waiting for execution in pipelined mode
+const pipelineDepth = 1
+
+// errProverInputExists is reported for blocks skipped because their prover input is already in the store
+var errProverInputExists = errors.New("prover input already exists")
+
+// GenerateRange generates prover inputs for every block in the inclusive range [from, to].
+// Unless opts.StopOnError is set, it does not stop on failure, failed blocks are reported in the returned summary
+// and an error is returned if at least one block failed.
+func (s *Service) GenerateRange(ctx context.Context, from, to *big.Int, opts *RangeOptions) (*RunSummary, error) {
+	if from.Cmp(to) > 0 {
+		return nil, fmt.Errorf("invalid block range: %v > %v", from, to)
+	}
+	if err := s.checkMaxBlocks(new(big.Int).Sub(to, from).Uint64() + 1); err != nil {
+		return nil, fmt.Errorf("block range %v-%v: %v", from, to, err)
+	}
+
+	var blocks []uint64
+	if opts != nil && opts.Touching != nil {
+		var err error
+		if blocks, err = s.BlocksTouching(ctx, from, to, *opts.Touching); err != nil {
+			return nil, err
+		}
+	} else {
+		blocks = make([]uint64, 0, to.Uint64()-from.Uint64()+1)
+		for n := from.Uint64(); n <= to.Uint64(); n++ {
+			blocks = append(blocks, n)
+		}
+	}
+
+	return s.generateBlocks(ctx, blocks, nil, opts)
+}
+
+// GenerateBlocks generates prover inputs for a list of blocks, in the listed order.
+// Blocks referenced by hash are resolved to their number on the remote RPC before the run starts.
+// Failures are handled as in GenerateRange.
+func (s *Service) GenerateBlocks(ctx context.Context, refs []*BlockRef, opts *RangeOptions) (*RunSummary, error) {
+	if opts == nil {
+		opts = &RangeOptions{}
+	}
+	if err := s.checkMaxBlocks(uint64(len(refs))); err != nil {
+		return nil, fmt.Errorf("block list: %v", err)
+	}
+
+	var (
+		blocks = make([]uint64, 0, len(refs))
+		failed []*BlockFailure
+	)
+	for _, ref := range refs {
+		if ref.Hash == nil {
+			blocks = append(blocks, ref.Number.Uint64())
+			continue
+		}
+
+		n, err := s.resolveBlockHash(ctx, ref)
+		if err != nil && opts.StopOnError {
+			return nil, err
+		}
+		if err != nil {
+			log.LoggerFromContext(ctx).Error("Failed to resolve block hash", zap.String("block.hash", ref.String()), zap.Error(err))
+			failed = append(failed, &BlockFailure{BlockHash: ref.String(), Error: err.Error()})
+			continue
+		}
+		blocks = append(blocks, n)
+	}
+
+	return s.generateBlocks(ctx, blocks, failed, opts)
+}
+
+// checkMaxBlocks checks a run of n blocks does not exceed the maximum number of blocks of a run (see Config.MaxBlocks)
+func (s *Service) checkMaxBlocks(n uint64) error {
+	if s.cfg.MaxBlocks > 0 && n > uint64(s.cfg.MaxBlocks) {
+		return fmt.Errorf("%d blocks requested, more than the maximum of %d blocks per run (max-blocks)", n, s.cfg.MaxBlocks)
+	}
 	return nil
 }
 
+func (s *Service) resolveBlockHash(ctx context.Context, ref *BlockRef) (uint64, error) {
+	if s.ethrpc == nil {
+		return 0, fmt.Errorf("resolving block hash %v requires a remote RPC or a local chain data directory", ref)
+	}
+
+	header, err := s.ethrpc.HeaderByHash(ctx, *ref.Hash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve block hash %v: %v", ref, err)
+	}
+
+	return header.Number.Uint64(), nil
+}
+
+// generateBlocks generates prover inputs for the given blocks, failed are failures that happened before the run
+// and that are reported in the summary
+func (s *Service) generateBlocks(ctx context.Context, blocks []uint64, failed []*BlockFailure, opts *RangeOptions) (*RunSummary, error) {
+	if opts == nil {
+		opts = &RangeOptions{}
+	}
+	if err := checkGasUsedRange(opts); err != nil {
+		return nil, err
+	}
+	if opts.Pipeline && s.cfg.MemoryLimit.enabled() {
+		// The next block is prepared while the current one is executed, so the heap is not the one of a single block
+		return nil, fmt.Errorf("memory limits can not be combined with pipelined mode")
+	}
+
+	summary := &RunSummary{
+		Attempted: len(failed),
+		Failed:    append([]*BlockFailure{}, failed...),
+		Seed:      s.random.Seed(),
+		StartTime: time.Now(),
+	}
+	if len(blocks) > 0 {
+		summary.From, summary.To = slices.Min(blocks), slices.Max(blocks)
+	}
+	rpcCallsStart, bytesWrittenStart := s.rpcCalls.Calls(), s.bytesWritten.BytesWritten()
+
+	// runCtx is canceled on the first failure if opts.StopOnError is set
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stopped := false
+	report := func(n uint64, err error) {
+		if stopped {
+			return // Blocks interrupted by the stop are not reported
+		}
+		if errors.Is(err, errProverInputExists) {
+			log.LoggerFromContext(ctx).Info("Prover input already exists, skipping", zap.Uint64("block.number", n))
+			summary.Skipped++
+			return
+		}
+		if errors.Is(err, errGasUsedOutOfRange) {
+			summary.Skipped++ // Logged by filterGasUsed along with the gas used
+			return
+		}
+
+		summary.Attempted++
+		if err != nil {
+			log.LoggerFromContext(ctx).Error("Failed to generate prover input", zap.Uint64("block.number", n), zap.Error(err))
+			summary.Failed = append(summary.Failed, &BlockFailure{BlockNumber: n, Error: err.Error()})
+			if opts.StopOnError {
+				stopped = true
+				cancel()
+			}
+			return
+		}
+		summary.Succeeded++
+	}
+
+	pacer := newBlockPacer(opts.InterBlockDelay, opts.InterBlockJitter, s.random)
+	if opts.Pipeline && (opts.StopAfter == "" || opts.StopAfter == PhaseExecute) {
+		s.generateBlocksPipelined(runCtx, blocks, opts, pacer, report)
+	} else {
+		for _, n := range blocks {
+			if s.skipExisting(runCtx, n, opts) {
+				report(n, errProverInputExists)
+				continue
+			}
+			if err := s.filterGasUsed(runCtx, n, opts); err != nil {
+				report(n, err)
+				continue
+			}
+			if pacer.Wait(runCtx) != nil {
+				break
+			}
+			report(n, s.Generate(runCtx, new(big.Int).SetUint64(n), &GenerateOptions{StopAfter: opts.StopAfter}))
+		}
+	}
+
+	// Prover inputs stored in the background are part of the run
+	for _, failure := range s.waitUploads() {
+		if slices.ContainsFunc(summary.Failed, func(f *BlockFailure) bool { return f.BlockHash == "" && f.BlockNumber == failure.BlockNumber }) {
+			continue
+		}
+		err := fmt.Errorf("failed to store provable inputs: %v", failure.Err)
+		log.LoggerFromContext(ctx).Error("Failed to generate prover input", zap.Uint64("block.number", failure.BlockNumber), zap.Error(err))
+		summary.Succeeded--
+		summary.Failed = append(summary.Failed, &BlockFailure{BlockNumber: failure.BlockNumber, Error: err.Error()})
+	}
+
+	summary.EndTime = time.Now()
+	summary.Duration = summary.EndTime.Sub(summary.StartTime).String()
+	summary.RPCCalls = s.rpcCalls.Calls() - rpcCallsStart
+	summary.BytesWritten = s.bytesWritten.BytesWritten() - bytesWrittenStart
+
+	if len(summary.Failed) > 0 {
+		return summary, fmt.Errorf("failed to generate prover inputs for %d/%d blocks", len(summary.Failed), summary.Attempted)
+	}
+
+	if ctx.Err() != nil {
+		return summary, ctx.Err()
+	}
+
+	return summary, nil
+}
+
+// waitUploads waits for the prover inputs being stored in the background and returns the ones that failed
+func (s *Service) waitUploads() []*inputstore.StoreFailure {
+	if s.uploads == nil {
+		return nil
+	}
+	s.uploads.Wait()
+	return s.uploads.Failures()
+}
+
+// skipExisting returns true if opts.SkipExisting is set and the prover input of the block is already in the store
+func (s *Service) skipExisting(ctx context.Context, n uint64, opts *RangeOptions) bool {
+	if !opts.SkipExisting || s.chainID == nil {
+		return false
+	}
+	_, err := s.loadProverInput(ctx, n) // Mismatching prover inputs are regenerated
+	return err == nil
+}
+
+// generateBlocksPipelined generates prover inputs for the given blocks with a prepare worker
+// feeding an execute worker through a bounded channel.
+func (s *Service) generateBlocksPipelined(ctx context.Context, blocks []uint64, opts *RangeOptions, pacer *blockPacer, report func(n uint64, err error)) {
+	type prepared struct {
+		n     uint64
+		block *preparedBlock
+		err   error
+	}
+
+	preparedC := make(chan *prepared, pipelineDepth)
+	go func() {
+		defer close(preparedC)
+		for _, n := range blocks {
+			if s.skipExisting(ctx, n, opts) {
+				preparedC <- &prepared{n: n, err: errProverInputExists}
+				continue
+			}
+			if err := s.filterGasUsed(ctx, n, opts); err != nil {
+				preparedC <- &prepared{n: n, err: err}
+				continue
+			}
+			if pacer.Wait(ctx) != nil || s.blocks.Acquire(ctx) != nil {
+				return
+			}
+			block, err := s.preflightAndPrepare(ctx, new(big.Int).SetUint64(n))
+			s.blocks.Release() // Prepared blocks waiting for execution do not hold a slot
+			preparedC <- &prepared{n: n, block: block, err: err}
+		}
+	}()
+
+	for p := range preparedC {
+		if p.err != nil {
+			report(p.n, p.err)
+			continue
+		}
+		err := s.executeWithSlot(ctx, p.block.number)
+		if err == nil {
+			err = s.publish(ctx, p.block, PhaseExecute)
+		}
+		report(p.n, err)
+	}
+}
+
+// executeWithSlot runs the execute phase of generate, waiting for a block processing slot (see Config.MaxConcurrentBlocks)
+func (s *Service) executeWithSlot(ctx context.Context, blockNumber *big.Int) error {
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return err
+	}
+	defer s.blocks.Release()
+
+	return s.execute(ctx, blockNumber)
+}
+
 // Preflight executes the preflight checks for the given block number.
 // If requires the remote RPC to be configured and started
 func (s *Service) Preflight(ctx context.Context, blockNumber *big.Int) error {
-	_, err := s.preflight(ctx, blockNumber)
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return err
+	}
+	defer s.blocks.Release()
 
-	return err
+	data, err := s.preflight(ctx, blockNumber)
+	if err != nil {
+		return err
+	}
+
+	return data.Close()
 }
 
-func (s *Service) preflight(ctx context.Context, blockNumber *big.Int) (*generator.PreflightData, error) {
-	data, err := generator.NewPreflight(s.ethrpc).Preflight(ctx, blockNumber)
+func (s *Service) preflight(ctx context.Context, blockNumber *big.Int) (data *generator.PreflightData, err error) {
+	err = s.withMemoryLimit(ctx, PhasePreflight, blockNumber, func(ctx context.Context) error {
+		data, err = s.runPreflight(ctx, blockNumber)
+		return err
+	})
+	return data, err
+}
+
+func (s *Service) runPreflight(ctx context.Context, blockNumber *big.Int) (*generator.PreflightData, error) {
+	cfg := s.cfg.Preflight
+	cfg.Execution = &s.cfg.Execution
+	data, err := generator.NewPreflight(s.ethrpc, &cfg).Preflight(ctx, blockNumber)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute preflight: %v", err)
+		typ := ErrorTypeChainData
+		if rpc.IsStateUnavailable(err) {
+			typ = ErrorTypeStateUnavailable
+		}
+		return nil, newBlockError(ctx, typ, PhasePreflight, blockNumber, nil, fmt.Errorf("failed to execute preflight: %v", err))
+	}
+
+	if err := s.verifyBlockHeader(ctx, data); err != nil {
+		data.Close()
+		return nil, err
 	}
 
 	if err = s.preflightDataStore.StorePreflightData(ctx, data); err != nil {
-		return nil, fmt.Errorf("failed to store preflight data: %v", err)
+		data.Close()
+		return nil, newBlockError(ctx, ErrorTypeStore, PhasePreflight, data.Block.Number.ToInt(), &data.Block.Hash, fmt.Errorf("failed to store preflight data: %v", err))
 	}
+	s.prunePreflightData(ctx)
 
 	return data, nil
 }
 
+// verifyBlockHeader verifies the header of the collected block against the verify RPC, if configured
+func (s *Service) verifyBlockHeader(ctx context.Context, data *generator.PreflightData) error {
+	if s.verify == nil {
+		return nil
+	}
+	if err := generator.VerifyBlockHeader(ctx, s.verify, data); err != nil {
+		return newBlockError(ctx, ErrorTypeChainData, PhasePreflight, data.Block.Number.ToInt(), &data.Block.Hash, fmt.Errorf("failed to verify block header: %v", err))
+	}
+	log.LoggerFromContext(ctx).Debug("Block header verified", zap.String("block.hash", data.Block.Hash.Hex()))
+	return nil
+}
+
+// CheckParentLinkage checks the header of the block served by the remote RPC links to the header of its parent (see
+// generator.CheckParentLinkage), it is skipped offline (local chain data or replayed RPC calls)
+func (s *Service) CheckParentLinkage(ctx context.Context, blockNumber *big.Int) error {
+	if s.cfg.Chain.RPC == nil || s.cfg.Chain.RPCReplay != "" || s.cfg.Chain.DataDir != "" {
+		return nil
+	}
+	if err := generator.CheckParentLinkage(ctx, s.ethrpc, blockNumber); err != nil {
+		return fmt.Errorf("canonical check failed: %v", err)
+	}
+	log.LoggerFromContext(ctx).Debug("Block parent linkage checked", zap.Stringer("block.number", blockNumber))
+	return nil
+}
+
+// ExportPreflight executes the preflight checks for the given block number, as Preflight does, and writes the collected
+// preflight data as a standalone JSON export (see generator.PreflightExport) to w
+func (s *Service) ExportPreflight(ctx context.Context, blockNumber *big.Int, w io.Writer) error {
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return err
+	}
+	defer s.blocks.Release()
+
+	data, err := s.preflight(ctx, blockNumber)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	return generator.WritePreflightExport(w, data)
+}
+
 func (s *Service) Prepare(ctx context.Context, blockNumber *big.Int) error {
 	if s.chainID == nil {
 		return fmt.Errorf("chain ID missing")
 	}
-	return s.prepare(ctx, blockNumber)
+
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return err
+	}
+	defer s.blocks.Release()
+
+	_, err := s.prepare(ctx, blockNumber)
+	return err
 }
 
-func (s *Service) prepare(ctx context.Context, blockNumber *big.Int) error {
+// PrepareImport prepares the prover inputs of the block of a JSON preflight export (see generator.PreflightExport) read from r,
+// instead of the preflight data of the store
+func (s *Service) PrepareImport(ctx context.Context, r io.Reader) error {
+	if s.chainID == nil {
+		return fmt.Errorf("chain ID missing")
+	}
+
+	export, err := generator.ReadPreflightExport(r)
+	if err != nil {
+		return fmt.Errorf("failed to import preflight data: %v", err)
+	}
+	if export.ChainID.ToInt().Cmp(s.chainID) != 0 {
+		return fmt.Errorf("failed to import preflight data: chain ID mismatch: expected %v but got %v", s.chainID, export.ChainID.ToInt())
+	}
+
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return err
+	}
+	defer s.blocks.Release()
+
+	_, err = s.prepareData(ctx, export.Data)
+	return err
+}
+
+// PrepareBlockRLP prepares the prover inputs of a raw RLP-encoded block read from r, without fetching the block
+//
+// The pre-state of the block is read from the JSON preflight export read from witness if set (see generator.PreflightExport),
+// else from the local chain data (or RPC) if configured, running the preflight of the block, else from the stored preflight
+// data of the block. The block must be the one of the preflight data, and its parent must be known to the chain data.
+func (s *Service) PrepareBlockRLP(ctx context.Context, r, witness io.Reader) error {
+	if s.chainID == nil {
+		return fmt.Errorf("chain ID missing")
+	}
+
+	block, err := generator.ReadBlockRLP(r)
+	if err != nil {
+		return err
+	}
+
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return err
+	}
+	defer s.blocks.Release()
+
+	var data *generator.PreflightData
+	switch {
+	case witness != nil:
+		export, err := generator.ReadPreflightExport(witness)
+		if err != nil {
+			return fmt.Errorf("failed to import preflight data: %v", err)
+		}
+		if export.ChainID.ToInt().Cmp(s.chainID) != 0 {
+			return fmt.Errorf("failed to import preflight data: chain ID mismatch: expected %v but got %v", s.chainID, export.ChainID.ToInt())
+		}
+		data = export.Data
+	case s.ethrpc != nil:
+		parent, err := s.ethrpc.HeaderByHash(ctx, block.ParentHash())
+		if err != nil {
+			return fmt.Errorf("failed to fetch parent %v of block %v: %v", block.ParentHash().Hex(), block.Number(), err)
+		}
+		if parent.Number.Uint64()+1 != block.NumberU64() {
+			return fmt.Errorf("invalid block %v: parent %v is block %v", block.Number(), block.ParentHash().Hex(), parent.Number)
+		}
+
+		cfg := s.cfg.Preflight
+		cfg.Execution = &s.cfg.Execution
+		if data, err = generator.NewPreflight(generator.NewBlockClient(s.ethrpc, block), &cfg).Preflight(ctx, block.Number()); err != nil {
+			return fmt.Errorf("failed to execute preflight: %v", err)
+		}
+		if err = s.verifyBlockHeader(ctx, data); err != nil {
+			data.Close()
+			return err
+		}
+		if err = s.preflightDataStore.StorePreflightData(ctx, data); err != nil {
+			data.Close()
+			return fmt.Errorf("failed to store preflight data: %v", err)
+		}
+	default:
+		if data, err = s.preflightDataStore.LoadPreflightData(ctx, s.chainID.Uint64(), block.NumberU64()); err != nil {
+			return fmt.Errorf("failed to load preflight data: %v", err)
+		}
+	}
+	defer data.Close()
+
+	blockData, err := data.WithBlock(block)
+	if err != nil {
+		return err
+	}
+	_, err = s.prepareData(ctx, blockData)
+	return err
+}
+
+func (s *Service) prepare(ctx context.Context, blockNumber *big.Int) (*preparedBlock, error) {
 	data, err := s.preflightDataStore.LoadPreflightData(ctx, s.chainID.Uint64(), blockNumber.Uint64())
 	if err != nil {
-		return fmt.Errorf("failed to load preflight data: %v", err)
+		return nil, newBlockError(ctx, ErrorTypeStore, PhasePrepare, blockNumber, nil, fmt.Errorf("failed to load preflight data: %v", err))
 	}
+	defer data.Close()
+
+	return s.prepareData(ctx, data)
+}
 
-	inputs, err := generator.NewPreparer().Prepare(ctx, data)
+func (s *Service) prepareData(ctx context.Context, data *generator.PreflightData) (block *preparedBlock, err error) {
+	err = s.withMemoryLimit(ctx, PhasePrepare, data.Block.Number.ToInt(), func(ctx context.Context) error {
+		block, err = s.runPrepare(ctx, data)
+		return err
+	})
+	return block, err
+}
+
+func (s *Service) runPrepare(ctx context.Context, data *generator.PreflightData) (*preparedBlock, error) {
+	inputs, err := generator.NewPreparer(&s.cfg.Execution).Prepare(ctx, data)
 	if err != nil {
-		return fmt.Errorf("failed to prepare provable inputs: %v", err)
+		return nil, newBlockError(ctx, ErrorTypePrepare, PhasePrepare, data.Block.Number.ToInt(), &data.Block.Hash, fmt.Errorf("failed to prepare provable inputs: %v", err))
+	}
+	inputs.Labels = s.cfg.Labels
+	if s.cfg.ProofFormat == input.ProofFormatMultiproof {
+		if err := inputs.ToMultiproof(); err != nil {
+			return nil, newBlockError(ctx, ErrorTypePrepare, PhasePrepare, data.Block.Number.ToInt(), &data.Block.Hash, fmt.Errorf("failed to compress witness: %v", err))
+		}
+	}
+	if s.cfg.Execution.WitnessCommitment {
+		if err := inputs.CommitWitness(); err != nil {
+			return nil, newBlockError(ctx, ErrorTypePrepare, PhasePrepare, data.Block.Number.ToInt(), &data.Block.Hash, fmt.Errorf("failed to commit to witness: %v", err))
+		}
 	}
 
 	err = s.ProverInputStore.StoreProverInput(ctx, inputs)
 	if err != nil {
-		return fmt.Errorf("failed to store provable inputs: %v", err)
+		return nil, newBlockError(ctx, ErrorTypeStore, PhasePrepare, data.Block.Number.ToInt(), &data.Block.Hash, fmt.Errorf("failed to store provable inputs: %v", err))
+	}
+
+	return &preparedBlock{number: data.Block.Number.ToInt(), hash: data.Block.Hash, labels: inputs.Labels}, nil
+}
+
+// publish publishes a reference to the stored prover input of the block to the sink, if any, once its last phase succeeded
+func (s *Service) publish(ctx context.Context, block *preparedBlock, phase Phase) error {
+	if s.sink == nil {
+		return nil
 	}
 
+	locations, err := inputstore.ProverInputLocations(&s.cfg.ProverInputStore, s.chainID.Uint64(), block.number.Uint64())
+	if err != nil {
+		return newBlockError(ctx, ErrorTypePublish, phase, block.number, &block.hash, fmt.Errorf("failed to locate provable inputs: %v", err))
+	}
+	msg := &sink.Message{
+		ChainID:     s.chainID.Uint64(),
+		BlockNumber: block.number.Uint64(),
+		BlockHash:   block.hash.Hex(),
+		Phase:       string(phase),
+		Locations:   locations,
+		Labels:      block.labels,
+	}
+	if err := s.sink.Publish(ctx, msg); err != nil {
+		return newBlockError(ctx, ErrorTypePublish, phase, block.number, &block.hash, fmt.Errorf("failed to publish provable inputs: %v", err))
+	}
 	return nil
 }
 
-func (s *Service) Execute(ctx context.Context, blockNumber *big.Int) error {
+// ExecuteOptions are the options for executing a block on its prover inputs.
+type ExecuteOptions struct {
+	// CrossCheckRPC is an optional JSON-RPC URL of a reference node against which a sample of the block's
+	// transactions is cross-checked after execution (see generator.CrossChecker)
+	CrossCheckRPC string
+}
+
+func (s *Service) Execute(ctx context.Context, blockNumber *big.Int, opts *ExecuteOptions) error {
 	if s.chainID == nil {
 		return fmt.Errorf("chain ID missing")
 	}
 
-	return s.execute(ctx, blockNumber)
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return err
+	}
+	defer s.blocks.Release()
+
+	// Explicit executions always verify the prover input, whatever the root verification configuration
+	inputs, res, err := s.loadAndExecute(ctx, blockNumber, false)
+	if err != nil {
+		return err
+	}
+
+	if opts == nil || opts.CrossCheckRPC == "" {
+		return nil
+	}
+
+	return s.crossCheck(ctx, opts.CrossCheckRPC, inputs, res)
 }
 
-func (s *Service) execute(ctx context.Context, blockNumber *big.Int) error {
-	inputs, err := s.ProverInputStore.LoadProverInput(ctx, s.chainID.Uint64(), blockNumber.Uint64())
+// ExecuteUpToTx executes the stored prover input of a block up to transaction upTo (included) and returns the
+// intermediate state root after every transaction (see generator.ExecuteUpToTx)
+func (s *Service) ExecuteUpToTx(ctx context.Context, blockNumber *big.Int, upTo int) ([]*evm.TxResult, error) {
+	if s.chainID == nil {
+		return nil, fmt.Errorf("chain ID missing")
+	}
+
+	if err := s.blocks.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.blocks.Release()
+
+	inputs, err := s.loadProverInput(ctx, blockNumber.Uint64())
 	if err != nil {
-		return fmt.Errorf("failed to load provable inputs: %v", err)
+		return nil, err
 	}
-	_, err = generator.NewExecutor().Execute(ctx, inputs)
+
+	results, err := generator.ExecuteUpToTx(ctx, &s.cfg.Execution, inputs, upTo)
 	if err != nil {
-		return fmt.Errorf("failed to execute block on provable inputs: %v", err)
+		return results, fmt.Errorf("failed to execute block up to transaction %d: %v", upTo, err)
 	}
+	return results, nil
+}
 
+// execute runs the execute phase of generate, skipping root verification if configured
+// (see generator.ExecutionConfig.SkipRootVerification)
+func (s *Service) execute(ctx context.Context, blockNumber *big.Int) error {
+	if s.cfg.Execution.WitnessCommitment {
+		// Prover inputs are bound to their block by the witness commitment stored at prepare instead
+		return nil
+	}
+	_, _, err := s.loadAndExecute(ctx, blockNumber, s.cfg.Execution.SkipRootVerification)
 	return err
 }
 
+func (s *Service) loadAndExecute(ctx context.Context, blockNumber *big.Int, skipRootVerification bool) (inputs *input.ProverInput, res *core.ProcessResult, err error) {
+	err = s.withMemoryLimit(ctx, PhaseExecute, blockNumber, func(ctx context.Context) error {
+		inputs, res, err = s.runExecute(ctx, blockNumber, skipRootVerification)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return inputs, res, nil
+}
+
+func (s *Service) runExecute(ctx context.Context, blockNumber *big.Int, skipRootVerification bool) (*input.ProverInput, *core.ProcessResult, error) {
+	inputs, err := s.loadProverInput(ctx, blockNumber.Uint64())
+	if err != nil {
+		return nil, nil, newBlockError(ctx, ErrorTypeStore, PhaseExecute, blockNumber, nil, err)
+	}
+	cfg := s.cfg.Execution
+	cfg.SkipRootVerification = skipRootVerification
+	if s.executeReport != nil {
+		cfg.Report = s.executeReport
+	}
+	if s.completenessReport != nil {
+		cfg.CompletenessReport = s.completenessReport
+	}
+	if s.accessList != nil {
+		cfg.AccessList = s.accessList
+	}
+	if s.callGraph != nil {
+		cfg.CallGraph = s.callGraph
+	}
+	cfg.PreExecuteHooks = slices.Concat(cfg.PreExecuteHooks, s.preExecuteHooks)
+	res, err := generator.NewExecutor(&cfg).Execute(ctx, inputs)
+	if err != nil {
+		typ, blockHash := ErrorTypeExecution, inputs.Blocks[0].Header.Hash()
+		if isValidationError(err.Error()) {
+			typ = ErrorTypeValidation
+		}
+		return nil, nil, newBlockError(ctx, typ, PhaseExecute, blockNumber, &blockHash, fmt.Errorf("failed to execute block on provable inputs: %v", err))
+	}
+
+	return inputs, res, nil
+}
+
+// loadProverInput loads the stored prover input of a block and checks it is the one of the requested block and chain
+func (s *Service) loadProverInput(ctx context.Context, blockNumber uint64) (*input.ProverInput, error) {
+	inputs, err := s.ProverInputStore.LoadProverInput(ctx, s.chainID.Uint64(), blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load provable inputs: %v", err)
+	}
+	if err := inputs.CheckBlock(s.chainID.Uint64(), blockNumber); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+// ExportFormat is a serialization format of prover inputs
+type ExportFormat string
+
+const (
+	ExportFormatProverInput      ExportFormat = "prover-input"      // zkpig prover input schema
+	ExportFormatExecutionWitness ExportFormat = "execution-witness" // Standard execution witness layout (see input.ExecutionWitness)
+)
+
+// ParseExportFormat parses an export format, an empty string defaults to ExportFormatProverInput
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch ExportFormat(s) {
+	case "", ExportFormatProverInput:
+		return ExportFormatProverInput, nil
+	case ExportFormatExecutionWitness:
+		return ExportFormatExecutionWitness, nil
+	}
+	return "", fmt.Errorf("invalid format %q (expected one of %q)", s, []ExportFormat{ExportFormatProverInput, ExportFormatExecutionWitness})
+}
+
+// Export writes the stored prover input of a block to w as JSON in the given format
+func (s *Service) Export(ctx context.Context, blockNumber *big.Int, format ExportFormat, w io.Writer) error {
+	if s.chainID == nil {
+		return fmt.Errorf("chain ID missing")
+	}
+
+	inputs, err := s.loadProverInput(ctx, blockNumber.Uint64())
+	if err != nil {
+		return err
+	}
+
+	var v any
+	switch format {
+	case ExportFormatExecutionWitness:
+		if v, err = generator.NewExecutionWitness(ctx, inputs); err != nil {
+			return fmt.Errorf("failed to build execution witness: %v", err)
+		}
+	default:
+		// Big integers are serialized as in the store
+		b, err := inputs.MarshalJSONWithFormat(s.cfg.ProverInputStore.NumberFormat)
+		if err != nil {
+			return fmt.Errorf("failed to encode %v: %v", format, err)
+		}
+		v = json.RawMessage(b)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode %v: %v", format, err)
+	}
+
+	return nil
+}
+
 // Errors returns the error channel for possible internal errors of the service.
 func (s *Service) Errors() <-chan error {
 	if errorable, ok := s.remote.(svc.ErrorReporter); ok {
@@ -207,11 +1163,76 @@ func (s *Service) Errors() <-chan error {
 }
 
 // Stop stops the service.
-// Must be called to release resources.
+// Must be called to release resources, every resource is released even if some fail to, and all failures are returned joined.
 func (s *Service) Stop(ctx context.Context) error {
+	// Waits for the prover inputs being stored in the background, failures are returned once the service is stopped
+	var errs []error
+	if failures := s.waitUploads(); len(failures) > 0 {
+		for _, failure := range failures {
+			log.LoggerFromContext(ctx).Error("Failed to store provable inputs", zap.Uint64("block.number", failure.BlockNumber), zap.Error(failure.Err))
+		}
+		errs = append(errs, fmt.Errorf("failed to store provable inputs for %d blocks", len(failures)))
+	}
+
+	s.pruner.wait()
+
+	if err := s.metrics.stop(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.executeReport != nil {
+		if err := s.executeReport.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close execution report: %v", err))
+		}
+	}
+
+	if s.completenessReport != nil {
+		if err := s.completenessReport.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close completeness report: %v", err))
+		}
+	}
+
+	if s.accessList != nil {
+		if err := s.accessList.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close access list file: %v", err))
+		}
+	}
+
+	if s.callGraph != nil {
+		if err := s.callGraph.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close call graph file: %v", err))
+		}
+	}
+
+	if s.recorder != nil {
+		if err := s.recorder.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.sink != nil {
+		if err := s.sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close sink: %v", err))
+		}
+	}
+
+	if s.chaindata != nil {
+		if err := s.chaindata.Stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	if runnable, ok := s.remote.(svc.Runnable); ok {
-		return runnable.Stop(ctx)
+		if err := runnable.Stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	return nil
+	if runnable, ok := s.verifyRemote.(svc.Runnable); ok {
+		if err := runnable.Stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
@@ -0,0 +1,322 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/kkrt-labs/go-utils/jsonrpc"
+	jsonrpcmrgd "github.com/kkrt-labs/go-utils/jsonrpc/merged"
+	"github.com/kkrt-labs/go-utils/log"
+	"github.com/kkrt-labs/zk-pig/src/generator"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/kkrt-labs/zk-pig/src/rpc"
+	"github.com/kkrt-labs/zk-pig/src/sink"
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestServiceRPCErrorLogsRequestID(t *testing.T) {
+	var requestIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg jsonrpc.RequestMsg
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID}
+		if msg.Method == "eth_chainId" {
+			resp["result"] = "0x539"
+		} else {
+			requestIDs = append(requestIDs, r.Header.Get(rpc.RequestIDHeader))
+			resp["error"] = map[string]interface{}{"code": -32000, "message": "required historical state unavailable"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, newTestConfig(t, ChainConfig{RPC: (&rpc.Config{Config: jsonrpcmrgd.Config{Addr: srv.URL}}).SetDefault()}))
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	ctx := log.WithLogger(context.Background(), zap.New(core))
+	_, err := s.ethrpc.HeaderByNumber(ctx, big.NewInt(1))
+	require.Error(t, err)
+
+	require.Len(t, requestIDs, 1, "state unavailable errors are not retried")
+	failed := logs.FilterMessageSnippet("JSON-RPC call failed").All()
+	require.Len(t, failed, 1)
+	fields := failed[0].ContextMap()
+	assert.Equal(t, "eth_getBlockByNumber", fields["req.method"])
+	assert.Equal(t, requestIDs[0], fields["req.request_id"], "the error is logged with the request ID sent to the node")
+}
+
+func TestServiceProtobufPostShanghaiBlockWithoutWithdrawals(t *testing.T) {
+	to := gethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	chain := newTestChain(t, nil, nil, 2, func(_ int, b *core.BlockGen) { sendTestTx(t, testConfig, b, to, nil) })
+	require.NotNil(t, chain.blocks[1].Withdrawals())
+	require.Empty(t, chain.blocks[1].Withdrawals())
+
+	cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+	cfg.ProverInputStore.Format = input.FormatProtobuf
+	s := newTestService(t, cfg)
+
+	ctx := context.Background()
+	require.NoError(t, s.Generate(ctx, big.NewInt(2), nil))
+	require.NoError(t, s.Execute(ctx, big.NewInt(2), nil), "the prover input read back from protobuf executes")
+}
+
+// recordingStore records the block numbers of the prover inputs stored in the decorated store, in order
+type recordingStore struct {
+	inputstore.ProverInputStore
+
+	mu     sync.Mutex
+	stored []uint64
+}
+
+func (s *recordingStore) StoreProverInput(ctx context.Context, inputs *input.ProverInput) error {
+	s.mu.Lock()
+	s.stored = append(s.stored, inputs.Blocks[0].Header.Number.Uint64())
+	s.mu.Unlock()
+	return s.ProverInputStore.StoreProverInput(ctx, inputs)
+}
+
+// readStoredFiles returns the content of every file under dir by relative path
+func readStoredFiles(t *testing.T, dir string) map[string]string {
+	files := make(map[string]string)
+	require.NoError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return nil // Nothing stored
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(b)
+		return nil
+	}))
+	return files
+}
+
+// goroutineRunning returns whether the stack of a goroutine contains fn
+func goroutineRunning(fn string) bool {
+	buf := make([]byte, 1<<20)
+	return strings.Contains(string(buf[:runtime.Stack(buf, true)]), fn)
+}
+
+func TestServiceGeneratePipelined(t *testing.T) {
+	to := gethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	chain := newTestChain(t, nil, nil, 5, func(_ int, b *core.BlockGen) { sendTestTx(t, testConfig, b, to, nil) })
+
+	// generate runs blocks on a new service with cfg, returning the order prover inputs were stored in and the stored
+	// files. It fails the test if the run does not return or leaves goroutines behind.
+	generate := func(ctx context.Context, t *testing.T, cfg *Config, blocks []uint64, opts *RangeOptions) (summary *RunSummary, stored []uint64, files map[string]string) {
+		s := newTestService(t, cfg)
+		recorder := &recordingStore{ProverInputStore: s.ProverInputStore}
+		s.ProverInputStore = recorder
+
+		refs := make([]*BlockRef, 0, len(blocks))
+		for _, n := range blocks {
+			refs = append(refs, &BlockRef{Number: new(big.Int).SetUint64(n)})
+		}
+
+		done := make(chan *RunSummary)
+		go func() {
+			summary, _ := s.GenerateBlocks(ctx, refs, opts)
+			done <- summary
+		}()
+		select {
+		case summary = <-done:
+		case <-time.After(30 * time.Second):
+			t.Fatal("generation did not return")
+		}
+
+		assert.Eventually(t, func() bool { return !goroutineRunning("generateBlocksPipelined") }, 5*time.Second, 10*time.Millisecond, "the prepare worker exited")
+
+		return summary, recorder.stored, readStoredFiles(t, filepath.Join(cfg.DataDir, testChainID.String(), "inputs"))
+	}
+
+	t.Run("same stored inputs as sequential", func(t *testing.T) {
+		var (
+			seqStored, pipeStored []uint64
+			seqFiles, pipeFiles   map[string]string
+		)
+		t.Run("sequential", func(t *testing.T) {
+			var summary *RunSummary
+			summary, seqStored, seqFiles = generate(context.Background(), t, newTestConfig(t, ChainConfig{DataDir: chain.dir}), []uint64{2, 3, 4, 5}, &RangeOptions{})
+			require.Equal(t, 4, summary.Succeeded)
+		})
+		t.Run("pipelined", func(t *testing.T) {
+			var summary *RunSummary
+			summary, pipeStored, pipeFiles = generate(context.Background(), t, newTestConfig(t, ChainConfig{DataDir: chain.dir}), []uint64{2, 3, 4, 5}, &RangeOptions{Pipeline: true})
+			require.Equal(t, 4, summary.Succeeded)
+		})
+
+		assert.Equal(t, []uint64{2, 3, 4, 5}, seqStored)
+		assert.Equal(t, seqStored, pipeStored, "prover inputs are stored in the same order")
+		require.Len(t, seqFiles, 4)
+		assert.Equal(t, seqFiles, pipeFiles, "the same prover inputs are stored")
+	})
+
+	t.Run("prepare failure", func(t *testing.T) {
+		// Block 9 is not in the chain, its preflight fails and the following blocks are still generated
+		summary, stored, _ := generate(context.Background(), t, newTestConfig(t, ChainConfig{DataDir: chain.dir}), []uint64{2, 9, 3, 4}, &RangeOptions{Pipeline: true})
+		assert.Equal(t, 3, summary.Succeeded)
+		require.Len(t, summary.Failed, 1)
+		assert.Equal(t, uint64(9), summary.Failed[0].BlockNumber)
+		assert.Equal(t, []uint64{2, 3, 4}, stored)
+	})
+
+	t.Run("prepare failure with stop on error", func(t *testing.T) {
+		// The run is canceled on the failure, while the producer may already be preparing the next blocks
+		summary, stored, _ := generate(context.Background(), t, newTestConfig(t, ChainConfig{DataDir: chain.dir}), []uint64{9, 2, 3, 4, 5}, &RangeOptions{Pipeline: true, StopOnError: true})
+		assert.Equal(t, 0, summary.Succeeded)
+		require.Len(t, summary.Failed, 1)
+		assert.Equal(t, uint64(9), summary.Failed[0].BlockNumber)
+		assert.Subset(t, []uint64{2, 3}, stored, "no block is prepared once the run is stopped")
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Cancels the run while block 2 is executed, once the producer prepared block 3 and block 4, so it is blocked
+		// sending block 4 to the full pipeline
+		cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+		cfg.Execution.PreExecuteHooks = []generator.PreExecuteHook{func(_ context.Context, env *generator.ExecutionEnv) error {
+			if env.Block.NumberU64() == 2 {
+				for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+					if _, err := os.Stat(filepath.Join(cfg.DataDir, testChainID.String(), "inputs", "4.json")); err == nil {
+						break
+					}
+				}
+				cancel()
+			}
+			return nil
+		}}
+		summary, stored, _ := generate(ctx, t, cfg, []uint64{2, 3, 4, 5}, &RangeOptions{Pipeline: true})
+		require.NotNil(t, summary)
+		assert.Equal(t, []uint64{2, 3, 4}, stored)
+	})
+}
+
+func TestServiceMaxBlocks(t *testing.T) {
+	s := &Service{cfg: &Config{MaxBlocks: 3}}
+	assert.NoError(t, s.checkMaxBlocks(1))
+	assert.NoError(t, s.checkMaxBlocks(3), "exactly the maximum is allowed")
+	assert.EqualError(t, s.checkMaxBlocks(4), "4 blocks requested, more than the maximum of 3 blocks per run (max-blocks)")
+	assert.NoError(t, (&Service{cfg: &Config{}}).checkMaxBlocks(1_000_000), "unlimited by default")
+
+	chain := newTestChain(t, nil, nil, 5, nil)
+	cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+	cfg.MaxBlocks = 3
+	s = newTestService(t, cfg)
+	ctx := context.Background()
+
+	summary, err := s.GenerateRange(ctx, big.NewInt(2), big.NewInt(4), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Succeeded)
+
+	_, err = s.GenerateRange(ctx, big.NewInt(2), big.NewInt(5), nil)
+	assert.EqualError(t, err, "block range 2-5: 4 blocks requested, more than the maximum of 3 blocks per run (max-blocks)")
+
+	refs := []*BlockRef{{Number: big.NewInt(2)}, {Number: big.NewInt(3)}, {Number: big.NewInt(4)}}
+	_, err = s.GenerateBlocks(ctx, refs, &RangeOptions{SkipExisting: true})
+	require.NoError(t, err)
+
+	_, err = s.GenerateBlocks(ctx, append(refs, &BlockRef{Number: big.NewInt(5)}), nil)
+	assert.EqualError(t, err, "block list: 4 blocks requested, more than the maximum of 3 blocks per run (max-blocks)")
+}
+
+func TestServiceGenerateStopAfter(t *testing.T) {
+	chain := newTestChain(t, nil, nil, 3, nil)
+
+	for _, tc := range []struct {
+		phase             Phase
+		pipeline          bool
+		preflight, inputs int  // Number of stored preflight data and prover inputs
+		execute           bool // Whether blocks are executed
+	}{
+		{phase: PhasePreflight, preflight: 2},
+		{phase: PhasePrepare, preflight: 2, inputs: 2},
+		{phase: PhasePrepare, pipeline: true, preflight: 2, inputs: 2},
+		{phase: PhaseExecute, preflight: 2, inputs: 2, execute: true},
+		{phase: "", preflight: 2, inputs: 2, execute: true},
+	} {
+		t.Run(fmt.Sprintf("%q pipeline=%v", tc.phase, tc.pipeline), func(t *testing.T) {
+			executed := false
+			cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+			cfg.Execution.PreExecuteHooks = []generator.PreExecuteHook{func(context.Context, *generator.ExecutionEnv) error {
+				executed = true
+				return nil
+			}}
+			s := newTestService(t, cfg)
+
+			summary, err := s.GenerateRange(context.Background(), big.NewInt(2), big.NewInt(3), &RangeOptions{StopAfter: tc.phase, Pipeline: tc.pipeline})
+			require.NoError(t, err)
+			assert.Equal(t, 2, summary.Succeeded)
+
+			assert.Len(t, readStoredFiles(t, filepath.Join(cfg.DataDir, testChainID.String(), "preflight")), tc.preflight, "preflight data")
+			assert.Len(t, readStoredFiles(t, filepath.Join(cfg.DataDir, testChainID.String(), "inputs")), tc.inputs, "prover inputs")
+			assert.Equal(t, tc.execute, executed, "execution")
+		})
+	}
+}
+
+// closeFailingSink is a sink failing to close
+type closeFailingSink struct {
+	closed bool
+}
+
+func (s *closeFailingSink) Publish(context.Context, *sink.Message) error { return nil }
+
+func (s *closeFailingSink) Close() error {
+	s.closed = true
+	return fmt.Errorf("connection reset")
+}
+
+func TestServiceStopReleasesEveryResource(t *testing.T) {
+	chain := newTestChain(t, nil, nil, 2, nil)
+	cfg := newTestConfig(t, ChainConfig{DataDir: chain.dir})
+	cfg.ExecuteReport = filepath.Join(t.TempDir(), "execute.jsonl")
+	cfg.AccessList = filepath.Join(t.TempDir(), "access-list.jsonl")
+	s, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, s.Start(context.Background()))
+
+	// The reports fail to close, as does the sink
+	require.NoError(t, s.executeReport.Close())
+	require.NoError(t, s.accessList.Close())
+	failing := &closeFailingSink{}
+	s.sink = failing
+
+	err = s.Stop(context.Background())
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "failed to close execution report")
+	assert.ErrorContains(t, err, "failed to close access list file")
+	assert.ErrorContains(t, err, "failed to close sink: connection reset")
+	assert.True(t, failing.closed, "resources are released after a failure")
+
+	// The chain database is released after the failures, so another service can open it
+	s = newTestService(t, newTestConfig(t, ChainConfig{DataDir: chain.dir}))
+	assert.NoError(t, s.Generate(context.Background(), big.NewInt(2), nil))
+}
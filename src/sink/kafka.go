@@ -0,0 +1,107 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// KafkaSink is a Sink producing messages to a Kafka topic, speaking the Kafka protocol to the brokers
+//
+// Messages are produced as JSON records keyed by Message.Key with the partitioner of the Java client (murmur2), so the
+// messages of a block always land in the same partition, as with KafkaRESTSink. A publish returns once the record is
+// acknowledged by all in-sync replicas (acks=all). The writer does not retry on its own, failed publishes being retried
+// by RetrySink. TLS is used with kafka+tls:// URLs, SASL/PLAIN authentication when credentials are set in the URL.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a new KafkaSink producing to topic on the Kafka brokers at rawURL, a comma separated list of
+// kafka://[user:password@]host:port or kafka+tls://[user:password@]host:port URLs
+func NewKafkaSink(rawURL, topic string, timeout time.Duration) (*KafkaSink, error) {
+	var (
+		scheme  string
+		brokers []string
+		user    *url.Userinfo
+	)
+	for _, broker := range strings.Split(rawURL, ",") {
+		u, err := url.Parse(strings.TrimSpace(broker))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Kafka broker URL: %v", err)
+		}
+		if (u.Scheme != "kafka" && u.Scheme != "kafka+tls") || u.Hostname() == "" || u.Port() == "" {
+			return nil, fmt.Errorf("invalid Kafka broker URL %q (expected kafka://[user:password@]host:port or kafka+tls://[user:password@]host:port)", broker)
+		}
+		if scheme != "" && u.Scheme != scheme {
+			return nil, fmt.Errorf("invalid Kafka broker URL %q (all brokers must use the %v scheme)", broker, scheme)
+		}
+		scheme = u.Scheme
+		if user == nil {
+			user = u.User
+		}
+		brokers = append(brokers, u.Host)
+	}
+	if topic == "" || strings.ContainsAny(topic, "/ ") {
+		return nil, fmt.Errorf("invalid Kafka topic %q", topic)
+	}
+
+	transport := &kafka.Transport{
+		DialTimeout:    timeout,
+		ClientID:       "zkpig",
+		MetadataTopics: []string{topic},
+	}
+	if scheme == "kafka+tls" {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if user != nil {
+		password, _ := user.Password()
+		transport.SASL = plain.Mechanism{Username: user.Username(), Password: password}
+	}
+
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Murmur2Balancer{},
+			RequiredAcks: kafka.RequireAll,
+			MaxAttempts:  1, // Retried by RetrySink
+			BatchSize:    1, // Every publish is produced right away
+			WriteTimeout: timeout,
+			ReadTimeout:  timeout,
+			Transport:    transport,
+		},
+	}, nil
+}
+
+// Publish produces the message to the topic
+func (s *KafkaSink) Publish(ctx context.Context, msg *Message) error {
+	value, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.writer.WriteTimeout)
+	defer cancel()
+	err = s.writer.WriteMessages(ctx, kafka.Message{Key: []byte(msg.Key()), Value: value})
+	var werrs kafka.WriteErrors
+	if errors.As(err, &werrs) && len(werrs) == 1 {
+		err = werrs[0] // Single message
+	}
+	if err != nil {
+		return fmt.Errorf("failed to produce to Kafka topic %v: %v", s.writer.Topic, err)
+	}
+	return nil
+}
+
+// Close closes the connections to the Kafka brokers, every message having already been acknowledged
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,129 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Content types of the v2 API of the Kafka REST Proxy
+const (
+	kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+	kafkaAccept          = "application/vnd.kafka.v2+json"
+)
+
+// KafkaRESTSink is a Sink producing messages to a Kafka topic through a Kafka REST Proxy (v2 API)
+//
+// It only speaks HTTP to the proxy, not the Kafka protocol to the brokers, so a proxy must be deployed in front of the
+// Kafka cluster. Messages are produced as JSON records keyed by Message.Key, so the messages of a block always land in
+// the same partition. Basic authentication credentials can be set in the URL.
+type KafkaRESTSink struct {
+	endpoint string
+	user     *url.Userinfo
+	client   *http.Client
+}
+
+// NewKafkaRESTSink creates a new KafkaRESTSink producing to topic through the Kafka REST Proxy at rawURL (http(s)://[user:password@]host[:port][/path])
+func NewKafkaRESTSink(rawURL, topic string, timeout time.Duration) (*KafkaRESTSink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kafka REST Proxy URL: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Kafka REST Proxy URL %q (expected http(s)://[user:password@]host[:port][/path])", rawURL)
+	}
+	if topic == "" || strings.ContainsAny(topic, "/ ") {
+		return nil, fmt.Errorf("invalid Kafka topic %q", topic)
+	}
+
+	user := u.User
+	u.User = nil
+	return &KafkaRESTSink{
+		endpoint: strings.TrimSuffix(u.String(), "/") + "/topics/" + url.PathEscape(topic),
+		user:     user,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+type kafkaRecord struct {
+	Key   string   `json:"key"`
+	Value *Message `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []*kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition *int   `json:"partition"`
+		Offset    *int64 `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+type kafkaError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// Publish produces the message to the topic
+func (s *KafkaRESTSink) Publish(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(&kafkaProduceRequest{Records: []*kafkaRecord{{Key: msg.Key(), Value: msg}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaJSONContentType)
+	req.Header.Set("Accept", kafkaAccept)
+	if s.user != nil {
+		password, _ := s.user.Password()
+		req.SetBasicAuth(s.user.Username(), password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to produce to Kafka REST Proxy: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Kafka REST Proxy response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var kerr kafkaError
+		if json.Unmarshal(respBody, &kerr) == nil && kerr.Message != "" {
+			return fmt.Errorf("kafka REST Proxy error (status %d, code %d): %s", resp.StatusCode, kerr.ErrorCode, kerr.Message)
+		}
+		return fmt.Errorf("kafka REST Proxy error (status %d)", resp.StatusCode)
+	}
+
+	var res kafkaProduceResponse
+	if err := json.Unmarshal(respBody, &res); err != nil {
+		return fmt.Errorf("invalid Kafka REST Proxy response: %v", err)
+	}
+	for _, offset := range res.Offsets {
+		if offset.ErrorCode != nil || offset.Error != "" {
+			return fmt.Errorf("failed to produce to Kafka: %s", offset.Error)
+		}
+	}
+	return nil
+}
+
+// Close closes the idle connections to the Kafka REST Proxy
+func (s *KafkaRESTSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,114 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSSink is a Sink publishing messages to a NATS subject
+//
+// It holds a single connection to the NATS servers, opened on the first publish and re-established by the client on
+// failures. TLS is used with tls:// URLs or when the server requires it. Publishes are serialized, every message being
+// flushed to the server before the next one is published, so errors reported by the server (e.g. permission
+// violations) are returned by the publish which caused them.
+type NATSSink struct {
+	url     string
+	subject string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn *nats.Conn
+}
+
+// NewNATSSink creates a new NATSSink publishing to subject on the NATS servers at rawURL, a comma separated list of
+// nats://[user:password@]host[:port] or tls://[user:password@]host[:port] URLs
+func NewNATSSink(rawURL, subject string, timeout time.Duration) (*NATSSink, error) {
+	for _, server := range strings.Split(rawURL, ",") {
+		u, err := url.Parse(strings.TrimSpace(server))
+		if err != nil {
+			return nil, fmt.Errorf("invalid NATS URL: %v", err)
+		}
+		if (u.Scheme != "nats" && u.Scheme != "tls") || u.Hostname() == "" {
+			return nil, fmt.Errorf("invalid NATS URL %q (expected nats://[user:password@]host[:port] or tls://[user:password@]host[:port])", server)
+		}
+	}
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return nil, fmt.Errorf("invalid NATS subject %q", subject)
+	}
+
+	return &NATSSink{
+		url:     rawURL,
+		subject: subject,
+		timeout: timeout,
+	}, nil
+}
+
+// connect returns the connection to the NATS servers, opening it if needed, s.mu must be held
+func (s *NATSSink) connect() (*nats.Conn, error) {
+	if s.conn != nil && !s.conn.IsClosed() {
+		return s.conn, nil
+	}
+
+	conn, err := nats.Connect(s.url, nats.Name("zkpig"), nats.Timeout(s.timeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS server: %v", err)
+	}
+	s.conn = conn
+	return conn, nil
+}
+
+// Publish publishes the message as JSON to the subject
+func (s *NATSSink) Publish(ctx context.Context, msg *Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+
+	if max := conn.MaxPayload(); max > 0 && int64(len(payload)) > max {
+		return fmt.Errorf("message of %d bytes exceeds the NATS server max payload of %d bytes", len(payload), max)
+	}
+
+	// The server processes messages in order, so a flush acknowledges the publish. Errors the server reports in
+	// between are only recorded by the connection, a new one is the error of this publish.
+	lastErr := conn.LastError()
+	if err := conn.Publish(s.subject, payload); err != nil {
+		return fmt.Errorf("failed to publish to NATS server: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	if err := conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush to NATS server: %v", err)
+	}
+	if err := conn.LastError(); err != nil && err != lastErr { //nolint:errorlint // identity of the recorded error
+		return fmt.Errorf("NATS server error: %v", err)
+	}
+	return nil
+}
+
+// Close closes the connection to the NATS servers, if any, every message having already been flushed
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return nil
+}
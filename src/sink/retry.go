@@ -0,0 +1,83 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/kkrt-labs/go-utils/log"
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+	"go.uber.org/zap"
+)
+
+// RetryError is returned by a RetrySink once all attempts to publish a message failed
+type RetryError struct {
+	Key      string
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("failed to publish %v after %d attempt(s): %v", e.Key, e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// RetrySink is a Sink decorator retrying failed publishes with an exponential backoff
+//
+// Every failure is retried, as publishing the same message twice is harmless (consumers identify prover inputs
+// by the message key), except when the context is done.
+type RetrySink struct {
+	sink Sink
+	cfg  inputstore.RetryConfig
+}
+
+// NewRetrySink creates a new RetrySink, s is returned as is if cfg allows a single attempt
+func NewRetrySink(s Sink, cfg *inputstore.RetryConfig) Sink {
+	if cfg == nil || cfg.MaxAttempts <= 1 {
+		return s
+	}
+	return &RetrySink{sink: s, cfg: *cfg}
+}
+
+// Publish publishes the message, retrying on failure
+func (s *RetrySink) Publish(ctx context.Context, msg *Message) error {
+	bckff := s.cfg.Random.BackOff(backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(s.cfg.InitialInterval),
+		backoff.WithMaxInterval(s.cfg.MaxInterval),
+		backoff.WithMaxElapsedTime(0), // Attempts are bounded by MaxAttempts
+	))
+
+	attempts := 0
+	err := backoff.RetryNotify(
+		func() error {
+			attempts++
+			err := s.sink.Publish(ctx, msg)
+			if err != nil && ctx.Err() != nil {
+				return backoff.Permanent(err)
+			}
+			return err
+		},
+		backoff.WithContext(backoff.WithMaxRetries(bckff, uint64(s.cfg.MaxAttempts-1)), ctx),
+		func(err error, d time.Duration) {
+			log.LoggerFromContext(ctx).Warn("Retrying publish in...",
+				zap.String("key", msg.Key()),
+				zap.Int("attempt", attempts),
+				zap.Error(err),
+				zap.Duration("duration", d),
+			)
+		},
+	)
+	if err != nil {
+		return &RetryError{Key: msg.Key(), Attempts: attempts, Err: err}
+	}
+	return nil
+}
+
+// Close closes the decorated sink
+func (s *RetrySink) Close() error {
+	return s.sink.Close()
+}
@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+)
+
+// Type is the type of message queue a Sink publishes to
+type Type string
+
+const (
+	TypeKafka     Type = "kafka"      // Kafka topic, produced to the brokers (see NewKafkaSink)
+	TypeKafkaREST Type = "kafka-rest" // Kafka topic, published to through a Kafka REST Proxy (see NewKafkaRESTSink)
+	TypeNATS      Type = "nats"       // NATS subject (see NewNATSSink)
+)
+
+// ParseType parses a sink type
+func ParseType(s string) (Type, error) {
+	switch t := Type(strings.ToLower(s)); t {
+	case TypeKafka, TypeKafkaREST, TypeNATS:
+		return t, nil
+	}
+	return "", fmt.Errorf("invalid sink type %q (expected one of %q)", s, []Type{TypeKafka, TypeKafkaREST, TypeNATS})
+}
+
+// Message is published for every generated prover input
+//
+// It references the stored prover input rather than embedding it, as prover inputs are typically larger than the
+// maximum message size of the queues.
+type Message struct {
+	ChainID     uint64            `json:"chainId"`
+	BlockNumber uint64            `json:"blockNumber"`
+	BlockHash   string            `json:"blockHash"`
+	Phase       string            `json:"phase"`            // Last generation phase run before publishing (prepare or execute)
+	Locations   []string          `json:"locations"`        // URLs of the stored prover input (see inputstore.ProverInputLocations)
+	Labels      map[string]string `json:"labels,omitempty"` // Labels of the prover input (see input.ProverInput)
+}
+
+// Key returns the key of the message, identifying the prover input on the queue
+func (msg *Message) Key() string {
+	return fmt.Sprintf("%d/%d", msg.ChainID, msg.BlockNumber)
+}
+
+// Sink publishes messages to a message queue
+type Sink interface {
+	// Publish publishes the message, it returns once the message is acknowledged by the queue
+	Publish(ctx context.Context, msg *Message) error
+
+	// Close releases the connections to the queue, once no more messages are published
+	Close() error
+}
+
+// Config is the configuration of a Sink
+type Config struct {
+	Type  Type   // Empty if prover inputs are not published
+	URL   string // URL of the Kafka brokers (kafka:// or kafka+tls://...), of the NATS servers (nats:// or tls://...) or of the Kafka REST Proxy (http(s)://...)
+	Topic string // Kafka topic or NATS subject
+
+	Timeout time.Duration          // Timeout of a publish attempt, defaults to DefaultTimeout
+	Retry   inputstore.RetryConfig // Retry policy of failed publishes (see NewRetrySink)
+}
+
+// DefaultTimeout is the default timeout of a publish attempt
+const DefaultTimeout = 10 * time.Second
+
+// New creates the Sink of cfg, retrying failed publishes
+func New(cfg *Config) (Sink, error) {
+	if cfg.URL == "" || cfg.Topic == "" {
+		return nil, fmt.Errorf("%s sink requires a URL and a topic", cfg.Type)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	var s Sink
+	var err error
+	switch cfg.Type {
+	case TypeKafka:
+		s, err = NewKafkaSink(cfg.URL, cfg.Topic, timeout)
+	case TypeKafkaREST:
+		s, err = NewKafkaRESTSink(cfg.URL, cfg.Topic, timeout)
+	case TypeNATS:
+		s, err = NewNATSSink(cfg.URL, cfg.Topic, timeout)
+	default:
+		return nil, fmt.Errorf("unsupported sink type %q", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewRetrySink(s, &cfg.Retry), nil
+}
@@ -0,0 +1,165 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/kkrt-labs/zk-pig/src/generator"
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+)
+
+// Sources of a storage diff
+const (
+	StorageDiffSourceProverInputs = "prover-inputs" // Executions of the stored prover inputs of the range, offline
+	StorageDiffSourceRPC          = "rpc"           // Storage proofs of the requested slots at both blocks
+)
+
+// StorageDiffReport lists the storage slots of an account whose value changed between the state after block From and
+// the state after block To
+type StorageDiffReport struct {
+	Address gethcommon.Address      `json:"address"`
+	From    uint64                  `json:"from"`
+	To      uint64                  `json:"to"`
+	Source  string                  `json:"source"`
+	Changes []*generator.SlotChange `json:"changes"` // Changed slots, sorted by slot
+}
+
+// StorageDiff computes the storage slots of an account that changed between blocks from and to, with their old and new values
+//
+// If the prover inputs of every block of (from, to] are stored, the diff is computed offline by executing them on their
+// witness (see generator.StorageChanges), so every changed slot is found. Otherwise the given slots are compared with
+// storage proofs from the node at both blocks. If slots are given, only these slots are reported.
+func (s *Service) StorageDiff(ctx context.Context, address gethcommon.Address, from, to *big.Int, slots []gethcommon.Hash) (*StorageDiffReport, error) {
+	if from.Cmp(to) >= 0 {
+		return nil, fmt.Errorf("invalid block range: %v >= %v", from, to)
+	}
+	if s.chainID == nil {
+		return nil, fmt.Errorf("chain ID missing")
+	}
+
+	report := &StorageDiffReport{
+		Address: address,
+		From:    from.Uint64(),
+		To:      to.Uint64(),
+		Changes: []*generator.SlotChange{},
+	}
+
+	changes, missing, err := s.storageDiffFromProverInputs(ctx, address, from.Uint64(), to.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	if missing == 0 {
+		report.Source = StorageDiffSourceProverInputs
+	} else {
+		if s.ethrpc == nil {
+			return nil, fmt.Errorf("prover input of block %d is not stored, computing the diff requires a remote RPC or local chain data", missing)
+		}
+		if len(slots) == 0 {
+			return nil, fmt.Errorf("prover input of block %d is not stored, the slots to compare must be given to compute the diff over RPC", missing)
+		}
+		report.Source = StorageDiffSourceRPC
+		if changes, err = s.storageDiffFromRPC(ctx, address, from, to, slots); err != nil {
+			return nil, err
+		}
+	}
+
+	wanted := make(map[gethcommon.Hash]bool, len(slots))
+	for _, slot := range slots {
+		wanted[slot] = true
+	}
+	for _, change := range changes {
+		if change.Before != change.After && (len(slots) == 0 || wanted[change.Slot]) {
+			report.Changes = append(report.Changes, change)
+		}
+	}
+	sort.Slice(report.Changes, func(i, j int) bool {
+		return bytes.Compare(report.Changes[i].Slot[:], report.Changes[j].Slot[:]) < 0
+	})
+
+	return report, nil
+}
+
+// storageDiffFromProverInputs composes the storage changes of the blocks (from, to] from their stored prover inputs,
+// it returns the first block whose prover input is missing if any
+//
+// The value of a slot before the range is its value before the first block accessing it, and its value after the range
+// is its value after the last block accessing it.
+func (s *Service) storageDiffFromProverInputs(ctx context.Context, address gethcommon.Address, from, to uint64) ([]*generator.SlotChange, uint64, error) {
+	changes := make(map[gethcommon.Hash]*generator.SlotChange)
+	for n := from + 1; n <= to; n++ {
+		inputs, err := s.ProverInputStore.LoadProverInput(ctx, s.chainID.Uint64(), n)
+		switch {
+		case inputstore.IsNotFound(err):
+			return nil, n, nil
+		case err != nil:
+			return nil, 0, fmt.Errorf("failed to load provable inputs of block %d: %v", n, err)
+		}
+		if err := inputs.CheckBlock(s.chainID.Uint64(), n); err != nil {
+			return nil, 0, err
+		}
+
+		blockChanges, err := generator.StorageChanges(ctx, inputs, address)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to execute block %d: %v", n, err)
+		}
+		for _, change := range blockChanges {
+			if prev, ok := changes[change.Slot]; ok {
+				prev.After = change.After
+			} else {
+				changes[change.Slot] = change
+			}
+		}
+	}
+
+	list := make([]*generator.SlotChange, 0, len(changes))
+	for _, change := range changes {
+		list = append(list, change)
+	}
+	return list, 0, nil
+}
+
+// storageDiffFromRPC compares the values of the slots after blocks from and to, as returned by the node
+func (s *Service) storageDiffFromRPC(ctx context.Context, address gethcommon.Address, from, to *big.Int, slots []gethcommon.Hash) ([]*generator.SlotChange, error) {
+	states := make([]*AccountState, 0, 2)
+	for _, n := range []*big.Int{from, to} {
+		header, err := s.ethrpc.HeaderByNumber(ctx, n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch block %v header: %v", n, err)
+		}
+		state, err := s.rpcAccountState(ctx, header.Number, header.Root, address, slots)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+
+	changes := make([]*generator.SlotChange, 0, len(slots))
+	for _, slot := range slots {
+		changes = append(changes, &generator.SlotChange{Slot: slot, Before: states[0].Storage[slot], After: states[1].Storage[slot]})
+	}
+	return changes, nil
+}
+
+// String returns a human-readable list of the changed slots
+func (r *StorageDiffReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Storage of account %v changed between block %d and block %d (from %s)\n", r.Address.Hex(), r.From, r.To, r.Source)
+	if len(r.Changes) == 0 {
+		fmt.Fprintln(&b, "  No changed slot")
+		return b.String()
+	}
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  SLOT\tBEFORE\tAFTER")
+	for _, change := range r.Changes {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", change.Slot.Hex(), change.Before.Hex(), change.After.Hex())
+	}
+	w.Flush()
+	return b.String()
+}
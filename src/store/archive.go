@@ -0,0 +1,209 @@
+package store
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+)
+
+// ArchiveManifestName is the name of the manifest of a prover inputs archive, which is its last entry
+const ArchiveManifestName = "manifest.json"
+
+// ArchiveManifest lists the prover inputs of an archive (see ExportArchive)
+type ArchiveManifest struct {
+	ChainID uint64          `json:"chainId"`
+	From    uint64          `json:"from"`
+	To      uint64          `json:"to"`
+	Format  string          `json:"format"` // Codec of the prover inputs (see input.LookupCodec)
+	Entries []*ArchiveEntry `json:"entries"`
+}
+
+// ArchiveEntry is a prover input of an archive
+type ArchiveEntry struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"` // Hex encoded SHA-256 checksum of the encoded prover input
+}
+
+// ExportArchive writes the prover inputs of every block in the inclusive range [from, to] loaded from s to w, as a
+// gzipped tar of the prover inputs encoded in JSON (<block>.json) followed by their manifest
+//
+// Every block of the range must have a prover input.
+func ExportArchive(ctx context.Context, s ProverInputStore, w io.Writer, chainID, from, to uint64, numberFormat input.NumberFormat) (*ArchiveManifest, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	codec := &input.JSONCodec{NumberFormat: numberFormat}
+	manifest := &ArchiveManifest{ChainID: chainID, From: from, To: to, Format: input.FormatJSON}
+	modTime := time.Now()
+
+	for n := from; n <= to; n++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pi, err := s.LoadProverInput(ctx, chainID, n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load prover input of block %d: %w", n, err)
+		}
+		data, err := codec.Marshal(pi)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode prover input of block %d: %w", n, err)
+		}
+		checksum := sha256.Sum256(data)
+		entry := &ArchiveEntry{
+			BlockNumber: n,
+			Name:        strconv.FormatUint(n, 10) + "." + input.FormatJSON,
+			Size:        int64(len(data)),
+			SHA256:      hex.EncodeToString(checksum[:]),
+		}
+		if err := writeArchiveFile(tw, entry.Name, data, modTime); err != nil {
+			return nil, err
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode archive manifest: %w", err)
+	}
+	if err := writeArchiveFile(tw, ArchiveManifestName, data, modTime); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return manifest, nil
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime}); err != nil {
+		return fmt.Errorf("failed to write archive entry %v: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write archive entry %v: %w", name, err)
+	}
+	return nil
+}
+
+// ImportArchive stores in s every prover input of an archive written by ExportArchive
+//
+// The archive is read twice: first to load its manifest, then to store its prover inputs, each of them being checked
+// against the checksum of the manifest and against its block and chainID before being stored.
+func ImportArchive(ctx context.Context, s ProverInputStore, r io.ReadSeeker, chainID uint64) (*ArchiveManifest, error) {
+	manifest, err := readArchiveManifest(r)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.ChainID != chainID {
+		return nil, fmt.Errorf("archive chain ID mismatch: expected %d but got %d", chainID, manifest.ChainID)
+	}
+	codec, err := input.LookupCodec(manifest.Format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive manifest: %w", err)
+	}
+	entries := make(map[string]*ArchiveEntry, len(manifest.Entries))
+	for _, entry := range manifest.Entries {
+		entries[entry.Name] = entry
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	err = eachArchiveFile(r, func(name string, tr io.Reader) error {
+		if name == ArchiveManifestName {
+			return nil
+		}
+		entry, ok := entries[name]
+		if !ok {
+			return fmt.Errorf("archive entry %v is not in the manifest", name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("failed to read archive entry %v: %w", name, err)
+		}
+		if checksum := sha256.Sum256(data); hex.EncodeToString(checksum[:]) != entry.SHA256 {
+			return fmt.Errorf("archive entry %v checksum mismatch: expected %v but got %x", name, entry.SHA256, checksum)
+		}
+		pi, err := codec.Unmarshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to decode archive entry %v: %w", name, err)
+		}
+		if err := pi.CheckBlock(chainID, entry.BlockNumber); err != nil {
+			return fmt.Errorf("invalid archive entry %v: %w", name, err)
+		}
+		if err := s.StoreProverInput(ctx, pi); err != nil {
+			return fmt.Errorf("failed to store prover input of block %d: %w", entry.BlockNumber, err)
+		}
+		delete(entries, name)
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("truncated archive: %d/%d prover inputs of the manifest are missing", len(entries), len(manifest.Entries))
+	}
+	return manifest, nil
+}
+
+// readArchiveManifest reads the manifest of an archive
+func readArchiveManifest(r io.Reader) (*ArchiveManifest, error) {
+	var manifest *ArchiveManifest
+	errFound := errors.New("found")
+	err := eachArchiveFile(r, func(name string, tr io.Reader) error {
+		if name != ArchiveManifestName {
+			return nil
+		}
+		manifest = new(ArchiveManifest)
+		if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+			return fmt.Errorf("invalid archive manifest: %w", err)
+		}
+		return errFound
+	})
+	if err != nil && err != errFound {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("invalid archive: %v is missing", ArchiveManifestName)
+	}
+	return manifest, nil
+}
+
+// eachArchiveFile calls fn on every regular file of a gzipped tar, stopping at the first error
+func eachArchiveFile(r io.Reader, fn func(name string, r io.Reader) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(header.Name, tr); err != nil {
+			return err
+		}
+	}
+}
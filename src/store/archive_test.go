@@ -0,0 +1,97 @@
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	storeinputs "github.com/kkrt-labs/go-utils/store"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	from := newTestProverInputStore(t, t.TempDir(), input.FormatProtobuf, storeinputs.ContentEncodingGzip, 0)
+	for _, n := range []int64{10, 11, 12} {
+		require.NoError(t, from.StoreProverInput(context.Background(), &input.ProverInput{
+			ChainConfig: &params.ChainConfig{ChainID: big.NewInt(2)},
+			Blocks:      []*input.Block{{Header: &gethtypes.Header{Number: big.NewInt(n), Difficulty: big.NewInt(n)}}},
+			Witness:     &input.Witness{State: []hexutil.Bytes{bytes.Repeat([]byte{byte(n)}, 100)}},
+		}))
+	}
+
+	var archive bytes.Buffer
+	manifest, err := ExportArchive(context.Background(), from, &archive, 2, 10, 12, input.NumberFormatHex)
+	require.NoError(t, err)
+	require.Len(t, manifest.Entries, 3)
+	assert.Equal(t, "10.json", manifest.Entries[0].Name)
+	assert.Len(t, manifest.Entries[0].SHA256, 64)
+
+	to := newTestProverInputStore(t, t.TempDir(), input.FormatJSON, storeinputs.ContentEncodingPlain, 0)
+	imported, err := ImportArchive(context.Background(), to, bytes.NewReader(archive.Bytes()), 2)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, imported)
+	loaded, err := to.LoadProverInput(context.Background(), 2, 11)
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{11}, 100), []byte(loaded.Witness.State[0]))
+
+	t.Run("missing block", func(t *testing.T) {
+		_, err := ExportArchive(context.Background(), from, io.Discard, 2, 12, 13, input.NumberFormatHex)
+		assert.ErrorContains(t, err, "failed to load prover input of block 13")
+	})
+
+	t.Run("chain ID mismatch", func(t *testing.T) {
+		_, err := ImportArchive(context.Background(), to, bytes.NewReader(archive.Bytes()), 1)
+		assert.ErrorContains(t, err, "archive chain ID mismatch")
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		tampered := rewriteArchive(t, archive.Bytes(), func(name string, data []byte) []byte {
+			if name == "11.json" {
+				return bytes.Replace(data, []byte("0x0b0b"), []byte("0x0c0c"), 1)
+			}
+			return data
+		})
+		_, err := ImportArchive(context.Background(), to, bytes.NewReader(tampered), 2)
+		assert.ErrorContains(t, err, "archive entry 11.json checksum mismatch")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		truncated := rewriteArchive(t, archive.Bytes(), func(name string, data []byte) []byte {
+			if name == "12.json" {
+				return nil
+			}
+			return data
+		})
+		_, err := ImportArchive(context.Background(), to, bytes.NewReader(truncated), 2)
+		assert.ErrorContains(t, err, "truncated archive: 1/3 prover inputs of the manifest are missing")
+	})
+}
+
+// rewriteArchive rewrites the files of an archive with fn, dropping the ones for which it returns nil
+func rewriteArchive(t *testing.T, archive []byte, fn func(name string, data []byte) []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, eachArchiveFile(bytes.NewReader(archive), func(name string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		if data = fn(name, data); data != nil {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}))
+			_, err = tw.Write(data)
+			require.NoError(t, err)
+		}
+		return nil
+	}))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
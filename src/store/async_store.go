@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+)
+
+// StoreFailure is a prover input that AsyncProverInputStore failed to store
+type StoreFailure struct {
+	ChainID     uint64
+	BlockNumber uint64
+	Err         error
+}
+
+// AsyncProverInputStore is a ProverInputStore decorator storing prover inputs in the background, with a bounded
+// number of concurrent uploads, so the next block can be generated while the previous prover inputs are uploaded
+//
+// Prover inputs that are being stored are served from memory by LoadProverInput.
+// Failed stores are reported by Failures, retries are left to the decorated store (see NewRetryStore).
+type AsyncProverInputStore struct {
+	store ProverInputStore
+	sem   chan struct{}
+	wg    sync.WaitGroup
+
+	mu       sync.Mutex
+	pending  map[[2]uint64]*input.ProverInput // Indexed by chain ID and block number
+	failures []*StoreFailure
+}
+
+// NewAsyncProverInputStore creates a new AsyncProverInputStore with up to concurrency prover inputs stored at the same time
+func NewAsyncProverInputStore(s ProverInputStore, concurrency int) *AsyncProverInputStore {
+	return &AsyncProverInputStore{
+		store:   s,
+		sem:     make(chan struct{}, max(concurrency, 1)),
+		pending: make(map[[2]uint64]*input.ProverInput),
+	}
+}
+
+// StoreProverInput starts storing the prover input in the background,
+// it blocks while the maximum number of concurrent uploads is reached.
+//
+// Uploads are not canceled with ctx, so prover inputs already generated are not lost on interruption.
+func (s *AsyncProverInputStore) StoreProverInput(ctx context.Context, data *input.ProverInput) error {
+	select {
+	case s.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	key := [2]uint64{data.ChainConfig.ChainID.Uint64(), data.Blocks[0].Header.Number.Uint64()}
+	s.mu.Lock()
+	s.pending[key] = data
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.sem }()
+
+		err := s.store.StoreProverInput(context.WithoutCancel(ctx), data)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pending[key] == data {
+			delete(s.pending, key)
+		}
+		if err != nil {
+			s.failures = append(s.failures, &StoreFailure{ChainID: key[0], BlockNumber: key[1], Err: err})
+		}
+	}()
+
+	return nil
+}
+
+// LoadProverInput loads the prover input from memory if it is being stored, otherwise from the underlying store
+func (s *AsyncProverInputStore) LoadProverInput(ctx context.Context, chainID, blockNumber uint64) (*input.ProverInput, error) {
+	s.mu.Lock()
+	data, ok := s.pending[[2]uint64{chainID, blockNumber}]
+	s.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	return s.store.LoadProverInput(ctx, chainID, blockNumber)
+}
+
+// Wait waits for all prover inputs being stored to be stored or to have failed
+func (s *AsyncProverInputStore) Wait() {
+	s.wg.Wait()
+}
+
+// Failures returns the failures that happened since the last call to Failures
+func (s *AsyncProverInputStore) Failures() []*StoreFailure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failures := s.failures
+	s.failures = nil
+	return failures
+}
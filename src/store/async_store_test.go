@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProverInputStore is an in-memory ProverInputStore failing the first failures stores of every block
+type fakeProverInputStore struct {
+	mu       sync.Mutex
+	release  chan struct{} // If set, stores block until it is closed
+	failures int
+	attempts map[uint64]int
+	stored   map[uint64]*input.ProverInput
+}
+
+func (s *fakeProverInputStore) StoreProverInput(_ context.Context, data *input.ProverInput) error {
+	if s.release != nil {
+		<-s.release
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := data.Blocks[0].Header.Number.Uint64()
+	s.attempts[n]++
+	if s.attempts[n] <= s.failures {
+		return fmt.Errorf("upload failed")
+	}
+	s.stored[n] = data
+	return nil
+}
+
+func (s *fakeProverInputStore) LoadProverInput(_ context.Context, _, blockNumber uint64) (*input.ProverInput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.stored[blockNumber]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return data, nil
+}
+
+func TestAsyncProverInputStore(t *testing.T) {
+	newInput := func(n int64) *input.ProverInput {
+		return &input.ProverInput{
+			ChainConfig: &params.ChainConfig{ChainID: big.NewInt(1)},
+			Blocks:      []*input.Block{{Header: &gethtypes.Header{Number: big.NewInt(n)}}},
+		}
+	}
+	newStores := func(failures int, release chan struct{}) (*fakeProverInputStore, *AsyncProverInputStore) {
+		base := &fakeProverInputStore{
+			release:  release,
+			failures: failures,
+			attempts: make(map[uint64]int),
+			stored:   make(map[uint64]*input.ProverInput),
+		}
+		return base, NewAsyncProverInputStore(base, 2)
+	}
+
+	t.Run("pending prover inputs are served from memory", func(t *testing.T) {
+		release := make(chan struct{})
+		base, s := newStores(0, release)
+
+		data := newInput(10)
+		require.NoError(t, s.StoreProverInput(context.Background(), data))
+		loaded, err := s.LoadProverInput(context.Background(), 1, 10)
+		require.NoError(t, err)
+		assert.Same(t, data, loaded)
+
+		close(release)
+		s.Wait()
+		assert.Empty(t, s.Failures())
+		assert.Same(t, data, base.stored[10])
+	})
+
+	t.Run("failures are reported per block", func(t *testing.T) {
+		base, s := newStores(1, nil)
+
+		require.NoError(t, s.StoreProverInput(context.Background(), newInput(10)))
+		require.NoError(t, s.StoreProverInput(context.Background(), newInput(11)))
+		s.Wait()
+
+		failures := s.Failures()
+		require.Len(t, failures, 2)
+		assert.ElementsMatch(t, []uint64{10, 11}, []uint64{failures[0].BlockNumber, failures[1].BlockNumber})
+		assert.EqualError(t, failures[0].Err, "upload failed", "the error of the decorated store is reported as is")
+		assert.Equal(t, map[uint64]int{10: 1, 11: 1}, base.attempts, "stores are not retried on top of the decorated store")
+		assert.Empty(t, s.Failures())
+
+		// Failed prover inputs are not served from memory anymore
+		_, err := s.LoadProverInput(context.Background(), 1, 10)
+		assert.Error(t, err)
+	})
+}
+
+func TestAsyncProverInputStoreRetries(t *testing.T) {
+	slowDown := fmt.Errorf("failed to put object in S3: %w", &smithy.GenericAPIError{Code: "SlowDown", Message: "Please reduce your request rate."})
+	flaky := &flakyStore{failures: 5, err: slowDown}
+	s := NewAsyncProverInputStore(NewFromStore(NewRetryStore(flaky, testRetryConfig), input.FormatJSON, input.NumberFormatHex, input.JSONEncoderStd), 1)
+
+	data := &input.ProverInput{
+		ChainConfig: &params.ChainConfig{ChainID: big.NewInt(1)},
+		Blocks:      []*input.Block{{Header: &gethtypes.Header{Number: big.NewInt(10), Difficulty: big.NewInt(0)}}},
+	}
+	require.NoError(t, s.StoreProverInput(context.Background(), data))
+	s.Wait()
+
+	failures := s.Failures()
+	require.Len(t, failures, 1)
+	assert.Equal(t, testRetryConfig.MaxAttempts, flaky.calls, "uploads are only retried by the retry store")
+	var retryErr *RetryError
+	require.ErrorAs(t, failures[0].Err, &retryErr)
+	assert.Equal(t, retryErr, failures[0].Err, "the retry error is reported as is")
+	assert.Equal(t, 3, retryErr.Attempts)
+}
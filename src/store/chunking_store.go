@@ -0,0 +1,155 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	store "github.com/kkrt-labs/go-utils/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// chunkIndexMagic prefixes chunk index objects so they can be told apart from non-chunked data
+// (neither JSON nor protobuf encoded prover inputs can start with it)
+const chunkIndexMagic = "zkpig-chunked\n"
+
+// maxParallelChunkLoads is the maximum number of parts loaded concurrently
+const maxParallelChunkLoads = 4
+
+// chunkIndex is the index object stored in place of data split into parts
+type chunkIndex struct {
+	Parts int    `json:"parts"` // Number of parts
+	Size  uint64 `json:"size"`  // Total size of the data in bytes
+}
+
+// ChunkingStore is a store.Store decorator splitting data larger than a threshold into numbered parts
+//
+// Parts are stored under <key>.part-<i> and a small index object is stored under key.
+// Loading transparently reassembles the parts, whatever the threshold is, while non-chunked data is returned as is.
+// When placed above a compressing store, the threshold applies to the uncompressed data and every part is compressed separately.
+type ChunkingStore struct {
+	store     store.Store
+	chunkSize uint64
+}
+
+// NewChunkingStore creates a new ChunkingStore splitting data larger than chunkSize bytes (0 disables splitting)
+func NewChunkingStore(s store.Store, chunkSize uint64) *ChunkingStore {
+	return &ChunkingStore{store: s, chunkSize: chunkSize}
+}
+
+// Store stores the data, split into parts if larger than the chunk size
+func (s *ChunkingStore) Store(ctx context.Context, key string, reader io.Reader, headers *store.Headers) error {
+	if s.chunkSize == 0 {
+		return s.store.Store(ctx, key, reader, headers)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read data: %v", err)
+	}
+
+	if uint64(len(data)) <= s.chunkSize {
+		return s.store.Store(ctx, key, bytes.NewReader(data), headers)
+	}
+
+	index := chunkIndex{Size: uint64(len(data))}
+	for start := uint64(0); start < index.Size; start += s.chunkSize {
+		end := min(start+s.chunkSize, index.Size)
+		if err := s.store.Store(ctx, chunkKey(key, index.Parts), bytes.NewReader(data[start:end]), copyHeaders(headers)); err != nil {
+			return fmt.Errorf("failed to store part %d: %v", index.Parts, err)
+		}
+		index.Parts++
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(chunkIndexMagic)
+	if err := json.NewEncoder(&buf).Encode(&index); err != nil {
+		return fmt.Errorf("failed to encode chunk index: %v", err)
+	}
+
+	// The index is stored last, so partially stored data is never loaded
+	return s.store.Store(ctx, key, &buf, headers)
+}
+
+// Load loads the data, reassembling it if it was split into parts
+func (s *ChunkingStore) Load(ctx context.Context, key string, headers *store.Headers) (io.Reader, error) {
+	reader, err := s.store.Load(ctx, key, copyHeaders(headers))
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(reader)
+	if prefix, _ := buffered.Peek(len(chunkIndexMagic)); string(prefix) != chunkIndexMagic {
+		return withCloser(buffered, reader), nil
+	}
+	defer closeReader(reader)
+
+	var index chunkIndex
+	if _, err := buffered.Discard(len(chunkIndexMagic)); err != nil {
+		return nil, err
+	}
+	if err := json.NewDecoder(buffered).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to decode chunk index: %v", err)
+	}
+
+	parts := make([][]byte, index.Parts)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxParallelChunkLoads)
+	for i := range parts {
+		g.Go(func() error {
+			partReader, err := s.store.Load(gctx, chunkKey(key, i), copyHeaders(headers))
+			if err != nil {
+				return fmt.Errorf("failed to load part %d: %v", i, err)
+			}
+			defer closeReader(partReader)
+
+			if parts[i], err = io.ReadAll(partReader); err != nil {
+				return fmt.Errorf("failed to read part %d: %v", i, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	data := bytes.Join(parts, nil)
+	if uint64(len(data)) != index.Size {
+		return nil, fmt.Errorf("reassembled data size mismatch: expected %d bytes but got %d", index.Size, len(data))
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+func chunkKey(key string, i int) string {
+	return fmt.Sprintf("%s.part-%d", key, i)
+}
+
+// copyHeaders returns a copy of the headers, as decorated stores may modify them
+func copyHeaders(headers *store.Headers) *store.Headers {
+	if headers == nil {
+		return nil
+	}
+	cpy := *headers
+	return &cpy
+}
+
+func closeReader(reader io.Reader) {
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// withCloser returns a reader reading from reader and closing closer (if it is an io.Closer) on Close
+func withCloser(reader, closer io.Reader) io.Reader {
+	if c, ok := closer.(io.Closer); ok {
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, c}
+	}
+	return reader
+}
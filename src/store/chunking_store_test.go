@@ -0,0 +1,85 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	storeinputs "github.com/kkrt-labs/go-utils/store"
+	compressstore "github.com/kkrt-labs/go-utils/store/compress"
+	filestore "github.com/kkrt-labs/go-utils/store/file"
+	multistore "github.com/kkrt-labs/go-utils/store/multi"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkingStore(t *testing.T) {
+	dir := t.TempDir()
+	s := NewChunkingStore(filestore.New(filestore.Config{DataDir: dir}), 4)
+	headers := &storeinputs.Headers{ContentType: storeinputs.ContentTypeJSON}
+
+	// Small data is stored as is
+	require.NoError(t, s.Store(context.Background(), "small", bytes.NewReader([]byte("abc")), headers))
+	small, err := os.ReadFile(filepath.Join(dir, "small"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", string(small))
+
+	// Large data is split into parts
+	require.NoError(t, s.Store(context.Background(), "large", bytes.NewReader([]byte("hello world!")), headers))
+	for _, part := range []string{"large.part-0", "large.part-1", "large.part-2"} {
+		assert.FileExists(t, filepath.Join(dir, part))
+	}
+	assert.NoFileExists(t, filepath.Join(dir, "large.part-3"))
+
+	for key, expected := range map[string]string{"small": "abc", "large": "hello world!"} {
+		reader, err := s.Load(context.Background(), key, headers)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(body))
+	}
+
+	// Chunked data is reassembled whatever the chunk size
+	reader, err := NewChunkingStore(filestore.New(filestore.Config{DataDir: dir}), 0).Load(context.Background(), "large", headers)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world!", string(body))
+
+	// Missing part
+	require.NoError(t, os.Remove(filepath.Join(dir, "large.part-1")))
+	_, err = s.Load(context.Background(), "large", headers)
+	assert.ErrorContains(t, err, "failed to load part 1")
+}
+
+func TestChunkedProverInputStore(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			compressStore, err := compressstore.New(compressstore.Config{
+				MultiStoreConfig: multistore.Config{FileConfig: &filestore.Config{DataDir: t.TempDir()}},
+				ContentEncoding:  tc.contentEncoding,
+			})
+			require.NoError(t, err)
+			s := NewFromStore(NewChunkingStore(compressStore, 16), tc.format, input.NumberFormatHex, input.JSONEncoderStd)
+
+			data := &input.ProverInput{
+				ChainConfig: &params.ChainConfig{ChainID: big.NewInt(2)},
+				Blocks:      []*input.Block{{Header: &gethtypes.Header{Number: big.NewInt(15), Difficulty: big.NewInt(15)}}},
+				Witness:     &input.Witness{State: []hexutil.Bytes{bytes.Repeat([]byte{0x1}, 100)}},
+			}
+			require.NoError(t, s.StoreProverInput(context.Background(), data))
+
+			loaded, err := s.LoadProverInput(context.Background(), 2, 15)
+			require.NoError(t, err)
+			assert.Equal(t, data.Witness.State, loaded.Witness.State)
+		})
+	}
+}
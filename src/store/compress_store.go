@@ -0,0 +1,157 @@
+package store
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	store "github.com/kkrt-labs/go-utils/store"
+	multistore "github.com/kkrt-labs/go-utils/store/multi"
+)
+
+// CompressStore is a store.Store decorator compressing data with a content encoding
+//
+// It is compatible with the go-utils compress store (same encodings and <key>.<content-type>[.<content-encoding>] paths)
+// but decorates any store, so local data can be written through a FileStore.
+type CompressStore struct {
+	store    store.Store
+	encoding store.ContentEncoding
+	level    int
+}
+
+// NewCompressStore creates a new CompressStore
+//
+// level is the compression level, from 1 (fastest) to 9 (smallest), zero uses the default level of the encoding
+// (see DefaultCompressionLevel). A fixed level gives byte-identical output across runs.
+func NewCompressStore(s store.Store, encoding store.ContentEncoding, level int) *CompressStore {
+	if level == 0 {
+		level = DefaultCompressionLevel(encoding)
+	}
+	return &CompressStore{store: s, encoding: encoding, level: level}
+}
+
+// DefaultCompressionLevel returns the compression level used for the content encoding when none is configured
+// (6 for gzip and zlib, 9 for flate)
+func DefaultCompressionLevel(encoding store.ContentEncoding) int {
+	if encoding == store.ContentEncodingFlate {
+		return flate.BestCompression
+	}
+	return flate.DefaultCompression
+}
+
+// ParseCompressionLevel parses a compression level, from 1 to 9 (see NewCompressStore)
+func ParseCompressionLevel(s string) (int, error) {
+	level, err := strconv.Atoi(s)
+	if err != nil || level < flate.BestSpeed || level > flate.BestCompression {
+		return 0, fmt.Errorf("invalid compression level %q (expected %d to %d)", s, flate.BestSpeed, flate.BestCompression)
+	}
+	return level, nil
+}
+
+// NewMultiStore creates a store writing to every store configured in cfg, with local files written atomically (see FileStore)
+// in the partitions of partitioning and S3 falling back to the default AWS credential chain (see S3Store)
+func NewMultiStore(cfg multistore.Config, s3ClientCfg *S3ClientConfig, partitioning FilePartitioning) (store.Store, error) {
+	var stores []store.Store
+	if cfg.FileConfig != nil {
+		stores = append(stores, NewPartitionedFileStore(*cfg.FileConfig, partitioning))
+	}
+	if cfg.S3Config != nil {
+		s3Store, err := NewS3Store(cfg.S3Config, s3ClientCfg)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, s3Store)
+	}
+	return multistore.New(stores...), nil
+}
+
+// Store compresses the data and stores it in the underlying store
+func (c *CompressStore) Store(ctx context.Context, key string, reader io.Reader, headers *store.Headers) error {
+	if headers == nil {
+		headers = &store.Headers{}
+	}
+	headers.ContentEncoding = c.encoding
+
+	if c.encoding != store.ContentEncodingPlain {
+		var buf bytes.Buffer
+		w, err := c.newWriter(&buf)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, reader); err != nil {
+			w.Close()
+			return fmt.Errorf("failed to compress with %s: %w", c.encoding, err)
+		}
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("failed to compress with %s: %w", c.encoding, err)
+		}
+		reader = &buf
+	}
+
+	path, err := c.path(key, headers)
+	if err != nil {
+		return err
+	}
+	return c.store.Store(ctx, path, reader, headers)
+}
+
+// Load loads the data from the underlying store and decompresses it
+func (c *CompressStore) Load(ctx context.Context, key string, headers *store.Headers) (io.Reader, error) {
+	if headers == nil {
+		headers = &store.Headers{}
+	}
+	headers.ContentEncoding = c.encoding
+
+	path, err := c.path(key, headers)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := c.store.Load(ctx, path, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.encoding {
+	case store.ContentEncodingGzip:
+		return gzip.NewReader(reader)
+	case store.ContentEncodingZlib:
+		return zlib.NewReader(reader)
+	case store.ContentEncodingFlate:
+		return flate.NewReader(reader), nil
+	default:
+		return reader, nil
+	}
+}
+
+func (c *CompressStore) newWriter(w io.Writer) (io.WriteCloser, error) {
+	switch c.encoding {
+	case store.ContentEncodingGzip:
+		return gzip.NewWriterLevel(w, c.level)
+	case store.ContentEncodingZlib:
+		return zlib.NewWriterLevel(w, c.level)
+	case store.ContentEncodingFlate:
+		return flate.NewWriter(w, c.level)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", c.encoding)
+	}
+}
+
+func (c *CompressStore) path(key string, headers *store.Headers) (string, error) {
+	ext, err := extension(headers)
+	if err != nil {
+		return "", err
+	}
+
+	filename := fmt.Sprintf("%s.%s", key, ext)
+	if c.encoding != store.ContentEncodingPlain {
+		filename = fmt.Sprintf("%s.%s", filename, c.encoding.String())
+	}
+
+	return filepath.Join(headers.KeyValue["key-prefix"], filename), nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	store "github.com/kkrt-labs/go-utils/store"
+)
+
+// CountingStore is a store.Store decorator that counts the bytes successfully written to the underlying store
+type CountingStore struct {
+	store   store.Store
+	written atomic.Uint64
+}
+
+// NewCountingStore creates a new CountingStore
+func NewCountingStore(s store.Store) *CountingStore {
+	return &CountingStore{store: s}
+}
+
+// Store stores the data and counts the bytes read from reader
+func (s *CountingStore) Store(ctx context.Context, key string, reader io.Reader, headers *store.Headers) error {
+	cr := &countingReader{reader: reader}
+	if err := s.store.Store(ctx, key, cr, headers); err != nil {
+		return err
+	}
+	s.written.Add(cr.n)
+	return nil
+}
+
+// Load loads the data from the underlying store
+func (s *CountingStore) Load(ctx context.Context, key string, headers *store.Headers) (io.Reader, error) {
+	return s.store.Load(ctx, key, headers)
+}
+
+// BytesWritten returns the number of bytes written so far
+func (s *CountingStore) BytesWritten() uint64 {
+	return s.written.Load()
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      uint64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += uint64(n)
+	return n, err
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	storeinputs "github.com/kkrt-labs/go-utils/store"
+	filestore "github.com/kkrt-labs/go-utils/store/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountingStore(t *testing.T) {
+	s := NewCountingStore(filestore.New(filestore.Config{DataDir: t.TempDir()}))
+	headers := &storeinputs.Headers{ContentType: storeinputs.ContentTypeJSON}
+
+	require.NoError(t, s.Store(context.Background(), "a", bytes.NewReader([]byte("hello")), headers))
+	require.NoError(t, s.Store(context.Background(), "b", bytes.NewReader([]byte("world!")), headers))
+	assert.Equal(t, uint64(11), s.BytesWritten())
+
+	reader, err := s.Load(context.Background(), "b", headers)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "world!", string(body))
+}
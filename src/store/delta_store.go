@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+)
+
+// deltaProverInputStore is a ProverInputStore decorator storing prover inputs as deltas relative to
+// the prover input of a base block (experimental)
+type deltaProverInputStore struct {
+	store     ProverInputStore
+	baseBlock *uint64
+}
+
+// NewDeltaStore creates a ProverInputStore that stores the prover inputs of blocks after baseBlock
+// as deltas relative to the prover input of baseBlock, which must be stored first.
+// If baseBlock is nil, prover inputs are stored in full.
+//
+// Loading a delta prover input automatically loads its base and reconstructs the full prover input,
+// whatever baseBlock is.
+func NewDeltaStore(s ProverInputStore, baseBlock *uint64) ProverInputStore {
+	return &deltaProverInputStore{store: s, baseBlock: baseBlock}
+}
+
+func (s *deltaProverInputStore) StoreProverInput(ctx context.Context, data *input.ProverInput) error {
+	if s.baseBlock == nil || data.Blocks[0].Header.Number.Uint64() <= *s.baseBlock {
+		return s.store.StoreProverInput(ctx, data)
+	}
+
+	base, err := s.LoadProverInput(ctx, data.ChainConfig.ChainID.Uint64(), *s.baseBlock)
+	if err != nil {
+		return fmt.Errorf("failed to load base prover input for block %d: %v", *s.baseBlock, err)
+	}
+
+	delta, err := input.NewDelta(base, data)
+	if err != nil {
+		return fmt.Errorf("failed to create delta prover input: %v", err)
+	}
+
+	return s.store.StoreProverInput(ctx, delta)
+}
+
+func (s *deltaProverInputStore) LoadProverInput(ctx context.Context, chainID, blockNumber uint64) (*input.ProverInput, error) {
+	data, err := s.store.LoadProverInput(ctx, chainID, blockNumber)
+	if err != nil || !data.IsDelta() {
+		return data, err
+	}
+
+	base, err := s.store.LoadProverInput(ctx, chainID, data.BaseBlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load base prover input for block %d: %v", data.BaseBlockNumber, err)
+	}
+
+	return input.ApplyDelta(base, data)
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/kkrt-labs/go-utils/common"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaStore(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fullStore, _ := setupProverInputTestStore(t, tc)
+			deltaStore := NewDeltaStore(fullStore, common.Ptr(uint64(15)))
+
+			newInput := func(n int64, state ...hexutil.Bytes) *input.ProverInput {
+				return &input.ProverInput{
+					ChainConfig: &params.ChainConfig{ChainID: big.NewInt(2)},
+					Blocks:      []*input.Block{{Header: &gethtypes.Header{Number: big.NewInt(n), Difficulty: big.NewInt(0)}}},
+					Witness:     &input.Witness{State: state, Codes: []hexutil.Bytes{}, Ancestors: []*gethtypes.Header{}},
+				}
+			}
+
+			base := newInput(15, hexutil.Bytes{0x1}, hexutil.Bytes{0x2})
+			require.NoError(t, deltaStore.StoreProverInput(context.Background(), base))
+			require.NoError(t, deltaStore.StoreProverInput(context.Background(), newInput(16, hexutil.Bytes{0x2}, hexutil.Bytes{0x3})))
+
+			// Base is stored in full, next block as a delta
+			stored, err := fullStore.LoadProverInput(context.Background(), 2, 15)
+			require.NoError(t, err)
+			assert.False(t, stored.IsDelta())
+
+			stored, err = fullStore.LoadProverInput(context.Background(), 2, 16)
+			require.NoError(t, err)
+			assert.True(t, stored.IsDelta())
+			assert.Equal(t, []hexutil.Bytes{{0x3}}, stored.Witness.State)
+
+			// Loading reconstructs the full prover input
+			loaded, err := deltaStore.LoadProverInput(context.Background(), 2, 16)
+			require.NoError(t, err)
+			assert.False(t, loaded.IsDelta())
+			assert.ElementsMatch(t, []hexutil.Bytes{{0x1}, {0x2}, {0x3}}, loaded.Witness.State)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/kkrt-labs/go-utils/log"
+	store "github.com/kkrt-labs/go-utils/store"
+	"go.uber.org/zap"
+)
+
+// Backends of a fan-out store (see ProverInputStoreConfig.FanOut)
+const (
+	BackendLocal = "local" // File store of StoreConfig.FileConfig
+	BackendS3    = "s3"    // S3 store of StoreConfig.S3Config
+)
+
+// NewBackendStore creates the store prover inputs are written to: a FanOutStore of the cfg.FanOut backends if set, or
+// else a store writing to every configured store (see NewMultiStore)
+//
+// The S3 downloads are resumed with the backoff of cfg.Retry.
+func NewBackendStore(cfg *ProverInputStoreConfig) (store.Store, error) {
+	if len(cfg.FanOut) == 0 {
+		return NewMultiStore(cfg.StoreConfig, cfg.s3ClientConfig(), cfg.FilePartitioning)
+	}
+
+	backends := make([]FanOutBackend, 0, len(cfg.FanOut))
+	seen := make(map[string]bool)
+	for _, name := range cfg.FanOut {
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate fan-out store %q", name)
+		}
+		seen[name] = true
+
+		var backend store.Store
+		switch name {
+		case BackendLocal:
+			if cfg.StoreConfig.FileConfig == nil {
+				return nil, fmt.Errorf("fan-out store %q is not configured (missing inputs directory)", name)
+			}
+			backend = NewPartitionedFileStore(*cfg.StoreConfig.FileConfig, cfg.FilePartitioning)
+		case BackendS3:
+			if cfg.StoreConfig.S3Config == nil {
+				return nil, fmt.Errorf("fan-out store %q is not configured (missing S3 bucket)", name)
+			}
+			s3Store, err := NewS3Store(cfg.StoreConfig.S3Config, cfg.s3ClientConfig())
+			if err != nil {
+				return nil, err
+			}
+			backend = s3Store
+		default:
+			return nil, fmt.Errorf("invalid fan-out store %q (expected %q or %q)", name, BackendLocal, BackendS3)
+		}
+		backends = append(backends, FanOutBackend{Name: name, Store: backend})
+	}
+	return NewFanOutStore(backends, cfg.FanOutQuorum), nil
+}
+
+// FanOutBackend is a named store of a FanOutStore
+type FanOutBackend struct {
+	Name  string
+	Store store.Store
+}
+
+// FanOutStore is a store.Store writing every object to all its backends at once, and loading it from the first one having it
+//
+// Contrary to a tiered store, every backend receives the data on each write: the writes run concurrently and succeed
+// once quorum backends stored the object, the failures of the others being logged. Loads try the backends in order,
+// moving to the next one on any error, so a backend missing an object written without it is served by the next one.
+type FanOutStore struct {
+	backends []FanOutBackend
+	quorum   int
+}
+
+// NewFanOutStore creates a new FanOutStore, a write succeeding on every backend if quorum is 0
+func NewFanOutStore(backends []FanOutBackend, quorum int) *FanOutStore {
+	if quorum <= 0 || quorum > len(backends) {
+		quorum = len(backends)
+	}
+	return &FanOutStore{backends: backends, quorum: quorum}
+}
+
+// Store stores the data in every backend, failing if less than quorum of them stored it
+func (s *FanOutStore) Store(ctx context.Context, key string, reader io.Reader, headers *store.Headers) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read data of %v: %w", key, err)
+	}
+
+	errs := make([]error, len(s.backends))
+	var wg sync.WaitGroup
+	for i, backend := range s.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := backend.Store.Store(ctx, key, bytes.NewReader(data), headers); err != nil {
+				errs[i] = fmt.Errorf("%v store: %w", backend.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(s.backends)-len(failed) < s.quorum {
+		return fmt.Errorf("failed to store %v on %d/%d stores (quorum %d): %w", key, len(failed), len(s.backends), s.quorum, errors.Join(failed...))
+	}
+	for _, err := range failed {
+		log.LoggerFromContext(ctx).Warn("Failed to store on fan-out store, quorum reached", zap.String("key", key), zap.Error(err))
+	}
+	return nil
+}
+
+// Load loads the data from the first backend having it
+//
+// If every backend fails, the first error which is not a missing object is returned, so it can be retried, or else the
+// error of the first backend (see IsNotFound).
+func (s *FanOutStore) Load(ctx context.Context, key string, headers *store.Headers) (io.Reader, error) {
+	var notFound, failed error
+	for _, backend := range s.backends {
+		reader, err := backend.Store.Load(ctx, key, headers)
+		if err == nil {
+			return reader, nil
+		}
+		err = fmt.Errorf("%v store: %w", backend.Name, err)
+		switch {
+		case IsNotFound(err):
+			if notFound == nil {
+				notFound = err
+			}
+		case failed == nil:
+			failed = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if failed != nil {
+		return nil, failed
+	}
+	return nil, notFound
+}
+
+// s3ClientConfig returns the options of the S3 client, resuming downloads with the backoff of the store retries
+func (cfg *ProverInputStoreConfig) s3ClientConfig() *S3ClientConfig {
+	clientCfg := cfg.S3Client
+	clientCfg.ResumeBackoff = cfg.Retry
+	return &clientCfg
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	storeinputs "github.com/kkrt-labs/go-utils/store"
+	filestore "github.com/kkrt-labs/go-utils/store/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOutStore(t *testing.T) {
+	headers := &storeinputs.Headers{ContentType: storeinputs.ContentTypeJSON}
+	load := func(t *testing.T, s storeinputs.Store, key string) string {
+		reader, err := s.Load(context.Background(), key, headers)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("writes to every store", func(t *testing.T) {
+		local, remote := filestore.New(filestore.Config{DataDir: t.TempDir()}), filestore.New(filestore.Config{DataDir: t.TempDir()})
+		s := NewFanOutStore([]FanOutBackend{{Name: "local", Store: local}, {Name: "s3", Store: remote}}, 0)
+
+		require.NoError(t, s.Store(context.Background(), "a", bytes.NewReader([]byte("hello")), headers))
+		assert.Equal(t, "hello", load(t, local, "a"))
+		assert.Equal(t, "hello", load(t, remote, "a"))
+	})
+
+	t.Run("quorum", func(t *testing.T) {
+		failing := &flakyStore{failures: 10, err: errors.New("unavailable")}
+		remote := filestore.New(filestore.Config{DataDir: t.TempDir()})
+
+		err := NewFanOutStore([]FanOutBackend{{Name: "local", Store: failing}, {Name: "s3", Store: remote}}, 0).Store(context.Background(), "a", bytes.NewReader([]byte("hello")), headers)
+		assert.ErrorContains(t, err, "failed to store a on 1/2 stores (quorum 2): local store: unavailable")
+
+		s := NewFanOutStore([]FanOutBackend{{Name: "local", Store: failing}, {Name: "s3", Store: remote}}, 1)
+		require.NoError(t, s.Store(context.Background(), "b", bytes.NewReader([]byte("world")), headers))
+		assert.Equal(t, "world", load(t, remote, "b"))
+	})
+
+	t.Run("loads in order", func(t *testing.T) {
+		local, remote := filestore.New(filestore.Config{DataDir: t.TempDir()}), filestore.New(filestore.Config{DataDir: t.TempDir()})
+		require.NoError(t, local.Store(context.Background(), "a", bytes.NewReader([]byte("local")), headers))
+		require.NoError(t, remote.Store(context.Background(), "a", bytes.NewReader([]byte("remote")), headers))
+		require.NoError(t, remote.Store(context.Background(), "b", bytes.NewReader([]byte("remote only")), headers))
+		s := NewFanOutStore([]FanOutBackend{{Name: "local", Store: local}, {Name: "s3", Store: remote}}, 0)
+
+		assert.Equal(t, "local", load(t, s, "a"))
+		assert.Equal(t, "remote only", load(t, s, "b"))
+
+		_, err := s.Load(context.Background(), "c", headers)
+		assert.True(t, IsNotFound(err), err)
+		assert.ErrorContains(t, err, "local store")
+	})
+}
+
+func TestNewBackendStore(t *testing.T) {
+	cfg := &ProverInputStoreConfig{FanOut: []string{BackendLocal, BackendS3}}
+	_, err := NewBackendStore(cfg)
+	assert.ErrorContains(t, err, `fan-out store "local" is not configured (missing inputs directory)`)
+
+	cfg.StoreConfig.FileConfig = &filestore.Config{DataDir: t.TempDir()}
+	_, err = NewBackendStore(cfg)
+	assert.ErrorContains(t, err, `fan-out store "s3" is not configured (missing S3 bucket)`)
+
+	cfg.FanOut = []string{BackendLocal, BackendLocal}
+	_, err = NewBackendStore(cfg)
+	assert.ErrorContains(t, err, `duplicate fan-out store "local"`)
+
+	cfg.FanOut = []string{"gcs"}
+	_, err = NewBackendStore(cfg)
+	assert.ErrorContains(t, err, `invalid fan-out store "gcs"`)
+
+	cfg.FanOut = []string{BackendLocal}
+	s, err := NewBackendStore(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &FanOutStore{}, s)
+}
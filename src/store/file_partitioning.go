@@ -0,0 +1,60 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FilePartitioning is the layout of the sub-directories files of a FileStore are bucketed into, so no directory holds
+// more than a few thousand files on large backfills
+//
+// The partition of a file only depends on its block (the part of its name before the first "."), so every file of
+// a block (extensions, chunked parts) is in the same directory and reads resolve the partition of writes.
+type FilePartitioning string
+
+const (
+	FilePartitioningNone   FilePartitioning = "none"   // Files are written directly in the data directory: <block>.json
+	FilePartitioningNumber FilePartitioning = "number" // Files are bucketed by block number, 1000 blocks per directory: 021/465/21465322.json
+	FilePartitioningHash   FilePartitioning = "hash"   // Files are bucketed by the SHA-256 of the block, 65536 directories: ab/f8/21465322.json
+)
+
+// ParseFilePartitioning parses a file partitioning, an empty string defaults to FilePartitioningNone
+func ParseFilePartitioning(s string) (FilePartitioning, error) {
+	switch FilePartitioning(strings.ToLower(s)) {
+	case "", FilePartitioningNone:
+		return FilePartitioningNone, nil
+	case FilePartitioningNumber:
+		return FilePartitioningNumber, nil
+	case FilePartitioningHash:
+		return FilePartitioningHash, nil
+	}
+	return "", fmt.Errorf("invalid file partitioning %q (expected one of %q)", s, []FilePartitioning{FilePartitioningNone, FilePartitioningNumber, FilePartitioningHash})
+}
+
+// Path returns the path of the file of key, relative to the data directory, with its partition inserted before the
+// file name (e.g. prefix/021/465/21465322.json for prefix/21465322.json)
+func (p FilePartitioning) Path(key string) string {
+	dir, name := filepath.Split(key)
+	block, _, _ := strings.Cut(name, ".")
+
+	var partition string
+	switch p {
+	case FilePartitioningNumber:
+		if n, err := strconv.ParseUint(block, 10, 64); err == nil {
+			partition = filepath.Join(fmt.Sprintf("%03d", n/1_000_000), fmt.Sprintf("%03d", n/1000%1000))
+			break
+		}
+		// Not a block number, fall back to hashing it
+		fallthrough
+	case FilePartitioningHash:
+		sum := sha256.Sum256([]byte(block))
+		partition = filepath.Join(hex.EncodeToString(sum[:1]), hex.EncodeToString(sum[1:2]))
+	default:
+		return key
+	}
+	return filepath.Join(dir, partition, name)
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	store "github.com/kkrt-labs/go-utils/store"
+	filestore "github.com/kkrt-labs/go-utils/store/file"
+)
+
+// tempFileMarker is part of the name of every temporary file written by FileStore
+const tempFileMarker = ".zkpig-tmp-"
+
+// FileStore is a store.Store writing files atomically in a local directory
+//
+// Data is written to a temporary file (.<name>.zkpig-tmp-<random>) in the destination directory, then renamed
+// into place once fully written and synced, so readers never see partially written files, even if the process crashes.
+// The file layout is the same as the go-utils file store (including the "default" to chain ID substitution in the
+// data directory), with the files optionally bucketed in sub-directories (see FilePartitioning).
+type FileStore struct {
+	cfg          filestore.Config
+	partitioning FilePartitioning
+}
+
+// NewFileStore creates a new FileStore
+func NewFileStore(cfg filestore.Config) *FileStore {
+	return &FileStore{cfg: cfg}
+}
+
+// NewPartitionedFileStore creates a new FileStore writing files in the partitions of partitioning
+func NewPartitionedFileStore(cfg filestore.Config, partitioning FilePartitioning) *FileStore {
+	return &FileStore{cfg: cfg, partitioning: partitioning}
+}
+
+// Store writes the data to a temporary file then atomically renames it to its final location
+func (f *FileStore) Store(_ context.Context, key string, reader io.Reader, headers *store.Headers) error {
+	path := f.path(key, headers)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+tempFileMarker+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	// os.CreateTemp creates files with 0600 permissions
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+
+	return nil
+}
+
+// Load opens the file stored at key
+func (f *FileStore) Load(_ context.Context, key string, headers *store.Headers) (io.Reader, error) {
+	return os.Open(f.path(key, headers))
+}
+
+func (f *FileStore) path(key string, headers *store.Headers) string {
+	return filepath.Join(f.baseDir(headers), f.partitioning.Path(key))
+}
+
+func (f *FileStore) baseDir(headers *store.Headers) string {
+	baseDir := f.cfg.DataDir
+	if headers != nil && strings.Contains(baseDir, "default") {
+		baseDir = strings.Replace(baseDir, "default", headers.KeyValue["chainID"], 1)
+	}
+	return baseDir
+}
+
+// CleanTempFiles removes the temporary files left in dir by interrupted FileStore writes that are older than maxAge
+// (more recent files may belong to a concurrent process still writing them) and returns the number of removed files
+func CleanTempFiles(dir string, maxAge time.Duration) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.Contains(d.Name(), tempFileMarker) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if time.Since(info.ModTime()) < maxAge {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
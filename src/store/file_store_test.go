@@ -0,0 +1,190 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	storeinputs "github.com/kkrt-labs/go-utils/store"
+	compressstore "github.com/kkrt-labs/go-utils/store/compress"
+	filestore "github.com/kkrt-labs/go-utils/store/file"
+	multistore "github.com/kkrt-labs/go-utils/store/multi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("interrupted") }
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileStore(filestore.Config{DataDir: filepath.Join(dir, "default")})
+	headers := &storeinputs.Headers{KeyValue: map[string]string{"chainID": "2"}}
+
+	require.NoError(t, s.Store(context.Background(), "10.json", bytes.NewReader([]byte("data")), headers))
+	reader, err := s.Load(context.Background(), "10.json", headers)
+	require.NoError(t, err)
+	b, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), b)
+
+	// An interrupted write leaves the existing file and no temporary file
+	err = s.Store(context.Background(), "10.json", io.MultiReader(bytes.NewReader([]byte("partial")), failingReader{}), headers)
+	require.Error(t, err)
+	b, err = os.ReadFile(filepath.Join(dir, "2", "10.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), b)
+
+	entries, err := os.ReadDir(filepath.Join(dir, "2"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFilePartitioning(t *testing.T) {
+	assert.Equal(t, "21465322.json", FilePartitioningNone.Path("21465322.json"))
+	assert.Equal(t, filepath.Join("021", "465", "21465322.json.gz"), FilePartitioningNumber.Path("21465322.json.gz"))
+	assert.Equal(t, filepath.Join("prefix", "021", "465", "21465322.part-1.json"), FilePartitioningNumber.Path("prefix/21465322.part-1.json"))
+	assert.Equal(t, filepath.Join("000", "000", "10.json"), FilePartitioningNumber.Path("10.json"))
+
+	// Every file of a block is in the same partition
+	hashed := filepath.Dir(FilePartitioningHash.Path("21465322.json"))
+	assert.Len(t, hashed, len("ab/cd"))
+	assert.Equal(t, hashed, filepath.Dir(FilePartitioningHash.Path("21465322.part-0.json.gz")))
+	assert.NotEqual(t, hashed, filepath.Dir(FilePartitioningHash.Path("21465323.json")))
+
+	p, err := ParseFilePartitioning("")
+	require.NoError(t, err)
+	assert.Equal(t, FilePartitioningNone, p)
+	_, err = ParseFilePartitioning("date")
+	assert.ErrorContains(t, err, `invalid file partitioning "date"`)
+
+	dir := t.TempDir()
+	s := NewPartitionedFileStore(filestore.Config{DataDir: filepath.Join(dir, "default")}, FilePartitioningNumber)
+	headers := &storeinputs.Headers{KeyValue: map[string]string{"chainID": "1"}}
+	require.NoError(t, s.Store(context.Background(), "21465322.json", bytes.NewReader([]byte("data")), headers))
+	b, err := os.ReadFile(filepath.Join(dir, "1", "021", "465", "21465322.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), b)
+
+	reader, err := s.Load(context.Background(), "21465322.json", headers)
+	require.NoError(t, err)
+	b, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), b)
+}
+
+func TestCleanTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "2", ".10.json"+tempFileMarker+"123")
+	recent := filepath.Join(dir, ".11.json"+tempFileMarker+"456")
+	other := filepath.Join(dir, "12.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(stale), 0o755))
+	for _, path := range []string{stale, recent, other} {
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(stale, old, old))
+	require.NoError(t, os.Chtimes(other, old, old))
+
+	removed, err := CleanTempFiles(dir, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, recent)
+	assert.FileExists(t, other)
+
+	removed, err = CleanTempFiles(filepath.Join(dir, "missing"), time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
+
+func TestCompressStoreCompatibility(t *testing.T) {
+	for _, encoding := range []storeinputs.ContentEncoding{
+		storeinputs.ContentEncodingPlain,
+		storeinputs.ContentEncodingGzip,
+		storeinputs.ContentEncodingZlib,
+		storeinputs.ContentEncodingFlate,
+	} {
+		t.Run(encoding.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			s := NewCompressStore(NewFileStore(filestore.Config{DataDir: dir}), encoding, 0)
+			reference, err := compressstore.New(compressstore.Config{
+				MultiStoreConfig: multistore.Config{FileConfig: &filestore.Config{DataDir: dir}},
+				ContentEncoding:  encoding,
+			})
+			require.NoError(t, err)
+
+			headers := func() *storeinputs.Headers {
+				return &storeinputs.Headers{ContentType: storeinputs.ContentTypeJSON, KeyValue: map[string]string{"key-prefix": "inputs"}}
+			}
+			require.NoError(t, s.Store(context.Background(), "10", bytes.NewReader([]byte("data")), headers()))
+
+			reader, err := reference.Load(context.Background(), "10", headers())
+			require.NoError(t, err)
+			b, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("data"), b)
+		})
+	}
+}
+
+func TestCompressStoreLevel(t *testing.T) {
+	// Hex encoded pseudo-random numbers, compressible but not trivially
+	var data []byte
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		data = fmt.Appendf(data, `"0x%x",`, rnd.Int63n(1<<20))
+	}
+	headers := func() *storeinputs.Headers {
+		return &storeinputs.Headers{ContentType: storeinputs.ContentTypeJSON}
+	}
+
+	compressed := func(encoding storeinputs.ContentEncoding, level int) []byte {
+		dir := t.TempDir()
+		s := NewCompressStore(NewFileStore(filestore.Config{DataDir: dir}), encoding, level)
+		require.NoError(t, s.Store(context.Background(), "10", bytes.NewReader(data), headers()))
+
+		// Data is decompressed whatever the level
+		reader, err := s.Load(context.Background(), "10", headers())
+		require.NoError(t, err)
+		b, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, data, b)
+
+		b, err = os.ReadFile(filepath.Join(dir, "10.json."+encoding.String()))
+		require.NoError(t, err)
+		return b
+	}
+
+	for _, encoding := range []storeinputs.ContentEncoding{
+		storeinputs.ContentEncodingGzip,
+		storeinputs.ContentEncodingZlib,
+		storeinputs.ContentEncodingFlate,
+	} {
+		t.Run(encoding.String(), func(t *testing.T) {
+			// Output is reproducible for a given level, and the default level is the documented one
+			assert.Equal(t, compressed(encoding, 1), compressed(encoding, 1))
+			assert.Equal(t, compressed(encoding, DefaultCompressionLevel(encoding)), compressed(encoding, 0))
+			assert.Less(t, len(compressed(encoding, 9)), len(compressed(encoding, 1)))
+		})
+	}
+}
+
+func TestParseCompressionLevel(t *testing.T) {
+	level, err := ParseCompressionLevel("9")
+	require.NoError(t, err)
+	assert.Equal(t, 9, level)
+
+	for _, s := range []string{"0", "10", "-1", "max"} {
+		_, err := ParseCompressionLevel(s)
+		assert.Error(t, err, s)
+	}
+}
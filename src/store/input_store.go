@@ -3,15 +3,15 @@ package store
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"path/filepath"
+	"strings"
 
 	store "github.com/kkrt-labs/go-utils/store"
 	multistore "github.com/kkrt-labs/go-utils/store/multi"
 	input "github.com/kkrt-labs/zk-pig/src/prover-input"
-	protoinput "github.com/kkrt-labs/zk-pig/src/prover-input/proto"
-	"google.golang.org/protobuf/proto"
+	_ "github.com/kkrt-labs/zk-pig/src/prover-input/proto" // Registers the protobuf codec
 )
 
 type ProverInputStore interface {
@@ -19,102 +19,138 @@ type ProverInputStore interface {
 	StoreProverInput(ctx context.Context, inputs *input.ProverInput) error
 
 	// LoadProverInput loads the prover inputs for a block.
-	// format can be "protobuf" or "json"
 	LoadProverInput(ctx context.Context, chainID, blockNumber uint64) (*input.ProverInput, error)
 }
 
 type ProverInputStoreConfig struct {
-	StoreConfig     multistore.Config
-	ContentType     store.ContentType
-	ContentEncoding store.ContentEncoding
+	StoreConfig      multistore.Config
+	S3Client         S3ClientConfig // Options of the S3 client if StoreConfig.S3Config is set
+	Format           string         // Name of the codec serializing prover inputs, "json", "protobuf" or a registered one (see input.RegisterCodec)
+	ContentEncoding  store.ContentEncoding
+	CompressionLevel int // Compression level of ContentEncoding, zero uses the default level of the encoding (see NewCompressStore)
+	NumberFormat     input.NumberFormat
+	JSONEncoder      input.JSONEncoder
+	ChunkSize        uint64           // If non zero, serialized prover inputs larger than ChunkSize bytes are split into parts (see NewChunkingStore)
+	DeltaBase        *uint64          // Experimental: if set, prover inputs of the following blocks are stored as deltas (see NewDeltaStore)
+	Uploads          int              // If non zero, prover inputs are stored in the background with up to Uploads concurrent uploads (see NewAsyncProverInputStore)
+	Retry            RetryConfig      // Retry policy of the store operations failing with a transient error (see NewRetryStore)
+	FanOut           []string         // If set, prover inputs are written to all these backends at once (BackendLocal, BackendS3) and loaded from them in order (see NewFanOutStore)
+	FanOutQuorum     int              // Number of FanOut backends a write must succeed on, all of them if 0
+	FilePartitioning FilePartitioning // Layout of the sub-directories of the file store (see FilePartitioning)
 }
 
+// labelMetadataPrefix prefixes the metadata keys of the labels of a prover input
+const labelMetadataPrefix = "label-"
+
+// FormatMetadataKey is the metadata key of the format of a stored prover input, which is also the extension of its file
+const FormatMetadataKey = "format"
+
 type proverInputStore struct {
-	store       store.Store
-	contentType store.ContentType
+	store        store.Store
+	format       string
+	numberFormat input.NumberFormat
+	jsonEncoder  input.JSONEncoder
 }
 
 func New(cfg *ProverInputStoreConfig) (ProverInputStore, error) {
-	inputstore, err := multistore.NewFromConfig(cfg.StoreConfig)
+	inputstore, err := NewBackendStore(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return NewFromStore(inputstore, cfg.ContentType), nil
+	return NewFromStore(NewChunkingStore(NewRetryStore(inputstore, &cfg.Retry), cfg.ChunkSize), cfg.Format, cfg.NumberFormat, cfg.JSONEncoder), nil
 }
 
-func NewFromStore(inputstore store.Store, contentType store.ContentType) ProverInputStore {
-	return &proverInputStore{store: inputstore, contentType: contentType}
+// NewFromStore creates a ProverInputStore serializing prover inputs with the codec of format (see input.LookupCodec),
+// numberFormat and jsonEncoder apply to JSON only
+func NewFromStore(inputstore store.Store, format string, numberFormat input.NumberFormat, jsonEncoder input.JSONEncoder) ProverInputStore {
+	return &proverInputStore{store: inputstore, format: format, numberFormat: numberFormat, jsonEncoder: jsonEncoder}
 }
 
 func (s *proverInputStore) StoreProverInput(ctx context.Context, data *input.ProverInput) error {
-	var buf bytes.Buffer
-	switch s.contentType {
-	case store.ContentTypeProtobuf:
-		protoMsg := protoinput.ToProto(data)
-		protoBytes, err := proto.Marshal(protoMsg)
-		if err != nil {
-			return fmt.Errorf("failed to marshal protobuf: %w", err)
-		}
-		buf.Write(protoBytes)
-	case store.ContentTypeJSON:
-		if err := json.NewEncoder(&buf).Encode(data); err != nil {
-			return fmt.Errorf("failed to encode JSON: %w", err)
-		}
-	default:
-		contentType, err := s.contentType.String()
-		if err != nil {
-			return fmt.Errorf("failed to get content type: %w", err)
-		}
-		return fmt.Errorf("unsupported content type: %s", contentType)
+	codec, err := s.codec()
+	if err != nil {
+		return err
+	}
+	b, err := codec.Marshal(data)
+	if err != nil {
+		return err
 	}
 
 	path := s.proverPath(data.Blocks[0].Header.Number.Uint64())
-	headers := store.Headers{
-		ContentType: s.contentType,
-		KeyValue:    map[string]string{"chainID": fmt.Sprintf("%d", data.ChainConfig.ChainID.Uint64())},
+	headers := s.headers(data.ChainConfig.ChainID.Uint64())
+	// Labels are also set as object metadata (e.g. on S3), so they can be read without loading the prover input
+	for key, value := range data.Labels {
+		headers.KeyValue[labelMetadataPrefix+key] = value
 	}
-	return s.store.Store(ctx, path, bytes.NewReader(buf.Bytes()), &headers)
+	return s.store.Store(ctx, path, bytes.NewReader(b), headers)
 }
 
 func (s *proverInputStore) LoadProverInput(ctx context.Context, chainID, blockNumber uint64) (*input.ProverInput, error) {
-	path := s.proverPath(blockNumber)
-	headers := store.Headers{
-		ContentType: s.contentType,
-		KeyValue:    map[string]string{"chainID": fmt.Sprintf("%d", chainID)},
+	codec, err := s.codec()
+	if err != nil {
+		return nil, err
 	}
-	reader, err := s.store.Load(ctx, path, &headers)
+
+	reader, err := s.store.Load(ctx, s.proverPath(blockNumber), s.headers(chainID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load data from store: %w", err)
 	}
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s data: %w", s.format, err)
+	}
+	return codec.Unmarshal(b)
+}
 
-	data := &input.ProverInput{}
+// codec returns the codec of the format of the store, the JSON codec being configured with the JSON options of the store
+func (s *proverInputStore) codec() (input.Codec, error) {
+	if s.format == input.FormatJSON {
+		return &input.JSONCodec{NumberFormat: s.numberFormat, Encoder: s.jsonEncoder}, nil
+	}
+	return input.LookupCodec(s.format)
+}
 
-	switch s.contentType {
-	case store.ContentTypeJSON:
-		if err := json.NewDecoder(reader).Decode(data); err != nil {
-			return nil, fmt.Errorf("failed to decode JSON: %w", err)
-		}
-	case store.ContentTypeProtobuf:
-		protoBytes, err := io.ReadAll(reader)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read protobuf data: %w", err)
-		}
-		protoMsg := &protoinput.ProverInput{}
-		if err := proto.Unmarshal(protoBytes, protoMsg); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal protobuf: %w", err)
-		}
-		data = protoinput.FromProto(protoMsg)
-	default:
-		contentType, err := s.contentType.String()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get content type: %w", err)
-		}
-		return nil, fmt.Errorf("unsupported content type: %s", contentType)
+func (s *proverInputStore) headers(chainID uint64) *store.Headers {
+	headers := &store.Headers{
+		KeyValue: map[string]string{"chainID": fmt.Sprintf("%d", chainID), FormatMetadataKey: s.format},
+	}
+	// The content type of the built-in formats is also set, for the stores not aware of the format metadata
+	if contentType, err := store.ParseContentType(s.format); err == nil {
+		headers.ContentType = contentType
 	}
+	return headers
+}
 
-	return data, nil
+// extension returns the file extension of data stored with headers: its format if set, otherwise its content type
+func extension(headers *store.Headers) (string, error) {
+	if format := headers.KeyValue[FormatMetadataKey]; format != "" {
+		return format, nil
+	}
+	return headers.GetContentType()
 }
 
 func (s *proverInputStore) proverPath(blockNumber uint64) string {
 	return fmt.Sprintf("%d", blockNumber)
 }
+
+// ProverInputLocations returns the URLs of the objects storing the prover input of a block in the stores of cfg,
+// file://<path> for the file store and s3://<bucket>/<key> for the S3 store
+func ProverInputLocations(cfg *ProverInputStoreConfig, chainID, blockNumber uint64) ([]string, error) {
+	filename := fmt.Sprintf("%d.%s", blockNumber, cfg.Format)
+	if cfg.ContentEncoding != store.ContentEncodingPlain {
+		filename = fmt.Sprintf("%s.%s", filename, cfg.ContentEncoding.String())
+	}
+
+	var locations []string
+	if fileCfg := cfg.StoreConfig.FileConfig; fileCfg != nil {
+		dir, err := filepath.Abs(strings.Replace(fileCfg.DataDir, "default", fmt.Sprintf("%d", chainID), 1))
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, "file://"+filepath.ToSlash(filepath.Join(dir, cfg.FilePartitioning.Path(filename))))
+	}
+	if s3Cfg := cfg.StoreConfig.S3Config; s3Cfg != nil {
+		locations = append(locations, fmt.Sprintf("s3://%s/%s", s3Cfg.Bucket, ObjectKey(s3Cfg.KeyPrefix, fmt.Sprintf("%d", chainID), filename, cfg.S3Client.FlatLayout)))
+	}
+	return locations, nil
+}
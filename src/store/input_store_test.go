@@ -3,6 +3,8 @@ package store
 import (
 	"context"
 	"math/big"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	gethcommon "github.com/ethereum/go-ethereum/common"
@@ -15,12 +17,13 @@ import (
 	s3store "github.com/kkrt-labs/go-utils/store/s3"
 	input "github.com/kkrt-labs/zk-pig/src/prover-input"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Common test structures and helpers
 type testCase struct {
 	name            string
-	contentType     storeinputs.ContentType
+	format          string
 	contentEncoding storeinputs.ContentEncoding
 	storage         string
 	s3Config        *s3store.Config
@@ -29,25 +32,25 @@ type testCase struct {
 var testCases = []testCase{
 	{
 		name:            "JSON Plain File",
-		contentType:     storeinputs.ContentTypeJSON,
+		format:          input.FormatJSON,
 		contentEncoding: storeinputs.ContentEncodingPlain,
 		storage:         "file",
 	},
 	{
 		name:            "Protobuf Plain File",
-		contentType:     storeinputs.ContentTypeProtobuf,
+		format:          input.FormatProtobuf,
 		contentEncoding: storeinputs.ContentEncodingPlain,
 		storage:         "file",
 	},
 	{
 		name:            "JSON Gzip File",
-		contentType:     storeinputs.ContentTypeJSON,
+		format:          input.FormatJSON,
 		contentEncoding: storeinputs.ContentEncodingGzip,
 		storage:         "file",
 	},
 	{
 		name:            "Protobuf Gzip File",
-		contentType:     storeinputs.ContentTypeProtobuf,
+		format:          input.FormatProtobuf,
 		contentEncoding: storeinputs.ContentEncodingGzip,
 		storage:         "file",
 	},
@@ -55,7 +58,7 @@ var testCases = []testCase{
 	// TODO: Figure out access key and secret key access
 	// {
 	// 	name:            "JSON Plain S3",
-	// 	contentType:     storeinputs.ContentTypeJSON,
+	// 	format:          input.FormatJSON,
 	// 	contentEncoding: storeinputs.ContentEncodingPlain,
 	// 	storage:         "s3",
 	// 	s3Config: &s3.Config{
@@ -82,7 +85,7 @@ func setupProverInputTestStore(t *testing.T, tc testCase) (store ProverInputStor
 		MultiStoreConfig: cfg.StoreConfig,
 		ContentEncoding:  tc.contentEncoding,
 	})
-	store = NewFromStore(compressStore, tc.contentType)
+	store = NewFromStore(compressStore, tc.format, input.NumberFormatHex, input.JSONEncoderStd)
 
 	assert.NoError(t, err)
 	return store, baseDir
@@ -124,3 +127,97 @@ func TestProverInputStore(t *testing.T) {
 		})
 	}
 }
+
+func TestProverInputStoreLabels(t *testing.T) {
+	var metadata map[string]string
+	s := NewFromStore(NewObservingStore(filestore.New(filestore.Config{DataDir: t.TempDir()}), func(headers *storeinputs.Headers, _ uint64) {
+		metadata = headers.KeyValue
+	}), input.FormatJSON, input.NumberFormatHex, input.JSONEncoderStd)
+
+	proverInput := &input.ProverInput{
+		ChainConfig: &params.ChainConfig{ChainID: big.NewInt(2)},
+		Blocks:      []*input.Block{{Header: &gethtypes.Header{Number: big.NewInt(15), Difficulty: big.NewInt(15)}}},
+		Labels:      map[string]string{"run": "42", "commit": "abc123"},
+	}
+	assert.NoError(t, s.StoreProverInput(context.Background(), proverInput))
+	assert.Equal(t, map[string]string{"chainID": "2", "format": "json", "label-run": "42", "label-commit": "abc123"}, metadata)
+
+	loaded, err := s.LoadProverInput(context.Background(), 2, 15)
+	assert.NoError(t, err)
+	assert.Equal(t, proverInput.Labels, loaded.Labels)
+}
+
+func TestProverInputLocations(t *testing.T) {
+	cfg := &ProverInputStoreConfig{
+		StoreConfig: multistore.Config{
+			FileConfig: &filestore.Config{DataDir: "/data/default/inputs"},
+			S3Config:   &s3store.Config{Bucket: "bucket", KeyPrefix: "zkpig"},
+		},
+		Format:          input.FormatJSON,
+		ContentEncoding: storeinputs.ContentEncodingGzip,
+	}
+	locations, err := ProverInputLocations(cfg, 1, 21465322)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"file:///data/1/inputs/21465322.json.gzip", "s3://bucket/zkpig/1/21465322.json.gzip"}, locations)
+
+	cfg.StoreConfig.FileConfig = nil
+	cfg.S3Client.FlatLayout = true
+	locations, err = ProverInputLocations(cfg, 1, 21465322)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"s3://bucket/zkpig/21465322.json.gzip"}, locations)
+}
+
+func TestObjectKey(t *testing.T) {
+	for _, test := range []struct {
+		prefix string
+		flat   bool
+		key    string
+	}{
+		{"zkpig", false, "zkpig/1/2.json"},
+		{"zkpig", true, "zkpig/2.json"},
+		{"zkpig/{chainID}/inputs", false, "zkpig/1/inputs/2.json"},
+		{"zkpig/{chainID}/inputs", true, "zkpig/1/inputs/2.json"},
+	} {
+		assert.Equal(t, test.key, ObjectKey(test.prefix, "1", "2.json", test.flat), "prefix %q (flat %v)", test.prefix, test.flat)
+	}
+}
+
+// reversedJSONCodec is a custom codec, storing JSON prover inputs with their bytes reversed
+type reversedJSONCodec struct{ input.JSONCodec }
+
+func (c *reversedJSONCodec) Marshal(pi *input.ProverInput) ([]byte, error) {
+	b, err := c.JSONCodec.Marshal(pi)
+	slices.Reverse(b)
+	return b, err
+}
+
+func (c *reversedJSONCodec) Unmarshal(data []byte) (*input.ProverInput, error) {
+	b := slices.Clone(data)
+	slices.Reverse(b)
+	return c.JSONCodec.Unmarshal(b)
+}
+
+func TestProverInputStoreRegisteredCodec(t *testing.T) {
+	input.RegisterCodec("reversed-json", &reversedJSONCodec{})
+
+	dir := t.TempDir()
+	var metadata map[string]string
+	s := NewFromStore(NewObservingStore(NewCompressStore(NewFileStore(filestore.Config{DataDir: dir}), storeinputs.ContentEncodingGzip, 0), func(headers *storeinputs.Headers, _ uint64) {
+		metadata = headers.KeyValue
+	}), "reversed-json", input.NumberFormatHex, input.JSONEncoderStd)
+
+	proverInput := &input.ProverInput{
+		ChainConfig: &params.ChainConfig{ChainID: big.NewInt(2)},
+		Blocks:      []*input.Block{{Header: &gethtypes.Header{Number: big.NewInt(15), Difficulty: big.NewInt(15)}}},
+	}
+	require.NoError(t, s.StoreProverInput(context.Background(), proverInput))
+	assert.Equal(t, "reversed-json", metadata[FormatMetadataKey])
+	assert.FileExists(t, filepath.Join(dir, "15.reversed-json.gzip"))
+
+	loaded, err := s.LoadProverInput(context.Background(), 2, 15)
+	require.NoError(t, err)
+	assert.Equal(t, proverInput.Blocks[0].Header.Number, loaded.Blocks[0].Header.Number)
+
+	_, err = NewFromStore(NewFileStore(filestore.Config{DataDir: dir}), "unknown", input.NumberFormatHex, input.JSONEncoderStd).LoadProverInput(context.Background(), 2, 15)
+	assert.ErrorContains(t, err, `unsupported format "unknown"`)
+}
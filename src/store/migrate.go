@@ -0,0 +1,228 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kkrt-labs/go-utils/log"
+	store "github.com/kkrt-labs/go-utils/store"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	protoinput "github.com/kkrt-labs/zk-pig/src/prover-input/proto"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+)
+
+// RemoveFunc deletes the prover input of a block from a store
+type RemoveFunc func(ctx context.Context, chainID, blockNumber uint64) error
+
+// MigrateOptions are the options of a prover inputs migration
+type MigrateOptions struct {
+	Concurrency int        // Number of blocks migrated concurrently (defaults to 1)
+	Resume      bool       // If true, blocks already present in the destination with the same content are not copied again
+	Remove      RemoveFunc // If set, source prover inputs are deleted once every block of the range has been copied and verified
+}
+
+// MigrateResult summarizes a prover inputs migration
+type MigrateResult struct {
+	Copied  []uint64          `json:"copied"`  // Blocks copied to the destination
+	Skipped []uint64          `json:"skipped"` // Blocks already present in the destination (resume)
+	Missing []uint64          `json:"missing"` // Blocks found neither in the source nor in the destination
+	Removed []uint64          `json:"removed"` // Blocks deleted from the source
+	Failed  map[uint64]string `json:"failed"`  // Blocks that failed to migrate, with the error
+}
+
+func (r *MigrateResult) String() string {
+	return fmt.Sprintf("Migrated %d blocks: %d copied, %d skipped, %d missing, %d removed from source, %d failed\n",
+		len(r.Copied)+len(r.Skipped), len(r.Copied), len(r.Skipped), len(r.Missing), len(r.Removed), len(r.Failed))
+}
+
+// Migrate copies the prover inputs of the blocks in the inclusive range [fromBlock, toBlock] from one store to another
+//
+// Prover inputs are fully loaded from the source and stored in the destination, so the destination can use a different
+// backend, content type, content encoding or chunk size. Every copied prover input is loaded back from the destination
+// and compared to the source before being considered migrated.
+func Migrate(ctx context.Context, from, to ProverInputStore, chainID, fromBlock, toBlock uint64, opts *MigrateOptions) (*MigrateResult, error) {
+	if opts == nil {
+		opts = &MigrateOptions{}
+	}
+
+	res := &MigrateResult{Failed: make(map[uint64]string)}
+	var mu sync.Mutex
+	record := func(list *[]uint64, blockNumber uint64) {
+		mu.Lock()
+		defer mu.Unlock()
+		*list = append(*list, blockNumber)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(opts.Concurrency, 1))
+	for n := fromBlock; n <= toBlock; n++ {
+		g.Go(func() error {
+			logger := log.LoggerFromContext(gctx).With(zap.Uint64("block.number", n))
+
+			status, err := migrateBlock(gctx, from, to, chainID, n, opts.Resume)
+			if err != nil {
+				if gctx.Err() != nil {
+					return gctx.Err()
+				}
+				logger.Error("Failed to migrate prover input", zap.Error(err))
+				mu.Lock()
+				res.Failed[n] = err.Error()
+				mu.Unlock()
+				return nil
+			}
+
+			switch status {
+			case migrateCopied:
+				logger.Info("Prover input copied")
+				record(&res.Copied, n)
+			case migrateSkipped:
+				logger.Debug("Prover input already migrated, skipping")
+				record(&res.Skipped, n)
+			case migrateMissing:
+				logger.Warn("Prover input not found in source")
+				record(&res.Missing, n)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return res, err
+	}
+
+	for _, list := range [][]uint64{res.Copied, res.Skipped, res.Missing} {
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	}
+
+	if len(res.Failed) > 0 {
+		return res, fmt.Errorf("failed to migrate %d prover inputs", len(res.Failed))
+	}
+
+	// Sources are only deleted once the whole range is migrated, as delta prover inputs need their base to be loaded
+	if opts.Remove != nil {
+		for _, list := range [][]uint64{res.Copied, res.Skipped} {
+			for _, n := range list {
+				if err := opts.Remove(ctx, chainID, n); err != nil {
+					return res, fmt.Errorf("failed to remove prover input for block %d from source: %v", n, err)
+				}
+				res.Removed = append(res.Removed, n)
+			}
+		}
+		sort.Slice(res.Removed, func(i, j int) bool { return res.Removed[i] < res.Removed[j] })
+	}
+
+	return res, nil
+}
+
+type migrateStatus int
+
+const (
+	migrateCopied migrateStatus = iota
+	migrateSkipped
+	migrateMissing
+)
+
+func migrateBlock(ctx context.Context, from, to ProverInputStore, chainID, blockNumber uint64, resume bool) (migrateStatus, error) {
+	var existing []byte
+	if resume {
+		if data, err := to.LoadProverInput(ctx, chainID, blockNumber); err == nil {
+			if existing, err = checksum(data); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	data, err := from.LoadProverInput(ctx, chainID, blockNumber)
+	switch {
+	case err != nil && IsNotFound(err) && existing != nil:
+		return migrateSkipped, nil // Already moved by a previous run
+	case err != nil && IsNotFound(err):
+		return migrateMissing, nil
+	case err != nil:
+		return 0, fmt.Errorf("failed to load from source: %v", err)
+	}
+
+	sum, err := checksum(data)
+	if err != nil {
+		return 0, err
+	}
+	if bytes.Equal(sum, existing) {
+		return migrateSkipped, nil
+	}
+
+	if err := to.StoreProverInput(ctx, data); err != nil {
+		return 0, fmt.Errorf("failed to store in destination: %v", err)
+	}
+
+	// Verify the copy can be loaded from the destination and matches the source
+	copied, err := to.LoadProverInput(ctx, chainID, blockNumber)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load copy from destination: %v", err)
+	}
+	copiedSum, err := checksum(copied)
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(sum, copiedSum) {
+		return 0, fmt.Errorf("copy in destination does not match source (checksum %x, expected %x)", copiedSum, sum)
+	}
+
+	return migrateCopied, nil
+}
+
+// checksum returns a checksum of the prover input content, independent of the format it was stored with
+func checksum(data *input.ProverInput) ([]byte, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(protoinput.ToProto(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal prover input: %v", err)
+	}
+	sum := sha256.Sum256(b)
+	return sum[:], nil
+}
+
+// IsNotFound returns true if err indicates that the requested object does not exist in the store
+func IsNotFound(err error) bool {
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+
+	var apiErr interface{ ErrorCode() string } // AWS API errors
+	return errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NoSuchKey" || apiErr.ErrorCode() == "NotFound")
+}
+
+// NewFileRemover returns a RemoveFunc deleting prover inputs stored as files in dir, including their chunked parts
+//
+// It mirrors the file layout of the file and compress stores: <dir>/[<partition>/]<block>.<format>[.<content-encoding>]
+func NewFileRemover(dir string, format string, contentEncoding store.ContentEncoding, partitioning FilePartitioning) RemoveFunc {
+	return func(_ context.Context, chainID, blockNumber uint64) error {
+		ext := format
+		if contentEncoding != store.ContentEncodingPlain {
+			ext += "." + contentEncoding.String()
+		}
+
+		baseDir := strings.Replace(dir, "default", fmt.Sprintf("%d", chainID), 1)
+		index := filepath.Join(baseDir, partitioning.Path(fmt.Sprintf("%d.%s", blockNumber, ext)))
+		parts, err := filepath.Glob(filepath.Join(filepath.Dir(index), fmt.Sprintf("%d.part-*.%s", blockNumber, ext)))
+		if err != nil {
+			return err
+		}
+
+		// The index is removed first, so partially removed data is never loaded
+		for _, path := range append([]string{index}, parts...) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	storeinputs "github.com/kkrt-labs/go-utils/store"
+	compressstore "github.com/kkrt-labs/go-utils/store/compress"
+	filestore "github.com/kkrt-labs/go-utils/store/file"
+	multistore "github.com/kkrt-labs/go-utils/store/multi"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProverInputStore(t *testing.T, dir string, format string, contentEncoding storeinputs.ContentEncoding, chunkSize uint64) ProverInputStore {
+	compressStore, err := compressstore.New(compressstore.Config{
+		MultiStoreConfig: multistore.Config{FileConfig: &filestore.Config{DataDir: dir}},
+		ContentEncoding:  contentEncoding,
+	})
+	require.NoError(t, err)
+	return NewFromStore(NewChunkingStore(compressStore, chunkSize), format, input.NumberFormatHex, input.JSONEncoderStd)
+}
+
+func TestMigrate(t *testing.T) {
+	fromDir, toDir := t.TempDir(), t.TempDir()
+	from := newTestProverInputStore(t, fromDir, input.FormatJSON, storeinputs.ContentEncodingPlain, 0)
+	to := newTestProverInputStore(t, toDir, input.FormatProtobuf, storeinputs.ContentEncodingGzip, 64)
+
+	// Block 11 is missing from the source
+	for _, n := range []int64{10, 12} {
+		require.NoError(t, from.StoreProverInput(context.Background(), &input.ProverInput{
+			ChainConfig: &params.ChainConfig{ChainID: big.NewInt(2)},
+			Blocks:      []*input.Block{{Header: &gethtypes.Header{Number: big.NewInt(n), Difficulty: big.NewInt(n)}}},
+			Witness:     &input.Witness{State: []hexutil.Bytes{bytes.Repeat([]byte{byte(n)}, 100)}},
+		}))
+	}
+
+	res, err := Migrate(context.Background(), from, to, 2, 10, 12, &MigrateOptions{Concurrency: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{10, 12}, res.Copied)
+	assert.Equal(t, []uint64{11}, res.Missing)
+	assert.FileExists(t, filepath.Join(toDir, "10.protobuf.gzip"))
+	assert.FileExists(t, filepath.Join(toDir, "10.part-0.protobuf.gzip"))
+
+	loaded, err := to.LoadProverInput(context.Background(), 2, 12)
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{12}, 100), []byte(loaded.Witness.State[0]))
+
+	// Resume and move skips already migrated blocks and removes them from the source
+	res, err = Migrate(context.Background(), from, to, 2, 10, 12, &MigrateOptions{
+		Resume: true,
+		Remove: NewFileRemover(fromDir, input.FormatJSON, storeinputs.ContentEncodingPlain, FilePartitioningNone),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, res.Copied)
+	assert.Equal(t, []uint64{10, 12}, res.Skipped)
+	assert.Equal(t, []uint64{10, 12}, res.Removed)
+	assert.NoFileExists(t, filepath.Join(fromDir, "10.json"))
+	assert.NoFileExists(t, filepath.Join(fromDir, "12.json"))
+
+	// Blocks already moved are still reported as migrated
+	res, err = Migrate(context.Background(), from, to, 2, 10, 12, &MigrateOptions{Resume: true})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{10, 12}, res.Skipped)
+	assert.Equal(t, []uint64{11}, res.Missing)
+}
+
+func TestMigrateFailure(t *testing.T) {
+	fromDir := t.TempDir()
+	from := newTestProverInputStore(t, fromDir, input.FormatJSON, storeinputs.ContentEncodingPlain, 0)
+	to := newTestProverInputStore(t, t.TempDir(), input.FormatJSON, storeinputs.ContentEncodingPlain, 0)
+	require.NoError(t, os.WriteFile(filepath.Join(fromDir, "10.json"), []byte("invalid"), 0o600))
+
+	res, err := Migrate(context.Background(), from, to, 2, 10, 10, &MigrateOptions{
+		Remove: NewFileRemover(fromDir, input.FormatJSON, storeinputs.ContentEncodingPlain, FilePartitioningNone),
+	})
+	require.Error(t, err)
+	assert.Contains(t, res.Failed[10], "failed to load from source")
+	assert.Empty(t, res.Removed)
+	assert.FileExists(t, filepath.Join(fromDir, "10.json"))
+}
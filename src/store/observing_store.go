@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"io"
+
+	store "github.com/kkrt-labs/go-utils/store"
+)
+
+// ObservingStore is a store.Store decorator that reports the size of every object successfully written to the underlying store
+type ObservingStore struct {
+	store   store.Store
+	observe func(headers *store.Headers, size uint64)
+}
+
+// NewObservingStore creates a new ObservingStore calling observe after every successful write
+func NewObservingStore(s store.Store, observe func(headers *store.Headers, size uint64)) *ObservingStore {
+	return &ObservingStore{store: s, observe: observe}
+}
+
+// Store stores the data and reports the number of bytes read from reader
+func (s *ObservingStore) Store(ctx context.Context, key string, reader io.Reader, headers *store.Headers) error {
+	cr := &countingReader{reader: reader}
+	if err := s.store.Store(ctx, key, cr, headers); err != nil {
+		return err
+	}
+	s.observe(headers, cr.n)
+	return nil
+}
+
+// Load loads the data from the underlying store
+func (s *ObservingStore) Load(ctx context.Context, key string, headers *store.Headers) (io.Reader, error) {
+	return s.store.Load(ctx, key, headers)
+}
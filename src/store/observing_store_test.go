@@ -0,0 +1,31 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	storeinputs "github.com/kkrt-labs/go-utils/store"
+	filestore "github.com/kkrt-labs/go-utils/store/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObservingStore(t *testing.T) {
+	var sizes []uint64
+	var chainIDs []string
+	s := NewObservingStore(filestore.New(filestore.Config{DataDir: t.TempDir()}), func(headers *storeinputs.Headers, size uint64) {
+		sizes = append(sizes, size)
+		chainIDs = append(chainIDs, headers.KeyValue["chainID"])
+	})
+	headers := &storeinputs.Headers{ContentType: storeinputs.ContentTypeJSON, KeyValue: map[string]string{"chainID": "1"}}
+
+	require.NoError(t, s.Store(context.Background(), "a", bytes.NewReader([]byte("hello")), headers))
+	require.NoError(t, s.Store(context.Background(), "b", bytes.NewReader([]byte("world!")), headers))
+	assert.Equal(t, []uint64{5, 6}, sizes)
+	assert.Equal(t, []string{"1", "1"}, chainIDs)
+
+	// Failed writes are not observed
+	require.Error(t, s.Store(context.Background(), "a/b", bytes.NewReader([]byte("fail")), headers))
+	assert.Len(t, sizes, 2)
+}
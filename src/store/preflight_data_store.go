@@ -1,10 +1,15 @@
 package store
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	store "github.com/kkrt-labs/go-utils/store"
 	filestore "github.com/kkrt-labs/go-utils/store/file"
@@ -21,28 +26,53 @@ type PreflightDataStore interface {
 
 // NewPreflightDataStore creates a new PreflightDataStore instance
 func NewPreflightDataStore(cfg *PreflightDataStoreConfig) (PreflightDataStore, error) {
-	inputstore := filestore.New(*cfg.FileConfig)
+	inputstore := NewFileStore(*cfg.FileConfig)
 
 	return &preflightDataStore{
-		store: inputstore,
+		store:        inputstore,
+		memoryBudget: cfg.MemoryBudget,
 	}, nil
 }
 
 type preflightDataStore struct {
-	store store.Store
+	store        store.Store
+	memoryBudget uint64
 }
 
 type PreflightDataStoreConfig struct {
 	FileConfig *filestore.Config
+
+	// Soft memory budget in bytes of the pre-state proofs of loaded preflight data, above which they are spilled to disk
+	// (see generator.ReadPreflightData). Zero disables it.
+	MemoryBudget uint64
+
+	// If true, the preflight data of blocks older than the finalized head of the chain, which won't reorg and are unlikely
+	// to be collected again, are evicted from the store (see PrunePreflightData)
+	PruneFinalized bool
+
+	// If set, the preflight data stored for longer than PruneMaxAge are evicted from the store (see PrunePreflightData)
+	PruneMaxAge time.Duration
+}
+
+// PruneEnabled returns true if a pruning policy is set
+func (cfg *PreflightDataStoreConfig) PruneEnabled() bool {
+	return cfg.FileConfig != nil && (cfg.PruneFinalized || cfg.PruneMaxAge > 0)
 }
 
 func (s *preflightDataStore) StorePreflightData(ctx context.Context, inputs *generator.PreflightData) error {
 	path := s.preflightPath(inputs.Block.Number.ToInt().Uint64())
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(inputs); err != nil {
-		return fmt.Errorf("failed to encode JSON: %w", err)
-	}
-	reader := bytes.NewReader(buf.Bytes())
+
+	// Stream the encoding so pre-state proofs spilled to disk are not loaded back in memory all at once
+	reader, writer := io.Pipe()
+	go func() {
+		if err := inputs.WriteJSON(writer); err != nil {
+			writer.CloseWithError(fmt.Errorf("failed to encode JSON: %w", err))
+			return
+		}
+		writer.Close()
+	}()
+	defer reader.Close()
+
 	headers := store.Headers{
 		ContentType:     store.ContentTypeJSON,
 		ContentEncoding: store.ContentEncodingPlain,
@@ -53,7 +83,6 @@ func (s *preflightDataStore) StorePreflightData(ctx context.Context, inputs *gen
 
 func (s *preflightDataStore) LoadPreflightData(ctx context.Context, chainID, blockNumber uint64) (*generator.PreflightData, error) {
 	path := s.preflightPath(blockNumber)
-	data := &generator.PreflightData{}
 	headers := store.Headers{
 		ContentType:     store.ContentTypeJSON,
 		ContentEncoding: store.ContentEncodingPlain,
@@ -63,12 +92,63 @@ func (s *preflightDataStore) LoadPreflightData(ctx context.Context, chainID, blo
 	if err != nil {
 		return nil, err
 	}
-	if err := json.NewDecoder(reader).Decode(data); err != nil {
-		return nil, err
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
 	}
-	return data, nil
+	return generator.ReadPreflightData(reader, s.memoryBudget)
 }
 
 func (s *preflightDataStore) preflightPath(blockNumber uint64) string {
 	return fmt.Sprintf("%d.json", blockNumber)
 }
+
+// PrunePreflightData removes the preflight data files stored in dir of blocks below the before block number, if not zero,
+// or stored for longer than maxAge, if not zero, and returns the number of removed files
+func PrunePreflightData(dir string, before uint64, maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		blockNumber, ok := preflightBlockNumber(entry)
+		if !ok {
+			continue
+		}
+
+		prune := before > 0 && blockNumber < before
+		if !prune && maxAge > 0 {
+			info, err := entry.Info()
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return removed, err
+			}
+			prune = time.Since(info.ModTime()) >= maxAge
+		}
+		if !prune {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// preflightBlockNumber returns the block number of a preflight data file (<block number>.json)
+func preflightBlockNumber(entry fs.DirEntry) (uint64, bool) {
+	name, ok := strings.CutSuffix(entry.Name(), ".json")
+	if !ok || entry.IsDir() {
+		return 0, false
+	}
+	blockNumber, err := strconv.ParseUint(name, 10, 64)
+	return blockNumber, err == nil
+}
@@ -3,14 +3,20 @@ package store
 import (
 	"context"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/kkrt-labs/go-utils/ethereum/rpc"
 	filestore "github.com/kkrt-labs/go-utils/store/file"
+	"github.com/kkrt-labs/zk-pig/src/ethereum/trie"
 	"github.com/kkrt-labs/zk-pig/src/generator"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupPreflightDataTestStore(t *testing.T) (store PreflightDataStore, baseDir string) {
@@ -55,3 +61,71 @@ func TestPreflightDataStore(t *testing.T) {
 		})
 	}
 }
+
+func TestPreflightDataStoreMemoryBudget(t *testing.T) {
+	preflightDataStore, err := NewPreflightDataStore(&PreflightDataStoreConfig{
+		FileConfig:   &filestore.Config{DataDir: t.TempDir()},
+		MemoryBudget: 1, // Spill every pre-state proof on load
+	})
+	assert.NoError(t, err)
+
+	preflightData := &generator.PreflightData{
+		ChainConfig: &params.ChainConfig{
+			ChainID: big.NewInt(1),
+		},
+		Block: &rpc.Block{
+			Header: rpc.Header{
+				Number: (*hexutil.Big)(hexutil.MustDecodeBig("0xa")),
+			},
+		},
+		PreStateProofs: []*trie.AccountProof{
+			{Address: gethcommon.HexToAddress("0x1"), Proof: []string{"0x01"}},
+			{Address: gethcommon.HexToAddress("0x2"), Proof: []string{"0x02"}},
+		},
+	}
+
+	err = preflightDataStore.StorePreflightData(context.Background(), preflightData)
+	assert.NoError(t, err)
+
+	loaded, err := preflightDataStore.LoadPreflightData(context.Background(), 1, 10)
+	assert.NoError(t, err)
+	defer loaded.Close()
+	assert.Empty(t, loaded.PreStateProofs)
+
+	var addresses []gethcommon.Address
+	err = loaded.EachPreStateProof(func(proof *trie.AccountProof) error {
+		addresses = append(addresses, proof.Address)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []gethcommon.Address{gethcommon.HexToAddress("0x1"), gethcommon.HexToAddress("0x2")}, addresses)
+}
+
+func TestPrunePreflightData(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"9.json", "10.json", "11.json", "12.json", ".8.json" + tempFileMarker + "123", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("data"), 0o600))
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "12.json"), old, old))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "notes.txt"), old, old))
+
+	// Blocks below the finalized block 11 and data older than an hour are pruned
+	removed, err := PrunePreflightData(dir, 11, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 3, removed)
+	for _, name := range []string{"9.json", "10.json", "12.json"} {
+		assert.NoFileExists(t, filepath.Join(dir, name))
+	}
+	for _, name := range []string{"11.json", ".8.json" + tempFileMarker + "123", "notes.txt"} {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+
+	removed, err = PrunePreflightData(dir, 0, 0)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+
+	removed, err = PrunePreflightData(filepath.Join(dir, "missing"), 11, 0)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/kkrt-labs/go-utils/log"
+	store "github.com/kkrt-labs/go-utils/store"
+	"github.com/kkrt-labs/zk-pig/src/random"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultRetryMaxAttempts     = 3
+	DefaultRetryInitialInterval = 200 * time.Millisecond
+	DefaultRetryMaxInterval     = 5 * time.Second
+)
+
+// RetryConfig is the retry policy of store operations, separate from the JSON-RPC retries
+type RetryConfig struct {
+	MaxAttempts     int            // Maximum number of attempts of an operation, 0 or 1 disables retries
+	InitialInterval time.Duration  // Delay before the first retry, doubled after every attempt
+	MaxInterval     time.Duration  // Maximum delay between two attempts
+	Random          *random.Source // Optional source of the backoff jitter (the global source if nil)
+}
+
+// backOff returns the exponential backoff between two attempts, unbounded as attempts are bounded by MaxAttempts
+func (cfg *RetryConfig) backOff() backoff.BackOff {
+	return cfg.Random.BackOff(backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(cfg.InitialInterval),
+		backoff.WithMaxInterval(cfg.MaxInterval),
+		backoff.WithMaxElapsedTime(0),
+	))
+}
+
+// RetryError is returned by RetryStore when an operation failed after MaxAttempts attempts
+// or with a non transient error
+type RetryError struct {
+	Op       string // "store" or "load"
+	Key      string
+	Attempts int
+	Err      error // Error of the last attempt
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("failed to %s %q after %d attempt(s): %v", e.Op, e.Key, e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// RetryStore is a store.Store decorator retrying operations failing with a transient error (e.g. S3 5xx and throttling
+// errors, network timeouts) with an exponential backoff
+//
+// Store and Load are idempotent: the content to store is buffered so every attempt uploads the same bytes.
+// Operations are not retried once ctx is done.
+type RetryStore struct {
+	store store.Store
+	cfg   RetryConfig
+}
+
+// NewRetryStore creates a new RetryStore, it returns s if cfg disables retries
+func NewRetryStore(s store.Store, cfg *RetryConfig) store.Store {
+	if cfg == nil || cfg.MaxAttempts <= 1 {
+		return s
+	}
+	return &RetryStore{store: s, cfg: *cfg}
+}
+
+// Store stores the data, retrying on transient errors
+func (s *RetryStore) Store(ctx context.Context, key string, reader io.Reader, headers *store.Headers) error {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read content: %w", err)
+	}
+	return s.retry(ctx, "store", key, func() error {
+		return s.store.Store(ctx, key, bytes.NewReader(content), headers)
+	})
+}
+
+// Load loads the data, retrying on transient errors
+func (s *RetryStore) Load(ctx context.Context, key string, headers *store.Headers) (io.Reader, error) {
+	var reader io.Reader
+	err := s.retry(ctx, "load", key, func() error {
+		var err error
+		reader, err = s.store.Load(ctx, key, headers)
+		return err
+	})
+	return reader, err
+}
+
+func (s *RetryStore) retry(ctx context.Context, op, key string, fn func() error) error {
+	bckff := s.cfg.backOff()
+
+	attempts := 0
+	err := backoff.RetryNotify(
+		func() error {
+			attempts++
+			err := fn()
+			if err != nil && !IsTransient(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		},
+		backoff.WithContext(backoff.WithMaxRetries(bckff, uint64(s.cfg.MaxAttempts-1)), ctx),
+		func(err error, d time.Duration) {
+			log.LoggerFromContext(ctx).Warn("Retrying store operation in...",
+				zap.String("op", op),
+				zap.String("key", key),
+				zap.Int("attempt", attempts),
+				zap.Error(err),
+				zap.Duration("duration", d),
+			)
+		},
+	)
+	if err != nil {
+		return &RetryError{Op: op, Key: key, Attempts: attempts, Err: err}
+	}
+	return nil
+}
+
+// transientErrorCodes are the S3 error codes of throttled requests and transient server errors
+var transientErrorCodes = map[string]bool{
+	"SlowDown":               true,
+	"Throttling":             true,
+	"ThrottlingException":    true,
+	"RequestLimitExceeded":   true,
+	"RequestThrottled":       true,
+	"TooManyRequests":        true,
+	"RequestTimeout":         true,
+	"InternalError":          true,
+	"ServiceUnavailable":     true,
+	"OperationAborted":       true,
+	"BandwidthLimitExceeded": true,
+}
+
+// IsTransient returns true if err is a transient store error worth retrying: S3 throttling and 5xx errors,
+// network timeouts and connection resets
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && transientErrorCodes[apiErr.ErrorCode()] {
+		return true
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		status := statusErr.HTTPStatusCode()
+		return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
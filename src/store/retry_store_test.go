@@ -0,0 +1,110 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	storeinputs "github.com/kkrt-labs/go-utils/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyStore fails the first failures operations with err
+type flakyStore struct {
+	failures int
+	err      error
+	calls    int
+	stored   []byte
+}
+
+func (s *flakyStore) Store(_ context.Context, _ string, reader io.Reader, _ *storeinputs.Headers) error {
+	s.calls++
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if s.calls <= s.failures {
+		return s.err
+	}
+	s.stored = content
+	return nil
+}
+
+func (s *flakyStore) Load(_ context.Context, _ string, _ *storeinputs.Headers) (io.Reader, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, s.err
+	}
+	return bytes.NewReader(s.stored), nil
+}
+
+var testRetryConfig = &RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond}
+
+func TestRetryStore(t *testing.T) {
+	slowDown := fmt.Errorf("failed to put object in S3: %w", &smithy.GenericAPIError{Code: "SlowDown", Message: "Please reduce your request rate."})
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		flaky := &flakyStore{failures: 2, err: slowDown}
+		s := NewRetryStore(flaky, testRetryConfig)
+
+		require.NoError(t, s.Store(context.Background(), "a", bytes.NewReader([]byte("hello")), nil))
+		assert.Equal(t, 3, flaky.calls)
+		assert.Equal(t, "hello", string(flaky.stored), "every attempt should upload the whole content")
+
+		flaky.calls, flaky.failures = 0, 1
+		reader, err := s.Load(context.Background(), "a", nil)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(body))
+		assert.Equal(t, 2, flaky.calls)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		flaky := &flakyStore{failures: 5, err: slowDown}
+		err := NewRetryStore(flaky, testRetryConfig).Store(context.Background(), "a", bytes.NewReader([]byte("hello")), nil)
+
+		var retryErr *RetryError
+		require.True(t, errors.As(err, &retryErr))
+		assert.Equal(t, "store", retryErr.Op)
+		assert.Equal(t, 3, retryErr.Attempts)
+		assert.ErrorIs(t, err, slowDown)
+		assert.Equal(t, 3, flaky.calls)
+	})
+
+	t.Run("non transient errors are not retried", func(t *testing.T) {
+		flaky := &flakyStore{failures: 5, err: &smithy.GenericAPIError{Code: "AccessDenied"}}
+		_, err := NewRetryStore(flaky, testRetryConfig).Load(context.Background(), "a", nil)
+
+		var retryErr *RetryError
+		require.True(t, errors.As(err, &retryErr))
+		assert.Equal(t, 1, retryErr.Attempts)
+		assert.Equal(t, 1, flaky.calls)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		flaky := &flakyStore{}
+		assert.Same(t, flaky, NewRetryStore(flaky, &RetryConfig{MaxAttempts: 1}))
+	})
+}
+
+type statusError int
+
+func (e statusError) Error() string       { return fmt.Sprintf("http status %d", int(e)) }
+func (e statusError) HTTPStatusCode() int { return int(e) }
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(&smithy.GenericAPIError{Code: "ServiceUnavailable"}))
+	assert.True(t, IsTransient(fmt.Errorf("wrapped: %w", statusError(503))))
+	assert.True(t, IsTransient(statusError(429)))
+	assert.False(t, IsTransient(statusError(404)))
+	assert.False(t, IsTransient(&smithy.GenericAPIError{Code: "NoSuchKey"}))
+	assert.False(t, IsTransient(context.Canceled))
+	assert.False(t, IsTransient(errors.New("boom")))
+}
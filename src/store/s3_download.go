@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // S3 ETags of single part uploads are MD5 checksums
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// s3Download is the body of an S3 object which, when the connection drops mid-download, resumes the download from
+// the last received byte with a ranged GetObject conditioned on the ETag of the object, at most maxResumes times
+// with an exponential backoff between failed requests
+//
+// Once the object is reassembled from several responses, its size is checked against the size of the object and,
+// if its ETag is the MD5 checksum of its content (single part uploads not encrypted with KMS), its checksum is verified.
+type s3Download struct {
+	ctx        context.Context
+	client     *s3.Client
+	bucket     string
+	key        string
+	maxResumes int
+	backoff    backoff.BackOff
+
+	body    io.ReadCloser
+	etag    *string
+	size    int64 // -1 if unknown
+	offset  int64
+	resumes int
+	md5     hash.Hash // nil if the ETag is not the checksum of the content
+	err     error     // Sticky error, returned by every further Read
+}
+
+func newS3Download(ctx context.Context, client *s3.Client, bucket, key string, output *s3.GetObjectOutput, maxResumes int, resumeBackoff *RetryConfig) *s3Download {
+	if resumeBackoff.InitialInterval <= 0 || resumeBackoff.MaxInterval <= 0 {
+		resumeBackoff = &RetryConfig{InitialInterval: DefaultRetryInitialInterval, MaxInterval: DefaultRetryMaxInterval, Random: resumeBackoff.Random}
+	}
+	d := &s3Download{
+		ctx:        ctx,
+		client:     client,
+		bucket:     bucket,
+		key:        key,
+		maxResumes: maxResumes,
+		backoff:    resumeBackoff.backOff(),
+		body:       output.Body,
+		etag:       output.ETag,
+		size:       -1,
+	}
+	if output.ContentLength != nil {
+		d.size = *output.ContentLength
+	}
+	if isMD5ETag(output) {
+		d.md5 = md5.New() //nolint:gosec // See above
+	}
+	return d
+}
+
+// isMD5ETag returns true if the ETag of an object is the MD5 checksum of its content
+func isMD5ETag(output *s3.GetObjectOutput) bool {
+	if output.ETag == nil || output.SSECustomerAlgorithm != nil {
+		return false
+	}
+	if sse := output.ServerSideEncryption; sse == types.ServerSideEncryptionAwsKms || sse == types.ServerSideEncryptionAwsKmsDsse {
+		return false
+	}
+	b, err := hex.DecodeString(strings.Trim(*output.ETag, `"`))
+	return err == nil && len(b) == md5.Size
+}
+
+// Read reads the object, resuming the download on a read error
+func (d *s3Download) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	for {
+		n, err := d.body.Read(p)
+		d.offset += int64(n)
+		if d.md5 != nil {
+			d.md5.Write(p[:n])
+		}
+		if err == nil {
+			return n, nil
+		}
+		if err == io.EOF || (d.size >= 0 && d.offset >= d.size) {
+			d.err = d.verify()
+			return n, d.err
+		}
+
+		if resumeErr := d.resume(err); resumeErr != nil {
+			d.err = resumeErr
+			return n, d.err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// resume closes the current response after the read error err and requests the rest of the object
+func (d *s3Download) resume(err error) error {
+	_ = d.body.Close()
+	for {
+		if d.ctx.Err() != nil || d.resumes >= d.maxResumes {
+			return fmt.Errorf("failed to download S3 object %v at byte %d after %d resumes: %w", d.key, d.offset, d.resumes, err)
+		}
+		d.resumes++
+
+		rng := fmt.Sprintf("bytes=%d-", d.offset)
+		var output *s3.GetObjectOutput
+		output, err = d.client.GetObject(d.ctx, &s3.GetObjectInput{
+			Bucket:  &d.bucket,
+			Key:     &d.key,
+			Range:   &rng,
+			IfMatch: d.etag,
+		})
+		if err != nil {
+			var precondition interface{ ErrorCode() string }
+			if errors.As(err, &precondition) && precondition.ErrorCode() == "PreconditionFailed" {
+				return fmt.Errorf("failed to resume download of S3 object %v: object changed during download", d.key)
+			}
+			d.wait()
+			continue
+		}
+		if !strings.HasPrefix(awssdk.ToString(output.ContentRange), fmt.Sprintf("bytes %d-", d.offset)) {
+			_ = output.Body.Close()
+			return fmt.Errorf("failed to resume download of S3 object %v: unexpected content range %q for range %q", d.key, awssdk.ToString(output.ContentRange), rng)
+		}
+		d.body = output.Body
+		return nil
+	}
+}
+
+// wait waits for the next backoff delay, or until the download context is done
+func (d *s3Download) wait() {
+	timer := time.NewTimer(d.backoff.NextBackOff())
+	defer timer.Stop()
+	select {
+	case <-d.ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// verify checks the size and checksum of a resumed download once it is complete
+func (d *s3Download) verify() error {
+	if d.resumes == 0 {
+		return io.EOF
+	}
+	if d.size >= 0 && d.offset != d.size {
+		return fmt.Errorf("resumed download of S3 object %v size mismatch: expected %d bytes but got %d", d.key, d.size, d.offset)
+	}
+	if d.md5 != nil {
+		if checksum := hex.EncodeToString(d.md5.Sum(nil)); checksum != strings.Trim(*d.etag, `"`) {
+			return fmt.Errorf("resumed download of S3 object %v checksum mismatch: expected %v but got %v", d.key, strings.Trim(*d.etag, `"`), checksum)
+		}
+	}
+	return io.EOF
+}
+
+// Close closes the current response
+func (d *s3Download) Close() error {
+	return d.body.Close()
+}
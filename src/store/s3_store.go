@@ -0,0 +1,285 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	aws "github.com/kkrt-labs/go-utils/aws"
+	store "github.com/kkrt-labs/go-utils/store"
+	s3store "github.com/kkrt-labs/go-utils/store/s3"
+)
+
+// S3Store is a store.Store storing data in an AWS S3 bucket
+//
+// It is compatible with the go-utils S3 store (objects are stored at <key-prefix>/<chain-id>/<key>, see ObjectKey), but when no static
+// access and secret keys are configured it authenticates with the default AWS credential chain (environment, shared
+// config, web identity, EC2 instance profile...), so it can run with an IAM role on EC2/EKS.
+type S3Store struct {
+	client    *s3.Client
+	cfg       s3store.Config
+	clientCfg S3ClientConfig
+}
+
+// S3ClientConfig holds options of the S3 client, to target S3-compatible services (e.g. MinIO, Cloudflare R2),
+// and of the objects it writes
+type S3ClientConfig struct {
+	Endpoint       string // Optional endpoint URL replacing the AWS S3 endpoints
+	ForcePathStyle bool   // If true, buckets are addressed in the URL path (<endpoint>/<bucket>/<key>) instead of the host (<bucket>.<endpoint>/<key>)
+
+	SSE      S3SSE                 // Server-side encryption of every written object (the bucket default if empty or S3SSENone)
+	KMSKeyID string                // Optional KMS key encrypting every written object, requires SSE to be S3SSEKMS (the AWS managed key if empty)
+	ACL      types.ObjectCannedACL // Optional canned ACL of every written object (the bucket default if empty)
+
+	// DownloadResumes is the maximum number of times a download interrupted by a read error is resumed from the last
+	// received byte with a range request (0 disables resuming)
+	DownloadResumes int
+
+	// ResumeBackoff is the exponential backoff between two failed requests resuming a download, its MaxAttempts being
+	// ignored (DefaultRetryInitialInterval and DefaultRetryMaxInterval if unset, see NewBackendStore)
+	ResumeBackoff RetryConfig
+
+	// FallbackPrefixes are key prefixes tried in order by Load when the object is missing under the primary key prefix
+	// (e.g. the previous prefixes of a bucket), objects are always stored under the primary key prefix
+	FallbackPrefixes []string
+
+	// FlatLayout stores objects at <key-prefix>/<key>, without the chain ID level of the layout (see ObjectKey)
+	FlatLayout bool
+}
+
+// ChainIDPlaceholder is replaced by the chain ID of the stored data in key prefix templates (e.g. zkpig/{chainID}/inputs)
+const ChainIDPlaceholder = "{chainID}"
+
+// ObjectKey returns the S3 key of the object storing key of chainID under prefix
+//
+// The key is <prefix>/<chainID>/<key>, unless prefix is a template containing ChainIDPlaceholder, which is then replaced
+// by chainID, or flat is set, in which case the key is <prefix>/<key>.
+func ObjectKey(prefix, chainID, key string, flat bool) string {
+	switch {
+	case strings.Contains(prefix, ChainIDPlaceholder):
+		return strings.ReplaceAll(prefix, ChainIDPlaceholder, chainID) + "/" + key
+	case flat:
+		return prefix + "/" + key
+	}
+	return prefix + "/" + chainID + "/" + key
+}
+
+// S3SSE is a server-side encryption mode of S3 objects
+type S3SSE string
+
+const (
+	S3SSENone   S3SSE = "none"   // No encryption header is sent, the bucket default encryption applies
+	S3SSEAES256 S3SSE = "aes256" // SSE-S3, with keys managed by S3
+	S3SSEKMS    S3SSE = "kms"    // SSE-KMS, with a KMS key
+)
+
+// ParseS3SSE parses a server-side encryption mode, an empty string defaults to S3SSENone
+func ParseS3SSE(s string) (S3SSE, error) {
+	switch S3SSE(strings.ToLower(s)) {
+	case "", S3SSENone:
+		return S3SSENone, nil
+	case S3SSEAES256:
+		return S3SSEAES256, nil
+	case S3SSEKMS:
+		return S3SSEKMS, nil
+	}
+	return "", fmt.Errorf("invalid S3 server-side encryption %q (expected one of %q)", s, []S3SSE{S3SSENone, S3SSEAES256, S3SSEKMS})
+}
+
+// ParseS3ACL parses a canned ACL of S3 objects (e.g. private, bucket-owner-full-control)
+func ParseS3ACL(s string) (types.ObjectCannedACL, error) {
+	acls := types.ObjectCannedACL("").Values()
+	if !slices.Contains(acls, types.ObjectCannedACL(s)) {
+		return "", fmt.Errorf("invalid S3 ACL %q (expected one of %q)", s, acls)
+	}
+	return types.ObjectCannedACL(s), nil
+}
+
+// Validate returns an error if the server-side encryption options are inconsistent
+func (cfg *S3ClientConfig) Validate() error {
+	if cfg.KMSKeyID != "" && cfg.SSE != S3SSEKMS {
+		return fmt.Errorf("an S3 KMS key ID requires the %q server-side encryption", S3SSEKMS)
+	}
+	if cfg.DownloadResumes < 0 {
+		return fmt.Errorf("invalid S3 download resumes %d: must not be negative", cfg.DownloadResumes)
+	}
+	return nil
+}
+
+// applyPut sets the server-side encryption and ACL options on an object upload
+func (cfg *S3ClientConfig) applyPut(input *s3.PutObjectInput) {
+	switch cfg.SSE {
+	case S3SSEAES256:
+		input.ServerSideEncryption = types.ServerSideEncryptionAes256
+	case S3SSEKMS:
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		if cfg.KMSKeyID != "" {
+			input.SSEKMSKeyId = awssdk.String(cfg.KMSKeyID)
+		}
+	}
+	if cfg.ACL != "" {
+		input.ACL = cfg.ACL
+	}
+}
+
+// NewS3Store creates a new S3Store, clientCfg is optional
+func NewS3Store(cfg *s3store.Config, clientCfg *S3ClientConfig) (*S3Store, error) {
+	if clientCfg == nil {
+		clientCfg = &S3ClientConfig{}
+	}
+	if err := clientCfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	awsCfg, err := LoadAWSConfig(context.Background(), cfg.ProviderConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return &S3Store{
+		client: s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+			if clientCfg.Endpoint != "" {
+				o.BaseEndpoint = awssdk.String(clientCfg.Endpoint)
+			}
+			o.UsePathStyle = clientCfg.ForcePathStyle
+		}),
+		cfg:       *cfg,
+		clientCfg: *clientCfg,
+	}, nil
+}
+
+// UsesStaticCredentials returns true if cfg holds static access and secret keys, false if the default AWS
+// credential chain is used
+func UsesStaticCredentials(cfg *aws.ProviderConfig) bool {
+	return cfg != nil && cfg.Credentials != nil && (cfg.Credentials.AccessKey != "" || cfg.Credentials.SecretKey != "")
+}
+
+// LoadAWSConfig loads the AWS configuration, using static credentials if set in cfg or the default credential chain otherwise
+func LoadAWSConfig(ctx context.Context, cfg *aws.ProviderConfig) (awssdk.Config, error) {
+	if cfg == nil {
+		return awsconfig.LoadDefaultConfig(ctx)
+	}
+
+	if UsesStaticCredentials(cfg) {
+		return aws.LoadConfig(cfg)
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	return awsconfig.LoadDefaultConfig(ctx, opts...)
+}
+
+// Store uploads the data to the bucket
+func (s *S3Store) Store(ctx context.Context, key string, reader io.Reader, headers *store.Headers) error {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read content: %w", err)
+	}
+
+	contentLength := int64(len(content))
+	key = s.path(key, headers)
+	input := &s3.PutObjectInput{
+		Bucket:        &s.cfg.Bucket,
+		Key:           &key,
+		Body:          bytes.NewReader(content),
+		ContentLength: &contentLength,
+	}
+
+	if headers != nil && headers.ContentEncoding != store.ContentEncodingPlain {
+		encoding := headers.ContentEncoding.String()
+		input.ContentEncoding = &encoding
+	}
+
+	if headers != nil && headers.KeyValue != nil {
+		input.Metadata = headers.KeyValue
+	}
+
+	s.clientCfg.applyPut(input)
+
+	if _, err = s.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to put object in S3: %w", err)
+	}
+	return nil
+}
+
+// Load downloads the data from the bucket
+//
+// The download is resumed from the last received byte if the connection drops while the data is read, at most
+// S3ClientConfig.DownloadResumes times backing off between failed requests, and a resumed download is checked against the size and checksum of the object.
+// If the object is missing under the primary key prefix, it is looked up under S3ClientConfig.FallbackPrefixes in order,
+// and the error of the primary key is returned if it is missing under all of them.
+func (s *S3Store) Load(ctx context.Context, key string, headers *store.Headers) (io.Reader, error) {
+	var primaryErr error
+	for _, prefix := range append([]string{s.cfg.KeyPrefix}, s.clientCfg.FallbackPrefixes...) {
+		objectKey := s.prefixedPath(prefix, key, headers)
+		output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: &s.cfg.Bucket,
+			Key:    &objectKey,
+		})
+		if err == nil {
+			return newS3Download(ctx, s.client, s.cfg.Bucket, objectKey, output, s.clientCfg.DownloadResumes, &s.clientCfg.ResumeBackoff), nil
+		}
+		if !IsNotFound(err) {
+			return nil, err
+		}
+		if primaryErr == nil {
+			primaryErr = err
+		}
+	}
+	return nil, primaryErr
+}
+
+// List returns the keys of the objects stored under the primary key prefix whose key starts with prefix
+func (s *S3Store) List(ctx context.Context, prefix string, headers *store.Headers) ([]string, error) {
+	root := s.path("", headers)
+	objectPrefix := root + prefix
+
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: &s.cfg.Bucket,
+		Prefix: &objectPrefix,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects in S3: %w", err)
+		}
+		for _, object := range page.Contents {
+			keys = append(keys, strings.TrimPrefix(awssdk.ToString(object.Key), root))
+		}
+	}
+	return keys, nil
+}
+
+// Delete deletes the object from the bucket, deleting a missing object is not an error
+func (s *S3Store) Delete(ctx context.Context, key string, headers *store.Headers) error {
+	key = s.path(key, headers)
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &s.cfg.Bucket,
+		Key:    &key,
+	}); err != nil {
+		return fmt.Errorf("failed to delete object in S3: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Store) path(key string, headers *store.Headers) string {
+	return s.prefixedPath(s.cfg.KeyPrefix, key, headers)
+}
+
+func (s *S3Store) prefixedPath(prefix, key string, headers *store.Headers) string {
+	var chainID string
+	if headers != nil {
+		chainID = headers.KeyValue["chainID"]
+	}
+	return ObjectKey(prefix, chainID, key, s.clientCfg.FlatLayout)
+}
@@ -0,0 +1,434 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // S3 ETags
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	aws "github.com/kkrt-labs/go-utils/aws"
+	store "github.com/kkrt-labs/go-utils/store"
+	s3store "github.com/kkrt-labs/go-utils/store/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAWSConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret-key")
+
+	// Static credentials
+	awsCfg, err := LoadAWSConfig(context.Background(), &aws.ProviderConfig{
+		Region:      "eu-west-1",
+		Credentials: &aws.CredentialsConfig{AccessKey: "access-key", SecretKey: "secret-key"},
+	})
+	require.NoError(t, err)
+	creds, err := awsCfg.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-key", creds.AccessKeyID)
+	assert.Equal(t, "eu-west-1", awsCfg.Region)
+
+	// Default credential chain
+	for _, cfg := range []*aws.ProviderConfig{
+		{Region: "eu-west-1"},
+		{Region: "eu-west-1", Credentials: &aws.CredentialsConfig{}},
+	} {
+		awsCfg, err = LoadAWSConfig(context.Background(), cfg)
+		require.NoError(t, err)
+		creds, err = awsCfg.Credentials.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "env-access-key", creds.AccessKeyID)
+		assert.Equal(t, "eu-west-1", awsCfg.Region)
+	}
+}
+
+func TestS3StoreEndpoint(t *testing.T) {
+	// Minimal S3-compatible server keeping objects in memory by request path
+	var (
+		mu      sync.Mutex
+		objects = make(map[string][]byte)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = body
+		case http.MethodGet:
+			if r.URL.Query().Get("list-type") == "2" {
+				prefix := r.URL.Query().Get("prefix")
+				_, _ = fmt.Fprint(w, "<ListBucketResult><Name>bucket</Name><IsTruncated>false</IsTruncated>")
+				for path := range objects {
+					if key := strings.TrimPrefix(path, "/bucket/"); strings.HasPrefix(key, prefix) {
+						_, _ = fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", key)
+					}
+				}
+				_, _ = fmt.Fprint(w, "</ListBucketResult>")
+				return
+			}
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(body)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	s3Store, err := NewS3Store(
+		&s3store.Config{
+			ProviderConfig: &aws.ProviderConfig{
+				Region:      "us-east-1",
+				Credentials: &aws.CredentialsConfig{AccessKey: "access-key", SecretKey: "secret-key"},
+			},
+			Bucket:    "bucket",
+			KeyPrefix: "prefix",
+		},
+		&S3ClientConfig{Endpoint: srv.URL, ForcePathStyle: true},
+	)
+	require.NoError(t, err)
+
+	headers := &store.Headers{KeyValue: map[string]string{"chainID": "1"}}
+	err = s3Store.Store(context.Background(), "key.json", bytes.NewReader([]byte("data")), headers)
+	require.NoError(t, err)
+	assert.Contains(t, objects, "/bucket/prefix/1/key.json")
+
+	reader, err := s3Store.Load(context.Background(), "key.json", headers)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(body))
+
+	keys, err := s3Store.List(context.Background(), "key", headers)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key.json"}, keys)
+
+	err = s3Store.Delete(context.Background(), "key.json", headers)
+	require.NoError(t, err)
+	assert.NotContains(t, objects, "/bucket/prefix/1/key.json")
+
+	keys, err = s3Store.List(context.Background(), "key", headers)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestS3StoreEncryptionAndACL(t *testing.T) {
+	// Records the headers of the last uploaded object
+	var (
+		mu      sync.Mutex
+		headers http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPut {
+			headers = r.Header.Clone()
+		}
+	}))
+	defer srv.Close()
+
+	upload := func(clientCfg *S3ClientConfig) http.Header {
+		clientCfg.Endpoint, clientCfg.ForcePathStyle = srv.URL, true
+		s3Store, err := NewS3Store(
+			&s3store.Config{
+				ProviderConfig: &aws.ProviderConfig{
+					Region:      "us-east-1",
+					Credentials: &aws.CredentialsConfig{AccessKey: "access-key", SecretKey: "secret-key"},
+				},
+				Bucket: "bucket",
+			},
+			clientCfg,
+		)
+		require.NoError(t, err)
+		require.NoError(t, s3Store.Store(context.Background(), "key.json", bytes.NewReader([]byte("data")), nil))
+		mu.Lock()
+		defer mu.Unlock()
+		return headers
+	}
+
+	h := upload(&S3ClientConfig{})
+	assert.Empty(t, h.Get("X-Amz-Server-Side-Encryption"))
+	assert.Empty(t, h.Get("X-Amz-Acl"))
+
+	h = upload(&S3ClientConfig{SSE: S3SSEAES256, ACL: "private"})
+	assert.Equal(t, "AES256", h.Get("X-Amz-Server-Side-Encryption"))
+	assert.Empty(t, h.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+	assert.Equal(t, "private", h.Get("X-Amz-Acl"))
+
+	h = upload(&S3ClientConfig{SSE: S3SSEKMS, KMSKeyID: "arn:aws:kms:us-east-1:111122223333:key/key-id"})
+	assert.Equal(t, "aws:kms", h.Get("X-Amz-Server-Side-Encryption"))
+	assert.Equal(t, "arn:aws:kms:us-east-1:111122223333:key/key-id", h.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+
+	_, err := NewS3Store(&s3store.Config{Bucket: "bucket"}, &S3ClientConfig{SSE: S3SSEAES256, KMSKeyID: "key-id"})
+	require.Error(t, err)
+}
+
+func TestParseS3SSEAndACL(t *testing.T) {
+	for s, expected := range map[string]S3SSE{"": S3SSENone, "none": S3SSENone, "aes256": S3SSEAES256, "KMS": S3SSEKMS} {
+		sse, err := ParseS3SSE(s)
+		require.NoError(t, err)
+		assert.Equal(t, expected, sse)
+	}
+	_, err := ParseS3SSE("aws:kms")
+	require.Error(t, err)
+
+	acl, err := ParseS3ACL("bucket-owner-full-control")
+	require.NoError(t, err)
+	assert.Equal(t, "bucket-owner-full-control", string(acl))
+	_, err = ParseS3ACL("public")
+	require.Error(t, err)
+}
+
+func TestS3StoreResumeDownload(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	checksum := md5.Sum(data) //nolint:gosec // S3 ETag
+	etag := `"` + hex.EncodeToString(checksum[:]) + `"`
+
+	// S3-compatible server serving data with range requests, dropping the connection after `drop` bytes of every response
+	// and serving `resumed` instead of data to the range requests, conditioned on `current` ETag
+	handler := func(drop int, resumed []byte, current string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			body := data
+			w.Header().Set("ETag", etag)
+			if rng := r.Header.Get("Range"); rng != "" {
+				if r.Header.Get("If-Match") != current {
+					w.WriteHeader(http.StatusPreconditionFailed)
+					_, _ = w.Write([]byte(`<Error><Code>PreconditionFailed</Code></Error>`))
+					return
+				}
+				start, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+				require.NoError(t, err)
+				body = resumed[start:]
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(data)-1, len(data)))
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				w.WriteHeader(http.StatusPartialContent)
+			} else {
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			}
+			if drop >= len(body) {
+				_, _ = w.Write(body)
+				return
+			}
+			_, _ = w.Write(body[:drop])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+	}
+	serve := func(drop int, resumed []byte, current string) *httptest.Server {
+		return httptest.NewServer(handler(drop, resumed, current))
+	}
+
+	// serveFailing serves as serve(3000, data, etag), failing the first `failures` range requests and recording their times
+	serveFailing := func(failures int) (*httptest.Server, func() []time.Time) {
+		var (
+			mu      sync.Mutex
+			resumed []time.Time
+			serve   = handler(3000, data, etag)
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") != "" {
+				mu.Lock()
+				resumed = append(resumed, time.Now())
+				fail := len(resumed) <= failures
+				mu.Unlock()
+				if fail {
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`<Error><Code>AccessDenied</Code></Error>`))
+					return
+				}
+			}
+			serve(w, r)
+		}))
+		return srv, func() []time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return slices.Clone(resumed)
+		}
+	}
+
+	loadWith := func(ctx context.Context, srv *httptest.Server, clientCfg *S3ClientConfig) ([]byte, error) {
+		clientCfg.Endpoint, clientCfg.ForcePathStyle = srv.URL, true
+		s3Store, err := NewS3Store(
+			&s3store.Config{
+				ProviderConfig: &aws.ProviderConfig{
+					Region:      "us-east-1",
+					Credentials: &aws.CredentialsConfig{AccessKey: "access-key", SecretKey: "secret-key"},
+				},
+				Bucket:    "bucket",
+				KeyPrefix: "prefix",
+			},
+			clientCfg,
+		)
+		require.NoError(t, err)
+		reader, err := s3Store.Load(ctx, "key.json", &store.Headers{KeyValue: map[string]string{"chainID": "1"}})
+		require.NoError(t, err)
+		return io.ReadAll(reader)
+	}
+	load := func(srv *httptest.Server, resumes int) ([]byte, error) {
+		return loadWith(context.Background(), srv, &S3ClientConfig{DownloadResumes: resumes})
+	}
+
+	t.Run("resumed", func(t *testing.T) {
+		srv := serve(3000, data, etag)
+		defer srv.Close()
+		body, err := load(srv, 3)
+		require.NoError(t, err)
+		assert.Equal(t, data, body)
+	})
+
+	t.Run("max resumes", func(t *testing.T) {
+		srv := serve(3000, data, etag)
+		defer srv.Close()
+		_, err := load(srv, 2)
+		assert.ErrorContains(t, err, "failed to download S3 object prefix/1/key.json at byte 9000 after 2 resumes")
+		_, err = load(srv, 0)
+		assert.ErrorContains(t, err, "at byte 3000 after 0 resumes")
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		srv := serve(5000, bytes.Repeat([]byte("x"), len(data)), etag)
+		defer srv.Close()
+		_, err := load(srv, 3)
+		assert.ErrorContains(t, err, "resumed download of S3 object prefix/1/key.json checksum mismatch")
+	})
+
+	t.Run("object changed", func(t *testing.T) {
+		srv := serve(5000, data, `"other"`)
+		defer srv.Close()
+		_, err := load(srv, 3)
+		assert.ErrorContains(t, err, "failed to resume download of S3 object prefix/1/key.json: object changed during download")
+	})
+
+	t.Run("backoff between failed resumes", func(t *testing.T) {
+		srv, resumed := serveFailing(2)
+		defer srv.Close()
+		body, err := loadWith(context.Background(), srv, &S3ClientConfig{
+			DownloadResumes: 5, // 2 failed requests and 3 resumes at bytes 3000, 6000 and 9000
+			ResumeBackoff:   RetryConfig{InitialInterval: 20 * time.Millisecond, MaxInterval: time.Second},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, data, body)
+
+		times := resumed()
+		require.Len(t, times, 5)
+		assert.GreaterOrEqual(t, times[1].Sub(times[0]), 10*time.Millisecond, "first backoff, with jitter")
+		assert.GreaterOrEqual(t, times[2].Sub(times[1]), 20*time.Millisecond, "backoff doubled, with jitter")
+	})
+
+	t.Run("canceled while backing off", func(t *testing.T) {
+		srv, resumed := serveFailing(10)
+		defer srv.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := loadWith(ctx, srv, &S3ClientConfig{
+			DownloadResumes: 5,
+			ResumeBackoff:   RetryConfig{InitialInterval: time.Hour, MaxInterval: time.Hour},
+		})
+		assert.ErrorContains(t, err, "failed to download S3 object prefix/1/key.json at byte 3000 after 1 resumes")
+		assert.Less(t, time.Since(start), 10*time.Second, "the backoff is interrupted by the context")
+		assert.Len(t, resumed(), 1)
+	})
+}
+
+func TestS3StoreFallbackPrefixes(t *testing.T) {
+	// S3-compatible server holding objects under several prefixes, recording the requested paths
+	var (
+		mu        sync.Mutex
+		requested []string
+		objects   = map[string]string{
+			"/bucket/older/1/1.json":  "older",
+			"/bucket/legacy/1/2.json": "legacy",
+			"/bucket/older/1/2.json":  "older",
+		}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = string(body)
+		case http.MethodGet:
+			requested = append(requested, r.URL.Path)
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+				return
+			}
+			_, _ = w.Write([]byte(body))
+		}
+	}))
+	defer srv.Close()
+
+	s3Store, err := NewS3Store(
+		&s3store.Config{
+			ProviderConfig: &aws.ProviderConfig{
+				Region:      "us-east-1",
+				Credentials: &aws.CredentialsConfig{AccessKey: "access-key", SecretKey: "secret-key"},
+			},
+			Bucket:    "bucket",
+			KeyPrefix: "current",
+		},
+		&S3ClientConfig{Endpoint: srv.URL, ForcePathStyle: true, FallbackPrefixes: []string{"legacy", "older"}},
+	)
+	require.NoError(t, err)
+
+	headers := &store.Headers{KeyValue: map[string]string{"chainID": "1"}}
+	load := func(key string) (string, []string, error) {
+		mu.Lock()
+		requested = nil
+		mu.Unlock()
+		reader, err := s3Store.Load(context.Background(), key, headers)
+		if err != nil {
+			return "", requested, err
+		}
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(body), requested, nil
+	}
+
+	// Fallback prefixes are tried in order
+	body, paths, err := load("2.json")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", body)
+	assert.Equal(t, []string{"/bucket/current/1/2.json", "/bucket/legacy/1/2.json"}, paths)
+
+	body, _, err = load("1.json")
+	require.NoError(t, err)
+	assert.Equal(t, "older", body)
+
+	// Writes use the primary prefix, which is read first
+	require.NoError(t, s3Store.Store(context.Background(), "1.json", bytes.NewReader([]byte("current")), headers))
+	body, paths, err = load("1.json")
+	require.NoError(t, err)
+	assert.Equal(t, "current", body)
+	assert.Equal(t, []string{"/bucket/current/1/1.json"}, paths)
+
+	// Missing under every prefix
+	_, paths, err = load("3.json")
+	assert.True(t, IsNotFound(err))
+	assert.Len(t, paths, 3)
+}
@@ -0,0 +1,137 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	store "github.com/kkrt-labs/go-utils/store"
+	filestore "github.com/kkrt-labs/go-utils/store/file"
+	multistore "github.com/kkrt-labs/go-utils/store/multi"
+	s3store "github.com/kkrt-labs/go-utils/store/s3"
+	"github.com/kkrt-labs/zk-pig/src/config"
+	input "github.com/kkrt-labs/zk-pig/src/prover-input"
+	inputstore "github.com/kkrt-labs/zk-pig/src/store"
+)
+
+// MigrateOptions are the options of a prover inputs store migration
+type MigrateOptions struct {
+	Concurrency int  // Number of blocks migrated concurrently
+	Resume      bool // Skip blocks already present in the destination
+	Move        bool // Delete prover inputs from the source once migrated (file sources only)
+}
+
+// ParseStoreURL parses the URL of a prover inputs store
+//
+// Supported URLs are file://<dir> (or a plain directory path) and s3://<bucket>[/<key-prefix>].
+// Query parameters content-type, content-encoding, number-format, chunk-size and file-partitioning override the values of the global configuration,
+// and S3 stores use the AWS region and credentials of the global configuration.
+func ParseStoreURL(rawURL string, gcfg *config.Config) (*inputstore.ProverInputStoreConfig, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store URL %q: %v", rawURL, err)
+	}
+
+	cfg, err := FromGlobalConfig(gcfg)
+	if err != nil {
+		return nil, err
+	}
+	storeCfg := cfg.ProverInputStore
+	storeCfg.StoreConfig = multistore.Config{}
+	storeCfg.DeltaBase = nil
+
+	switch u.Scheme {
+	case "", "file":
+		dir := u.Host + u.Path
+		if dir == "" {
+			return nil, fmt.Errorf("invalid store URL %q: missing directory", rawURL)
+		}
+		storeCfg.StoreConfig.FileConfig = &filestore.Config{DataDir: dir}
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid store URL %q: missing bucket", rawURL)
+		}
+		storeCfg.StoreConfig.S3Config = &s3store.Config{
+			Bucket:         u.Host,
+			KeyPrefix:      strings.TrimPrefix(u.Path, "/"),
+			ProviderConfig: AWSProviderConfig(gcfg),
+		}
+	default:
+		return nil, fmt.Errorf("invalid store URL %q: unsupported scheme %q (expected file or s3)", rawURL, u.Scheme)
+	}
+
+	query := u.Query()
+	if v := query.Get("content-type"); v != "" {
+		if _, err := input.LookupCodec(v); err != nil {
+			return nil, fmt.Errorf("invalid store URL %q: %v", rawURL, err)
+		}
+		storeCfg.Format = v
+	}
+	if query.Has("content-encoding") {
+		if storeCfg.ContentEncoding, err = store.ParseContentEncoding(query.Get("content-encoding")); err != nil {
+			return nil, fmt.Errorf("invalid store URL %q: %v", rawURL, err)
+		}
+	}
+	if v := query.Get("compression-level"); v != "" {
+		if storeCfg.CompressionLevel, err = inputstore.ParseCompressionLevel(v); err != nil {
+			return nil, fmt.Errorf("invalid store URL %q: %v", rawURL, err)
+		}
+	}
+	if v := query.Get("number-format"); v != "" {
+		if storeCfg.NumberFormat, err = input.ParseNumberFormat(v); err != nil {
+			return nil, fmt.Errorf("invalid store URL %q: %v", rawURL, err)
+		}
+	}
+	if v := query.Get("chunk-size"); v != "" {
+		if storeCfg.ChunkSize, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid store URL %q: invalid chunk size: %v", rawURL, err)
+		}
+	}
+	if query.Has("file-partitioning") {
+		if storeCfg.FilePartitioning, err = inputstore.ParseFilePartitioning(query.Get("file-partitioning")); err != nil {
+			return nil, fmt.Errorf("invalid store URL %q: %v", rawURL, err)
+		}
+	}
+
+	return &storeCfg, nil
+}
+
+// MigrateStore copies the prover inputs of the blocks in the inclusive range [fromBlock, toBlock] between two stores
+// (see inputstore.Migrate)
+func MigrateStore(ctx context.Context, chainID uint64, from, to *inputstore.ProverInputStoreConfig, fromBlock, toBlock uint64, opts *MigrateOptions) (*inputstore.MigrateResult, error) {
+	migrateOpts := &inputstore.MigrateOptions{
+		Concurrency: opts.Concurrency,
+		Resume:      opts.Resume,
+	}
+	if opts.Move {
+		if from.StoreConfig.FileConfig == nil || from.StoreConfig.S3Config != nil {
+			return nil, fmt.Errorf("moving prover inputs is only supported from a file store")
+		}
+		migrateOpts.Remove = inputstore.NewFileRemover(from.StoreConfig.FileConfig.DataDir, from.Format, from.ContentEncoding, from.FilePartitioning)
+	}
+
+	fromStore, err := newProverInputStore(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create source store: %v", err)
+	}
+	toStore, err := newProverInputStore(to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create destination store: %v", err)
+	}
+
+	return inputstore.Migrate(ctx, fromStore, toStore, chainID, fromBlock, toBlock, migrateOpts)
+}
+
+func newProverInputStore(cfg *inputstore.ProverInputStoreConfig) (inputstore.ProverInputStore, error) {
+	baseStore, err := inputstore.NewBackendStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return inputstore.NewDeltaStore(
+		inputstore.NewFromStore(inputstore.NewChunkingStore(inputstore.NewCompressStore(inputstore.NewRetryStore(baseStore, &cfg.Retry), cfg.ContentEncoding, cfg.CompressionLevel), cfg.ChunkSize), cfg.Format, cfg.NumberFormat, cfg.JSONEncoder),
+		cfg.DeltaBase,
+	), nil
+}
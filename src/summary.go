@@ -0,0 +1,68 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RunSummary is a machine-readable report of a multi-block generation run.
+type RunSummary struct {
+	From         uint64          `json:"from"`         // First block of the run
+	To           uint64          `json:"to"`           // Last block of the run
+	Attempted    int             `json:"attempted"`    // Number of blocks attempted
+	Succeeded    int             `json:"succeeded"`    // Number of blocks successfully generated
+	Skipped      int             `json:"skipped"`      // Number of blocks skipped because their prover input already existed or their gas used was out of range
+	Failed       []*BlockFailure `json:"failed"`       // Blocks that failed, so they can be re-run
+	BytesWritten uint64          `json:"bytesWritten"` // Total size of the serialized prover inputs written to the store (before content encoding)
+	RPCCalls     uint64          `json:"rpcCalls"`     // Total number of JSON-RPC calls (including retries)
+	Seed         uint64          `json:"seed"`         // Seed of the pseudo-random choices of the run (see --seed)
+	StartTime    time.Time       `json:"startTime"`
+	EndTime      time.Time       `json:"endTime"`
+	Duration     string          `json:"duration"` // Wall-clock duration of the run
+}
+
+// BlockFailure describes a block that failed during a run.
+type BlockFailure struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	BlockHash   string `json:"blockHash,omitempty"` // Set when the block was referenced by a hash that could not be resolved
+	Error       string `json:"error"`
+}
+
+// WriteFile writes the summary as JSON to the given path.
+func (s *RunSummary) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create summary directory: %v", err)
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run summary: %v", err)
+	}
+
+	return os.WriteFile(path, b, 0o600)
+}
+
+// String returns a human-readable summary.
+func (s *RunSummary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Run summary for blocks %d-%d\n", s.From, s.To)
+	fmt.Fprintf(&b, "  Attempted:     %d\n", s.Attempted)
+	fmt.Fprintf(&b, "  Succeeded:     %d\n", s.Succeeded)
+	fmt.Fprintf(&b, "  Skipped:       %d\n", s.Skipped)
+	fmt.Fprintf(&b, "  Failed:        %d\n", len(s.Failed))
+	fmt.Fprintf(&b, "  Bytes written: %d\n", s.BytesWritten)
+	fmt.Fprintf(&b, "  RPC calls:     %d\n", s.RPCCalls)
+	fmt.Fprintf(&b, "  Duration:      %s\n", s.Duration)
+	for _, f := range s.Failed {
+		if f.BlockHash != "" {
+			fmt.Fprintf(&b, "  - block %s: %s\n", f.BlockHash, f.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "  - block %d: %s\n", f.BlockNumber, f.Error)
+	}
+	return b.String()
+}
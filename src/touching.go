@@ -0,0 +1,88 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kkrt-labs/go-utils/log"
+	"go.uber.org/zap"
+)
+
+// touchingLogsChunkSize is the maximum number of blocks queried by a single eth_getLogs call, as most providers bound it
+const touchingLogsChunkSize = 1000
+
+// BlocksTouching returns, in increasing order, the blocks of the inclusive range [from, to] touching address, i.e. with a
+// transaction sent from or to address, or a log emitted by address (which includes internal calls emitting events).
+//
+// Logs are fetched with eth_getLogs by chunks of blocks, and the transactions of every block with eth_getBlockByNumber.
+func (s *Service) BlocksTouching(ctx context.Context, from, to *big.Int, address gethcommon.Address) ([]uint64, error) {
+	if from.Cmp(to) > 0 {
+		return nil, fmt.Errorf("invalid block range: %v > %v", from, to)
+	}
+	if s.ethrpc == nil {
+		return nil, fmt.Errorf("filtering blocks requires a remote RPC or a local chain data directory")
+	}
+
+	touched := make(map[uint64]struct{})
+	for start := from.Uint64(); start <= to.Uint64(); start += touchingLogsChunkSize {
+		end := min(start+touchingLogsChunkSize-1, to.Uint64())
+		logs, err := s.ethrpc.FilterLogs(ctx, geth.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []gethcommon.Address{address},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch logs of blocks %d-%d: %v", start, end, err)
+		}
+		for _, l := range logs {
+			touched[l.BlockNumber] = struct{}{}
+		}
+	}
+
+	signer := gethtypes.LatestSignerForChainID(s.chainID)
+	for n := from.Uint64(); n <= to.Uint64(); n++ {
+		if _, ok := touched[n]; ok {
+			continue
+		}
+		block, err := s.ethrpc.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch block %d: %v", n, err)
+		}
+		if touchesAddress(block, signer, address) {
+			touched[n] = struct{}{}
+		}
+	}
+
+	blocks := make([]uint64, 0, len(touched))
+	for n := range touched {
+		blocks = append(blocks, n)
+	}
+	slices.Sort(blocks)
+
+	log.LoggerFromContext(ctx).Info(
+		"Filtered blocks touching address",
+		zap.String("address", address.Hex()),
+		zap.Int("blocks", len(blocks)),
+		zap.Uint64("range", to.Uint64()-from.Uint64()+1),
+	)
+
+	return blocks, nil
+}
+
+// touchesAddress returns true if a transaction of the block is sent from or to address
+func touchesAddress(block *gethtypes.Block, signer gethtypes.Signer, address gethcommon.Address) bool {
+	for _, tx := range block.Transactions() {
+		if to := tx.To(); to != nil && *to == address {
+			return true
+		}
+		if sender, err := gethtypes.Sender(signer, tx); err == nil && sender == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package src
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocksTouchingChainData(t *testing.T) {
+	var (
+		target  = gethcommon.HexToAddress("0x1000000000000000000000000000000000000001") // Emits a log when called
+		proxy   = gethcommon.HexToAddress("0x1000000000000000000000000000000000000002") // Calls target
+		other   = gethcommon.HexToAddress("0x1000000000000000000000000000000000000003")
+		logCode = []byte{0x60, 0x00, 0x60, 0x00, 0xa0, 0x00} // LOG0(0, 0)
+	)
+	// CALL(gas, target, 0, 0, 0, 0, 0)
+	proxyCode := append([]byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x73}, target.Bytes()...)
+	proxyCode = append(proxyCode, 0x5a, 0xf1, 0x00)
+
+	chain := newTestChain(t, nil, gethtypes.GenesisAlloc{target: {Code: logCode}, proxy: {Code: proxyCode}}, 4, func(i int, b *core.BlockGen) {
+		switch i {
+		case 0:
+			sendTestTx(t, testConfig, b, target, nil) // Block 1: transaction to the address
+		case 1:
+			sendTestTx(t, testConfig, b, other, nil) // Block 2: unrelated
+		case 2:
+			sendTestTx(t, testConfig, b, proxy, nil) // Block 3: internal call emitting a log
+		}
+		// Block 4: empty
+	})
+	s := newTestService(t, newTestConfig(t, ChainConfig{DataDir: chain.dir}))
+
+	blocks, err := s.BlocksTouching(context.Background(), big.NewInt(1), big.NewInt(4), target)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 3}, blocks)
+
+	blocks, err = s.BlocksTouching(context.Background(), big.NewInt(1), big.NewInt(4), testSender)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, blocks)
+}